@@ -21,6 +21,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -28,6 +29,7 @@ import (
 	"go-micro.dev/v6/ai"
 	"go-micro.dev/v6/flow"
 	"go-micro.dev/v6/gateway/a2a"
+	"go-micro.dev/v6/prompt"
 	"go-micro.dev/v6/server"
 	"go-micro.dev/v6/store"
 
@@ -105,6 +107,21 @@ type agentImpl struct {
 	// completed tool results without replaying side effects.
 	currentRun *flow.Run
 
+	// toolMu guards the per-Ask tool state above (steps, spend, calls,
+	// pause, currentRun's steps) and the plan while a turn's tool calls
+	// run concurrently. payMu serializes x402 payments so concurrent calls
+	// can't spend past Budget together.
+	toolMu sync.Mutex
+	payMu  sync.Mutex
+
+	// cache serves repeated model requests when ModelCache is set.
+	// It's read without mu by CacheStats.
+	cache atomic.Pointer[ai.CachedModel]
+
+	// promptVersion is the registry prompt version of the current run, if
+	// the agent takes its prompt from a registry (PromptFrom).
+	promptVersion *prompt.Version
+
 	// delegateCalls collapses concurrent equivalent delegate tool calls so a
 	// provider replay cannot fan out duplicate delegated side effects before the
 	// durable delegate-result cache is written.
@@ -180,7 +197,23 @@ func (a *agentImpl) setupWithToolHandler(handler ai.ToolHandler) {
 		handler = a.toolHandler()
 	}
 	modelOpts = append(modelOpts, ai.WithToolHandler(handler))
+	params := a.opts.ModelParams
+	if params.PromptCache && params.PromptCacheKey == "" {
+		params.PromptCacheKey = a.opts.Name
+	}
+	modelOpts = append(modelOpts, ai.WithParams(params))
 	a.model = ai.New(a.opts.Provider, modelOpts...)
+	if a.model != nil && a.opts.ModelCache != nil {
+		c := a.cache.Load()
+		if c == nil {
+			c = ai.Cached(a.model, a.opts.ModelCache...)
+			a.cache.Store(c)
+		} else {
+			// keep the cache's semantic index and counts across setups
+			c.Model = a.model
+		}
+		a.model = c
+	}
 	if a.model != nil {
 		a.model = a.tracedModel(a.model)
 	}
@@ -197,12 +230,21 @@ func (a *agentImpl) setupWithToolHandler(handler ai.ToolHandler) {
 		a.mem = a.opts.Memory
 	case a.ephemeral:
 		a.mem = NewInMemory(a.opts.HistoryLimit)
+	default:
+		a.mem = a.storeMemory("history")
+	}
+}
+
+// storeMemory returns the store-backed memory the agent's options select,
+// persisted under key in the agent's state store.
+func (a *agentImpl) storeMemory(key string) Memory {
+	switch {
 	case a.opts.MemoryCompaction.MaxMessages > 0:
-		a.mem = NewCompactingMemoryWithOptions(a.stateStore(), "history", a.opts.MemoryCompaction)
+		return NewCompactingMemoryWithOptions(a.stateStore(), key, a.opts.MemoryCompaction)
 	case a.opts.MemoryRetrievalLimit > 0:
-		a.mem = NewRetrievalMemory(a.stateStore(), "history", a.opts.MemoryRetrievalLimit)
+		return NewRetrievalMemory(a.stateStore(), key, a.opts.MemoryRetrievalLimit)
 	default:
-		a.mem = NewMemory(a.stateStore(), "history", a.opts.HistoryLimit)
+		return NewMemory(a.stateStore(), key, a.opts.HistoryLimit)
 	}
 }
 
@@ -221,13 +263,30 @@ func (a *agentImpl) stateStore() store.Store {
 // Ask sends a message and returns the agent's response.
 // This is the programmatic API for direct use.
 func (a *agentImpl) Ask(ctx context.Context, message string) (*Response, error) {
-	return a.ask(ctx, message, a.parentRunID)
+	return a.ask(ctx, message, nil, a.parentRunID)
+}
+
+// AskParts is Ask for a multimodal message: text with images, audio or
+// files. The parts are kept in memory and sent in the provider's native
+// format; a provider that can't take a part fails with an
+// *ai.UnsupportedContentError.
+func (a *agentImpl) AskParts(ctx context.Context, parts []ai.Part) (*Response, error) {
+	return a.ask(ctx, ai.ContentText(parts), parts, a.parentRunID)
 }
 
 // Stream sends a message and returns a streaming model response. Tool-calling
 // agent runs still use Ask; Stream is for chat turns where immediate token
 // delivery is more important than tool orchestration.
 func (a *agentImpl) Stream(ctx context.Context, message string) (ai.Stream, error) {
+	return a.stream(ctx, message, nil)
+}
+
+// StreamParts is Stream for a multimodal message.
+func (a *agentImpl) StreamParts(ctx context.Context, parts []ai.Part) (ai.Stream, error) {
+	return a.stream(ctx, ai.ContentText(parts), parts)
+}
+
+func (a *agentImpl) stream(ctx context.Context, message string, parts []ai.Part) (ai.Stream, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	if err := ctx.Err(); err != nil {
@@ -236,6 +295,7 @@ func (a *agentImpl) Stream(ctx context.Context, message string) (ai.Stream, erro
 	if a.model == nil {
 		a.setup()
 	}
+	defer a.useSession(ctx)()
 	toolList, err := a.discoverTools()
 	if err != nil {
 		return nil, fmt.Errorf("discover tools: %w", err)
@@ -246,10 +306,20 @@ func (a *agentImpl) Stream(ctx context.Context, message string) (ai.Stream, erro
 		ParentID: a.parentRunID,
 		Agent:    a.opts.Name,
 	})
+	// Streamed tokens reach the caller as they're generated, so only the
+	// input is guarded here.
+	if message, parts, err = a.guardInput(ctx, message, parts); err != nil {
+		return nil, err
+	}
+	a.promptVersion = a.resolvePrompt(ctx, runID, nil)
+	defer func() { a.promptVersion = nil }()
 	messages := append([]ai.Message(nil), a.mem.Messages()...)
-	messages = append(messages, ai.Message{Role: "user", Content: message})
+	if len(parts) == 0 {
+		messages = append(messages, ai.Message{Role: "user", Content: message})
+	}
 	stream, err := a.model.Stream(ctx, &ai.Request{
 		Prompt:       message,
+		Parts:        parts,
 		SystemPrompt: a.buildPrompt(),
 		Tools:        toolList,
 		Messages:     messages,
@@ -261,10 +331,20 @@ func (a *agentImpl) Stream(ctx context.Context, message string) (ai.Stream, erro
 		_ = stream.Close()
 		return nil, err
 	}
-	a.mem.Add("user", message)
+	a.addUserMessage(message, parts)
 	return &memoryRecordingStream{stream: stream, memory: a.mem}, nil
 }
 
+// addUserMessage adds the user's turn to memory, with its media when the
+// memory keeps multimodal turns.
+func (a *agentImpl) addUserMessage(message string, parts []ai.Part) {
+	if mm, ok := a.mem.(MultimodalMemory); ok && ai.HasMedia(parts) {
+		mm.AddParts("user", parts)
+		return
+	}
+	a.mem.Add("user", message)
+}
+
 // StreamChat serves the Agent.StreamChat RPC endpoint by forwarding stream-capable
 // remote clients to the agent streaming path. If the model cannot stream, the
 // underlying error is returned so callers can fall back to Agent.Chat.
@@ -273,7 +353,11 @@ func (a *agentImpl) StreamChat(ctx context.Context, stream pb.Agent_StreamChatSt
 	if err != nil {
 		return err
 	}
-	aiStream, err := a.streamAskAI(ctx, req.Message)
+	message, parts := chatContent(req)
+	if req.Session != "" {
+		ctx = WithSession(ctx, req.Session)
+	}
+	aiStream, err := a.streamAskParts(ctx, message, parts)
 	if err != nil {
 		return err
 	}
@@ -295,6 +379,30 @@ func (a *agentImpl) StreamChat(ctx context.Context, stream pb.Agent_StreamChatSt
 	}
 }
 
+// AskParts sends a multimodal message, text with images, audio or files,
+// and returns the agent's response. Providers that can't take a part's
+// modality fail with an *ai.UnsupportedContentError.
+func AskParts(ctx context.Context, ag Agent, parts ...ai.Part) (*Response, error) {
+	asker, ok := ag.(interface {
+		AskParts(context.Context, []ai.Part) (*Response, error)
+	})
+	if !ok {
+		return nil, errors.New("agent: AskParts unsupported by implementation")
+	}
+	return asker.AskParts(ctx, parts)
+}
+
+// StreamParts is Stream for a multimodal message.
+func StreamParts(ctx context.Context, ag Agent, parts ...ai.Part) (ai.Stream, error) {
+	streamer, ok := ag.(interface {
+		StreamParts(context.Context, []ai.Part) (ai.Stream, error)
+	})
+	if !ok {
+		return nil, errors.New("agent: StreamParts unsupported by implementation")
+	}
+	return streamer.StreamParts(ctx, parts)
+}
+
 // Pending returns checkpointed agent runs that have not completed. It mirrors
 // flow.Pending for startup recovery loops that drain durable agent work.
 func Pending(ctx context.Context, ag Agent) ([]flow.Run, error) {
@@ -305,6 +413,21 @@ func Pending(ctx context.Context, ag Agent) ([]flow.Run, error) {
 	return a.pending(ctx)
 }
 
+// CacheStats returns the hit and miss counts of an agent's ModelCache. It
+// reports false for an agent without one, or one that hasn't set up its
+// model yet.
+func CacheStats(ag Agent) (ai.CacheStats, bool) {
+	a, ok := ag.(*agentImpl)
+	if !ok {
+		return ai.CacheStats{}, false
+	}
+	c := a.cache.Load()
+	if c == nil {
+		return ai.CacheStats{}, false
+	}
+	return c.Stats(), true
+}
+
 // ResumePending resumes every checkpointed agent run that has not completed
 // yet, in the same oldest-first order returned by Pending.
 //
@@ -330,26 +453,25 @@ func ResumePending(ctx context.Context, ag Agent) (string, error) {
 	return "", nil
 }
 
-func (a *agentImpl) ask(ctx context.Context, message, parentRunID string) (*Response, error) {
+func (a *agentImpl) ask(ctx context.Context, message string, parts []ai.Part, parentRunID string) (*Response, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
 	if a.model == nil {
 		a.setup()
 	}
+	defer a.useSession(ctx)()
 
-	return a.askLocked(ctx, uuid.New().String(), message, parentRunID, nil, true)
+	return a.askLocked(ctx, uuid.New().String(), message, parts, parentRunID, nil, true)
 }
 
-func (a *agentImpl) askLocked(ctx context.Context, runID, message, parentRunID string, existing *flow.Run, addUserMessage bool) (*Response, error) {
+// askLocked runs one Ask. parts is the multimodal form of message, if any.
+func (a *agentImpl) askLocked(ctx context.Context, runID, message string, parts []ai.Part, parentRunID string, existing *flow.Run, addUserMessage bool) (*Response, error) {
 	toolList, err := a.discoverTools()
 	if err != nil {
 		return nil, fmt.Errorf("discover tools: %w", err)
 	}
 
-	if addUserMessage {
-		a.mem.Add("user", message)
-	}
 	a.steps = 0
 	a.spend = 0
 	a.calls = map[string]int{}
@@ -362,7 +484,20 @@ func (a *agentImpl) askLocked(ctx context.Context, runID, message, parentRunID s
 		ParentID: parentRunID,
 		Agent:    a.opts.Name,
 	})
+	if addUserMessage {
+		// guard new input before it's remembered, traced or checkpointed
+		if message, parts, err = a.guardInput(ctx, message, parts); err != nil {
+			return nil, err
+		}
+		a.addUserMessage(message, parts)
+	}
+	input := message
 	run := a.newCheckpointRun(runID, message, parentRunID, existing)
+	a.promptVersion = a.resolvePrompt(ctx, runID, existing)
+	defer func() { a.promptVersion = nil }()
+	if v := a.promptVersion; v != nil {
+		run.Prompt, run.PromptVersion = v.Name, v.Version
+	}
 	a.currentRun = &run
 	defer func() { a.currentRun = nil }()
 	if err := a.saveRun(ctx, run); err != nil {
@@ -375,6 +510,12 @@ func (a *agentImpl) askLocked(ctx context.Context, runID, message, parentRunID s
 	defer func() { endRun(err) }()
 
 	messages := a.mem.Messages()
+	if n := len(messages); n > 0 && ai.HasMedia(parts) && ai.HasMedia(messages[n-1].Content) {
+		// the turn just remembered goes as the request's Parts
+		messages = messages[:n-1]
+	} else {
+		parts = nil
+	}
 	if recall, ok := a.mem.(MemoryRecall); ok && a.opts.MemoryRecallLimit > 0 {
 		if recalled := recall.Recall(message, a.opts.MemoryRecallLimit); len(recalled) > 0 {
 			messages = append([]ai.Message{{
@@ -393,6 +534,7 @@ func (a *agentImpl) askLocked(ctx context.Context, runID, message, parentRunID s
 	for planCompletionTurn := 0; ; planCompletionTurn++ {
 		resp, err = ai.GenerateWithRetry(ctx, a.model, &ai.Request{
 			Prompt:       message,
+			Parts:        parts,
 			SystemPrompt: a.buildPrompt(),
 			Tools:        toolList,
 			Messages:     messages,
@@ -402,6 +544,9 @@ func (a *agentImpl) askLocked(ctx context.Context, runID, message, parentRunID s
 			Backoff:     a.opts.ModelRetryBackoff,
 			Jitter:      a.opts.ModelRetryJitter,
 		})
+		if err == nil {
+			err = a.guardResponse(ctx, resp)
+		}
 		if err != nil {
 			run.Status = agentRunFailureStatus(err)
 			failureKind := ai.ClassifyError(err)
@@ -469,7 +614,7 @@ func (a *agentImpl) askLocked(ctx context.Context, runID, message, parentRunID s
 				}
 				message = fmt.Sprintf("Continue the same run by calling the required tool(s) for the unfinished plan steps below. Do not repeat completed work, do not provide a final answer yet, and complete at least one unfinished step this turn if a matching tool is available. Unfinished plan steps: %s", strings.Join(unfinished, ", "))
 				a.mem.Add("user", message)
-				messages = a.mem.Messages()
+				messages, parts = a.mem.Messages(), nil
 				continue
 			}
 		}
@@ -479,7 +624,7 @@ func (a *agentImpl) askLocked(ctx context.Context, runID, message, parentRunID s
 			}
 			message = fmt.Sprintf("Your previous response started a %q tool call but did not finish valid tool-call markup or JSON arguments, so no tool was executed. Retry the same step now by emitting one complete valid tool call for %q. Do not describe the action in prose, and do not claim completion until the tool call succeeds.", toolName, toolName)
 			a.mem.Add("user", message)
-			messages = a.mem.Messages()
+			messages, parts = a.mem.Messages(), nil
 			continue
 		}
 		break
@@ -531,7 +676,7 @@ func (a *agentImpl) askLocked(ctx context.Context, runID, message, parentRunID s
 	}
 	run.Status = "done"
 	run.State.Stage = ""
-	if b, marshalErr := json.Marshal(res); marshalErr == nil {
+	if b, marshalErr := json.Marshal(completedRun{Response: res, Input: input}); marshalErr == nil {
 		run.State.Data = b
 	}
 	if a.currentRun != nil {
@@ -552,7 +697,11 @@ func (a *agentImpl) askLocked(ctx context.Context, runID, message, parentRunID s
 // Chat implements the proto AgentHandler interface for RPC.
 // @example {"message": "What tasks are overdue?"}
 func (a *agentImpl) Chat(ctx context.Context, req *pb.ChatRequest, rsp *pb.ChatResponse) error {
-	resp, err := a.ask(ctx, req.Message, req.ParentId)
+	message, parts := chatContent(req)
+	if req.Session != "" {
+		ctx = WithSession(ctx, req.Session)
+	}
+	resp, err := a.ask(ctx, message, parts, req.ParentId)
 	if err != nil {
 		return err
 	}
@@ -572,6 +721,43 @@ func (a *agentImpl) Chat(ctx context.Context, req *pb.ChatRequest, rsp *pb.ChatR
 	return nil
 }
 
+// chatContent returns a Chat request's message text and its parts, if it
+// has any.
+func chatContent(req *pb.ChatRequest) (string, []ai.Part) {
+	if len(req.Parts) == 0 {
+		return req.Message, nil
+	}
+	parts := make([]ai.Part, 0, len(req.Parts))
+	for _, p := range req.Parts {
+		parts = append(parts, ai.Part{
+			Type:     ai.PartType(p.Type),
+			Text:     p.Text,
+			MIMEType: p.MimeType,
+			Data:     p.Data,
+			URL:      p.Url,
+			Name:     p.Name,
+		})
+	}
+	message := req.Message
+	if message == "" {
+		message = ai.ContentText(parts)
+	}
+	return message, parts
+}
+
+// a2aParts returns the parts of the A2A message being served, or nil when
+// it is text only.
+func a2aParts(ctx context.Context, text string) []ai.Part {
+	files := a2a.MessageFiles(ctx)
+	if len(files) == 0 {
+		return nil
+	}
+	if text == "" {
+		return files
+	}
+	return append([]ai.Part{ai.TextPart(text)}, files...)
+}
+
 // Run starts the agent as a service with a Chat RPC endpoint.
 func (a *agentImpl) Run() error {
 	if a.model == nil {
@@ -610,7 +796,7 @@ func (a *agentImpl) Run() error {
 	if a.opts.A2AAddress != "" {
 		card := a2a.Card(a.opts.Name, "http://localhost"+a.opts.A2AAddress, "", a.opts.Services)
 		handler := a2a.NewAgentStreamHandler(card, func(ctx context.Context, text string) (string, error) {
-			resp, err := a.Ask(ctx, text)
+			resp, err := a.ask(ctx, text, a2aParts(ctx, text), a.parentRunID)
 			if err != nil {
 				return "", err
 			}
@@ -680,6 +866,8 @@ func (a *agentImpl) discoverTools() ([]ai.Tool, error) {
 func (a *agentImpl) buildPrompt() string {
 	var base string
 	switch {
+	case a.promptVersion != nil:
+		base = a.promptVersion.Text
 	case a.opts.Prompt != "":
 		base = a.opts.Prompt
 	case len(a.opts.Services) > 0:
@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
 	pb "go-micro.dev/v6/agent/proto"
@@ -93,6 +94,73 @@ func TestChatRequestParentIDPropagatesToResponse(t *testing.T) {
 	}
 }
 
+// Chats with a session share memory with each other and not with the
+// agent's default conversation.
+func TestChatRequestSessionHasOwnMemory(t *testing.T) {
+	var seen []int
+	fakeGen = func(ctx context.Context, opts ai.Options, req *ai.Request) (*ai.Response, error) {
+		seen = append(seen, len(req.Messages))
+		return &ai.Response{Reply: "ok"}, nil
+	}
+	defer func() { fakeGen = nil }()
+
+	a := newTestAgent(Name("sessions"))
+	if _, err := a.Ask(context.Background(), "default"); err != nil {
+		t.Fatal(err)
+	}
+	for _, session := range []string{"one", "one", "two"} {
+		var rsp pb.ChatResponse
+		if err := a.Chat(context.Background(), &pb.ChatRequest{Message: "hello", Session: session}, &rsp); err != nil {
+			t.Fatalf("Chat: %v", err)
+		}
+	}
+	if _, err := a.Ask(context.Background(), "default again"); err != nil {
+		t.Fatal(err)
+	}
+	want := []int{1, 1, 3, 1, 3}
+	if fmt.Sprint(seen) != fmt.Sprint(want) {
+		t.Fatalf("messages per request = %v, want %v", seen, want)
+	}
+}
+
+// A Chat request's parts reach the model as the request's Parts and are
+// remembered, so the next turn sees the image in the history.
+func TestChatRequestPartsReachModel(t *testing.T) {
+	var reqs []*ai.Request
+	fakeGen = func(ctx context.Context, opts ai.Options, req *ai.Request) (*ai.Response, error) {
+		reqs = append(reqs, req)
+		return &ai.Response{Reply: "a cat"}, nil
+	}
+	defer func() { fakeGen = nil }()
+
+	a := newTestAgent(Name("vision"))
+	var rsp pb.ChatResponse
+	err := a.Chat(context.Background(), &pb.ChatRequest{Parts: []*pb.Part{
+		{Type: "text", Text: "what is this?"},
+		{Type: "image", MimeType: "image/png", Data: []byte("png")},
+	}}, &rsp)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	req := reqs[0]
+	if req.Prompt != "what is this?" || len(req.Parts) != 2 || string(req.Parts[1].Data) != "png" {
+		t.Fatalf("request prompt = %q, parts = %+v", req.Prompt, req.Parts)
+	}
+	for _, m := range req.Messages {
+		if ai.HasMedia(m.Content) {
+			t.Fatal("the image turn should be sent once, as Parts, not in Messages too")
+		}
+	}
+
+	if _, err := a.Ask(context.Background(), "and its colour?"); err != nil {
+		t.Fatalf("Ask: %v", err)
+	}
+	history := reqs[1].Messages
+	if len(history) < 1 || !ai.HasMedia(history[0].Content) || reqs[1].Parts != nil {
+		t.Fatalf("second turn messages = %+v, parts = %+v", history, reqs[1].Parts)
+	}
+}
+
 func TestBuildPrompt(t *testing.T) {
 	// Custom prompt
 	a := New(Name("test"), Prompt("custom prompt")).(*agentImpl)
@@ -128,17 +128,26 @@ func (a *agentImpl) toolHandler() ai.ToolHandler {
 
 	// Innermost first: base, then guardrails (approve → loop → step →
 	// plan), then developer wrappers outermost. Wrapping reverses order,
-	// so the result runs plan → step → loop → approve → checkpoint → base.
+	// so the result runs plan → step → loop → approve → checkpoint →
+	// content guard → base: results are screened before they're stored.
+	// A turn's calls run concurrently, but orderWrap admits them to the
+	// guardrails from plan to checkpoint in the order the model made
+	// them, so step, loop and spend limits refuse the same calls however
+	// they're scheduled. admitWrap lets the next call in once a call has
+	// passed them.
 	h := a.baseHandler()
 	h = a.toolTimeoutWrap(h)
 	h = a.x402PayWrap(h)
 	h = a.toolRetryWrap(h)
+	h = a.guardToolWrap(h)
+	h = admitWrap(h)
 	h = a.checkpointToolWrap(h)
 	h = a.approveWrap(h)
 	h = a.spendWrap(h)
 	h = a.loopWrap(h)
 	h = a.stepWrap(h)
 	h = a.planWrap(h)
+	h = orderWrap(h)
 	h = contextWrap(h)
 	h = a.traceTool(h)
 	for i := len(a.opts.wrappers) - 1; i >= 0; i-- {
@@ -162,6 +171,30 @@ func contextWrap(next ai.ToolHandler) ai.ToolHandler {
 	}
 }
 
+type admitKey struct{}
+
+// orderWrap waits for the call's turn with ai.InCallOrder and passes the
+// admit func on to admitWrap. A call refused before it gets there is
+// admitted when it returns.
+func orderWrap(next ai.ToolHandler) ai.ToolHandler {
+	return func(ctx context.Context, call ai.ToolCall) ai.ToolResult {
+		admit := ai.InCallOrder(ctx)
+		defer admit()
+		return next(context.WithValue(ctx, admitKey{}, admit), call)
+	}
+}
+
+// admitWrap admits the call once the guardrails have passed it, so the
+// turn's later calls are checked while it executes.
+func admitWrap(next ai.ToolHandler) ai.ToolHandler {
+	return func(ctx context.Context, call ai.ToolCall) ai.ToolResult {
+		if admit, ok := ctx.Value(admitKey{}).(func()); ok {
+			admit()
+		}
+		return next(ctx, call)
+	}
+}
+
 // toolTimeoutWrap gives each tool execution its own deadline while preserving
 // caller cancellation. Handlers still execute synchronously; tools that honor
 // context (custom tools, delegate RPC/A2A, and go-micro RPC clients) return
@@ -191,13 +224,17 @@ func (a *agentImpl) x402PayWrap(next ai.ToolHandler) ai.ToolHandler {
 		if url == "" {
 			return errResult(call.ID, "x402: payment required but tool result did not include a retryable url input")
 		}
+		// one payment at a time, so each sees what the others spent
+		a.payMu.Lock()
+		defer a.payMu.Unlock()
 		budget := a.opts.Budget
 		if budget > 0 {
-			remaining := budget - a.spend
+			spent := a.spent()
+			remaining := budget - spent
 			if remaining <= 0 {
 				return refused(call.ID, ai.RefusedSpendBudget, fmt.Sprintf(
 					"x402 spend budget exceeded: no budget remaining for %s (spent %d of %d)",
-					call.Name, a.spend, budget))
+					call.Name, spent, budget))
 			}
 			budget = remaining
 		}
@@ -218,7 +255,7 @@ func (a *agentImpl) x402PayWrap(next ai.ToolHandler) ai.ToolHandler {
 		if err != nil {
 			return errResult(call.ID, err.Error())
 		}
-		a.spend += client.Spent()
+		a.addSpend(ctx, client.Spent())
 		var value any
 		if err := json.Unmarshal(body, &value); err != nil {
 			value = string(body)
@@ -357,19 +394,26 @@ func (a *agentImpl) baseHandler() ai.ToolHandler {
 func (a *agentImpl) planWrap(next ai.ToolHandler) ai.ToolHandler {
 	return func(ctx context.Context, call ai.ToolCall) ai.ToolResult {
 		if call.Name == toolPlan {
+			a.toolMu.Lock()
+			defer a.toolMu.Unlock()
 			return a.handlePlan(call)
 		}
 		if containsNestedTextToolCall(call.Input) {
 			return refused(call.ID, ai.RefusedApproval, "malformed tool call: nested text tool-call markup found inside arguments; call the intended tool directly with clean JSON arguments")
 		}
 		if call.Name == toolDelegate {
-			if blocked := a.unfinishedPlanStepsBeforeDelegation(); len(blocked) > 0 {
+			a.toolMu.Lock()
+			blocked := a.unfinishedPlanStepsBeforeDelegation()
+			a.toolMu.Unlock()
+			if len(blocked) > 0 {
 				return refused(call.ID, ai.RefusedApproval, "complete these plan steps before delegating: "+strings.Join(blocked, ", "))
 			}
 		}
 		res := next(ctx, call)
 		if res.Refused == "" && toolErrorMessage(res) == "" {
+			a.toolMu.Lock()
 			a.completeNextPlanStep()
+			a.toolMu.Unlock()
 		}
 		return res
 	}
@@ -379,8 +423,11 @@ func (a *agentImpl) planWrap(next ai.ToolHandler) ai.ToolHandler {
 func (a *agentImpl) stepWrap(next ai.ToolHandler) ai.ToolHandler {
 	return func(ctx context.Context, call ai.ToolCall) ai.ToolResult {
 		if a.opts.MaxSteps > 0 {
+			a.toolMu.Lock()
 			a.steps++
-			if a.steps > a.opts.MaxSteps {
+			steps := a.steps
+			a.toolMu.Unlock()
+			if steps > a.opts.MaxSteps {
 				return refused(call.ID, ai.RefusedMaxSteps, fmt.Sprintf(
 					"step limit reached (%d). Do not call any more tools; stop and summarize what you have so far.",
 					a.opts.MaxSteps))
@@ -395,13 +442,16 @@ func (a *agentImpl) stepWrap(next ai.ToolHandler) ai.ToolHandler {
 func (a *agentImpl) loopWrap(next ai.ToolHandler) ai.ToolHandler {
 	return func(ctx context.Context, call ai.ToolCall) ai.ToolResult {
 		if a.opts.LoopLimit > 0 {
+			args, _ := json.Marshal(call.Input)
+			fp := call.Name + ":" + string(args)
+			a.toolMu.Lock()
 			if a.calls == nil {
 				a.calls = map[string]int{}
 			}
-			args, _ := json.Marshal(call.Input)
-			fp := call.Name + ":" + string(args)
 			a.calls[fp]++
-			if a.calls[fp] > a.opts.LoopLimit {
+			n := a.calls[fp]
+			a.toolMu.Unlock()
+			if n > a.opts.LoopLimit {
 				return refused(call.ID, ai.RefusedLoop, fmt.Sprintf(
 					"loop detected: you have already called %q with the same arguments %d times and the result will not change. Stop repeating it — try a different approach, or finish with what you have.",
 					call.Name, a.opts.LoopLimit))
@@ -430,7 +480,7 @@ func (a *agentImpl) approveWrap(next ai.ToolHandler) ai.ToolHandler {
 				if reason != "" {
 					msg += ": " + reason
 				}
-				a.pause = &approvalPause{Tool: call.Name, Message: msg}
+				a.setPause(&approvalPause{Tool: call.Name, Message: msg})
 				return refused(call.ID, ai.RefusedApproval, msg)
 			}
 		}
@@ -445,25 +495,82 @@ func (a *agentImpl) spendWrap(next ai.ToolHandler) ai.ToolHandler {
 		if amount <= 0 || a.opts.MaxSpend <= 0 {
 			return next(ctx, call)
 		}
-		if a.spend+amount > a.opts.MaxSpend {
+		spent, ok := a.reserveSpend(ctx, amount, a.opts.MaxSpend)
+		if !ok {
 			return refused(call.ID, ai.RefusedSpendBudget, fmt.Sprintf(
 				"x402 spend budget exceeded: paying %d for %s would exceed per-run budget (spent %d of %d)",
-				amount, call.Name, a.spend, a.opts.MaxSpend))
+				amount, call.Name, spent, a.opts.MaxSpend))
 		}
-		a.spend += amount
 		if info, ok := ai.RunInfoFrom(ctx); ok {
-			info.Spent = a.spend
+			info.Spent = spent
 			info.ToolSpend = amount
 			ctx = ai.WithRunInfo(ctx, info)
 		}
 		res := next(ctx, call)
 		if res.Refused != "" || toolErrorMessage(res) != "" {
-			a.spend -= amount
+			a.addSpend(ctx, -amount)
 		}
 		return res
 	}
 }
 
+type callSpendKey struct{}
+
+// withCallSpend returns ctx carrying a counter of what the call spends,
+// which addSpend updates alongside the run's total.
+func withCallSpend(ctx context.Context) (context.Context, *int64) {
+	n := new(int64)
+	return context.WithValue(ctx, callSpendKey{}, n), n
+}
+
+// addSpend adds n to the run's spend and the call's, and returns the
+// run's new total.
+func (a *agentImpl) addSpend(ctx context.Context, n int64) int64 {
+	a.toolMu.Lock()
+	defer a.toolMu.Unlock()
+	return a.addSpendLocked(ctx, n)
+}
+
+// reserveSpend adds n as addSpend does unless that takes the run past
+// max. It returns the run's total, and whether n was added.
+func (a *agentImpl) reserveSpend(ctx context.Context, n, max int64) (int64, bool) {
+	a.toolMu.Lock()
+	defer a.toolMu.Unlock()
+	if a.spend+n > max {
+		return a.spend, false
+	}
+	return a.addSpendLocked(ctx, n), true
+}
+
+func (a *agentImpl) addSpendLocked(ctx context.Context, n int64) int64 {
+	a.spend += n
+	if c, ok := ctx.Value(callSpendKey{}).(*int64); ok {
+		*c += n
+	}
+	return a.spend
+}
+
+// spent returns the run's spend so far.
+func (a *agentImpl) spent() int64 {
+	a.toolMu.Lock()
+	defer a.toolMu.Unlock()
+	return a.spend
+}
+
+// callSpent returns the run's spend so far and what the call counted by
+// c (from withCallSpend) spent.
+func (a *agentImpl) callSpent(c *int64) (total, call int64) {
+	a.toolMu.Lock()
+	defer a.toolMu.Unlock()
+	return a.spend, *c
+}
+
+func (a *agentImpl) setPause(p *approvalPause) {
+	a.toolMu.Lock()
+	a.pause = p
+	a.toolMu.Unlock()
+}
+
 // handlePlan persists the supplied plan to the agent's memory and
 // echoes it back so the model can see the stored state.
 func (a *agentImpl) handlePlan(call ai.ToolCall) ai.ToolResult {
@@ -674,7 +781,7 @@ func (a *agentImpl) handleHumanInput(call ai.ToolCall) ai.ToolResult {
 	if prompt == "" {
 		prompt = "human input required"
 	}
-	a.pause = &approvalPause{Tool: toolHumanInput, Message: prompt}
+	a.setPause(&approvalPause{Tool: toolHumanInput, Message: prompt})
 	return refused(call.ID, ai.RefusedApproval, "input-required: "+prompt)
 }
 
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"go-micro.dev/v6/ai"
+	"go-micro.dev/v6/cache"
+	"go-micro.dev/v6/store"
+)
+
+func TestModelCacheSharedBetweenAgents(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	fakeGen = func(ctx context.Context, opts ai.Options, req *ai.Request) (*ai.Response, error) {
+		calls++
+		return &ai.Response{Reply: "42", Usage: ai.Usage{TotalTokens: 9}}, nil
+	}
+	defer func() { fakeGen = nil }()
+	shared := cache.NewCache()
+	newAgent := func(st store.Store) *agentImpl {
+		return newTestAgent(Name("grader"), WithStore(st), WithMemory(NewInMemory(10)), ModelCache(ai.CacheStore(shared)))
+	}
+
+	if _, err := newAgent(store.NewMemoryStore()).Ask(ctx, "what is 6 x 7?"); err != nil {
+		t.Fatal(err)
+	}
+	st := store.NewMemoryStore()
+	b := newAgent(st)
+	resp, err := b.Ask(ctx, "what is 6 x 7?")
+	if err != nil || resp.Reply != "42" {
+		t.Fatalf("Ask = %+v, %v", resp, err)
+	}
+	if calls != 1 {
+		t.Fatalf("provider calls = %d, want the second agent served from the cache", calls)
+	}
+	if s, ok := CacheStats(b); !ok || s.Hits != 1 || s.Misses != 0 {
+		t.Fatalf("stats = %+v, %v", s, ok)
+	}
+	events, _ := LoadRunEvents(st, "grader", resp.RunID)
+	models := 0
+	for _, e := range events {
+		if e.Kind != "model" {
+			continue
+		}
+		models++
+		if !e.Cached || e.Tokens.TotalTokens != 0 {
+			t.Fatalf("model event = %+v, want a cached call without tokens", e)
+		}
+	}
+	if models != 1 {
+		t.Fatalf("model events = %d, want 1", models)
+	}
+	if _, ok := CacheStats(newTestAgent(Name("plain"))); ok {
+		t.Fatal("an agent without ModelCache reported cache stats")
+	}
+}
+
+func TestModelParamsPromptCache(t *testing.T) {
+	var got ai.Params
+	fakeGen = func(ctx context.Context, opts ai.Options, req *ai.Request) (*ai.Response, error) {
+		got = opts.Params
+		return &ai.Response{Reply: "ok", Usage: ai.Usage{InputTokens: 2000, TotalTokens: 2010, OutputTokens: 10, CacheReadTokens: 1900}}, nil
+	}
+	defer func() { fakeGen = nil }()
+	temp := 0.1
+	st := store.NewMemoryStore()
+	a := newTestAgent(Name("support"), WithStore(st), WithMemory(NewInMemory(10)),
+		ModelParams(ai.Params{Temperature: &temp, PromptCache: true}))
+
+	resp, err := a.Ask(context.Background(), "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Temperature == nil || *got.Temperature != 0.1 || !got.PromptCache || got.PromptCacheKey != "support" {
+		t.Fatalf("model params = %+v", got)
+	}
+	events, _ := LoadRunEvents(st, "support", resp.RunID)
+	models := 0
+	for _, e := range events {
+		if e.Kind != "model" {
+			continue
+		}
+		models++
+		if e.Tokens.CacheReadTokens != 1900 {
+			t.Fatalf("model event tokens = %+v", e.Tokens)
+		}
+	}
+	if models == 0 {
+		t.Fatal("no model events recorded")
+	}
+}
@@ -44,6 +44,13 @@ func (a *agentImpl) newCheckpointRun(runID, message, parentRunID string, existin
 	return run
 }
 
+// completedRun is the checkpoint data of a done run: the response Resume
+// returns, and the message that started the run, kept for Replay.
+type completedRun struct {
+	*Response
+	Input string `json:",omitempty"`
+}
+
 func (a *agentImpl) saveRun(ctx context.Context, run flow.Run) error {
 	if a.opts.Checkpoint == nil {
 		return nil
@@ -110,7 +117,7 @@ func (a *agentImpl) resume(ctx context.Context, runID string) (*Response, error)
 	if a.model == nil {
 		a.setup()
 	}
-	return a.askLocked(ctx, run.ID, message, parentID, &run, false)
+	return a.askLocked(ctx, run.ID, message, nil, parentID, &run, false)
 }
 
 // ResumeInput resumes a checkpointed agent run that paused via the built-in
@@ -155,7 +162,7 @@ func (a *agentImpl) resumeInput(ctx context.Context, runID, input string) (*Resp
 	if a.model == nil {
 		a.setup()
 	}
-	return a.askLocked(ctx, run.ID, message, run.ParentID, &run, true)
+	return a.askLocked(ctx, run.ID, message, nil, run.ParentID, &run, true)
 }
 
 func (a *agentImpl) pending(ctx context.Context) ([]flow.Run, error) {
@@ -249,31 +256,44 @@ func (a *agentImpl) checkpointToolWrap(next ai.ToolHandler) ai.ToolHandler {
 			return next(ctx, call)
 		}
 		name := toolCheckpointName(call)
+		a.toolMu.Lock()
 		if rec, ok := findStep(run.Steps, name); ok && rec.Status == "done" {
+			a.toolMu.Unlock()
 			return ai.ToolResult{ID: call.ID, Value: rec.Result, Content: rec.Result}
 		}
+		upsertStep(&run.Steps, flow.StepRecord{Name: name, Status: "in_progress"})
+		_ = a.saveRun(ctx, copyRun(run))
+		a.toolMu.Unlock()
 
-		idx := upsertStep(&run.Steps, flow.StepRecord{Name: name, Status: "in_progress"})
-		_ = a.saveRun(ctx, *run)
 		res := next(ctx, call)
-		if idx < 0 || idx >= len(run.Steps) || run.Steps[idx].Name != name {
-			idx = upsertStep(&run.Steps, flow.StepRecord{Name: name, Status: "in_progress"})
-		}
+
+		// other calls of the turn may have added steps meanwhile
+		a.toolMu.Lock()
+		defer a.toolMu.Unlock()
+		idx := upsertStep(&run.Steps, flow.StepRecord{Name: name, Status: "in_progress"})
 		run.Steps[idx].Attempts++
 		if res.Refused != "" {
 			run.Steps[idx].Status = "failed"
 			run.Steps[idx].Error = res.Content
-			_ = a.saveRun(ctx, *run)
+			_ = a.saveRun(ctx, copyRun(run))
 			return res
 		}
 		run.Steps[idx].Status = "done"
 		run.Steps[idx].Result = res.Content
 		run.Steps[idx].Error = ""
-		_ = a.saveRun(ctx, *run)
+		_ = a.saveRun(ctx, copyRun(run))
 		return res
 	}
 }
 
+// copyRun copies run with its own Steps, so a store that keeps the value
+// it's given doesn't share the slice the turn's other calls update.
+func copyRun(run *flow.Run) flow.Run {
+	cp := *run
+	cp.Steps = append([]flow.StepRecord(nil), run.Steps...)
+	return cp
+}
+
 func toolCheckpointName(call ai.ToolCall) string {
 	b, _ := json.Marshal(call.Input)
 	return "tool:" + call.Name + ":" + string(b)
@@ -316,7 +336,15 @@ func checkpointToolCalls(steps []flow.StepRecord) []ai.ToolCall {
 }
 
 func checkpointToolCall(step flow.StepRecord) (ai.ToolCall, bool) {
-	if step.Status != "done" || !strings.HasPrefix(step.Name, "tool:") {
+	if step.Status != "done" {
+		return ai.ToolCall{}, false
+	}
+	return parseToolStep(step)
+}
+
+// parseToolStep decodes a tool step written by checkpointToolWrap.
+func parseToolStep(step flow.StepRecord) (ai.ToolCall, bool) {
+	if !strings.HasPrefix(step.Name, "tool:") {
 		return ai.ToolCall{}, false
 	}
 	parts := strings.SplitN(strings.TrimPrefix(step.Name, "tool:"), ":", 2)
@@ -329,7 +357,7 @@ func checkpointToolCall(step flow.StepRecord) (ai.ToolCall, bool) {
 			return ai.ToolCall{}, false
 		}
 	}
-	return ai.ToolCall{Name: parts[0], Input: input, Result: step.Result}, true
+	return ai.ToolCall{Name: parts[0], Input: input, Result: step.Result, Error: step.Error}, true
 }
 
 func mergeCheckpointToolCalls(checkpointed, current []ai.ToolCall) []ai.ToolCall {
@@ -3,13 +3,17 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"go-micro.dev/v6/ai"
+	"go-micro.dev/v6/flow"
 	"go-micro.dev/v6/registry"
 	"go-micro.dev/v6/store"
 	"go-micro.dev/v6/wrapper/x402"
@@ -315,3 +319,76 @@ func TestAgentPayerRequiredWithoutPayerReturnsClearError(t *testing.T) {
 		t.Fatalf("content = %q, want no payer error", res.Content)
 	}
 }
+
+// A turn's tool calls run concurrently, but the guardrails see them in
+// the order the model made them: the same calls are refused however the
+// calls are scheduled.
+func TestConcurrentToolCallsKeepGuardrailOrder(t *testing.T) {
+	var running, peak atomic.Int32
+	cp := flow.StoreCheckpoint(store.NewMemoryStore(), "concurrent")
+	a := newTestAgent(Name("concurrent"), MaxSteps(4), ToolSpend("work", 10), MaxSpend(30), WithCheckpoint(cp),
+		WithTool("work", "Does work.", map[string]any{"n": map[string]any{"type": "number"}},
+			func(_ context.Context, input map[string]any) (string, error) {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+				}
+				// later calls finish first
+				i, _ := input["n"].(float64)
+				time.Sleep(time.Duration(6-i) * 5 * time.Millisecond)
+				return fmt.Sprintf("done-%v", i), nil
+			}))
+
+	fakeGen = func(ctx context.Context, opts ai.Options, req *ai.Request) (*ai.Response, error) {
+		turn := func(_ context.Context, _ *ai.Request, rounds []ai.ToolRound) (*ai.Response, error) {
+			if len(rounds) > 0 {
+				return &ai.Response{Reply: "finished"}, nil
+			}
+			resp := &ai.Response{}
+			for i := 0; i < 6; i++ {
+				resp.ToolCalls = append(resp.ToolCalls, ai.ToolCall{ID: fmt.Sprint(i), Name: "work", Input: map[string]any{"n": float64(i)}})
+			}
+			return resp, nil
+		}
+		return ai.ToolLoop(ctx, turnFunc(turn), req, opts)
+	}
+	defer func() { fakeGen = nil }()
+
+	resp, err := a.Ask(context.Background(), "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak.Load() < 2 {
+		t.Fatalf("peak running = %d, want the calls to run concurrently", peak.Load())
+	}
+	for i, call := range resp.ToolCalls {
+		switch {
+		case i < 3 && call.Result != fmt.Sprintf("done-%d", i):
+			t.Errorf("call %d = %q, want it done", i, call.Result)
+		case i == 3 && call.Error != ai.RefusedSpendBudget:
+			t.Errorf("call 3 = %q, want refused by the spend budget", call.Result)
+		case i > 3 && call.Error != ai.RefusedMaxSteps:
+			t.Errorf("call %d = %q, want refused by the step limit", i, call.Result)
+		}
+	}
+
+	run, ok, err := cp.Load(context.Background(), resp.RunID)
+	if err != nil || !ok {
+		t.Fatalf("Load = %v, %v", ok, err)
+	}
+	done := 0
+	for _, step := range run.Steps {
+		if strings.HasPrefix(step.Name, "tool:work:") && step.Status == "done" {
+			done++
+		}
+	}
+	if done != 3 {
+		t.Fatalf("checkpointed %d done tool steps, want 3: %+v", done, run.Steps)
+	}
+}
+
+type turnFunc func(context.Context, *ai.Request, []ai.ToolRound) (*ai.Response, error)
+
+func (f turnFunc) Turn(ctx context.Context, req *ai.Request, rounds []ai.ToolRound) (*ai.Response, error) {
+	return f(ctx, req, rounds)
+}
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -24,6 +25,42 @@ type Memory interface {
 	Clear()
 }
 
+type sessionKey struct{}
+
+// WithSession returns a context whose asks and streams run in the named
+// conversation rather than the agent's default one. Calls with the same
+// session share memory with each other, not with the default conversation.
+// Session memory is the store-backed default, kept in the agent's store
+// even when the agent was given its own memory with WithMemory; an
+// ephemeral sub-agent already has an isolated context and ignores it.
+func WithSession(ctx context.Context, session string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, session)
+}
+
+func sessionFrom(ctx context.Context) string {
+	s, _ := ctx.Value(sessionKey{}).(string)
+	return s
+}
+
+// useSession points the agent's memory at ctx's session for one call and
+// returns the func that points it back. The caller holds a.mu.
+func (a *agentImpl) useSession(ctx context.Context) func() {
+	session := sessionFrom(ctx)
+	if session == "" || a.ephemeral {
+		return func() {}
+	}
+	mem := a.mem
+	a.mem = a.storeMemory("history/" + session)
+	return func() { a.mem = mem }
+}
+
+// MultimodalMemory is implemented by memory backends that keep multimodal
+// turns. An agent asked with images, audio or files stores the turn with
+// AddParts; with other memory it stores only the turn's text.
+type MultimodalMemory interface {
+	AddParts(role string, parts []ai.Part)
+}
+
 // MemorySummaryFunc turns older conversation messages into a compact
 // replacement message for active context. It is called while the default
 // memory is locked, so implementations should be deterministic and avoid
@@ -142,6 +179,16 @@ type storeMemory struct {
 }
 
 func (m *storeMemory) Add(role, content string) {
+	m.add(role, content)
+}
+
+// AddParts appends a multimodal message. Its media is persisted with it,
+// so keep large files out of long-lived conversations.
+func (m *storeMemory) AddParts(role string, parts []ai.Part) {
+	m.add(role, parts)
+}
+
+func (m *storeMemory) add(role string, content any) {
 	m.mu.Lock()
 	if m.retrieveAll {
 		m.archive = append(m.archive, ai.Message{Role: role, Content: content})
@@ -233,8 +280,9 @@ func (m *storeMemory) load() {
 		state.Messages = msgs
 	}
 	m.mu.Lock()
-	m.archive = state.Archive
+	m.archive = restoreParts(state.Archive)
 	m.summary = state.Summary
+	state.Messages = restoreParts(state.Messages)
 	if m.retrieveAll && len(m.archive) == 0 {
 		m.archive = append(m.archive, state.Messages...)
 	}
@@ -247,6 +295,16 @@ func (m *storeMemory) load() {
 	m.mu.Unlock()
 }
 
+// restoreParts turns multimodal content decoded from JSON back into parts.
+func restoreParts(msgs []ai.Message) []ai.Message {
+	for i, msg := range msgs {
+		if _, ok := msg.Content.(string); !ok && msg.Content != nil {
+			msgs[i].Content = ai.Parts(msg.Content)
+		}
+	}
+	return msgs
+}
+
 func (m *storeMemory) save() {
 	if m.store == nil || m.key == "" {
 		return
@@ -306,7 +364,7 @@ func currentMemorySummary(msgs []ai.Message) string {
 		if msg.Role != "system" {
 			continue
 		}
-		text := fmt.Sprint(msg.Content)
+		text := ai.ContentText(msg.Content)
 		if strings.HasPrefix(text, "Conversation memory summary:") {
 			return text
 		}
@@ -327,7 +385,7 @@ func summarizeMessages(msgs []ai.Message) string {
 		if i > 0 {
 			b.WriteString(" | ")
 		}
-		fmt.Fprintf(&b, "%s: %s", msg.Role, compactText(fmt.Sprint(msg.Content), 120))
+		fmt.Fprintf(&b, "%s: %s", msg.Role, compactText(ai.ContentText(msg.Content), 120))
 	}
 	return b.String()
 }
@@ -341,7 +399,7 @@ func compactText(s string, max int) string {
 }
 
 func recallScore(msg ai.Message, terms []string) int {
-	text := strings.ToLower(fmt.Sprint(msg.Content))
+	text := strings.ToLower(ai.ContentText(msg.Content))
 	score := 0
 	for _, term := range terms {
 		if strings.Contains(text, term) {
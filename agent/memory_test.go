@@ -25,6 +25,24 @@ func TestStoreMemoryPersists(t *testing.T) {
 	}
 }
 
+func TestStoreMemoryPersistsParts(t *testing.T) {
+	st := store.NewMemoryStore()
+	m := NewMemory(st, "agent/x/history", 10)
+	m.(MultimodalMemory).AddParts("user", []ai.Part{ai.TextPart("read this"), ai.FilePart("a.pdf", "application/pdf", []byte("%PDF"))})
+
+	reloaded := NewMemory(st, "agent/x/history", 10).Messages()
+	if len(reloaded) != 1 {
+		t.Fatalf("restored %d messages, want 1", len(reloaded))
+	}
+	parts, ok := reloaded[0].Content.([]ai.Part)
+	if !ok || len(parts) != 2 || parts[1].Name != "a.pdf" || string(parts[1].Data) != "%PDF" {
+		t.Fatalf("restored content = %#v", reloaded[0].Content)
+	}
+	if got := ai.ContentText(reloaded[0].Content); got != "read this" {
+		t.Errorf("text = %q", got)
+	}
+}
+
 func TestInMemoryNotPersisted(t *testing.T) {
 	m := NewInMemory(10)
 	m.Add("user", "x")
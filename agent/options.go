@@ -8,6 +8,7 @@ import (
 	"go-micro.dev/v6/broker"
 	"go-micro.dev/v6/client"
 	"go-micro.dev/v6/flow"
+	"go-micro.dev/v6/prompt"
 	"go-micro.dev/v6/registry"
 	"go-micro.dev/v6/store"
 	"go-micro.dev/v6/wrapper/x402"
@@ -50,6 +51,11 @@ type Options struct {
 	Store        store.Store
 	HistoryLimit int
 
+	// PromptRegistry and PromptName, when set, take the system prompt from
+	// a versioned registry prompt instead of Prompt; see PromptFrom.
+	PromptRegistry *prompt.Registry
+	PromptName     string
+
 	// ModelTimeout bounds each provider Generate call (0 disables).
 	ModelTimeout time.Duration
 	// ModelMaxAttempts bounds provider Generate attempts including the first
@@ -63,6 +69,12 @@ type Options struct {
 	// ModelRetryJitter adds up to this random delay to each provider retry
 	// backoff. Default 0 preserves deterministic timing unless explicitly set.
 	ModelRetryJitter time.Duration
+	// ModelCache, when set, serves repeated model requests from a response
+	// cache configured by these options; see ai.Cached. Nil disables it.
+	ModelCache []ai.CacheOption
+	// ModelParams are the generation parameters sent with each model
+	// request, such as temperature and prompt caching; see ai.Params.
+	ModelParams ai.Params
 	// ToolTimeout bounds each tool execution (0 disables). The timeout is
 	// applied before custom tools, delegate, and service RPC calls so context
 	// deadlines propagate consistently through the agent loop.
@@ -102,6 +114,9 @@ type Options struct {
 	LoopLimit int
 	// Approve gates each action before it runs. Nil = allow all.
 	Approve ApproveFunc
+	// Guardrails check the content flowing through the agent: user
+	// messages, tool results and the model's replies. Nil = none.
+	Guardrails []Guardrail
 	// MaxSpend bounds paid x402 tool spend per Ask in the asset's smallest
 	// unit (0 = disabled). ToolSpend lists known paid tools and their prices.
 	MaxSpend  int64
@@ -168,7 +183,22 @@ func Prompt(p string) Option {
 	return func(o *Options) { o.Prompt = p }
 }
 
-// Provider sets the LLM provider.
+// PromptFrom takes the system prompt from the registry prompt name. Each
+// run resolves a version through the prompt's labels and rollout, keyed
+// by the key on the Ask context (prompt.WithKey), such as a session or
+// user id, or by the run id without one. The version is recorded on the
+// run's events and checkpoint so runs can be compared by prompt version.
+// Resumed runs keep the version they started with. Prompt is the fallback if the registry can't be read.
+func PromptFrom(reg *prompt.Registry, name string) Option {
+	return func(o *Options) {
+		o.PromptRegistry = reg
+		o.PromptName = name
+	}
+}
+
+// Provider sets the LLM provider. "router" spreads the agent's model
+// calls over the routes configured under ai.router, falling back between
+// them; see ai.Router.
 func Provider(p string) Option {
 	return func(o *Options) { o.Provider = p }
 }
@@ -236,6 +266,14 @@ func ApproveTool(fn ApproveFunc) Option {
 	return func(o *Options) { o.Approve = fn }
 }
 
+// Guard adds content guardrails, run in order over user messages, tool
+// results and the model's replies. Use RedactPII and RedactTerms to keep
+// personal data out of memory, traces and checkpoints, ScreenInjection to
+// withhold suspicious tool results, and LLMGuard for a model's judgement.
+func Guard(g ...Guardrail) Option {
+	return func(o *Options) { o.Guardrails = append(o.Guardrails, g...) }
+}
+
 // MaxSpend bounds paid x402 tool spend per Ask, in the asset's smallest unit
 // (0 = disabled). A paid tool that would exceed the cap is refused before the
 // tool handler runs or any payment can be made.
@@ -301,6 +339,23 @@ func ModelRetryJitter(d time.Duration) Option {
 	return func(o *Options) { o.ModelRetryJitter = d }
 }
 
+// ModelCache serves repeated model requests from a response cache, such
+// as an eval or grader sending the same prompts again. A turn that ran a
+// tool with side effects is never cached: name the read-only tools with
+// ai.CacheSafeTools to cache their turns. Share a cache between agents
+// with ai.CacheStore and read the hit counts with CacheStats.
+func ModelCache(opts ...ai.CacheOption) Option {
+	return func(o *Options) { o.ModelCache = append([]ai.CacheOption{}, opts...) }
+}
+
+// ModelParams sets the generation parameters of the agent's model
+// requests. With PromptCache set, the agent's long system prompt and tool
+// list are cached by the provider between turns; the cache key defaults
+// to the agent name.
+func ModelParams(p ai.Params) Option {
+	return func(o *Options) { o.ModelParams = p }
+}
+
 // ToolRetry sets the tool retry budget and backoff for transient failures.
 // Attempts include the first call. Retries are opt-in because tools may have
 // side effects; keep handlers idempotent before enabling this.
@@ -382,6 +437,8 @@ func WithCheckpoint(c flow.Checkpoint) Option {
 // retries, or custom policy. Wrappers run outside the built-in guardrails
 // (MaxSteps, LoopLimit, ApproveTool), so they observe every call and its
 // result, including refusals. Multiple wrappers compose outermost-first.
+// The calls of one model turn run concurrently, so a wrapper that keeps
+// state must guard it, or use ai.InCallOrder to see calls in order.
 //
 //	micro.NewAgent("worker", micro.AgentWrapTool(
 //	    func(next ai.ToolHandler) ai.ToolHandler {
@@ -34,6 +34,8 @@ const (
 	AttrInputTokens      = "agent.tokens.input"
 	AttrOutputTokens     = "agent.tokens.output"
 	AttrTotalTokens      = "agent.tokens.total"
+	AttrCacheReadTokens  = "agent.tokens.cache_read"
+	AttrCacheWriteTokens = "agent.tokens.cache_write"
 	AttrAttempt          = "agent.model.attempt"
 	AttrMaxAttempts      = "agent.model.max_attempts"
 	AttrToolAttempt      = "agent.tool.attempt"
@@ -53,6 +55,8 @@ const (
 	AttrRunEventKind     = "agent.event.kind"
 	AttrSpend            = "agent.spend"
 	AttrToolSpend        = "agent.tool.spend"
+	AttrPrompt           = "agent.prompt"
+	AttrCacheHit         = "agent.model.cache_hit"
 )
 
 type RunEvent struct {
@@ -77,6 +81,17 @@ type RunEvent struct {
 	InputChars  int       `json:"input_chars,omitempty"`
 	Spent       int64     `json:"spent,omitempty"`
 	ToolSpend   int64     `json:"tool_spend,omitempty"`
+	// Guard, Stage and Reason describe a "guard" event: the guardrail
+	// that redacted or blocked content, at which stage, and why.
+	Guard  string `json:"guard,omitempty"`
+	Stage  string `json:"stage,omitempty"`
+	Reason string `json:"reason,omitempty"`
+	// Prompt and PromptVersion name the registry prompt version a "run"
+	// event used, for agents configured with PromptFrom.
+	Prompt        string `json:"prompt,omitempty"`
+	PromptVersion int    `json:"prompt_version,omitempty"`
+	// Cached marks a "model" event served from the agent's ModelCache.
+	Cached bool `json:"cached,omitempty"`
 }
 
 type Usage = ai.Usage
@@ -116,6 +131,8 @@ type RunSummary struct {
 	LastError     string    `json:"last_error,omitempty"`
 	LastErrorKind string    `json:"last_error_kind,omitempty"`
 	Spent         int64     `json:"spent,omitempty"`
+	Prompt        string    `json:"prompt,omitempty"`
+	PromptVersion int       `json:"prompt_version,omitempty"`
 }
 
 func (a *agentImpl) tracer() trace.Tracer {
@@ -129,6 +146,9 @@ func (a *agentImpl) startRun(ctx context.Context, message string) (context.Conte
 	if a.opts.TraceInputs {
 		runEvent.Name = message
 	}
+	if v := a.promptVersion; v != nil {
+		runEvent.Prompt, runEvent.PromptVersion = v.Name, v.Version
+	}
 
 	if a.opts.TraceProvider == nil {
 		a.recordRunEvent(runEvent)
@@ -184,7 +204,7 @@ func (m *tracedModel) Generate(ctx context.Context, req *ai.Request, opts ...ai.
 		if resp != nil {
 			usage = resp.Usage
 		}
-		e := RunEvent{Time: time.Now(), RunID: info.RunID, ParentID: info.ParentID, Agent: info.Agent, Kind: "model", Provider: provider, Model: model, Attempt: info.Attempt, MaxAttempts: info.MaxAttempts, LatencyMS: dur, Tokens: usage}
+		e := RunEvent{Time: time.Now(), RunID: info.RunID, ParentID: info.ParentID, Agent: info.Agent, Kind: "model", Provider: provider, Model: model, Attempt: info.Attempt, MaxAttempts: info.MaxAttempts, LatencyMS: dur, Tokens: usage, Cached: resp != nil && resp.Cached}
 		if err != nil {
 			e.Error = err.Error()
 			e.ErrorKind = string(ai.ClassifyError(err))
@@ -214,6 +234,9 @@ func (m *tracedModel) Generate(ctx context.Context, req *ai.Request, opts ...ai.
 	if resp != nil {
 		usage = resp.Usage
 		attrs = appendUsage(attrs, usage)
+		if resp.Cached {
+			attrs = append(attrs, attribute.Bool(AttrCacheHit, true))
+		}
 	}
 	span.SetAttributes(attrs...)
 	if err != nil {
@@ -223,7 +246,7 @@ func (m *tracedModel) Generate(ctx context.Context, req *ai.Request, opts ...ai.
 	} else {
 		span.SetStatus(codes.Ok, "")
 	}
-	e := RunEvent{Time: time.Now(), RunID: info.RunID, ParentID: info.ParentID, Agent: info.Agent, Kind: "model", Provider: provider, Model: model, Attempt: info.Attempt, MaxAttempts: info.MaxAttempts, LatencyMS: dur, Tokens: usage}
+	e := RunEvent{Time: time.Now(), RunID: info.RunID, ParentID: info.ParentID, Agent: info.Agent, Kind: "model", Provider: provider, Model: model, Attempt: info.Attempt, MaxAttempts: info.MaxAttempts, LatencyMS: dur, Tokens: usage, Cached: resp != nil && resp.Cached}
 	if err != nil {
 		e.Error = err.Error()
 		e.ErrorKind = string(ai.ClassifyError(err))
@@ -347,6 +370,12 @@ func mergeUsage(current, next ai.Usage) ai.Usage {
 	if next.TotalTokens > current.TotalTokens {
 		current.TotalTokens = next.TotalTokens
 	}
+	if next.CacheReadTokens > current.CacheReadTokens {
+		current.CacheReadTokens = next.CacheReadTokens
+	}
+	if next.CacheWriteTokens > current.CacheWriteTokens {
+		current.CacheWriteTokens = next.CacheWriteTokens
+	}
 	return current
 }
 
@@ -360,6 +389,12 @@ func appendUsage(attrs []attribute.KeyValue, u ai.Usage) []attribute.KeyValue {
 	if u.TotalTokens > 0 {
 		attrs = append(attrs, attribute.Int(AttrTotalTokens, u.TotalTokens))
 	}
+	if u.CacheReadTokens > 0 {
+		attrs = append(attrs, attribute.Int(AttrCacheReadTokens, u.CacheReadTokens))
+	}
+	if u.CacheWriteTokens > 0 {
+		attrs = append(attrs, attribute.Int(AttrCacheWriteTokens, u.CacheWriteTokens))
+	}
 	return attrs
 }
 
@@ -367,7 +402,7 @@ func (a *agentImpl) traceTool(next ai.ToolHandler) ai.ToolHandler {
 	return func(ctx context.Context, call ai.ToolCall) ai.ToolResult {
 		info, _ := ai.RunInfoFrom(ctx)
 		start := time.Now()
-		spentBefore := a.spend
+		ctx, callSpend := withCallSpend(ctx)
 
 		if a.opts.TraceProvider == nil {
 			res := next(ctx, call)
@@ -377,7 +412,8 @@ func (a *agentImpl) traceTool(next ai.ToolHandler) ai.ToolHandler {
 			if toolAttempts <= 0 {
 				toolAttempts = 1
 			}
-			a.recordRunEvent(RunEvent{Time: time.Now(), RunID: info.RunID, ParentID: info.ParentID, Agent: info.Agent, Kind: "tool", Name: call.Name, Attempt: toolAttempts, MaxAttempts: a.opts.ToolMaxAttempts, LatencyMS: dur, Refused: res.Refused, Error: resErr, ErrorKind: classifyToolError(resErr), Spent: a.spend, ToolSpend: a.spend - spentBefore})
+			spent, toolSpend := a.callSpent(callSpend)
+			a.recordRunEvent(RunEvent{Time: time.Now(), RunID: info.RunID, ParentID: info.ParentID, Agent: info.Agent, Kind: "tool", Name: call.Name, Attempt: toolAttempts, MaxAttempts: a.opts.ToolMaxAttempts, LatencyMS: dur, Refused: res.Refused, Error: resErr, ErrorKind: classifyToolError(resErr), Spent: spent, ToolSpend: toolSpend})
 			return res
 		}
 
@@ -391,7 +427,7 @@ func (a *agentImpl) traceTool(next ai.ToolHandler) ai.ToolHandler {
 		ctx, span := a.tracer().Start(ctx, spanNameToolCall, trace.WithAttributes(spanAttrs...))
 		res := next(ctx, call)
 		dur := time.Since(start).Milliseconds()
-		toolSpend := a.spend - spentBefore
+		spent, toolSpend := a.callSpent(callSpend)
 		attrs := []attribute.KeyValue{attribute.Int64(AttrLatencyMS, dur)}
 		toolAttempts := res.Attempts
 		if toolAttempts <= 0 {
@@ -402,7 +438,7 @@ func (a *agentImpl) traceTool(next ai.ToolHandler) ai.ToolHandler {
 			attrs = append(attrs, attribute.Int(AttrToolMaxAttempts, a.opts.ToolMaxAttempts))
 		}
 		if toolSpend > 0 {
-			attrs = append(attrs, attribute.Int64(AttrSpend, a.spend), attribute.Int64(AttrToolSpend, toolSpend))
+			attrs = append(attrs, attribute.Int64(AttrSpend, spent), attribute.Int64(AttrToolSpend, toolSpend))
 		}
 		if res.Refused != "" {
 			attrs = append(attrs, attribute.Bool(AttrGuardrailBlock, true), attribute.String(AttrRefusal, res.Refused))
@@ -419,7 +455,7 @@ func (a *agentImpl) traceTool(next ai.ToolHandler) ai.ToolHandler {
 		} else {
 			span.SetStatus(codes.Ok, "")
 		}
-		a.recordSpanEvent(span, RunEvent{Time: time.Now(), RunID: info.RunID, ParentID: info.ParentID, Agent: info.Agent, Kind: "tool", Name: call.Name, Attempt: toolAttempts, MaxAttempts: a.opts.ToolMaxAttempts, LatencyMS: dur, Refused: res.Refused, Error: resErr, ErrorKind: classifyToolError(resErr), Spent: a.spend, ToolSpend: toolSpend})
+		a.recordSpanEvent(span, RunEvent{Time: time.Now(), RunID: info.RunID, ParentID: info.ParentID, Agent: info.Agent, Kind: "tool", Name: call.Name, Attempt: toolAttempts, MaxAttempts: a.opts.ToolMaxAttempts, LatencyMS: dur, Refused: res.Refused, Error: resErr, ErrorKind: classifyToolError(resErr), Spent: spent, ToolSpend: toolSpend})
 		span.End()
 		return res
 	}
@@ -512,6 +548,9 @@ func runEventAttributes(e RunEvent) []attribute.KeyValue {
 	if e.ToolSpend > 0 {
 		attrs = append(attrs, attribute.Int64(AttrToolSpend, e.ToolSpend))
 	}
+	if e.Prompt != "" {
+		attrs = append(attrs, attribute.String(AttrPrompt, fmt.Sprintf("%s@%d", e.Prompt, e.PromptVersion)))
+	}
 	attrs = appendUsage(attrs, e.Tokens)
 	if e.Refused != "" {
 		attrs = append(attrs, attribute.Bool(AttrGuardrailBlock, true), attribute.String(AttrRefusal, e.Refused))
@@ -641,6 +680,9 @@ func ListRunSummariesWithOptions(s store.Store, agentName string, opts RunListOp
 			if e.Spent > summary.Spent {
 				summary.Spent = e.Spent
 			}
+			if e.Prompt != "" {
+				summary.Prompt, summary.PromptVersion = e.Prompt, e.PromptVersion
+			}
 		}
 		if opts.Status != "" && summary.Status != opts.Status {
 			continue
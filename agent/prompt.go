@@ -0,0 +1,33 @@
+package agent
+
+import (
+	"context"
+
+	"go-micro.dev/v6/flow"
+	"go-micro.dev/v6/prompt"
+)
+
+// resolvePrompt picks the registry prompt version for a run: the one a
+// resumed run started with, or the version the prompt's rollout assigns
+// to the key on ctx (prompt.WithKey), or to runID without one. It returns
+// nil, and the agent falls back to Options.Prompt, when there's no
+// registry prompt or it can't be read.
+func (a *agentImpl) resolvePrompt(ctx context.Context, runID string, existing *flow.Run) *prompt.Version {
+	reg, name := a.opts.PromptRegistry, a.opts.PromptName
+	if reg == nil || name == "" {
+		return nil
+	}
+	var (
+		v   *prompt.Version
+		err error
+	)
+	if existing != nil && existing.Prompt == name && existing.PromptVersion > 0 {
+		v, err = reg.Get(name, existing.PromptVersion)
+	} else {
+		v, err = reg.Resolve(name, prompt.KeyFrom(ctx, runID))
+	}
+	if err != nil {
+		return nil
+	}
+	return v
+}
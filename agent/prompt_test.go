@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"go-micro.dev/v6/ai"
+	"go-micro.dev/v6/flow"
+	"go-micro.dev/v6/prompt"
+	"go-micro.dev/v6/store"
+)
+
+func TestPromptFromRecordsVersion(t *testing.T) {
+	ctx := context.Background()
+	reg := prompt.NewRegistry(store.NewMemoryStore())
+	reg.Push("support", "You are support v1.", "")
+	reg.Push("support", "You are support v2.", "")
+	reg.SetLabel("support", "prod", 1)
+
+	var system string
+	fakeGen = func(ctx context.Context, opts ai.Options, req *ai.Request) (*ai.Response, error) {
+		system = req.SystemPrompt
+		return &ai.Response{Reply: "ok"}, nil
+	}
+	defer func() { fakeGen = nil }()
+	st := store.NewMemoryStore()
+	cp := flow.StoreCheckpoint(store.NewMemoryStore(), "support")
+	a := newTestAgent(Name("support"), Prompt("fallback"), PromptFrom(reg, "support"),
+		WithStore(st), WithCheckpoint(cp), WithMemory(NewInMemory(10)))
+
+	resp, err := a.Ask(ctx, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if system != "You are support v1." {
+		t.Fatalf("system prompt = %q", system)
+	}
+	run, _, _ := cp.Load(ctx, resp.RunID)
+	if run.Prompt != "support" || run.PromptVersion != 1 {
+		t.Fatalf("checkpoint prompt = %s@%d", run.Prompt, run.PromptVersion)
+	}
+	runs, err := ListRunSummariesWithOptions(st, "support", RunListOptions{})
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("summaries = %v, %v", runs, err)
+	}
+	if runs[0].Prompt != "support" || runs[0].PromptVersion != 1 {
+		t.Fatalf("summary prompt = %s@%d", runs[0].Prompt, runs[0].PromptVersion)
+	}
+
+	// a resumed run keeps the version it started with
+	reg.SetLabel("support", "prod", 2)
+	run.Status, run.Steps[0].Status = "failed", "failed"
+	if err := cp.Save(ctx, run); err != nil {
+		t.Fatal(err)
+	}
+	system = ""
+	if _, err := Resume(ctx, a, resp.RunID); err != nil {
+		t.Fatal(err)
+	}
+	if system != "You are support v1." {
+		t.Fatalf("resumed system prompt = %q", system)
+	}
+	if _, err := a.Ask(ctx, "again"); err != nil {
+		t.Fatal(err)
+	}
+	if system != "You are support v2." {
+		t.Fatalf("new run system prompt = %q", system)
+	}
+
+	// without the registry prompt the agent falls back to Prompt
+	b := newTestAgent(Name("support"), Prompt("fallback"), PromptFrom(reg, "missing"), WithMemory(NewInMemory(10)))
+	if _, err := b.Ask(ctx, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if system != "fallback" {
+		t.Fatalf("fallback system prompt = %q", system)
+	}
+}
+
+func TestPromptFromBucketsOnKey(t *testing.T) {
+	reg := prompt.NewRegistry(store.NewMemoryStore())
+	reg.Push("support", "You are support v1.", "")
+	reg.Push("support", "You are support v2.", "")
+	reg.SetLabel("support", "prod", 1)
+	reg.SetLabel("support", "canary", 2)
+	reg.SetRollout("support", "canary", 50)
+
+	var system string
+	fakeGen = func(ctx context.Context, opts ai.Options, req *ai.Request) (*ai.Response, error) {
+		system = req.SystemPrompt
+		return &ai.Response{Reply: "ok"}, nil
+	}
+	defer func() { fakeGen = nil }()
+	a := newTestAgent(Name("support"), PromptFrom(reg, "support"), WithMemory(NewInMemory(10)))
+
+	// every run of the same user gets the user's version
+	ctx := prompt.WithKey(context.Background(), "user-42")
+	want, _ := reg.Resolve("support", "user-42")
+	for i := 0; i < 10; i++ {
+		if _, err := a.Ask(ctx, "hello"); err != nil {
+			t.Fatal(err)
+		}
+		if system != want.Text {
+			t.Fatalf("run %d system prompt = %q, want %q", i, system, want.Text)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"go-micro.dev/v6/ai"
+	"go-micro.dev/v6/flow"
+	"go-micro.dev/v6/prompt"
+)
+
+// Divergence kinds reported by Replay.
+const (
+	// DivergenceTool: the replay called a different tool than the
+	// recorded run did at this point.
+	DivergenceTool = "tool"
+	// DivergenceArguments: the replay called the same tool with
+	// different arguments.
+	DivergenceArguments = "arguments"
+	// DivergenceExtra: the replay made more tool calls than the recording.
+	DivergenceExtra = "extra"
+	// DivergenceMissing: the replay stopped before making a recorded call.
+	DivergenceMissing = "missing"
+)
+
+// ReplayOptions change what a replayed run is executed with. Zero values
+// keep the agent's own setting.
+type ReplayOptions struct {
+	Provider string
+	Model    string
+	APIKey   string
+	BaseURL  string
+	// Prompt replaces the agent's system prompt. Without it, a run that
+	// used a registry prompt (PromptFrom) replays with the same version.
+	Prompt string
+	// Prompts is the registry to find that version in; the agent's
+	// PromptFrom registry by default.
+	Prompts *prompt.Registry
+	// Input replaces the message that started the run. It is required
+	// for runs checkpointed before their input was kept.
+	Input string
+}
+
+// Divergence is a point where a replay's tool calls differ from the
+// recorded run's. Index is the position in the call sequence.
+type Divergence struct {
+	Index    int          `json:"index"`
+	Kind     string       `json:"kind"`
+	Recorded *ai.ToolCall `json:"recorded,omitempty"`
+	Replayed *ai.ToolCall `json:"replayed,omitempty"`
+}
+
+func (d Divergence) String() string {
+	switch d.Kind {
+	case DivergenceTool:
+		return fmt.Sprintf("call %d: %s instead of %s", d.Index+1, d.Replayed.Name, d.Recorded.Name)
+	case DivergenceArguments:
+		return fmt.Sprintf("call %d: %s with %s instead of %s", d.Index+1, d.Replayed.Name, inputJSON(d.Replayed.Input), inputJSON(d.Recorded.Input))
+	case DivergenceExtra:
+		return fmt.Sprintf("call %d: unrecorded %s %s", d.Index+1, d.Replayed.Name, inputJSON(d.Replayed.Input))
+	default:
+		return fmt.Sprintf("call %d: %s %s was not made", d.Index+1, d.Recorded.Name, inputJSON(d.Recorded.Input))
+	}
+}
+
+// ReplayResult is the outcome of a replayed run next to the recording.
+type ReplayResult struct {
+	RunID string `json:"run_id"`
+	Agent string `json:"agent"`
+	Input string `json:"input"`
+	// Prompt is the registry prompt version replayed with, as name@version.
+	Prompt string `json:"prompt,omitempty"`
+
+	// Reply is the replay's reply; RecordedReply the original run's,
+	// when it completed.
+	Reply         string `json:"reply"`
+	RecordedReply string `json:"recorded_reply,omitempty"`
+
+	// Recorded are the run's checkpointed tool calls in the order they
+	// were first made; Replayed are the replay's, with the results it
+	// was served.
+	Recorded []ai.ToolCall `json:"recorded"`
+	Replayed []ai.ToolCall `json:"replayed"`
+
+	Divergences []Divergence `json:"divergences,omitempty"`
+	Tokens      Usage        `json:"tokens"`
+}
+
+// Diverged reports whether the replay made different tool calls than the
+// recorded run.
+func (r *ReplayResult) Diverged() bool {
+	return len(r.Divergences) > 0
+}
+
+// Replay re-executes a checkpointed run, optionally with a different
+// provider, model or prompt, to test a change against recorded history.
+// Tool calls are served from the run's recorded results: nothing reaches a
+// live service, and a call the recording can't answer gets an error result.
+// The replay runs as a single turn without the conversation history the
+// run originally had, and doesn't touch the agent's memory or checkpoints.
+func Replay(ctx context.Context, ag Agent, runID string, opts ReplayOptions) (*ReplayResult, error) {
+	a, ok := ag.(*agentImpl)
+	if !ok {
+		return nil, fmt.Errorf("agent replay: unsupported agent implementation %T", ag)
+	}
+	return a.replay(ctx, runID, opts)
+}
+
+func (a *agentImpl) replay(ctx context.Context, runID string, opts ReplayOptions) (*ReplayResult, error) {
+	if a.opts.Checkpoint == nil {
+		return nil, fmt.Errorf("agent %s has no checkpoint configured", a.opts.Name)
+	}
+	run, ok, err := a.opts.Checkpoint.Load(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("agent run %s not found", runID)
+	}
+	res := &ReplayResult{RunID: runID, Agent: a.opts.Name, Recorded: recordedToolCalls(run.Steps), Input: opts.Input}
+	if run.Status == "done" {
+		var done completedRun
+		if err := json.Unmarshal(run.State.Data, &done); err != nil {
+			return nil, fmt.Errorf("agent run %s response decode: %w", runID, err)
+		}
+		res.RecordedReply = done.Reply
+		if res.Input == "" {
+			res.Input = done.Input
+		}
+	} else if res.Input == "" && run.State.Stage != agentInputStep {
+		res.Input = string(run.State.Data)
+	}
+	if res.Input == "" {
+		return nil, fmt.Errorf("agent run %s has no recorded input; set ReplayOptions.Input", runID)
+	}
+
+	a.mu.Lock()
+	if a.model == nil {
+		a.setup()
+	}
+	toolList, err := a.discoverTools()
+	if opts.Prompts == nil {
+		opts.Prompts = a.opts.PromptRegistry
+	}
+	system := opts.Prompt
+	if system == "" {
+		if reg := opts.Prompts; reg != nil && run.Prompt != "" {
+			if v, err := reg.Get(run.Prompt, run.PromptVersion); err == nil {
+				a.promptVersion = v
+				res.Prompt = v.Ref()
+			}
+		}
+		system = a.buildPrompt()
+		a.promptVersion = nil
+	}
+	a.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("discover tools: %w", err)
+	}
+	toolList = withRecordedTools(toolList, res.Recorded)
+
+	provider, model, apiKey, baseURL := a.opts.Provider, a.opts.Model, a.opts.APIKey, a.opts.BaseURL
+	if opts.Provider != "" {
+		provider = opts.Provider
+	}
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	if opts.APIKey != "" {
+		apiKey = opts.APIKey
+	}
+	if opts.BaseURL != "" {
+		baseURL = opts.BaseURL
+	}
+	var mu sync.Mutex
+	results := newRecordedResults(res.Recorded)
+	handler := func(_ context.Context, call ai.ToolCall) ai.ToolResult {
+		mu.Lock()
+		content, found := results.next(call)
+		if !found {
+			content = fmt.Sprintf(`{"error": "replay: run %s has no recorded result for %s with these arguments"}`, runID, call.Name)
+		}
+		call.Result = content
+		res.Replayed = append(res.Replayed, call)
+		mu.Unlock()
+		return ai.ToolResult{ID: call.ID, Value: content, Content: content}
+	}
+	modelOpts := []ai.Option{ai.WithAPIKey(apiKey), ai.WithToolHandler(handler), ai.WithToolConcurrency(1)}
+	if model != "" {
+		modelOpts = append(modelOpts, ai.WithModel(model))
+	}
+	if baseURL != "" {
+		modelOpts = append(modelOpts, ai.WithBaseURL(baseURL))
+	}
+	m := ai.New(provider, modelOpts...)
+	if m == nil {
+		return nil, fmt.Errorf("agent replay: unknown provider %q", provider)
+	}
+
+	resp, err := m.Generate(ctx, &ai.Request{Prompt: res.Input, SystemPrompt: system, Tools: toolList})
+	if err != nil {
+		return nil, fmt.Errorf("agent run %s replay: %w", runID, err)
+	}
+	res.Reply = resp.Reply
+	if resp.Answer != "" {
+		if res.Reply != "" {
+			res.Reply += "\n\n"
+		}
+		res.Reply += resp.Answer
+	}
+	res.Tokens = resp.Usage
+	res.Divergences = divergences(res.Recorded, res.Replayed)
+	return res, nil
+}
+
+// recordedToolCalls returns the tool calls checkpointed in steps, including
+// failed ones, whose Error holds what the model was told.
+func recordedToolCalls(steps []flow.StepRecord) []ai.ToolCall {
+	var calls []ai.ToolCall
+	for _, step := range steps {
+		call, ok := parseToolStep(step)
+		if !ok || (step.Status != "done" && step.Status != "failed") {
+			continue
+		}
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// recordedResults answers replayed calls from a recording. Calls with the
+// same tool and arguments get the recorded results in the order they were
+// recorded, so a run that polled a tool until it changed replays the same
+// way; calls past the end of the recording get the last result again.
+type recordedResults map[string][]ai.ToolCall
+
+func newRecordedResults(recorded []ai.ToolCall) recordedResults {
+	r := make(recordedResults)
+	for _, rec := range recorded {
+		key := replayKey(rec.Name, rec.Input)
+		r[key] = append(r[key], rec)
+	}
+	return r
+}
+
+// next returns the result of the next recorded call with call's tool and
+// arguments.
+func (r recordedResults) next(call ai.ToolCall) (string, bool) {
+	key := replayKey(call.Name, call.Input)
+	queue := r[key]
+	if len(queue) == 0 {
+		return "", false
+	}
+	rec := queue[0]
+	if len(queue) > 1 {
+		r[key] = queue[1:]
+	}
+	if rec.Error != "" {
+		return rec.Error, true
+	}
+	return rec.Result, true
+}
+
+// withRecordedTools adds a definition for recorded tools the agent can no
+// longer discover, such as services that aren't running where the replay
+// is, so the model can still call them.
+func withRecordedTools(tools []ai.Tool, recorded []ai.ToolCall) []ai.Tool {
+	known := make(map[string]bool, len(tools))
+	for _, t := range tools {
+		known[t.Name] = true
+	}
+	for _, call := range recorded {
+		if known[call.Name] {
+			continue
+		}
+		known[call.Name] = true
+		props := map[string]any{}
+		for k, v := range call.Input {
+			props[k] = map[string]any{"type": jsonType(v)}
+		}
+		tools = append(tools, ai.Tool{
+			Name:        call.Name,
+			Description: "Recorded tool " + call.Name,
+			Properties:  props,
+		})
+	}
+	return tools
+}
+
+func jsonType(v any) string {
+	switch v.(type) {
+	case bool:
+		return "boolean"
+	case float64, int, int64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return "string"
+	}
+}
+
+// divergences compares the replayed call sequence with the recorded one,
+// position by position.
+func divergences(recorded, replayed []ai.ToolCall) []Divergence {
+	var out []Divergence
+	for i := 0; i < len(recorded) || i < len(replayed); i++ {
+		d := Divergence{Index: i}
+		switch {
+		case i >= len(recorded):
+			d.Kind = DivergenceExtra
+			d.Replayed = &replayed[i]
+		case i >= len(replayed):
+			d.Kind = DivergenceMissing
+			d.Recorded = &recorded[i]
+		case recorded[i].Name != replayed[i].Name:
+			d.Kind = DivergenceTool
+			d.Recorded, d.Replayed = &recorded[i], &replayed[i]
+		case replayKey("", recorded[i].Input) != replayKey("", replayed[i].Input):
+			d.Kind = DivergenceArguments
+			d.Recorded, d.Replayed = &recorded[i], &replayed[i]
+		default:
+			continue
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+func inputJSON(input map[string]any) string {
+	b, _ := json.Marshal(input)
+	return string(b)
+}
+
+// replayKey identifies a call by tool and arguments, treating no arguments
+// and empty arguments alike.
+func replayKey(name string, input map[string]any) string {
+	if input == nil {
+		input = map[string]any{}
+	}
+	return toolCallKey(name, input)
+}
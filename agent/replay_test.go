@@ -0,0 +1,169 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go-micro.dev/v6/ai"
+	"go-micro.dev/v6/flow"
+	"go-micro.dev/v6/store"
+)
+
+func TestReplayServesRecordedToolsAndFlagsDivergence(t *testing.T) {
+	ctx := context.Background()
+	cp := flow.StoreCheckpoint(store.NewMemoryStore(), "replayed-agent")
+	fakeGen = func(ctx context.Context, opts ai.Options, req *ai.Request) (*ai.Response, error) {
+		for _, call := range []ai.ToolCall{
+			{ID: "1", Name: "orders_Get", Input: map[string]any{"id": "42"}},
+			{ID: "2", Name: "payments_Refund", Input: map[string]any{"order": "42", "amount": 10.0}},
+		} {
+			call.Result = opts.ToolHandler(ctx, call).Content
+		}
+		return &ai.Response{Reply: "refunded"}, nil
+	}
+	defer func() { fakeGen = nil }()
+
+	live := 0
+	tool := func(name, result string) Option {
+		return WithTool(name, name, nil, func(context.Context, map[string]any) (string, error) {
+			live++
+			return result, nil
+		})
+	}
+	a := newTestAgent(Name("replayed-agent"), Model("old"), WithCheckpoint(cp),
+		tool("orders_Get", `{"status":"delivered"}`), tool("payments_Refund", `{"ok":true}`), tool("orders_Cancel", `{"ok":true}`))
+	resp, err := a.Ask(ctx, "refund order 42")
+	if err != nil {
+		t.Fatalf("Ask: %v", err)
+	}
+	if live != 2 {
+		t.Fatalf("live tool calls = %d, want 2", live)
+	}
+
+	var seen ai.Options
+	var system string
+	fakeGen = func(ctx context.Context, opts ai.Options, req *ai.Request) (*ai.Response, error) {
+		seen, system = opts, req.SystemPrompt
+		if req.Prompt != "refund order 42" {
+			t.Fatalf("replayed input = %q", req.Prompt)
+		}
+		got := opts.ToolHandler(ctx, ai.ToolCall{ID: "1", Name: "orders_Get", Input: map[string]any{"id": "42"}})
+		if got.Content != `{"status":"delivered"}` {
+			t.Fatalf("recorded result = %q", got.Content)
+		}
+		opts.ToolHandler(ctx, ai.ToolCall{ID: "2", Name: "payments_Refund", Input: map[string]any{"order": "42", "amount": 5.0}})
+		opts.ToolHandler(ctx, ai.ToolCall{ID: "3", Name: "orders_Cancel", Input: map[string]any{"id": "42"}})
+		return &ai.Response{Reply: "refunded half", Usage: ai.Usage{TotalTokens: 9}}, nil
+	}
+	res, err := Replay(ctx, a, resp.RunID, ReplayOptions{Model: "new", Prompt: "Refund at most half."})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if live != 2 {
+		t.Fatalf("replay reached live tools: %d calls", live)
+	}
+	if seen.Model != "new" || system != "Refund at most half." {
+		t.Fatalf("replay model = %q, prompt = %q", seen.Model, system)
+	}
+	if res.Reply != "refunded half" || res.RecordedReply != "refunded" || res.Tokens.TotalTokens != 9 {
+		t.Fatalf("result = %+v", res)
+	}
+	if !strings.Contains(res.Replayed[2].Result, "no recorded result") {
+		t.Fatalf("unrecorded call result = %q", res.Replayed[2].Result)
+	}
+	if !res.Diverged() || len(res.Divergences) != 2 {
+		t.Fatalf("divergences = %+v", res.Divergences)
+	}
+	if d := res.Divergences[0]; d.Kind != DivergenceArguments || d.Index != 1 || d.Recorded.Input["amount"] != 10.0 {
+		t.Fatalf("first divergence = %+v", d)
+	}
+	if d := res.Divergences[1]; d.Kind != DivergenceExtra || d.Replayed.Name != "orders_Cancel" {
+		t.Fatalf("second divergence = %+v", d)
+	}
+
+	// runs with the same calls don't diverge; a resumed done run still
+	// returns its response
+	fakeGen = func(ctx context.Context, opts ai.Options, req *ai.Request) (*ai.Response, error) {
+		opts.ToolHandler(ctx, ai.ToolCall{Name: "orders_Get", Input: map[string]any{"id": "42"}})
+		opts.ToolHandler(ctx, ai.ToolCall{Name: "payments_Refund", Input: map[string]any{"amount": 10.0, "order": "42"}})
+		return &ai.Response{Reply: "refunded"}, nil
+	}
+	if res, err := Replay(ctx, a, resp.RunID, ReplayOptions{}); err != nil || res.Diverged() {
+		t.Fatalf("same replay = %+v, %v", res, err)
+	}
+	if resumed, err := Resume(ctx, a, resp.RunID); err != nil || resumed.Reply != "refunded" {
+		t.Fatalf("Resume = %+v, %v", resumed, err)
+	}
+}
+
+func TestReplayDivergences(t *testing.T) {
+	recorded := []ai.ToolCall{{Name: "a"}, {Name: "b", Input: map[string]any{"n": 1.0}}}
+	got := divergences(recorded, []ai.ToolCall{{Name: "c", Input: map[string]any{}}})
+	if len(got) != 2 || got[0].Kind != DivergenceTool || got[1].Kind != DivergenceMissing {
+		t.Fatalf("divergences = %+v", got)
+	}
+	if got[0].String() != "call 1: c instead of a" || got[1].String() != `call 2: b {"n":1} was not made` {
+		t.Fatalf("strings = %q, %q", got[0], got[1])
+	}
+	if got := divergences(recorded, []ai.ToolCall{{Name: "a", Input: map[string]any{}}, {Name: "b", Input: map[string]any{"n": 1.0}}}); len(got) != 0 {
+		t.Fatalf("matching calls diverged: %+v", got)
+	}
+}
+
+func TestReplayRequiresInput(t *testing.T) {
+	ctx := context.Background()
+	cp := flow.StoreCheckpoint(store.NewMemoryStore(), "old-agent")
+	// a run checkpointed before done runs kept their input
+	if err := cp.Save(ctx, flow.Run{ID: "r1", Flow: "old-agent", Status: "done", State: flow.State{Data: []byte(`{"Reply":"ok"}`)}}); err != nil {
+		t.Fatal(err)
+	}
+	fakeGen = func(ctx context.Context, opts ai.Options, req *ai.Request) (*ai.Response, error) {
+		return &ai.Response{Reply: req.Prompt}, nil
+	}
+	defer func() { fakeGen = nil }()
+	a := newTestAgent(Name("old-agent"), WithCheckpoint(cp))
+	if _, err := Replay(ctx, a, "r1", ReplayOptions{}); err == nil || !strings.Contains(err.Error(), "no recorded input") {
+		t.Fatalf("err = %v, want missing input", err)
+	}
+	res, err := Replay(ctx, a, "r1", ReplayOptions{Input: "again"})
+	if err != nil || res.Reply != "again" || res.RecordedReply != "ok" {
+		t.Fatalf("Replay = %+v, %v", res, err)
+	}
+}
+
+// Repeated calls with the same arguments, such as a run polling a job,
+// get the recorded results in the order they were recorded.
+func TestReplayRepeatedCallsInOrder(t *testing.T) {
+	ctx := context.Background()
+	cp := flow.StoreCheckpoint(store.NewMemoryStore(), "polling-agent")
+	step := func(result string) flow.StepRecord {
+		return flow.StepRecord{Name: `tool:jobs_Status:{"id":"7"}`, Status: "done", Result: result}
+	}
+	if err := cp.Save(ctx, flow.Run{ID: "r1", Flow: "polling-agent", Status: "done",
+		State: flow.State{Data: []byte(`{"Reply":"done","Input":"wait for job 7"}`)},
+		Steps: []flow.StepRecord{step(`{"state":"queued"}`), step(`{"state":"running"}`), step(`{"state":"done"}`)},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	fakeGen = func(ctx context.Context, opts ai.Options, req *ai.Request) (*ai.Response, error) {
+		for i := 0; i < 4; i++ {
+			opts.ToolHandler(ctx, ai.ToolCall{Name: "jobs_Status", Input: map[string]any{"id": "7"}})
+		}
+		return &ai.Response{Reply: "done"}, nil
+	}
+	defer func() { fakeGen = nil }()
+	a := newTestAgent(Name("polling-agent"), WithCheckpoint(cp))
+	res, err := Replay(ctx, a, "r1", ReplayOptions{})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	var got []string
+	for _, call := range res.Replayed {
+		got = append(got, call.Result)
+	}
+	want := []string{`{"state":"queued"}`, `{"state":"running"}`, `{"state":"done"}`, `{"state":"done"}`}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("replayed results = %v, want %v", got, want)
+	}
+}
@@ -71,6 +71,10 @@ func ResumeStreamAsk(ctx context.Context, ag Agent, runID string) (AgentStream,
 // StreamAsk runs tools like Ask, emits ToolStart/ToolEnd events as they execute,
 // then emits chunks of the final answer followed by a Done event.
 func (a *agentImpl) StreamAsk(ctx context.Context, message string) (AgentStream, error) {
+	return a.streamAsk(ctx, message, nil)
+}
+
+func (a *agentImpl) streamAsk(ctx context.Context, message string, parts []ai.Part) (AgentStream, error) {
 	streamCtx, cancel := context.WithCancel(ctx)
 	events := make(chan *StreamEvent, 16)
 	done := make(chan struct{})
@@ -79,7 +83,7 @@ func (a *agentImpl) StreamAsk(ctx context.Context, message string) (AgentStream,
 	go func() {
 		defer close(events)
 		defer close(done)
-		resp, err := a.askWithStreamEvents(streamCtx, message, events)
+		resp, err := a.askWithStreamEvents(streamCtx, message, parts, events)
 		if err != nil {
 			s.setErr(err)
 			return
@@ -118,7 +122,7 @@ func (a *agentImpl) resumeStreamAsk(ctx context.Context, runID string) (AgentStr
 	return s, nil
 }
 
-func (a *agentImpl) askWithStreamEvents(ctx context.Context, message string, events chan<- *StreamEvent) (*Response, error) {
+func (a *agentImpl) askWithStreamEvents(ctx context.Context, message string, parts []ai.Part, events chan<- *StreamEvent) (*Response, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -134,7 +138,8 @@ func (a *agentImpl) askWithStreamEvents(ctx context.Context, message string, eve
 	}
 	a.setupWithToolHandler(handler)
 	defer a.setupWithToolHandler(nil)
-	return a.askLocked(ctx, uuid.New().String(), message, a.parentRunID, nil, true)
+	defer a.useSession(ctx)()
+	return a.askLocked(ctx, uuid.New().String(), message, parts, a.parentRunID, nil, true)
 }
 
 func (a *agentImpl) resumeWithStreamEvents(ctx context.Context, runID string, events chan<- *StreamEvent) (*Response, error) {
@@ -180,7 +185,7 @@ func (a *agentImpl) resumeWithStreamEvents(ctx context.Context, runID string, ev
 		run.Status = "running"
 		run.State.Stage = agentAskStep
 	}
-	return a.askLocked(ctx, run.ID, string(run.State.Data), run.ParentID, &run, false)
+	return a.askLocked(ctx, run.ID, string(run.State.Data), nil, run.ParentID, &run, false)
 }
 
 type agentStreamAdapter struct {
@@ -251,8 +256,13 @@ func (s *agentStreamAdapter) Close() error {
 	return s.stream.Close()
 }
 
+// streamAskAI is the A2A StreamInvoke for an embedded agent.
 func (a *agentImpl) streamAskAI(ctx context.Context, message string) (ai.Stream, error) {
-	stream, err := a.StreamAsk(ctx, message)
+	return a.streamAskParts(ctx, message, a2aParts(ctx, message))
+}
+
+func (a *agentImpl) streamAskParts(ctx context.Context, message string, parts []ai.Part) (ai.Stream, error) {
+	stream, err := a.streamAsk(ctx, message, parts)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,143 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go-micro.dev/v6/store"
+)
+
+// ErrNotFound is returned for a tool that isn't in the registry.
+var ErrNotFound = errors.New("wasm: tool not found")
+
+// wasmMagic starts every WebAssembly binary module.
+var wasmMagic = []byte("\x00asm")
+
+// Registry keeps tool modules and their manifests in a store, so a
+// platform team can publish tools that agents load at startup without
+// shipping them in the agent's image.
+type Registry struct {
+	st store.Store
+}
+
+// NewRegistry returns a registry kept in s, or store.DefaultStore if s is nil.
+func NewRegistry(s store.Store) *Registry {
+	if s == nil {
+		s = store.DefaultStore
+	}
+	return &Registry{st: store.Scope(s, "wasm", "tools")}
+}
+
+func manifestKey(name string) string { return name + "/manifest" }
+func moduleKey(name string) string   { return name + "/module" }
+
+// Push adds the tool, replacing any tool of the same name. The manifest's
+// Module path is ignored; the module is stored with it.
+func (r *Registry) Push(m Manifest, module []byte) error {
+	if err := m.validate(); err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(module, wasmMagic) {
+		return fmt.Errorf("wasm tool %s: not a WebAssembly module", m.Name)
+	}
+	m.Module = ""
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := r.st.Write(&store.Record{Key: moduleKey(m.Name), Value: module}); err != nil {
+		return err
+	}
+	return r.st.Write(&store.Record{Key: manifestKey(m.Name), Value: b})
+}
+
+// Get returns the manifest and module of the named tool.
+func (r *Registry) Get(name string) (Manifest, []byte, error) {
+	var m Manifest
+	recs, err := r.st.Read(manifestKey(name))
+	if errors.Is(err, store.ErrNotFound) || (err == nil && len(recs) == 0) {
+		return m, nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	if err != nil {
+		return m, nil, err
+	}
+	if err := json.Unmarshal(recs[0].Value, &m); err != nil {
+		return m, nil, err
+	}
+	recs, err = r.st.Read(moduleKey(name))
+	if errors.Is(err, store.ErrNotFound) || (err == nil && len(recs) == 0) {
+		return m, nil, fmt.Errorf("%w: %s has no module", ErrNotFound, name)
+	}
+	if err != nil {
+		return m, nil, err
+	}
+	return m, recs[0].Value, nil
+}
+
+// List returns the manifests of every tool, by name.
+func (r *Registry) List() ([]Manifest, error) {
+	keys, err := r.st.List(store.ListSuffix("/manifest"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	out := make([]Manifest, 0, len(keys))
+	for _, k := range keys {
+		recs, err := r.st.Read(k)
+		if err != nil || len(recs) == 0 {
+			continue
+		}
+		var m Manifest
+		if err := json.Unmarshal(recs[0].Value, &m); err == nil {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+// Delete removes the named tool.
+func (r *Registry) Delete(name string) error {
+	if err := r.st.Delete(manifestKey(name)); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	if err := r.st.Delete(moduleKey(name)); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// LoadRegistry loads the named tools from reg, or every tool if no names
+// are given. Relative directories in their manifests resolve against the
+// working directory. It fails, closing the tools already loaded, if any
+// tool fails to load.
+func LoadRegistry(ctx context.Context, reg *Registry, names []string, opts ...Option) ([]*Tool, error) {
+	if len(names) == 0 {
+		manifests, err := reg.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range manifests {
+			names = append(names, m.Name)
+		}
+	}
+	var tools []*Tool
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		m, module, err := reg.Get(name)
+		if err == nil {
+			var t *Tool
+			if t, err = Load(ctx, m, module, "", opts...); err == nil {
+				tools = append(tools, t)
+				continue
+			}
+		}
+		closeAll(ctx, tools)
+		return nil, err
+	}
+	return tools, nil
+}
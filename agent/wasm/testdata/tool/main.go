@@ -0,0 +1,55 @@
+// Command tool is a WASI tool module used by the wasm package tests. The
+// "mode" input picks what it does.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func main() {
+	var in struct {
+		Mode string  `json:"mode"`
+		A    float64 `json:"a"`
+		B    float64 `json:"b"`
+	}
+	if err := json.NewDecoder(os.Stdin).Decode(&in); err != nil {
+		fmt.Fprintln(os.Stderr, "bad input:", err)
+		os.Exit(2)
+	}
+	switch in.Mode {
+	case "env":
+		fmt.Printf("%q", os.Getenv("WASM_TEST_SECRET"))
+	case "file":
+		b, err := os.ReadFile("/data/note.txt")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Print(string(b))
+	case "write":
+		if err := os.WriteFile("/data/new.txt", []byte("x"), 0o644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	case "loop":
+		for {
+		}
+	case "alloc":
+		var keep [][]byte
+		for i := 0; i < 64; i++ {
+			keep = append(keep, make([]byte, 8<<20))
+			keep[i][0] = 1
+		}
+		fmt.Print(len(keep))
+	case "spam":
+		fmt.Print(strings.Repeat("x", 4096))
+	case "fail":
+		fmt.Fprintln(os.Stderr, "lookup failed: upstream down")
+		os.Exit(3)
+	default:
+		fmt.Printf(`{"sum":%g}`, in.A+in.B)
+	}
+}
@@ -0,0 +1,461 @@
+// Package wasm runs agent tools compiled to WebAssembly in an in-process
+// sandbox.
+//
+// A tool is a WASI command module with a JSON manifest. The tool's input is
+// written to the module's stdin as a JSON object and its stdout is the
+// result; a non-zero exit fails the call with the module's stderr. Any
+// language that targets wasip1 works, e.g. GOOS=wasip1 GOARCH=wasm go build.
+//
+// Modules run under wazero with no host access: no filesystem, no network,
+// no environment, a fixed clock and a deterministic random source. A
+// manifest can declare environment variables, read-only directories, the
+// real clock and a secure random source, and the loader only grants the
+// environment variables and directories it allows. Every call runs in a
+// fresh instance bounded by the manifest's memory, time and output limits.
+//
+//	tools, err := wasm.LoadDir(ctx, "./tools", wasm.AllowEnv("FX_API_KEY"))
+//	ag := agent.New(agent.Name("billing"), wasm.WithTools(tools...))
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+
+	"go-micro.dev/v6/agent"
+	"go-micro.dev/v6/ai"
+)
+
+// Default limits applied when a manifest leaves a limit unset.
+const (
+	DefaultMemoryMB  = 64
+	DefaultTimeout   = 10 * time.Second
+	DefaultMaxOutput = 1 << 20
+)
+
+// MaxMemoryMB is the most memory a module can be given: 32-bit
+// WebAssembly addresses at most 65536 pages of 64KiB.
+const MaxMemoryMB = 4096
+
+// ErrOutputTooLarge is returned by a call whose output exceeds the tool's
+// MaxOutput limit.
+var ErrOutputTooLarge = errors.New("wasm tool output exceeds its limit")
+
+var toolName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// Manifest describes a tool module. It is read from a JSON file next to
+// the module, or pushed to a Registry with it.
+type Manifest struct {
+	// Name is the tool name shown to the model.
+	Name string `json:"name"`
+	// Description tells the model what the tool does.
+	Description string `json:"description"`
+	// Properties is the JSON schema of the tool's input properties, as
+	// in ai.Tool.
+	Properties map[string]any `json:"properties,omitempty"`
+	// Module is the path of the .wasm file, relative to the manifest.
+	// Defaults to <name>.wasm.
+	Module string `json:"module,omitempty"`
+	// Limits bound each call.
+	Limits Limits `json:"limits,omitzero"`
+	// Capabilities is the host access the module needs.
+	Capabilities Capabilities `json:"capabilities,omitzero"`
+}
+
+// Limits bound a single call of a tool. Zero values use the defaults.
+// wazero doesn't meter instructions, so CPU use is bounded by Timeout.
+type Limits struct {
+	// MemoryMB caps the module's linear memory.
+	MemoryMB int `json:"memory_mb,omitempty"`
+	// Timeout caps the wall time of a call, in JSON as a duration
+	// string such as "2s".
+	Timeout time.Duration `json:"-"`
+	// MaxOutput caps the bytes a call may write to stdout.
+	MaxOutput int `json:"max_output,omitempty"`
+}
+
+// Capabilities is the host access a module declares. Anything not
+// declared is unavailable to it.
+type Capabilities struct {
+	// Env names host environment variables passed to the module.
+	Env []string `json:"env,omitempty"`
+	// Dirs mounts host directories read-only, keyed by guest path. A
+	// relative host path is relative to the manifest's directory.
+	Dirs map[string]string `json:"dirs,omitempty"`
+	// Clock gives the module the host's wall clock and sleep. Without
+	// it the module sees a fixed time.
+	Clock bool `json:"clock,omitempty"`
+	// Random gives the module a cryptographic random source. Without it
+	// random numbers are deterministic.
+	Random bool `json:"random,omitempty"`
+}
+
+func (l Limits) MarshalJSON() ([]byte, error) {
+	type limits Limits
+	out := struct {
+		limits
+		Timeout string `json:"timeout,omitempty"`
+	}{limits: limits(l)}
+	if l.Timeout > 0 {
+		out.Timeout = l.Timeout.String()
+	}
+	return json.Marshal(out)
+}
+
+func (l *Limits) UnmarshalJSON(b []byte) error {
+	type limits Limits
+	in := struct {
+		*limits
+		Timeout string `json:"timeout"`
+	}{limits: (*limits)(l)}
+	if err := json.Unmarshal(b, &in); err != nil {
+		return err
+	}
+	if in.Timeout == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(in.Timeout)
+	if err != nil {
+		return fmt.Errorf("invalid timeout %q: %w", in.Timeout, err)
+	}
+	l.Timeout = d
+	return nil
+}
+
+// Options configure how tools are loaded.
+type Options struct {
+	// MaxLimits caps the limits a manifest may ask for. Zero fields
+	// leave that limit uncapped.
+	MaxLimits Limits
+	// Env is the host environment variables manifests may declare.
+	Env []string
+	// Dirs is the host directories manifests may mount, with everything
+	// beneath them.
+	Dirs []string
+}
+
+// Option sets Options.
+type Option func(*Options)
+
+// MaxLimits caps the memory, time and output a manifest may ask for.
+func MaxLimits(l Limits) Option {
+	return func(o *Options) { o.MaxLimits = l }
+}
+
+// AllowEnv lets manifests declare the named host environment variables.
+func AllowEnv(names ...string) Option {
+	return func(o *Options) { o.Env = append(o.Env, names...) }
+}
+
+// AllowDirs lets manifests mount the given host directories, or
+// directories beneath them, read-only.
+func AllowDirs(dirs ...string) Option {
+	return func(o *Options) { o.Dirs = append(o.Dirs, dirs...) }
+}
+
+// Tool is a loaded tool module. It is safe for concurrent use; each call
+// runs in its own instance.
+type Tool struct {
+	manifest Manifest
+	limits   Limits
+	dirs     map[string]string
+	runtime  wazero.Runtime
+	module   wazero.CompiledModule
+}
+
+// Load compiles a tool module. dir resolves relative paths in the
+// manifest's Dirs; it may be empty.
+func Load(ctx context.Context, m Manifest, module []byte, dir string, opts ...Option) (*Tool, error) {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+	dirs, err := grant(m, dir, options)
+	if err != nil {
+		return nil, err
+	}
+	limits := m.Limits.withDefaults(options.MaxLimits)
+
+	cfg := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(uint32(limits.MemoryMB) * 16). // 64KiB pages
+		WithCloseOnContextDone(true)
+	r := wazero.NewRuntimeWithConfig(ctx, cfg)
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, r); err != nil {
+		r.Close(ctx)
+		return nil, fmt.Errorf("wasm tool %s: %w", m.Name, err)
+	}
+	compiled, err := r.CompileModule(ctx, module)
+	if err != nil {
+		r.Close(ctx)
+		return nil, fmt.Errorf("wasm tool %s: compile: %w", m.Name, err)
+	}
+	return &Tool{manifest: m, limits: limits, dirs: dirs, runtime: r, module: compiled}, nil
+}
+
+// LoadFile loads the tool described by the manifest at path.
+func LoadFile(ctx context.Context, path string, opts ...Option) (*Tool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("wasm manifest %s: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	name := m.Module
+	if name == "" {
+		name = m.Name + ".wasm"
+	}
+	module, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, fmt.Errorf("wasm tool %s: %w", m.Name, err)
+	}
+	return Load(ctx, m, module, dir, opts...)
+}
+
+// LoadDir loads every tool whose manifest is a .json file in dir. It
+// fails, closing the tools already loaded, if any tool fails to load.
+func LoadDir(ctx context.Context, dir string, opts ...Option) ([]*Tool, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var tools []*Tool
+	for _, path := range paths {
+		t, err := LoadFile(ctx, path, opts...)
+		if err != nil {
+			closeAll(ctx, tools)
+			return nil, err
+		}
+		tools = append(tools, t)
+	}
+	return tools, nil
+}
+
+// Manifest returns the tool's manifest.
+func (t *Tool) Manifest() Manifest {
+	return t.manifest
+}
+
+// Limits returns the limits each call runs under.
+func (t *Tool) Limits() Limits {
+	return t.limits
+}
+
+// Def returns the tool's definition for a model.
+func (t *Tool) Def() ai.Tool {
+	return ai.Tool{
+		Name:         t.manifest.Name,
+		OriginalName: t.manifest.Name,
+		Description:  t.manifest.Description,
+		Properties:   t.manifest.Properties,
+	}
+}
+
+// Call runs the module with input on stdin and returns its stdout.
+func (t *Tool) Call(ctx context.Context, input map[string]any) (string, error) {
+	if input == nil {
+		input = map[string]any{}
+	}
+	in, err := json.Marshal(input)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.limits.Timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{max: t.limits.MaxOutput}
+	stderr := &limitedBuffer{max: 4096}
+	cfg := wazero.NewModuleConfig().
+		WithName("").
+		WithArgs(t.manifest.Name).
+		WithStdin(bytes.NewReader(in)).
+		WithStdout(stdout).
+		WithStderr(stderr)
+	caps := t.manifest.Capabilities
+	for _, name := range caps.Env {
+		if v, ok := os.LookupEnv(name); ok {
+			cfg = cfg.WithEnv(name, v)
+		}
+	}
+	if len(t.dirs) > 0 {
+		fs := wazero.NewFSConfig()
+		for guest, host := range t.dirs {
+			fs = fs.WithReadOnlyDirMount(host, guest)
+		}
+		cfg = cfg.WithFSConfig(fs)
+	}
+	if caps.Clock {
+		cfg = cfg.WithSysWalltime().WithSysNanotime().WithSysNanosleep()
+	}
+	if caps.Random {
+		cfg = cfg.WithRandSource(rand.Reader)
+	}
+
+	mod, err := t.runtime.InstantiateModule(ctx, t.module, cfg)
+	if mod != nil {
+		mod.Close(context.Background())
+	}
+	if stdout.overflow {
+		return "", fmt.Errorf("wasm tool %s: %w (%d bytes)", t.manifest.Name, ErrOutputTooLarge, t.limits.MaxOutput)
+	}
+	var exit *sys.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == 0 {
+		err = nil
+	}
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("wasm tool %s: exceeded its %s timeout: %w", t.manifest.Name, t.limits.Timeout, context.DeadlineExceeded)
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("wasm tool %s: %s", t.manifest.Name, msg)
+		}
+		return "", fmt.Errorf("wasm tool %s: %w", t.manifest.Name, err)
+	}
+	return stdout.String(), nil
+}
+
+// Close releases the tool's runtime.
+func (t *Tool) Close(ctx context.Context) error {
+	return t.runtime.Close(ctx)
+}
+
+// WithTools adds the tools to an agent, alongside those added with
+// agent.WithTool. The agent doesn't close them: the caller still owns the
+// tools and closes each once the agent is stopped.
+func WithTools(tools ...*Tool) agent.Option {
+	return func(o *agent.Options) {
+		for _, t := range tools {
+			def := t.Def()
+			agent.WithTool(def.Name, def.Description, def.Properties, t.Call)(o)
+		}
+	}
+}
+
+func (m Manifest) validate() error {
+	if !toolName.MatchString(m.Name) {
+		return fmt.Errorf("wasm tool name %q must be 1-64 letters, digits, '_' or '-'", m.Name)
+	}
+	if m.Description == "" {
+		return fmt.Errorf("wasm tool %s: missing description", m.Name)
+	}
+	if m.Limits.MemoryMB < 0 || m.Limits.Timeout < 0 || m.Limits.MaxOutput < 0 {
+		return fmt.Errorf("wasm tool %s: negative limit", m.Name)
+	}
+	if m.Limits.MemoryMB > MaxMemoryMB {
+		return fmt.Errorf("wasm tool %s: memory_mb %d exceeds %d", m.Name, m.Limits.MemoryMB, MaxMemoryMB)
+	}
+	return nil
+}
+
+// withDefaults fills unset limits from the defaults and caps them at max.
+func (l Limits) withDefaults(max Limits) Limits {
+	if l.MemoryMB == 0 {
+		l.MemoryMB = DefaultMemoryMB
+	}
+	if l.Timeout == 0 {
+		l.Timeout = DefaultTimeout
+	}
+	if l.MaxOutput == 0 {
+		l.MaxOutput = DefaultMaxOutput
+	}
+	if max.MemoryMB > 0 && l.MemoryMB > max.MemoryMB {
+		l.MemoryMB = max.MemoryMB
+	}
+	if max.Timeout > 0 && l.Timeout > max.Timeout {
+		l.Timeout = max.Timeout
+	}
+	if max.MaxOutput > 0 && l.MaxOutput > max.MaxOutput {
+		l.MaxOutput = max.MaxOutput
+	}
+	return l
+}
+
+// grant checks the manifest's environment variables and directories
+// against those the loader allows, and resolves the directories.
+func grant(m Manifest, dir string, o Options) (map[string]string, error) {
+	for _, name := range m.Capabilities.Env {
+		if !slices.Contains(o.Env, name) {
+			return nil, fmt.Errorf("wasm tool %s: environment variable %s is not allowed", m.Name, name)
+		}
+	}
+	dirs := make(map[string]string, len(m.Capabilities.Dirs))
+	for guest, host := range m.Capabilities.Dirs {
+		if !filepath.IsAbs(host) {
+			host = filepath.Join(dir, host)
+		}
+		host, err := filepath.Abs(host)
+		if err != nil {
+			return nil, err
+		}
+		// a symlink inside an allowed directory can point anywhere, so
+		// check and mount the directory it resolves to
+		if host, err = filepath.EvalSymlinks(host); err != nil {
+			return nil, fmt.Errorf("wasm tool %s: directory %w", m.Name, err)
+		}
+		if !allowedDir(o.Dirs, host) {
+			return nil, fmt.Errorf("wasm tool %s: directory %s is not allowed", m.Name, host)
+		}
+		dirs[guest] = host
+	}
+	return dirs, nil
+}
+
+// allowedDir reports whether dir, with its symlinks resolved, is one of
+// the allowed directories or inside one.
+func allowedDir(allowed []string, dir string) bool {
+	for _, a := range allowed {
+		a, err := filepath.Abs(a)
+		if err != nil {
+			continue
+		}
+		if a, err = filepath.EvalSymlinks(a); err != nil {
+			continue
+		}
+		if rel, err := filepath.Rel(a, dir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+func closeAll(ctx context.Context, tools []*Tool) {
+	for _, t := range tools {
+		t.Close(ctx)
+	}
+}
+
+// limitedBuffer is a bytes.Buffer that stops accepting writes past max.
+type limitedBuffer struct {
+	bytes.Buffer
+	max      int
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.max {
+		b.overflow = true
+		return 0, ErrOutputTooLarge
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,243 @@
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/store"
+)
+
+var (
+	buildOnce sync.Once
+	module    []byte
+	buildErr  error
+)
+
+// testModule builds testdata/tool for wasip1, once per test binary.
+func testModule(t *testing.T) []byte {
+	t.Helper()
+	buildOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "wasm-tool")
+		if err != nil {
+			buildErr = err
+			return
+		}
+		defer os.RemoveAll(dir)
+		out := filepath.Join(dir, "tool.wasm")
+		cmd := exec.Command("go", "build", "-o", out, "./testdata/tool")
+		cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+		if b, err := cmd.CombinedOutput(); err != nil {
+			buildErr = errors.New(string(b))
+			return
+		}
+		module, buildErr = os.ReadFile(out)
+	})
+	if buildErr != nil {
+		t.Skipf("building the wasip1 test module: %v", buildErr)
+	}
+	return module
+}
+
+func testManifest() Manifest {
+	return Manifest{
+		Name:        "calc",
+		Description: "Adds a and b.",
+		Properties:  map[string]any{"a": map[string]any{"type": "number"}, "b": map[string]any{"type": "number"}},
+	}
+}
+
+func TestToolCall(t *testing.T) {
+	ctx := context.Background()
+	tool, err := Load(ctx, testManifest(), testModule(t), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tool.Close(ctx)
+
+	if def := tool.Def(); def.Name != "calc" || def.Properties["a"] == nil {
+		t.Fatalf("def = %+v", def)
+	}
+	out, err := tool.Call(ctx, map[string]any{"a": 2, "b": 40})
+	if err != nil || out != `{"sum":42}` {
+		t.Fatalf("Call = %q, %v", out, err)
+	}
+
+	_, err = tool.Call(ctx, map[string]any{"mode": "fail"})
+	if err == nil || !strings.Contains(err.Error(), "lookup failed: upstream down") {
+		t.Fatalf("failing call = %v, want its stderr", err)
+	}
+}
+
+func TestToolLimits(t *testing.T) {
+	ctx := context.Background()
+	m := testManifest()
+	m.Limits = Limits{Timeout: time.Minute, MaxOutput: 1024, MemoryMB: 256}
+	tool, err := Load(ctx, m, testModule(t), "", MaxLimits(Limits{Timeout: 500 * time.Millisecond, MemoryMB: 32}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tool.Close(ctx)
+	if l := tool.Limits(); l.Timeout != 500*time.Millisecond || l.MemoryMB != 32 || l.MaxOutput != 1024 {
+		t.Fatalf("limits = %+v, want the manifest's capped by MaxLimits", l)
+	}
+
+	m.Limits = Limits{MemoryMB: MaxMemoryMB + 1}
+	if _, err := Load(ctx, m, testModule(t), ""); err == nil {
+		t.Fatal("loaded a tool with more memory than 32-bit WebAssembly can address")
+	}
+
+	start := time.Now()
+	if _, err := tool.Call(ctx, map[string]any{"mode": "loop"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("busy loop = %v, want a timeout", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("busy loop ran for %s", d)
+	}
+	if _, err := tool.Call(ctx, map[string]any{"mode": "alloc"}); err == nil {
+		t.Fatal("allocated 512MB under a 32MB limit")
+	}
+	if _, err := tool.Call(ctx, map[string]any{"mode": "spam"}); !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("oversized output = %v", err)
+	}
+	// the tool still works after its failed calls
+	if out, err := tool.Call(ctx, map[string]any{"a": 1, "b": 1}); err != nil || out != `{"sum":2}` {
+		t.Fatalf("Call after limits = %q, %v", out, err)
+	}
+}
+
+func TestToolCapabilities(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("WASM_TEST_SECRET", "s3cret")
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "note.txt"), []byte("hello from the host"), 0o644)
+
+	// undeclared: nothing from the host
+	sealed, err := Load(ctx, testManifest(), testModule(t), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sealed.Close(ctx)
+	if out, _ := sealed.Call(ctx, map[string]any{"mode": "env"}); out != `""` {
+		t.Fatalf("undeclared env = %s", out)
+	}
+	if _, err := sealed.Call(ctx, map[string]any{"mode": "file"}); err == nil {
+		t.Fatal("read a host file without declaring it")
+	}
+
+	m := testManifest()
+	m.Capabilities = Capabilities{Env: []string{"WASM_TEST_SECRET"}, Dirs: map[string]string{"/data": dir}}
+	if _, err := Load(ctx, m, testModule(t), ""); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Fatalf("loading undeclared-by-host capabilities = %v", err)
+	}
+	if _, err := Load(ctx, m, testModule(t), "", AllowEnv("WASM_TEST_SECRET"), AllowDirs(filepath.Join(dir, "sub"))); err == nil {
+		t.Fatal("mounted a parent of the allowed directory")
+	}
+	open, err := Load(ctx, m, testModule(t), "", AllowEnv("WASM_TEST_SECRET"), AllowDirs(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer open.Close(ctx)
+	if out, _ := open.Call(ctx, map[string]any{"mode": "env"}); out != `"s3cret"` {
+		t.Fatalf("declared env = %s", out)
+	}
+	if out, err := open.Call(ctx, map[string]any{"mode": "file"}); err != nil || out != "hello from the host" {
+		t.Fatalf("declared dir = %q, %v", out, err)
+	}
+	if _, err := open.Call(ctx, map[string]any{"mode": "write"}); err == nil {
+		t.Fatal("wrote to a read-only mount")
+	}
+}
+
+func TestToolDirSymlinks(t *testing.T) {
+	ctx := context.Background()
+	allowed, outside := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(outside, "note.txt"), []byte("secret"), 0o644)
+	if err := os.Symlink(outside, filepath.Join(allowed, "escape")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+	m := testManifest()
+	m.Capabilities = Capabilities{Dirs: map[string]string{"/data": filepath.Join(allowed, "escape")}}
+	if tool, err := Load(ctx, m, testModule(t), "", AllowDirs(allowed)); err == nil {
+		tool.Close(ctx)
+		t.Fatal("mounted a directory outside the allowed one through a symlink")
+	}
+
+	// an allowed directory named through a symlink allows its target
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatal(err)
+	}
+	m.Capabilities.Dirs["/data"] = outside
+	tool, err := Load(ctx, m, testModule(t), "", AllowDirs(link))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tool.Close(ctx)
+	if out, err := tool.Call(ctx, map[string]any{"mode": "file"}); err != nil || out != "secret" {
+		t.Fatalf("mounted dir = %q, %v", out, err)
+	}
+}
+
+func TestLoadDirAndRegistry(t *testing.T) {
+	ctx := context.Background()
+	mod := testModule(t)
+	dir := t.TempDir()
+	b, _ := json.Marshal(map[string]any{
+		"name":        "calc",
+		"description": "Adds a and b.",
+		"module":      "calc-v1.wasm",
+		"limits":      map[string]any{"timeout": "2s"},
+	})
+	os.WriteFile(filepath.Join(dir, "calc.json"), b, 0o644)
+	os.WriteFile(filepath.Join(dir, "calc-v1.wasm"), mod, 0o644)
+
+	tools, err := LoadDir(ctx, dir)
+	if err != nil || len(tools) != 1 {
+		t.Fatalf("LoadDir = %v, %v", tools, err)
+	}
+	defer tools[0].Close(ctx)
+	if tools[0].Limits().Timeout != 2*time.Second {
+		t.Fatalf("limits = %+v", tools[0].Limits())
+	}
+
+	os.WriteFile(filepath.Join(dir, "bad.json"), []byte(`{"name":"bad tool","description":"x"}`), 0o644)
+	if _, err := LoadDir(ctx, dir); err == nil {
+		t.Fatal("loaded a tool with an invalid name")
+	}
+
+	reg := NewRegistry(store.NewMemoryStore())
+	if err := reg.Push(testManifest(), []byte("not wasm")); err == nil {
+		t.Fatal("pushed a module that isn't WebAssembly")
+	}
+	if err := reg.Push(testManifest(), mod); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := reg.List(); len(list) != 1 || list[0].Name != "calc" {
+		t.Fatalf("List = %+v", list)
+	}
+	if _, err := LoadRegistry(ctx, reg, []string{"missing"}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("loading a missing tool = %v", err)
+	}
+	loaded, err := LoadRegistry(ctx, reg, nil)
+	if err != nil || len(loaded) != 1 {
+		t.Fatalf("LoadRegistry = %v, %v", loaded, err)
+	}
+	defer loaded[0].Close(ctx)
+	if out, err := loaded[0].Call(ctx, map[string]any{"a": 3, "b": 4}); err != nil || out != `{"sum":7}` {
+		t.Fatalf("Call = %q, %v", out, err)
+	}
+	if err := reg.Delete("calc"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := reg.Get("calc"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete = %v", err)
+	}
+}
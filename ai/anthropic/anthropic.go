@@ -20,6 +20,8 @@ func init() {
 	})
 	ai.RegisterStream("anthropic")
 	ai.RegisterToolStream("anthropic")
+	ai.RegisterInput("anthropic", ai.PartImage, ai.PartFile)
+	ai.RegisterParams("anthropic", ai.ParamTemperature, ai.ParamTopP, ai.ParamStop, ai.ParamPromptCache)
 }
 
 // Provider implements the ai.Model interface for Anthropic Claude
@@ -62,8 +64,15 @@ func (p *Provider) String() string {
 	return "anthropic"
 }
 
-// Generate generates a response from the model
+// Generate generates a response from the model, executing any tool calls
+// it makes with the tool handler
 func (p *Provider) Generate(ctx context.Context, req *ai.Request, opts ...ai.GenerateOption) (*ai.Response, error) {
+	return ai.ToolLoop(ctx, p, req, p.opts)
+}
+
+// Turn makes a single Messages API call for req followed by the tool
+// rounds so far
+func (p *Provider) Turn(ctx context.Context, req *ai.Request, rounds []ai.ToolRound) (*ai.Response, error) {
 	// Build tools for Anthropic format
 	var anthropicTools []map[string]any
 	for _, t := range req.Tools {
@@ -77,97 +86,62 @@ func (p *Provider) Generate(ctx context.Context, req *ai.Request, opts ...ai.Gen
 		})
 	}
 
-	// Build initial request
-	apiReq := map[string]any{
-		"model":      p.opts.Model,
-		"max_tokens": anthropicMaxTokens(p.opts),
-		"system":     req.SystemPrompt,
-		"messages":   threadAnthropicMessages(req),
-	}
-
-	if len(anthropicTools) > 0 {
-		apiReq["tools"] = anthropicTools
-	}
-
-	// Make API call
-	resp, rawContent, err := p.callAPI(ctx, apiReq)
+	messages, err := threadAnthropicMessages(req)
 	if err != nil {
 		return nil, err
 	}
-
-	// If no tool calls or no handler, return as-is
-	if len(resp.ToolCalls) == 0 || p.opts.ToolHandler == nil {
-		return resp, nil
-	}
-
-	// Tool execution loop: execute tools, send results back, repeat
-	// until the model responds with text only (no more tool calls)
-	messages := append(threadAnthropicMessages(req),
-		map[string]any{"role": "assistant", "content": cleanContent(rawContent)},
-	)
-
-	pendingCalls := resp.ToolCalls
-
-	for rounds := 0; rounds < 10; rounds++ {
-		var toolResultBlocks []map[string]any
-		for i := range pendingCalls {
-			content := p.opts.ToolHandler(ctx, pendingCalls[i]).Content
-			pendingCalls[i].Result = content
-			toolResultBlocks = append(toolResultBlocks, map[string]any{
-				"type":        "tool_result",
-				"tool_use_id": pendingCalls[i].ID,
-				"content":     content,
-			})
-		}
-
-		messages = append(messages, map[string]any{
-			"role":    "user",
-			"content": toolResultBlocks,
-		})
-
-		followUpReq := map[string]any{
-			"model":      p.opts.Model,
-			"max_tokens": anthropicMaxTokens(p.opts),
-			"system":     req.SystemPrompt,
-			"messages":   messages,
+	params := ai.ProviderParams("anthropic", p.opts.Params)
+	if params.PromptCache {
+		// The conversation so far and the latest tool round are the
+		// prefix of the next turn.
+		if n := len(req.Messages); n > 0 {
+			cacheBreakpoint(messages[n-1])
 		}
-		if len(anthropicTools) > 0 {
-			followUpReq["tools"] = anthropicTools
+		if tm := toolMessages(rounds); len(tm) > 0 {
+			cacheBreakpoint(tm[len(tm)-1])
+			messages = append(messages, tm...)
 		}
-
-		followUpResp, followUpRaw, err := p.callAPI(ctx, followUpReq)
-		if err != nil {
-			break
-		}
-
-		if len(followUpResp.ToolCalls) > 0 {
-			resp.ToolCalls = append(resp.ToolCalls, followUpResp.ToolCalls...)
-			pendingCalls = followUpResp.ToolCalls
-			messages = append(messages, map[string]any{
-				"role":    "assistant",
-				"content": cleanContent(followUpRaw),
-			})
-			continue
+		// Tools come before the system prompt in the prefix, so a
+		// breakpoint on the system prompt caches both.
+		if req.SystemPrompt == "" && len(anthropicTools) > 0 {
+			anthropicTools[len(anthropicTools)-1]["cache_control"] = ephemeral()
 		}
+	} else {
+		messages = append(messages, toolMessages(rounds)...)
+	}
+	apiReq := map[string]any{
+		"model":      p.opts.Model,
+		"max_tokens": anthropicMaxTokens(p.opts),
+		"system":     system(req.SystemPrompt, params),
+		"messages":   messages,
+	}
+	setParams(apiReq, params)
 
-		if followUpResp.Reply != "" {
-			resp.Answer = followUpResp.Reply
-		}
-		break
+	if len(anthropicTools) > 0 {
+		apiReq["tools"] = anthropicTools
 	}
 
-	return resp, nil
+	return p.callAPI(ctx, apiReq)
 }
 
 // Stream generates a streaming response from Anthropic's Messages SSE API.
 func (p *Provider) Stream(ctx context.Context, req *ai.Request, opts ...ai.GenerateOption) (ai.Stream, error) {
+	messages, err := threadAnthropicMessages(req)
+	if err != nil {
+		return nil, err
+	}
+	params := ai.ProviderParams("anthropic", p.opts.Params)
+	if n := len(req.Messages); params.PromptCache && n > 0 {
+		cacheBreakpoint(messages[n-1])
+	}
 	apiReq := map[string]any{
 		"model":      p.opts.Model,
 		"max_tokens": anthropicMaxTokens(p.opts),
-		"system":     req.SystemPrompt,
-		"messages":   threadAnthropicMessages(req),
+		"system":     system(req.SystemPrompt, params),
+		"messages":   messages,
 		"stream":     true,
 	}
+	setParams(apiReq, params)
 	reqBody, err := json.Marshal(apiReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal stream request: %w", err)
@@ -218,15 +192,9 @@ func (s *streamReader) Recv() (*ai.Response, error) {
 				Text string `json:"text"`
 			} `json:"delta"`
 			Message struct {
-				Usage struct {
-					InputTokens  int `json:"input_tokens"`
-					OutputTokens int `json:"output_tokens"`
-				} `json:"usage"`
+				Usage anthropicUsage `json:"usage"`
 			} `json:"message"`
-			Usage *struct {
-				InputTokens  int `json:"input_tokens"`
-				OutputTokens int `json:"output_tokens"`
-			} `json:"usage"`
+			Usage *anthropicUsage `json:"usage"`
 		}
 		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
 			return nil, fmt.Errorf("failed to parse stream chunk: %w", err)
@@ -237,12 +205,12 @@ func (s *streamReader) Recv() (*ai.Response, error) {
 				return &ai.Response{Reply: chunk.Delta.Text}, nil
 			}
 		case "message_start":
-			if chunk.Message.Usage.InputTokens > 0 || chunk.Message.Usage.OutputTokens > 0 {
-				return &ai.Response{Usage: usage(chunk.Message.Usage.InputTokens, chunk.Message.Usage.OutputTokens)}, nil
+			if u := chunk.Message.Usage.usage(); u.TotalTokens > 0 {
+				return &ai.Response{Usage: u}, nil
 			}
 		case "message_delta":
 			if chunk.Usage != nil {
-				return &ai.Response{Usage: usage(chunk.Usage.InputTokens, chunk.Usage.OutputTokens)}, nil
+				return &ai.Response{Usage: chunk.Usage.usage()}, nil
 			}
 		case "message_stop":
 			return nil, io.EOF
@@ -264,23 +232,40 @@ func (s *streamReader) Close() error {
 	return s.body.Close()
 }
 
-func usage(input, output int) ai.Usage {
-	return ai.Usage{InputTokens: input, OutputTokens: output, TotalTokens: input + output}
+// anthropicUsage is the usage object of a Messages API response.
+// input_tokens doesn't count the tokens read from or written to the
+// prompt cache, which are billed separately.
+type anthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+func (u anthropicUsage) usage() ai.Usage {
+	input := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return ai.Usage{
+		InputTokens:      input,
+		OutputTokens:     u.OutputTokens,
+		TotalTokens:      input + u.OutputTokens,
+		CacheReadTokens:  u.CacheReadInputTokens,
+		CacheWriteTokens: u.CacheCreationInputTokens,
+	}
 }
 
 // callAPI makes an HTTP request to the Anthropic API
-func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Response, any, error) {
+func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Response, error) {
 	// Marshal request
 	reqBody, err := json.Marshal(req)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	// Build HTTP request
 	apiURL := strings.TrimRight(p.opts.BaseURL, "/") + "/v1/messages"
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(reqBody))
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
@@ -291,14 +276,14 @@ func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Respons
 	// Make request
 	httpResp, err := http.DefaultClient.Do(httpReq)
 	if err != nil {
-		return nil, nil, fmt.Errorf("API request failed: %w", err)
+		return nil, fmt.Errorf("API request failed: %w", err)
 	}
 	defer httpResp.Body.Close()
 
 	// Read response
 	respBody, _ := io.ReadAll(httpResp.Body)
 	if httpResp.StatusCode != http.StatusOK {
-		return nil, nil, ai.NewHTTPError(httpResp, respBody)
+		return nil, ai.NewHTTPError(httpResp, respBody)
 	}
 
 	// Parse response
@@ -310,14 +295,15 @@ func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Respons
 			Name  string          `json:"name"`
 			Input json.RawMessage `json:"input"`
 		} `json:"content"`
-		StopReason string `json:"stop_reason"`
+		StopReason string         `json:"stop_reason"`
+		Usage      anthropicUsage `json:"usage"`
 	}
 
 	if err := json.Unmarshal(respBody, &anthropicResp); err != nil {
-		return nil, nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	response := &ai.Response{}
+	response := &ai.Response{Usage: anthropicResp.Usage.usage()}
 
 	// Extract text reply
 	var replyParts []string
@@ -345,48 +331,139 @@ func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Respons
 		}
 	}
 
-	return response, anthropicResp.Content, nil
+	return response, nil
 }
 
-// cleanContent strips fields from response content blocks that Anthropic
-// rejects when sent back as assistant message content (e.g. "id" on text blocks).
-func cleanContent(raw any) any {
-	blocks, ok := raw.([]struct {
-		Type  string          `json:"type"`
-		Text  string          `json:"text"`
-		ID    string          `json:"id"`
-		Name  string          `json:"name"`
-		Input json.RawMessage `json:"input"`
-	})
-	if !ok {
-		return raw
-	}
-	var cleaned []map[string]any
-	for _, b := range blocks {
-		switch b.Type {
-		case "text":
-			cleaned = append(cleaned, map[string]any{"type": "text", "text": b.Text})
-		case "tool_use":
-			var input any
-			_ = json.Unmarshal(b.Input, &input)
-			cleaned = append(cleaned, map[string]any{"type": "tool_use", "id": b.ID, "name": b.Name, "input": input})
+// toolMessages converts completed tool rounds to Anthropic messages: the
+// assistant's text and tool_use blocks, then a user message with a
+// tool_result block per call.
+func toolMessages(rounds []ai.ToolRound) []map[string]any {
+	var msgs []map[string]any
+	for _, r := range rounds {
+		var content, results []map[string]any
+		if r.Reply != "" {
+			content = append(content, map[string]any{"type": "text", "text": r.Reply})
 		}
+		for i, call := range r.Calls {
+			input := call.Input
+			if input == nil {
+				input = map[string]any{}
+			}
+			content = append(content, map[string]any{"type": "tool_use", "id": call.ID, "name": call.Name, "input": input})
+			results = append(results, map[string]any{
+				"type":        "tool_result",
+				"tool_use_id": call.ID,
+				"content":     r.Results[i].Content,
+			})
+		}
+		msgs = append(msgs,
+			map[string]any{"role": "assistant", "content": content},
+			map[string]any{"role": "user", "content": results},
+		)
 	}
-	return cleaned
+	return msgs
 }
 
 // threadAnthropicMessages builds the Anthropic messages array from the
 // conversation history (req.Messages) followed by the current prompt. The
 // system prompt is sent separately via the top-level "system" field.
-func threadAnthropicMessages(req *ai.Request) []map[string]any {
+func threadAnthropicMessages(req *ai.Request) ([]map[string]any, error) {
 	msgs := make([]map[string]any, 0, len(req.Messages)+1)
 	for _, m := range req.Messages {
-		msgs = append(msgs, map[string]any{"role": m.Role, "content": m.Content})
+		content, err := anthropicContent(m.Content)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, map[string]any{"role": m.Role, "content": content})
 	}
-	if req.Prompt != "" {
-		msgs = append(msgs, map[string]any{"role": "user", "content": req.Prompt})
+	if c := req.UserContent(); c != nil {
+		content, err := anthropicContent(c)
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, map[string]any{"role": "user", "content": content})
 	}
-	return msgs
+	return msgs, nil
+}
+
+// anthropicContent converts message content to Anthropic content: text
+// as a string, multimodal parts as text, image and document blocks.
+func anthropicContent(content any) (any, error) {
+	if s, ok := content.(string); ok {
+		return s, nil
+	}
+	if !ai.HasMedia(content) {
+		return ai.ContentText(content), nil
+	}
+	parts, err := ai.ProviderParts("anthropic", content)
+	if err != nil {
+		return nil, err
+	}
+	blocks := make([]map[string]any, 0, len(parts))
+	for _, p := range parts {
+		switch p.Type {
+		case ai.PartText:
+			blocks = append(blocks, map[string]any{"type": "text", "text": p.Text})
+		case ai.PartImage:
+			blocks = append(blocks, map[string]any{"type": "image", "source": anthropicSource(p)})
+		case ai.PartFile:
+			block := map[string]any{"type": "document", "source": anthropicSource(p)}
+			if p.Name != "" {
+				block["title"] = p.Name
+			}
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks, nil
+}
+
+func anthropicSource(p ai.Part) map[string]any {
+	if len(p.Data) == 0 {
+		return map[string]any{"type": "url", "url": p.URL}
+	}
+	return map[string]any{"type": "base64", "media_type": p.MIMEType, "data": p.Base64()}
+}
+
+// setParams sets the sampling parameters of params on a Messages API
+// request.
+func setParams(req map[string]any, params ai.Params) {
+	if params.Temperature != nil {
+		req["temperature"] = *params.Temperature
+	}
+	if params.TopP != nil {
+		req["top_p"] = *params.TopP
+	}
+	if len(params.Stop) > 0 {
+		req["stop_sequences"] = params.Stop
+	}
+}
+
+// system returns the system field for prompt: a string, or a text block
+// with a cache breakpoint when prompt caching is on.
+func system(prompt string, params ai.Params) any {
+	if !params.PromptCache || prompt == "" {
+		return prompt
+	}
+	return []map[string]any{{"type": "text", "text": prompt, "cache_control": ephemeral()}}
+}
+
+// cacheBreakpoint marks the last content block of msg as the end of a
+// cacheable prefix, turning string content into a text block first.
+func cacheBreakpoint(msg map[string]any) {
+	switch c := msg["content"].(type) {
+	case string:
+		if c != "" {
+			msg["content"] = []map[string]any{{"type": "text", "text": c, "cache_control": ephemeral()}}
+		}
+	case []map[string]any:
+		if len(c) > 0 {
+			c[len(c)-1]["cache_control"] = ephemeral()
+		}
+	}
+}
+
+func ephemeral() map[string]any {
+	return map[string]any{"type": "ephemeral"}
 }
 
 func anthropicMaxTokens(o ai.Options) int {
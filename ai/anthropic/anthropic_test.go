@@ -2,6 +2,7 @@ package anthropic
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
@@ -149,3 +150,203 @@ func TestProvider_Stream(t *testing.T) {
 		t.Fatalf("usage = %+v, want total 3", usage)
 	}
 }
+
+func TestProvider_GenerateToolRounds(t *testing.T) {
+	var bodies []map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		bodies = append(bodies, body)
+		w.Header().Set("Content-Type", "application/json")
+		switch len(bodies) {
+		case 1:
+			_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"checking"},{"type":"tool_use","id":"tu-1","name":"lookup","input":{"q":"a"}},{"type":"tool_use","id":"tu-2","name":"lookup","input":{"q":"b"}}]}`))
+		case 2:
+			_, _ = w.Write([]byte(`{"content":[{"type":"tool_use","id":"tu-3","name":"lookup","input":{"q":"c"}}]}`))
+		case 3:
+			_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"done"}]}`))
+		default:
+			t.Fatalf("unexpected API call %d", len(bodies))
+		}
+	}))
+	defer ts.Close()
+
+	p := NewProvider(
+		ai.WithAPIKey("test-key"),
+		ai.WithBaseURL(ts.URL),
+		ai.WithToolHandler(func(ctx context.Context, call ai.ToolCall) ai.ToolResult {
+			return ai.ToolResult{ID: call.ID, Content: "result-" + call.Input["q"].(string)}
+		}),
+	)
+	resp, err := p.Generate(context.Background(), &ai.Request{
+		Prompt: "look things up",
+		Tools:  []ai.Tool{{Name: "lookup", Properties: map[string]any{"q": map[string]any{"type": "string"}}}},
+	})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if resp.Reply != "checking" || resp.Answer != "done" || len(resp.ToolCalls) != 3 {
+		t.Fatalf("response = %+v", resp)
+	}
+
+	// the last request replays both rounds: assistant tool_use, then user tool_result
+	messages := bodies[2]["messages"].([]any)
+	if len(messages) != 5 {
+		t.Fatalf("messages = %d, want prompt plus two rounds", len(messages))
+	}
+	first := messages[1].(map[string]any)["content"].([]any)
+	if len(first) != 3 || first[0].(map[string]any)["type"] != "text" || first[2].(map[string]any)["id"] != "tu-2" {
+		t.Fatalf("assistant content = %#v", first)
+	}
+	results := messages[2].(map[string]any)["content"].([]any)
+	if len(results) != 2 || results[1].(map[string]any)["tool_use_id"] != "tu-2" || results[1].(map[string]any)["content"] != "result-b" {
+		t.Fatalf("tool results = %#v", results)
+	}
+}
+
+func TestProvider_GenerateFollowUpError(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls > 1 {
+			http.Error(w, `{"type":"error"}`, http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"content":[{"type":"tool_use","id":"tu-1","name":"lookup","input":{}}]}`))
+	}))
+	defer ts.Close()
+
+	p := NewProvider(
+		ai.WithAPIKey("test-key"),
+		ai.WithBaseURL(ts.URL),
+		ai.WithToolHandler(func(ctx context.Context, call ai.ToolCall) ai.ToolResult {
+			return ai.ToolResult{ID: call.ID, Content: "ok"}
+		}),
+	)
+	_, err := p.Generate(context.Background(), &ai.Request{Prompt: "look it up"})
+	var loopErr *ai.ToolLoopError
+	if !errors.As(err, &loopErr) {
+		t.Fatalf("error = %v, want ToolLoopError", err)
+	}
+	if kind := ai.ClassifyError(err); kind != ai.ErrorKindUnavailable {
+		t.Fatalf("kind = %s, want unavailable", kind)
+	}
+}
+
+func TestProvider_GenerateMultimodal(t *testing.T) {
+	var body map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"a cat"}]}`))
+	}))
+	defer ts.Close()
+
+	p := NewProvider(ai.WithAPIKey("test-key"), ai.WithBaseURL(ts.URL))
+	_, err := p.Generate(context.Background(), &ai.Request{
+		Messages: []ai.Message{{Role: "user", Content: []ai.Part{
+			ai.TextPart("compare"),
+			ai.ImagePart("image/jpeg", []byte("jpg")),
+			ai.FileURLPart("spec.pdf", "application/pdf", "https://example.com/spec.pdf"),
+		}}},
+	})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	blocks := body["messages"].([]any)[0].(map[string]any)["content"].([]any)
+	if len(blocks) != 3 {
+		t.Fatalf("content = %#v", blocks)
+	}
+	image := blocks[1].(map[string]any)
+	source := image["source"].(map[string]any)
+	if image["type"] != "image" || source["type"] != "base64" || source["media_type"] != "image/jpeg" || source["data"] != "anBn" {
+		t.Fatalf("image block = %#v", image)
+	}
+	doc := blocks[2].(map[string]any)
+	if doc["type"] != "document" || doc["title"] != "spec.pdf" || doc["source"].(map[string]any)["url"] != "https://example.com/spec.pdf" {
+		t.Fatalf("document block = %#v", doc)
+	}
+
+	// Anthropic takes no audio input
+	_, err = p.Generate(context.Background(), &ai.Request{Parts: []ai.Part{ai.AudioPart("audio/wav", []byte("wav"))}})
+	var unsupported *ai.UnsupportedContentError
+	if !errors.As(err, &unsupported) || unsupported.Type != ai.PartAudio {
+		t.Fatalf("error = %v, want unsupported audio", err)
+	}
+}
+
+func TestProvider_GeneratePromptCache(t *testing.T) {
+	var bodies []map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		bodies = append(bodies, body)
+		w.Header().Set("Content-Type", "application/json")
+		if len(bodies) == 1 {
+			_, _ = w.Write([]byte(`{"content":[{"type":"tool_use","id":"tu-1","name":"lookup","input":{}}],"usage":{"input_tokens":20,"output_tokens":5,"cache_creation_input_tokens":2000}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"done"}],"usage":{"input_tokens":30,"output_tokens":10,"cache_read_input_tokens":2000}}`))
+	}))
+	defer ts.Close()
+
+	p := NewProvider(
+		ai.WithAPIKey("test-key"),
+		ai.WithBaseURL(ts.URL),
+		ai.WithTemperature(0),
+		ai.WithStop("END"),
+		ai.WithSeed(7),
+		ai.WithPromptCache(""),
+		ai.WithToolHandler(func(ctx context.Context, call ai.ToolCall) ai.ToolResult {
+			return ai.ToolResult{ID: call.ID, Content: "ok"}
+		}),
+	)
+	resp, err := p.Generate(context.Background(), &ai.Request{
+		SystemPrompt: "You are support.",
+		Messages:     []ai.Message{{Role: "user", Content: "hi"}, {Role: "assistant", Content: "hello"}},
+		Prompt:       "look it up",
+		Tools:        []ai.Tool{{Name: "lookup"}},
+	})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	first := bodies[0]
+	if first["temperature"] != 0.0 || first["stop_sequences"].([]any)[0] != "END" {
+		t.Fatalf("params = %v, %v", first["temperature"], first["stop_sequences"])
+	}
+	if _, ok := first["seed"]; ok {
+		t.Fatal("sent seed, which anthropic doesn't support")
+	}
+	system := first["system"].([]any)[0].(map[string]any)
+	if system["text"] != "You are support." || system["cache_control"] == nil {
+		t.Fatalf("system = %#v", first["system"])
+	}
+	history := first["messages"].([]any)[1].(map[string]any)["content"].([]any)[0].(map[string]any)
+	if history["text"] != "hello" || history["cache_control"] == nil {
+		t.Fatalf("last history message = %#v", history)
+	}
+	if prompt := first["messages"].([]any)[2].(map[string]any); prompt["content"] != "look it up" {
+		t.Fatalf("prompt message = %#v", prompt)
+	}
+
+	// the follow-up also marks the tool round it replays
+	messages := bodies[1]["messages"].([]any)
+	results := messages[len(messages)-1].(map[string]any)["content"].([]any)
+	if results[0].(map[string]any)["cache_control"] == nil {
+		t.Fatalf("tool results = %#v", results)
+	}
+
+	want := ai.Usage{InputTokens: 4050, OutputTokens: 15, TotalTokens: 4065, CacheReadTokens: 2000, CacheWriteTokens: 2000}
+	if resp.Usage != want {
+		t.Fatalf("usage = %+v, want %+v", resp.Usage, want)
+	}
+}
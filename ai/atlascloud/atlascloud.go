@@ -33,6 +33,7 @@ import (
 	"time"
 
 	"go-micro.dev/v6/ai"
+	"go-micro.dev/v6/ai/internal/openaiapi"
 )
 
 func init() {
@@ -46,6 +47,7 @@ func init() {
 		return NewProvider(opts...)
 	})
 	ai.RegisterStream("atlascloud")
+	ai.RegisterParams("atlascloud", ai.ParamTemperature, ai.ParamTopP, ai.ParamStop)
 }
 
 // Provider implements the ai.Model interface for Atlas Cloud.
@@ -79,6 +81,10 @@ func NewProvider(opts ...ai.Option) *Provider {
 	if options.BaseURL == "" {
 		options.BaseURL = "https://api.atlascloud.ai"
 	}
+	if options.MaxToolRounds == 0 {
+		// follow-ups past a handful of tool rounds rarely converge
+		options.MaxToolRounds = 4
+	}
 
 	return &Provider{opts: options}
 }
@@ -94,22 +100,35 @@ func (p *Provider) Options() ai.Options { return p.opts }
 func (p *Provider) String() string      { return "atlascloud" }
 
 func (p *Provider) Generate(ctx context.Context, req *ai.Request, opts ...ai.GenerateOption) (*ai.Response, error) {
+	resp, err := ai.ToolLoop(ctx, p, req, p.opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.ToolCalls) > 0 && p.opts.ToolHandler != nil {
+		atlascloudToolAnswer(resp)
+	}
+	return resp, nil
+}
+
+// Turn makes a single chat completions call for req followed by the tool
+// rounds so far. The first turn retries with Minimax-compatible tools and
+// repairs partial text tool calls; follow-ups fall back to a request
+// without tools when the tool schema is rejected.
+func (p *Provider) Turn(ctx context.Context, req *ai.Request, rounds []ai.ToolRound) (*ai.Response, error) {
 	tools := atlascloudTools(req.Tools)
 	compatTools, compatPrompt := atlascloudMinimaxCompatTools(p.opts.Model, req.Tools)
 	textToolPrompt := atlascloudMinimaxTextToolPrompt(p.opts.Model, req.Tools)
 
-	messages := []map[string]any{
-		{"role": "system", "content": req.SystemPrompt},
-	}
-	for _, m := range req.Messages {
-		messages = append(messages, map[string]any{"role": m.Role, "content": m.Content})
-	}
-	if req.Prompt != "" {
-		messages = append(messages, map[string]any{"role": "user", "content": req.Prompt})
+	messages, err := openaiapi.Messages("atlascloud", req)
+	if err != nil {
+		return nil, err
 	}
 	if compatPrompt != "" {
 		messages = append(messages, map[string]any{"role": "system", "content": compatPrompt})
 	}
+	if len(rounds) > 0 {
+		return p.followUp(ctx, tools, append(messages, openaiapi.ToolMessages(rounds)...))
+	}
 
 	apiReq := map[string]any{
 		"model":    p.opts.Model,
@@ -118,21 +137,22 @@ func (p *Provider) Generate(ctx context.Context, req *ai.Request, opts ...ai.Gen
 	if p.opts.MaxTokens > 0 {
 		apiReq["max_tokens"] = p.opts.MaxTokens
 	}
+	openaiapi.SetParams(apiReq, ai.ProviderParams("atlascloud", p.opts.Params))
 
 	if len(tools) > 0 {
 		apiReq["tools"] = tools
 	}
 
-	resp, rawMessage, err := p.callAPI(ctx, "chat", apiReq)
+	resp, err := p.callAPI(ctx, "chat", apiReq)
 	if err != nil {
 		if atlascloudShouldRetryMinimaxCompat(err, compatTools) {
 			apiReq["tools"] = compatTools
-			resp, rawMessage, err = p.callAPI(ctx, "chat-minimax-compat", apiReq)
+			resp, err = p.callAPI(ctx, "chat-minimax-compat", apiReq)
 		}
 		if atlascloudShouldRetryMinimaxTextTools(err, textToolPrompt) {
 			delete(apiReq, "tools")
 			apiReq["messages"] = append(messages, map[string]any{"role": "system", "content": textToolPrompt})
-			resp, rawMessage, err = p.callAPI(ctx, "chat-minimax-text-tools", apiReq)
+			resp, err = p.callAPI(ctx, "chat-minimax-text-tools", apiReq)
 		}
 		if err != nil {
 			return nil, err
@@ -150,10 +170,11 @@ func (p *Provider) Generate(ctx context.Context, req *ai.Request, opts ...ai.Gen
 		if p.opts.MaxTokens > 0 {
 			repairReq["max_tokens"] = p.opts.MaxTokens
 		}
+		openaiapi.SetParams(repairReq, ai.ProviderParams("atlascloud", p.opts.Params))
 		if len(tools) > 0 {
 			repairReq["tools"] = tools
 		}
-		resp, rawMessage, err = p.callAPI(ctx, "chat-partial-tool-repair", repairReq)
+		resp, err = p.callAPI(ctx, "chat-partial-tool-repair", repairReq)
 		if err != nil {
 			return nil, fmt.Errorf("atlascloud partial text tool-call repair failed for %q: %w", toolName, err)
 		}
@@ -166,90 +187,53 @@ func (p *Provider) Generate(ctx context.Context, req *ai.Request, opts ...ai.Gen
 		}
 	}
 
-	if len(resp.ToolCalls) == 0 {
-		return resp, nil
-	}
-
-	if p.opts.ToolHandler != nil {
-		var allToolCalls []ai.ToolCall
-		var toolResults []string
-		pendingToolCalls := append([]ai.ToolCall(nil), resp.ToolCalls...)
-		followUpMessages := append(messages, map[string]any{
-			"role":       "assistant",
-			"content":    rawMessage["content"],
-			"tool_calls": rawMessage["tool_calls"],
-		})
-
-		for attempt := 0; len(pendingToolCalls) > 0 && attempt < 4; attempt++ {
-			for _, tc := range pendingToolCalls {
-				result := p.opts.ToolHandler(ctx, tc)
-				if result.Refused != "" {
-					tc.Error = result.Refused
-				}
-				if result.Content != "" {
-					tc.Result = result.Content
-					toolResults = append(toolResults, result.Content)
-				}
-				allToolCalls = append(allToolCalls, tc)
-				resp.ToolCalls = allToolCalls
-				followUpMessages = append(followUpMessages, map[string]any{
-					"role":         "tool",
-					"tool_call_id": tc.ID,
-					"content":      result.Content,
-				})
-			}
+	return resp, nil
+}
 
-			followUpReq := map[string]any{
-				"model":    p.opts.Model,
-				"messages": followUpMessages,
-			}
-			if len(tools) > 0 {
-				// Keep the tool schema available during follow-up turns. Minimax
-				// models behind Atlas Cloud sometimes complete a multi-tool task
-				// one call at a time (plan, then service tools, then delegate).
-				followUpReq["tools"] = tools
-			}
+func (p *Provider) followUp(ctx context.Context, tools []map[string]any, messages []map[string]any) (*ai.Response, error) {
+	followUpReq := map[string]any{
+		"model":    p.opts.Model,
+		"messages": messages,
+	}
+	openaiapi.SetParams(followUpReq, ai.ProviderParams("atlascloud", p.opts.Params))
+	if len(tools) > 0 {
+		// Keep the tool schema available during follow-up turns. Minimax
+		// models behind Atlas Cloud sometimes complete a multi-tool task
+		// one call at a time (plan, then service tools, then delegate).
+		followUpReq["tools"] = tools
+	}
 
-			followUpResp, followUpRawMessage, err := p.callAPI(ctx, "tool-follow-up", followUpReq)
-			if err != nil {
-				if atlascloudShouldRetryWithoutTools(err, followUpReq) {
-					delete(followUpReq, "tools")
-					followUpReq["messages"] = atlascloudFollowUpMessagesWithoutTools(p.opts.Model, followUpMessages)
-					followUpResp, followUpRawMessage, err = p.callAPI(ctx, "tool-follow-up-no-tools", followUpReq)
-				}
-				if err != nil {
-					return nil, err
-				}
-			}
-			if len(followUpResp.ToolCalls) == 0 {
-				if followUpResp.Reply != "" {
-					if strings.Contains(followUpResp.Reply, "<tool_call") || strings.Contains(followUpResp.Reply, "function=") {
-						// Preserve follow-up assistant content as Reply, not Answer, when
-						// it may contain a text-encoded tool call. The agent harness
-						// inspects Reply for text fallback calls after Generate returns.
-						resp.Reply = followUpResp.Reply
-						if len(toolResults) > 0 {
-							resp.Answer = strings.Join(toolResults, "\n")
-						}
-					} else {
-						resp.Answer = atlascloudAnswerWithRequiredToolMarkers(followUpResp.Reply, toolResults, allToolCalls)
-					}
-				} else if len(toolResults) > 0 {
-					resp.Answer = strings.Join(toolResults, "\n")
-				}
-				break
-			}
+	resp, err := p.callAPI(ctx, "tool-follow-up", followUpReq)
+	if err != nil && atlascloudShouldRetryWithoutTools(err, followUpReq) {
+		delete(followUpReq, "tools")
+		followUpReq["messages"] = atlascloudFollowUpMessagesWithoutTools(p.opts.Model, messages)
+		resp, err = p.callAPI(ctx, "tool-follow-up-no-tools", followUpReq)
+	}
+	return resp, err
+}
 
-			followUpMessages = append(followUpMessages, map[string]any{
-				"role":       "assistant",
-				"content":    followUpRawMessage["content"],
-				"tool_calls": followUpRawMessage["tool_calls"],
-			})
-			pendingToolCalls = followUpResp.ToolCalls
+// atlascloudToolAnswer settles the answer once tools have run, falling
+// back to the tool results when the final reply is empty.
+func atlascloudToolAnswer(resp *ai.Response) {
+	var toolResults []string
+	for _, call := range resp.ToolCalls {
+		if call.Result != "" {
+			toolResults = append(toolResults, call.Result)
 		}
 	}
 
-	return resp, nil
+	switch {
+	case strings.Contains(resp.Answer, "<tool_call") || strings.Contains(resp.Answer, "function="):
+		// Preserve follow-up assistant content as Reply, not Answer, when
+		// it may contain a text-encoded tool call. The agent harness
+		// inspects Reply for text fallback calls after Generate returns.
+		resp.Reply = resp.Answer
+		resp.Answer = strings.Join(toolResults, "\n")
+	case resp.Answer != "":
+		resp.Answer = atlascloudAnswerWithRequiredToolMarkers(resp.Answer, toolResults, resp.ToolCalls)
+	default:
+		resp.Answer = strings.Join(toolResults, "\n")
+	}
 }
 
 func atlascloudAnswerWithRequiredToolMarkers(answer string, toolResults []string, toolCalls []ai.ToolCall) string {
@@ -280,14 +264,9 @@ func (p *Provider) Stream(ctx context.Context, req *ai.Request, opts ...ai.Gener
 		return nil, fmt.Errorf("%w: atlascloud streaming does not expose tools", ai.ErrStreamingUnsupported)
 	}
 
-	messages := []map[string]any{
-		{"role": "system", "content": req.SystemPrompt},
-	}
-	for _, m := range req.Messages {
-		messages = append(messages, map[string]any{"role": m.Role, "content": m.Content})
-	}
-	if req.Prompt != "" {
-		messages = append(messages, map[string]any{"role": "user", "content": req.Prompt})
+	messages, err := openaiapi.Messages("atlascloud", req)
+	if err != nil {
+		return nil, err
 	}
 	apiReq := map[string]any{
 		"model":          p.opts.Model,
@@ -298,6 +277,7 @@ func (p *Provider) Stream(ctx context.Context, req *ai.Request, opts ...ai.Gener
 	if p.opts.MaxTokens > 0 {
 		apiReq["max_tokens"] = p.opts.MaxTokens
 	}
+	openaiapi.SetParams(apiReq, ai.ProviderParams("atlascloud", p.opts.Params))
 	reqBody, err := json.Marshal(apiReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal stream request: %w", err)
@@ -411,16 +391,16 @@ func (e *atlascloudAPIError) RetryAfter() time.Duration {
 	return e.Retry
 }
 
-func (p *Provider) callAPI(ctx context.Context, phase string, req map[string]any) (*ai.Response, map[string]any, error) {
+func (p *Provider) callAPI(ctx context.Context, phase string, req map[string]any) (*ai.Response, error) {
 	reqBody, err := json.Marshal(req)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	apiURL := strings.TrimRight(p.opts.BaseURL, "/") + "/v1/chat/completions"
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(reqBody))
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
@@ -428,7 +408,7 @@ func (p *Provider) callAPI(ctx context.Context, phase string, req map[string]any
 
 	httpResp, err := http.DefaultClient.Do(httpReq)
 	if err != nil {
-		return nil, nil, fmt.Errorf("API request failed: %w", err)
+		return nil, fmt.Errorf("API request failed: %w", err)
 	}
 	defer httpResp.Body.Close()
 
@@ -439,7 +419,7 @@ func (p *Provider) callAPI(ctx context.Context, phase string, req map[string]any
 		if errors.As(ai.NewHTTPError(httpResp, respBody), &retryErr) {
 			retryAfter = retryErr.RetryAfter()
 		}
-		return nil, nil, &atlascloudAPIError{Status: httpResp.Status, Code: httpResp.StatusCode, Retry: retryAfter, Phase: phase, Summary: atlascloudRequestSummary(req), Body: string(respBody)}
+		return nil, &atlascloudAPIError{Status: httpResp.Status, Code: httpResp.StatusCode, Retry: retryAfter, Phase: phase, Summary: atlascloudRequestSummary(req), Body: string(respBody)}
 	}
 
 	var chatResp struct {
@@ -452,11 +432,11 @@ func (p *Provider) callAPI(ctx context.Context, phase string, req map[string]any
 	}
 
 	if err := json.Unmarshal(respBody, &chatResp); err != nil {
-		return nil, nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	if len(chatResp.Choices) == 0 {
-		return nil, nil, fmt.Errorf("no response from API")
+		return nil, fmt.Errorf("no response from API")
 	}
 
 	choice := chatResp.Choices[0]
@@ -476,12 +456,7 @@ func (p *Provider) callAPI(ctx context.Context, phase string, req map[string]any
 		})
 	}
 
-	rawMessage := map[string]any{
-		"content":    choice.Message.Content,
-		"tool_calls": normalizeAtlasCloudToolCalls(choice.Message.ToolCalls),
-	}
-
-	return response, rawMessage, nil
+	return response, nil
 }
 
 func atlascloudFollowUpMessagesWithoutTools(model string, messages []map[string]any) []map[string]any {
@@ -704,12 +679,7 @@ func atlascloudRequestText(req *ai.Request) string {
 		parts = append(parts, req.SystemPrompt)
 	}
 	for _, msg := range req.Messages {
-		switch c := msg.Content.(type) {
-		case string:
-			parts = append(parts, c)
-		default:
-			parts = append(parts, fmt.Sprint(c))
-		}
+		parts = append(parts, ai.ContentText(msg.Content))
 	}
 	if req.Prompt != "" {
 		parts = append(parts, req.Prompt)
@@ -840,25 +810,6 @@ func normalizeAtlasCloudSchemaValue(v any) any {
 	}
 }
 
-func normalizeAtlasCloudToolCalls(toolCalls []atlasToolCall) []map[string]any {
-	out := make([]map[string]any, 0, len(toolCalls))
-	for _, tc := range toolCalls {
-		toolType := tc.Type
-		if toolType == "" {
-			toolType = "function"
-		}
-		out = append(out, map[string]any{
-			"id":   tc.ID,
-			"type": toolType,
-			"function": map[string]any{
-				"name":      tc.Function.Name,
-				"arguments": tc.Function.Arguments,
-			},
-		})
-	}
-	return out
-}
-
 func atlascloudRequestSummary(req map[string]any) string {
 	parts := []string{}
 	if model, ok := req["model"].(string); ok && model != "" {
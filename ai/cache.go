@@ -0,0 +1,413 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-micro.dev/v6/cache"
+)
+
+// DefaultCacheThreshold is the cosine similarity at or above which a
+// semantic cache serves a stored response for a new prompt.
+const DefaultCacheThreshold = 0.95
+
+// DefaultCacheEntries bounds how many prompt embeddings a semantic cache
+// keeps to compare new prompts against.
+const DefaultCacheEntries = 1000
+
+// Embedder turns text into a vector, for semantic caching. Vectors from
+// one Embedder must have the same length.
+type Embedder func(ctx context.Context, text string) ([]float64, error)
+
+// CacheOptions configures a CachedModel.
+type CacheOptions struct {
+	// Cache stores the responses. The default is an in-memory cache;
+	// share a cache/redis cache to share responses between processes.
+	Cache cache.Cache
+	// TTL is how long a response is served (0 = until the cache evicts it).
+	TTL time.Duration
+	// Prefix namespaces the cache keys (default "ai:cache:").
+	Prefix string
+	// Embedder, when set, turns on semantic mode: a prompt whose
+	// embedding is at least Threshold similar to a cached prompt's, with
+	// the same system prompt, tools and history, gets its response.
+	Embedder Embedder
+	// Threshold is the semantic mode's cosine similarity cutoff
+	// (0 = DefaultCacheThreshold).
+	Threshold float64
+	// MaxEntries bounds the semantic mode's embeddings, which are kept
+	// in process (0 = DefaultCacheEntries).
+	MaxEntries int
+	// SafeTools names tools without side effects. A turn in which the
+	// model's tool handler ran any other tool is never cached, since
+	// serving it again would skip the tool's effects.
+	SafeTools []string
+}
+
+// CacheOption configures a CachedModel.
+type CacheOption func(*CacheOptions)
+
+// CacheStore sets the cache the responses are kept in.
+func CacheStore(c cache.Cache) CacheOption {
+	return func(o *CacheOptions) { o.Cache = c }
+}
+
+// CacheTTL sets how long a cached response is served.
+func CacheTTL(d time.Duration) CacheOption {
+	return func(o *CacheOptions) { o.TTL = d }
+}
+
+// CachePrefix sets the namespace of the cache keys.
+func CachePrefix(p string) CacheOption {
+	return func(o *CacheOptions) { o.Prefix = p }
+}
+
+// CacheSemantic turns on semantic mode: prompts whose embeddings are at
+// least threshold similar share a response. A threshold of 0 uses
+// DefaultCacheThreshold.
+func CacheSemantic(e Embedder, threshold float64) CacheOption {
+	return func(o *CacheOptions) {
+		o.Embedder = e
+		o.Threshold = threshold
+	}
+}
+
+// CacheMaxEntries bounds how many prompt embeddings semantic mode keeps.
+func CacheMaxEntries(n int) CacheOption {
+	return func(o *CacheOptions) { o.MaxEntries = n }
+}
+
+// CacheSafeTools names tools without side effects, whose turns may be
+// cached.
+func CacheSafeTools(names ...string) CacheOption {
+	return func(o *CacheOptions) { o.SafeTools = append(o.SafeTools, names...) }
+}
+
+// CacheStats counts a CachedModel's lookups.
+type CacheStats struct {
+	// Hits are requests served from the cache, SemanticHits the ones
+	// among them matched by embedding rather than exactly.
+	Hits         int64 `json:"hits"`
+	SemanticHits int64 `json:"semantic_hits"`
+	// Misses are requests sent to the model.
+	Misses int64 `json:"misses"`
+	// Bypassed are requests that skipped the cache: streams, and calls
+	// whose context was marked with NoCache.
+	Bypassed int64 `json:"bypassed"`
+	// Uncacheable are misses whose response wasn't stored because the
+	// turn ran a tool with side effects.
+	Uncacheable int64 `json:"uncacheable"`
+	// Errors are cache and embedder failures; the request still went
+	// to the model.
+	Errors int64 `json:"errors"`
+}
+
+// HitRate is Hits over the requests that looked the cache up.
+func (s CacheStats) HitRate() float64 {
+	if n := s.Hits + s.Misses; n > 0 {
+		return float64(s.Hits) / float64(n)
+	}
+	return 0
+}
+
+type noCacheKey struct{}
+
+// NoCache marks ctx so a CachedModel neither serves nor stores the
+// response of the calls made with it.
+func NoCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCacheKey{}, true)
+}
+
+// CachedModel is a Model that serves repeated requests from a cache.
+// Requests are keyed on a hash of the provider, model, system prompt,
+// prompt, history and tools, with whitespace normalized; semantic mode
+// also matches prompts by embedding similarity. A cached response has
+// Cached set and no Usage, since no tokens were spent on it.
+type CachedModel struct {
+	Model
+	opts CacheOptions
+	safe map[string]bool
+
+	mu      sync.Mutex
+	entries []cacheEntry
+
+	hits, semanticHits, misses, bypassed, uncacheable, errs atomic.Int64
+}
+
+// cacheEntry is a cached prompt's embedding, for semantic lookups.
+type cacheEntry struct {
+	scope  string
+	key    string
+	vector []float64
+}
+
+// Cached wraps m with a response cache.
+func Cached(m Model, opts ...CacheOption) *CachedModel {
+	o := CacheOptions{Prefix: "ai:cache:"}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.Cache == nil {
+		o.Cache = cache.NewCache()
+	}
+	if o.Threshold <= 0 {
+		o.Threshold = DefaultCacheThreshold
+	}
+	if o.MaxEntries <= 0 {
+		o.MaxEntries = DefaultCacheEntries
+	}
+	c := &CachedModel{Model: m, opts: o, safe: map[string]bool{}}
+	for _, name := range o.SafeTools {
+		c.safe[name] = true
+	}
+	return c
+}
+
+// Stats returns the cache's counts so far.
+func (c *CachedModel) Stats() CacheStats {
+	return CacheStats{
+		Hits:         c.hits.Load(),
+		SemanticHits: c.semanticHits.Load(),
+		Misses:       c.misses.Load(),
+		Bypassed:     c.bypassed.Load(),
+		Uncacheable:  c.uncacheable.Load(),
+		Errors:       c.errs.Load(),
+	}
+}
+
+// Generate serves req from the cache, or generates and caches it.
+func (c *CachedModel) Generate(ctx context.Context, req *Request, opts ...GenerateOption) (*Response, error) {
+	if skip, _ := ctx.Value(noCacheKey{}).(bool); skip {
+		c.bypassed.Add(1)
+		return c.Model.Generate(ctx, req, opts...)
+	}
+	key, scope, err := c.keys(req)
+	if err != nil {
+		c.errs.Add(1)
+		return c.Model.Generate(ctx, req, opts...)
+	}
+	if resp, ok := c.get(ctx, key); ok {
+		c.hits.Add(1)
+		return resp, nil
+	}
+	var vector []float64
+	if text := c.semanticText(req); text != "" {
+		if vector, err = c.opts.Embedder(ctx, text); err != nil {
+			c.errs.Add(1)
+			vector = nil
+		} else if resp, ok := c.nearest(ctx, scope, vector); ok {
+			c.hits.Add(1)
+			c.semanticHits.Add(1)
+			return resp, nil
+		}
+	}
+
+	c.misses.Add(1)
+	resp, err := c.Model.Generate(ctx, req, opts...)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	if !c.cacheable(resp) {
+		c.uncacheable.Add(1)
+		return resp, nil
+	}
+	b, err := json.Marshal(resp)
+	if err == nil {
+		err = c.opts.Cache.Put(ctx, key, b, c.opts.TTL)
+	}
+	if err != nil {
+		c.errs.Add(1)
+		return resp, nil
+	}
+	if vector != nil {
+		c.remember(cacheEntry{scope: scope, key: key, vector: vector})
+	}
+	return resp, nil
+}
+
+// Stream isn't cached.
+func (c *CachedModel) Stream(ctx context.Context, req *Request, opts ...GenerateOption) (Stream, error) {
+	c.bypassed.Add(1)
+	return c.Model.Stream(ctx, req, opts...)
+}
+
+// cacheable reports whether resp may be served again: its turn ran no
+// tool with side effects.
+func (c *CachedModel) cacheable(resp *Response) bool {
+	if len(resp.ToolCalls) == 0 || c.Model.Options().ToolHandler == nil {
+		// without a handler tool calls are only proposed, never run
+		return true
+	}
+	for _, call := range resp.ToolCalls {
+		if !c.safe[call.Name] {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *CachedModel) get(ctx context.Context, key string) (*Response, bool) {
+	v, _, err := c.opts.Cache.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, cache.ErrKeyNotFound) && !errors.Is(err, cache.ErrItemExpired) {
+			c.errs.Add(1)
+		}
+		return nil, false
+	}
+	var b []byte
+	switch v := v.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return nil, false
+	}
+	var resp Response
+	if err := json.Unmarshal(b, &resp); err != nil {
+		c.errs.Add(1)
+		return nil, false
+	}
+	resp.Cached = true
+	resp.Usage = Usage{}
+	return &resp, true
+}
+
+// semanticText is the prompt text semantic mode embeds, or "" when it
+// isn't on or the prompt carries media.
+func (c *CachedModel) semanticText(req *Request) string {
+	if c.opts.Embedder == nil || HasMedia(req.Parts) {
+		return ""
+	}
+	if len(req.Parts) > 0 {
+		return normalizeSpace(ContentText(req.Parts))
+	}
+	return normalizeSpace(req.Prompt)
+}
+
+// nearest serves the response of the most similar cached prompt in scope.
+func (c *CachedModel) nearest(ctx context.Context, scope string, vector []float64) (*Response, bool) {
+	c.mu.Lock()
+	best, bestKey := c.opts.Threshold, ""
+	for _, e := range c.entries {
+		if e.scope != scope {
+			continue
+		}
+		if sim := cosine(vector, e.vector); sim >= best {
+			best, bestKey = sim, e.key
+		}
+	}
+	c.mu.Unlock()
+	if bestKey == "" {
+		return nil, false
+	}
+	resp, ok := c.get(ctx, bestKey)
+	if !ok {
+		c.forget(bestKey)
+	}
+	return resp, ok
+}
+
+func (c *CachedModel) remember(e cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = append(c.entries, e)
+	if n := len(c.entries) - c.opts.MaxEntries; n > 0 {
+		c.entries = append(c.entries[:0:0], c.entries[n:]...)
+	}
+}
+
+func (c *CachedModel) forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, e := range c.entries {
+		if e.key == key {
+			c.entries = append(c.entries[:i], c.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// cacheRequest is the normalized form of a request that's hashed into
+// its cache key.
+type cacheRequest struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// Params and MaxTokens change the answer, so requests that differ
+	// in them don't share an entry.
+	Params    Params    `json:"params"`
+	MaxTokens int       `json:"max_tokens,omitempty"`
+	System    string    `json:"system"`
+	Prompt    string    `json:"prompt"`
+	Parts     []Part    `json:"parts,omitempty"`
+	Messages  []Message `json:"messages,omitempty"`
+	Tools     []Tool    `json:"tools,omitempty"`
+}
+
+// keys returns the cache key of req and its semantic scope: the hash of
+// everything but the prompt.
+func (c *CachedModel) keys(req *Request) (key, scope string, err error) {
+	opts := c.Model.Options()
+	r := cacheRequest{
+		Provider:  c.Model.String(),
+		Model:     opts.Model,
+		Params:    opts.Params,
+		MaxTokens: opts.MaxTokens,
+		System:    normalizeSpace(req.SystemPrompt),
+		Tools:     append([]Tool(nil), req.Tools...),
+	}
+	// prompt caching changes what the provider keeps, not what it answers
+	r.Params.PromptCache, r.Params.PromptCacheKey = false, ""
+	sort.Slice(r.Tools, func(i, j int) bool { return r.Tools[i].Name < r.Tools[j].Name })
+	for _, m := range req.Messages {
+		if s, ok := m.Content.(string); ok {
+			m.Content = normalizeSpace(s)
+		}
+		r.Messages = append(r.Messages, m)
+	}
+	if scope, err = c.hash(r); err != nil {
+		return "", "", err
+	}
+	r.Prompt, r.Parts = normalizeSpace(req.Prompt), req.Parts
+	key, err = c.hash(r)
+	return key, scope, err
+}
+
+func (c *CachedModel) hash(r cacheRequest) (string, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return c.opts.Prefix + hex.EncodeToString(sum[:]), nil
+}
+
+// normalizeSpace trims s and collapses its runs of whitespace.
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func cosine(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,131 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/cache"
+)
+
+func TestCachedModelExact(t *testing.T) {
+	ctx := context.Background()
+	m := &fakeModel{opts: Options{Model: "m1"}, gen: func() (*Response, error) {
+		return &Response{Reply: "hello", Usage: Usage{TotalTokens: 12}}, nil
+	}}
+	c := Cached(m)
+
+	first, err := c.Generate(ctx, &Request{SystemPrompt: "Be brief.", Prompt: "Say  hello\n"})
+	if err != nil || first.Cached || first.Usage.TotalTokens != 12 {
+		t.Fatalf("first = %+v, %v", first, err)
+	}
+	second, err := c.Generate(ctx, &Request{SystemPrompt: " Be brief.", Prompt: "Say hello"})
+	if err != nil || !second.Cached || second.Reply != "hello" || second.Usage.TotalTokens != 0 {
+		t.Fatalf("second = %+v, %v", second, err)
+	}
+	if _, err := c.Generate(ctx, &Request{SystemPrompt: "Be verbose.", Prompt: "Say hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Generate(NoCache(ctx), &Request{SystemPrompt: "Be brief.", Prompt: "Say hello"}); err != nil {
+		t.Fatal(err)
+	}
+	if m.calls != 3 {
+		t.Fatalf("model calls = %d, want 3", m.calls)
+	}
+	if s := c.Stats(); s.Hits != 1 || s.Misses != 2 || s.Bypassed != 1 || s.HitRate() != 1.0/3 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
+
+func TestCachedModelKeysOnParams(t *testing.T) {
+	ctx := context.Background()
+	m := &fakeModel{opts: Options{Model: "m1"}, gen: reply("hello")}
+	c := Cached(m)
+	req := &Request{Prompt: "Say hello"}
+
+	hot := 1.0
+	for _, opt := range []Option{
+		WithParams(Params{}),
+		WithParams(Params{Temperature: &hot}),
+		WithMaxTokens(10),
+		WithParams(Params{Temperature: &hot, Stop: []string{"."}}),
+	} {
+		m.Init(opt)
+		if resp, err := c.Generate(ctx, req); err != nil || resp.Cached {
+			t.Fatalf("after %+v: %+v, %v, want a miss", m.opts.Params, resp, err)
+		}
+	}
+	// prompt caching doesn't change the answer
+	m.Init(WithParams(Params{Temperature: &hot, Stop: []string{"."}, PromptCache: true, PromptCacheKey: "support"}))
+	if resp, err := c.Generate(ctx, req); err != nil || !resp.Cached {
+		t.Fatalf("with prompt caching: %+v, %v, want a hit", resp, err)
+	}
+}
+
+func TestCachedModelTTL(t *testing.T) {
+	ctx := context.Background()
+	m := &fakeModel{gen: reply("hi")}
+	c := Cached(m, CacheStore(cache.NewCache()), CacheTTL(20*time.Millisecond))
+	req := &Request{Prompt: "hi"}
+	c.Generate(ctx, req)
+	c.Generate(ctx, req)
+	time.Sleep(30 * time.Millisecond)
+	c.Generate(ctx, req)
+	if m.calls != 2 {
+		t.Fatalf("model calls = %d, want the expired response regenerated", m.calls)
+	}
+}
+
+func TestCachedModelSkipsSideEffectTurns(t *testing.T) {
+	ctx := context.Background()
+	m := &fakeModel{gen: func() (*Response, error) {
+		return &Response{Reply: "done", ToolCalls: []ToolCall{{Name: "orders_Refund"}}}, nil
+	}}
+	m.Init(WithToolHandler(func(context.Context, ToolCall) ToolResult { return ToolResult{} }))
+	c := Cached(m)
+	req := &Request{Prompt: "refund order 42"}
+	c.Generate(ctx, req)
+	c.Generate(ctx, req)
+	if m.calls != 2 || c.Stats().Uncacheable != 2 {
+		t.Fatalf("calls = %d, stats = %+v: a refund turn was served from the cache", m.calls, c.Stats())
+	}
+
+	safe := Cached(m, CacheSafeTools("orders_Refund"))
+	safe.Generate(ctx, req)
+	safe.Generate(ctx, req)
+	if m.calls != 3 {
+		t.Fatalf("calls = %d, want a safe tool's turn cached", m.calls)
+	}
+}
+
+func TestCachedModelSemantic(t *testing.T) {
+	ctx := context.Background()
+	// embed by whether the prompt is about weather or billing
+	embed := func(_ context.Context, text string) ([]float64, error) {
+		v := []float64{0.01, 0.01}
+		if strings.Contains(text, "weather") {
+			v[0] = 1
+		}
+		if strings.Contains(text, "bill") {
+			v[1] = 1
+		}
+		return v, nil
+	}
+	m := &fakeModel{gen: reply("sunny")}
+	c := Cached(m, CacheSemantic(embed, 0.9))
+
+	c.Generate(ctx, &Request{SystemPrompt: "s", Prompt: "what's the weather in Paris"})
+	resp, _ := c.Generate(ctx, &Request{SystemPrompt: "s", Prompt: "Paris weather today?"})
+	if !resp.Cached {
+		t.Fatal("similar prompt missed the semantic cache")
+	}
+	c.Generate(ctx, &Request{SystemPrompt: "s", Prompt: "why is my bill so high"})
+	c.Generate(ctx, &Request{SystemPrompt: "other", Prompt: "weather in Paris?"})
+	if m.calls != 3 {
+		t.Fatalf("model calls = %d, want 3", m.calls)
+	}
+	if s := c.Stats(); s.Hits != 1 || s.SemanticHits != 1 || s.Misses != 3 {
+		t.Fatalf("stats = %+v", s)
+	}
+}
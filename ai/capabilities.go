@@ -27,15 +27,36 @@ type Capabilities struct {
 	// include tool schemas. Providers may support plain token streaming while
 	// leaving this false when their streaming API cannot accept tools.
 	ToolStream bool `json:"tool_stream"`
+	// ImageInput, AudioInput and FileInput report whether the provider
+	// sends image, audio and file Parts to the model in its native format.
+	// Providers reject parts they can't take with an UnsupportedContentError.
+	ImageInput bool `json:"image_input"`
+	AudioInput bool `json:"audio_input"`
+	FileInput  bool `json:"file_input"`
+	// Temperature, TopP, Stop, Seed, ReasoningEffort and PromptCache
+	// report which Params the provider sends. Params a provider doesn't
+	// support are dropped rather than failing the request.
+	Temperature     bool `json:"temperature"`
+	TopP            bool `json:"top_p"`
+	Stop            bool `json:"stop"`
+	Seed            bool `json:"seed"`
+	ReasoningEffort bool `json:"reasoning_effort"`
+	PromptCache     bool `json:"prompt_cache"`
 }
 
 // ProviderCapabilities reports the capabilities registered for provider.
 func ProviderCapabilities(provider string) Capabilities {
+	if fn, ok := derivedCapabilities[provider]; ok {
+		return fn()
+	}
 	_, hasModel := providers[provider]
 	_, hasImage := imageProviders[provider]
 	_, hasVideo := videoProviders[provider]
 	_, hasStream := streamProviders[provider]
 	_, hasToolStream := toolStreamProviders[provider]
+	_, hasImageInput := inputProviders[PartImage][provider]
+	_, hasAudioInput := inputProviders[PartAudio][provider]
+	_, hasFileInput := inputProviders[PartFile][provider]
 
 	return Capabilities{
 		Model:      hasModel,
@@ -43,6 +64,16 @@ func ProviderCapabilities(provider string) Capabilities {
 		Video:      hasVideo,
 		Stream:     hasStream,
 		ToolStream: hasToolStream,
+		ImageInput: hasImageInput,
+		AudioInput: hasAudioInput,
+		FileInput:  hasFileInput,
+
+		Temperature:     supportsParam(provider, ParamTemperature),
+		TopP:            supportsParam(provider, ParamTopP),
+		Stop:            supportsParam(provider, ParamStop),
+		Seed:            supportsParam(provider, ParamSeed),
+		ReasoningEffort: supportsParam(provider, ParamReasoningEffort),
+		PromptCache:     supportsParam(provider, ParamPromptCache),
 	}
 }
 
@@ -67,6 +98,16 @@ func CapabilityMatrix() map[string]Capabilities {
 	for name := range toolStreamProviders {
 		names[name] = struct{}{}
 	}
+	for _, registry := range inputProviders {
+		for name := range registry {
+			names[name] = struct{}{}
+		}
+	}
+	for _, registry := range paramProviders {
+		for name := range registry {
+			names[name] = struct{}{}
+		}
+	}
 
 	matrix := make(map[string]Capabilities, len(names))
 	for name := range names {
@@ -104,12 +145,29 @@ func RegisterToolStream(provider string) {
 	toolStreamProviders[provider] = struct{}{}
 }
 
+// RegisterInput records that provider sends parts of the given types to the
+// model in its native format. Text is always supported and needn't be
+// registered.
+func RegisterInput(provider string, types ...PartType) {
+	for _, t := range types {
+		if inputProviders[t] == nil {
+			inputProviders[t] = make(map[string]struct{})
+		}
+		inputProviders[t][provider] = struct{}{}
+	}
+}
+
+// derivedCapabilities holds providers whose capabilities are those of
+// other providers, such as the router, which has its routes'.
+var derivedCapabilities = make(map[string]func() Capabilities)
+
 var streamProviders = make(map[string]struct{})
 var toolStreamProviders = make(map[string]struct{})
+var inputProviders = make(map[PartType]map[string]struct{})
 
 // RegisteredProviders returns the registered provider names in sorted order.
-// kind may be "model", "image", "video", "stream", "tool_stream", or empty for the union of all
-// provider registries.
+// kind may be "model", "image", "video", "stream", "tool_stream", "image_input",
+// "audio_input", "file_input", or empty for the union of all provider registries.
 func RegisteredProviders(kind string) []string {
 	names := map[string]struct{}{}
 	add := func(registry any) {
@@ -144,11 +202,22 @@ func RegisteredProviders(kind string) []string {
 		add(imageProviders)
 	case "video":
 		add(videoProviders)
+	case "image_input":
+		add(inputProviders[PartImage])
+	case "audio_input":
+		add(inputProviders[PartAudio])
+	case "file_input":
+		add(inputProviders[PartFile])
 	default:
 		add(providers)
 		add(imageProviders)
 		add(videoProviders)
 	}
+	for name, fn := range derivedCapabilities {
+		if hasCapability(fn(), kind) {
+			names[name] = struct{}{}
+		}
+	}
 
 	out := make([]string, 0, len(names))
 	for name := range names {
@@ -157,3 +226,48 @@ func RegisteredProviders(kind string) []string {
 	sort.Strings(out)
 	return out
 }
+
+// hasCapability reports whether c has the capability RegisteredProviders
+// calls kind.
+func hasCapability(c Capabilities, kind string) bool {
+	switch kind {
+	case "model":
+		return c.Model
+	case "stream":
+		return c.Stream
+	case "tool_stream":
+		return c.ToolStream
+	case "image":
+		return c.Image
+	case "video":
+		return c.Video
+	case "image_input":
+		return c.ImageInput
+	case "audio_input":
+		return c.AudioInput
+	case "file_input":
+		return c.FileInput
+	default:
+		return c.Model || c.Image || c.Video
+	}
+}
+
+// unionCapabilities returns the capabilities either a or b has.
+func unionCapabilities(a, b Capabilities) Capabilities {
+	return Capabilities{
+		Model:           a.Model || b.Model,
+		Image:           a.Image || b.Image,
+		Video:           a.Video || b.Video,
+		Stream:          a.Stream || b.Stream,
+		ToolStream:      a.ToolStream || b.ToolStream,
+		ImageInput:      a.ImageInput || b.ImageInput,
+		AudioInput:      a.AudioInput || b.AudioInput,
+		FileInput:       a.FileInput || b.FileInput,
+		Temperature:     a.Temperature || b.Temperature,
+		TopP:            a.TopP || b.TopP,
+		Stop:            a.Stop || b.Stop,
+		Seed:            a.Seed || b.Seed,
+		ReasoningEffort: a.ReasoningEffort || b.ReasoningEffort,
+		PromptCache:     a.PromptCache || b.PromptCache,
+	}
+}
@@ -17,7 +17,7 @@ import (
 
 func TestRegisteredProviders(t *testing.T) {
 	got := ai.RegisteredProviders("")
-	want := []string{"anthropic", "atlascloud", "gemini", "groq", "minimax", "mistral", "openai", "together"}
+	want := []string{"anthropic", "atlascloud", "gemini", "groq", "minimax", "mistral", "openai", "router", "together"}
 	if !reflect.DeepEqual(got, want) {
 		t.Fatalf("RegisteredProviders() = %#v, want %#v", got, want)
 	}
@@ -35,23 +35,33 @@ func TestRegisteredProviders(t *testing.T) {
 	}
 
 	got = ai.RegisteredProviders("stream")
-	want = []string{"anthropic", "atlascloud", "gemini", "groq", "minimax", "mistral", "openai", "together"}
+	want = []string{"anthropic", "atlascloud", "gemini", "groq", "minimax", "mistral", "openai", "router", "together"}
 	if !reflect.DeepEqual(got, want) {
 		t.Fatalf("RegisteredProviders(stream) = %#v, want %#v", got, want)
 	}
+
+	got = ai.RegisteredProviders("audio_input")
+	want = []string{"gemini", "openai", "router"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("RegisteredProviders(audio_input) = %#v, want %#v", got, want)
+	}
 }
 
 func TestCapabilityRows(t *testing.T) {
 	got := ai.CapabilityRows()
 	want := []ai.CapabilityRow{
-		{Provider: "anthropic", Capabilities: ai.Capabilities{Model: true, Stream: true, ToolStream: true}},
-		{Provider: "atlascloud", Capabilities: ai.Capabilities{Model: true, Image: true, Video: true, Stream: true}},
-		{Provider: "gemini", Capabilities: ai.Capabilities{Model: true, Stream: true}},
-		{Provider: "groq", Capabilities: ai.Capabilities{Model: true, Stream: true, ToolStream: true}},
-		{Provider: "minimax", Capabilities: ai.Capabilities{Model: true, Stream: true, ToolStream: true}},
-		{Provider: "mistral", Capabilities: ai.Capabilities{Model: true, Stream: true, ToolStream: true}},
-		{Provider: "openai", Capabilities: ai.Capabilities{Model: true, Image: true, Stream: true, ToolStream: true}},
-		{Provider: "together", Capabilities: ai.Capabilities{Model: true, Stream: true, ToolStream: true}},
+		{Provider: "anthropic", Capabilities: ai.Capabilities{Model: true, Stream: true, ToolStream: true, ImageInput: true, FileInput: true, Temperature: true, TopP: true, Stop: true, PromptCache: true}},
+		{Provider: "atlascloud", Capabilities: ai.Capabilities{Model: true, Image: true, Video: true, Stream: true, Temperature: true, TopP: true, Stop: true}},
+		{Provider: "gemini", Capabilities: ai.Capabilities{Model: true, Stream: true, ImageInput: true, AudioInput: true, FileInput: true, Temperature: true, TopP: true, Stop: true, Seed: true}},
+		{Provider: "groq", Capabilities: ai.Capabilities{Model: true, Stream: true, ToolStream: true, ImageInput: true, Temperature: true, TopP: true, Stop: true, Seed: true}},
+		{Provider: "minimax", Capabilities: ai.Capabilities{Model: true, Stream: true, ToolStream: true, Temperature: true, TopP: true}},
+		{Provider: "mistral", Capabilities: ai.Capabilities{Model: true, Stream: true, ToolStream: true, ImageInput: true, Temperature: true, TopP: true, Stop: true}},
+		{Provider: "openai", Capabilities: ai.Capabilities{Model: true, Image: true, Stream: true, ToolStream: true, ImageInput: true, AudioInput: true, FileInput: true,
+			Temperature: true, TopP: true, Stop: true, Seed: true, ReasoningEffort: true, PromptCache: true}},
+		// the router can do what the providers it routes to can
+		{Provider: "router", Capabilities: ai.Capabilities{Model: true, Stream: true, ToolStream: true, ImageInput: true, AudioInput: true, FileInput: true,
+			Temperature: true, TopP: true, Stop: true, Seed: true, ReasoningEffort: true, PromptCache: true}},
+		{Provider: "together", Capabilities: ai.Capabilities{Model: true, Stream: true, ToolStream: true, ImageInput: true, Temperature: true, TopP: true, Stop: true, Seed: true}},
 	}
 	if !reflect.DeepEqual(got, want) {
 		t.Fatalf("CapabilityRows() = %#v, want %#v", got, want)
@@ -71,10 +81,11 @@ func TestCapabilityMatrix(t *testing.T) {
 		}
 	}
 
-	if caps := ai.ProviderCapabilities("openai"); caps != (ai.Capabilities{Model: true, Image: true, Stream: true, ToolStream: true}) {
+	if caps := ai.ProviderCapabilities("openai"); caps != (ai.Capabilities{Model: true, Image: true, Stream: true, ToolStream: true, ImageInput: true, AudioInput: true, FileInput: true,
+		Temperature: true, TopP: true, Stop: true, Seed: true, ReasoningEffort: true, PromptCache: true}) {
 		t.Fatalf("ProviderCapabilities(openai) = %#v", caps)
 	}
-	if caps := ai.ProviderCapabilities("atlascloud"); caps != (ai.Capabilities{Model: true, Image: true, Video: true, Stream: true}) {
+	if caps := ai.ProviderCapabilities("atlascloud"); caps != (ai.Capabilities{Model: true, Image: true, Video: true, Stream: true, Temperature: true, TopP: true, Stop: true}) {
 		t.Fatalf("ProviderCapabilities(atlascloud) = %#v", caps)
 	}
 	if caps := ai.ProviderCapabilities("missing"); caps != (ai.Capabilities{}) {
@@ -90,7 +101,7 @@ func TestRegisterStream(t *testing.T) {
 	}
 
 	got := ai.RegisteredProviders("stream")
-	want := []string{"anthropic", "atlascloud", "gemini", "groq", "minimax", "mistral", "openai", "test-stream", "together"}
+	want := []string{"anthropic", "atlascloud", "gemini", "groq", "minimax", "mistral", "openai", "router", "test-stream", "together"}
 	if !reflect.DeepEqual(got, want) {
 		t.Fatalf("RegisteredProviders(stream) = %#v, want %#v", got, want)
 	}
@@ -104,8 +115,27 @@ func TestRegisterToolStream(t *testing.T) {
 	}
 
 	got := ai.RegisteredProviders("tool_stream")
-	want := []string{"anthropic", "groq", "minimax", "mistral", "openai", "test-tool-stream", "together"}
+	want := []string{"anthropic", "groq", "minimax", "mistral", "openai", "router", "test-tool-stream", "together"}
 	if !reflect.DeepEqual(got, want) {
 		t.Fatalf("RegisteredProviders(tool_stream) = %#v, want %#v", got, want)
 	}
 }
+
+func TestRegisterParams(t *testing.T) {
+	ai.RegisterParams("test-params", ai.ParamTemperature, ai.ParamStop)
+
+	if caps := ai.ProviderCapabilities("test-params"); caps != (ai.Capabilities{Temperature: true, Stop: true}) {
+		t.Fatalf("ProviderCapabilities(test-params) = %#v", caps)
+	}
+
+	temp, seed := 0.2, 7
+	params := ai.Params{Temperature: &temp, Seed: &seed, Stop: []string{"END"}, PromptCache: true, PromptCacheKey: "support"}
+	got := ai.ProviderParams("test-params", params)
+	want := ai.Params{Temperature: &temp, Stop: []string{"END"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ProviderParams(test-params) = %#v, want %#v", got, want)
+	}
+	if got := ai.UnsupportedParams("test-params", params); !reflect.DeepEqual(got, []ai.Param{ai.ParamSeed, ai.ParamPromptCache}) {
+		t.Fatalf("UnsupportedParams(test-params) = %v", got)
+	}
+}
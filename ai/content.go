@@ -0,0 +1,244 @@
+package ai
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// PartType is the modality of a content Part.
+type PartType string
+
+const (
+	PartText  PartType = "text"
+	PartImage PartType = "image"
+	PartAudio PartType = "audio"
+	PartFile  PartType = "file"
+)
+
+// Part is one piece of multimodal message content. A Message's Content or
+// a Request's Parts may be a []Part instead of a string to send images,
+// audio or files alongside text. Media is either inline, in Data, or a
+// reference to a URL the provider fetches.
+type Part struct {
+	Type PartType `json:"type"`
+	// Text is the content of a text part.
+	Text string `json:"text,omitempty"`
+	// MIMEType is the media type of Data or URL, e.g. "image/png".
+	MIMEType string `json:"mime_type,omitempty"`
+	// Data is the inline content of an image, audio or file part.
+	Data []byte `json:"data,omitempty"`
+	// URL references the content of an image, audio or file part.
+	URL string `json:"url,omitempty"`
+	// Name is the file name of a file part.
+	Name string `json:"name,omitempty"`
+}
+
+// TextPart returns a text part.
+func TextPart(text string) Part {
+	return Part{Type: PartText, Text: text}
+}
+
+// ImagePart returns an inline image part.
+func ImagePart(mimeType string, data []byte) Part {
+	return Part{Type: PartImage, MIMEType: mimeType, Data: data}
+}
+
+// ImageURLPart returns an image part the provider fetches from url.
+func ImageURLPart(url string) Part {
+	return Part{Type: PartImage, URL: url}
+}
+
+// AudioPart returns an inline audio part.
+func AudioPart(mimeType string, data []byte) Part {
+	return Part{Type: PartAudio, MIMEType: mimeType, Data: data}
+}
+
+// FilePart returns an inline file part, such as a PDF.
+func FilePart(name, mimeType string, data []byte) Part {
+	return Part{Type: PartFile, Name: name, MIMEType: mimeType, Data: data}
+}
+
+// FileURLPart returns a file part the provider fetches from url.
+func FileURLPart(name, mimeType, url string) Part {
+	return Part{Type: PartFile, Name: name, MIMEType: mimeType, URL: url}
+}
+
+// Base64 returns the part's Data base64 encoded.
+func (p Part) Base64() string {
+	return base64.StdEncoding.EncodeToString(p.Data)
+}
+
+// DataURL returns the part's URL, or its Data as a data: URL when it is
+// inline.
+func (p Part) DataURL() string {
+	if p.URL != "" || len(p.Data) == 0 {
+		return p.URL
+	}
+	return "data:" + p.MIMEType + ";base64," + p.Base64()
+}
+
+// UnsupportedContentError is returned by a provider asked to send a part
+// its API, or this package's support for it, can't take.
+type UnsupportedContentError struct {
+	Provider string
+	Type     PartType
+}
+
+func (e *UnsupportedContentError) Error() string {
+	return fmt.Sprintf("ai: %s content is unsupported by provider %s", e.Type, e.Provider)
+}
+
+func (e *UnsupportedContentError) ErrorKind() ErrorKind {
+	return ErrorKindConfiguration
+}
+
+// Parts returns message content as parts: a string is one text part, and
+// parts decoded from JSON, as from persisted memory, are restored.
+func Parts(content any) []Part {
+	switch c := content.(type) {
+	case nil:
+		return nil
+	case string:
+		if c == "" {
+			return nil
+		}
+		return []Part{TextPart(c)}
+	case []Part:
+		return c
+	case Part:
+		return []Part{c}
+	}
+	b, err := json.Marshal(content)
+	if err != nil {
+		return []Part{TextPart(fmt.Sprint(content))}
+	}
+	var parts []Part
+	if err := json.Unmarshal(b, &parts); err != nil {
+		return []Part{TextPart(fmt.Sprint(content))}
+	}
+	return parts
+}
+
+// ContentText returns the text of message content, joining the text of
+// its parts and leaving out media.
+func ContentText(content any) string {
+	if s, ok := content.(string); ok {
+		return s
+	}
+	var texts []string
+	for _, p := range Parts(content) {
+		if p.Type == PartText && p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// HasMedia reports whether content has any part other than text.
+func HasMedia(content any) bool {
+	if _, ok := content.(string); ok {
+		return false
+	}
+	for _, p := range Parts(content) {
+		if p.Type != PartText {
+			return true
+		}
+	}
+	return false
+}
+
+// ProviderParts returns content as the parts provider can send. Parts of
+// a type the provider hasn't registered with RegisterInput are degraded
+// where nothing is lost: an inline text file, such as CSV or JSON,
+// becomes a text part. Any other is an *UnsupportedContentError.
+func ProviderParts(provider string, content any) ([]Part, error) {
+	caps := ProviderCapabilities(provider)
+	parts := Parts(content)
+	out := make([]Part, 0, len(parts))
+	for _, p := range parts {
+		switch p.Type {
+		case PartText, "":
+			out = append(out, TextPart(p.Text))
+			continue
+		case PartImage:
+			if caps.ImageInput {
+				out = append(out, p)
+				continue
+			}
+		case PartAudio:
+			if caps.AudioInput {
+				out = append(out, p)
+				continue
+			}
+		case PartFile:
+			if caps.FileInput {
+				out = append(out, p)
+				continue
+			}
+			if text, ok := fileText(p); ok {
+				out = append(out, TextPart(text))
+				continue
+			}
+		}
+		return nil, &UnsupportedContentError{Provider: provider, Type: p.Type}
+	}
+	return out, nil
+}
+
+// contentInputs returns the input capabilities a provider needs to send
+// content: one for each type of part ProviderParts can't degrade to text.
+func contentInputs(content any) Capabilities {
+	var c Capabilities
+	for _, p := range Parts(content) {
+		switch p.Type {
+		case PartImage:
+			c.ImageInput = true
+		case PartAudio:
+			c.AudioInput = true
+		case PartFile:
+			if _, ok := fileText(p); !ok {
+				c.FileInput = true
+			}
+		}
+	}
+	return c
+}
+
+// fileText returns an inline text file's content, headed by its name.
+func fileText(p Part) (string, bool) {
+	if len(p.Data) == 0 || !utf8.Valid(p.Data) || !isTextMIME(p.MIMEType) {
+		return "", false
+	}
+	if p.Name == "" {
+		return string(p.Data), true
+	}
+	return p.Name + ":\n" + string(p.Data), true
+}
+
+func isTextMIME(mimeType string) bool {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	switch {
+	case strings.HasPrefix(mimeType, "text/"):
+		return true
+	case mimeType == "application/json", mimeType == "application/xml", mimeType == "application/yaml",
+		mimeType == "application/x-yaml", mimeType == "application/csv":
+		return true
+	}
+	return false
+}
+
+// ProviderText returns content as text for a provider that only takes
+// text, degrading parts as ProviderParts does.
+func ProviderText(provider string, content any) (string, error) {
+	if s, ok := content.(string); ok {
+		return s, nil
+	}
+	parts, err := ProviderParts(provider, content)
+	if err != nil {
+		return "", err
+	}
+	return ContentText(parts), nil
+}
@@ -0,0 +1,65 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPartsRestoresDecodedContent(t *testing.T) {
+	parts := []Part{TextPart("what is this?"), ImagePart("image/png", []byte{1, 2, 3})}
+	b, err := json.Marshal(Message{Role: "user", Content: parts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg Message
+	if err := json.Unmarshal(b, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if got := Parts(msg.Content); !reflect.DeepEqual(got, parts) {
+		t.Fatalf("Parts = %#v, want %#v", got, parts)
+	}
+	if got := ContentText(msg.Content); got != "what is this?" {
+		t.Fatalf("ContentText = %q", got)
+	}
+	if !HasMedia(msg.Content) || HasMedia("text") {
+		t.Fatal("HasMedia should report the image only")
+	}
+	if got := ImagePart("image/png", []byte("hi")).DataURL(); got != "data:image/png;base64,aGk=" {
+		t.Fatalf("DataURL = %q", got)
+	}
+}
+
+func TestProviderPartsDegradesOrRejects(t *testing.T) {
+	RegisterInput("test-vision", PartImage)
+	t.Cleanup(func() { delete(inputProviders[PartImage], "test-vision") })
+
+	content := []Part{
+		TextPart("summarise"),
+		FilePart("notes.csv", "text/csv", []byte("a,b\n1,2")),
+		ImageURLPart("https://example.com/cat.png"),
+	}
+	parts, err := ProviderParts("test-vision", content)
+	if err != nil {
+		t.Fatalf("ProviderParts returned error: %v", err)
+	}
+	want := []Part{TextPart("summarise"), TextPart("notes.csv:\na,b\n1,2"), ImageURLPart("https://example.com/cat.png")}
+	if !reflect.DeepEqual(parts, want) {
+		t.Fatalf("parts = %#v, want %#v", parts, want)
+	}
+
+	_, err = ProviderText("test-text", content)
+	var unsupported *UnsupportedContentError
+	if !errors.As(err, &unsupported) || unsupported.Type != PartImage {
+		t.Fatalf("error = %v, want unsupported image", err)
+	}
+	if ClassifyError(err) != ErrorKindConfiguration {
+		t.Fatalf("kind = %s", ClassifyError(err))
+	}
+
+	_, err = ProviderParts("test-vision", []Part{FilePart("report.pdf", "application/pdf", []byte("%PDF"))})
+	if !errors.As(err, &unsupported) || unsupported.Type != PartFile {
+		t.Fatalf("error = %v, want unsupported file", err)
+	}
+}
@@ -27,6 +27,8 @@ func init() {
 		return NewProvider(opts...)
 	})
 	ai.RegisterStream("gemini")
+	ai.RegisterInput("gemini", ai.PartImage, ai.PartAudio, ai.PartFile)
+	ai.RegisterParams("gemini", ai.ParamTemperature, ai.ParamTopP, ai.ParamStop, ai.ParamSeed)
 }
 
 // Provider implements the ai.Model interface for Google Gemini.
@@ -59,6 +61,12 @@ func (p *Provider) Options() ai.Options { return p.opts }
 func (p *Provider) String() string      { return "gemini" }
 
 func (p *Provider) Generate(ctx context.Context, req *ai.Request, opts ...ai.GenerateOption) (*ai.Response, error) {
+	return ai.ToolLoop(ctx, p, req, p.opts)
+}
+
+// Turn makes a single generateContent call for req followed by the tool
+// rounds so far.
+func (p *Provider) Turn(ctx context.Context, req *ai.Request, rounds []ai.ToolRound) (*ai.Response, error) {
 	var tools []map[string]any
 	for _, t := range req.Tools {
 		tools = append(tools, map[string]any{
@@ -71,10 +79,12 @@ func (p *Provider) Generate(ctx context.Context, req *ai.Request, opts ...ai.Gen
 		})
 	}
 
-	contents := geminiContents(req)
-
+	contents, err := geminiContents(req)
+	if err != nil {
+		return nil, err
+	}
 	apiReq := map[string]any{
-		"contents": contents,
+		"contents": append(contents, toolContents(rounds)...),
 	}
 
 	if req.SystemPrompt != "" {
@@ -88,63 +98,28 @@ func (p *Provider) Generate(ctx context.Context, req *ai.Request, opts ...ai.Gen
 			{"functionDeclarations": tools},
 		}
 	}
-
-	resp, rawParts, err := p.callAPI(ctx, apiReq)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(resp.ToolCalls) == 0 {
-		return resp, nil
-	}
-
-	if p.opts.ToolHandler != nil {
-		var resultParts []map[string]any
-		for _, tc := range resp.ToolCalls {
-			result := p.opts.ToolHandler(ctx, tc).Value
-			resultParts = append(resultParts, map[string]any{
-				"functionResponse": map[string]any{
-					"name":     tc.Name,
-					"id":       tc.ID,
-					"response": result,
-				},
-			})
-		}
-
-		followUpContents := append(contents,
-			map[string]any{"role": "model", "parts": rawParts},
-			map[string]any{"role": "user", "parts": resultParts},
-		)
-
-		followUpReq := map[string]any{
-			"contents": followUpContents,
-		}
-		if req.SystemPrompt != "" {
-			followUpReq["system_instruction"] = map[string]any{
-				"parts": []map[string]any{{"text": req.SystemPrompt}},
-			}
-		}
-
-		followUpResp, _, err := p.callAPI(ctx, followUpReq)
-		if err == nil && followUpResp.Reply != "" {
-			resp.Answer = followUpResp.Reply
-		}
+	if config := generationConfig(p.opts); len(config) > 0 {
+		apiReq["generationConfig"] = config
 	}
 
-	return resp, nil
+	return p.callAPI(ctx, apiReq)
 }
 
 func (p *Provider) Stream(ctx context.Context, req *ai.Request, opts ...ai.GenerateOption) (ai.Stream, error) {
+	contents, err := geminiContents(req)
+	if err != nil {
+		return nil, err
+	}
 	apiReq := map[string]any{
-		"contents": geminiContents(req),
+		"contents": contents,
 	}
 	if req.SystemPrompt != "" {
 		apiReq["system_instruction"] = map[string]any{
 			"parts": []map[string]any{{"text": req.SystemPrompt}},
 		}
 	}
-	if p.opts.MaxTokens > 0 {
-		apiReq["generationConfig"] = map[string]any{"maxOutputTokens": p.opts.MaxTokens}
+	if config := generationConfig(p.opts); len(config) > 0 {
+		apiReq["generationConfig"] = config
 	}
 
 	reqBody, err := json.Marshal(apiReq)
@@ -207,11 +182,7 @@ func (s *streamReader) Recv() (*ai.Response, error) {
 					} `json:"parts"`
 				} `json:"content"`
 			} `json:"candidates"`
-			UsageMetadata *struct {
-				PromptTokenCount     int `json:"promptTokenCount"`
-				CandidatesTokenCount int `json:"candidatesTokenCount"`
-				TotalTokenCount      int `json:"totalTokenCount"`
-			} `json:"usageMetadata"`
+			UsageMetadata *usageMetadata `json:"usageMetadata"`
 		}
 		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
 			return nil, fmt.Errorf("failed to parse stream chunk: %w", err)
@@ -231,11 +202,7 @@ func (s *streamReader) Recv() (*ai.Response, error) {
 			}
 		}
 		if chunk.UsageMetadata != nil {
-			return &ai.Response{Usage: ai.Usage{
-				InputTokens:  chunk.UsageMetadata.PromptTokenCount,
-				OutputTokens: chunk.UsageMetadata.CandidatesTokenCount,
-				TotalTokens:  chunk.UsageMetadata.TotalTokenCount,
-			}}, nil
+			return &ai.Response{Usage: chunk.UsageMetadata.usage()}, nil
 		}
 	}
 	if err := s.scanner.Err(); err != nil {
@@ -252,10 +219,10 @@ func (s *streamReader) Close() error {
 	return s.body.Close()
 }
 
-func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Response, []map[string]any, error) {
+func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Response, error) {
 	reqBody, err := json.Marshal(req)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	apiURL := strings.TrimRight(p.opts.BaseURL, "/") +
@@ -263,7 +230,7 @@ func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Respons
 
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(reqBody))
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
@@ -271,13 +238,13 @@ func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Respons
 
 	httpResp, err := http.DefaultClient.Do(httpReq)
 	if err != nil {
-		return nil, nil, fmt.Errorf("API request failed: %w", err)
+		return nil, fmt.Errorf("API request failed: %w", err)
 	}
 	defer httpResp.Body.Close()
 
 	respBody, _ := io.ReadAll(httpResp.Body)
 	if httpResp.StatusCode != http.StatusOK {
-		return nil, nil, ai.NewHTTPError(httpResp, respBody)
+		return nil, ai.NewHTTPError(httpResp, respBody)
 	}
 
 	var geminiResp struct {
@@ -289,26 +256,25 @@ func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Respons
 				} `json:"parts"`
 			} `json:"content"`
 		} `json:"candidates"`
+		UsageMetadata usageMetadata `json:"usageMetadata"`
 	}
 
 	if err := json.Unmarshal(respBody, &geminiResp); err != nil {
-		return nil, nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	if len(geminiResp.Candidates) == 0 {
-		return nil, nil, fmt.Errorf("no response from API")
+		return nil, fmt.Errorf("no response from API")
 	}
 
 	parts := geminiResp.Candidates[0].Content.Parts
-	response := &ai.Response{}
+	response := &ai.Response{Usage: geminiResp.UsageMetadata.usage()}
 
 	var replyParts []string
-	var rawParts []map[string]any
 
 	for _, part := range parts {
 		if part.Text != "" {
 			replyParts = append(replyParts, part.Text)
-			rawParts = append(rawParts, map[string]any{"text": part.Text})
 		}
 		if part.FunctionCall != nil {
 			response.ToolCalls = append(response.ToolCalls, ai.ToolCall{
@@ -316,13 +282,6 @@ func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Respons
 				Name:  part.FunctionCall.Name,
 				Input: part.FunctionCall.Args,
 			})
-			rawParts = append(rawParts, map[string]any{
-				"functionCall": map[string]any{
-					"id":   part.FunctionCall.ID,
-					"name": part.FunctionCall.Name,
-					"args": part.FunctionCall.Args,
-				},
-			})
 		}
 	}
 
@@ -330,7 +289,49 @@ func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Respons
 		response.Reply = strings.Join(replyParts, "\n")
 	}
 
-	return response, rawParts, nil
+	return response, nil
+}
+
+// usageMetadata is the token usage of a generateContent response. Gemini
+// caches repeated prompt prefixes on its own and counts the tokens it
+// read from the cache in cachedContentTokenCount.
+type usageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount"`
+}
+
+func (u usageMetadata) usage() ai.Usage {
+	return ai.Usage{
+		InputTokens:     u.PromptTokenCount,
+		OutputTokens:    u.CandidatesTokenCount,
+		TotalTokens:     u.TotalTokenCount,
+		CacheReadTokens: u.CachedContentTokenCount,
+	}
+}
+
+// generationConfig returns the generationConfig for the max tokens and
+// generation parameters of o.
+func generationConfig(o ai.Options) map[string]any {
+	config := map[string]any{}
+	if o.MaxTokens > 0 {
+		config["maxOutputTokens"] = o.MaxTokens
+	}
+	params := ai.ProviderParams("gemini", o.Params)
+	if params.Temperature != nil {
+		config["temperature"] = *params.Temperature
+	}
+	if params.TopP != nil {
+		config["topP"] = *params.TopP
+	}
+	if len(params.Stop) > 0 {
+		config["stopSequences"] = params.Stop
+	}
+	if params.Seed != nil {
+		config["seed"] = *params.Seed
+	}
+	return config
 }
 
 type functionCallPB struct {
@@ -339,7 +340,7 @@ type functionCallPB struct {
 	Args map[string]any `json:"args"`
 }
 
-func geminiContents(req *ai.Request) []map[string]any {
+func geminiContents(req *ai.Request) ([]map[string]any, error) {
 	contents := make([]map[string]any, 0, len(req.Messages)+1)
 	for _, m := range req.Messages {
 		role := m.Role
@@ -349,10 +350,89 @@ func geminiContents(req *ai.Request) []map[string]any {
 		if role == "system" || role == "" {
 			continue
 		}
-		contents = append(contents, map[string]any{"role": role, "parts": []map[string]any{{"text": fmt.Sprint(m.Content)}}})
+		parts, err := geminiParts(m.Content)
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, map[string]any{"role": role, "parts": parts})
 	}
-	if req.Prompt != "" {
-		contents = append(contents, map[string]any{"role": "user", "parts": []map[string]any{{"text": req.Prompt}}})
+	if c := req.UserContent(); c != nil {
+		parts, err := geminiParts(c)
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, map[string]any{"role": "user", "parts": parts})
+	}
+	return contents, nil
+}
+
+// geminiParts converts message content to Gemini parts: text, inline_data
+// for inline media and file_data for media referenced by URL.
+func geminiParts(content any) ([]map[string]any, error) {
+	if !ai.HasMedia(content) {
+		return []map[string]any{{"text": ai.ContentText(content)}}, nil
+	}
+	parts, err := ai.ProviderParts("gemini", content)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]map[string]any, 0, len(parts))
+	for _, p := range parts {
+		switch {
+		case p.Type == ai.PartText:
+			out = append(out, map[string]any{"text": p.Text})
+		case len(p.Data) > 0:
+			out = append(out, map[string]any{"inline_data": map[string]any{"mime_type": p.MIMEType, "data": p.Base64()}})
+		default:
+			out = append(out, map[string]any{"file_data": map[string]any{"mime_type": p.MIMEType, "file_uri": p.URL}})
+		}
+	}
+	return out, nil
+}
+
+// toolContents converts completed tool rounds to Gemini contents: the
+// model's text and functionCall parts, then a user turn with a
+// functionResponse part per call.
+func toolContents(rounds []ai.ToolRound) []map[string]any {
+	var contents []map[string]any
+	for _, r := range rounds {
+		var calls, responses []map[string]any
+		if r.Reply != "" {
+			calls = append(calls, map[string]any{"text": r.Reply})
+		}
+		for i, call := range r.Calls {
+			calls = append(calls, map[string]any{
+				"functionCall": map[string]any{
+					"id":   call.ID,
+					"name": call.Name,
+					"args": call.Input,
+				},
+			})
+			responses = append(responses, map[string]any{
+				"functionResponse": map[string]any{
+					"name":     call.Name,
+					"id":       call.ID,
+					"response": functionResponse(r.Results[i]),
+				},
+			})
+		}
+		contents = append(contents,
+			map[string]any{"role": "model", "parts": calls},
+			map[string]any{"role": "user", "parts": responses},
+		)
 	}
 	return contents
 }
+
+// functionResponse returns a tool result as the object Gemini expects,
+// wrapping values that aren't one.
+func functionResponse(res ai.ToolResult) map[string]any {
+	switch v := res.Value.(type) {
+	case map[string]any:
+		return v
+	case nil:
+		return map[string]any{"result": res.Content}
+	default:
+		return map[string]any{"result": v}
+	}
+}
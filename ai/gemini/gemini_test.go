@@ -200,3 +200,68 @@ func TestProvider_Registration(t *testing.T) {
 		t.Errorf("Expected 'gemini', got '%s'", m.String())
 	}
 }
+
+func TestProvider_GenerateMultimodal(t *testing.T) {
+	var body map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"a song"}]}}]}`))
+	}))
+	defer ts.Close()
+
+	p := NewProvider(ai.WithAPIKey("test-key"), ai.WithBaseURL(ts.URL))
+	_, err := p.Generate(context.Background(), &ai.Request{Parts: []ai.Part{
+		ai.TextPart("what is playing?"),
+		ai.AudioPart("audio/wav", []byte("wav")),
+		ai.ImageURLPart("gs://bucket/cover.png"),
+	}})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	parts := body["contents"].([]any)[0].(map[string]any)["parts"].([]any)
+	if len(parts) != 3 || parts[0].(map[string]any)["text"] != "what is playing?" {
+		t.Fatalf("parts = %#v", parts)
+	}
+	inline := parts[1].(map[string]any)["inline_data"].(map[string]any)
+	if inline["mime_type"] != "audio/wav" || inline["data"] != "d2F2" {
+		t.Fatalf("inline_data = %#v", inline)
+	}
+	if uri := parts[2].(map[string]any)["file_data"].(map[string]any)["file_uri"]; uri != "gs://bucket/cover.png" {
+		t.Fatalf("file_uri = %v", uri)
+	}
+}
+
+func TestProvider_GenerateParams(t *testing.T) {
+	var body map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"parts":[{"text":"ok"}]}}],"usageMetadata":{"promptTokenCount":1200,"candidatesTokenCount":4,"totalTokenCount":1204,"cachedContentTokenCount":1024}}`))
+	}))
+	defer ts.Close()
+
+	p := NewProvider(ai.WithAPIKey("test-key"), ai.WithBaseURL(ts.URL),
+		ai.WithMaxTokens(100), ai.WithTemperature(0.5), ai.WithStop("END"), ai.WithSeed(3),
+		ai.WithReasoningEffort(ai.ReasoningHigh))
+	resp, err := p.Generate(context.Background(), &ai.Request{Prompt: "hi"})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+
+	config := body["generationConfig"].(map[string]any)
+	if config["maxOutputTokens"] != 100.0 || config["temperature"] != 0.5 || config["seed"] != 3.0 || config["stopSequences"].([]any)[0] != "END" {
+		t.Fatalf("generationConfig = %#v", config)
+	}
+	if len(config) != 4 {
+		t.Fatalf("generationConfig has unsupported params: %#v", config)
+	}
+	if want := (ai.Usage{InputTokens: 1200, OutputTokens: 4, TotalTokens: 1204, CacheReadTokens: 1024}); resp.Usage != want {
+		t.Fatalf("usage = %+v, want %+v", resp.Usage, want)
+	}
+}
@@ -0,0 +1,188 @@
+package openaiapi
+
+import (
+	"encoding/json"
+
+	"go-micro.dev/v6/ai"
+)
+
+// Tools converts tools to chat completions function tools.
+func Tools(tools []ai.Tool) []map[string]any {
+	var out []map[string]any
+	for _, t := range tools {
+		out = append(out, map[string]any{
+			"type": "function",
+			"function": map[string]any{
+				"name":        t.Name,
+				"description": t.Description,
+				"parameters": map[string]any{
+					"type":       "object",
+					"properties": t.Properties,
+				},
+			},
+		})
+	}
+	return out
+}
+
+// SetParams sets the generation parameters of p on a chat completions
+// request. Filter p with ai.ProviderParams first so the provider only
+// gets the parameters it takes.
+func SetParams(req map[string]any, p ai.Params) {
+	if p.Temperature != nil {
+		req["temperature"] = *p.Temperature
+	}
+	if p.TopP != nil {
+		req["top_p"] = *p.TopP
+	}
+	if len(p.Stop) > 0 {
+		req["stop"] = p.Stop
+	}
+	if p.Seed != nil {
+		req["seed"] = *p.Seed
+	}
+	if p.ReasoningEffort != "" {
+		req["reasoning_effort"] = string(p.ReasoningEffort)
+	}
+	if p.PromptCacheKey != "" {
+		req["prompt_cache_key"] = p.PromptCacheKey
+	}
+}
+
+// ChatUsage is the usage object of a chat completions response.
+type ChatUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details"`
+}
+
+// Usage converts u to ai.Usage. Cached prompt tokens are reported as
+// cache reads; chat completions doesn't report cache writes.
+func (u ChatUsage) Usage() ai.Usage {
+	return ai.Usage{
+		InputTokens:     u.PromptTokens,
+		OutputTokens:    u.CompletionTokens,
+		TotalTokens:     u.TotalTokens,
+		CacheReadTokens: u.PromptTokensDetails.CachedTokens,
+	}
+}
+
+// ToolMessages converts completed tool rounds to chat completions
+// messages: for each, the assistant message carrying the tool calls and
+// one tool message per result.
+func ToolMessages(rounds []ai.ToolRound) []map[string]any {
+	var out []map[string]any
+	for _, r := range rounds {
+		out = append(out, map[string]any{
+			"role":       "assistant",
+			"content":    r.Reply,
+			"tool_calls": ToolCalls(r.Calls),
+		})
+		for i, call := range r.Calls {
+			out = append(out, map[string]any{
+				"role":         "tool",
+				"tool_call_id": call.ID,
+				"content":      r.Results[i].Content,
+			})
+		}
+	}
+	return out
+}
+
+// ToolCalls converts tool calls to the assistant message tool_calls field.
+func ToolCalls(calls []ai.ToolCall) []map[string]any {
+	out := make([]map[string]any, 0, len(calls))
+	for _, call := range calls {
+		args, err := json.Marshal(call.Input)
+		if err != nil || call.Input == nil {
+			args = []byte("{}")
+		}
+		out = append(out, map[string]any{
+			"id":   call.ID,
+			"type": "function",
+			"function": map[string]any{
+				"name":      call.Name,
+				"arguments": string(args),
+			},
+		})
+	}
+	return out
+}
+
+// Messages converts the system prompt, the conversation and the prompt of
+// req to chat completions messages for provider.
+func Messages(provider string, req *ai.Request) ([]map[string]any, error) {
+	messages := []map[string]any{{"role": "system", "content": req.SystemPrompt}}
+	for _, m := range req.Messages {
+		content, err := Content(provider, m.Content)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, map[string]any{"role": m.Role, "content": content})
+	}
+	if c := req.UserContent(); c != nil {
+		content, err := Content(provider, c)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, map[string]any{"role": "user", "content": content})
+	}
+	return messages, nil
+}
+
+// Content converts message content to the chat completions content field
+// for provider: text as a string, multimodal parts as an array of text,
+// image_url, input_audio and file content parts.
+func Content(provider string, content any) (any, error) {
+	if s, ok := content.(string); ok {
+		return s, nil
+	}
+	if !ai.HasMedia(content) {
+		return ai.ContentText(content), nil
+	}
+	parts, err := ai.ProviderParts(provider, content)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]map[string]any, 0, len(parts))
+	for _, p := range parts {
+		switch p.Type {
+		case ai.PartText:
+			out = append(out, map[string]any{"type": "text", "text": p.Text})
+		case ai.PartImage:
+			out = append(out, map[string]any{"type": "image_url", "image_url": map[string]any{"url": p.DataURL()}})
+		case ai.PartAudio:
+			// audio is only accepted inline
+			if len(p.Data) == 0 {
+				return nil, &ai.UnsupportedContentError{Provider: provider, Type: p.Type}
+			}
+			out = append(out, map[string]any{"type": "input_audio", "input_audio": map[string]any{
+				"data":   p.Base64(),
+				"format": audioFormat(p.MIMEType),
+			}})
+		case ai.PartFile:
+			// files are only accepted inline, or by uploaded file id
+			if len(p.Data) == 0 {
+				return nil, &ai.UnsupportedContentError{Provider: provider, Type: p.Type}
+			}
+			out = append(out, map[string]any{"type": "file", "file": map[string]any{
+				"filename":  p.Name,
+				"file_data": p.DataURL(),
+			}})
+		}
+	}
+	return out, nil
+}
+
+// audioFormat returns the input_audio format for an audio MIME type.
+func audioFormat(mimeType string) string {
+	switch mimeType {
+	case "audio/mpeg", "audio/mp3":
+		return "mp3"
+	default:
+		return "wav"
+	}
+}
@@ -13,14 +13,12 @@ import (
 	"go-micro.dev/v6/ai"
 )
 
-// Stream opens an OpenAI-compatible chat completions SSE stream.
-func Stream(ctx context.Context, opts ai.Options, req *ai.Request, basePath string) (ai.Stream, error) {
-	messages := []map[string]any{{"role": "system", "content": req.SystemPrompt}}
-	for _, m := range req.Messages {
-		messages = append(messages, map[string]any{"role": m.Role, "content": m.Content})
-	}
-	if req.Prompt != "" {
-		messages = append(messages, map[string]any{"role": "user", "content": req.Prompt})
+// Stream opens an OpenAI-compatible chat completions SSE stream for
+// provider.
+func Stream(ctx context.Context, provider string, opts ai.Options, req *ai.Request, basePath string) (ai.Stream, error) {
+	messages, err := Messages(provider, req)
+	if err != nil {
+		return nil, err
 	}
 	apiReq := map[string]any{
 		"model":          opts.Model,
@@ -31,6 +29,7 @@ func Stream(ctx context.Context, opts ai.Options, req *ai.Request, basePath stri
 	if opts.MaxTokens > 0 {
 		apiReq["max_tokens"] = opts.MaxTokens
 	}
+	SetParams(apiReq, ai.ProviderParams(provider, opts.Params))
 	reqBody, err := json.Marshal(apiReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal stream request: %w", err)
@@ -82,11 +81,7 @@ func (s *StreamReader) Recv() (*ai.Response, error) {
 					Content string `json:"content"`
 				} `json:"delta"`
 			} `json:"choices"`
-			Usage *struct {
-				PromptTokens     int `json:"prompt_tokens"`
-				CompletionTokens int `json:"completion_tokens"`
-				TotalTokens      int `json:"total_tokens"`
-			} `json:"usage"`
+			Usage *ChatUsage `json:"usage"`
 		}
 		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
 			return nil, fmt.Errorf("failed to parse stream chunk: %w", err)
@@ -95,11 +90,7 @@ func (s *StreamReader) Recv() (*ai.Response, error) {
 			return &ai.Response{Reply: chunk.Choices[0].Delta.Content}, nil
 		}
 		if chunk.Usage != nil {
-			return &ai.Response{Usage: ai.Usage{
-				InputTokens:  chunk.Usage.PromptTokens,
-				OutputTokens: chunk.Usage.CompletionTokens,
-				TotalTokens:  chunk.Usage.TotalTokens,
-			}}, nil
+			return &ai.Response{Usage: chunk.Usage.Usage()}, nil
 		}
 	}
 	if err := s.scanner.Err(); err != nil {
@@ -0,0 +1,218 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+var (
+	// DefaultMaxToolRounds is how many times ToolLoop executes tools and
+	// goes back to the model when Options.MaxToolRounds isn't set.
+	DefaultMaxToolRounds = 10
+	// DefaultToolConcurrency is how many of a turn's tool calls ToolLoop
+	// executes at once when Options.ToolConcurrency isn't set.
+	DefaultToolConcurrency = 4
+)
+
+// Turner is implemented by providers that make a single model call per
+// turn and leave tool execution to ToolLoop. Turn sends the request
+// followed by the tool rounds completed so far, translated to the
+// provider's wire format, and returns the model's reply and tool calls
+// without executing them.
+type Turner interface {
+	Turn(ctx context.Context, req *Request, rounds []ToolRound) (*Response, error)
+}
+
+// ToolRound is one completed round of tool use: the model's reply that
+// asked for the calls, and the result of each. Results[i] answers Calls[i].
+type ToolRound struct {
+	Reply   string
+	Calls   []ToolCall
+	Results []ToolResult
+}
+
+// ToolLoopError is returned by ToolLoop when a follow-up turn fails after
+// tools were executed. Response holds what was done before the failure,
+// including the executed calls and their results.
+type ToolLoopError struct {
+	Round    int
+	Response *Response
+	Err      error
+}
+
+func (e *ToolLoopError) Error() string {
+	if e == nil {
+		return ""
+	}
+	return fmt.Sprintf("ai tool loop failed after round %d (%s): %v", e.Round, e.ErrorKind(), e.Err)
+}
+
+func (e *ToolLoopError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.Err
+}
+
+func (e *ToolLoopError) ErrorKind() ErrorKind {
+	if e == nil {
+		return ErrorKindUnknown
+	}
+	return ClassifyError(e.Err)
+}
+
+// ToolLoop generates a response from t, executing the tool calls it asks
+// for with opts.ToolHandler and sending the results back until the model
+// replies without tool calls. It is how Turner providers implement
+// Generate:
+//
+//	func (p *Provider) Generate(ctx context.Context, req *ai.Request, opts ...ai.GenerateOption) (*ai.Response, error) {
+//	    return ai.ToolLoop(ctx, p, req, p.opts)
+//	}
+//
+// The calls in a turn are independent of each other, so up to
+// opts.ToolConcurrency of them run at once; a handler that needs them in
+// order uses InCallOrder. After opts.MaxToolRounds rounds the loop stops,
+// leaving the last turn's calls unexecuted, Answer empty and Truncated
+// set. The response's Reply is the first turn's, Answer the last turn's,
+// ToolCalls every call made, and Usage the sum over turns.
+func ToolLoop(ctx context.Context, t Turner, req *Request, opts Options) (*Response, error) {
+	resp, err := t.Turn(ctx, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.ToolCalls) == 0 || opts.ToolHandler == nil {
+		return resp, nil
+	}
+
+	maxRounds := opts.MaxToolRounds
+	if maxRounds <= 0 {
+		maxRounds = DefaultMaxToolRounds
+	}
+
+	var (
+		rounds  []ToolRound
+		calls   []ToolCall
+		reply   = resp.Reply
+		pending = resp.ToolCalls
+	)
+	for len(rounds) < maxRounds {
+		results := runTools(ctx, opts.ToolHandler, pending, opts.ToolConcurrency)
+		for i := range pending {
+			pending[i].Result = results[i].Content
+			if results[i].Refused != "" {
+				pending[i].Error = results[i].Refused
+			}
+		}
+		calls = append(calls, pending...)
+		rounds = append(rounds, ToolRound{Reply: reply, Calls: pending, Results: results})
+		resp.ToolCalls = calls
+
+		next, err := t.Turn(ctx, req, rounds)
+		if err != nil {
+			return nil, &ToolLoopError{Round: len(rounds), Response: resp, Err: err}
+		}
+		resp.Usage = addUsage(resp.Usage, next.Usage)
+
+		if len(next.ToolCalls) == 0 {
+			resp.Answer = next.Reply
+			return resp, nil
+		}
+		reply, pending = next.Reply, next.ToolCalls
+	}
+
+	// out of rounds: report the calls the model still wanted
+	resp.ToolCalls = append(calls, pending...)
+	resp.Truncated = true
+	return resp, nil
+}
+
+// runTools executes calls with up to concurrency of them at once and
+// returns their results in call order.
+func runTools(ctx context.Context, handler ToolHandler, calls []ToolCall, concurrency int) []ToolResult {
+	if concurrency <= 0 {
+		concurrency = DefaultToolConcurrency
+	}
+	results := make([]ToolResult, len(calls))
+	if concurrency == 1 || len(calls) == 1 {
+		for i, call := range calls {
+			results[i] = handler(ctx, call)
+		}
+		return results
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	order := newCallOrder(len(calls))
+	for i, call := range calls {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer order.admit(i)
+			results[i] = handler(context.WithValue(ctx, orderKey{}, orderedCall{order, i}), call)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+type orderKey struct{}
+
+// orderedCall is a call's place in its turn.
+type orderedCall struct {
+	order *callOrder
+	index int
+}
+
+// callOrder tracks which of a turn's calls have been admitted.
+type callOrder struct {
+	admitted []chan struct{}
+	once     []sync.Once
+}
+
+func newCallOrder(n int) *callOrder {
+	o := &callOrder{admitted: make([]chan struct{}, n), once: make([]sync.Once, n)}
+	for i := range o.admitted {
+		o.admitted[i] = make(chan struct{})
+	}
+	return o
+}
+
+func (o *callOrder) admit(i int) {
+	o.once[i].Do(func() { close(o.admitted[i]) })
+}
+
+// InCallOrder waits until the calls that come before the one running on
+// ctx in its ToolLoop turn have been admitted, and returns the func that
+// admits this one. A handler that keeps state across calls, such as a
+// step count or a budget, calls InCallOrder before it reads that state
+// and admit once it has updated it, so the calls see the state in the
+// order the model made them however many run at once. ToolLoop admits a
+// call when its handler returns, and stops waiting if ctx is done.
+// Outside a concurrent ToolLoop turn, InCallOrder returns at once.
+func InCallOrder(ctx context.Context) (admit func()) {
+	c, ok := ctx.Value(orderKey{}).(orderedCall)
+	if !ok {
+		return func() {}
+	}
+	for i := 0; i < c.index; i++ {
+		select {
+		case <-c.order.admitted[i]:
+		case <-ctx.Done():
+		}
+	}
+	return func() { c.order.admit(c.index) }
+}
+
+func addUsage(a, b Usage) Usage {
+	return Usage{
+		InputTokens:      a.InputTokens + b.InputTokens,
+		OutputTokens:     a.OutputTokens + b.OutputTokens,
+		TotalTokens:      a.TotalTokens + b.TotalTokens,
+		CacheReadTokens:  a.CacheReadTokens + b.CacheReadTokens,
+		CacheWriteTokens: a.CacheWriteTokens + b.CacheWriteTokens,
+	}
+}
@@ -0,0 +1,205 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type turnFunc func(context.Context, *Request, []ToolRound) (*Response, error)
+
+func (f turnFunc) Turn(ctx context.Context, req *Request, rounds []ToolRound) (*Response, error) {
+	return f(ctx, req, rounds)
+}
+
+func TestToolLoopRunsRoundsUntilAnswer(t *testing.T) {
+	turns := 0
+	model := turnFunc(func(_ context.Context, _ *Request, rounds []ToolRound) (*Response, error) {
+		if len(rounds) != turns {
+			t.Fatalf("turn %d got %d rounds", turns, len(rounds))
+		}
+		turns++
+		switch len(rounds) {
+		case 0:
+			return &Response{Reply: "looking", ToolCalls: []ToolCall{{ID: "a", Name: "first"}}, Usage: Usage{TotalTokens: 1}}, nil
+		case 1:
+			if rounds[0].Reply != "looking" || rounds[0].Results[0].Content != "first-result" {
+				t.Fatalf("round = %+v", rounds[0])
+			}
+			return &Response{ToolCalls: []ToolCall{{ID: "b", Name: "second"}}, Usage: Usage{TotalTokens: 2}}, nil
+		default:
+			return &Response{Reply: "done", Usage: Usage{TotalTokens: 3}}, nil
+		}
+	})
+
+	resp, err := ToolLoop(context.Background(), model, &Request{Prompt: "hi"}, NewOptions(
+		WithToolHandler(func(_ context.Context, call ToolCall) ToolResult {
+			return ToolResult{ID: call.ID, Content: call.Name + "-result"}
+		}),
+	))
+	if err != nil {
+		t.Fatalf("ToolLoop returned error: %v", err)
+	}
+	if resp.Reply != "looking" || resp.Answer != "done" {
+		t.Fatalf("reply = %q, answer = %q", resp.Reply, resp.Answer)
+	}
+	if len(resp.ToolCalls) != 2 || resp.ToolCalls[0].Result != "first-result" || resp.ToolCalls[1].Result != "second-result" {
+		t.Fatalf("tool calls = %+v", resp.ToolCalls)
+	}
+	if resp.Usage.TotalTokens != 6 {
+		t.Fatalf("total tokens = %d, want 6", resp.Usage.TotalTokens)
+	}
+}
+
+func TestToolLoopRunsCallsConcurrently(t *testing.T) {
+	const calls = 6
+	model := turnFunc(func(_ context.Context, _ *Request, rounds []ToolRound) (*Response, error) {
+		if len(rounds) > 0 {
+			for i, res := range rounds[0].Results {
+				if want := fmt.Sprint(i); res.Content != want {
+					t.Fatalf("result %d = %q, want %q", i, res.Content, want)
+				}
+			}
+			return &Response{Reply: "done"}, nil
+		}
+		resp := &Response{}
+		for i := 0; i < calls; i++ {
+			resp.ToolCalls = append(resp.ToolCalls, ToolCall{ID: fmt.Sprint(i), Name: "slow"})
+		}
+		return resp, nil
+	})
+
+	var running, peak atomic.Int32
+	handler := func(_ context.Context, call ToolCall) ToolResult {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		return ToolResult{ID: call.ID, Content: call.ID}
+	}
+
+	for _, tc := range []struct {
+		concurrency int
+		want        int32
+	}{{1, 1}, {3, 3}} {
+		peak.Store(0)
+		_, err := ToolLoop(context.Background(), model, &Request{}, NewOptions(
+			WithToolHandler(handler),
+			WithToolConcurrency(tc.concurrency),
+		))
+		if err != nil {
+			t.Fatalf("ToolLoop returned error: %v", err)
+		}
+		if got := peak.Load(); got != tc.want {
+			t.Fatalf("concurrency %d: peak running = %d, want %d", tc.concurrency, got, tc.want)
+		}
+	}
+}
+
+func TestToolLoopStopsAtMaxRounds(t *testing.T) {
+	turns := 0
+	model := turnFunc(func(context.Context, *Request, []ToolRound) (*Response, error) {
+		turns++
+		return &Response{ToolCalls: []ToolCall{{ID: fmt.Sprint(turns), Name: "again"}}}, nil
+	})
+
+	executed := 0
+	resp, err := ToolLoop(context.Background(), model, &Request{}, NewOptions(
+		WithMaxToolRounds(2),
+		WithToolHandler(func(_ context.Context, call ToolCall) ToolResult {
+			executed++
+			return ToolResult{ID: call.ID, Content: "ok"}
+		}),
+	))
+	if err != nil {
+		t.Fatalf("ToolLoop returned error: %v", err)
+	}
+	if executed != 2 || turns != 3 {
+		t.Fatalf("executed = %d, turns = %d, want 2 and 3", executed, turns)
+	}
+	if len(resp.ToolCalls) != 3 || resp.ToolCalls[2].Result != "" {
+		t.Fatalf("tool calls = %+v, want the last one unexecuted", resp.ToolCalls)
+	}
+	if resp.Answer != "" || !resp.Truncated {
+		t.Fatalf("answer = %q, truncated = %v, want no answer and truncated", resp.Answer, resp.Truncated)
+	}
+}
+
+func TestToolLoopAdmitsCallsInOrder(t *testing.T) {
+	const calls = 8
+	model := turnFunc(func(_ context.Context, _ *Request, rounds []ToolRound) (*Response, error) {
+		if len(rounds) > 0 {
+			return &Response{Reply: "done"}, nil
+		}
+		resp := &Response{}
+		for i := 0; i < calls; i++ {
+			resp.ToolCalls = append(resp.ToolCalls, ToolCall{ID: fmt.Sprint(i), Name: "count"})
+		}
+		return resp, nil
+	})
+
+	// the handler keeps a counter; admitted in order, each call sees its
+	// own index however the goroutines are scheduled
+	var count int
+	resp, err := ToolLoop(context.Background(), model, &Request{}, NewOptions(
+		WithToolConcurrency(calls),
+		WithToolHandler(func(ctx context.Context, call ToolCall) ToolResult {
+			if call.ID == "0" {
+				time.Sleep(20 * time.Millisecond)
+			}
+			admit := InCallOrder(ctx)
+			n := count
+			count++
+			admit()
+			return ToolResult{ID: call.ID, Content: fmt.Sprint(n)}
+		}),
+	))
+	if err != nil {
+		t.Fatalf("ToolLoop returned error: %v", err)
+	}
+	for _, c := range resp.ToolCalls {
+		if c.Result != c.ID {
+			t.Fatalf("call %s saw count %s", c.ID, c.Result)
+		}
+	}
+	if resp.Truncated {
+		t.Fatal("finished loop reported as truncated")
+	}
+}
+
+func TestToolLoopReturnsFollowUpErrors(t *testing.T) {
+	model := turnFunc(func(_ context.Context, _ *Request, rounds []ToolRound) (*Response, error) {
+		if len(rounds) == 0 {
+			return &Response{ToolCalls: []ToolCall{{ID: "a", Name: "tool"}}}, nil
+		}
+		return nil, &HTTPError{Status: "429 Too Many Requests", Code: http.StatusTooManyRequests}
+	})
+
+	_, err := ToolLoop(context.Background(), model, &Request{}, NewOptions(
+		WithToolHandler(func(_ context.Context, call ToolCall) ToolResult {
+			return ToolResult{ID: call.ID, Refused: RefusedApproval, Content: "no"}
+		}),
+	))
+	var loopErr *ToolLoopError
+	if !errors.As(err, &loopErr) {
+		t.Fatalf("error = %v, want ToolLoopError", err)
+	}
+	if loopErr.Round != 1 || ClassifyError(err) != ErrorKindRateLimited {
+		t.Fatalf("round = %d, kind = %s", loopErr.Round, ClassifyError(err))
+	}
+	if calls := loopErr.Response.ToolCalls; len(calls) != 1 || calls[0].Error != RefusedApproval {
+		t.Fatalf("partial tool calls = %+v", calls)
+	}
+	if !IsTransientError(err) {
+		t.Fatal("rate limited follow-up should be transient")
+	}
+}
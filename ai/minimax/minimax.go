@@ -31,6 +31,7 @@ func init() {
 	})
 	ai.RegisterStream("minimax")
 	ai.RegisterToolStream("minimax")
+	ai.RegisterParams("minimax", ai.ParamTemperature, ai.ParamTopP)
 }
 
 type Provider struct {
@@ -59,82 +60,46 @@ func (p *Provider) Options() ai.Options { return p.opts }
 func (p *Provider) String() string      { return "minimax" }
 
 func (p *Provider) Generate(ctx context.Context, req *ai.Request, opts ...ai.GenerateOption) (*ai.Response, error) {
-	var tools []map[string]any
-	for _, t := range req.Tools {
-		tools = append(tools, map[string]any{
-			"type": "function",
-			"function": map[string]any{
-				"name":        t.Name,
-				"description": t.Description,
-				"parameters": map[string]any{
-					"type":       "object",
-					"properties": t.Properties,
-				},
-			},
-		})
-	}
+	return ai.ToolLoop(ctx, p, req, p.opts)
+}
 
+func (p *Provider) Turn(ctx context.Context, req *ai.Request, rounds []ai.ToolRound) (*ai.Response, error) {
+	content, err := openaiapi.Content("minimax", req.UserContent())
+	if err != nil {
+		return nil, err
+	}
 	messages := []map[string]any{
 		{"role": "system", "content": req.SystemPrompt},
-		{"role": "user", "content": req.Prompt},
+		{"role": "user", "content": content},
 	}
+	messages = append(messages, openaiapi.ToolMessages(rounds)...)
 
 	apiReq := map[string]any{
 		"model":    p.opts.Model,
 		"messages": messages,
 	}
-	if len(tools) > 0 {
+	if tools := openaiapi.Tools(req.Tools); len(tools) > 0 {
 		apiReq["tools"] = tools
 	}
+	openaiapi.SetParams(apiReq, ai.ProviderParams("minimax", p.opts.Params))
 
-	resp, rawMessage, err := p.callAPI(ctx, apiReq)
-	if err != nil {
-		return nil, err
-	}
-	if len(resp.ToolCalls) == 0 {
-		return resp, nil
-	}
-
-	if p.opts.ToolHandler != nil {
-		followUpMessages := append(messages, map[string]any{
-			"role":       "assistant",
-			"content":    rawMessage["content"],
-			"tool_calls": rawMessage["tool_calls"],
-		})
-		for _, tc := range resp.ToolCalls {
-			content := p.opts.ToolHandler(ctx, tc).Content
-			followUpMessages = append(followUpMessages, map[string]any{
-				"role":         "tool",
-				"tool_call_id": tc.ID,
-				"content":      content,
-			})
-		}
-		followUpResp, _, err := p.callAPI(ctx, map[string]any{
-			"model":    p.opts.Model,
-			"messages": followUpMessages,
-		})
-		if err == nil && followUpResp.Reply != "" {
-			resp.Answer = followUpResp.Reply
-		}
-	}
-
-	return resp, nil
+	return p.callAPI(ctx, apiReq)
 }
 
 func (p *Provider) Stream(ctx context.Context, req *ai.Request, opts ...ai.GenerateOption) (ai.Stream, error) {
-	return openaiapi.Stream(ctx, p.opts, req, "/v1/chat/completions")
+	return openaiapi.Stream(ctx, "minimax", p.opts, req, "/v1/chat/completions")
 }
 
-func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Response, map[string]any, error) {
+func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Response, error) {
 	reqBody, err := json.Marshal(req)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	apiURL := strings.TrimRight(p.opts.BaseURL, "/") + "/v1/chat/completions"
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(reqBody))
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
@@ -142,16 +107,17 @@ func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Respons
 
 	httpResp, err := http.DefaultClient.Do(httpReq)
 	if err != nil {
-		return nil, nil, fmt.Errorf("API request failed: %w", err)
+		return nil, fmt.Errorf("API request failed: %w", err)
 	}
 	defer httpResp.Body.Close()
 
 	respBody, _ := io.ReadAll(httpResp.Body)
 	if httpResp.StatusCode != http.StatusOK {
-		return nil, nil, ai.NewHTTPError(httpResp, respBody)
+		return nil, ai.NewHTTPError(httpResp, respBody)
 	}
 
 	var chatResp struct {
+		Usage   openaiapi.ChatUsage `json:"usage"`
 		Choices []struct {
 			Message struct {
 				Content   string `json:"content"`
@@ -167,14 +133,14 @@ func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Respons
 	}
 
 	if err := json.Unmarshal(respBody, &chatResp); err != nil {
-		return nil, nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 	if len(chatResp.Choices) == 0 {
-		return nil, nil, fmt.Errorf("no response from API")
+		return nil, fmt.Errorf("no response from API")
 	}
 
 	choice := chatResp.Choices[0]
-	response := &ai.Response{Reply: choice.Message.Content}
+	response := &ai.Response{Reply: choice.Message.Content, Usage: chatResp.Usage.Usage()}
 
 	for _, tc := range choice.Message.ToolCalls {
 		var input map[string]any
@@ -188,10 +154,5 @@ func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Respons
 		})
 	}
 
-	rawMessage := map[string]any{
-		"content":    choice.Message.Content,
-		"tool_calls": choice.Message.ToolCalls,
-	}
-
-	return response, rawMessage, nil
+	return response, nil
 }
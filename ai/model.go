@@ -34,6 +34,10 @@ type Tool struct {
 type Request struct {
 	// Prompt is the user's message/prompt
 	Prompt string
+	// Parts is the prompt as multimodal content (text, images, audio,
+	// files). When set, providers send it in place of Prompt, which
+	// should carry its text.
+	Parts []Part
 	// SystemPrompt is the system instruction for the model
 	SystemPrompt string
 	// Tools available for the model to use
@@ -43,10 +47,22 @@ type Request struct {
 	Messages []Message
 }
 
+// UserContent returns the content of the request's prompt message: Parts
+// when set, otherwise Prompt, or nil when there is neither.
+func (r *Request) UserContent() any {
+	switch {
+	case len(r.Parts) > 0:
+		return r.Parts
+	case r.Prompt != "":
+		return r.Prompt
+	}
+	return nil
+}
+
 // Message represents a conversation message
 type Message struct {
 	Role    string // "user", "assistant", "system", "tool"
-	Content any    // Can be string or structured content
+	Content any    // A string, or []Part for multimodal content
 }
 
 // Usage describes token counts returned by model providers.
@@ -54,6 +70,10 @@ type Usage struct {
 	InputTokens  int `json:"input_tokens,omitempty"`
 	OutputTokens int `json:"output_tokens,omitempty"`
 	TotalTokens  int `json:"total_tokens,omitempty"`
+	// CacheReadTokens and CacheWriteTokens are the part of InputTokens
+	// read from and written to the provider's prompt cache.
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
 }
 
 // Response represents the response from a model
@@ -66,6 +86,13 @@ type Response struct {
 	Answer string
 	// Usage contains provider token usage when available.
 	Usage Usage
+	// Cached is set on a response served by a CachedModel instead of
+	// the provider.
+	Cached bool `json:",omitempty"`
+	// Truncated is set when the tool loop ran out of rounds
+	// (MaxToolRounds) while the model still wanted tools, so Answer is
+	// empty and the last calls in ToolCalls were not executed.
+	Truncated bool `json:",omitempty"`
 }
 
 // ToolCall represents a request to call a tool and its result
@@ -112,6 +139,9 @@ const (
 	// RefusedSpendBudget means an agent refused a paid tool before execution
 	// because the configured per-run x402 spend budget would be exceeded.
 	RefusedSpendBudget = "spend_budget"
+	// RefusedGuardrail means an agent's content guardrail withheld a
+	// tool's result from the model, such as a suspected prompt injection.
+	RefusedGuardrail = "guardrail"
 )
 
 // RunInfo describes the agent run a tool call belongs to. The agent
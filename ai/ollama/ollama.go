@@ -38,6 +38,7 @@ import (
 	"strings"
 
 	"go-micro.dev/v6/ai"
+	"go-micro.dev/v6/ai/internal/openaiapi"
 )
 
 func init() {
@@ -46,6 +47,8 @@ func init() {
 	})
 	ai.RegisterStream("ollama")
 	ai.RegisterToolStream("ollama")
+	ai.RegisterInput("ollama", ai.PartImage)
+	ai.RegisterParams("ollama", ai.ParamTemperature, ai.ParamTopP, ai.ParamStop, ai.ParamSeed)
 }
 
 // Provider implements the ai.Model interface for Ollama.
@@ -108,12 +111,18 @@ func (p *Provider) streamPath() string {
 	return p.chatPath()
 }
 
-// Generate generates a response from the Ollama model.
+// Generate generates a response from the Ollama model, executing any tool
+// calls it makes with the tool handler.
 func (p *Provider) Generate(ctx context.Context, req *ai.Request, opts ...ai.GenerateOption) (*ai.Response, error) {
+	return ai.ToolLoop(ctx, p, req, p.opts)
+}
+
+// Turn makes a single chat call for req followed by the tool rounds so far.
+func (p *Provider) Turn(ctx context.Context, req *ai.Request, rounds []ai.ToolRound) (*ai.Response, error) {
 	if p.isCloud() {
-		return p.generateOpenAI(ctx, req)
+		return p.turnOpenAI(ctx, req, rounds)
 	}
-	return p.generateNative(ctx, req)
+	return p.turnNative(ctx, req, rounds)
 }
 
 // Stream generates a streaming response.
@@ -128,111 +137,38 @@ func (p *Provider) Stream(ctx context.Context, req *ai.Request, opts ...ai.Gener
 // OpenAI-compatible mode (Ollama Cloud: ollama.com/v1)
 // ---------------------------------------------------------------------------
 
-func (p *Provider) generateOpenAI(ctx context.Context, req *ai.Request) (*ai.Response, error) {
-	var tools []map[string]any
-	for _, t := range req.Tools {
-		tools = append(tools, map[string]any{
-			"type": "function",
-			"function": map[string]any{
-				"name":        t.Name,
-				"description": t.Description,
-				"parameters": map[string]any{
-					"type":       "object",
-					"properties": t.Properties,
-				},
-			},
-		})
+func (p *Provider) turnOpenAI(ctx context.Context, req *ai.Request, rounds []ai.ToolRound) (*ai.Response, error) {
+	messages, err := buildOpenAIMessages(req)
+	if err != nil {
+		return nil, err
 	}
-
-	messages := buildOpenAIMessages(req)
+	messages = append(messages, openaiapi.ToolMessages(rounds)...)
 	apiReq := map[string]any{
 		"model":    p.opts.Model,
 		"messages": messages,
 		"stream":   false,
 	}
-	if len(tools) > 0 {
+	if tools := openaiapi.Tools(req.Tools); len(tools) > 0 {
 		apiReq["tools"] = tools
 	}
 	if p.opts.MaxTokens > 0 {
 		apiReq["max_tokens"] = p.opts.MaxTokens
 	}
+	openaiapi.SetParams(apiReq, ai.ProviderParams("ollama", p.opts.Params))
 
-	resp, rawMsg, err := p.callOpenAI(ctx, apiReq)
-	if err != nil {
-		return nil, err
-	}
-
-	// No tool calls or no handler — return as-is.
-	if len(resp.ToolCalls) == 0 || p.opts.ToolHandler == nil {
-		return resp, nil
-	}
-
-	// Tool execution loop.
-	convMessages := append(messages, map[string]any{
-		"role":       "assistant",
-		"content":    rawMsg.content,
-		"tool_calls": rawMsg.toolCalls,
-	})
-
-	pendingCalls := resp.ToolCalls
-	for round := 0; round < 10; round++ {
-		for i := range pendingCalls {
-			result := p.opts.ToolHandler(ctx, pendingCalls[i])
-			pendingCalls[i].Result = result.Content
-			convMessages = append(convMessages, map[string]any{
-				"role":         "tool",
-				"tool_call_id": pendingCalls[i].ID,
-				"content":      result.Content,
-			})
-		}
-
-		followUpReq := map[string]any{
-			"model":    p.opts.Model,
-			"messages": convMessages,
-			"stream":   false,
-		}
-		if len(tools) > 0 {
-			followUpReq["tools"] = tools
-		}
-		if p.opts.MaxTokens > 0 {
-			followUpReq["max_tokens"] = p.opts.MaxTokens
-		}
-
-		followUpResp, followUpRaw, err := p.callOpenAI(ctx, followUpReq)
-		if err != nil {
-			break
-		}
-
-		if len(followUpResp.ToolCalls) > 0 {
-			resp.ToolCalls = append(resp.ToolCalls, followUpResp.ToolCalls...)
-			pendingCalls = followUpResp.ToolCalls
-			convMessages = append(convMessages, map[string]any{
-				"role":       "assistant",
-				"content":    followUpRaw.content,
-				"tool_calls": followUpRaw.toolCalls,
-			})
-			continue
-		}
-
-		if followUpResp.Reply != "" {
-			resp.Answer = followUpResp.Reply
-		}
-		break
-	}
-
-	return resp, nil
+	return p.callOpenAI(ctx, apiReq)
 }
 
-func (p *Provider) callOpenAI(ctx context.Context, req map[string]any) (*ai.Response, *rawChatMessage, error) {
+func (p *Provider) callOpenAI(ctx context.Context, req map[string]any) (*ai.Response, error) {
 	reqBody, err := json.Marshal(req)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	apiURL := strings.TrimRight(p.opts.BaseURL, "/") + p.chatPath()
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(reqBody))
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 	if p.opts.APIKey != "" {
@@ -241,13 +177,13 @@ func (p *Provider) callOpenAI(ctx context.Context, req map[string]any) (*ai.Resp
 
 	httpResp, err := http.DefaultClient.Do(httpReq)
 	if err != nil {
-		return nil, nil, fmt.Errorf("API request failed: %w", err)
+		return nil, fmt.Errorf("API request failed: %w", err)
 	}
 	defer httpResp.Body.Close()
 
 	respBody, _ := io.ReadAll(httpResp.Body)
 	if httpResp.StatusCode != http.StatusOK {
-		return nil, nil, fmt.Errorf("API error (%s): %s", httpResp.Status, string(respBody))
+		return nil, fmt.Errorf("API error (%s): %s", httpResp.Status, string(respBody))
 	}
 
 	var chatResp struct {
@@ -272,10 +208,10 @@ func (p *Provider) callOpenAI(ctx context.Context, req map[string]any) (*ai.Resp
 	}
 
 	if err := json.Unmarshal(respBody, &chatResp); err != nil {
-		return nil, nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 	if len(chatResp.Choices) == 0 {
-		return nil, nil, fmt.Errorf("no response from API")
+		return nil, fmt.Errorf("no response from API")
 	}
 
 	choice := chatResp.Choices[0]
@@ -288,7 +224,6 @@ func (p *Provider) callOpenAI(ctx context.Context, req map[string]any) (*ai.Resp
 		},
 	}
 
-	var rawToolCalls []map[string]any
 	for _, tc := range choice.Message.ToolCalls {
 		var input map[string]any
 		if err := json.Unmarshal([]byte(tc.Function.Arguments), &input); err != nil {
@@ -299,25 +234,16 @@ func (p *Provider) callOpenAI(ctx context.Context, req map[string]any) (*ai.Resp
 			Name:  tc.Function.Name,
 			Input: input,
 		})
-		rawToolCalls = append(rawToolCalls, map[string]any{
-			"id":   tc.ID,
-			"type": "function",
-			"function": map[string]any{
-				"name":      tc.Function.Name,
-				"arguments": tc.Function.Arguments,
-			},
-		})
 	}
 
-	raw := &rawChatMessage{
-		content:   choice.Message.Content,
-		toolCalls: rawToolCalls,
-	}
-	return response, raw, nil
+	return response, nil
 }
 
 func (p *Provider) streamOpenAI(ctx context.Context, req *ai.Request) (ai.Stream, error) {
-	messages := buildOpenAIMessages(req)
+	messages, err := buildOpenAIMessages(req)
+	if err != nil {
+		return nil, err
+	}
 	apiReq := map[string]any{
 		"model":          p.opts.Model,
 		"messages":       messages,
@@ -327,6 +253,7 @@ func (p *Provider) streamOpenAI(ctx context.Context, req *ai.Request) (ai.Stream
 	if p.opts.MaxTokens > 0 {
 		apiReq["max_tokens"] = p.opts.MaxTokens
 	}
+	openaiapi.SetParams(apiReq, ai.ProviderParams("ollama", p.opts.Params))
 
 	reqBody, err := json.Marshal(apiReq)
 	if err != nil {
@@ -358,18 +285,26 @@ func (p *Provider) streamOpenAI(ctx context.Context, req *ai.Request) (ai.Stream
 }
 
 // buildOpenAIMessages converts an ai.Request into the OpenAI chat message format.
-func buildOpenAIMessages(req *ai.Request) []map[string]any {
+func buildOpenAIMessages(req *ai.Request) ([]map[string]any, error) {
 	messages := []map[string]any{}
 	if req.SystemPrompt != "" {
 		messages = append(messages, map[string]any{"role": "system", "content": req.SystemPrompt})
 	}
 	for _, m := range req.Messages {
-		messages = append(messages, map[string]any{"role": m.Role, "content": m.Content})
+		content, err := openaiapi.Content("ollama", m.Content)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, map[string]any{"role": m.Role, "content": content})
 	}
-	if req.Prompt != "" {
-		messages = append(messages, map[string]any{"role": "user", "content": req.Prompt})
+	if c := req.UserContent(); c != nil {
+		content, err := openaiapi.Content("ollama", c)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, map[string]any{"role": "user", "content": content})
 	}
-	return messages
+	return messages, nil
 }
 
 // sseStream reads OpenAI-style server-sent events (used by Ollama Cloud).
@@ -436,122 +371,136 @@ func (s *sseStream) Close() error {
 // Native mode (local Ollama: localhost:11434/api/chat)
 // ---------------------------------------------------------------------------
 
-func (p *Provider) generateNative(ctx context.Context, req *ai.Request) (*ai.Response, error) {
-	var tools []map[string]any
-	for _, t := range req.Tools {
-		tools = append(tools, map[string]any{
-			"type": "function",
-			"function": map[string]any{
-				"name":        t.Name,
-				"description": t.Description,
-				"parameters": map[string]any{
-					"type":       "object",
-					"properties": t.Properties,
-				},
-			},
-		})
-	}
-
-	messages := []map[string]any{}
-	if req.SystemPrompt != "" {
-		messages = append(messages, map[string]any{"role": "system", "content": req.SystemPrompt})
-	}
-	for _, m := range req.Messages {
-		messages = append(messages, map[string]any{"role": m.Role, "content": m.Content})
-	}
-	if req.Prompt != "" {
-		messages = append(messages, map[string]any{"role": "user", "content": req.Prompt})
+func (p *Provider) turnNative(ctx context.Context, req *ai.Request, rounds []ai.ToolRound) (*ai.Response, error) {
+	messages, err := buildNativeMessages(req)
+	if err != nil {
+		return nil, err
 	}
+	messages = append(messages, nativeToolMessages(rounds)...)
 
 	apiReq := map[string]any{
 		"model":    p.opts.Model,
 		"messages": messages,
 		"stream":   false,
 	}
-	if len(tools) > 0 {
+	if tools := openaiapi.Tools(req.Tools); len(tools) > 0 {
 		apiReq["tools"] = tools
 	}
-	if p.opts.MaxTokens > 0 {
-		apiReq["options"] = map[string]any{"num_predict": p.opts.MaxTokens}
+	if options := nativeOptions(p.opts); len(options) > 0 {
+		apiReq["options"] = options
 	}
 
-	resp, rawMsg, err := p.callNative(ctx, apiReq)
-	if err != nil {
-		return nil, err
-	}
+	return p.callNative(ctx, apiReq)
+}
 
-	if len(resp.ToolCalls) == 0 || p.opts.ToolHandler == nil {
-		return resp, nil
+// nativeOptions returns the /api/chat model options for the max tokens
+// and generation parameters of o.
+func nativeOptions(o ai.Options) map[string]any {
+	options := map[string]any{}
+	if o.MaxTokens > 0 {
+		options["num_predict"] = o.MaxTokens
 	}
-
-	convMessages := append(messages, map[string]any{
-		"role":    "assistant",
-		"content": rawMsg.content,
-	})
-	if len(rawMsg.toolCalls) > 0 {
-		convMessages[len(convMessages)-1]["tool_calls"] = rawMsg.toolCalls
+	params := ai.ProviderParams("ollama", o.Params)
+	if params.Temperature != nil {
+		options["temperature"] = *params.Temperature
 	}
+	if params.TopP != nil {
+		options["top_p"] = *params.TopP
+	}
+	if len(params.Stop) > 0 {
+		options["stop"] = params.Stop
+	}
+	if params.Seed != nil {
+		options["seed"] = *params.Seed
+	}
+	return options
+}
 
-	pendingCalls := resp.ToolCalls
-	for round := 0; round < 10; round++ {
-		for i := range pendingCalls {
-			result := p.opts.ToolHandler(ctx, pendingCalls[i])
-			pendingCalls[i].Result = result.Content
-			convMessages = append(convMessages, map[string]any{
-				"role":    "tool",
-				"content": result.Content,
-			})
-		}
-
-		followUpReq := map[string]any{
-			"model":    p.opts.Model,
-			"messages": convMessages,
-			"stream":   false,
-		}
-		if len(tools) > 0 {
-			followUpReq["tools"] = tools
-		}
-		if p.opts.MaxTokens > 0 {
-			followUpReq["options"] = map[string]any{"num_predict": p.opts.MaxTokens}
+// buildNativeMessages converts an ai.Request into /api/chat messages.
+// Images go in a message's images field, base64 encoded.
+func buildNativeMessages(req *ai.Request) ([]map[string]any, error) {
+	messages := []map[string]any{}
+	if req.SystemPrompt != "" {
+		messages = append(messages, map[string]any{"role": "system", "content": req.SystemPrompt})
+	}
+	for _, m := range req.Messages {
+		msg, err := nativeMessage(m.Role, m.Content)
+		if err != nil {
+			return nil, err
 		}
-
-		followUpResp, followUpRaw, err := p.callNative(ctx, followUpReq)
+		messages = append(messages, msg)
+	}
+	if c := req.UserContent(); c != nil {
+		msg, err := nativeMessage("user", c)
 		if err != nil {
-			break
+			return nil, err
 		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
 
-		if len(followUpResp.ToolCalls) > 0 {
-			resp.ToolCalls = append(resp.ToolCalls, followUpResp.ToolCalls...)
-			pendingCalls = followUpResp.ToolCalls
-			convMessages = append(convMessages, map[string]any{
-				"role":    "assistant",
-				"content": followUpRaw.content,
-			})
-			if len(followUpRaw.toolCalls) > 0 {
-				convMessages[len(convMessages)-1]["tool_calls"] = followUpRaw.toolCalls
-			}
+func nativeMessage(role string, content any) (map[string]any, error) {
+	if !ai.HasMedia(content) {
+		return map[string]any{"role": role, "content": ai.ContentText(content)}, nil
+	}
+	parts, err := ai.ProviderParts("ollama", content)
+	if err != nil {
+		return nil, err
+	}
+	var images []string
+	for _, p := range parts {
+		if p.Type != ai.PartImage {
 			continue
 		}
-
-		if followUpResp.Reply != "" {
-			resp.Answer = followUpResp.Reply
+		// the native API only takes inline images
+		if len(p.Data) == 0 {
+			return nil, &ai.UnsupportedContentError{Provider: "ollama", Type: p.Type}
 		}
-		break
+		images = append(images, p.Base64())
 	}
+	return map[string]any{"role": role, "content": ai.ContentText(parts), "images": images}, nil
+}
 
-	return resp, nil
+// nativeToolMessages converts completed tool rounds to /api/chat messages.
+// Native tool calls carry no IDs, so results follow their calls in order.
+func nativeToolMessages(rounds []ai.ToolRound) []map[string]any {
+	var messages []map[string]any
+	for _, r := range rounds {
+		calls := make([]map[string]any, 0, len(r.Calls))
+		for _, call := range r.Calls {
+			calls = append(calls, map[string]any{
+				"function": map[string]any{
+					"name":      call.Name,
+					"arguments": call.Input,
+				},
+			})
+		}
+		messages = append(messages, map[string]any{
+			"role":       "assistant",
+			"content":    r.Reply,
+			"tool_calls": calls,
+		})
+		for _, res := range r.Results {
+			messages = append(messages, map[string]any{
+				"role":    "tool",
+				"content": res.Content,
+			})
+		}
+	}
+	return messages
 }
 
-func (p *Provider) callNative(ctx context.Context, req map[string]any) (*ai.Response, *rawChatMessage, error) {
+func (p *Provider) callNative(ctx context.Context, req map[string]any) (*ai.Response, error) {
 	reqBody, err := json.Marshal(req)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	apiURL := strings.TrimRight(p.opts.BaseURL, "/") + p.chatPath()
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(reqBody))
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 	if p.opts.APIKey != "" {
@@ -560,13 +509,13 @@ func (p *Provider) callNative(ctx context.Context, req map[string]any) (*ai.Resp
 
 	httpResp, err := http.DefaultClient.Do(httpReq)
 	if err != nil {
-		return nil, nil, fmt.Errorf("API request failed: %w", err)
+		return nil, fmt.Errorf("API request failed: %w", err)
 	}
 	defer httpResp.Body.Close()
 
 	respBody, _ := io.ReadAll(httpResp.Body)
 	if httpResp.StatusCode != http.StatusOK {
-		return nil, nil, fmt.Errorf("API error (%s): %s", httpResp.Status, string(respBody))
+		return nil, fmt.Errorf("API error (%s): %s", httpResp.Status, string(respBody))
 	}
 
 	var chatResp struct {
@@ -586,7 +535,7 @@ func (p *Provider) callNative(ctx context.Context, req map[string]any) (*ai.Resp
 	}
 
 	if err := json.Unmarshal(respBody, &chatResp); err != nil {
-		return nil, nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	response := &ai.Response{
@@ -598,7 +547,6 @@ func (p *Provider) callNative(ctx context.Context, req map[string]any) (*ai.Resp
 		},
 	}
 
-	var rawToolCalls []map[string]any
 	for _, tc := range chatResp.Message.ToolCalls {
 		var input map[string]any
 		switch v := tc.Function.Arguments.(type) {
@@ -615,31 +563,15 @@ func (p *Provider) callNative(ctx context.Context, req map[string]any) (*ai.Resp
 			Name:  tc.Function.Name,
 			Input: input,
 		})
-		rawToolCalls = append(rawToolCalls, map[string]any{
-			"function": map[string]any{
-				"name":      tc.Function.Name,
-				"arguments": tc.Function.Arguments,
-			},
-		})
 	}
 
-	raw := &rawChatMessage{
-		content:   chatResp.Message.Content,
-		toolCalls: rawToolCalls,
-	}
-	return response, raw, nil
+	return response, nil
 }
 
 func (p *Provider) streamNative(ctx context.Context, req *ai.Request) (ai.Stream, error) {
-	messages := []map[string]any{}
-	if req.SystemPrompt != "" {
-		messages = append(messages, map[string]any{"role": "system", "content": req.SystemPrompt})
-	}
-	for _, m := range req.Messages {
-		messages = append(messages, map[string]any{"role": m.Role, "content": m.Content})
-	}
-	if req.Prompt != "" {
-		messages = append(messages, map[string]any{"role": "user", "content": req.Prompt})
+	messages, err := buildNativeMessages(req)
+	if err != nil {
+		return nil, err
 	}
 
 	apiReq := map[string]any{
@@ -647,8 +579,8 @@ func (p *Provider) streamNative(ctx context.Context, req *ai.Request) (ai.Stream
 		"messages": messages,
 		"stream":   true,
 	}
-	if p.opts.MaxTokens > 0 {
-		apiReq["options"] = map[string]any{"num_predict": p.opts.MaxTokens}
+	if options := nativeOptions(p.opts); len(options) > 0 {
+		apiReq["options"] = options
 	}
 
 	reqBody, err := json.Marshal(apiReq)
@@ -721,10 +653,3 @@ func (s *ndjsonStream) Close() error {
 	s.closed = true
 	return s.body.Close()
 }
-
-// rawChatMessage holds the raw assistant content and tool calls for
-// follow-up messages.
-type rawChatMessage struct {
-	content   string
-	toolCalls []map[string]any
-}
@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"go-micro.dev/v6/ai"
+	"go-micro.dev/v6/ai/internal/openaiapi"
 )
 
 func init() {
@@ -23,6 +24,9 @@ func init() {
 	})
 	ai.RegisterStream("openai")
 	ai.RegisterToolStream("openai")
+	ai.RegisterInput("openai", ai.PartImage, ai.PartAudio, ai.PartFile)
+	ai.RegisterParams("openai", ai.ParamTemperature, ai.ParamTopP, ai.ParamStop, ai.ParamSeed,
+		ai.ParamReasoningEffort, ai.ParamPromptCache)
 }
 
 // Provider implements the ai.Model interface for OpenAI
@@ -65,36 +69,21 @@ func (p *Provider) String() string {
 	return "openai"
 }
 
-// Generate generates a response from the model
+// Generate generates a response from the model, executing any tool calls
+// it makes with the tool handler
 func (p *Provider) Generate(ctx context.Context, req *ai.Request, opts ...ai.GenerateOption) (*ai.Response, error) {
-	// Build tools for OpenAI format
-	var openaiTools []map[string]any
-	for _, t := range req.Tools {
-		openaiTools = append(openaiTools, map[string]any{
-			"type": "function",
-			"function": map[string]any{
-				"name":        t.Name,
-				"description": t.Description,
-				"parameters": map[string]any{
-					"type":       "object",
-					"properties": t.Properties,
-				},
-			},
-		})
-	}
+	return ai.ToolLoop(ctx, p, req, p.opts)
+}
 
-	// Build messages
-	messages := []map[string]any{
-		{"role": "system", "content": req.SystemPrompt},
-	}
-	for _, m := range req.Messages {
-		messages = append(messages, map[string]any{"role": m.Role, "content": m.Content})
-	}
-	if req.Prompt != "" {
-		messages = append(messages, map[string]any{"role": "user", "content": req.Prompt})
+// Turn makes a single chat completions call for req followed by the tool
+// rounds so far
+func (p *Provider) Turn(ctx context.Context, req *ai.Request, rounds []ai.ToolRound) (*ai.Response, error) {
+	messages, err := openaiapi.Messages("openai", req)
+	if err != nil {
+		return nil, err
 	}
+	messages = append(messages, openaiapi.ToolMessages(rounds)...)
 
-	// Build initial request
 	apiReq := map[string]any{
 		"model":    p.opts.Model,
 		"messages": messages,
@@ -102,65 +91,19 @@ func (p *Provider) Generate(ctx context.Context, req *ai.Request, opts ...ai.Gen
 	if p.opts.MaxTokens > 0 {
 		apiReq["max_tokens"] = p.opts.MaxTokens
 	}
-
-	if len(openaiTools) > 0 {
-		apiReq["tools"] = openaiTools
-	}
-
-	// Make API call
-	resp, rawMessage, err := p.callAPI(ctx, apiReq)
-	if err != nil {
-		return nil, err
-	}
-
-	// If no tool calls, return response
-	if len(resp.ToolCalls) == 0 {
-		return resp, nil
-	}
-
-	// If tool handler is provided, execute tools and get final answer
-	if p.opts.ToolHandler != nil {
-		// Build follow-up messages
-		followUpMessages := append(messages, map[string]any{
-			"role":       "assistant",
-			"content":    rawMessage["content"],
-			"tool_calls": rawMessage["tool_calls"],
-		})
-
-		for _, tc := range resp.ToolCalls {
-			content := p.opts.ToolHandler(ctx, tc).Content
-			followUpMessages = append(followUpMessages, map[string]any{
-				"role":         "tool",
-				"tool_call_id": tc.ID,
-				"content":      content,
-			})
-		}
-
-		followUpReq := map[string]any{
-			"model":    p.opts.Model,
-			"messages": followUpMessages,
-		}
-
-		// Make follow-up API call
-		followUpResp, _, err := p.callAPI(ctx, followUpReq)
-		if err == nil && followUpResp.Reply != "" {
-			resp.Answer = followUpResp.Reply
-		}
+	openaiapi.SetParams(apiReq, ai.ProviderParams("openai", p.opts.Params))
+	if tools := openaiapi.Tools(req.Tools); len(tools) > 0 {
+		apiReq["tools"] = tools
 	}
 
-	return resp, nil
+	return p.callAPI(ctx, apiReq)
 }
 
 // Stream generates a streaming response from the OpenAI chat completions API.
 func (p *Provider) Stream(ctx context.Context, req *ai.Request, opts ...ai.GenerateOption) (ai.Stream, error) {
-	messages := []map[string]any{
-		{"role": "system", "content": req.SystemPrompt},
-	}
-	for _, m := range req.Messages {
-		messages = append(messages, map[string]any{"role": m.Role, "content": m.Content})
-	}
-	if req.Prompt != "" {
-		messages = append(messages, map[string]any{"role": "user", "content": req.Prompt})
+	messages, err := openaiapi.Messages("openai", req)
+	if err != nil {
+		return nil, err
 	}
 	apiReq := map[string]any{
 		"model":          p.opts.Model,
@@ -171,6 +114,7 @@ func (p *Provider) Stream(ctx context.Context, req *ai.Request, opts ...ai.Gener
 	if p.opts.MaxTokens > 0 {
 		apiReq["max_tokens"] = p.opts.MaxTokens
 	}
+	openaiapi.SetParams(apiReq, ai.ProviderParams("openai", p.opts.Params))
 	reqBody, err := json.Marshal(apiReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal stream request: %w", err)
@@ -221,11 +165,7 @@ func (s *openAIStream) Recv() (*ai.Response, error) {
 					Content string `json:"content"`
 				} `json:"delta"`
 			} `json:"choices"`
-			Usage *struct {
-				PromptTokens     int `json:"prompt_tokens"`
-				CompletionTokens int `json:"completion_tokens"`
-				TotalTokens      int `json:"total_tokens"`
-			} `json:"usage"`
+			Usage *openaiapi.ChatUsage `json:"usage"`
 		}
 		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
 			return nil, fmt.Errorf("failed to parse stream chunk: %w", err)
@@ -235,11 +175,7 @@ func (s *openAIStream) Recv() (*ai.Response, error) {
 		}
 		// Final chunk (after include_usage) carries token usage and no content.
 		if chunk.Usage != nil {
-			return &ai.Response{Usage: ai.Usage{
-				InputTokens:  chunk.Usage.PromptTokens,
-				OutputTokens: chunk.Usage.CompletionTokens,
-				TotalTokens:  chunk.Usage.TotalTokens,
-			}}, nil
+			return &ai.Response{Usage: chunk.Usage.Usage()}, nil
 		}
 		continue
 	}
@@ -258,18 +194,18 @@ func (s *openAIStream) Close() error {
 }
 
 // callAPI makes an HTTP request to the OpenAI API
-func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Response, map[string]any, error) {
+func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Response, error) {
 	// Marshal request
 	reqBody, err := json.Marshal(req)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	// Build HTTP request
 	apiURL := strings.TrimRight(p.opts.BaseURL, "/") + "/v1/chat/completions"
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(reqBody))
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
@@ -279,23 +215,19 @@ func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Respons
 	// Make request
 	httpResp, err := http.DefaultClient.Do(httpReq)
 	if err != nil {
-		return nil, nil, fmt.Errorf("API request failed: %w", err)
+		return nil, fmt.Errorf("API request failed: %w", err)
 	}
 	defer httpResp.Body.Close()
 
 	// Read response
 	respBody, _ := io.ReadAll(httpResp.Body)
 	if httpResp.StatusCode != http.StatusOK {
-		return nil, nil, ai.NewHTTPError(httpResp, respBody)
+		return nil, ai.NewHTTPError(httpResp, respBody)
 	}
 
 	// Parse response
 	var chatResp struct {
-		Usage struct {
-			PromptTokens     int `json:"prompt_tokens"`
-			CompletionTokens int `json:"completion_tokens"`
-			TotalTokens      int `json:"total_tokens"`
-		} `json:"usage"`
+		Usage   openaiapi.ChatUsage `json:"usage"`
 		Choices []struct {
 			Message struct {
 				Content   string `json:"content"`
@@ -311,17 +243,17 @@ func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Respons
 	}
 
 	if err := json.Unmarshal(respBody, &chatResp); err != nil {
-		return nil, nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	if len(chatResp.Choices) == 0 {
-		return nil, nil, fmt.Errorf("no response from API")
+		return nil, fmt.Errorf("no response from API")
 	}
 
 	choice := chatResp.Choices[0]
 	response := &ai.Response{
 		Reply: choice.Message.Content,
-		Usage: ai.Usage{InputTokens: chatResp.Usage.PromptTokens, OutputTokens: chatResp.Usage.CompletionTokens, TotalTokens: chatResp.Usage.TotalTokens},
+		Usage: chatResp.Usage.Usage(),
 	}
 
 	// Extract tool calls
@@ -337,13 +269,7 @@ func (p *Provider) callAPI(ctx context.Context, req map[string]any) (*ai.Respons
 		})
 	}
 
-	// Return raw message for potential follow-up
-	rawMessage := map[string]any{
-		"content":    choice.Message.Content,
-		"tool_calls": choice.Message.ToolCalls,
-	}
-
-	return response, rawMessage, nil
+	return response, nil
 }
 
 const defaultImageModel = "gpt-image-1"
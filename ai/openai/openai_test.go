@@ -200,3 +200,85 @@ func TestProvider_GenerateImage_NoAPIKey(t *testing.T) {
 func TestProvider_ImplementsImageModel(t *testing.T) {
 	var _ ai.ImageModel = (*Provider)(nil)
 }
+
+func TestProvider_GenerateMultimodal(t *testing.T) {
+	var body map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"a cat"}}]}`))
+	}))
+	defer ts.Close()
+
+	p := NewProvider(ai.WithAPIKey("test-key"), ai.WithBaseURL(ts.URL))
+	resp, err := p.Generate(context.Background(), &ai.Request{
+		Prompt: "what is this?",
+		Parts: []ai.Part{
+			ai.TextPart("what is this?"),
+			ai.ImagePart("image/png", []byte("png")),
+			ai.AudioPart("audio/mpeg", []byte("mp3")),
+			ai.FilePart("a.pdf", "application/pdf", []byte("pdf")),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if resp.Reply != "a cat" {
+		t.Fatalf("reply = %q", resp.Reply)
+	}
+
+	messages := body["messages"].([]any)
+	content := messages[1].(map[string]any)["content"].([]any)
+	if len(content) != 4 {
+		t.Fatalf("content = %#v", content)
+	}
+	image := content[1].(map[string]any)["image_url"].(map[string]any)
+	if image["url"] != "data:image/png;base64,cG5n" {
+		t.Fatalf("image = %#v", image)
+	}
+	audio := content[2].(map[string]any)["input_audio"].(map[string]any)
+	if audio["format"] != "mp3" || audio["data"] != "bXAz" {
+		t.Fatalf("audio = %#v", audio)
+	}
+	file := content[3].(map[string]any)["file"].(map[string]any)
+	if file["filename"] != "a.pdf" || file["file_data"] != "data:application/pdf;base64,cGRm" {
+		t.Fatalf("file = %#v", file)
+	}
+}
+
+func TestProvider_GenerateParams(t *testing.T) {
+	var body map[string]any
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}],"usage":{"prompt_tokens":2100,"completion_tokens":10,"total_tokens":2110,"prompt_tokens_details":{"cached_tokens":1920}}}`))
+	}))
+	defer ts.Close()
+
+	p := NewProvider(
+		ai.WithAPIKey("test-key"),
+		ai.WithBaseURL(ts.URL),
+		ai.WithTemperature(0.2),
+		ai.WithTopP(0.9),
+		ai.WithStop("END"),
+		ai.WithSeed(7),
+		ai.WithReasoningEffort(ai.ReasoningLow),
+		ai.WithPromptCache("support"),
+	)
+	resp, err := p.Generate(context.Background(), &ai.Request{SystemPrompt: "You are support.", Prompt: "hi"})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if body["temperature"] != 0.2 || body["top_p"] != 0.9 || body["seed"] != 7.0 ||
+		body["reasoning_effort"] != "low" || body["prompt_cache_key"] != "support" || body["stop"].([]any)[0] != "END" {
+		t.Fatalf("request = %#v", body)
+	}
+	want := ai.Usage{InputTokens: 2100, OutputTokens: 10, TotalTokens: 2110, CacheReadTokens: 1920}
+	if resp.Usage != want {
+		t.Fatalf("usage = %+v, want %+v", resp.Usage, want)
+	}
+}
@@ -18,6 +18,14 @@ type Options struct {
 	ToolHandler ToolHandler
 	// MaxTokens caps the length of the response (0 = provider default)
 	MaxTokens int
+	// MaxToolRounds caps how many times tools are executed and the results
+	// sent back to the model in one Generate (0 = DefaultMaxToolRounds)
+	MaxToolRounds int
+	// ToolConcurrency caps how many of a turn's tool calls are executed at
+	// once (0 = DefaultToolConcurrency, 1 = one at a time)
+	ToolConcurrency int
+	// Params are the generation parameters sent with each request
+	Params Params
 }
 
 // GenerateOptions for generate call
@@ -101,3 +109,74 @@ func WithMaxTokens(n int) Option {
 		o.MaxTokens = n
 	}
 }
+
+// WithMaxToolRounds caps how many rounds of tool execution a Generate call
+// runs before returning. 0 uses DefaultMaxToolRounds.
+func WithMaxToolRounds(n int) Option {
+	return func(o *Options) {
+		o.MaxToolRounds = n
+	}
+}
+
+// WithToolConcurrency caps how many tool calls from the same model turn
+// are executed at once. Use 1 when the ToolHandler isn't safe to call
+// concurrently. 0 uses DefaultToolConcurrency.
+func WithToolConcurrency(n int) Option {
+	return func(o *Options) {
+		o.ToolConcurrency = n
+	}
+}
+
+// WithParams sets all the generation parameters at once, replacing any
+// set before.
+func WithParams(p Params) Option {
+	return func(o *Options) {
+		o.Params = p
+	}
+}
+
+// WithTemperature sets the sampling temperature.
+func WithTemperature(t float64) Option {
+	return func(o *Options) {
+		o.Params.Temperature = &t
+	}
+}
+
+// WithTopP sets the nucleus sampling probability mass.
+func WithTopP(p float64) Option {
+	return func(o *Options) {
+		o.Params.TopP = &p
+	}
+}
+
+// WithStop sets sequences that end the response when generated.
+func WithStop(sequences ...string) Option {
+	return func(o *Options) {
+		o.Params.Stop = sequences
+	}
+}
+
+// WithSeed asks for deterministic sampling where the provider offers it.
+func WithSeed(seed int) Option {
+	return func(o *Options) {
+		o.Params.Seed = &seed
+	}
+}
+
+// WithReasoningEffort sets how much a reasoning model thinks before
+// answering.
+func WithReasoningEffort(e ReasoningEffort) Option {
+	return func(o *Options) {
+		o.Params.ReasoningEffort = e
+	}
+}
+
+// WithPromptCache asks the provider to cache the stable prefix of each
+// request, so long system prompts and tool lists aren't billed in full
+// every turn. key optionally groups requests that share a prefix.
+func WithPromptCache(key string) Option {
+	return func(o *Options) {
+		o.Params.PromptCache = true
+		o.Params.PromptCacheKey = key
+	}
+}
@@ -0,0 +1,108 @@
+package ai
+
+// Param names a generation parameter in Params.
+type Param string
+
+const (
+	ParamTemperature     Param = "temperature"
+	ParamTopP            Param = "top_p"
+	ParamStop            Param = "stop"
+	ParamSeed            Param = "seed"
+	ParamReasoningEffort Param = "reasoning_effort"
+	ParamPromptCache     Param = "prompt_cache"
+)
+
+// ReasoningEffort is how much a reasoning model thinks before answering.
+type ReasoningEffort string
+
+const (
+	ReasoningLow    ReasoningEffort = "low"
+	ReasoningMedium ReasoningEffort = "medium"
+	ReasoningHigh   ReasoningEffort = "high"
+)
+
+// Params are the generation parameters sent with every request. Nil and
+// zero fields leave the provider default in place. Each provider sends
+// the parameters it registered with RegisterParams and drops the rest,
+// so the same Params work across providers; ProviderCapabilities reports
+// which ones a provider takes.
+type Params struct {
+	// Temperature controls randomness, typically 0 to 1 (or 2).
+	Temperature *float64 `json:"temperature,omitempty"`
+	// TopP is the nucleus sampling probability mass.
+	TopP *float64 `json:"top_p,omitempty"`
+	// Stop sequences end the response when generated.
+	Stop []string `json:"stop,omitempty"`
+	// Seed asks for deterministic sampling where the provider offers it.
+	Seed *int `json:"seed,omitempty"`
+	// ReasoningEffort is passed to reasoning models.
+	ReasoningEffort ReasoningEffort `json:"reasoning_effort,omitempty"`
+	// PromptCache asks the provider to cache the stable prefix of each
+	// request: the tools, system prompt and conversation so far. Anthropic
+	// marks it with cache_control breakpoints. OpenAI caches prefixes on
+	// its own; PromptCacheKey routes requests sharing a prefix to the same
+	// cache. Cache reads and writes are reported in Usage.
+	PromptCache bool `json:"prompt_cache,omitempty"`
+	// PromptCacheKey identifies requests that share a prefix, such as one
+	// agent's system prompt and tools.
+	PromptCacheKey string `json:"prompt_cache_key,omitempty"`
+}
+
+// RegisterParams records that provider sends the given Params fields.
+// Providers call this from init alongside Register.
+func RegisterParams(provider string, params ...Param) {
+	for _, p := range params {
+		if paramProviders[p] == nil {
+			paramProviders[p] = make(map[string]struct{})
+		}
+		paramProviders[p][provider] = struct{}{}
+	}
+}
+
+// ProviderParams returns p with the fields provider doesn't support
+// cleared. Providers call it before mapping Params onto a request.
+func ProviderParams(provider string, p Params) Params {
+	if !supportsParam(provider, ParamTemperature) {
+		p.Temperature = nil
+	}
+	if !supportsParam(provider, ParamTopP) {
+		p.TopP = nil
+	}
+	if !supportsParam(provider, ParamStop) {
+		p.Stop = nil
+	}
+	if !supportsParam(provider, ParamSeed) {
+		p.Seed = nil
+	}
+	if !supportsParam(provider, ParamReasoningEffort) {
+		p.ReasoningEffort = ""
+	}
+	if !supportsParam(provider, ParamPromptCache) {
+		p.PromptCache, p.PromptCacheKey = false, ""
+	}
+	return p
+}
+
+// UnsupportedParams lists the fields set in p that provider drops.
+func UnsupportedParams(provider string, p Params) []Param {
+	var out []Param
+	add := func(set bool, param Param) {
+		if set && !supportsParam(provider, param) {
+			out = append(out, param)
+		}
+	}
+	add(p.Temperature != nil, ParamTemperature)
+	add(p.TopP != nil, ParamTopP)
+	add(len(p.Stop) > 0, ParamStop)
+	add(p.Seed != nil, ParamSeed)
+	add(p.ReasoningEffort != "", ParamReasoningEffort)
+	add(p.PromptCache || p.PromptCacheKey != "", ParamPromptCache)
+	return out
+}
+
+func supportsParam(provider string, p Param) bool {
+	_, ok := paramProviders[p][provider]
+	return ok
+}
+
+var paramProviders = make(map[Param]map[string]struct{})
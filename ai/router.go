@@ -0,0 +1,534 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go-micro.dev/v6/config"
+)
+
+// DefaultRouteCooldown is the longest a Router keeps a failing route out
+// of rotation. Auth and configuration failures sit out the full cooldown;
+// transient failures back off exponentially up to it, or for as long as
+// the provider's Retry-After asks.
+var DefaultRouteCooldown = time.Minute
+
+// ErrNoRoute is returned by a Router that has no route able to serve a
+// request, because none are configured or none has the capabilities the
+// request needs.
+var ErrNoRoute = errors.New("ai router: missing a usable route")
+
+// latencySamples is how many recent Generate latencies a route keeps to
+// compute its p50.
+const latencySamples = 32
+
+// Route is one provider/model pair a Router can send requests to.
+type Route struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	APIKey   string `json:"api_key"`
+	BaseURL  string `json:"base_url"`
+	// Cost is the route's relative price, used by RouteCheapest. Any unit
+	// works as long as the routes agree; 0 means unknown.
+	Cost float64 `json:"cost"`
+}
+
+// RoutePolicy decides the order in which a Router tries its healthy routes.
+type RoutePolicy string
+
+const (
+	// RouteOrdered tries routes in the order they were configured.
+	RouteOrdered RoutePolicy = "ordered"
+	// RouteCheapest tries the lowest Cost first; routes without a cost go last.
+	RouteCheapest RoutePolicy = "cheapest"
+	// RouteFastest tries the lowest observed p50 latency first. Routes that
+	// haven't answered yet go first so they get measured.
+	RouteFastest RoutePolicy = "fastest"
+)
+
+// RouterConfig is the routing table of a Router. Without WithRoutes it is
+// read from go-micro config under ai.router:
+//
+//	{"ai": {"router": {
+//	    "policy": "cheapest",
+//	    "require": {"stream": true},
+//	    "routes": [
+//	        {"provider": "groq", "model": "llama-3.3-70b-versatile", "api_key": "...", "cost": 0.6},
+//	        {"provider": "anthropic", "model": "claude-sonnet-4-20250514", "api_key": "...", "cost": 3}
+//	    ]
+//	}}}
+type RouterConfig struct {
+	Policy RoutePolicy `json:"policy"`
+	Routes []Route     `json:"routes"`
+	// Require limits every request to routes whose provider has these
+	// capabilities, as reported by ProviderCapabilities.
+	Require Capabilities `json:"require"`
+}
+
+// RouteHealth is a snapshot of a route's health as seen by a Router.
+type RouteHealth struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	// Healthy is false while the route is cooling down after a failure.
+	Healthy  bool      `json:"healthy"`
+	Failures int       `json:"failures"`
+	RetryAt  time.Time `json:"retry_at,omitzero"`
+	// LastError is the most recent failure, cleared by a success.
+	LastError string        `json:"last_error,omitempty"`
+	P50       time.Duration `json:"p50"`
+}
+
+type routesKey struct{}
+type routePolicyKey struct{}
+type routeRequireKey struct{}
+type routeSourceKey struct{}
+
+// WithRoutes sets a Router's routes, in priority order. Without it the
+// Router reads its routing table from config.
+func WithRoutes(routes ...Route) Option {
+	return setOption(routesKey{}, routes)
+}
+
+// WithRoutePolicy sets the order in which a Router tries its healthy
+// routes. It overrides the policy read from config. The default is
+// RouteOrdered.
+func WithRoutePolicy(p RoutePolicy) Option {
+	return setOption(routePolicyKey{}, p)
+}
+
+// WithRouteRequire limits a Router to routes whose provider has the
+// given capabilities. It overrides the requirement read from config.
+func WithRouteRequire(c Capabilities) Option {
+	return setOption(routeRequireKey{}, c)
+}
+
+// WithRouteConfig sets the config a Router reads its routing table from
+// when WithRoutes isn't given. The default is config.DefaultConfig.
+func WithRouteConfig(c config.Config) Option {
+	return setOption(routeSourceKey{}, c)
+}
+
+func setOption(k, v any) Option {
+	return func(o *Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, k, v)
+	}
+}
+
+func init() {
+	Register("router", func(opts ...Option) Model {
+		return NewRouter(opts...)
+	})
+	derivedCapabilities["router"] = routerCapabilities
+}
+
+// routerCapabilities reports what a Router can do in this build: what any
+// model provider it could route to can. Image and video models aren't
+// routed.
+func routerCapabilities() Capabilities {
+	c := Capabilities{Model: true}
+	for name := range providers {
+		if name != "router" {
+			c = unionCapabilities(c, ProviderCapabilities(name))
+		}
+	}
+	c.Image, c.Video = false, false
+	return c
+}
+
+// Router is a Model that sends each request to one of several
+// provider/model routes. It tries them in the order its policy picks,
+// falling back to the next when a route fails, and keeps routes that
+// keep failing out of rotation for a while. Select it with
+// ai.New("router") or agent.Provider("router").
+//
+// A request only falls back when the failure is the route's, such as an
+// unreachable or rate limited provider or a bad API key. A request the
+// provider rejected, or the caller canceled, would fail the same way on
+// every route and is returned as is. Nor is a request retried on another
+// route once tools have run, since that would run them again; the
+// ToolLoopError is returned instead.
+type Router struct {
+	opts Options
+	now  func() time.Time
+
+	mu     sync.Mutex
+	cfg    RouterConfig
+	routes []*route
+	// errs holds why the config or a route couldn't be loaded
+	errs []error
+}
+
+type route struct {
+	Route
+	model Model
+	caps  Capabilities
+
+	failures int
+	lastErr  error
+	retryAt  time.Time
+	samples  []time.Duration
+	next     int
+}
+
+func (r *route) String() string {
+	if r.Model == "" {
+		return r.Provider
+	}
+	return r.Provider + "/" + r.Model
+}
+
+func (r *route) p50() time.Duration {
+	if len(r.samples) == 0 {
+		return 0
+	}
+	s := slices.Clone(r.samples)
+	slices.Sort(s)
+	return s[len(s)/2]
+}
+
+// NewRouter returns a Router over the routes set with WithRoutes, or
+// read from config.
+func NewRouter(opts ...Option) *Router {
+	r := &Router{opts: NewOptions(), now: time.Now}
+	r.Init(opts...)
+	return r
+}
+
+func (r *Router) Init(opts ...Option) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, o := range opts {
+		o(&r.opts)
+	}
+
+	r.routes, r.errs = nil, nil
+	cfg, err := r.loadConfig()
+	if err != nil {
+		r.errs = append(r.errs, err)
+		return err
+	}
+	r.cfg = cfg
+	for _, rt := range cfg.Routes {
+		fn, ok := providers[rt.Provider]
+		if !ok || rt.Provider == "router" {
+			r.errs = append(r.errs, fmt.Errorf("ai router: unsupported provider %q", rt.Provider))
+			continue
+		}
+		r.routes = append(r.routes, &route{
+			Route: rt,
+			caps:  ProviderCapabilities(rt.Provider),
+			model: fn(
+				WithContext(r.opts.Context),
+				WithModel(rt.Model),
+				WithAPIKey(rt.APIKey),
+				WithBaseURL(rt.BaseURL),
+				WithToolHandler(r.opts.ToolHandler),
+				WithMaxTokens(r.opts.MaxTokens),
+				WithMaxToolRounds(r.opts.MaxToolRounds),
+				WithToolConcurrency(r.opts.ToolConcurrency),
+				WithParams(r.opts.Params),
+			),
+		})
+	}
+	return nil
+}
+
+func (r *Router) loadConfig() (RouterConfig, error) {
+	var cfg RouterConfig
+	ctx := r.opts.Context
+	if routes, ok := ctx.Value(routesKey{}).([]Route); ok {
+		cfg.Routes = routes
+	} else {
+		src, ok := ctx.Value(routeSourceKey{}).(config.Config)
+		if !ok {
+			src = config.DefaultConfig
+		}
+		if src != nil {
+			if val, err := src.Get("ai", "router"); err == nil {
+				if err := val.Scan(&cfg); err != nil {
+					return cfg, fmt.Errorf("ai router: reading config: %w", err)
+				}
+			}
+		}
+	}
+	if p, ok := ctx.Value(routePolicyKey{}).(RoutePolicy); ok {
+		cfg.Policy = p
+	}
+	if c, ok := ctx.Value(routeRequireKey{}).(Capabilities); ok {
+		cfg.Require = c
+	}
+	switch cfg.Policy {
+	case "":
+		cfg.Policy = RouteOrdered
+	case RouteOrdered, RouteCheapest, RouteFastest:
+	default:
+		return cfg, fmt.Errorf("ai router: unsupported policy %q", cfg.Policy)
+	}
+	return cfg, nil
+}
+
+func (r *Router) Options() Options {
+	return r.opts
+}
+
+// Capabilities reports what the router can serve: the union of the
+// capabilities of its routes that meet its requirement.
+func (r *Router) Capabilities() Capabilities {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var c Capabilities
+	for _, rt := range r.routes {
+		if supports(rt.caps, r.cfg.Require) {
+			c = unionCapabilities(c, rt.caps)
+		}
+	}
+	c.Image, c.Video = false, false
+	return c
+}
+
+// Generate sends req to the first route that answers, among those whose
+// provider takes the images, audio and files in req. When every route
+// fails, the error is a RetryError joining each route's failure.
+func (r *Router) Generate(ctx context.Context, req *Request, opts ...GenerateOption) (*Response, error) {
+	routes, err := r.candidates(requestInputs(req))
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, rt := range routes {
+		start := r.now()
+		resp, err := rt.model.Generate(ctx, req, opts...)
+		if err == nil {
+			r.succeed(rt, r.now().Sub(start))
+			return resp, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			// the caller gave up; that says nothing about the route
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", rt, err))
+		if !routeFault(err) {
+			break
+		}
+		r.fail(rt, err)
+
+		var loopErr *ToolLoopError
+		if errors.As(err, &loopErr) {
+			break
+		}
+	}
+	return nil, routeError(errs)
+}
+
+// Stream opens a stream on the first route whose provider streams, and
+// streams tools when req has any, falling back if opening it fails.
+func (r *Router) Stream(ctx context.Context, req *Request, opts ...GenerateOption) (Stream, error) {
+	need := requestInputs(req)
+	need.Stream, need.ToolStream = true, len(req.Tools) > 0
+	routes, err := r.candidates(need)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, rt := range routes {
+		stream, err := rt.model.Stream(ctx, req, opts...)
+		if err == nil {
+			r.succeed(rt, 0)
+			return stream, nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", rt, err))
+		if !routeFault(err) {
+			break
+		}
+		r.fail(rt, err)
+	}
+	return nil, routeError(errs)
+}
+
+// requestInputs returns the input capabilities a route needs to send the
+// content of req and its messages.
+func requestInputs(req *Request) Capabilities {
+	c := contentInputs(req.Parts)
+	for _, m := range req.Messages {
+		c = unionCapabilities(c, contentInputs(m.Content))
+	}
+	return c
+}
+
+func routeError(errs []error) error {
+	if len(errs) == 1 {
+		return errors.Unwrap(errs[0])
+	}
+	last := errors.Unwrap(errs[len(errs)-1])
+	return &RetryError{Attempts: len(errs), Kind: ClassifyError(last), Err: errors.Join(errs...)}
+}
+
+func (r *Router) String() string {
+	return "router"
+}
+
+// Health reports the health of each route, in configured order.
+func (r *Router) Health() []RouteHealth {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	out := make([]RouteHealth, 0, len(r.routes))
+	for _, rt := range r.routes {
+		h := RouteHealth{
+			Provider: rt.Provider,
+			Model:    rt.Model,
+			Healthy:  !now.Before(rt.retryAt),
+			Failures: rt.failures,
+			P50:      rt.p50(),
+		}
+		if !h.Healthy {
+			h.RetryAt = rt.retryAt
+		}
+		if rt.lastErr != nil {
+			h.LastError = rt.lastErr.Error()
+		}
+		out = append(out, h)
+	}
+	return out
+}
+
+// candidates returns the routes able to serve a request needing need, and
+// the router's own requirement: healthy ones in policy order, then those
+// cooling down, soonest back first, as a last resort.
+func (r *Router) candidates(need Capabilities) ([]*route, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var healthy, cooling []*route
+	now := r.now()
+	for _, rt := range r.routes {
+		if !supports(rt.caps, need) || !supports(rt.caps, r.cfg.Require) {
+			continue
+		}
+		if now.Before(rt.retryAt) {
+			cooling = append(cooling, rt)
+		} else {
+			healthy = append(healthy, rt)
+		}
+	}
+	if len(healthy)+len(cooling) == 0 {
+		return nil, errors.Join(append([]error{ErrNoRoute}, r.errs...)...)
+	}
+
+	switch r.cfg.Policy {
+	case RouteCheapest:
+		sort.SliceStable(healthy, func(i, j int) bool {
+			a, b := healthy[i].Cost, healthy[j].Cost
+			return a > 0 && (b <= 0 || a < b)
+		})
+	case RouteFastest:
+		p50 := make(map[*route]time.Duration, len(healthy))
+		for _, rt := range healthy {
+			p50[rt] = rt.p50()
+		}
+		sort.SliceStable(healthy, func(i, j int) bool {
+			return p50[healthy[i]] < p50[healthy[j]]
+		})
+	}
+	sort.SliceStable(cooling, func(i, j int) bool {
+		return cooling[i].retryAt.Before(cooling[j].retryAt)
+	})
+	return append(healthy, cooling...), nil
+}
+
+// supports reports whether have includes every capability set in need.
+func supports(have, need Capabilities) bool {
+	return (!need.Model || have.Model) &&
+		(!need.Image || have.Image) &&
+		(!need.Video || have.Video) &&
+		(!need.Stream || have.Stream) &&
+		(!need.ToolStream || have.ToolStream) &&
+		(!need.ImageInput || have.ImageInput) &&
+		(!need.AudioInput || have.AudioInput) &&
+		(!need.FileInput || have.FileInput) &&
+		(!need.Temperature || have.Temperature) &&
+		(!need.TopP || have.TopP) &&
+		(!need.Stop || have.Stop) &&
+		(!need.Seed || have.Seed) &&
+		(!need.ReasoningEffort || have.ReasoningEffort) &&
+		(!need.PromptCache || have.PromptCache)
+}
+
+func (r *Router) succeed(rt *route, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rt.failures, rt.lastErr, rt.retryAt = 0, nil, time.Time{}
+	if latency <= 0 {
+		return
+	}
+	if len(rt.samples) < latencySamples {
+		rt.samples = append(rt.samples, latency)
+		return
+	}
+	rt.samples[rt.next] = latency
+	rt.next = (rt.next + 1) % latencySamples
+}
+
+// routeFault reports whether err is the route's fault, so that another
+// route may succeed: the provider was unreachable, overloaded or slow,
+// the route's credentials or model are wrong, or its reply couldn't be
+// read. A canceled request, and one the provider rejected with another
+// 4xx status, would fail the same way anywhere.
+func routeFault(err error) bool {
+	switch ClassifyError(err) {
+	case ErrorKindCanceled:
+		return false
+	case ErrorKindTimeout, ErrorKindRateLimited, ErrorKindUnavailable, ErrorKindAuth:
+		return true
+	}
+	var sc StatusCoder
+	if errors.As(err, &sc) && sc.StatusCode() > 0 {
+		// 402: the route's account is out of credit; 404: its model is unknown
+		code := sc.StatusCode()
+		return code == 402 || code == 404
+	}
+	return true
+}
+
+func (r *Router) fail(rt *route, err error) {
+	var contentErr *UnsupportedContentError
+	if errors.As(err, &contentErr) {
+		// another route may take the content, but this one is fine
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rt.failures++
+	rt.lastErr = err
+
+	var cooldown time.Duration
+	switch {
+	case IsTransientError(err):
+		cooldown = min(retryBackoff(err, rt.failures, time.Second), DefaultRouteCooldown)
+	case ClassifyError(err) == ErrorKindAuth, ClassifyError(err) == ErrorKindConfiguration:
+		cooldown = DefaultRouteCooldown
+	default:
+		// the request may be at fault rather than the route
+		return
+	}
+	rt.retryAt = r.now().Add(cooldown)
+}
@@ -0,0 +1,362 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/config"
+	"go-micro.dev/v6/config/source/memory"
+)
+
+// fakeModel answers Generate and Stream with gen, recording calls.
+type fakeModel struct {
+	opts  Options
+	calls int
+	gen   func() (*Response, error)
+}
+
+func (f *fakeModel) Init(opts ...Option) error {
+	for _, o := range opts {
+		o(&f.opts)
+	}
+	return nil
+}
+func (f *fakeModel) Options() Options { return f.opts }
+func (f *fakeModel) String() string   { return "fake" }
+
+func (f *fakeModel) Generate(context.Context, *Request, ...GenerateOption) (*Response, error) {
+	f.calls++
+	return f.gen()
+}
+
+func (f *fakeModel) Stream(context.Context, *Request, ...GenerateOption) (Stream, error) {
+	f.calls++
+	if _, err := f.gen(); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// registerFake registers a provider that builds m, with the given
+// streaming and input capabilities, for the duration of the test.
+func registerFake(t *testing.T, name string, m *fakeModel, stream, toolStream bool, inputs ...PartType) {
+	t.Helper()
+	Register(name, func(opts ...Option) Model {
+		m.Init(opts...)
+		return m
+	})
+	if stream {
+		RegisterStream(name)
+	}
+	if toolStream {
+		RegisterToolStream(name)
+	}
+	RegisterInput(name, inputs...)
+	t.Cleanup(func() {
+		delete(providers, name)
+		delete(streamProviders, name)
+		delete(toolStreamProviders, name)
+		for _, registry := range inputProviders {
+			delete(registry, name)
+		}
+	})
+}
+
+func reply(s string) func() (*Response, error) {
+	return func() (*Response, error) { return &Response{Reply: s}, nil }
+}
+
+func failWith(code int) func() (*Response, error) {
+	return func() (*Response, error) {
+		return nil, &HTTPError{Status: http.StatusText(code), Code: code}
+	}
+}
+
+func TestRouterFallsBackOnClassifiedErrors(t *testing.T) {
+	limited := &fakeModel{gen: failWith(http.StatusTooManyRequests)}
+	backup := &fakeModel{gen: reply("backup")}
+	registerFake(t, "fake-limited", limited, false, false)
+	registerFake(t, "fake-backup", backup, false, false)
+
+	r := NewRouter(WithRoutes(
+		Route{Provider: "fake-limited", Model: "a", APIKey: "key-a"},
+		Route{Provider: "fake-backup", Model: "b"},
+	))
+	if limited.opts.Model != "a" || limited.opts.APIKey != "key-a" {
+		t.Fatalf("route options = %+v", limited.opts)
+	}
+
+	resp, err := r.Generate(context.Background(), &Request{Prompt: "hi"})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if resp.Reply != "backup" {
+		t.Fatalf("reply = %q, want backup", resp.Reply)
+	}
+
+	health := r.Health()
+	if health[0].Healthy || health[0].Failures != 1 || health[0].RetryAt.IsZero() || health[0].LastError == "" {
+		t.Fatalf("limited route health = %+v", health[0])
+	}
+	if !health[1].Healthy || health[1].Failures != 0 {
+		t.Fatalf("backup route health = %+v", health[1])
+	}
+
+	// the limited route is cooling down, so the backup goes first
+	if _, err := r.Generate(context.Background(), &Request{Prompt: "hi"}); err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if limited.calls != 1 || backup.calls != 2 {
+		t.Fatalf("calls = %d, %d, want 1 and 2", limited.calls, backup.calls)
+	}
+}
+
+func TestRouterReturnsEveryRouteError(t *testing.T) {
+	registerFake(t, "fake-down", &fakeModel{gen: failWith(http.StatusServiceUnavailable)}, false, false)
+	registerFake(t, "fake-auth", &fakeModel{gen: failWith(http.StatusUnauthorized)}, false, false)
+
+	now := time.Now()
+	r := NewRouter(WithRoutes(Route{Provider: "fake-down"}, Route{Provider: "fake-auth"}))
+	r.now = func() time.Time { return now }
+	_, err := r.Generate(context.Background(), &Request{})
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("error = %v, want RetryError", err)
+	}
+	if retryErr.Attempts != 2 || retryErr.ErrorKind() != ErrorKindAuth {
+		t.Fatalf("attempts = %d, kind = %s", retryErr.Attempts, retryErr.ErrorKind())
+	}
+
+	health := r.Health()
+	if !health[0].RetryAt.Equal(now.Add(time.Second)) {
+		t.Fatalf("unavailable route retry at %v, want a one second backoff", health[0].RetryAt)
+	}
+	if !health[1].RetryAt.Equal(now.Add(DefaultRouteCooldown)) {
+		t.Fatalf("auth route retry at %v, want the full cooldown", health[1].RetryAt)
+	}
+
+	// once the cooldown has passed the routes are tried again
+	r.now = func() time.Time { return now.Add(DefaultRouteCooldown + time.Second) }
+	for _, h := range r.Health() {
+		if !h.Healthy {
+			t.Fatalf("route %s should be healthy again", h.Provider)
+		}
+	}
+}
+
+func TestRouterDoesNotFallBackOnRequestErrors(t *testing.T) {
+	invalid := &fakeModel{gen: failWith(http.StatusBadRequest)}
+	backup := &fakeModel{gen: reply("backup")}
+	registerFake(t, "fake-invalid", invalid, false, false)
+	registerFake(t, "fake-backup", backup, false, false)
+
+	r := NewRouter(WithRoutes(Route{Provider: "fake-invalid"}, Route{Provider: "fake-backup"}))
+	_, err := r.Generate(context.Background(), &Request{})
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Code != http.StatusBadRequest {
+		t.Fatalf("error = %v, want the route's 400", err)
+	}
+	if backup.calls != 0 {
+		t.Fatal("the request was rejected; it should not be sent to another route")
+	}
+	if h := r.Health()[0]; !h.Healthy || h.Failures != 0 {
+		t.Fatalf("route health = %+v, want a rejected request not held against it", h)
+	}
+
+	invalid.gen = func() (*Response, error) { return nil, context.Canceled }
+	if _, err := r.Generate(context.Background(), &Request{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+	if backup.calls != 0 {
+		t.Fatal("a canceled request should not be sent to another route")
+	}
+
+	// the route's own failures still fall back
+	invalid.gen = failWith(http.StatusNotFound)
+	if resp, err := r.Generate(context.Background(), &Request{}); err != nil || resp.Reply != "backup" {
+		t.Fatalf("Generate = %v, %v, want the backup's reply", resp, err)
+	}
+}
+
+func TestRouterDoesNotFallBackAfterTools(t *testing.T) {
+	loopErr := &ToolLoopError{Round: 1, Response: &Response{}, Err: errors.New("service unavailable")}
+	registerFake(t, "fake-loop", &fakeModel{gen: func() (*Response, error) { return nil, loopErr }}, false, false)
+	backup := &fakeModel{gen: reply("backup")}
+	registerFake(t, "fake-backup", backup, false, false)
+
+	r := NewRouter(WithRoutes(Route{Provider: "fake-loop"}, Route{Provider: "fake-backup"}))
+	_, err := r.Generate(context.Background(), &Request{})
+	if !errors.Is(err, loopErr) {
+		t.Fatalf("error = %v, want the tool loop error", err)
+	}
+	if backup.calls != 0 {
+		t.Fatal("tools already ran; the request should not be sent to another route")
+	}
+}
+
+func TestRouterPolicies(t *testing.T) {
+	var order []string
+	for _, name := range []string{"fake-a", "fake-b", "fake-c"} {
+		registerFake(t, name, &fakeModel{gen: func() (*Response, error) {
+			order = append(order, name)
+			return nil, errors.New("bad request body")
+		}}, false, false)
+	}
+	routes := []Route{
+		{Provider: "fake-a", Cost: 0},
+		{Provider: "fake-b", Cost: 3},
+		{Provider: "fake-c", Cost: 1},
+	}
+
+	for _, tc := range []struct {
+		policy RoutePolicy
+		want   []string
+	}{
+		{RouteOrdered, []string{"fake-a", "fake-b", "fake-c"}},
+		{RouteCheapest, []string{"fake-c", "fake-b", "fake-a"}},
+	} {
+		order = nil
+		r := NewRouter(WithRoutes(routes...), WithRoutePolicy(tc.policy))
+		r.Generate(context.Background(), &Request{})
+		if !slices.Equal(order, tc.want) {
+			t.Fatalf("%s order = %v, want %v", tc.policy, order, tc.want)
+		}
+	}
+
+	r := NewRouter(WithRoutes(routes...), WithRoutePolicy(RouteFastest))
+	r.succeed(r.routes[0], 300*time.Millisecond)
+	r.succeed(r.routes[1], 100*time.Millisecond)
+	order = nil
+	r.Generate(context.Background(), &Request{})
+	if want := []string{"fake-c", "fake-b", "fake-a"}; !slices.Equal(order, want) {
+		t.Fatalf("fastest order = %v, want %v", order, want)
+	}
+	if p50 := r.Health()[1].P50; p50 != 100*time.Millisecond {
+		t.Fatalf("p50 = %v", p50)
+	}
+}
+
+func TestRouterRequiresCapabilities(t *testing.T) {
+	plain := &fakeModel{gen: reply("plain")}
+	streams := &fakeModel{gen: reply("streams")}
+	tools := &fakeModel{gen: reply("tools")}
+	registerFake(t, "fake-plain", plain, false, false)
+	registerFake(t, "fake-streams", streams, true, false)
+	registerFake(t, "fake-tools", tools, true, true)
+
+	r := NewRouter(WithRoutes(
+		Route{Provider: "fake-plain"},
+		Route{Provider: "fake-streams"},
+		Route{Provider: "fake-tools"},
+	))
+	if _, err := r.Stream(context.Background(), &Request{}); err != nil {
+		t.Fatalf("Stream returned error: %v", err)
+	}
+	if _, err := r.Stream(context.Background(), &Request{Tools: []Tool{{Name: "t"}}}); err != nil {
+		t.Fatalf("Stream returned error: %v", err)
+	}
+	if plain.calls != 0 || streams.calls != 1 || tools.calls != 1 {
+		t.Fatalf("calls = %d, %d, %d, want 0, 1, 1", plain.calls, streams.calls, tools.calls)
+	}
+	if c := r.Capabilities(); c != (Capabilities{Model: true, Stream: true, ToolStream: true}) {
+		t.Fatalf("Capabilities = %+v, want the union of the routes'", c)
+	}
+
+	r = NewRouter(WithRoutes(Route{Provider: "fake-plain"}), WithRouteRequire(Capabilities{Stream: true}))
+	if _, err := r.Generate(context.Background(), &Request{}); !errors.Is(err, ErrNoRoute) {
+		t.Fatalf("error = %v, want ErrNoRoute", err)
+	}
+	if kind := ClassifyError(ErrNoRoute); kind != ErrorKindConfiguration {
+		t.Fatalf("ErrNoRoute kind = %s", kind)
+	}
+}
+
+func TestRouterRoutesByContent(t *testing.T) {
+	text := &fakeModel{gen: reply("text")}
+	vision := &fakeModel{gen: reply("vision")}
+	registerFake(t, "fake-text", text, false, false)
+	registerFake(t, "fake-vision", vision, false, false, PartImage)
+
+	r := NewRouter(WithRoutes(Route{Provider: "fake-text"}, Route{Provider: "fake-vision"}))
+	if c := r.Capabilities(); !c.ImageInput || c.AudioInput {
+		t.Fatalf("Capabilities = %+v, want image input only", c)
+	}
+
+	// an image, in the prompt or the history, needs the vision route
+	image := ImagePart("image/png", []byte("png"))
+	for _, req := range []*Request{
+		{Parts: []Part{TextPart("what is this?"), image}},
+		{Prompt: "and now?", Messages: []Message{{Role: "user", Content: []Part{image}}}},
+	} {
+		resp, err := r.Generate(context.Background(), req)
+		if err != nil || resp.Reply != "vision" {
+			t.Fatalf("Generate = %v, %v, want the vision route", resp, err)
+		}
+	}
+	// a text file is sent as text, so any route takes it
+	csv := FilePart("data.csv", "text/csv", []byte("a,b"))
+	if resp, err := r.Generate(context.Background(), &Request{Parts: []Part{csv}}); err != nil || resp.Reply != "text" {
+		t.Fatalf("Generate = %v, %v, want the text route", resp, err)
+	}
+	if _, err := r.Generate(context.Background(), &Request{Parts: []Part{AudioPart("audio/wav", []byte("wav"))}}); !errors.Is(err, ErrNoRoute) {
+		t.Fatalf("error = %v, want ErrNoRoute for audio", err)
+	}
+
+	// a route that turns down the content falls back, without cooling down
+	vision.gen = func() (*Response, error) {
+		return nil, &UnsupportedContentError{Provider: "fake-vision", Type: PartImage}
+	}
+	r = NewRouter(WithRoutes(Route{Provider: "fake-vision"}, Route{Provider: "fake-vision"}))
+	if _, err := r.Generate(context.Background(), &Request{Parts: []Part{image}}); err == nil {
+		t.Fatal("Generate succeeded with every route turning down the content")
+	}
+	if vision.calls != 4 {
+		t.Fatalf("vision calls = %d, want both routes tried", vision.calls)
+	}
+	for _, h := range r.Health() {
+		if !h.Healthy || h.Failures != 0 {
+			t.Fatalf("route health = %+v, want unsupported content not held against it", h)
+		}
+	}
+}
+
+func TestRouterReadsRoutesFromConfig(t *testing.T) {
+	registerFake(t, "fake-cheap", &fakeModel{gen: reply("cheap")}, false, false)
+	registerFake(t, "fake-dear", &fakeModel{gen: reply("dear")}, false, false)
+
+	c, err := config.NewConfig(config.WithSource(memory.NewSource(memory.WithJSON([]byte(`{
+		"ai": {"router": {
+			"policy": "cheapest",
+			"routes": [
+				{"provider": "fake-dear", "model": "big", "cost": 10},
+				{"provider": "fake-cheap", "model": "small", "cost": 1},
+				{"provider": "missing"}
+			]
+		}}
+	}`)))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	m := New("router", WithRouteConfig(c))
+	resp, err := m.Generate(context.Background(), &Request{})
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if resp.Reply != "cheap" {
+		t.Fatalf("reply = %q, want cheap", resp.Reply)
+	}
+	if n := len(m.(*Router).Health()); n != 2 {
+		t.Fatalf("routes = %d, want 2", n)
+	}
+
+	m = New("router", WithRouteConfig(c), WithRoutePolicy("random"))
+	if _, err := m.Generate(context.Background(), &Request{}); err == nil {
+		t.Fatal("an unsupported policy should fail")
+	}
+}
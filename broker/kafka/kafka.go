@@ -0,0 +1,311 @@
+// Package kafka provides a Kafka broker.
+//
+// Messages are published as records with the message headers as record
+// headers, keyed by the DefaultKeyHeader header when present so related
+// messages keep their order on one partition.
+//
+// A subscription with broker.Queue joins the Kafka consumer group of that
+// name, sharing the topic's partitions with the other members; without a
+// queue every subscriber receives every message. Acking a message commits
+// its offset to the group. Kafka tracks one position per partition, so a
+// commit also covers earlier messages on that partition, and a message
+// that is never acked is delivered again only after the group rebalances
+// or restarts from its last commit.
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go-micro.dev/v6/broker"
+	"go-micro.dev/v6/logger"
+)
+
+type kbroker struct {
+	opts broker.Options
+
+	sync.RWMutex
+	producer    *kgo.Client
+	subscribers map[*subscriber]bool
+}
+
+type subscriber struct {
+	b      *kbroker
+	opts   broker.SubscribeOptions
+	topic  string
+	client *kgo.Client
+	group  bool
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type publication struct {
+	topic  string
+	m      *broker.Message
+	record *kgo.Record
+	s      *subscriber
+	err    error
+}
+
+// NewBroker returns a Kafka broker. broker.Addrs sets the seed brokers,
+// defaulting to 127.0.0.1:9092.
+func NewBroker(opts ...broker.Option) broker.Broker {
+	return &kbroker{
+		opts:        *broker.NewOptions(opts...),
+		subscribers: make(map[*subscriber]bool),
+	}
+}
+
+func (k *kbroker) Init(opts ...broker.Option) error {
+	k.Lock()
+	defer k.Unlock()
+	for _, o := range opts {
+		o(&k.opts)
+	}
+	return nil
+}
+
+func (k *kbroker) Options() broker.Options {
+	return k.opts
+}
+
+func (k *kbroker) Address() string {
+	if len(k.opts.Addrs) > 0 {
+		return k.opts.Addrs[0]
+	}
+	return "127.0.0.1:9092"
+}
+
+// clientOpts returns the options every client is created with.
+func (k *kbroker) clientOpts() []kgo.Opt {
+	addrs := k.opts.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{k.Address()}
+	}
+
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(addrs...),
+		kgo.AllowAutoTopicCreation(),
+	}
+	if k.opts.TLSConfig != nil {
+		opts = append(opts, kgo.DialTLSConfig(k.opts.TLSConfig))
+	}
+	if o, ok := k.opts.Context.Value(clientOptsKey{}).([]kgo.Opt); ok {
+		opts = append(opts, o...)
+	}
+	return opts
+}
+
+func (k *kbroker) keyHeader() string {
+	if h, ok := k.opts.Context.Value(keyHeaderKey{}).(string); ok && len(h) > 0 {
+		return h
+	}
+	return DefaultKeyHeader
+}
+
+func (k *kbroker) Connect() error {
+	k.Lock()
+	defer k.Unlock()
+
+	if k.producer != nil {
+		return nil
+	}
+
+	c, err := kgo.NewClient(k.clientOpts()...)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := c.Ping(ctx); err != nil {
+		c.Close()
+		return err
+	}
+
+	k.producer = c
+	return nil
+}
+
+func (k *kbroker) Disconnect() error {
+	k.Lock()
+	subs := make([]*subscriber, 0, len(k.subscribers))
+	for s := range k.subscribers {
+		subs = append(subs, s)
+	}
+	producer := k.producer
+	k.producer = nil
+	k.Unlock()
+
+	for _, s := range subs {
+		s.Unsubscribe()
+	}
+	if producer != nil {
+		producer.Close()
+	}
+	return nil
+}
+
+func (k *kbroker) Publish(topic string, m *broker.Message, opts ...broker.PublishOption) error {
+	k.RLock()
+	producer := k.producer
+	k.RUnlock()
+
+	if producer == nil {
+		return errors.New("kafka: not connected")
+	}
+
+	options := broker.PublishOptions{Context: context.Background()}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	r := &kgo.Record{
+		Topic: topic,
+		Value: m.Body,
+	}
+	keyHeader := k.keyHeader()
+	for key, val := range m.Header {
+		if key == keyHeader {
+			r.Key = []byte(val)
+		}
+		r.Headers = append(r.Headers, kgo.RecordHeader{Key: key, Value: []byte(val)})
+	}
+
+	return producer.ProduceSync(options.Context, r).FirstErr()
+}
+
+func (k *kbroker) Subscribe(topic string, h broker.Handler, opts ...broker.SubscribeOption) (broker.Subscriber, error) {
+	options := broker.NewSubscribeOptions(opts...)
+
+	start := kgo.NewOffset().AtEnd()
+	if ctx := options.Context; ctx != nil {
+		if t, ok := ctx.Value(offsetKey{}).(time.Time); ok && !t.IsZero() {
+			start = kgo.NewOffset().AfterMilli(t.UnixMilli())
+		}
+	}
+
+	copts := append(k.clientOpts(),
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(start),
+	)
+	if len(options.Queue) > 0 {
+		copts = append(copts,
+			kgo.ConsumerGroup(options.Queue),
+			kgo.DisableAutoCommit(),
+		)
+	}
+
+	c, err := kgo.NewClient(copts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &subscriber{
+		b:      k,
+		opts:   options,
+		topic:  topic,
+		client: c,
+		group:  len(options.Queue) > 0,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	k.Lock()
+	k.subscribers[s] = true
+	k.Unlock()
+
+	go s.run(ctx, h)
+
+	return s, nil
+}
+
+func (k *kbroker) String() string {
+	return "kafka"
+}
+
+func (s *subscriber) run(ctx context.Context, h broker.Handler) {
+	defer close(s.done)
+
+	log := s.b.opts.Logger
+
+	for {
+		fetches := s.client.PollFetches(ctx)
+		if ctx.Err() != nil || fetches.IsClientClosed() {
+			return
+		}
+		fetches.EachError(func(t string, p int32, err error) {
+			log.Logf(logger.ErrorLevel, "kafka: fetch %s/%d: %v", t, p, err)
+		})
+
+		fetches.EachRecord(func(r *kgo.Record) {
+			m := &broker.Message{
+				Header: make(map[string]string, len(r.Headers)),
+				Body:   r.Value,
+			}
+			for _, hdr := range r.Headers {
+				m.Header[hdr.Key] = string(hdr.Value)
+			}
+
+			p := &publication{topic: r.Topic, m: m, record: r, s: s}
+			p.err = h(p)
+			if p.err == nil && s.opts.AutoAck {
+				if err := p.Ack(); err != nil {
+					log.Logf(logger.ErrorLevel, "kafka: commit %s/%d@%d: %v", r.Topic, r.Partition, r.Offset, err)
+				}
+			} else if p.err != nil && s.b.opts.ErrorHandler != nil {
+				s.b.opts.ErrorHandler(p)
+			}
+		})
+	}
+}
+
+func (s *subscriber) Options() broker.SubscribeOptions {
+	return s.opts
+}
+
+func (s *subscriber) Topic() string {
+	return s.topic
+}
+
+func (s *subscriber) Unsubscribe() error {
+	s.b.Lock()
+	_, ok := s.b.subscribers[s]
+	delete(s.b.subscribers, s)
+	s.b.Unlock()
+	if !ok {
+		return nil
+	}
+
+	s.cancel()
+	<-s.done
+	s.client.Close()
+	return nil
+}
+
+func (p *publication) Topic() string {
+	return p.topic
+}
+
+func (p *publication) Message() *broker.Message {
+	return p.m
+}
+
+// Ack commits the message's offset to the consumer group. It does nothing
+// for subscriptions without a queue, which have no group to commit to.
+func (p *publication) Ack() error {
+	if !p.s.group {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return p.s.client.CommitRecords(ctx, p.record)
+}
+
+func (p *publication) Error() error {
+	return p.err
+}
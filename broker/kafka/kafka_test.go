@@ -0,0 +1,173 @@
+package kafka
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kfake"
+	"go-micro.dev/v6/broker"
+)
+
+func newBroker(t *testing.T) broker.Broker {
+	t.Helper()
+
+	c, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(4, "events"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+
+	b := NewBroker(broker.Addrs(c.ListenAddrs()...))
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Disconnect() })
+	return b
+}
+
+func publish(t *testing.T, b broker.Broker, n int, key string) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		m := &broker.Message{
+			Header: map[string]string{"Id": fmt.Sprint(i)},
+			Body:   []byte(fmt.Sprintf("message %d", i)),
+		}
+		if len(key) > 0 {
+			m.Header[DefaultKeyHeader] = key
+		}
+		if err := b.Publish("events", m); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// collector gathers the messages handled by subscribers.
+type collector struct {
+	sync.Mutex
+	ids        map[string]int
+	partitions map[int32]bool
+	count      int
+}
+
+func newCollector() *collector {
+	return &collector{ids: map[string]int{}, partitions: map[int32]bool{}}
+}
+
+func (c *collector) handler(e broker.Event) error {
+	c.Lock()
+	defer c.Unlock()
+	c.ids[e.Message().Header["Id"]]++
+	c.partitions[e.(*publication).record.Partition] = true
+	c.count++
+	return nil
+}
+
+func (c *collector) wait(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		c.Lock()
+		count := c.count
+		c.Unlock()
+		if count >= n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("received %d messages, want %d", c.count, n)
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	b := newBroker(t)
+	start := time.Now()
+
+	// without a queue every subscriber gets every message
+	a, c := newCollector(), newCollector()
+	for _, col := range []*collector{a, c} {
+		if _, err := b.Subscribe("events", col.handler, Offset(start)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	publish(t, b, 10, "customer-1")
+
+	a.wait(t, 10)
+	c.wait(t, 10)
+
+	// one key, one partition
+	if len(a.partitions) != 1 {
+		t.Fatalf("keyed messages spread over %d partitions", len(a.partitions))
+	}
+	if a.ids["3"] != 1 {
+		t.Fatalf("headers not carried: %v", a.ids)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	b := newBroker(t)
+	start := time.Now()
+
+	col := newCollector()
+	for i := 0; i < 2; i++ {
+		if _, err := b.Subscribe("events", col.handler, broker.Queue("workers"), Offset(start)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	publish(t, b, 20, "")
+	col.wait(t, 20)
+
+	if len(col.ids) != 20 {
+		t.Fatalf("got %d distinct messages, want 20", len(col.ids))
+	}
+}
+
+func TestManualAck(t *testing.T) {
+	b := newBroker(t)
+	start := time.Now()
+
+	// one key keeps the messages on one partition, in order
+	publish(t, b, 5, "k")
+
+	var mu sync.Mutex
+	var got []string
+	sub, err := b.Subscribe("events", func(e broker.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, e.Message().Header["Id"])
+		// ack only the first three
+		if len(got) <= 3 {
+			return e.Ack()
+		}
+		return nil
+	}, broker.Queue("manual"), broker.DisableAutoAck(), Offset(start))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor(t, func() bool { mu.Lock(); defer mu.Unlock(); return len(got) == 5 })
+	sub.Unsubscribe()
+
+	// the group resumes after the last acked message
+	col := newCollector()
+	if _, err := b.Subscribe("events", col.handler, broker.Queue("manual"), Offset(start)); err != nil {
+		t.Fatal(err)
+	}
+	col.wait(t, 2)
+	if col.ids["3"] != 1 || col.ids["4"] != 1 || col.count != 2 {
+		t.Fatalf("redelivered %v, want messages 3 and 4", col.ids)
+	}
+}
+
+func waitFor(t *testing.T, fn func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !fn() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
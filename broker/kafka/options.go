@@ -0,0 +1,59 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go-micro.dev/v6/broker"
+)
+
+type clientOptsKey struct{}
+
+type keyHeaderKey struct{}
+
+type offsetKey struct{}
+
+// DefaultKeyHeader is the message header whose value is used as the
+// record key, so messages with the same key land on the same partition
+// and are consumed in order.
+var DefaultKeyHeader = "Micro-Partition-Key"
+
+// ClientOptions passes options to the underlying franz-go clients, e.g.
+// SASL authentication or producer batching.
+func ClientOptions(opts ...kgo.Opt) broker.Option {
+	return setBrokerOption(clientOptsKey{}, opts)
+}
+
+// KeyHeader sets the message header used as the record key. Defaults to
+// DefaultKeyHeader. Messages without the header are spread across
+// partitions.
+func KeyHeader(name string) broker.Option {
+	return setBrokerOption(keyHeaderKey{}, name)
+}
+
+// Offset starts a subscription at the first message published at or
+// after t, replaying history. For a queue subscription it only applies
+// when the consumer group has no committed offset yet. Without it
+// subscriptions start with new messages.
+func Offset(t time.Time) broker.SubscribeOption {
+	return setSubscribeOption(offsetKey{}, t)
+}
+
+func setBrokerOption(k, v interface{}) broker.Option {
+	return func(o *broker.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, k, v)
+	}
+}
+
+func setSubscribeOption(k, v interface{}) broker.SubscribeOption {
+	return func(o *broker.SubscribeOptions) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, k, v)
+	}
+}
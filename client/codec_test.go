@@ -0,0 +1,35 @@
+package client_test
+
+import (
+	"context"
+	"testing"
+
+	"go-micro.dev/v6/client"
+	"go-micro.dev/v6/registry"
+)
+
+// TestBinaryCodecsNegotiate checks that the msgpack and cbor content types
+// are registered on both sides, so a plain struct call negotiates them with
+// no codec wiring in the service.
+func TestBinaryCodecsNegotiate(t *testing.T) {
+	reg := registry.NewMemoryRegistry()
+	stop := startEchoServer(t, reg)
+	defer stop()
+
+	for _, ct := range []string{"application/msgpack", "application/cbor"} {
+		t.Run(ct, func(t *testing.T) {
+			cl := newEchoClient(reg, client.ContentType(ct))
+			req := cl.NewRequest("echo.local", "EchoHandler.Echo", &EchoReq{Msg: "hi"})
+			if req.ContentType() != ct {
+				t.Fatalf("content type = %q, want %q", req.ContentType(), ct)
+			}
+			var rsp EchoRsp
+			if err := cl.Call(context.Background(), req, &rsp); err != nil {
+				t.Fatalf("call: %v", err)
+			}
+			if rsp.Msg != "echo:hi" {
+				t.Fatalf("reply = %q, want echo:hi", rsp.Msg)
+			}
+		})
+	}
+}
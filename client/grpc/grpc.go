@@ -74,7 +74,7 @@ func (g *grpcClient) secure(addr string) grpc.DialOption {
 	return grpc.WithInsecure()
 }
 
-func (g *grpcClient) next(request client.Request, opts client.CallOptions) (selector.Next, error) {
+func (g *grpcClient) next(ctx context.Context, request client.Request, opts client.CallOptions) (selector.Next, error) {
 	service, address, _ := pnet.Proxy(request.Service(), opts.Address)
 
 	// return remote address
@@ -87,7 +87,7 @@ func (g *grpcClient) next(request client.Request, opts client.CallOptions) (sele
 	}
 
 	// get next nodes from the selector
-	next, err := g.opts.Selector.Select(service, opts.SelectOptions...)
+	next, err := g.opts.Selector.Select(service, client.SelectOptions(ctx, opts)...)
 	if err != nil {
 		if err == selector.ErrNotFound {
 			return nil, errors.InternalServerError("go.micro.client", "service %s: %s", service, err.Error())
@@ -405,7 +405,7 @@ func (g *grpcClient) Call(ctx context.Context, req client.Request, rsp interface
 		opt(&callOpts)
 	}
 
-	next, err := g.next(req, callOpts)
+	next, err := g.next(ctx, req, callOpts)
 	if err != nil {
 		return err
 	}
@@ -512,7 +512,7 @@ func (g *grpcClient) Stream(ctx context.Context, req client.Request, opts ...cli
 		opt(&callOpts)
 	}
 
-	next, err := g.next(req, callOpts)
+	next, err := g.next(ctx, req, callOpts)
 	if err != nil {
 		return nil, err
 	}
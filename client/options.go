@@ -7,9 +7,11 @@ import (
 	"go-micro.dev/v6/broker"
 	"go-micro.dev/v6/codec"
 	"go-micro.dev/v6/logger"
+	"go-micro.dev/v6/metadata"
 	"go-micro.dev/v6/registry"
 	"go-micro.dev/v6/selector"
 	"go-micro.dev/v6/transport"
+	"go-micro.dev/v6/transport/headers"
 )
 
 var (
@@ -334,6 +336,21 @@ func WithSelectOption(so ...selector.SelectOption) CallOption {
 	}
 }
 
+// SelectOptions returns the selector options for a call. A region set in
+// the Micro-Region metadata of ctx pins the call to nodes in that region,
+// ahead of any other filter such as selector.FilterLocality, and carries
+// on down the call chain with the rest of the metadata.
+func SelectOptions(ctx context.Context, opts CallOptions) []selector.SelectOption {
+	region, ok := metadata.Get(ctx, headers.Region)
+	if !ok || len(region) == 0 {
+		return opts.SelectOptions
+	}
+
+	so := make([]selector.SelectOption, 0, len(opts.SelectOptions)+1)
+	so = append(so, selector.WithFilter(selector.FilterLabel(registry.MetadataRegion, region)))
+	return append(so, opts.SelectOptions...)
+}
+
 // WithCallWrapper is a CallOption which adds to the existing CallFunc wrappers.
 func WithCallWrapper(cw ...CallWrapper) CallOption {
 	return func(o *CallOptions) {
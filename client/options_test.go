@@ -1,10 +1,15 @@
 package client
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"go-micro.dev/v6/metadata"
+	"go-micro.dev/v6/registry"
+	"go-micro.dev/v6/selector"
 	"go-micro.dev/v6/transport"
+	"go-micro.dev/v6/transport/headers"
 )
 
 func TestCallOptions(t *testing.T) {
@@ -81,3 +86,40 @@ func TestCallOptions(t *testing.T) {
 		}
 	}
 }
+
+func TestSelectOptionsRegion(t *testing.T) {
+	services := []*registry.Service{{
+		Name: "greeter",
+		Nodes: []*registry.Node{
+			{Id: "eu-1", Metadata: map[string]string{registry.MetadataRegion: "eu"}},
+			{Id: "us-1", Metadata: map[string]string{registry.MetadataRegion: "us"}},
+		},
+	}}
+
+	filter := func(ctx context.Context) []string {
+		var so selector.SelectOptions
+		for _, o := range SelectOptions(ctx, CallOptions{}) {
+			o(&so)
+		}
+		out := services
+		for _, f := range so.Filters {
+			out = f(out)
+		}
+		var ids []string
+		for _, s := range out {
+			for _, n := range s.Nodes {
+				ids = append(ids, n.Id)
+			}
+		}
+		return ids
+	}
+
+	if ids := filter(context.Background()); len(ids) != 2 {
+		t.Fatalf("without a region got %v, want both nodes", ids)
+	}
+
+	ctx := metadata.Set(context.Background(), headers.Region, "us")
+	if ids := filter(ctx); len(ids) != 1 || ids[0] != "us-1" {
+		t.Fatalf("pinned to us got %v, want [us-1]", ids)
+	}
+}
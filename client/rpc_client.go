@@ -393,7 +393,7 @@ func (r *rpcClient) Options() Options {
 }
 
 // next returns an iterator for the next nodes to call.
-func (r *rpcClient) next(request Request, opts CallOptions) (selector.Next, error) {
+func (r *rpcClient) next(ctx context.Context, request Request, opts CallOptions) (selector.Next, error) {
 	// try get the proxy
 	service, address, _ := net.Proxy(request.Service(), opts.Address)
 
@@ -418,7 +418,7 @@ func (r *rpcClient) next(request Request, opts CallOptions) (selector.Next, erro
 	}
 
 	// get next nodes from the selector
-	next, err := r.opts.Selector.Select(service, opts.SelectOptions...)
+	next, err := r.opts.Selector.Select(service, SelectOptions(ctx, opts)...)
 	if err != nil {
 		if errors.Is(err, selector.ErrNotFound) {
 			return nil, merrors.InternalServerError("go.micro.client", "service %s: %s", service, err.Error())
@@ -442,7 +442,7 @@ func (r *rpcClient) Call(ctx context.Context, request Request, response interfac
 		opt(&callOpts)
 	}
 
-	next, err := r.next(request, callOpts)
+	next, err := r.next(ctx, request, callOpts)
 	if err != nil {
 		return err
 	}
@@ -567,7 +567,7 @@ func (r *rpcClient) Stream(ctx context.Context, request Request, opts ...CallOpt
 		opt(&callOpts)
 	}
 
-	next, err := r.next(request, callOpts)
+	next, err := r.next(ctx, request, callOpts)
 	if err != nil {
 		return nil, err
 	}
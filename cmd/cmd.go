@@ -11,6 +11,7 @@ import (
 	"github.com/urfave/cli/v2"
 	"go-micro.dev/v6/auth"
 	"go-micro.dev/v6/broker"
+	"go-micro.dev/v6/broker/kafka"
 	nbroker "go-micro.dev/v6/broker/nats"
 	rabbit "go-micro.dev/v6/broker/rabbitmq"
 	"go-micro.dev/v6/cache"
@@ -25,15 +26,20 @@ import (
 	"go-micro.dev/v6/logger"
 	"go-micro.dev/v6/registry"
 	"go-micro.dev/v6/registry/consul"
+	"go-micro.dev/v6/registry/dns"
 	"go-micro.dev/v6/registry/etcd"
+	"go-micro.dev/v6/registry/file"
+	"go-micro.dev/v6/registry/kubernetes"
 	"go-micro.dev/v6/registry/nats"
 	"go-micro.dev/v6/selector"
 	"go-micro.dev/v6/server"
 	mprofile "go-micro.dev/v6/service/profile"
 	"go-micro.dev/v6/store"
+	sbolt "go-micro.dev/v6/store/bolt"
 	"go-micro.dev/v6/store/mysql"
 	natsjskv "go-micro.dev/v6/store/nats-js-kv"
 	postgres "go-micro.dev/v6/store/postgres"
+	sredis "go-micro.dev/v6/store/redis"
 	"go-micro.dev/v6/transport"
 	ntransport "go-micro.dev/v6/transport/nats"
 )
@@ -136,7 +142,7 @@ var (
 		&cli.StringFlag{
 			Name:    "broker",
 			EnvVars: []string{"MICRO_BROKER"},
-			Usage:   "Broker for pub/sub. http, nats, rabbitmq",
+			Usage:   "Broker for pub/sub. http, nats, rabbitmq, kafka",
 		},
 		&cli.StringFlag{
 			Name:    "broker_address",
@@ -156,7 +162,7 @@ var (
 		&cli.StringFlag{
 			Name:    "registry",
 			EnvVars: []string{"MICRO_REGISTRY"},
-			Usage:   "Registry for discovery. etcd, mdns",
+			Usage:   "Registry for discovery. etcd, mdns, consul, nats, kubernetes, dns, file",
 		},
 		&cli.StringFlag{
 			Name:    "registry_address",
@@ -251,16 +257,20 @@ var (
 		"http":     broker.NewHttpBroker,
 		"nats":     nbroker.NewNatsBroker,
 		"rabbitmq": rabbit.NewBroker,
+		"kafka":    kafka.NewBroker,
 	}
 
 	DefaultClients = map[string]func(...client.Option) client.Client{}
 
 	DefaultRegistries = map[string]func(...registry.Option) registry.Registry{
-		"consul": consul.NewConsulRegistry,
-		"memory": registry.NewMemoryRegistry,
-		"nats":   nats.NewNatsRegistry,
-		"mdns":   registry.NewMDNSRegistry,
-		"etcd":   etcd.NewEtcdRegistry,
+		"consul":     consul.NewConsulRegistry,
+		"memory":     registry.NewMemoryRegistry,
+		"nats":       nats.NewNatsRegistry,
+		"mdns":       registry.NewMDNSRegistry,
+		"etcd":       etcd.NewEtcdRegistry,
+		"kubernetes": kubernetes.NewRegistry,
+		"dns":        dns.NewRegistry,
+		"file":       file.NewRegistry,
 	}
 
 	DefaultSelectors = map[string]func(...selector.Option) selector.Selector{}
@@ -272,10 +282,12 @@ var (
 	}
 
 	DefaultStores = map[string]func(...store.Option) store.Store{
+		"bolt":     sbolt.NewStore,
 		"memory":   store.NewMemoryStore,
 		"mysql":    mysql.NewMysqlStore,
 		"natsjskv": natsjskv.NewStore,
 		"postgres": postgres.NewStore,
+		"redis":    sredis.NewStore,
 	}
 
 	DefaultTracers = map[string]func(...trace.Option) trace.Tracer{}
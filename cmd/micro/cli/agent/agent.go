@@ -196,6 +196,8 @@ history, and no-secret fallback checks.`,
 				},
 			},
 
+			evalCommand(),
+			replayCommand(),
 			{
 				Name:      "resume-input",
 				Usage:     "Continue an input-required agent run with human input",
@@ -293,6 +295,9 @@ func writeRunIndex(w io.Writer, name string, runs []goagent.RunSummary, asJSON b
 		if run.Stage != "" {
 			line += "  stage=" + run.Stage
 		}
+		if run.Prompt != "" {
+			line += fmt.Sprintf("  prompt=%s@%d", run.Prompt, run.PromptVersion)
+		}
 		if run.LastError != "" {
 			line += "  error=" + run.LastError
 		}
@@ -0,0 +1,184 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go-micro.dev/v6/ai"
+	"go-micro.dev/v6/client"
+	"go-micro.dev/v6/eval"
+	"go-micro.dev/v6/store"
+)
+
+func evalCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "eval",
+		Usage:     "Run an evaluation suite against an agent and compare it with the baseline",
+		ArgsUsage: "[suite.jsonl] [agent]",
+		Description: `Send every case of a JSONL suite to a running agent over Agent.Chat,
+grade the replies and tool calls, and save the result. The result is compared
+with the suite's baseline: pass rate, token, spend and latency deltas, and the
+cases that regressed or were fixed.
+
+Each case runs in a conversation of its own, apart from the agent's default
+one. Each line of the suite is a case:
+
+  {"id": "refund", "input": "Refund order 42",
+   "tools": ["orders_Orders_Get", {"name": "payments_Payments_Refund", "input": {"order": 42}}],
+   "expect": {"status": "refunded"}, "rubric": "Confirms the amount refunded"}
+
+Rubrics are graded by the judge model set with --judge_provider.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "label", Usage: "Name what is evaluated, such as a version or commit"},
+			&cli.StringFlag{Name: "baseline", Usage: "Compare with this result id instead of the suite's baseline"},
+			&cli.BoolFlag{Name: "set-baseline", Usage: "Make this result the suite's baseline"},
+			&cli.BoolFlag{Name: "fail-on-regression", Usage: "Exit with an error if a case regressed or the pass rate fell"},
+			&cli.BoolFlag{Name: "json", Usage: "Print the result and comparison as JSON"},
+			&cli.StringFlag{Name: "judge_provider", Usage: "AI provider grading rubrics", EnvVars: []string{"MICRO_AI_PROVIDER"}},
+			&cli.StringFlag{Name: "judge_api_key", Usage: "API key for the judge provider", EnvVars: []string{"MICRO_AI_API_KEY"}},
+			&cli.StringFlag{Name: "judge_model", Usage: "Judge model name (uses provider default if unset)", EnvVars: []string{"MICRO_AI_MODEL"}},
+		},
+		Action: func(c *cli.Context) error {
+			path, name := c.Args().First(), c.Args().Get(1)
+			if path == "" || name == "" {
+				return fmt.Errorf("usage: micro agent eval [suite.jsonl] [agent]")
+			}
+			suite, err := eval.LoadSuite(path)
+			if err != nil {
+				return err
+			}
+			cfg := evalConfig{
+				agent:            name,
+				label:            c.String("label"),
+				baseline:         c.String("baseline"),
+				setBaseline:      c.Bool("set-baseline"),
+				failOnRegression: c.Bool("fail-on-regression"),
+				asJSON:           c.Bool("json"),
+			}
+			if p := c.String("judge_provider"); p != "" {
+				cfg.judge = ai.New(p, ai.WithAPIKey(c.String("judge_api_key")), ai.WithModel(c.String("judge_model")))
+			}
+			target := eval.ServiceTarget(client.DefaultClient, name, store.DefaultStore)
+			return runAgentEval(context.Background(), c.App.Writer, store.DefaultStore, suite, target, cfg)
+		},
+	}
+}
+
+type evalConfig struct {
+	agent            string
+	label            string
+	baseline         string
+	setBaseline      bool
+	failOnRegression bool
+	asJSON           bool
+	judge            ai.Model
+}
+
+func runAgentEval(ctx context.Context, w io.Writer, st store.Store, suite *eval.Suite, target eval.Target, cfg evalConfig) error {
+	graders := eval.DefaultGraders()
+	if cfg.judge != nil {
+		graders = append(graders, eval.LLMGrader(cfg.judge))
+	} else if hasRubric(suite) && !cfg.asJSON {
+		fmt.Fprintln(w, "  Rubrics aren't graded: set --judge_provider to grade them with a model.")
+	}
+	res, err := eval.Run(ctx, suite, target, eval.Graders(graders...), eval.Label(cfg.label), eval.Agent(cfg.agent), eval.Store(st))
+	if err != nil {
+		return err
+	}
+
+	var base *eval.Result
+	ok := false
+	if cfg.baseline != "" {
+		base, ok, err = eval.Load(st, suite.Name, cfg.baseline)
+		if err == nil && !ok {
+			err = fmt.Errorf("no result %s for suite %s", cfg.baseline, suite.Name)
+		}
+	} else {
+		base, ok, err = eval.Baseline(st, suite.Name)
+	}
+	if err != nil {
+		return err
+	}
+	var cmp *eval.Comparison
+	if ok {
+		cmp = eval.Compare(base, res)
+	}
+	if cfg.setBaseline {
+		if err := eval.SetBaseline(st, suite.Name, res.ID); err != nil {
+			return err
+		}
+	}
+
+	if cfg.asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(struct {
+			Result     *eval.Result     `json:"result"`
+			Comparison *eval.Comparison `json:"comparison,omitempty"`
+		}{res, cmp}); err != nil {
+			return err
+		}
+	} else {
+		writeEvalResult(w, res, base, cmp, cfg.setBaseline)
+	}
+	if cfg.failOnRegression && cmp != nil && cmp.Regressed() {
+		return fmt.Errorf("eval %s regressed against baseline %s", suite.Name, cmp.Baseline)
+	}
+	return nil
+}
+
+func hasRubric(s *eval.Suite) bool {
+	for _, c := range s.Cases {
+		if c.Rubric != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func writeEvalResult(w io.Writer, res *eval.Result, base *eval.Result, cmp *eval.Comparison, setBaseline bool) {
+	fmt.Fprintf(w, "  Eval %s against %s", res.Suite, res.Agent)
+	if res.Label != "" {
+		fmt.Fprintf(w, " (%s)", res.Label)
+	}
+	fmt.Fprintf(w, ", result %s\n", res.ID)
+	for _, c := range res.Cases {
+		mark := "✓"
+		if !c.Pass {
+			mark = "✗"
+		}
+		line := fmt.Sprintf("    %s %-20s %s", mark, c.Case, formatDurationMS(c.Latency.Milliseconds()))
+		if c.Output != nil && c.Output.Tokens.TotalTokens > 0 {
+			line += fmt.Sprintf("  tokens=%d", c.Output.Tokens.TotalTokens)
+		}
+		if c.Error != "" {
+			line += "  error=" + c.Error
+		}
+		for _, g := range c.Grades {
+			if !g.Pass {
+				line += fmt.Sprintf("  %s: %s", g.Grader, g.Feedback)
+			}
+		}
+		fmt.Fprintln(w, line)
+	}
+	s := res.Summary
+	fmt.Fprintf(w, "  Pass rate %.1f%% (%d/%d)  tokens=%d  spent=%d  p50=%s  p95=%s\n",
+		s.PassRate*100, s.Passed, s.Cases, s.Tokens, s.Spent, s.P50Latency.Round(time.Millisecond), s.P95Latency.Round(time.Millisecond))
+	switch {
+	case cmp != nil:
+		label := base.ID
+		if base.Label != "" {
+			label += " (" + base.Label + ")"
+		}
+		fmt.Fprintf(w, "  Against baseline %s: %s\n", label, cmp)
+	case !setBaseline:
+		fmt.Fprintf(w, "  No baseline yet: rerun with --set-baseline to compare later runs with this one.\n")
+	}
+	if setBaseline {
+		fmt.Fprintf(w, "  Result %s is now the baseline of %s.\n", res.ID, res.Suite)
+	}
+}
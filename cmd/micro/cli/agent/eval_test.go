@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"go-micro.dev/v6/eval"
+	"go-micro.dev/v6/store"
+)
+
+func TestRunAgentEvalComparesWithBaseline(t *testing.T) {
+	st := store.NewMemoryStore()
+	suite := &eval.Suite{Name: "support", Cases: []eval.Case{
+		{ID: "status", Input: "order status", Expect: map[string]any{"status": "shipped"}},
+		{ID: "rubric", Input: "be nice", Rubric: "Is polite"},
+	}}
+	reply := `{"status": "shipped"}`
+	target := func(context.Context, string) (*eval.Output, error) {
+		return &eval.Output{Reply: reply}, nil
+	}
+
+	var out bytes.Buffer
+	if err := runAgentEval(context.Background(), &out, st, suite, target, evalConfig{agent: "helper", label: "v1", setBaseline: true}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Rubrics aren't graded", "✓ status", "Pass rate 100.0% (2/2)", "is now the baseline of support"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	reply = `{"status": "lost"}`
+	out.Reset()
+	err := runAgentEval(context.Background(), &out, st, suite, target, evalConfig{agent: "helper", label: "v2", failOnRegression: true})
+	if err == nil || !strings.Contains(err.Error(), "regressed") {
+		t.Fatalf("error = %v, want a regression", err)
+	}
+	for _, want := range []string{"✗ status", `json_fields: status is "lost", want "shipped"`, "(v1): pass rate -50.0%", "regressed: status"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := runAgentEval(context.Background(), &out, st, suite, target, evalConfig{agent: "helper", asJSON: true}); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Result     eval.Result     `json:"result"`
+		Comparison eval.Comparison `json:"comparison"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if got.Result.Summary.Passed != 1 || len(got.Comparison.Regressions) != 1 {
+		t.Fatalf("decoded = %+v", got)
+	}
+}
@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/urfave/cli/v2"
+	goagent "go-micro.dev/v6/agent"
+	aiflow "go-micro.dev/v6/flow"
+	"go-micro.dev/v6/prompt"
+	"go-micro.dev/v6/store"
+)
+
+func replayCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "replay",
+		Usage:     "Re-execute a recorded agent run with a different provider, model or prompt",
+		ArgsUsage: "[name] [run-id]",
+		Description: `Replay a checkpointed run of an agent against a new provider, model or system
+prompt. Tool calls are answered from the run's recorded results, so nothing
+reaches a live service. The replay's reply is printed next to the original and
+every point where its tool calls diverge from the recording is flagged: a
+different tool, different arguments, or a call made or skipped.
+
+A run that took its prompt from the prompt registry (see micro prompt) replays
+with the same prompt version. Other runs don't store their prompt: pass the
+prompt to test with --prompt.`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "provider", Usage: "AI provider to replay with", EnvVars: []string{"MICRO_AI_PROVIDER"}},
+			&cli.StringFlag{Name: "api_key", Usage: "API key for the provider", EnvVars: []string{"MICRO_AI_API_KEY"}},
+			&cli.StringFlag{Name: "model", Usage: "Model name (uses provider default if unset)", EnvVars: []string{"MICRO_AI_MODEL"}},
+			&cli.StringFlag{Name: "base_url", Usage: "Provider base URL"},
+			&cli.StringFlag{Name: "prompt", Usage: "System prompt to replay with"},
+			&cli.StringFlag{Name: "input", Usage: "Replace the message that started the run"},
+			&cli.BoolFlag{Name: "fail-on-divergence", Usage: "Exit with an error if the replay's tool calls diverge"},
+			&cli.BoolFlag{Name: "json", Usage: "Print the replay as JSON"},
+		},
+		Action: func(c *cli.Context) error {
+			name, runID := c.Args().First(), c.Args().Get(1)
+			if name == "" || runID == "" {
+				return fmt.Errorf("usage: micro agent replay [name] [run-id]")
+			}
+			if c.String("provider") == "" {
+				return fmt.Errorf("provider required: pass --provider or set MICRO_AI_PROVIDER")
+			}
+			ag := goagent.New(
+				goagent.Name(name),
+				goagent.Provider(c.String("provider")),
+				goagent.APIKey(c.String("api_key")),
+				goagent.Model(c.String("model")),
+				goagent.BaseURL(c.String("base_url")),
+				goagent.WithStore(store.DefaultStore),
+				goagent.WithCheckpoint(aiflow.StoreCheckpoint(store.DefaultStore, name)),
+			)
+			cfg := replayConfig{failOnDivergence: c.Bool("fail-on-divergence"), asJSON: c.Bool("json")}
+			opts := goagent.ReplayOptions{
+				Prompt:  c.String("prompt"),
+				Prompts: prompt.NewRegistry(store.DefaultStore),
+				Input:   c.String("input"),
+			}
+			return runAgentReplay(context.Background(), c.App.Writer, ag, runID, opts, cfg)
+		},
+	}
+}
+
+type replayConfig struct {
+	failOnDivergence bool
+	asJSON           bool
+}
+
+func runAgentReplay(ctx context.Context, w io.Writer, ag goagent.Agent, runID string, opts goagent.ReplayOptions, cfg replayConfig) error {
+	res, err := goagent.Replay(ctx, ag, runID, opts)
+	if err != nil {
+		return err
+	}
+	if cfg.asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+	} else {
+		writeReplayResult(w, res)
+	}
+	if cfg.failOnDivergence && res.Diverged() {
+		return fmt.Errorf("replay of run %s diverged in %d tool call(s)", runID, len(res.Divergences))
+	}
+	return nil
+}
+
+func writeReplayResult(w io.Writer, res *goagent.ReplayResult) {
+	fmt.Fprintf(w, "  Replay of %s run %s\n", res.Agent, res.RunID)
+	fmt.Fprintf(w, "  Input: %s\n", res.Input)
+	if res.Prompt != "" {
+		fmt.Fprintf(w, "  Prompt: %s\n", res.Prompt)
+	}
+	fmt.Fprintf(w, "  Tool calls: %d recorded, %d replayed", len(res.Recorded), len(res.Replayed))
+	if res.Tokens.TotalTokens > 0 {
+		fmt.Fprintf(w, "  tokens=%d", res.Tokens.TotalTokens)
+	}
+	fmt.Fprintln(w)
+	if res.Diverged() {
+		fmt.Fprintf(w, "  Diverged:\n")
+		for _, d := range res.Divergences {
+			fmt.Fprintf(w, "    ✗ %s\n", d)
+		}
+	} else {
+		fmt.Fprintf(w, "  ✓ Same tool calls as the recorded run\n")
+	}
+	if res.RecordedReply != "" {
+		fmt.Fprintf(w, "  Recorded reply: %s\n", res.RecordedReply)
+	}
+	fmt.Fprintf(w, "  Replay reply:   %s\n", res.Reply)
+}
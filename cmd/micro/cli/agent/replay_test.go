@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	goagent "go-micro.dev/v6/agent"
+	"go-micro.dev/v6/ai"
+	aiflow "go-micro.dev/v6/flow"
+	"go-micro.dev/v6/registry"
+	"go-micro.dev/v6/store"
+)
+
+// replayModel looks an order up with whatever id it was configured with.
+type replayModel struct{ opts ai.Options }
+
+func (m *replayModel) Init(opts ...ai.Option) error {
+	for _, o := range opts {
+		o(&m.opts)
+	}
+	return nil
+}
+func (m *replayModel) Options() ai.Options { return m.opts }
+func (m *replayModel) String() string      { return "replaymodel" }
+func (m *replayModel) Stream(context.Context, *ai.Request, ...ai.GenerateOption) (ai.Stream, error) {
+	return nil, ai.ErrStreamingUnsupported
+}
+func (m *replayModel) Generate(ctx context.Context, req *ai.Request, _ ...ai.GenerateOption) (*ai.Response, error) {
+	res := m.opts.ToolHandler(ctx, ai.ToolCall{ID: "1", Name: "orders_Get", Input: map[string]any{"id": m.opts.Model}})
+	return &ai.Response{Reply: "order: " + res.Content}, nil
+}
+
+func TestRunAgentReplayFlagsDivergence(t *testing.T) {
+	ai.Register("replaymodel", func(opts ...ai.Option) ai.Model {
+		m := &replayModel{}
+		_ = m.Init(opts...)
+		return m
+	})
+	ctx := context.Background()
+	cp := aiflow.StoreCheckpoint(store.NewMemoryStore(), "support")
+	if err := cp.Save(ctx, aiflow.Run{ID: "run-1", Flow: "support", Status: "done",
+		State: aiflow.State{Data: []byte(`{"Reply":"order shipped","Input":"where is order 42"}`)},
+		Steps: []aiflow.StepRecord{
+			{Name: "ask", Status: "done"},
+			{Name: `tool:orders_Get:{"id":"42"}`, Status: "done", Result: "shipped"},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	newAgent := func(model string) goagent.Agent {
+		return goagent.New(goagent.Name("support"), goagent.Provider("replaymodel"), goagent.Model(model),
+			goagent.WithRegistry(registry.NewMemoryRegistry()), goagent.WithStore(store.NewMemoryStore()), goagent.WithCheckpoint(cp))
+	}
+
+	var out bytes.Buffer
+	if err := runAgentReplay(ctx, &out, newAgent("42"), "run-1", goagent.ReplayOptions{}, replayConfig{failOnDivergence: true}); err != nil {
+		t.Fatalf("replay: %v\n%s", err, out.String())
+	}
+	for _, want := range []string{"Input: where is order 42", "1 recorded, 1 replayed", "✓ Same tool calls", "Recorded reply: order shipped", "Replay reply:   order: shipped"} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	err := runAgentReplay(ctx, &out, newAgent("43"), "run-1", goagent.ReplayOptions{}, replayConfig{failOnDivergence: true})
+	if err == nil || !strings.Contains(err.Error(), "diverged") {
+		t.Fatalf("error = %v, want a divergence", err)
+	}
+	if want := `✗ call 1: orders_Get with {"id":"43"} instead of {"id":"42"}`; !strings.Contains(out.String(), want) {
+		t.Fatalf("output missing %q:\n%s", want, out.String())
+	}
+}
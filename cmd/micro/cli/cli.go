@@ -4,13 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/urfave/cli/v2"
 	"go-micro.dev/v6/client"
 	"go-micro.dev/v6/cmd"
 	"go-micro.dev/v6/codec/bytes"
+	"go-micro.dev/v6/events/schema"
 	"go-micro.dev/v6/registry"
 
 	"go-micro.dev/v6/cmd/micro/cli/new"
@@ -21,6 +24,7 @@ import (
 	_ "go-micro.dev/v6/cmd/micro/cli/build"
 	_ "go-micro.dev/v6/cmd/micro/cli/deploy"
 	_ "go-micro.dev/v6/cmd/micro/cli/init"
+	_ "go-micro.dev/v6/cmd/micro/cli/prompt"
 	_ "go-micro.dev/v6/cmd/micro/cli/remote"
 )
 
@@ -109,6 +113,54 @@ const docsWayfinding = `First-agent and 0→hero docs:
      https://go-micro.dev/docs/guides/zero-to-hero.html
      Walk the scaffold → run → chat → inspect → deploy dry-run lifecycle.`
 
+// listServices prints the registered services, flagging those with nodes
+// that advertise a degraded or down health status.
+func listServices(w io.Writer, reg registry.Registry) error {
+	services, err := reg.ListServices()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, service := range services {
+		if seen[service.Name] {
+			continue
+		}
+		seen[service.Name] = true
+
+		// listings may leave out nodes, so look them up
+		var nodes, degraded, down int
+		if versions, err := reg.GetService(service.Name); err == nil {
+			for _, v := range versions {
+				for _, node := range v.Nodes {
+					nodes++
+					switch node.Metadata[registry.MetadataHealth] {
+					case "degraded":
+						degraded++
+					case "down":
+						down++
+					}
+				}
+			}
+		}
+
+		var flags []string
+		if degraded > 0 {
+			flags = append(flags, fmt.Sprintf("%d degraded", degraded))
+		}
+		if down > 0 {
+			flags = append(flags, fmt.Sprintf("%d down", down))
+		}
+		if len(flags) == 0 {
+			fmt.Fprintln(w, service.Name)
+			continue
+		}
+		fmt.Fprintf(w, "%s [%d nodes: %s]\n", service.Name, nodes, strings.Join(flags, ", "))
+	}
+
+	return nil
+}
+
 func genProtoHandler(c *cli.Context) error {
 	cmd := exec.Command("find", ".", "-name", "*.proto", "-exec", "protoc", "--proto_path=.", "--micro_out=.", "--go_out=.", `{}`, `;`)
 	cmd.Stdout = os.Stdout
@@ -171,14 +223,7 @@ func init() {
 			Name:  "services",
 			Usage: "List available services",
 			Action: func(ctx *cli.Context) error {
-				services, err := registry.ListServices()
-				if err != nil {
-					return err
-				}
-				for _, service := range services {
-					fmt.Println(service.Name)
-				}
-				return nil
+				return listServices(ctx.App.Writer, registry.DefaultRegistry)
 			},
 		},
 
@@ -265,6 +310,12 @@ the no-secret first-agent transcript, Your First Agent, debugging guide, and
 		{
 			Name:  "describe",
 			Usage: "Describe a service",
+			Flags: []cli.Flag{
+				&cli.BoolFlag{
+					Name:  "events",
+					Usage: "Only show the events the service publishes and subscribes to",
+				},
+			},
 			Action: func(ctx *cli.Context) error {
 				args := ctx.Args()
 
@@ -280,7 +331,11 @@ the no-secret first-agent transcript, Your First Agent, debugging guide, and
 				if len(services) == 0 {
 					return nil
 				}
-				b, _ := json.MarshalIndent(services[0], "", "    ")
+				var v interface{} = services[0]
+				if ctx.Bool("events") {
+					v = schema.Contracts(services[0])
+				}
+				b, _ := json.MarshalIndent(v, "", "    ")
 				fmt.Println(string(b))
 				return nil
 			},
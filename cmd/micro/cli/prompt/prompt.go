@@ -0,0 +1,298 @@
+// Package prompt registers the 'micro prompt' CLI commands.
+package prompt
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go-micro.dev/v6/cmd"
+	aiflow "go-micro.dev/v6/flow"
+	goprompt "go-micro.dev/v6/prompt"
+	"go-micro.dev/v6/store"
+)
+
+func init() {
+	cmd.Register(&cli.Command{
+		Name:  "prompt",
+		Usage: "Manage versioned agent and flow prompts",
+		Description: `Prompts in the registry are versioned: every push adds a version, labels
+such as prod and canary point at versions, and a rollout sends a percentage
+of runs to a label's version. Agents and flows built with PromptFrom resolve
+their prompt here and record the version each run used.
+
+  micro prompt push support prompt.txt --note "shorter refund policy"
+  micro prompt label support canary 3
+  micro prompt rollout support canary 10
+  micro prompt stats support`,
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "List prompts with their labels and rollouts",
+				Flags: []cli.Flag{jsonFlag()},
+				Action: func(c *cli.Context) error {
+					return listPrompts(c.App.Writer, registry(), c.Bool("json"))
+				},
+			},
+			{
+				Name:      "versions",
+				Usage:     "List a prompt's versions",
+				ArgsUsage: "[name]",
+				Flags:     []cli.Flag{jsonFlag()},
+				Action: func(c *cli.Context) error {
+					name := c.Args().First()
+					if name == "" {
+						return fmt.Errorf("usage: micro prompt versions [name]")
+					}
+					return listVersions(c.App.Writer, registry(), name, c.Bool("json"))
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "Print a prompt version's text (the latest by default)",
+				ArgsUsage: "[name] [version|label]",
+				Action: func(c *cli.Context) error {
+					name := c.Args().First()
+					if name == "" {
+						return fmt.Errorf("usage: micro prompt show [name] [version|label]")
+					}
+					v, err := lookup(registry(), name, c.Args().Get(1))
+					if err != nil {
+						return err
+					}
+					fmt.Fprintln(c.App.Writer, v.Text)
+					return nil
+				},
+			},
+			{
+				Name:      "push",
+				Usage:     "Add a new version of a prompt from a file, stdin (-) or --text",
+				ArgsUsage: "[name] [file]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "text", Usage: "Prompt text, instead of a file"},
+					&cli.StringFlag{Name: "note", Usage: "What changed in this version"},
+					&cli.StringFlag{Name: "label", Usage: "Point this label at the new version"},
+				},
+				Action: func(c *cli.Context) error {
+					name, file := c.Args().First(), c.Args().Get(1)
+					if name == "" || (file == "" && c.String("text") == "") {
+						return fmt.Errorf("usage: micro prompt push [name] [file] or --text <prompt>")
+					}
+					text := c.String("text")
+					if file != "" {
+						b, err := readFile(file)
+						if err != nil {
+							return err
+						}
+						text = string(b)
+					}
+					return pushPrompt(c.App.Writer, registry(), name, text, c.String("note"), c.String("label"))
+				},
+			},
+			{
+				Name:      "label",
+				Usage:     "Point a label, such as prod or canary, at a version",
+				ArgsUsage: "[name] [label] [version]",
+				Action: func(c *cli.Context) error {
+					name, label := c.Args().First(), c.Args().Get(1)
+					version, err := strconv.Atoi(c.Args().Get(2))
+					if name == "" || label == "" || err != nil {
+						return fmt.Errorf("usage: micro prompt label [name] [label] [version]")
+					}
+					if err := registry().SetLabel(name, label, version); err != nil {
+						return err
+					}
+					fmt.Fprintf(c.App.Writer, "  %s → %s@%d\n", label, name, version)
+					return nil
+				},
+			},
+			{
+				Name:      "rollout",
+				Usage:     "Send a percentage of runs to a label's version (0 ends the rollout)",
+				ArgsUsage: "[name] [label] [percent]",
+				Action: func(c *cli.Context) error {
+					name, label := c.Args().First(), c.Args().Get(1)
+					percent, err := strconv.Atoi(strings.TrimSuffix(c.Args().Get(2), "%"))
+					if name == "" || label == "" || err != nil {
+						return fmt.Errorf("usage: micro prompt rollout [name] [label] [percent]")
+					}
+					reg := registry()
+					if err := reg.SetRollout(name, label, percent); err != nil {
+						return err
+					}
+					p, err := reg.Info(name)
+					if err != nil {
+						return err
+					}
+					writePrompt(c.App.Writer, p)
+					return nil
+				},
+			},
+			{
+				Name:      "stats",
+				Usage:     "Compare prompt versions across an agent's or flow's recorded runs",
+				ArgsUsage: "[agent|flow]",
+				Description: `Aggregate the checkpointed runs of an agent or stepped flow by the prompt
+version each run used: runs, failures, pass rate, error rate and latency.
+Runs that didn't use a registry prompt aren't counted.`,
+				Flags: []cli.Flag{
+					&cli.IntFlag{Name: "limit", Usage: "Only the most recently updated N runs"},
+					jsonFlag(),
+				},
+				Action: func(c *cli.Context) error {
+					name := c.Args().First()
+					if name == "" {
+						return fmt.Errorf("usage: micro prompt stats [agent|flow]")
+					}
+					cp := aiflow.StoreCheckpoint(store.DefaultStore, name)
+					return promptStats(context.Background(), c.App.Writer, cp, name, c.Int("limit"), c.Bool("json"))
+				},
+			},
+		},
+	})
+}
+
+func jsonFlag() cli.Flag {
+	return &cli.BoolFlag{Name: "json", Usage: "Print as JSON"}
+}
+
+func registry() *goprompt.Registry {
+	return goprompt.NewRegistry(store.DefaultStore)
+}
+
+func readFile(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// lookup finds a version by number or label; empty means the latest.
+func lookup(reg *goprompt.Registry, name, ref string) (*goprompt.Version, error) {
+	if ref == "" {
+		return reg.Get(name, 0)
+	}
+	if n, err := strconv.Atoi(ref); err == nil {
+		return reg.Get(name, n)
+	}
+	return reg.Labeled(name, ref)
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func listPrompts(w io.Writer, reg *goprompt.Registry, asJSON bool) error {
+	prompts, err := reg.List()
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return writeJSON(w, prompts)
+	}
+	if len(prompts) == 0 {
+		fmt.Fprintln(w, "  No prompts. Add one with: micro prompt push [name] [file]")
+		return nil
+	}
+	for _, p := range prompts {
+		writePrompt(w, p)
+	}
+	return nil
+}
+
+func writePrompt(w io.Writer, p *goprompt.Prompt) {
+	line := fmt.Sprintf("  %-20s latest=%d", p.Name, p.Latest)
+	labels := make([]string, 0, len(p.Labels))
+	for l := range p.Labels {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	for _, l := range labels {
+		line += fmt.Sprintf("  %s=%d", l, p.Labels[l])
+		if pc, ok := p.Rollout[l]; ok {
+			line += fmt.Sprintf(" (%d%%)", pc)
+		}
+	}
+	fmt.Fprintln(w, line)
+}
+
+func listVersions(w io.Writer, reg *goprompt.Registry, name string, asJSON bool) error {
+	p, err := reg.Info(name)
+	if err != nil {
+		return err
+	}
+	versions, err := reg.Versions(name)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return writeJSON(w, versions)
+	}
+	labels := map[int][]string{}
+	for l, n := range p.Labels {
+		labels[n] = append(labels[n], l)
+	}
+	for _, v := range versions {
+		line := fmt.Sprintf("  v%-4d %s", v.Version, v.Created.Format("2006-01-02 15:04:05"))
+		if ls := labels[v.Version]; len(ls) > 0 {
+			sort.Strings(ls)
+			line += "  [" + strings.Join(ls, ", ") + "]"
+		}
+		if v.Note != "" {
+			line += "  " + v.Note
+		}
+		fmt.Fprintln(w, line)
+	}
+	return nil
+}
+
+func pushPrompt(w io.Writer, reg *goprompt.Registry, name, text, note, label string) error {
+	v, err := reg.Push(name, strings.TrimSpace(text), note)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "  Pushed %s\n", v.Ref())
+	if label == "" {
+		return nil
+	}
+	if err := reg.SetLabel(name, label, v.Version); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "  %s → %s\n", label, v.Ref())
+	return nil
+}
+
+func promptStats(ctx context.Context, w io.Writer, cp aiflow.Checkpoint, name string, limit int, asJSON bool) error {
+	runs, err := cp.List(ctx)
+	if err != nil {
+		return err
+	}
+	if limit > 0 && len(runs) > limit {
+		sort.Slice(runs, func(i, j int) bool { return runs[i].Updated.After(runs[j].Updated) })
+		runs = runs[:limit]
+	}
+	stats := aiflow.Analyze(runs).Prompts
+	if asJSON {
+		return writeJSON(w, stats)
+	}
+	if len(stats) == 0 {
+		fmt.Fprintf(w, "  No runs of %q used a registry prompt.\n", name)
+		return nil
+	}
+	fmt.Fprintf(w, "  %-24s %6s %8s %6s %6s %9s %9s\n", "PROMPT", "RUNS", "FAILURES", "PASS", "ERROR", "P50", "P95")
+	for _, s := range stats {
+		fmt.Fprintf(w, "  %-24s %6d %8d %5.0f%% %5.0f%% %9s %9s\n",
+			fmt.Sprintf("%s@%d", s.Prompt, s.Version), s.Runs, s.Failures, s.PassRate*100, s.ErrorRate*100,
+			s.P50Latency.Round(time.Millisecond), s.P95Latency.Round(time.Millisecond))
+	}
+	return nil
+}
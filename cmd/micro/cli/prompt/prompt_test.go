@@ -0,0 +1,79 @@
+package prompt
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	aiflow "go-micro.dev/v6/flow"
+	goprompt "go-micro.dev/v6/prompt"
+	"go-micro.dev/v6/store"
+)
+
+func TestPushLabelAndList(t *testing.T) {
+	reg := goprompt.NewRegistry(store.NewMemoryStore())
+	var out bytes.Buffer
+	if err := pushPrompt(&out, reg, "support", "  You are support.\n", "first", "prod"); err != nil {
+		t.Fatal(err)
+	}
+	if err := pushPrompt(&out, reg, "support", "You are friendly support.", "friendlier", "canary"); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.SetRollout("support", "canary", 10); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Pushed support@2") || !strings.Contains(out.String(), "canary → support@2") {
+		t.Fatalf("push output:\n%s", out.String())
+	}
+	if v, _ := lookup(reg, "support", "prod"); v.Text != "You are support." {
+		t.Fatalf("prod = %q", v.Text)
+	}
+
+	out.Reset()
+	if err := listPrompts(&out, reg, false); err != nil {
+		t.Fatal(err)
+	}
+	if want := "latest=2  canary=2 (10%)  prod=1"; !strings.Contains(out.String(), want) {
+		t.Fatalf("list output missing %q:\n%s", want, out.String())
+	}
+	out.Reset()
+	if err := listVersions(&out, reg, "support", false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "[prod]  first") || !strings.Contains(out.String(), "[canary]  friendlier") {
+		t.Fatalf("versions output:\n%s", out.String())
+	}
+}
+
+func TestPromptStats(t *testing.T) {
+	ctx := context.Background()
+	cp := aiflow.StoreCheckpoint(store.NewMemoryStore(), "support")
+	now := time.Now()
+	for _, run := range []aiflow.Run{
+		{ID: "1", Prompt: "support", PromptVersion: 1, Status: "done", Started: now, Steps: []aiflow.StepRecord{{Name: "ask", Status: "done"}}},
+		{ID: "2", Prompt: "support", PromptVersion: 2, Status: "failed", Started: now, Steps: []aiflow.StepRecord{{Name: "ask", Status: "failed", Error: "boom"}}},
+		{ID: "3", Status: "done", Started: now},
+	} {
+		if err := cp.Save(ctx, run); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var out bytes.Buffer
+	if err := promptStats(ctx, &out, cp, "support", 0, false); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "support@1") || !strings.Contains(lines[2], "support@2") || !strings.Contains(lines[2], "100%") {
+		t.Fatalf("stats output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := promptStats(ctx, &out, aiflow.StoreCheckpoint(store.NewMemoryStore(), "other"), "other", 0, false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "No runs") {
+		t.Fatalf("empty stats output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,47 @@
+package microcli
+
+import (
+	"bytes"
+	"testing"
+
+	"go-micro.dev/v6/registry"
+)
+
+func TestListServicesFlagsUnhealthyNodes(t *testing.T) {
+	reg := registry.NewMemoryRegistry()
+	for _, s := range []*registry.Service{
+		{Name: "greeter", Version: "1", Nodes: []*registry.Node{
+			{Id: "greeter-1", Address: "10.0.0.1:1"},
+			{Id: "greeter-2", Address: "10.0.0.2:1", Metadata: map[string]string{registry.MetadataHealth: "degraded"}},
+		}},
+		{Name: "greeter", Version: "2", Nodes: []*registry.Node{
+			{Id: "greeter-3", Address: "10.0.0.3:1", Metadata: map[string]string{registry.MetadataHealth: "down"}},
+		}},
+		{Name: "orders", Version: "1", Nodes: []*registry.Node{
+			{Id: "orders-1", Address: "10.0.0.4:1", Metadata: map[string]string{registry.MetadataHealth: "up"}},
+		}},
+	} {
+		if err := reg.Register(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := listServices(&out, reg); err != nil {
+		t.Fatalf("list services: %v", err)
+	}
+
+	want := map[string]bool{
+		"greeter [3 nodes: 1 degraded, 1 down]": true,
+		"orders":                                true,
+	}
+	lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+	if len(lines) != len(want) {
+		t.Fatalf("output = %q, want one line per service", out.String())
+	}
+	for _, l := range lines {
+		if !want[string(l)] {
+			t.Errorf("unexpected line %q", l)
+		}
+	}
+}
@@ -1,51 +1,151 @@
 package resource
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/urfave/cli/v2"
 	"go-micro.dev/v6/config"
+	"go-micro.dev/v6/config/source"
+	"go-micro.dev/v6/config/source/consul"
 	"go-micro.dev/v6/config/source/env"
+	"go-micro.dev/v6/config/source/etcd"
+	"go-micro.dev/v6/config/source/nats"
+	storesource "go-micro.dev/v6/config/source/store"
 )
 
-// configCommand exposes the config interface: get, dump.
+// configCommand exposes the config interface: get, set, watch, dump.
 //
-// The CLI loads configuration from environment variables (the source
-// that makes sense without a running service). Keys use dot notation,
+// By default the CLI loads configuration from environment variables (the
+// source that makes sense without a running service). With --source it
+// reads and writes a shared source instead, so values set here are picked
+// up by running services through config.Watch. Keys use dot notation,
 // e.g. "database.host" reads from DATABASE_HOST.
 func configCommand() *cli.Command {
 	return &cli.Command{
 		Name:  "config",
-		Usage: "Read dynamic configuration (from environment)",
-		Description: `Read dynamic configuration loaded from environment variables.
+		Usage: "Read, write and watch dynamic configuration",
+		Description: `Read, write and watch dynamic configuration.
 
-Keys use dot notation: "database.host" maps to DATABASE_HOST.
+Keys use dot notation: "database.host" maps to DATABASE_HOST in the
+environment, or to database/host under the etcd and consul prefix.
 
-  micro config get <key>    Read a config value
-  micro config dump         Print the full config as JSON`,
+  micro config get <key>            Read a config value
+  micro config set <key> <value>    Write a config value
+  micro config watch <key>          Print a value each time it changes
+  micro config dump                 Print the full config as JSON
+
+Use --source to choose where config lives: env (default, read-only),
+store, etcd, consul or nats.`,
 		Subcommands: []*cli.Command{
 			{
 				Name:      "get",
 				Usage:     "Read a config value",
 				ArgsUsage: "<key>",
+				Flags:     configFlags(),
 				Action:    configGet,
 			},
+			{
+				Name:      "set",
+				Usage:     "Write a config value",
+				ArgsUsage: "<key> <value>",
+				Flags:     configFlags(),
+				Action:    configSet,
+			},
+			{
+				Name:      "watch",
+				Usage:     "Print a config value each time it changes",
+				ArgsUsage: "<key>",
+				Flags:     configFlags(),
+				Action:    configWatch,
+			},
 			{
 				Name:   "dump",
 				Usage:  "Print the full config",
+				Flags:  configFlags(),
 				Action: configDump,
 			},
 		},
 	}
 }
 
-func loadConfig() (config.Config, error) {
+func configFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "source",
+			Usage:   "Config source: env, store, etcd, consul or nats",
+			Value:   "env",
+			EnvVars: []string{"MICRO_CONFIG_SOURCE"},
+		},
+		&cli.StringFlag{
+			Name:    "address",
+			Usage:   "Address of the etcd, consul or nats server",
+			EnvVars: []string{"MICRO_CONFIG_ADDRESS"},
+		},
+		&cli.StringFlag{
+			Name:    "prefix",
+			Usage:   "Key prefix (etcd, consul) or key (store, nats) config is kept under",
+			EnvVars: []string{"MICRO_CONFIG_PREFIX"},
+		},
+	}
+}
+
+// configSource returns the source selected by --source.
+func configSource(c *cli.Context) (source.Source, error) {
+	addr, prefix := c.String("address"), c.String("prefix")
+
+	switch name := c.String("source"); name {
+	case "", "env":
+		return env.NewSource(), nil
+	case "store":
+		var opts []source.Option
+		if prefix != "" {
+			opts = append(opts, storesource.WithKey(prefix))
+		}
+		return storesource.NewSource(opts...), nil
+	case "etcd":
+		var opts []source.Option
+		if addr != "" {
+			opts = append(opts, etcd.WithAddress(strings.Split(addr, ",")...))
+		}
+		if prefix != "" {
+			opts = append(opts, etcd.WithPrefix(prefix))
+		}
+		return etcd.NewSource(opts...), nil
+	case "consul":
+		var opts []source.Option
+		if addr != "" {
+			opts = append(opts, consul.WithAddress(addr))
+		}
+		if prefix != "" {
+			opts = append(opts, consul.WithPrefix(prefix))
+		}
+		return consul.NewSource(opts...), nil
+	case "nats":
+		var opts []source.Option
+		if addr != "" {
+			opts = append(opts, nats.WithUrl(strings.Split(addr, ",")...))
+		}
+		if prefix != "" {
+			opts = append(opts, nats.WithKey(prefix))
+		}
+		return nats.NewSource(opts...), nil
+	default:
+		return nil, fmt.Errorf("unknown config source %q", name)
+	}
+}
+
+func loadConfig(c *cli.Context) (config.Config, error) {
+	src, err := configSource(c)
+	if err != nil {
+		return nil, err
+	}
 	conf, err := config.NewConfig()
 	if err != nil {
 		return nil, err
 	}
-	if err := conf.Load(env.NewSource()); err != nil {
+	if err := conf.Load(src); err != nil {
 		return nil, err
 	}
 	return conf, nil
@@ -56,7 +156,7 @@ func configGet(c *cli.Context) error {
 	if key == "" {
 		return fail("usage: micro config get <key>")
 	}
-	conf, err := loadConfig()
+	conf, err := loadConfig(c)
 	if err != nil {
 		return fail("load config: %v", err)
 	}
@@ -69,11 +169,116 @@ func configGet(c *cli.Context) error {
 	return nil
 }
 
+func configSet(c *cli.Context) error {
+	if c.Args().Len() < 2 {
+		return fail("usage: micro config set <key> <value>")
+	}
+	key, value := c.Args().Get(0), c.Args().Get(1)
+
+	if c.String("source") == "" || c.String("source") == "env" {
+		return fail("the env source is read-only, use --source to pick a writable one")
+	}
+	src, err := configSource(c)
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	// sources with a key per value set just that key, so concurrent sets
+	// of different keys don't overwrite each other
+	if kw, ok := src.(source.KeyWriter); ok {
+		if err := kw.WriteKey(strings.Split(key, "."), parseValue(value)); err != nil {
+			return fail("set %q: %v", key, err)
+		}
+		fmt.Printf("Set %q\n", key)
+		return nil
+	}
+
+	cs, err := src.Read()
+	if err != nil {
+		return fail("read config: %v", err)
+	}
+	data := map[string]interface{}{}
+	if cs != nil && len(cs.Data) > 0 {
+		if err := json.Unmarshal(cs.Data, &data); err != nil {
+			return fail("decode config: %v", err)
+		}
+	}
+
+	setPath(data, strings.Split(key, "."), parseValue(value))
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fail("encode config: %v", err)
+	}
+	if err := src.Write(&source.ChangeSet{Data: b, Format: "json"}); err != nil {
+		return fail("set %q: %v", key, err)
+	}
+	fmt.Printf("Set %q\n", key)
+	return nil
+}
+
+func configWatch(c *cli.Context) error {
+	key := c.Args().First()
+	if key == "" {
+		return fail("usage: micro config watch <key>")
+	}
+	conf, err := loadConfig(c)
+	if err != nil {
+		return fail("load config: %v", err)
+	}
+	defer conf.Close()
+
+	path := strings.Split(key, ".")
+	val, err := conf.Get(path...)
+	if err != nil {
+		return fail("get %q: %v", key, err)
+	}
+	fmt.Println(string(val.Bytes()))
+
+	w, err := conf.Watch(path...)
+	if err != nil {
+		return fail("watch %q: %v", key, err)
+	}
+	defer w.Stop()
+
+	for {
+		val, err := w.Next()
+		if err != nil {
+			return fail("watch %q: %v", key, err)
+		}
+		fmt.Println(string(val.Bytes()))
+	}
+}
+
 func configDump(c *cli.Context) error {
-	conf, err := loadConfig()
+	conf, err := loadConfig(c)
 	if err != nil {
 		return fail("load config: %v", err)
 	}
 	fmt.Println(string(conf.Bytes()))
 	return nil
 }
+
+// parseValue reads v as JSON so numbers, booleans and objects keep their
+// type, falling back to a plain string.
+func parseValue(v string) interface{} {
+	var val interface{}
+	if err := json.Unmarshal([]byte(v), &val); err != nil {
+		return v
+	}
+	return val
+}
+
+// setPath sets val at path in data, creating or replacing intermediate
+// maps as needed.
+func setPath(data map[string]interface{}, path []string, val interface{}) {
+	for _, p := range path[:len(path)-1] {
+		next, ok := data[p].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			data[p] = next
+		}
+		data = next
+	}
+	data[path[len(path)-1]] = val
+}
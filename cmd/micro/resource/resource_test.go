@@ -27,3 +27,24 @@ func TestExpectedCommands(t *testing.T) {
 		}
 	}
 }
+
+func TestConfigSetPath(t *testing.T) {
+	data := map[string]interface{}{"database": "flat"}
+	setPath(data, []string{"database", "port"}, parseValue("5432"))
+	setPath(data, []string{"database", "host"}, parseValue("db.local"))
+	setPath(data, []string{"debug"}, parseValue("true"))
+
+	db, ok := data["database"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("database is %T, want map", data["database"])
+	}
+	if db["port"] != float64(5432) {
+		t.Errorf("database.port = %v, want 5432", db["port"])
+	}
+	if db["host"] != "db.local" {
+		t.Errorf("database.host = %v, want db.local", db["host"])
+	}
+	if data["debug"] != true {
+		t.Errorf("debug = %v, want true", data["debug"])
+	}
+}
@@ -17,6 +17,7 @@ const (
 	clientPkgPath  = "go-micro.dev/v6/client"
 	serverPkgPath  = "go-micro.dev/v6/server"
 	modelPkgPath   = "go-micro.dev/v6/model"
+	schemaPkgPath  = "go-micro.dev/v6/events/schema"
 )
 
 func init() {
@@ -42,6 +43,7 @@ var (
 	clientPkg  string
 	serverPkg  string
 	modelPkg   string
+	schemaPkg  string
 	pkgImports map[generator.GoPackageName]bool
 )
 
@@ -52,6 +54,7 @@ func (g *micro) Init(gen *generator.Generator) {
 	clientPkg = generator.RegisterUniquePackageName("client", nil)
 	serverPkg = generator.RegisterUniquePackageName("server", nil)
 	modelPkg = generator.RegisterUniquePackageName("model", nil)
+	schemaPkg = generator.RegisterUniquePackageName("schema", nil)
 }
 
 // Given a type name defined in a .proto, return its object.
@@ -71,27 +74,33 @@ func (g *micro) P(args ...interface{}) { g.gen.P(args...) }
 
 // Generate generates code for the services in the given file.
 func (g *micro) Generate(file *generator.FileDescriptor) {
-	// Check if any messages have @model annotation
+	// Check if any messages have @model or @event annotations
 	hasModels := false
+	hasEvents := false
 	for i := range file.MessageType {
 		if g.isModelMessage(i) {
 			hasModels = true
-			break
+		}
+		if g.isEventMessage(i) {
+			hasEvents = true
 		}
 	}
 
-	if len(file.Service) == 0 && !hasModels {
+	if len(file.Service) == 0 && !hasModels && !hasEvents {
 		return
 	}
 
 	g.P("// Reference imports to suppress errors if they are not otherwise used.")
 	g.P("var _ ", contextPkg, ".Context")
-	if len(file.Service) > 0 {
+	if len(file.Service) > 0 || hasEvents {
 		g.P("var _ ", clientPkg, ".Option")
 		g.P("var _ ", serverPkg, ".Option")
 	}
 	if hasModels {
-		g.P("var _ ", modelPkg, ".Database")
+		g.P("var _ ", modelPkg, ".Model")
+	}
+	if hasEvents {
+		g.P("var _ ", schemaPkg, ".Schema")
 	}
 	g.P()
 
@@ -105,32 +114,45 @@ func (g *micro) Generate(file *generator.FileDescriptor) {
 			g.generateModel(msg, i)
 		}
 	}
+
+	// Generate typed publishers and subscribers for @event annotated messages
+	for i, msg := range file.MessageType {
+		if g.isEventMessage(i) {
+			g.generateEvent(file, msg, i)
+		}
+	}
 }
 
 // GenerateImports generates the import declaration for this file.
 func (g *micro) GenerateImports(file *generator.FileDescriptor, imports map[generator.GoImportPath]generator.GoPackageName) {
 	hasServices := len(file.Service) > 0
 	hasModels := false
+	hasEvents := false
 	for i := range file.MessageType {
 		if g.isModelMessage(i) {
 			hasModels = true
-			break
+		}
+		if g.isEventMessage(i) {
+			hasEvents = true
 		}
 	}
 
-	if !hasServices && !hasModels {
+	if !hasServices && !hasModels && !hasEvents {
 		return
 	}
 
 	g.P("import (")
 	g.P(contextPkg, " ", strconv.Quote(path.Join(g.gen.ImportPrefix, contextPkgPath)))
-	if hasServices {
+	if hasServices || hasEvents {
 		g.P(clientPkg, " ", strconv.Quote(path.Join(g.gen.ImportPrefix, clientPkgPath)))
 		g.P(serverPkg, " ", strconv.Quote(path.Join(g.gen.ImportPrefix, serverPkgPath)))
 	}
 	if hasModels {
 		g.P(modelPkg, " ", strconv.Quote(path.Join(g.gen.ImportPrefix, modelPkgPath)))
 	}
+	if hasEvents {
+		g.P(schemaPkg, " ", strconv.Quote(path.Join(g.gen.ImportPrefix, schemaPkgPath)))
+	}
 	g.P(")")
 	g.P()
 
@@ -709,3 +731,112 @@ func (g *micro) generateModel(msg *pb.DescriptorProto, msgIndex int) {
 	g.P("}")
 	g.P()
 }
+
+// isEventMessage checks if the message at the given index has a // @event annotation.
+func (g *micro) isEventMessage(msgIndex int) bool {
+	commentPath := fmt.Sprintf("4,%d", msgIndex)
+	comment, ok := g.gen.GetComments(commentPath)
+	if !ok {
+		return false
+	}
+	return strings.Contains(comment, "@event")
+}
+
+// parseEventOptions extracts options from the @event annotation comment.
+// Supports: @event, @event(topic=user.created), @event(version=2)
+func parseEventOptions(comment string) (topic string, version int) {
+	idx := strings.Index(comment, "@event")
+	if idx < 0 {
+		return "", 0
+	}
+	rest := comment[idx+len("@event"):]
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, "(") {
+		return "", 0
+	}
+	end := strings.Index(rest, ")")
+	if end < 0 {
+		return "", 0
+	}
+	opts := rest[1:end]
+	for _, part := range strings.Split(opts, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch strings.TrimSpace(kv[0]) {
+		case "topic":
+			topic = strings.TrimSpace(kv[1])
+		case "version":
+			version, _ = strconv.Atoi(strings.TrimSpace(kv[1]))
+		}
+	}
+	return topic, version
+}
+
+// generateEvent generates the topic, schema, typed publisher and subscriber
+// registration for an event message.
+func (g *micro) generateEvent(file *generator.FileDescriptor, msg *pb.DescriptorProto, msgIndex int) {
+	msgName := generator.CamelCase(msg.GetName())
+
+	commentPath := fmt.Sprintf("4,%d", msgIndex)
+	comment, _ := g.gen.GetComments(commentPath)
+	topic, version := parseEventOptions(comment)
+
+	// Default topic: proto package + "." + message name
+	if topic == "" {
+		topic = msg.GetName()
+		if pkg := file.GetPackage(); pkg != "" {
+			topic = pkg + "." + topic
+		}
+	}
+	if version < 1 {
+		version = 1
+	}
+
+	pubName := msgName + "Publisher"
+	pubImpl := unexport(pubName)
+
+	g.P()
+	g.P("// ", msgName, "Topic is the topic ", msgName, " events are published on.")
+	g.P("const ", msgName, "Topic = ", strconv.Quote(topic))
+	g.P()
+	g.P("// ", msgName, "SchemaVersion is the version of the ", msgName, " event contract.")
+	g.P("const ", msgName, "SchemaVersion = ", version)
+	g.P()
+
+	g.P("// ", msgName, "Schema returns the ", msgName, " event contract, to register")
+	g.P("// with a schema.Registry and advertise with schema.Advertise.")
+	g.P("func ", msgName, "Schema() *", schemaPkg, ".Schema {")
+	g.P("return ", schemaPkg, ".New(", msgName, "Topic, ", msgName, "SchemaVersion, &", msgName, "{})")
+	g.P("}")
+	g.P()
+
+	g.P("// ", pubName, " publishes ", msgName, " events.")
+	g.P("type ", pubName, " interface {")
+	g.P("Publish(ctx ", contextPkg, ".Context, in *", msgName, ", opts ...", clientPkg, ".PublishOption) error")
+	g.P("}")
+	g.P()
+	g.P("type ", pubImpl, " struct {")
+	g.P("c ", clientPkg, ".Client")
+	g.P("}")
+	g.P()
+	g.P("// New", pubName, " returns a publisher of ", msgName, " events.")
+	g.P("func New", pubName, "(c ", clientPkg, ".Client) ", pubName, " {")
+	g.P("return &", pubImpl, "{c: c}")
+	g.P("}")
+	g.P()
+	g.P("// Publish an event, stamped with its schema version.")
+	g.P("func (p *", pubImpl, ") Publish(ctx ", contextPkg, ".Context, in *", msgName, ", opts ...", clientPkg, ".PublishOption) error {")
+	g.P("ctx = ", schemaPkg, ".WithVersion(ctx, ", msgName, "SchemaVersion)")
+	g.P("return p.c.Publish(ctx, p.c.NewMessage(", msgName, "Topic, in), opts...)")
+	g.P("}")
+	g.P()
+
+	g.P("// Register", msgName, "Subscriber subscribes h to ", msgName, " events and")
+	g.P("// advertises the schema version it reads.")
+	g.P("func Register", msgName, "Subscriber(s ", serverPkg, ".Server, h func(", contextPkg, ".Context, *", msgName, ") error, opts ...", serverPkg, ".SubscriberOption) error {")
+	g.P("opts = append(opts, ", schemaPkg, ".SubscriberVersion(", msgName, "SchemaVersion))")
+	g.P("return s.Subscribe(s.NewSubscriber(", msgName, "Topic, h, opts...))")
+	g.P("}")
+}
@@ -27,6 +27,31 @@ func TestParseModelOptions(t *testing.T) {
 	}
 }
 
+func TestParseEventOptions(t *testing.T) {
+	tests := []struct {
+		comment     string
+		wantTopic   string
+		wantVersion int
+	}{
+		{" @event\n", "", 0},
+		{" @event(topic=user.created)\n", "user.created", 0},
+		{" @event(version=2)\n", "", 2},
+		{" @event(topic=user.created, version=3)\n", "user.created", 3},
+		{" UserCreated is published on signup.\n @event(version=x)\n", "", 0},
+		{" no annotation here\n", "", 0},
+	}
+
+	for _, tt := range tests {
+		topic, version := parseEventOptions(tt.comment)
+		if topic != tt.wantTopic {
+			t.Errorf("parseEventOptions(%q): topic = %q, want %q", tt.comment, topic, tt.wantTopic)
+		}
+		if version != tt.wantVersion {
+			t.Errorf("parseEventOptions(%q): version = %d, want %d", tt.comment, version, tt.wantVersion)
+		}
+	}
+}
+
 func TestProtoFieldGoType(t *testing.T) {
 	// Smoke test - just verify it doesn't panic with nil
 	typ := protoFieldGoType(nil)
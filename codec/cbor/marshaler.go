@@ -0,0 +1,29 @@
+package cbor
+
+import (
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// decMode decodes untyped maps as map[string]interface{} so that values
+// read into interface{} look the same as they would through the json codec.
+var decMode, _ = cbor.DecOptions{
+	DefaultMapType: reflect.TypeOf(map[string]interface{}(nil)),
+}.DecMode()
+
+// Marshaler encodes values as CBOR. Struct fields fall back to their json
+// tag when no cbor tag is present.
+type Marshaler struct{}
+
+func (Marshaler) Marshal(v interface{}) ([]byte, error) {
+	return cbor.Marshal(v)
+}
+
+func (Marshaler) Unmarshal(d []byte, v interface{}) error {
+	return decMode.Unmarshal(d, v)
+}
+
+func (Marshaler) String() string {
+	return "cbor"
+}
@@ -0,0 +1,38 @@
+package msgpack
+
+import (
+	"bytes"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Marshaler encodes values as MessagePack. Struct fields are keyed by their
+// json tag when no msgpack tag is present, so types already shaped for the
+// json codec can switch content type without changes.
+type Marshaler struct{}
+
+func (Marshaler) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(enc)
+
+	enc.Reset(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (Marshaler) Unmarshal(d []byte, v interface{}) error {
+	dec := msgpack.GetDecoder()
+	defer msgpack.PutDecoder(dec)
+
+	dec.Reset(bytes.NewReader(d))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+func (Marshaler) String() string {
+	return "msgpack"
+}
@@ -0,0 +1,130 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"go-micro.dev/v6/codec"
+)
+
+type rwc struct {
+	*bytes.Buffer
+}
+
+func (rwc) Close() error {
+	return nil
+}
+
+type payload struct {
+	Name   string            `json:"name"`
+	Count  int64             `json:"count"`
+	Tags   []string          `json:"tags"`
+	Labels map[string]string `json:"labels"`
+	Blob   []byte            `json:"blob"`
+}
+
+func testPayload() *payload {
+	return &payload{
+		Name:   "greeter",
+		Count:  42,
+		Tags:   []string{"a", "b", "c"},
+		Labels: map[string]string{"zone": "eu-west-1a"},
+		Blob:   bytes.Repeat([]byte{0xde, 0xad, 0xbe, 0xef}, 256),
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	buf := rwc{bytes.NewBuffer(nil)}
+	c := NewCodec(buf)
+
+	in := testPayload()
+	if err := c.Write(&codec.Message{Type: codec.Request}, in); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	out := new(payload)
+	if err := c.ReadBody(out); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Fatalf("round trip mismatch: got %+v want %+v", out, in)
+	}
+	if c.String() != "msgpack" {
+		t.Fatalf("unexpected codec name %q", c.String())
+	}
+}
+
+func TestMarshalerUsesJSONTags(t *testing.T) {
+	m := Marshaler{}
+	b, err := m.Marshal(testPayload())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var generic map[string]interface{}
+	if err := m.Unmarshal(b, &generic); err != nil {
+		t.Fatal(err)
+	}
+	if generic["name"] != "greeter" {
+		t.Fatalf("expected json tag keys, got %v", generic)
+	}
+}
+
+func TestMarshalerSmallerThanJSON(t *testing.T) {
+	in := testPayload()
+	mb, err := Marshaler{}.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jb, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mb) >= len(jb) {
+		t.Fatalf("msgpack %d bytes, json %d bytes", len(mb), len(jb))
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	in := testPayload()
+	b.Run("msgpack", func(b *testing.B) {
+		m := Marshaler{}
+		for i := 0; i < b.N; i++ {
+			if _, err := m.Marshal(in); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("json", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := json.Marshal(in); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	in := testPayload()
+	mb, _ := Marshaler{}.Marshal(in)
+	jb, _ := json.Marshal(in)
+	b.Run("msgpack", func(b *testing.B) {
+		m := Marshaler{}
+		for i := 0; i < b.N; i++ {
+			var out payload
+			if err := m.Unmarshal(mb, &out); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("json", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var out payload
+			if err := json.Unmarshal(jb, &out); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,379 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-micro.dev/v6/config/reader"
+	"go-micro.dev/v6/logger"
+)
+
+// Validator is implemented by bound types that need checks beyond the
+// `validate` struct tags. Validate is called after the tags pass.
+type Validator interface {
+	Validate() error
+}
+
+// Binding keeps a struct in sync with a config path. Create one with Bind
+// or BindConfig.
+type Binding[T any] struct {
+	path []string
+	cur  atomic.Pointer[T]
+
+	sync.Mutex
+	onChange []func(old, new T)
+	err      error
+	w        Watcher
+	exit     chan bool
+}
+
+// Bind binds v to path of the default config. See BindConfig.
+func Bind[T any](v *T, path ...string) (*Binding[T], error) {
+	return BindConfig(DefaultConfig, v, path...)
+}
+
+// BindConfig scans the value at path into v, which must point to a
+// struct. Fields missing from the config are set from their `default`
+// tag, and the result is checked against `validate` tags (required,
+// min, max, oneof) and the Validator interface.
+//
+// v holds the value at the time of the call. The binding then follows
+// the config as it changes, swapping in each accepted value atomically;
+// read it with Get, which is safe from any goroutine. v is never written
+// again, so it can't race with readers. An update that fails to decode or
+// validate is rejected and Get keeps returning the last good value; the
+// error is logged and available from Err.
+func BindConfig[T any](c Config, v *T, path ...string) (*Binding[T], error) {
+	if reflect.TypeOf(v).Elem().Kind() != reflect.Struct {
+		return nil, fmt.Errorf("config: bind target must point to a struct, got %T", v)
+	}
+
+	val, err := c.Get(path...)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := decode[T](val, path)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Binding[T]{
+		path: path,
+		exit: make(chan bool),
+	}
+	*v = *n
+	b.cur.Store(n)
+
+	if c.Options().WithWatcherDisabled {
+		return b, nil
+	}
+
+	w, err := c.Watch(path...)
+	if err != nil {
+		return nil, err
+	}
+	b.w = w
+
+	go b.run()
+
+	return b, nil
+}
+
+// Get returns the current value. It is safe for concurrent use.
+func (b *Binding[T]) Get() T {
+	return *b.cur.Load()
+}
+
+// OnChange registers fn to be called with the old and new value after
+// each accepted update, e.g. to resize a pool. Callbacks run in order on
+// the binding's watch goroutine.
+func (b *Binding[T]) OnChange(fn func(old, new T)) {
+	b.Lock()
+	b.onChange = append(b.onChange, fn)
+	b.Unlock()
+}
+
+// Err returns the error that caused the most recent update to be
+// rejected, or nil if the last update was accepted.
+func (b *Binding[T]) Err() error {
+	b.Lock()
+	defer b.Unlock()
+	return b.err
+}
+
+// Stop stops watching for changes. Get keeps returning the last value.
+func (b *Binding[T]) Stop() error {
+	b.Lock()
+	defer b.Unlock()
+
+	select {
+	case <-b.exit:
+		return nil
+	default:
+		close(b.exit)
+	}
+
+	if b.w == nil {
+		return nil
+	}
+	return b.w.Stop()
+}
+
+func (b *Binding[T]) run() {
+	for {
+		val, err := b.w.Next()
+		if err != nil {
+			select {
+			case <-b.exit:
+				return
+			default:
+			}
+			logger.Logf(logger.ErrorLevel, "config: watch %s: %v", strings.Join(b.path, "."), err)
+			return
+		}
+
+		b.update(val)
+	}
+}
+
+func (b *Binding[T]) update(val reader.Value) {
+	n, err := decode[T](val, b.path)
+
+	b.Lock()
+	b.err = err
+	if err != nil {
+		b.Unlock()
+		logger.Logf(logger.WarnLevel, "config: rejected update to %s: %v", strings.Join(b.path, "."), err)
+		return
+	}
+
+	old := b.cur.Swap(n)
+	callbacks := b.onChange
+	b.Unlock()
+
+	for _, fn := range callbacks {
+		fn(*old, *n)
+	}
+}
+
+// decode builds a T from the value at path: defaults first, then the
+// config values on top, then validation.
+func decode[T any](val reader.Value, path []string) (*T, error) {
+	n := new(T)
+	rv := reflect.ValueOf(n).Elem()
+
+	if err := setDefaults(rv); err != nil {
+		return nil, err
+	}
+
+	if err := val.Scan(n); err != nil {
+		return nil, err
+	}
+
+	prefix := ""
+	if len(path) > 0 {
+		prefix = strings.Join(path, ".") + "."
+	}
+	if err := validate(rv, prefix); err != nil {
+		return nil, err
+	}
+	if v, ok := any(n).(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return n, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setDefaults sets each field of the struct rv from its `default` tag.
+func setDefaults(rv reflect.Value) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+
+		if f.Type.Kind() == reflect.Struct {
+			if err := setDefaults(fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		def, ok := f.Tag.Lookup("default")
+		if !ok {
+			continue
+		}
+		if err := setString(fv, def); err != nil {
+			return fmt.Errorf("config: default for %s: %w", f.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// setString parses s into fv according to its kind.
+func setString(fv reflect.Value, s string) error {
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(v)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", fv.Type())
+		}
+		parts := strings.Split(s, ",")
+		sl := reflect.MakeSlice(fv.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			sl.Index(i).SetString(strings.TrimSpace(p))
+		}
+		fv.Set(sl)
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+
+	return nil
+}
+
+// validate checks the `validate` tags of the struct rv. prefix is the
+// dotted path of rv, used in error messages.
+func validate(rv reflect.Value, prefix string) error {
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+		name := prefix + fieldName(f)
+
+		if f.Type.Kind() == reflect.Struct && f.Type != reflect.TypeOf(time.Time{}) {
+			if err := validate(fv, name+"."); err != nil {
+				return err
+			}
+		}
+
+		tag := f.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		for _, rule := range strings.Split(tag, ",") {
+			key, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+			if err := check(fv, key, arg); err != nil {
+				return fmt.Errorf("config: %s %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func check(fv reflect.Value, rule, arg string) error {
+	switch rule {
+	case "":
+		return nil
+	case "required":
+		if fv.IsZero() {
+			return errors.New("is required")
+		}
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Errorf("has invalid %s rule %q", rule, arg)
+		}
+		n, ok := size(fv)
+		if !ok {
+			return fmt.Errorf("does not support %s", rule)
+		}
+		if rule == "min" && n < limit {
+			return fmt.Errorf("must be at least %s", arg)
+		}
+		if rule == "max" && n > limit {
+			return fmt.Errorf("must be at most %s", arg)
+		}
+	case "oneof":
+		s := fmt.Sprint(fv.Interface())
+		for _, o := range strings.Fields(arg) {
+			if s == o {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of [%s], got %q", arg, s)
+	default:
+		return fmt.Errorf("has unknown validate rule %q", rule)
+	}
+
+	return nil
+}
+
+// size is the value of a number, or the length of a string, slice or map.
+func size(fv reflect.Value) (float64, bool) {
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), true
+	case reflect.String, reflect.Slice, reflect.Map:
+		return float64(fv.Len()), true
+	}
+	return 0, false
+}
+
+// fieldName is the config key of f: its json name if it has one.
+func fieldName(f reflect.StructField) string {
+	if tag, ok := f.Tag.Lookup("json"); ok {
+		if name, _, _ := strings.Cut(tag, ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return f.Name
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/config/source"
+	"go-micro.dev/v6/config/source/memory"
+)
+
+type poolConfig struct {
+	Host    string        `json:"host" validate:"required"`
+	Size    int           `json:"size" default:"10" validate:"min=1,max=100"`
+	Mode    string        `json:"mode" default:"rw" validate:"oneof=ro rw"`
+	Timeout time.Duration `json:"timeout" default:"5s"`
+	Tags    []string      `json:"tags" default:"a, b"`
+}
+
+type evenConfig struct {
+	N int `json:"n"`
+}
+
+func (e evenConfig) Validate() error {
+	if e.N%2 != 0 {
+		return errors.New("n must be even")
+	}
+	return nil
+}
+
+func newBindConfig(t *testing.T, data string) (Config, source.Source) {
+	t.Helper()
+	src := memory.NewSource(memory.WithJSON([]byte(data)))
+	c, err := NewConfig(WithSource(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c, src
+}
+
+func TestBindDefaultsAndValidation(t *testing.T) {
+	c, _ := newBindConfig(t, `{"db": {"host": "localhost", "size": 20}}`)
+
+	var cfg poolConfig
+	b, err := BindConfig(c, &cfg, "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Stop()
+
+	if cfg.Host != "localhost" || cfg.Size != 20 {
+		t.Fatalf("config values not scanned: %+v", cfg)
+	}
+	if cfg.Mode != "rw" || cfg.Timeout != 5*time.Second || len(cfg.Tags) != 2 || cfg.Tags[1] != "b" {
+		t.Fatalf("defaults not applied: %+v", cfg)
+	}
+	if got := b.Get(); got.Host != cfg.Host {
+		t.Fatalf("Get() = %+v, want %+v", got, cfg)
+	}
+
+	for data, want := range map[string]string{
+		`{"db": {"size": 20}}`:                          "db.host is required",
+		`{"db": {"host": "h", "size": 0}}`:              "db.size must be at least 1",
+		`{"db": {"host": "h", "size": 101}}`:            "db.size must be at most 100",
+		`{"db": {"host": "h", "mode": "wo"}}`:           "db.mode must be one of",
+		`{"db": {"host": "h", "size": "not a number"}}`: "cannot unmarshal",
+	} {
+		c, _ := newBindConfig(t, data)
+		var cfg poolConfig
+		if _, err := BindConfig(c, &cfg, "db"); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("bind %s: err = %v, want %q", data, err, want)
+		}
+	}
+}
+
+func TestBindValidator(t *testing.T) {
+	c, _ := newBindConfig(t, `{"n": 3}`)
+
+	var cfg evenConfig
+	if _, err := BindConfig(c, &cfg); err == nil {
+		t.Fatal("expected Validate error")
+	}
+}
+
+func TestBindUpdates(t *testing.T) {
+	c, src := newBindConfig(t, `{"db": {"host": "localhost", "size": 10}}`)
+
+	var cfg poolConfig
+	b, err := BindConfig(c, &cfg, "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Stop()
+
+	changes := make(chan [2]poolConfig, 2)
+	b.OnChange(func(old, new poolConfig) {
+		changes <- [2]poolConfig{old, new}
+	})
+
+	// the loader starts watching sources in the background, so keep
+	// writing until the update is seen
+	write := func(data string, done func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !done() {
+			if time.Now().After(deadline) {
+				t.Fatalf("update %s not seen", data)
+			}
+			src.Write(&source.ChangeSet{Data: []byte(data), Format: "json"})
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+
+	// an invalid update is rejected and the last good value kept
+	write(`{"db": {"host": "localhost", "size": 500}}`, func() bool { return b.Err() != nil })
+	if got := b.Get().Size; got != 10 {
+		t.Fatalf("size after rejected update = %d, want 10", got)
+	}
+
+	write(`{"db": {"host": "localhost", "size": 50}}`, func() bool { return len(changes) > 0 })
+	select {
+	case ch := <-changes:
+		if ch[0].Size != 10 || ch[1].Size != 50 {
+			t.Fatalf("OnChange(old, new) sizes = %d, %d, want 10, 50", ch[0].Size, ch[1].Size)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for OnChange")
+	}
+	if b.Err() != nil {
+		t.Fatalf("Err after good update = %v", b.Err())
+	}
+	if got := b.Get().Size; got != 50 {
+		t.Fatalf("Get().Size = %d, want 50", got)
+	}
+}
+
+func TestBindConcurrentReads(t *testing.T) {
+	c, src := newBindConfig(t, `{"db": {"host": "localhost", "size": 10}}`)
+
+	var cfg poolConfig
+	b, err := BindConfig(c, &cfg, "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Stop()
+
+	// readers run throughout the update; -race flags any unsynchronized
+	// write to a value they can see
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if got := b.Get(); got.Size != 10 && got.Size != 50 {
+					t.Errorf("Get().Size = %d mid-update", got.Size)
+					return
+				}
+				_ = cfg.Size
+			}
+		}()
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for b.Get().Size != 50 {
+		if time.Now().After(deadline) {
+			t.Fatal("update not seen")
+		}
+		src.Write(&source.ChangeSet{Data: []byte(`{"db": {"host": "localhost", "size": 50}}`), Format: "json"})
+		time.Sleep(20 * time.Millisecond)
+	}
+	close(stop)
+	wg.Wait()
+
+	if cfg.Size != 10 {
+		t.Fatalf("bound struct changed to size %d, want the value at bind time", cfg.Size)
+	}
+}
@@ -443,8 +443,9 @@ func (w *watcher) Stop() error {
 	select {
 	case <-w.exit:
 	default:
+		// updates is left open: the loader may be sending to it
+		// concurrently, and Next returns on exit
 		close(w.exit)
-		close(w.updates)
 	}
 
 	return nil
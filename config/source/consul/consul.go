@@ -0,0 +1,233 @@
+// Package consul is a config source backed by the consul KV store. Every
+// key under the prefix is a leaf of the config tree, so
+// "micro/config/database/port" is read as database.port, and changes are
+// picked up with blocking queries.
+package consul
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"go-micro.dev/v6/config/source"
+	"go-micro.dev/v6/config/source/internal/kv"
+)
+
+var (
+	// DefaultPrefix is the key prefix config is read from.
+	DefaultPrefix = "micro/config/"
+	// DefaultWaitTime is how long a blocking query waits for a change
+	// before it is reissued.
+	DefaultWaitTime = 5 * time.Minute
+)
+
+// maxTxnOps is the number of operations consul accepts in one transaction.
+const maxTxnOps = 64
+
+type consul struct {
+	prefix      string
+	stripPrefix string
+	opts        source.Options
+	client      *api.Client
+	cerr        error
+}
+
+func (c *consul) Read() (*source.ChangeSet, error) {
+	if c.cerr != nil {
+		return nil, c.cerr
+	}
+
+	pairs, _, err := c.client.KV().List(c.prefix, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.changeSet(pairs)
+}
+
+func (c *consul) changeSet(pairs api.KVPairs) (*source.ChangeSet, error) {
+	values := make(map[string][]byte, len(pairs))
+	for _, p := range pairs {
+		// folders are keys ending in a slash with no value
+		if strings.HasSuffix(p.Key, "/") {
+			continue
+		}
+		values[p.Key] = p.Value
+	}
+
+	b, err := c.opts.Encoder.Encode(kv.Map(c.opts.Encoder, c.stripPrefix, values))
+	if err != nil {
+		return nil, err
+	}
+
+	cs := &source.ChangeSet{
+		Timestamp: time.Now(),
+		Source:    c.String(),
+		Data:      b,
+		Format:    c.opts.Encoder.String(),
+	}
+	cs.Checksum = cs.Sum()
+
+	return cs, nil
+}
+
+// Write replaces the config under the prefix with cs: each leaf is set
+// under its own key and keys no longer present are deleted.
+func (c *consul) Write(cs *source.ChangeSet) error {
+	if c.cerr != nil {
+		return c.cerr
+	}
+
+	var data map[string]interface{}
+	if err := c.opts.Encoder.Decode(cs.Data, &data); err != nil {
+		return err
+	}
+
+	flat, err := kv.Flatten(c.opts.Encoder, c.stripPrefix, data)
+	if err != nil {
+		return err
+	}
+
+	// consul keys have no leading slash
+	values := make(map[string][]byte, len(flat))
+	for k, v := range flat {
+		values[strings.TrimPrefix(k, "/")] = v
+	}
+
+	keys, _, err := c.client.KV().Keys(c.prefix, "", nil)
+	if err != nil {
+		return err
+	}
+
+	var ops api.TxnOps
+	for k, v := range values {
+		ops = append(ops, &api.TxnOp{KV: &api.KVTxnOp{Verb: api.KVSet, Key: k, Value: v}})
+	}
+	for _, k := range keys {
+		if _, ok := values[k]; !ok {
+			ops = append(ops, &api.TxnOp{KV: &api.KVTxnOp{Verb: api.KVDelete, Key: k}})
+		}
+	}
+
+	// consul caps the size of a transaction, so large configs are
+	// applied in batches
+	for len(ops) > 0 {
+		n := len(ops)
+		if n > maxTxnOps {
+			n = maxTxnOps
+		}
+		ok, rsp, _, err := c.client.Txn().Txn(ops[:n], nil)
+		if err != nil {
+			return err
+		}
+		if !ok && rsp != nil && len(rsp.Errors) > 0 {
+			return fmt.Errorf("consul: %s", rsp.Errors[0].What)
+		}
+		ops = ops[n:]
+	}
+
+	return nil
+}
+
+// WriteKey sets the value at path, replacing anything below it, in one
+// transaction.
+func (c *consul) WriteKey(path []string, value interface{}) error {
+	if c.cerr != nil {
+		return c.cerr
+	}
+	if len(path) == 0 {
+		return errors.New("consul: empty key")
+	}
+
+	values, err := kv.Flatten(c.opts.Encoder, c.stripPrefix, kv.Nest(path, value))
+	if err != nil {
+		return err
+	}
+	key := strings.TrimPrefix(kv.Key(c.stripPrefix, path), "/")
+
+	// transaction ops run in order, so the old subtree goes before the
+	// new leaves are set
+	ops := api.TxnOps{
+		{KV: &api.KVTxnOp{Verb: api.KVDeleteTree, Key: key + "/"}},
+		{KV: &api.KVTxnOp{Verb: api.KVDelete, Key: key}},
+	}
+	for _, k := range kv.Parents(c.stripPrefix, path) {
+		ops = append(ops, &api.TxnOp{KV: &api.KVTxnOp{Verb: api.KVDelete, Key: strings.TrimPrefix(k, "/")}})
+	}
+	for k, v := range values {
+		ops = append(ops, &api.TxnOp{KV: &api.KVTxnOp{Verb: api.KVSet, Key: strings.TrimPrefix(k, "/"), Value: v}})
+	}
+	if len(ops) > maxTxnOps {
+		return fmt.Errorf("consul: setting %s takes %d operations, more than fit in one transaction", key, len(ops))
+	}
+
+	ok, rsp, _, err := c.client.Txn().Txn(ops, nil)
+	if err != nil {
+		return err
+	}
+	if !ok && rsp != nil && len(rsp.Errors) > 0 {
+		return fmt.Errorf("consul: %s", rsp.Errors[0].What)
+	}
+	return nil
+}
+
+func (c *consul) Watch() (source.Watcher, error) {
+	if c.cerr != nil {
+		return nil, c.cerr
+	}
+	return newWatcher(c), nil
+}
+
+func (c *consul) String() string {
+	return "consul"
+}
+
+// NewSource returns a consul config source. Client errors are returned
+// from Read and Watch rather than here, like the other sources.
+func NewSource(opts ...source.Option) source.Source {
+	options := source.NewOptions(opts...)
+
+	config := api.DefaultConfig()
+	if a, ok := options.Context.Value(addressKey{}).(string); ok && len(a) > 0 {
+		config.Address = a
+	}
+	if dc, ok := options.Context.Value(datacenterKey{}).(string); ok {
+		config.Datacenter = dc
+	}
+	if t, ok := options.Context.Value(tokenKey{}).(string); ok {
+		config.Token = t
+	}
+	config.WaitTime = DefaultWaitTime
+
+	prefix := DefaultPrefix
+	if p, ok := options.Context.Value(prefixKey{}).(string); ok && len(p) > 0 {
+		prefix = p
+	}
+	// consul keys have no leading slash
+	prefix = strings.TrimPrefix(prefix, "/")
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	strip := true
+	if s, ok := options.Context.Value(stripPrefixKey{}).(bool); ok {
+		strip = s
+	}
+
+	stripPrefix := ""
+	if strip {
+		stripPrefix = prefix
+	}
+
+	client, err := api.NewClient(config)
+
+	return &consul{
+		prefix:      prefix,
+		stripPrefix: stripPrefix,
+		opts:        options,
+		client:      client,
+		cerr:        err,
+	}
+}
@@ -0,0 +1,252 @@
+package consul
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"go-micro.dev/v6/config"
+	"go-micro.dev/v6/config/source"
+)
+
+// mockKV is just enough of the consul KV and txn endpoints to exercise
+// the source, including blocking queries.
+type mockKV struct {
+	sync.Mutex
+	index   uint64
+	kvs     map[string][]byte
+	changed chan struct{}
+}
+
+func newMockKV(t *testing.T) (*mockKV, string) {
+	m := &mockKV{index: 1, kvs: make(map[string][]byte), changed: make(chan struct{})}
+	srv := httptest.NewServer(m)
+	t.Cleanup(srv.Close)
+	return m, strings.TrimPrefix(srv.URL, "http://")
+}
+
+func (m *mockKV) put(k, v string) {
+	m.Lock()
+	defer m.Unlock()
+	m.kvs[k] = []byte(v)
+	m.bump()
+}
+
+// bump must be called with the lock held.
+func (m *mockKV) bump() {
+	m.index++
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+func (m *mockKV) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/v1/txn" && r.Method == http.MethodPut:
+		var ops api.TxnOps
+		if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		m.Lock()
+		for _, op := range ops {
+			switch op.KV.Verb {
+			case api.KVSet:
+				m.kvs[op.KV.Key] = op.KV.Value
+			case api.KVDelete:
+				delete(m.kvs, op.KV.Key)
+			case api.KVDeleteTree:
+				for k := range m.kvs {
+					if strings.HasPrefix(k, op.KV.Key) {
+						delete(m.kvs, k)
+					}
+				}
+			}
+		}
+		m.bump()
+		m.Unlock()
+		w.Write([]byte(`{"Results":[],"Errors":[]}`))
+	case strings.HasPrefix(r.URL.Path, "/v1/kv/") && r.Method == http.MethodGet:
+		m.list(w, r, strings.TrimPrefix(r.URL.Path, "/v1/kv/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (m *mockKV) list(w http.ResponseWriter, r *http.Request, prefix string) {
+	q := r.URL.Query()
+	wait, _ := strconv.ParseUint(q.Get("index"), 10, 64)
+
+	m.Lock()
+	for wait > 0 && m.index == wait {
+		ch := m.changed
+		m.Unlock()
+		select {
+		case <-ch:
+		case <-r.Context().Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+		m.Lock()
+	}
+	defer m.Unlock()
+
+	var keys []string
+	for k := range m.kvs {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(m.index, 10))
+	if len(keys) == 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if _, ok := q["keys"]; ok {
+		json.NewEncoder(w).Encode(keys)
+		return
+	}
+
+	var pairs api.KVPairs
+	for _, k := range keys {
+		pairs = append(pairs, &api.KVPair{Key: k, Value: m.kvs[k], ModifyIndex: m.index})
+	}
+	json.NewEncoder(w).Encode(pairs)
+}
+
+func TestConsulSourceRead(t *testing.T) {
+	m, addr := newMockKV(t)
+	m.put("micro/config/database/host", "db")
+	m.put("micro/config/database/port", "3306")
+	m.put("other/key", "ignored")
+
+	conf, err := config.NewConfig(config.WithSource(NewSource(WithAddress(addr))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conf.Close()
+
+	v, err := conf.Get("database", "host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.String(""); got != "db" {
+		t.Fatalf("database.host = %q, want db", got)
+	}
+	v, err = conf.Get("database", "port")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.Int(0); got != 3306 {
+		t.Fatalf("database.port = %d, want 3306", got)
+	}
+	v, err = conf.Get("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.String("") != "" {
+		t.Fatalf("read key outside the prefix: %s", v.Bytes())
+	}
+}
+
+func TestConsulSourceWatch(t *testing.T) {
+	m, addr := newMockKV(t)
+	m.put("micro/config/database/port", "3306")
+
+	s := NewSource(WithAddress(addr))
+	w, err := s.Watch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	ch := make(chan *source.ChangeSet, 1)
+	go func() {
+		cs, err := w.Next()
+		if err != nil {
+			t.Error(err)
+		}
+		ch <- cs
+	}()
+
+	// give the watcher time to establish its index
+	time.Sleep(100 * time.Millisecond)
+	m.put("micro/config/database/port", "5432")
+
+	select {
+	case cs := <-ch:
+		if cs == nil || !strings.Contains(string(cs.Data), "5432") {
+			t.Fatalf("unexpected change set: %v", cs)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for change")
+	}
+
+	w.Stop()
+	if _, err := w.Next(); err != source.ErrWatcherStopped {
+		t.Fatalf("Next after Stop = %v, want ErrWatcherStopped", err)
+	}
+}
+
+func TestConsulSourceWrite(t *testing.T) {
+	m, addr := newMockKV(t)
+	m.put("micro/config/stale", "x")
+
+	s := NewSource(WithAddress(addr))
+	if err := s.Write(&source.ChangeSet{Data: []byte(`{"database":{"host":"db","port":3306}}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if _, ok := m.kvs["micro/config/stale"]; ok {
+		t.Fatal("stale key was not deleted")
+	}
+	if got := string(m.kvs["micro/config/database/host"]); got != "db" {
+		t.Fatalf("database/host = %q, want db", got)
+	}
+	if got := string(m.kvs["micro/config/database/port"]); got != "3306" {
+		t.Fatalf("database/port = %q, want 3306", got)
+	}
+}
+
+func TestConsulSourceWriteKey(t *testing.T) {
+	m, addr := newMockKV(t)
+	m.put("micro/config/database/host", "db")
+	m.put("micro/config/database/primary/port", "3306")
+	m.put("micro/config/database/primary/user", "root")
+	m.put("micro/config/feature", "on")
+
+	s := NewSource(WithAddress(addr))
+	if err := s.(source.KeyWriter).WriteKey([]string{"database", "primary"}, map[string]interface{}{"port": 5432}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.(source.KeyWriter).WriteKey([]string{"feature", "beta"}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	want := map[string]string{
+		"micro/config/database/host":         "db",
+		"micro/config/database/primary/port": "5432",
+		"micro/config/feature/beta":          "true",
+	}
+	if len(m.kvs) != len(want) {
+		t.Fatalf("keys after WriteKey: %q", m.kvs)
+	}
+	for k, v := range want {
+		if got := string(m.kvs[k]); got != v {
+			t.Fatalf("%s = %q, want %q", k, got, v)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package consul
+
+import (
+	"context"
+
+	"go-micro.dev/v6/config/source"
+)
+
+type (
+	addressKey     struct{}
+	prefixKey      struct{}
+	stripPrefixKey struct{}
+	datacenterKey  struct{}
+	tokenKey       struct{}
+)
+
+func setOption(k, v interface{}) source.Option {
+	return func(o *source.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, k, v)
+	}
+}
+
+// WithAddress sets the consul agent address.
+func WithAddress(a string) source.Option {
+	return setOption(addressKey{}, a)
+}
+
+// WithPrefix sets the key prefix config is read from.
+func WithPrefix(p string) source.Option {
+	return setOption(prefixKey{}, p)
+}
+
+// StripPrefix controls whether the prefix is removed from the config
+// paths. Defaults to true, so "micro/config/database/port" is read as
+// database.port.
+func StripPrefix(strip bool) source.Option {
+	return setOption(stripPrefixKey{}, strip)
+}
+
+// WithDatacenter sets the consul datacenter.
+func WithDatacenter(dc string) source.Option {
+	return setOption(datacenterKey{}, dc)
+}
+
+// WithToken sets the consul ACL token.
+func WithToken(t string) source.Option {
+	return setOption(tokenKey{}, t)
+}
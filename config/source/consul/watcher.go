@@ -0,0 +1,73 @@
+package consul
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"go-micro.dev/v6/config/source"
+)
+
+type watcher struct {
+	source  *consul
+	index   uint64
+	started bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func newWatcher(c *consul) *watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &watcher{
+		source: c,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Next blocks until a key under the prefix changes and returns the whole
+// config at that index.
+func (w *watcher) Next() (*source.ChangeSet, error) {
+	for {
+		q := (&api.QueryOptions{WaitIndex: w.index}).WithContext(w.ctx)
+
+		pairs, meta, err := w.source.client.KV().List(w.source.prefix, q)
+		if w.ctx.Err() != nil {
+			return nil, source.ErrWatcherStopped
+		}
+		if err != nil {
+			// back off so an unreachable agent isn't hammered
+			select {
+			case <-w.ctx.Done():
+				return nil, source.ErrWatcherStopped
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		last := w.index
+		w.index = meta.LastIndex
+		// consul may move the index backwards, in which case the next
+		// query has to start over from zero
+		if w.index < last {
+			w.index = 0
+		}
+
+		// the first query only establishes the starting index
+		if !w.started {
+			w.started = true
+			continue
+		}
+		if meta.LastIndex == last {
+			continue
+		}
+
+		return w.source.changeSet(pairs)
+	}
+}
+
+func (w *watcher) Stop() error {
+	w.cancel()
+	return nil
+}
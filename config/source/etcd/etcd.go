@@ -0,0 +1,276 @@
+// Package etcd is a config source backed by etcd. Every key under the
+// prefix is a leaf of the config tree, so "/micro/config/database/port"
+// is read as database.port, and changes are pushed through an etcd watch.
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"go-micro.dev/v6/config/source"
+	"go-micro.dev/v6/config/source/internal/kv"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var (
+	// DefaultPrefix is the key prefix config is read from.
+	DefaultPrefix = "/micro/config/"
+	// DefaultTimeout bounds each etcd request.
+	DefaultTimeout = 10 * time.Second
+)
+
+const (
+	// maxTxnOps is etcd's default limit on operations in one transaction
+	// (--max-txn-ops).
+	maxTxnOps = 128
+	// maxWriteAttempts bounds the retries of a WriteKey that raced
+	// another writer.
+	maxWriteAttempts = 5
+)
+
+type etcd struct {
+	prefix      string
+	stripPrefix string
+	opts        source.Options
+	client      *clientv3.Client
+	cerr        error
+}
+
+func (c *etcd) Read() (*source.ChangeSet, error) {
+	if c.cerr != nil {
+		return nil, c.cerr
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	rsp, err := c.client.Get(ctx, c.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	return c.changeSet(rsp.Kvs)
+}
+
+func (c *etcd) changeSet(kvs []*mvccpb.KeyValue) (*source.ChangeSet, error) {
+	values := make(map[string][]byte, len(kvs))
+	for _, v := range kvs {
+		values[string(v.Key)] = v.Value
+	}
+
+	b, err := c.opts.Encoder.Encode(kv.Map(c.opts.Encoder, c.stripPrefix, values))
+	if err != nil {
+		return nil, err
+	}
+
+	cs := &source.ChangeSet{
+		Timestamp: time.Now(),
+		Source:    c.String(),
+		Data:      b,
+		Format:    c.opts.Encoder.String(),
+	}
+	cs.Checksum = cs.Sum()
+
+	return cs, nil
+}
+
+// Write replaces the config under the prefix with cs: each changed leaf is
+// put under its own key and keys no longer present are deleted. Use
+// WriteKey to change one value, since a Write of a stale document deletes
+// keys written since it was read.
+func (c *etcd) Write(cs *source.ChangeSet) error {
+	if c.cerr != nil {
+		return c.cerr
+	}
+
+	var data map[string]interface{}
+	if err := c.opts.Encoder.Decode(cs.Data, &data); err != nil {
+		return err
+	}
+
+	values, err := kv.Flatten(c.opts.Encoder, c.stripPrefix, data)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	rsp, err := c.client.Get(ctx, c.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]string, len(rsp.Kvs))
+	for _, v := range rsp.Kvs {
+		current[string(v.Key)] = string(v.Value)
+	}
+
+	var ops []clientv3.Op
+	for k, v := range values {
+		if cur, ok := current[k]; !ok || cur != string(v) {
+			ops = append(ops, clientv3.OpPut(k, string(v)))
+		}
+	}
+	for k := range current {
+		if _, ok := values[k]; !ok {
+			ops = append(ops, clientv3.OpDelete(k))
+		}
+	}
+
+	// apply in one transaction so watchers see a single revision, or in
+	// batches if the change is bigger than etcd takes in one
+	for len(ops) > 0 {
+		n := min(len(ops), maxTxnOps)
+		if _, err := c.client.Txn(ctx).Then(ops[:n]...).Commit(); err != nil {
+			return err
+		}
+		ops = ops[n:]
+	}
+	return nil
+}
+
+// WriteKey sets the value at path, replacing anything below it, in one
+// transaction that fails and is retried if a key under path changed after
+// it was read.
+func (c *etcd) WriteKey(path []string, value interface{}) error {
+	if c.cerr != nil {
+		return c.cerr
+	}
+	if len(path) == 0 {
+		return errors.New("etcd: empty key")
+	}
+
+	values, err := kv.Flatten(c.opts.Encoder, c.stripPrefix, kv.Nest(path, value))
+	if err != nil {
+		return err
+	}
+	key := kv.Key(c.stripPrefix, path)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+
+	for range maxWriteAttempts {
+		rsp, err := c.client.Get(ctx, key, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+		if err != nil {
+			return err
+		}
+
+		var ops []clientv3.Op
+		for k, v := range values {
+			ops = append(ops, clientv3.OpPut(k, string(v)))
+		}
+		for _, v := range rsp.Kvs {
+			k := string(v.Key)
+			if _, ok := values[k]; !ok && (k == key || strings.HasPrefix(k, key+"/")) {
+				ops = append(ops, clientv3.OpDelete(k))
+			}
+		}
+		for _, k := range kv.Parents(c.stripPrefix, path) {
+			ops = append(ops, clientv3.OpDelete(k))
+		}
+		if len(ops) > maxTxnOps {
+			return fmt.Errorf("etcd: setting %s takes %d operations, more than fit in one transaction", key, len(ops))
+		}
+
+		txn, err := c.client.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(key).WithPrefix(), "<", rsp.Header.Revision+1)).
+			Then(ops...).
+			Commit()
+		if err != nil {
+			return err
+		}
+		if txn.Succeeded {
+			return nil
+		}
+	}
+	return fmt.Errorf("etcd: %s kept changing while it was being set", key)
+}
+
+func (c *etcd) Watch() (source.Watcher, error) {
+	if c.cerr != nil {
+		return nil, c.cerr
+	}
+	return newWatcher(c), nil
+}
+
+func (c *etcd) String() string {
+	return "etcd"
+}
+
+// Close releases the etcd client.
+func (c *etcd) Close() error {
+	if c.client == nil {
+		return nil
+	}
+	return c.client.Close()
+}
+
+// NewSource returns an etcd config source. Connection errors are returned
+// from Read and Watch rather than here, like the other sources.
+func NewSource(opts ...source.Option) source.Source {
+	options := source.NewOptions(opts...)
+
+	var endpoints []string
+	if addrs, ok := options.Context.Value(addressKey{}).([]string); ok {
+		for _, a := range addrs {
+			addr, port, err := net.SplitHostPort(a)
+			if ae, ok := err.(*net.AddrError); ok && ae.Err == "missing port in address" {
+				endpoints = append(endpoints, net.JoinHostPort(a, "2379"))
+			} else if err == nil {
+				endpoints = append(endpoints, net.JoinHostPort(addr, port))
+			}
+		}
+	}
+	if len(endpoints) == 0 {
+		endpoints = []string{"127.0.0.1:2379"}
+	}
+
+	config := clientv3.Config{
+		Endpoints:   endpoints,
+		DialTimeout: 5 * time.Second,
+	}
+	if d, ok := options.Context.Value(dialTimeoutKey{}).(time.Duration); ok {
+		config.DialTimeout = d
+	}
+	if creds, ok := options.Context.Value(authKey{}).(*authCreds); ok {
+		config.Username = creds.Username
+		config.Password = creds.Password
+	}
+
+	prefix := DefaultPrefix
+	if p, ok := options.Context.Value(prefixKey{}).(string); ok && len(p) > 0 {
+		prefix = p
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	strip := true
+	if s, ok := options.Context.Value(stripPrefixKey{}).(bool); ok {
+		strip = s
+	}
+
+	stripPrefix := ""
+	if strip {
+		stripPrefix = prefix
+	}
+
+	client, err := clientv3.New(config)
+	if err == nil && client == nil {
+		err = errors.New("etcd: no client")
+	}
+
+	return &etcd{
+		prefix:      prefix,
+		stripPrefix: stripPrefix,
+		opts:        options,
+		client:      client,
+		cerr:        err,
+	}
+}
@@ -0,0 +1,195 @@
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/config"
+	"go-micro.dev/v6/config/source"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func testSource(t *testing.T) source.Source {
+	t.Helper()
+
+	addr := os.Getenv("ETCD_ADDRESS")
+	if addr == "" {
+		addr = "127.0.0.1:2379"
+	}
+
+	prefix := "/micro/config-test/" + t.Name() + "/"
+	s := NewSource(WithAddress(addr), WithPrefix(prefix), WithDialTimeout(2*time.Second))
+	e := s.(*etcd)
+	if e.cerr != nil {
+		t.Skip("Etcd not available, skipping test:", e.cerr)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := e.client.Delete(ctx, prefix, clientv3.WithPrefix()); err != nil {
+		t.Skip("Etcd not reachable, skipping test:", err)
+	}
+	t.Cleanup(func() {
+		e.client.Delete(context.Background(), prefix, clientv3.WithPrefix())
+		e.Close()
+	})
+
+	return s
+}
+
+func TestEtcdSourceWatch(t *testing.T) {
+	s := testSource(t)
+	e := s.(*etcd)
+
+	if _, err := e.client.Put(context.Background(), e.prefix+"database/port", "3306"); err != nil {
+		t.Fatal(err)
+	}
+
+	conf, err := config.NewConfig(config.WithSource(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conf.Close()
+
+	v, err := conf.Get("database", "port")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.Int(0); got != 3306 {
+		t.Fatalf("database.port = %d, want 3306", got)
+	}
+
+	w, err := conf.Watch("database", "port")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	if _, err := e.client.Put(context.Background(), e.prefix+"database/port", "5432"); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err = w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.Int(0); got != 5432 {
+		t.Fatalf("watched database.port = %d, want 5432", got)
+	}
+}
+
+func TestEtcdSourceWrite(t *testing.T) {
+	s := testSource(t)
+	e := s.(*etcd)
+
+	if _, err := e.client.Put(context.Background(), e.prefix+"stale", "x"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Write(&source.ChangeSet{Data: []byte(`{"database":{"host":"db"}}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	rsp, err := e.client.Get(context.Background(), e.prefix, clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rsp.Kvs) != 1 || string(rsp.Kvs[0].Key) != e.prefix+"database/host" || string(rsp.Kvs[0].Value) != "db" {
+		t.Fatalf("unexpected keys after write: %v", rsp.Kvs)
+	}
+}
+
+func TestEtcdSourceWriteKey(t *testing.T) {
+	s := testSource(t)
+	e := s.(*etcd)
+
+	for k, v := range map[string]string{
+		"database/host":         "db",
+		"database/primary/port": "3306",
+		"database/primary/user": "root",
+		"feature":               "on",
+	} {
+		if _, err := e.client.Put(context.Background(), e.prefix+k, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := e.WriteKey([]string{"database", "primary"}, map[string]interface{}{"port": 5432}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteKey([]string{"feature", "beta"}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	rsp, err := e.client.Get(context.Background(), e.prefix, clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, v := range rsp.Kvs {
+		got[strings.TrimPrefix(string(v.Key), e.prefix)] = string(v.Value)
+	}
+	want := map[string]string{"database/host": "db", "database/primary/port": "5432", "feature/beta": "true"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("keys after WriteKey = %q, want %q", got, want)
+	}
+}
+
+func TestEtcdSourceWriteKeyKeepsTypes(t *testing.T) {
+	s := testSource(t)
+	e := s.(*etcd)
+
+	if err := e.WriteKey([]string{"build"}, "123"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteKey([]string{"debug"}, "true"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.WriteKey([]string{"port"}, 8080); err != nil {
+		t.Fatal(err)
+	}
+
+	cs, err := s.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]interface{}
+	if err := e.opts.Encoder.Decode(cs.Data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"build": "123", "debug": "true", "port": float64(8080)}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("read back %#v, want %#v", got, want)
+	}
+}
+
+func TestEtcdSourceWriteKeyConcurrent(t *testing.T) {
+	s := testSource(t)
+	e := s.(*etcd)
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := e.WriteKey([]string{"flags", fmt.Sprintf("f%d", i)}, i); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	rsp, err := e.client.Get(context.Background(), e.prefix+"flags/", clientv3.WithPrefix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rsp.Kvs) != 20 {
+		t.Fatalf("%d keys after 20 concurrent sets, want 20", len(rsp.Kvs))
+	}
+}
@@ -0,0 +1,57 @@
+package etcd
+
+import (
+	"context"
+	"time"
+
+	"go-micro.dev/v6/config/source"
+)
+
+type (
+	addressKey     struct{}
+	prefixKey      struct{}
+	stripPrefixKey struct{}
+	authKey        struct{}
+	dialTimeoutKey struct{}
+)
+
+type authCreds struct {
+	Username string
+	Password string
+}
+
+func setOption(k, v interface{}) source.Option {
+	return func(o *source.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, k, v)
+	}
+}
+
+// WithAddress sets the etcd endpoints.
+func WithAddress(a ...string) source.Option {
+	return setOption(addressKey{}, a)
+}
+
+// WithPrefix sets the key prefix config is read from.
+func WithPrefix(p string) source.Option {
+	return setOption(prefixKey{}, p)
+}
+
+// StripPrefix controls whether the prefix is removed from the config
+// paths. Defaults to true, so "/micro/config/database/port" is read as
+// database.port.
+func StripPrefix(strip bool) source.Option {
+	return setOption(stripPrefixKey{}, strip)
+}
+
+// Auth sets the etcd username and password.
+func Auth(username, password string) source.Option {
+	return setOption(authKey{}, &authCreds{Username: username, Password: password})
+}
+
+// WithDialTimeout sets the dial timeout for the etcd client.
+func WithDialTimeout(d time.Duration) source.Option {
+	return setOption(dialTimeoutKey{}, d)
+}
@@ -0,0 +1,69 @@
+package etcd
+
+import (
+	"context"
+
+	"go-micro.dev/v6/config/source"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type watcher struct {
+	source *etcd
+	ch     clientv3.WatchChan
+	cancel context.CancelFunc
+	exit   chan struct{}
+}
+
+func newWatcher(e *etcd) *watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &watcher{
+		source: e,
+		ch:     e.client.Watch(ctx, e.prefix, clientv3.WithPrefix()),
+		cancel: cancel,
+		exit:   make(chan struct{}),
+	}
+}
+
+// Next blocks until a key under the prefix changes and returns the whole
+// config as of that revision.
+func (w *watcher) Next() (*source.ChangeSet, error) {
+	for {
+		select {
+		case <-w.exit:
+			return nil, source.ErrWatcherStopped
+		case rsp, ok := <-w.ch:
+			if !ok {
+				return nil, source.ErrWatcherStopped
+			}
+			if err := rsp.Err(); err != nil {
+				return nil, err
+			}
+			if len(rsp.Events) == 0 {
+				continue
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+			get, err := w.source.client.Get(ctx, w.source.prefix,
+				clientv3.WithPrefix(),
+				clientv3.WithRev(rsp.Header.Revision),
+			)
+			cancel()
+			if err != nil {
+				return nil, err
+			}
+
+			return w.source.changeSet(get.Kvs)
+		}
+	}
+}
+
+func (w *watcher) Stop() error {
+	select {
+	case <-w.exit:
+	default:
+		close(w.exit)
+		w.cancel()
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// Package kv maps between a tree of config values and the flat key/value
+// layout used by the etcd and consul sources, where each leaf lives under
+// its own key, e.g. "micro/config/database/address".
+package kv
+
+import (
+	"fmt"
+	"strings"
+
+	"go-micro.dev/v6/config/encoder"
+)
+
+// Map builds a nested map from keys under prefix. Each value is decoded
+// with e where possible, so "8080" becomes a number and `{"a":1}` a map;
+// anything else is kept as a string.
+func Map(e encoder.Encoder, prefix string, kvs map[string][]byte) map[string]interface{} {
+	data := make(map[string]interface{})
+
+	for k, v := range kvs {
+		path := split(strings.TrimPrefix(k, prefix))
+		if len(path) == 0 {
+			continue
+		}
+
+		var val interface{}
+		if err := e.Decode(v, &val); err != nil {
+			val = string(v)
+		}
+
+		// walk down, creating maps as needed; a leaf value is replaced by
+		// a map if a deeper key shares its path
+		m := data
+		for _, p := range path[:len(path)-1] {
+			next, ok := m[p].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				m[p] = next
+			}
+			m = next
+		}
+
+		leaf := path[len(path)-1]
+		if _, ok := m[leaf].(map[string]interface{}); ok {
+			if vm, ok := val.(map[string]interface{}); ok {
+				merge(m[leaf].(map[string]interface{}), vm)
+			}
+			continue
+		}
+		m[leaf] = val
+	}
+
+	return data
+}
+
+// Flatten is the inverse of Map: it returns one key per leaf under prefix.
+// Leaves are encoded with e, except strings that Map would read back as
+// the same string, which are stored raw so they stay readable in the
+// store. "8080" or "true" are encoded, so they don't come back as a number
+// or a bool.
+func Flatten(e encoder.Encoder, prefix string, data map[string]interface{}) (map[string][]byte, error) {
+	kvs := make(map[string][]byte)
+	if err := flatten(e, strings.TrimSuffix(prefix, "/"), data, kvs); err != nil {
+		return nil, err
+	}
+	return kvs, nil
+}
+
+// Key returns the key of path under prefix, as Flatten builds it.
+func Key(prefix string, path []string) string {
+	return strings.TrimSuffix(prefix, "/") + "/" + strings.Join(path, "/")
+}
+
+// Parents returns the keys of the ancestors of path under prefix. Setting
+// a value below one of them turns it into a map, so any leaf stored there
+// has to go.
+func Parents(prefix string, path []string) []string {
+	var keys []string
+	for i := 1; i < len(path); i++ {
+		keys = append(keys, Key(prefix, path[:i]))
+	}
+	return keys
+}
+
+// Nest returns a tree holding val at path.
+func Nest(path []string, val interface{}) map[string]interface{} {
+	data := map[string]interface{}{path[len(path)-1]: val}
+	for i := len(path) - 2; i >= 0; i-- {
+		data = map[string]interface{}{path[i]: data}
+	}
+	return data
+}
+
+func flatten(e encoder.Encoder, prefix string, data map[string]interface{}, kvs map[string][]byte) error {
+	for k, v := range data {
+		key := prefix + "/" + k
+
+		switch val := v.(type) {
+		case map[string]interface{}:
+			if err := flatten(e, key, val, kvs); err != nil {
+				return err
+			}
+		case string:
+			if raw(e, val) {
+				kvs[key] = []byte(val)
+				break
+			}
+			b, err := e.Encode(val)
+			if err != nil {
+				return fmt.Errorf("encode %s: %w", key, err)
+			}
+			kvs[key] = b
+		default:
+			b, err := e.Encode(val)
+			if err != nil {
+				return fmt.Errorf("encode %s: %w", key, err)
+			}
+			kvs[key] = b
+		}
+	}
+	return nil
+}
+
+// raw reports whether s can be stored unencoded: Map decodes it to itself
+// or keeps it as a string.
+func raw(e encoder.Encoder, s string) bool {
+	var v interface{}
+	if err := e.Decode([]byte(s), &v); err != nil {
+		return true
+	}
+	d, ok := v.(string)
+	return ok && d == s
+}
+
+func split(key string) []string {
+	var path []string
+	for _, p := range strings.Split(key, "/") {
+		if len(p) > 0 {
+			path = append(path, p)
+		}
+	}
+	return path
+}
+
+func merge(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if _, ok := dst[k]; !ok {
+			dst[k] = v
+		}
+	}
+}
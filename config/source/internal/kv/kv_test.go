@@ -0,0 +1,85 @@
+package kv
+
+import (
+	"reflect"
+	"testing"
+
+	"go-micro.dev/v6/config/encoder/json"
+)
+
+func TestMapFlattenRoundTrip(t *testing.T) {
+	e := json.NewEncoder()
+	kvs := map[string][]byte{
+		"/micro/config/database/address": []byte("10.0.0.1"),
+		"/micro/config/database/port":    []byte("3306"),
+		"/micro/config/features":         []byte(`{"beta":true}`),
+		"/micro/config/":                 []byte("ignored"),
+	}
+
+	data := Map(e, "/micro/config", kvs)
+	want := map[string]interface{}{
+		"database": map[string]interface{}{
+			"address": "10.0.0.1",
+			"port":    float64(3306),
+		},
+		"features": map[string]interface{}{"beta": true},
+	}
+	if !reflect.DeepEqual(data, want) {
+		t.Fatalf("Map = %#v, want %#v", data, want)
+	}
+
+	flat, err := Flatten(e, "/micro/config/", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(flat["/micro/config/database/address"]) != "10.0.0.1" {
+		t.Fatalf("string leaf stored as %q", flat["/micro/config/database/address"])
+	}
+	if string(flat["/micro/config/features/beta"]) != "true" {
+		t.Fatalf("nested leaf stored as %q", flat["/micro/config/features/beta"])
+	}
+	if !reflect.DeepEqual(Map(e, "/micro/config", flat), want) {
+		t.Fatalf("round trip mismatch: %#v", Map(e, "/micro/config", flat))
+	}
+}
+
+func TestFlattenKeepsStringTypes(t *testing.T) {
+	e := json.NewEncoder()
+	data := map[string]interface{}{
+		"port":    "8080",
+		"enabled": "true",
+		"quoted":  `"x"`,
+		"nothing": "null",
+		"object":  `{"a":1}`,
+		"host":    "localhost",
+	}
+
+	flat, err := Flatten(e, "/micro/config", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(flat["/micro/config/host"]) != "localhost" {
+		t.Fatalf("plain string stored as %q", flat["/micro/config/host"])
+	}
+	if got := Map(e, "/micro/config", flat); !reflect.DeepEqual(got, data) {
+		t.Fatalf("round trip = %#v, want %#v", got, data)
+	}
+}
+
+func TestKeyParentsNest(t *testing.T) {
+	path := []string{"database", "primary", "port"}
+	if got := Key("/micro/config/", path); got != "/micro/config/database/primary/port" {
+		t.Fatalf("Key = %q", got)
+	}
+	want := []string{"/micro/config/database", "/micro/config/database/primary"}
+	if got := Parents("/micro/config/", path); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Parents = %q, want %q", got, want)
+	}
+	flat, err := Flatten(json.NewEncoder(), "/micro/config/", Nest(path, 5432))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flat) != 1 || string(flat[Key("/micro/config/", path)]) != "5432" {
+		t.Fatalf("Flatten(Nest) = %q", flat)
+	}
+}
@@ -19,6 +19,14 @@ type Source interface {
 	String() string
 }
 
+// KeyWriter is implemented by sources that keep each value under its own
+// key, such as etcd and consul. WriteKey sets the value at path, replacing
+// anything below it, without rewriting the rest of the config, so writers
+// of different keys don't overwrite each other.
+type KeyWriter interface {
+	WriteKey(path []string, value interface{}) error
+}
+
 // ChangeSet represents a set of changes from a source.
 type ChangeSet struct {
 	Timestamp time.Time
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"go-micro.dev/v6/config/source"
+	"go-micro.dev/v6/store"
+)
+
+type (
+	storeKey    struct{}
+	keyKey      struct{}
+	intervalKey struct{}
+)
+
+func setOption(k, v interface{}) source.Option {
+	return func(o *source.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, k, v)
+	}
+}
+
+// WithStore sets the store config is read from. Defaults to
+// store.DefaultStore.
+func WithStore(s store.Store) source.Option {
+	return setOption(storeKey{}, s)
+}
+
+// WithKey sets the key the config document is stored under.
+func WithKey(k string) source.Option {
+	return setOption(keyKey{}, k)
+}
+
+// WithInterval sets how often the store is polled for changes.
+func WithInterval(d time.Duration) source.Option {
+	return setOption(intervalKey{}, d)
+}
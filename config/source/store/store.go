@@ -0,0 +1,113 @@
+// Package store is a config source that reads a single config document
+// from any store.Store. Stores have no change notifications, so Watch
+// polls the key and reports when its contents change.
+package store
+
+import (
+	"sync"
+	"time"
+
+	"go-micro.dev/v6/config/source"
+	"go-micro.dev/v6/store"
+)
+
+var (
+	// DefaultKey is the store key the config document is read from.
+	DefaultKey = "micro/config"
+	// DefaultInterval is how often Watch polls the store.
+	DefaultInterval = 5 * time.Second
+)
+
+type storeSource struct {
+	store    store.Store
+	key      string
+	interval time.Duration
+	opts     source.Options
+
+	sync.Mutex
+	// checksum of the last read, so a watch started after a Read
+	// reports changes made in between
+	checksum string
+}
+
+func (s *storeSource) Read() (*source.ChangeSet, error) {
+	data := []byte("{}")
+
+	recs, err := s.store.Read(s.key)
+	switch {
+	case err == store.ErrNotFound:
+		// no config yet, start from an empty document
+	case err != nil:
+		return nil, err
+	case len(recs) > 0:
+		data = recs[0].Value
+	}
+
+	cs := &source.ChangeSet{
+		Timestamp: time.Now(),
+		Source:    s.String(),
+		Data:      data,
+		Format:    s.opts.Encoder.String(),
+	}
+	cs.Checksum = cs.Sum()
+
+	s.Lock()
+	s.checksum = cs.Checksum
+	s.Unlock()
+
+	return cs, nil
+}
+
+func (s *storeSource) Write(cs *source.ChangeSet) error {
+	return s.store.Write(&store.Record{
+		Key:   s.key,
+		Value: cs.Data,
+	})
+}
+
+func (s *storeSource) Watch() (source.Watcher, error) {
+	s.Lock()
+	checksum := s.checksum
+	s.Unlock()
+
+	if len(checksum) == 0 {
+		cs, err := s.Read()
+		if err != nil {
+			return nil, err
+		}
+		checksum = cs.Checksum
+	}
+
+	return newWatcher(s, checksum), nil
+}
+
+func (s *storeSource) String() string {
+	return "store"
+}
+
+// NewSource returns a config source backed by a store.
+func NewSource(opts ...source.Option) source.Source {
+	options := source.NewOptions(opts...)
+
+	st := store.DefaultStore
+	if v, ok := options.Context.Value(storeKey{}).(store.Store); ok && v != nil {
+		st = v
+	}
+
+	key := DefaultKey
+	if v, ok := options.Context.Value(keyKey{}).(string); ok && len(v) > 0 {
+		key = v
+	}
+
+	interval := DefaultInterval
+	if v, ok := options.Context.Value(intervalKey{}).(time.Duration); ok && v > 0 {
+		interval = v
+	}
+
+	return &storeSource{
+		store:    st,
+		key:      key,
+		interval: interval,
+		opts:     options,
+	}
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/config"
+	"go-micro.dev/v6/config/source"
+	"go-micro.dev/v6/store"
+)
+
+func TestStoreSource(t *testing.T) {
+	st := store.NewMemoryStore()
+	if err := st.Write(&store.Record{Key: DefaultKey, Value: []byte(`{"database":{"port":3306}}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSource(WithStore(st), WithInterval(10*time.Millisecond))
+	conf, err := config.NewConfig(config.WithSource(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conf.Close()
+
+	v, err := conf.Get("database", "port")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.Int(0); got != 3306 {
+		t.Fatalf("database.port = %d, want 3306", got)
+	}
+
+	w, err := conf.Watch("database", "port")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	if err := s.Write(&source.ChangeSet{Data: []byte(`{"database":{"port":5432}}`)}); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err = w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := v.Int(0); got != 5432 {
+		t.Fatalf("watched database.port = %d, want 5432", got)
+	}
+}
+
+func TestStoreSourceMissingKey(t *testing.T) {
+	s := NewSource(WithStore(store.NewMemoryStore()), WithKey("missing"))
+
+	cs, err := s.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(cs.Data) != "{}" {
+		t.Fatalf("Read of missing key = %s, want {}", cs.Data)
+	}
+}
@@ -0,0 +1,55 @@
+package store
+
+import (
+	"time"
+
+	"go-micro.dev/v6/config/source"
+)
+
+type watcher struct {
+	source   *storeSource
+	checksum string
+	exit     chan struct{}
+}
+
+func newWatcher(s *storeSource, checksum string) *watcher {
+	return &watcher{
+		source:   s,
+		checksum: checksum,
+		exit:     make(chan struct{}),
+	}
+}
+
+// Next polls the store until the config document changes.
+func (w *watcher) Next() (*source.ChangeSet, error) {
+	t := time.NewTicker(w.source.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-w.exit:
+			return nil, source.ErrWatcherStopped
+		case <-t.C:
+		}
+
+		cs, err := w.source.Read()
+		if err != nil {
+			return nil, err
+		}
+		if cs.Checksum == w.checksum {
+			continue
+		}
+
+		w.checksum = cs.Checksum
+		return cs, nil
+	}
+}
+
+func (w *watcher) Stop() error {
+	select {
+	case <-w.exit:
+	default:
+		close(w.exit)
+	}
+	return nil
+}
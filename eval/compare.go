@@ -0,0 +1,83 @@
+package eval
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Comparison is how a result moved against a baseline. Deltas are the
+// result's value minus the baseline's.
+type Comparison struct {
+	Baseline string `json:"baseline"`
+	Result   string `json:"result"`
+
+	PassRateDelta   float64       `json:"pass_rate_delta"`
+	TokensDelta     int           `json:"tokens_delta"`
+	SpentDelta      int64         `json:"spent_delta"`
+	P50LatencyDelta time.Duration `json:"p50_latency_delta"`
+	P95LatencyDelta time.Duration `json:"p95_latency_delta"`
+
+	// Regressions are cases that passed in the baseline and fail now.
+	Regressions []string `json:"regressions,omitempty"`
+	// Fixes are cases that failed in the baseline and pass now.
+	Fixes []string `json:"fixes,omitempty"`
+	// Added are cases that aren't in the baseline.
+	Added []string `json:"added,omitempty"`
+}
+
+// Compare compares res with baseline.
+func Compare(baseline, res *Result) *Comparison {
+	b, r := baseline.Summary, res.Summary
+	c := &Comparison{
+		Baseline:        baseline.ID,
+		Result:          res.ID,
+		PassRateDelta:   r.PassRate - b.PassRate,
+		TokensDelta:     r.Tokens - b.Tokens,
+		SpentDelta:      r.Spent - b.Spent,
+		P50LatencyDelta: r.P50Latency - b.P50Latency,
+		P95LatencyDelta: r.P95Latency - b.P95Latency,
+	}
+	before := map[string]bool{}
+	for _, cr := range baseline.Cases {
+		before[cr.Case] = cr.Pass
+	}
+	for _, cr := range res.Cases {
+		passed, ok := before[cr.Case]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, cr.Case)
+		case passed && !cr.Pass:
+			c.Regressions = append(c.Regressions, cr.Case)
+		case !passed && cr.Pass:
+			c.Fixes = append(c.Fixes, cr.Case)
+		}
+	}
+	return c
+}
+
+// Regressed reports whether any case regressed or the pass rate fell.
+func (c *Comparison) Regressed() bool {
+	return len(c.Regressions) > 0 || c.PassRateDelta < 0
+}
+
+func (c *Comparison) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "pass rate %+.1f%%, tokens %+d, spent %+d, p50 %s, p95 %s",
+		c.PassRateDelta*100, c.TokensDelta, c.SpentDelta, signed(c.P50LatencyDelta), signed(c.P95LatencyDelta))
+	if len(c.Regressions) > 0 {
+		fmt.Fprintf(&b, "; regressed: %s", strings.Join(c.Regressions, ", "))
+	}
+	if len(c.Fixes) > 0 {
+		fmt.Fprintf(&b, "; fixed: %s", strings.Join(c.Fixes, ", "))
+	}
+	return b.String()
+}
+
+func signed(d time.Duration) string {
+	d = d.Round(time.Millisecond)
+	if d >= 0 {
+		return "+" + d.String()
+	}
+	return d.String()
+}
@@ -0,0 +1,282 @@
+// Package eval runs agents against datasets of prompts and grades what they
+// do, so a change to a prompt, model or tool can be measured before it ships.
+//
+// A Suite is a list of Cases, read from JSONL with one case per line. Each
+// case has an input and the outcomes it expects: the tool calls the agent
+// should make, fields of a JSON reply, or a rubric for an LLM judge. Run
+// sends every case to a Target, an in-process agent or one reached over RPC,
+// grades the output and returns a Result with the pass rate, token use,
+// spend and latency. Results are saved to a store.Store, and Compare reports
+// how a result moved against the suite's baseline.
+//
+// Example:
+//
+//	suite, err := eval.LoadSuite("support.jsonl")
+//	if err != nil {
+//		return err
+//	}
+//	res, err := eval.Run(ctx, suite, eval.AgentTarget(ag), eval.Store(store.DefaultStore))
+//	if err != nil {
+//		return err
+//	}
+//	base, ok, err := eval.Baseline(store.DefaultStore, suite.Name)
+//	if err == nil && ok {
+//		fmt.Println(eval.Compare(base, res))
+//	}
+package eval
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"go-micro.dev/v6/ai"
+)
+
+// Case is one prompt of a suite and the outcome it expects. A case without
+// expectations passes when the target answers without an error.
+type Case struct {
+	// ID names the case in results. It defaults to the case's line number.
+	ID string `json:"id"`
+	// Input is the message sent to the agent.
+	Input string `json:"input"`
+	// Tools is the tool-call sequence the agent should make, graded by
+	// ToolCalls.
+	Tools []ExpectedCall `json:"tools,omitempty"`
+	// ToolMatch is how Tools is matched: "exact" (the default), "ordered"
+	// or "unordered". See ToolCalls.
+	ToolMatch string `json:"tool_match,omitempty"`
+	// Expect asserts fields of the JSON object in the reply, by dotted
+	// path, graded by JSONFields.
+	Expect map[string]any `json:"expect,omitempty"`
+	// Rubric is what an LLM judge grades the reply against, with LLMGrader.
+	Rubric string `json:"rubric,omitempty"`
+	// Tags group cases in a suite.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// ExpectedCall is a tool call a case expects. Input, when set, must be a
+// subset of the call's input. In JSON it is an object or just the tool name.
+type ExpectedCall struct {
+	Name  string         `json:"name"`
+	Input map[string]any `json:"input,omitempty"`
+}
+
+func (e *ExpectedCall) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		*e = ExpectedCall{Name: name}
+		return nil
+	}
+	type call ExpectedCall
+	return json.Unmarshal(b, (*call)(e))
+}
+
+// Suite is a named list of cases.
+type Suite struct {
+	Name  string
+	Cases []Case
+}
+
+// LoadSuite reads a JSONL suite from path. The suite is named after the
+// file, without its extension.
+func LoadSuite(path string) (*Suite, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return ReadSuite(name, f)
+}
+
+// ReadSuite reads a JSONL suite, one case per line. Blank lines are skipped.
+func ReadSuite(name string, r io.Reader) (*Suite, error) {
+	s := &Suite{Name: name}
+	ids := map[string]bool{}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		var c Case
+		if err := json.Unmarshal(b, &c); err != nil {
+			return nil, fmt.Errorf("eval: suite %s line %d: %w", name, line, err)
+		}
+		if c.Input == "" {
+			return nil, fmt.Errorf("eval: suite %s line %d: case has no input", name, line)
+		}
+		if c.ID == "" {
+			c.ID = fmt.Sprint(line)
+		}
+		if ids[c.ID] {
+			return nil, fmt.Errorf("eval: suite %s line %d: duplicate case id %q", name, line, c.ID)
+		}
+		ids[c.ID] = true
+		s.Cases = append(s.Cases, c)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Output is what a target did for one case.
+type Output struct {
+	Reply     string        `json:"reply"`
+	ToolCalls []ai.ToolCall `json:"tool_calls,omitempty"`
+	// RunID is the agent run that answered, when the target knows it.
+	RunID string `json:"run_id,omitempty"`
+	// Tokens and Spent are the run's model token use and x402 spend, when
+	// the target can read them.
+	Tokens ai.Usage `json:"tokens"`
+	Spent  int64    `json:"spent,omitempty"`
+}
+
+// Target answers one case's input.
+type Target func(ctx context.Context, input string) (*Output, error)
+
+// Result is one run of a suite.
+type Result struct {
+	ID    string `json:"id"`
+	Suite string `json:"suite"`
+	// Label names what was evaluated, such as a version or a prompt change.
+	Label   string        `json:"label,omitempty"`
+	Agent   string        `json:"agent,omitempty"`
+	Started time.Time     `json:"started"`
+	Elapsed time.Duration `json:"elapsed"`
+	Cases   []CaseResult  `json:"cases"`
+	Summary Summary       `json:"summary"`
+}
+
+// CaseResult is how one case went.
+type CaseResult struct {
+	Case    string        `json:"case"`
+	Pass    bool          `json:"pass"`
+	Error   string        `json:"error,omitempty"`
+	Grades  []Grade       `json:"grades,omitempty"`
+	Output  *Output       `json:"output,omitempty"`
+	Latency time.Duration `json:"latency"`
+}
+
+// Summary aggregates a result's cases.
+type Summary struct {
+	Cases    int     `json:"cases"`
+	Passed   int     `json:"passed"`
+	Errors   int     `json:"errors"`
+	PassRate float64 `json:"pass_rate"`
+	// Tokens is the total token use across cases.
+	Tokens int   `json:"tokens"`
+	Spent  int64 `json:"spent,omitempty"`
+	// P50Latency and P95Latency are over the cases' latencies.
+	P50Latency time.Duration `json:"p50_latency"`
+	P95Latency time.Duration `json:"p95_latency"`
+}
+
+// Run sends each case of suite to target in order, grades its output and
+// returns the result. A case fails when the target errors or any grader
+// that applies to it fails; a grader error is recorded as a failed grade.
+// Run only returns an error when ctx is done or the result can't be saved.
+func Run(ctx context.Context, suite *Suite, target Target, opts ...Option) (*Result, error) {
+	o := newOptions(opts...)
+	res := &Result{
+		ID:      uuid.New().String(),
+		Suite:   suite.Name,
+		Label:   o.Label,
+		Agent:   o.Agent,
+		Started: time.Now(),
+	}
+	for _, c := range suite.Cases {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		res.Cases = append(res.Cases, runCase(ctx, c, target, o.Graders))
+	}
+	res.Elapsed = time.Since(res.Started)
+	res.Summary = summarize(res.Cases)
+	if o.Store != nil {
+		if err := Save(o.Store, res); err != nil {
+			return res, err
+		}
+	}
+	return res, nil
+}
+
+func runCase(ctx context.Context, c Case, target Target, graders []Grader) CaseResult {
+	cr := CaseResult{Case: c.ID}
+	start := time.Now()
+	out, err := target(ctx, c.Input)
+	cr.Latency = time.Since(start)
+	if err == nil && out == nil {
+		err = errors.New("target returned no output")
+	}
+	if err != nil {
+		cr.Error = err.Error()
+		return cr
+	}
+	cr.Output = out
+	cr.Pass = true
+	for _, g := range graders {
+		grade, err := g(ctx, c, out)
+		if err != nil {
+			grade.Pass = false
+			grade.Feedback = err.Error()
+		}
+		if grade.Skipped {
+			continue
+		}
+		cr.Grades = append(cr.Grades, grade)
+		cr.Pass = cr.Pass && grade.Pass
+	}
+	return cr
+}
+
+func summarize(cases []CaseResult) Summary {
+	s := Summary{Cases: len(cases)}
+	latencies := make([]time.Duration, 0, len(cases))
+	for _, c := range cases {
+		if c.Pass {
+			s.Passed++
+		}
+		if c.Error != "" {
+			s.Errors++
+		}
+		if c.Output != nil {
+			s.Tokens += c.Output.Tokens.TotalTokens
+			s.Spent += c.Output.Spent
+		}
+		latencies = append(latencies, c.Latency)
+	}
+	if s.Cases > 0 {
+		s.PassRate = float64(s.Passed) / float64(s.Cases)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	s.P50Latency = percentile(latencies, 50)
+	s.P95Latency = percentile(latencies, 95)
+	return s
+}
+
+// percentile returns the nearest-rank percentile of sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := (len(sorted)*p + 99) / 100
+	if i < 1 {
+		i = 1
+	}
+	return sorted[i-1]
+}
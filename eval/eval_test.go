@@ -0,0 +1,198 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go-micro.dev/v6/agent"
+	"go-micro.dev/v6/ai"
+	"go-micro.dev/v6/registry"
+	"go-micro.dev/v6/store"
+)
+
+const suiteJSONL = `{"id":"refund","input":"refund order 42","tools":["orders_Get",{"name":"payments_Refund","input":{"order":42}}],"expect":{"status":"refunded"}}
+
+{"id":"greet","input":"hello"}
+{"id":"broken","input":"fail please"}
+`
+
+// replies answers each input from a table, failing inputs it doesn't know.
+func replies(outs map[string]*Output) Target {
+	return func(_ context.Context, input string) (*Output, error) {
+		out, ok := outs[input]
+		if !ok {
+			return nil, errors.New("provider unavailable")
+		}
+		return out, nil
+	}
+}
+
+func TestReadSuite(t *testing.T) {
+	s, err := ReadSuite("support", strings.NewReader(suiteJSONL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Cases) != 3 || s.Cases[0].ID != "refund" {
+		t.Fatalf("cases = %+v", s.Cases)
+	}
+	tools := s.Cases[0].Tools
+	if tools[0].Name != "orders_Get" || tools[1].Name != "payments_Refund" || tools[1].Input["order"] != float64(42) {
+		t.Fatalf("tools = %+v", tools)
+	}
+
+	if _, err := ReadSuite("bad", strings.NewReader(`{"id":"a","input":"x"}`+"\n"+`{"id":"a","input":"y"}`)); err == nil {
+		t.Fatal("duplicate case ids should fail")
+	}
+	if _, err := ReadSuite("bad", strings.NewReader(`{"id":"a"}`)); err == nil {
+		t.Fatal("a case without input should fail")
+	}
+}
+
+func TestRunGradesAndComparesWithBaseline(t *testing.T) {
+	s, err := ReadSuite("support", strings.NewReader(suiteJSONL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := store.NewMemoryStore()
+	refund := &Output{
+		Reply: "Done:\n```json\n{\"status\": \"refunded\"}\n```",
+		ToolCalls: []ai.ToolCall{
+			{Name: "orders_Get"},
+			{Name: "payments_Refund", Input: map[string]any{"order": 42, "reason": "damaged"}},
+		},
+		Tokens: ai.Usage{TotalTokens: 100},
+	}
+	base, err := Run(context.Background(), s, replies(map[string]*Output{
+		"refund order 42": refund,
+		"hello":           {Reply: "hi", Tokens: ai.Usage{TotalTokens: 10}},
+	}), Label("v1"), Store(st))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base.Summary.Passed != 2 || base.Summary.Errors != 1 || base.Summary.Tokens != 110 {
+		t.Fatalf("summary = %+v", base.Summary)
+	}
+	if got := base.Cases[0].Grades; len(got) != 2 || !got[0].Pass || !got[1].Pass {
+		t.Fatalf("refund grades = %+v", got)
+	}
+	if base.Cases[2].Error != "provider unavailable" || base.Cases[2].Pass {
+		t.Fatalf("broken case = %+v", base.Cases[2])
+	}
+	if err := SetBaseline(st, "support", base.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// v2 skips the lookup and gets the greeting's failure fixed
+	res, err := Run(context.Background(), s, replies(map[string]*Output{
+		"refund order 42": {Reply: `{"status":"refunded"}`, ToolCalls: refund.ToolCalls[1:], Tokens: ai.Usage{TotalTokens: 60}},
+		"hello":           {Reply: "hi", Tokens: ai.Usage{TotalTokens: 10}},
+		"fail please":     {Reply: "ok", Tokens: ai.Usage{TotalTokens: 5}},
+	}), Label("v2"), Store(st))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := res.Cases[0].Grades[0]; g.Pass || !strings.Contains(g.Feedback, "[orders_Get, payments_Refund]") {
+		t.Fatalf("tool grade = %+v", g)
+	}
+
+	saved, ok, err := Baseline(st, "support")
+	if err != nil || !ok || saved.ID != base.ID || saved.Label != "v1" {
+		t.Fatalf("baseline = %+v, %v, %v", saved, ok, err)
+	}
+	c := Compare(saved, res)
+	if c.PassRateDelta != 0 || c.TokensDelta != -35 {
+		t.Fatalf("comparison = %+v", c)
+	}
+	if len(c.Regressions) != 1 || c.Regressions[0] != "refund" || len(c.Fixes) != 1 || c.Fixes[0] != "broken" {
+		t.Fatalf("regressions = %v, fixes = %v", c.Regressions, c.Fixes)
+	}
+	if !c.Regressed() {
+		t.Fatal("a regressed case should count as a regression")
+	}
+
+	all, err := List(st, "support")
+	if err != nil || len(all) != 2 || all[0].Label != "v1" || all[1].Label != "v2" {
+		t.Fatalf("list = %v, %v", all, err)
+	}
+}
+
+func TestSetBaselineRequiresResult(t *testing.T) {
+	if err := SetBaseline(store.NewMemoryStore(), "support", "missing"); err == nil {
+		t.Fatal("an unknown result should not become the baseline")
+	}
+	if _, ok, err := Baseline(store.NewMemoryStore(), "support"); ok || err != nil {
+		t.Fatalf("baseline = %v, %v, want none", ok, err)
+	}
+}
+
+// evalModel answers by calling the agent's lookup tool and replying with JSON.
+type evalModel struct{ opts ai.Options }
+
+func (m *evalModel) Init(opts ...ai.Option) error {
+	for _, o := range opts {
+		o(&m.opts)
+	}
+	return nil
+}
+func (m *evalModel) Options() ai.Options { return m.opts }
+func (m *evalModel) String() string      { return "evalmodel" }
+func (m *evalModel) Stream(context.Context, *ai.Request, ...ai.GenerateOption) (ai.Stream, error) {
+	return nil, ai.ErrStreamingUnsupported
+}
+func (m *evalModel) Generate(ctx context.Context, req *ai.Request, _ ...ai.GenerateOption) (*ai.Response, error) {
+	call := ai.ToolCall{ID: "1", Name: "lookup", Input: map[string]any{"id": "7"}}
+	res := m.opts.ToolHandler(ctx, call)
+	call.Result = res.Content
+	return &ai.Response{
+		ToolCalls: []ai.ToolCall{call},
+		Answer:    `{"messages": ` + strconv.Itoa(len(req.Messages)) + `}`,
+		Usage:     ai.Usage{TotalTokens: 12},
+	}, nil
+}
+
+func TestAgentTarget(t *testing.T) {
+	ai.Register("evalmodel", func(opts ...ai.Option) ai.Model {
+		m := &evalModel{}
+		_ = m.Init(opts...)
+		return m
+	})
+	ag := agent.New(
+		agent.Name("evaluated"),
+		agent.Provider("evalmodel"),
+		agent.WithRegistry(registry.NewMemoryRegistry()),
+		agent.WithStore(store.NewMemoryStore()),
+		agent.WithTool("lookup", "Look up a record", map[string]any{"id": map[string]any{"type": "string"}},
+			func(ctx context.Context, input map[string]any) (string, error) { return "found", nil }),
+	)
+	// A conversation already under way must neither leak into the cases
+	// nor pick up their turns.
+	if _, err := ag.Ask(context.Background(), "earlier"); err != nil {
+		t.Fatal(err)
+	}
+	s := &Suite{Name: "agent", Cases: []Case{
+		{ID: "a", Input: "first", Tools: []ExpectedCall{{Name: "lookup"}}, Expect: map[string]any{"messages": 1}},
+		{ID: "b", Input: "second", Expect: map[string]any{"messages": 1}},
+	}}
+	res, err := Run(context.Background(), s, AgentTarget(ag))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range res.Cases {
+		if !c.Pass {
+			t.Fatalf("case %s failed: %+v", c.Case, c)
+		}
+		if c.Output.RunID == "" || c.Output.Tokens.TotalTokens != 12 {
+			t.Fatalf("case %s output = %+v", c.Case, c.Output)
+		}
+	}
+	resp, err := ag.Ask(context.Background(), "later")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Reply != `{"messages": 3}` {
+		t.Fatalf("default conversation answer = %s, want 3 messages: the earlier turn and this one", resp.Reply)
+	}
+}
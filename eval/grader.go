@@ -0,0 +1,252 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go-micro.dev/v6/ai"
+)
+
+// Grade is one grader's verdict on a case.
+type Grade struct {
+	Grader   string `json:"grader"`
+	Pass     bool   `json:"pass"`
+	Feedback string `json:"feedback,omitempty"`
+	// Skipped is set when the case has nothing for the grader to check.
+	// Skipped grades aren't recorded.
+	Skipped bool `json:"-"`
+}
+
+// Grader grades a case's output. It returns a skipped grade for a case
+// that doesn't set what it checks.
+type Grader func(ctx context.Context, c Case, out *Output) (Grade, error)
+
+// DefaultGraders returns the deterministic graders: ToolCalls and
+// JSONFields.
+func DefaultGraders() []Grader {
+	return []Grader{ToolCalls(), JSONFields()}
+}
+
+// ToolCalls grades the agent's tool calls against the case's Tools. With
+// ToolMatch "exact" the calls must be the expected ones in order and
+// nothing else; "ordered" allows other calls in between; "unordered" only
+// requires each expected call to be made.
+func ToolCalls() Grader {
+	return func(_ context.Context, c Case, out *Output) (Grade, error) {
+		g := Grade{Grader: "tool_calls"}
+		if len(c.Tools) == 0 {
+			g.Skipped = true
+			return g, nil
+		}
+		var ok bool
+		switch c.ToolMatch {
+		case "", "exact":
+			ok = len(out.ToolCalls) == len(c.Tools)
+			for i := 0; ok && i < len(c.Tools); i++ {
+				ok = callMatches(c.Tools[i], out.ToolCalls[i])
+			}
+		case "ordered":
+			i := 0
+			for _, call := range out.ToolCalls {
+				if i < len(c.Tools) && callMatches(c.Tools[i], call) {
+					i++
+				}
+			}
+			ok = i == len(c.Tools)
+		case "unordered":
+			used := make([]bool, len(out.ToolCalls))
+			ok = true
+			for _, want := range c.Tools {
+				found := false
+				for i, call := range out.ToolCalls {
+					if !used[i] && callMatches(want, call) {
+						used[i], found = true, true
+						break
+					}
+				}
+				ok = ok && found
+			}
+		default:
+			return g, fmt.Errorf("unknown tool_match %q", c.ToolMatch)
+		}
+		g.Pass = ok
+		if !ok {
+			g.Feedback = fmt.Sprintf("expected %s tool calls %s, got %s", matchMode(c.ToolMatch), expectedNames(c.Tools), callNames(out.ToolCalls))
+		}
+		return g, nil
+	}
+}
+
+func matchMode(m string) string {
+	if m == "" {
+		return "exact"
+	}
+	return m
+}
+
+func callMatches(want ExpectedCall, got ai.ToolCall) bool {
+	if want.Name != got.Name {
+		return false
+	}
+	for k, v := range want.Input {
+		if !jsonEqual(v, got.Input[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+func expectedNames(calls []ExpectedCall) string {
+	names := make([]string, len(calls))
+	for i, c := range calls {
+		names[i] = c.Name
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+func callNames(calls []ai.ToolCall) string {
+	names := make([]string, len(calls))
+	for i, c := range calls {
+		names[i] = c.Name
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+// JSONFields grades the reply against the case's Expect. The reply must
+// hold a JSON object, bare or in a code fence, and each expected path, such
+// as "order.items.0.sku", must hold the expected value.
+func JSONFields() Grader {
+	return func(_ context.Context, c Case, out *Output) (Grade, error) {
+		g := Grade{Grader: "json_fields"}
+		if len(c.Expect) == 0 {
+			g.Skipped = true
+			return g, nil
+		}
+		obj, ok := replyJSON(out.Reply)
+		if !ok {
+			g.Feedback = "reply has no JSON object"
+			return g, nil
+		}
+		paths := make([]string, 0, len(c.Expect))
+		for p := range c.Expect {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		var failed []string
+		for _, p := range paths {
+			got, found := lookup(obj, p)
+			switch {
+			case !found:
+				failed = append(failed, p+" is missing")
+			case !jsonEqual(c.Expect[p], got):
+				b, _ := json.Marshal(got)
+				want, _ := json.Marshal(c.Expect[p])
+				failed = append(failed, fmt.Sprintf("%s is %s, want %s", p, b, want))
+			}
+		}
+		g.Pass = len(failed) == 0
+		g.Feedback = strings.Join(failed, "; ")
+		return g, nil
+	}
+}
+
+// replyJSON finds the JSON object in a reply.
+func replyJSON(reply string) (any, bool) {
+	s := strings.TrimSpace(reply)
+	if i := strings.Index(s, "```"); i >= 0 {
+		rest := s[i+3:]
+		if nl := strings.IndexByte(rest, '\n'); nl >= 0 {
+			rest = rest[nl+1:]
+		}
+		if j := strings.Index(rest, "```"); j >= 0 {
+			s = strings.TrimSpace(rest[:j])
+		}
+	}
+	start, end := strings.IndexByte(s, '{'), strings.LastIndexByte(s, '}')
+	if start < 0 || end < start {
+		return nil, false
+	}
+	var v any
+	if err := json.Unmarshal([]byte(s[start:end+1]), &v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// lookup follows a dotted path of object keys and array indexes.
+func lookup(v any, path string) (any, bool) {
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// jsonEqual compares values as JSON, so 42 and 42.0 are equal.
+func jsonEqual(a, b any) bool {
+	return reflect.DeepEqual(normalize(a), normalize(b))
+}
+
+func normalize(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if json.Unmarshal(b, &out) != nil {
+		return v
+	}
+	return out
+}
+
+// LLMGrader asks m to judge the reply against the case's Rubric. The model
+// answers PASS or FAIL with a sentence of feedback.
+func LLMGrader(m ai.Model) Grader {
+	return func(ctx context.Context, c Case, out *Output) (Grade, error) {
+		g := Grade{Grader: "llm"}
+		if c.Rubric == "" {
+			g.Skipped = true
+			return g, nil
+		}
+		prompt := fmt.Sprintf("Grade an agent's reply against this rubric:\n%s\n\nThe agent was asked:\n%s\n\nIt called these tools: %s\n\nIt replied:\n%s\n\nAnswer with PASS or FAIL on the first line, followed by one short feedback sentence.",
+			c.Rubric, c.Input, callNames(out.ToolCalls), out.Reply)
+		resp, err := m.Generate(ctx, &ai.Request{Prompt: prompt})
+		if err != nil {
+			return g, err
+		}
+		reply := resp.Answer
+		if reply == "" {
+			reply = resp.Reply
+		}
+		text := strings.TrimSpace(reply)
+		if text == "" {
+			return g, fmt.Errorf("judge returned an empty grade")
+		}
+		first, rest, _ := strings.Cut(text, "\n")
+		g.Pass = strings.HasPrefix(strings.ToLower(strings.TrimSpace(first)), "pass")
+		g.Feedback = strings.TrimSpace(rest)
+		if g.Feedback == "" && !g.Pass {
+			g.Feedback = text
+		}
+		return g, nil
+	}
+}
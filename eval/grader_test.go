@@ -0,0 +1,91 @@
+package eval
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go-micro.dev/v6/ai"
+)
+
+func TestToolCallsMatchModes(t *testing.T) {
+	calls := []ai.ToolCall{{Name: "a"}, {Name: "log"}, {Name: "b", Input: map[string]any{"n": 1}}}
+	for _, tc := range []struct {
+		match string
+		want  []ExpectedCall
+		pass  bool
+	}{
+		{"exact", []ExpectedCall{{Name: "a"}, {Name: "b"}}, false},
+		{"exact", []ExpectedCall{{Name: "a"}, {Name: "log"}, {Name: "b"}}, true},
+		{"ordered", []ExpectedCall{{Name: "a"}, {Name: "b", Input: map[string]any{"n": 1.0}}}, true},
+		{"ordered", []ExpectedCall{{Name: "b"}, {Name: "a"}}, false},
+		{"unordered", []ExpectedCall{{Name: "b"}, {Name: "a"}}, true},
+		{"unordered", []ExpectedCall{{Name: "a"}, {Name: "a"}}, false},
+		{"ordered", []ExpectedCall{{Name: "b", Input: map[string]any{"n": 2}}}, false},
+	} {
+		g, err := ToolCalls()(context.Background(), Case{Tools: tc.want, ToolMatch: tc.match}, &Output{ToolCalls: calls})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if g.Pass != tc.pass {
+			t.Errorf("%s %v: pass = %v, want %v (%s)", tc.match, tc.want, g.Pass, tc.pass, g.Feedback)
+		}
+	}
+	if _, err := ToolCalls()(context.Background(), Case{Tools: []ExpectedCall{{Name: "a"}}, ToolMatch: "fuzzy"}, &Output{}); err == nil {
+		t.Error("an unknown match mode should fail")
+	}
+	if g, _ := ToolCalls()(context.Background(), Case{}, &Output{ToolCalls: calls}); !g.Skipped {
+		t.Error("a case without tools should skip the grader")
+	}
+}
+
+func TestJSONFields(t *testing.T) {
+	c := Case{Expect: map[string]any{"order.items.0.sku": "A1", "total": 42, "paid": true}}
+	g, _ := JSONFields()(context.Background(), c, &Output{Reply: `Here you go: {"order": {"items": [{"sku": "A1"}]}, "total": 42.0, "paid": true}`})
+	if !g.Pass {
+		t.Fatalf("grade = %+v", g)
+	}
+	g, _ = JSONFields()(context.Background(), c, &Output{Reply: `{"order": {"items": []}, "total": 41, "paid": true}`})
+	if g.Pass || g.Feedback != "order.items.0.sku is missing; total is 41, want 42" {
+		t.Fatalf("grade = %+v", g)
+	}
+	g, _ = JSONFields()(context.Background(), c, &Output{Reply: "no json here"})
+	if g.Pass || g.Feedback != "reply has no JSON object" {
+		t.Fatalf("grade = %+v", g)
+	}
+}
+
+// judge replies with a fixed grade and records the prompt it was given.
+type judge struct {
+	evalModel
+	reply  string
+	prompt string
+}
+
+func (j *judge) Generate(_ context.Context, req *ai.Request, _ ...ai.GenerateOption) (*ai.Response, error) {
+	j.prompt = req.Prompt
+	return &ai.Response{Reply: j.reply}, nil
+}
+
+func TestLLMGrader(t *testing.T) {
+	j := &judge{reply: "FAIL\nThe reply never mentions the refund amount."}
+	c := Case{Input: "refund order 42", Rubric: "States the refunded amount"}
+	g, err := LLMGrader(j)(context.Background(), c, &Output{Reply: "Refunded.", ToolCalls: []ai.ToolCall{{Name: "payments_Refund"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Pass || g.Feedback != "The reply never mentions the refund amount." {
+		t.Fatalf("grade = %+v", g)
+	}
+	if !strings.Contains(j.prompt, "States the refunded amount") || !strings.Contains(j.prompt, "[payments_Refund]") {
+		t.Fatalf("prompt = %q", j.prompt)
+	}
+
+	j.reply = "PASS"
+	if g, _ := LLMGrader(j)(context.Background(), c, &Output{}); !g.Pass {
+		t.Fatalf("grade = %+v", g)
+	}
+	if g, _ := LLMGrader(j)(context.Background(), Case{}, &Output{}); !g.Skipped {
+		t.Fatal("a case without a rubric should skip the judge")
+	}
+}
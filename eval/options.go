@@ -0,0 +1,47 @@
+package eval
+
+import "go-micro.dev/v6/store"
+
+// Options configure Run.
+type Options struct {
+	// Graders grade each case's output. Default DefaultGraders.
+	Graders []Grader
+	// Label is recorded on the result, to tell versions apart.
+	Label string
+	// Agent is the name of the agent evaluated, recorded on the result.
+	Agent string
+	// Store, when set, is where the result is saved.
+	Store store.Store
+}
+
+// Option configures Run.
+type Option func(*Options)
+
+// Graders sets the graders, replacing DefaultGraders. Include the defaults
+// to add to them: Graders(append(DefaultGraders(), LLMGrader(m))...).
+func Graders(g ...Grader) Option {
+	return func(o *Options) { o.Graders = g }
+}
+
+// Label names what is evaluated, such as "v2" or a commit.
+func Label(l string) Option {
+	return func(o *Options) { o.Label = l }
+}
+
+// Agent records the name of the agent evaluated.
+func Agent(name string) Option {
+	return func(o *Options) { o.Agent = name }
+}
+
+// Store saves the result to s.
+func Store(s store.Store) Option {
+	return func(o *Options) { o.Store = s }
+}
+
+func newOptions(opts ...Option) Options {
+	o := Options{Graders: DefaultGraders()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
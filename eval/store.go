@@ -0,0 +1,87 @@
+package eval
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	"go-micro.dev/v6/store"
+)
+
+// Results are kept in the "eval" database, one table per suite: each result
+// under "results/<id>" and the baseline's id under "baseline".
+const baselineKey = "baseline"
+
+func suiteStore(s store.Store, suite string) store.Store {
+	return store.Scope(s, "eval", suite)
+}
+
+// Save writes a result to s.
+func Save(s store.Store, res *Result) error {
+	b, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return suiteStore(s, res.Suite).Write(&store.Record{Key: "results/" + res.ID, Value: b})
+}
+
+// Load reads a saved result of suite by id.
+func Load(s store.Store, suite, id string) (*Result, bool, error) {
+	recs, err := suiteStore(s, suite).Read("results/" + id)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && len(recs) == 0) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	var res Result
+	if err := json.Unmarshal(recs[0].Value, &res); err != nil {
+		return nil, false, err
+	}
+	return &res, true, nil
+}
+
+// List returns the saved results of suite, oldest first.
+func List(s store.Store, suite string) ([]*Result, error) {
+	st := suiteStore(s, suite)
+	keys, err := st.List(store.ListPrefix("results/"))
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*Result, 0, len(keys))
+	for _, k := range keys {
+		recs, err := st.Read(k)
+		if err != nil || len(recs) == 0 {
+			continue
+		}
+		var res Result
+		if json.Unmarshal(recs[0].Value, &res) == nil {
+			results = append(results, &res)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Started.Before(results[j].Started) })
+	return results, nil
+}
+
+// SetBaseline makes the saved result id the baseline of suite, which later
+// results are compared against.
+func SetBaseline(s store.Store, suite, id string) error {
+	if _, ok, err := Load(s, suite, id); err != nil {
+		return err
+	} else if !ok {
+		return errors.New("eval: no result " + id + " for suite " + suite)
+	}
+	return suiteStore(s, suite).Write(&store.Record{Key: baselineKey, Value: []byte(id)})
+}
+
+// Baseline returns the baseline result of suite, if one is set.
+func Baseline(s store.Store, suite string) (*Result, bool, error) {
+	recs, err := suiteStore(s, suite).Read(baselineKey)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && len(recs) == 0) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return Load(s, suite, string(recs[0].Value))
+}
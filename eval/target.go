@@ -0,0 +1,91 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+
+	"go-micro.dev/v6/agent"
+	pb "go-micro.dev/v6/agent/proto"
+	"go-micro.dev/v6/ai"
+	"go-micro.dev/v6/client"
+	"go-micro.dev/v6/store"
+)
+
+// AgentTarget evaluates an in-process agent with Ask. Token use and spend
+// are read from the run events the agent records in its store.
+//
+// Each case is asked in a conversation of its own (see agent.WithSession),
+// so cases don't see each other or the agent's default conversation.
+func AgentTarget(ag agent.Agent) Target {
+	return func(ctx context.Context, input string) (*Output, error) {
+		opts := ag.Options()
+		resp, err := ag.Ask(agent.WithSession(ctx, newSession()), input)
+		if err != nil {
+			return nil, err
+		}
+		out := &Output{Reply: resp.Reply, ToolCalls: resp.ToolCalls, RunID: resp.RunID}
+		events := opts.Store
+		if events == nil {
+			events = store.DefaultStore
+		}
+		out.Tokens, out.Spent = runUsage(events, ag.Name(), resp.RunID)
+		return out, nil
+	}
+}
+
+// ServiceTarget evaluates a running agent through its Agent.Chat RPC. Each
+// case is sent with a session of its own, so it runs in a fresh
+// conversation. When events is the store the agent records its runs in,
+// token use and spend are read from it.
+func ServiceTarget(c client.Client, name string, events store.Store) Target {
+	svc := pb.NewAgentService(name, c)
+	return func(ctx context.Context, input string) (*Output, error) {
+		rsp, err := svc.Chat(ctx, &pb.ChatRequest{Message: input, Session: newSession()})
+		if err != nil {
+			return nil, err
+		}
+		out := &Output{Reply: rsp.Reply, RunID: rsp.RunId}
+		for _, tc := range rsp.ToolCalls {
+			call := ai.ToolCall{ID: tc.Id, Name: tc.Name, Result: tc.Result}
+			_ = json.Unmarshal([]byte(tc.Input), &call.Input)
+			out.ToolCalls = append(out.ToolCalls, call)
+		}
+		if events != nil {
+			out.Tokens, out.Spent = runUsage(events, name, rsp.RunId)
+		}
+		return out, nil
+	}
+}
+
+// newSession returns the session a case runs in.
+func newSession() string {
+	return "eval/" + uuid.New().String()
+}
+
+// runUsage sums a run's model token use and returns its spend.
+func runUsage(s store.Store, name, runID string) (ai.Usage, int64) {
+	var usage ai.Usage
+	var spent int64
+	if runID == "" {
+		return usage, spent
+	}
+	events, err := agent.LoadRunEvents(s, name, runID)
+	if err != nil {
+		return usage, spent
+	}
+	for _, e := range events {
+		if e.Kind == "model" || e.Kind == "stream" {
+			usage.InputTokens += e.Tokens.InputTokens
+			usage.OutputTokens += e.Tokens.OutputTokens
+			usage.TotalTokens += e.Tokens.TotalTokens
+			usage.CacheReadTokens += e.Tokens.CacheReadTokens
+			usage.CacheWriteTokens += e.Tokens.CacheWriteTokens
+		}
+		if e.Spent > spent {
+			spent = e.Spent
+		}
+	}
+	return usage, spent
+}
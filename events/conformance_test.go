@@ -0,0 +1,20 @@
+package events_test
+
+import (
+	"testing"
+
+	"go-micro.dev/v6/events"
+	"go-micro.dev/v6/events/eventstest"
+)
+
+func TestStream(t *testing.T) {
+	stream, err := events.NewStream()
+	if err != nil {
+		t.Fatalf("NewStream: %v", err)
+	}
+	eventstest.RunStream(t, stream)
+}
+
+func TestStore(t *testing.T) {
+	eventstest.RunStore(t, events.NewStore())
+}
@@ -1,19 +1,22 @@
-package events
+package eventstest
 
 import (
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"go-micro.dev/v6/events"
 )
 
-func TestStore(t *testing.T) {
-	store := NewStore()
+// RunStore exercises the behaviour every store must share. Topics are
+// unique to the run, so a store may be reused.
+func RunStore(t *testing.T, store events.Store) {
+	foo, bar := uuid.New().String(), uuid.New().String()
 
-	testData := []Event{
-		{ID: uuid.New().String(), Topic: "foo"},
-		{ID: uuid.New().String(), Topic: "foo"},
-		{ID: uuid.New().String(), Topic: "bar"},
+	testData := []events.Event{
+		{ID: uuid.New().String(), Topic: foo},
+		{ID: uuid.New().String(), Topic: foo},
+		{ID: uuid.New().String(), Topic: bar},
 	}
 
 	// write the records to the store
@@ -27,20 +30,20 @@ func TestStore(t *testing.T) {
 	// should not be able to read events from a blank topic
 	t.Run("ReadMissingTopic", func(t *testing.T) {
 		evs, err := store.Read("")
-		assert.Equal(t, err, ErrMissingTopic, "Reading a blank topic should return an error")
+		assert.Equal(t, err, events.ErrMissingTopic, "Reading a blank topic should return an error")
 		assert.Nil(t, evs, "No events should be returned")
 	})
 
 	// should only get the events from the topic requested
 	t.Run("ReadTopic", func(t *testing.T) {
-		evs, err := store.Read("foo")
+		evs, err := store.Read(foo)
 		assert.Nilf(t, err, "No error should be returned")
 		assert.Len(t, evs, 2, "Only the events for this topic should be returned")
 	})
 
 	// limits should be honored
 	t.Run("ReadTopicLimit", func(t *testing.T) {
-		evs, err := store.Read("foo", ReadLimit(1))
+		evs, err := store.Read(foo, events.ReadLimit(1))
 		assert.Nilf(t, err, "No error should be returned")
 		assert.Len(t, evs, 1, "The result should include no more than the read limit")
 	})
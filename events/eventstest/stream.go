@@ -1,4 +1,15 @@
-package events
+// Package eventstest provides a conformance suite for events.Stream and
+// events.Store implementations. Run it from a test in the implementation's
+// package:
+//
+//	func TestStream(t *testing.T) {
+//		stream, err := mystream.NewStream()
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		eventstest.RunStream(t, stream)
+//	}
+package eventstest
 
 import (
 	"sync"
@@ -7,38 +18,22 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"go-micro.dev/v6/events"
 )
 
 type testPayload struct {
 	Message string
 }
 
-type testCase struct {
-	str  Stream
-	name string
-}
-
-func TestStream(t *testing.T) {
-	tcs := []testCase{}
-
-	stream, err := NewStream()
-	assert.Nilf(t, err, "NewStream should not return an error")
-	assert.NotNilf(t, stream, "NewStream should return a stream object")
-	tcs = append(tcs, testCase{str: stream, name: "memory"})
-
-	for _, tc := range tcs {
-		t.Run(tc.name, func(t *testing.T) {
-			runTestStream(t, tc.str)
-		})
-	}
-
-}
-
-func runTestStream(t *testing.T, stream Stream) {
+// RunStream exercises the behaviour every stream must share: topic
+// validation, delivery to each consumer group, replay with WithOffset,
+// acking and nacking, and retry limits. It takes about 30 seconds, most of
+// it waiting out ack deadlines.
+func RunStream(t *testing.T, stream events.Stream) {
 	// TestMissingTopic will test the topic validation on publish
 	t.Run("TestMissingTopic", func(t *testing.T) {
 		err := stream.Publish("", nil)
-		assert.Equalf(t, err, ErrMissingTopic, "Publishing to a blank topic should return an error")
+		assert.Equalf(t, err, events.ErrMissingTopic, "Publishing to a blank topic should return an error")
 	})
 
 	// TestConsumeTopic will publish a message to the test topic. The subscriber will subscribe to the
@@ -75,7 +70,7 @@ func runTestStream(t *testing.T, stream Stream) {
 			}
 		}()
 
-		err = stream.Publish("test", payload, WithMetadata(metadata))
+		err = stream.Publish("test", payload, events.WithMetadata(metadata))
 		assert.Nil(t, err, "Publishing a valid message should not return an error")
 
 		// wait for the subscriber to receive the message or timeout
@@ -118,13 +113,13 @@ func runTestStream(t *testing.T, stream Stream) {
 			}
 		}()
 
-		err = stream.Publish(topic, payload, WithMetadata(metadata))
+		err = stream.Publish(topic, payload, events.WithMetadata(metadata))
 		assert.Nil(t, err, "Publishing a valid message should not return an error")
 
 		// create the second subscriber
 		evChan2, err := stream.Consume(topic,
-			WithGroup("second_queue"),
-			WithOffset(time.Now().Add(time.Minute*-1)),
+			events.WithGroup("second_queue"),
+			events.WithOffset(time.Now().Add(time.Minute*-1)),
 		)
 		assert.Nilf(t, err, "Consume should not return an error")
 
@@ -153,7 +148,7 @@ func runTestStream(t *testing.T, stream Stream) {
 	})
 
 	t.Run("AckingNacking", func(t *testing.T) {
-		ch, err := stream.Consume("foobarAck", WithAutoAck(false, 5*time.Second))
+		ch, err := stream.Consume("foobarAck", events.WithAutoAck(false, 5*time.Second))
 		assert.NoError(t, err, "Unexpected error subscribing")
 		assert.NoError(t, stream.Publish("foobarAck", map[string]string{"foo": "message 1"}))
 		assert.NoError(t, stream.Publish("foobarAck", map[string]string{"foo": "message 2"}))
@@ -182,7 +177,7 @@ func runTestStream(t *testing.T, stream Stream) {
 	})
 
 	t.Run("Retries", func(t *testing.T) {
-		ch, err := stream.Consume("foobarRetries", WithAutoAck(false, 5*time.Second), WithRetryLimit(1))
+		ch, err := stream.Consume("foobarRetries", events.WithAutoAck(false, 5*time.Second), events.WithRetryLimit(1))
 		assert.NoError(t, err, "Unexpected error subscribing")
 		assert.NoError(t, stream.Publish("foobarRetries", map[string]string{"foo": "message 1"}))
 
@@ -201,7 +196,7 @@ func runTestStream(t *testing.T, stream Stream) {
 	})
 
 	t.Run("InfiniteRetries", func(t *testing.T) {
-		ch, err := stream.Consume("foobarRetriesInf", WithAutoAck(false, 2*time.Second))
+		ch, err := stream.Consume("foobarRetriesInf", events.WithAutoAck(false, 2*time.Second))
 		assert.NoError(t, err, "Unexpected error subscribing")
 		assert.NoError(t, stream.Publish("foobarRetriesInf", map[string]string{"foo": "message 1"}))
 
@@ -227,9 +222,9 @@ func runTestStream(t *testing.T, stream Stream) {
 	})
 
 	t.Run("twoSubs", func(t *testing.T) {
-		ch1, err := stream.Consume("foobarTwoSubs1", WithAutoAck(false, 5*time.Second))
+		ch1, err := stream.Consume("foobarTwoSubs1", events.WithAutoAck(false, 5*time.Second))
 		assert.NoError(t, err, "Unexpected error subscribing to topic 1")
-		ch2, err := stream.Consume("foobarTwoSubs2", WithAutoAck(false, 5*time.Second))
+		ch2, err := stream.Consume("foobarTwoSubs2", events.WithAutoAck(false, 5*time.Second))
 		assert.NoError(t, err, "Unexpected error subscribing to topic 2")
 
 		assert.NoError(t, stream.Publish("foobarTwoSubs2", map[string]string{"foo": "message 1"}))
@@ -0,0 +1,621 @@
+// Package sqlevents implements events.Stream and events.Store on a SQL
+// database, shared by the postgres and sqlite packages, which supply the
+// dialect.
+//
+// Events are appended to one table. Each consumer group keeps a cursor
+// per topic, the last event handed out, and a pending row for every event
+// it has claimed but not yet acked. Claiming happens in a transaction:
+// first a pending event past its ack deadline (a nack sets the deadline to
+// now), otherwise the next event after the cursor. A group's consumers
+// therefore share its events, and an unacked event is redelivered until
+// it is acked or runs out of retries.
+//
+// Events are ordered by (xid, seq), where xid is the writing transaction
+// as the dialect records it. A database whose writers can commit out of
+// seq order records an increasing transaction id there and only lets
+// consumers claim settled events, those written by transactions older
+// than any still running. An event that commits late then sorts after
+// every event already claimed instead of behind the cursor.
+package sqlevents
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go-micro.dev/v6/events"
+	"go-micro.dev/v6/logger"
+)
+
+// DefaultAckWait is how long an auto-acked event stays claimed before it
+// is redelivered, which only happens if the consumer dies mid-delivery.
+var DefaultAckWait = 30 * time.Second
+
+// Dialect adapts the queries to a database.
+type Dialect interface {
+	// Schema returns the statements creating the tables, which are named
+	// by the prefix and suffixes "_groups" and "_pending".
+	Schema(table string) []string
+	// Placeholder returns the nth (from 1) query placeholder.
+	Placeholder(n int) string
+	// Lock is appended to a SELECT to lock the rows it reads for the
+	// transaction, skipping rows other transactions hold.
+	Lock(skipLocked bool) string
+	// Settled is a condition on the events table, ANDed into the query
+	// claiming new events, that holds once no event ordered before the
+	// row can still commit. It is empty if writers commit in seq order.
+	Settled() string
+	// Notified is called after an event is written, in the same
+	// transaction, to notify consumers in other processes.
+	Notified(ctx context.Context, tx *sql.Tx, topic string) error
+}
+
+// Config configures a DB.
+type Config struct {
+	DB           *sql.DB
+	Dialect      Dialect
+	Table        string
+	PollInterval time.Duration
+	Logger       logger.Logger
+}
+
+// DB is an events stream and store on a database.
+type DB struct {
+	cfg Config
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	sync.Mutex
+	// consumers waiting for events, by topic
+	waiting map[string]map[chan struct{}]bool
+	purged  time.Time
+}
+
+// New creates the tables if needed and returns the DB.
+func New(cfg Config) (*DB, error) {
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logger.DefaultLogger
+	}
+
+	for _, stmt := range cfg.Dialect.Schema(cfg.Table) {
+		if _, err := cfg.DB.Exec(stmt); err != nil {
+			return nil, fmt.Errorf("create events tables: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &DB{
+		cfg:     cfg,
+		ctx:     ctx,
+		cancel:  cancel,
+		waiting: make(map[string]map[chan struct{}]bool),
+	}, nil
+}
+
+// Close stops the consumers. The caller closes the database.
+func (d *DB) Close() error {
+	d.cancel()
+	return nil
+}
+
+// Notify wakes the consumers of topic, e.g. on a notification from
+// another process.
+func (d *DB) Notify(topic string) {
+	d.Lock()
+	defer d.Unlock()
+	for ch := range d.waiting[topic] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// q rewrites the ? placeholders of query for the dialect and fills in the
+// table names.
+func (d *DB) q(query string) string {
+	query = strings.NewReplacer(
+		"{events}", d.cfg.Table,
+		"{groups}", d.cfg.Table+"_groups",
+		"{pending}", d.cfg.Table+"_pending",
+	).Replace(query)
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString(d.cfg.Dialect.Placeholder(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Publish a message to a topic.
+func (d *DB) Publish(topic string, msg interface{}, opts ...events.PublishOption) error {
+	// validate the topic
+	if len(topic) == 0 {
+		return events.ErrMissingTopic
+	}
+
+	// parse the options
+	options := events.PublishOptions{
+		Timestamp: time.Now(),
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	// encode the message if it's not already encoded
+	var payload []byte
+	if p, ok := msg.([]byte); ok {
+		payload = p
+	} else {
+		p, err := json.Marshal(msg)
+		if err != nil {
+			return events.ErrEncodingMessage
+		}
+		payload = p
+	}
+
+	return d.write(&events.Event{
+		ID:        uuid.New().String(),
+		Topic:     topic,
+		Timestamp: options.Timestamp,
+		Metadata:  options.Metadata,
+		Payload:   payload,
+	}, 0)
+}
+
+// Write an event to the store. It is also delivered to consumers.
+func (d *DB) Write(event *events.Event, opts ...events.WriteOption) error {
+	if len(event.Topic) == 0 {
+		return events.ErrMissingTopic
+	}
+
+	var options events.WriteOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if len(event.ID) == 0 {
+		event.ID = uuid.New().String()
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	return d.write(event, options.TTL)
+}
+
+func (d *DB) write(ev *events.Event, ttl time.Duration) error {
+	md, err := json.Marshal(ev.Metadata)
+	if err != nil {
+		return fmt.Errorf("encode metadata: %w", err)
+	}
+
+	var expires sql.NullInt64
+	if ttl > 0 {
+		expires = sql.NullInt64{Int64: time.Now().Add(ttl).UnixNano(), Valid: true}
+	}
+
+	ctx := context.Background()
+	tx, err := d.cfg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, d.q(`INSERT INTO {events} (id, topic, created, metadata, payload, expires) VALUES (?, ?, ?, ?, ?, ?)`),
+		ev.ID, ev.Topic, ev.Timestamp.UnixNano(), string(md), ev.Payload, expires); err != nil {
+		return fmt.Errorf("write event: %w", err)
+	}
+	if err := d.cfg.Dialect.Notified(ctx, tx, ev.Topic); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	d.Notify(ev.Topic)
+	d.purge()
+	return nil
+}
+
+// purge deletes expired events, at most once a minute.
+func (d *DB) purge() {
+	d.Lock()
+	if time.Since(d.purged) < time.Minute {
+		d.Unlock()
+		return
+	}
+	d.purged = time.Now()
+	d.Unlock()
+
+	if _, err := d.cfg.DB.Exec(d.q(`DELETE FROM {events} WHERE expires IS NOT NULL AND expires < ?`), time.Now().UnixNano()); err != nil {
+		d.cfg.Logger.Logf(logger.ErrorLevel, "Error purging expired events: %v", err)
+	}
+}
+
+// Read events for a topic, oldest first.
+func (d *DB) Read(topic string, opts ...events.ReadOption) ([]*events.Event, error) {
+	// validate the topic
+	if len(topic) == 0 {
+		return nil, events.ErrMissingTopic
+	}
+
+	// parse the options
+	options := events.ReadOptions{
+		Offset: 0,
+		Limit:  250,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	rows, err := d.cfg.DB.Query(d.q(`SELECT seq, id, topic, created, metadata, payload FROM {events}
+		WHERE topic = ? AND (expires IS NULL OR expires > ?) ORDER BY seq LIMIT ? OFFSET ?`),
+		topic, time.Now().UnixNano(), int64(options.Limit), int64(options.Offset))
+	if err != nil {
+		return nil, fmt.Errorf("read events: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*events.Event
+	for rows.Next() {
+		ev, _, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ev)
+	}
+	return result, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// xidScanner reads a leading xid column into xid before the columns scan
+// expects.
+type xidScanner struct {
+	scanner
+	xid *int64
+}
+
+func (x xidScanner) Scan(dest ...interface{}) error {
+	return x.scanner.Scan(append([]interface{}{x.xid}, dest...)...)
+}
+
+func scan(s scanner) (*events.Event, int64, error) {
+	var (
+		ev      events.Event
+		seq     int64
+		created int64
+		md      string
+	)
+	if err := s.Scan(&seq, &ev.ID, &ev.Topic, &created, &md, &ev.Payload); err != nil {
+		return nil, 0, err
+	}
+	ev.Timestamp = time.Unix(0, created)
+	if err := json.Unmarshal([]byte(md), &ev.Metadata); err != nil {
+		return nil, 0, fmt.Errorf("decode metadata: %w", err)
+	}
+	return &ev, seq, nil
+}
+
+// Consume from a topic.
+func (d *DB) Consume(topic string, opts ...events.ConsumeOption) (<-chan events.Event, error) {
+	// validate the topic
+	if len(topic) == 0 {
+		return nil, events.ErrMissingTopic
+	}
+
+	// parse the options
+	options := events.ConsumeOptions{
+		Group:   uuid.New().String(),
+		AutoAck: true,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if !options.AutoAck && options.AckWait <= 0 {
+		return nil, fmt.Errorf("invalid AckWait passed, should be positive integer")
+	}
+	if options.AutoAck && options.AckWait <= 0 {
+		options.AckWait = DefaultAckWait
+	}
+
+	if err := d.join(topic, options); err != nil {
+		return nil, err
+	}
+
+	c := &consumer{
+		d:       d,
+		topic:   topic,
+		options: options,
+		channel: make(chan events.Event),
+		wake:    make(chan struct{}, 1),
+	}
+
+	d.Lock()
+	if d.waiting[topic] == nil {
+		d.waiting[topic] = make(map[chan struct{}]bool)
+	}
+	d.waiting[topic][c.wake] = true
+	d.Unlock()
+
+	go c.run()
+
+	return c.channel, nil
+}
+
+// join creates the group's cursor at the end of the topic if it doesn't
+// exist. With an offset the cursor is set to the last event before it,
+// moving an existing group's cursor so it replays from there.
+func (d *DB) join(topic string, options events.ConsumeOptions) error {
+	query := `SELECT xid, seq FROM {events} WHERE topic = ?`
+	args := []interface{}{topic}
+	if !options.Offset.IsZero() {
+		query += ` AND created < ?`
+		args = append(args, options.Offset.UnixNano())
+	}
+	query += ` ORDER BY xid DESC, seq DESC LIMIT 1`
+
+	var xid, seq int64
+	err := d.cfg.DB.QueryRow(d.q(query), args...).Scan(&xid, &seq)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("find group offset: %w", err)
+	}
+
+	if options.Offset.IsZero() {
+		if _, err := d.cfg.DB.Exec(d.q(`INSERT INTO {groups} (name, topic, last_xid, last_seq) VALUES (?, ?, ?, ?)
+			ON CONFLICT (name, topic) DO NOTHING`), options.Group, topic, xid, seq); err != nil {
+			return fmt.Errorf("join group: %w", err)
+		}
+		return nil
+	}
+
+	tx, err := d.cfg.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(d.q(`INSERT INTO {groups} (name, topic, last_xid, last_seq) VALUES (?, ?, ?, ?)
+		ON CONFLICT (name, topic) DO UPDATE SET last_xid = excluded.last_xid, last_seq = excluded.last_seq`),
+		options.Group, topic, xid, seq); err != nil {
+		return fmt.Errorf("join group: %w", err)
+	}
+	// events past the new cursor are claimed from it again
+	if _, err := tx.Exec(d.q(`DELETE FROM {pending} WHERE name = ? AND topic = ? AND seq IN
+		(SELECT seq FROM {events} WHERE topic = ? AND (xid > ? OR (xid = ? AND seq > ?)))`),
+		options.Group, topic, topic, xid, xid, seq); err != nil {
+		return fmt.Errorf("join group: %w", err)
+	}
+	return tx.Commit()
+}
+
+type consumer struct {
+	d       *DB
+	topic   string
+	options events.ConsumeOptions
+	channel chan events.Event
+	wake    chan struct{}
+}
+
+func (c *consumer) run() {
+	log := c.d.cfg.Logger
+
+	for {
+		ev, seq, err := c.claim()
+		if err != nil {
+			log.Logf(logger.ErrorLevel, "Error claiming event: %v", err)
+		}
+		if ev == nil {
+			select {
+			case <-c.d.ctx.Done():
+				return
+			case <-c.wake:
+			case <-time.After(c.d.cfg.PollInterval):
+			}
+			continue
+		}
+
+		if !c.deliver(ev, seq) {
+			return
+		}
+	}
+}
+
+// deliver hands ev to the consumer and settles it. It returns false once
+// the DB is closed.
+func (c *consumer) deliver(ev *events.Event, seq int64) bool {
+	log := c.d.cfg.Logger
+
+	if c.options.AutoAck {
+		ev.SetAckFunc(func() error { return nil })
+		ev.SetNackFunc(func() error { return nil })
+
+		select {
+		case c.channel <- *ev:
+		case <-c.d.ctx.Done():
+			return false
+		}
+		if err := c.ack(seq); err != nil {
+			log.Logf(logger.ErrorLevel, "Error acknowledging event: %v", err)
+		}
+		return true
+	}
+
+	result := make(chan bool, 1)
+	ev.SetAckFunc(func() error {
+		select {
+		case result <- true:
+		default:
+		}
+		return nil
+	})
+	ev.SetNackFunc(func() error {
+		select {
+		case result <- false:
+		default:
+		}
+		return nil
+	})
+
+	select {
+	case c.channel <- *ev:
+	case <-c.d.ctx.Done():
+		return false
+	}
+
+	// one event in flight at a time, so a nacked event is redelivered
+	// before the ones after it
+	timer := time.NewTimer(c.options.AckWait)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case acked := <-result:
+		if acked {
+			err = c.ack(seq)
+		} else {
+			err = c.nack(seq)
+		}
+	case <-timer.C:
+		// the claim expires on its own
+	case <-c.d.ctx.Done():
+		return false
+	}
+	if err != nil {
+		log.Logf(logger.ErrorLevel, "Error acknowledging event: %v", err)
+	}
+	return true
+}
+
+func (c *consumer) ack(seq int64) error {
+	_, err := c.d.cfg.DB.Exec(c.d.q(`DELETE FROM {pending} WHERE name = ? AND topic = ? AND seq = ?`),
+		c.options.Group, c.topic, seq)
+	return err
+}
+
+func (c *consumer) nack(seq int64) error {
+	_, err := c.d.cfg.DB.Exec(c.d.q(`UPDATE {pending} SET deadline = 0 WHERE name = ? AND topic = ? AND seq = ?`),
+		c.options.Group, c.topic, seq)
+	return err
+}
+
+// claim takes the next event for the consumer's group: a pending event
+// due for redelivery, or else the next new one. It returns nil if there
+// is none.
+func (c *consumer) claim() (*events.Event, int64, error) {
+	d := c.d
+	ctx := d.ctx
+	group, topic := c.options.Group, c.topic
+
+	tx, err := d.cfg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UnixNano()
+	deadline := time.Now().Add(c.options.AckWait).UnixNano()
+	limit := c.options.GetRetryLimit()
+
+	for {
+		var seq int64
+		var deliveries int
+		err := tx.QueryRowContext(ctx, d.q(`SELECT seq, deliveries FROM {pending}
+			WHERE name = ? AND topic = ? AND deadline <= ? ORDER BY seq LIMIT 1`+d.cfg.Dialect.Lock(true)),
+			group, topic, now).Scan(&seq, &deliveries)
+		if errors.Is(err, sql.ErrNoRows) {
+			break
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if limit > -1 && deliveries > limit {
+			d.cfg.Logger.Logf(logger.ErrorLevel, "Message retry limit reached, discarding: %s/%d %d %d", topic, seq, deliveries-1, limit)
+			if _, err := tx.ExecContext(ctx, d.q(`DELETE FROM {pending} WHERE name = ? AND topic = ? AND seq = ?`), group, topic, seq); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+
+		ev, err := c.event(tx, seq)
+		if err != nil {
+			return nil, 0, err
+		}
+		if ev == nil {
+			// expired since it was claimed
+			if _, err := tx.ExecContext(ctx, d.q(`DELETE FROM {pending} WHERE name = ? AND topic = ? AND seq = ?`), group, topic, seq); err != nil {
+				return nil, 0, err
+			}
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, d.q(`UPDATE {pending} SET deliveries = deliveries + 1, deadline = ? WHERE name = ? AND topic = ? AND seq = ?`),
+			deadline, group, topic, seq); err != nil {
+			return nil, 0, err
+		}
+		return ev, seq, tx.Commit()
+	}
+
+	// the cursor row lock serializes the group's consumers
+	var lastXid, lastSeq int64
+	if err := tx.QueryRowContext(ctx, d.q(`SELECT last_xid, last_seq FROM {groups} WHERE name = ? AND topic = ?`+d.cfg.Dialect.Lock(false)),
+		group, topic).Scan(&lastXid, &lastSeq); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT xid, seq, id, topic, created, metadata, payload FROM {events}
+		WHERE topic = ? AND (xid > ? OR (xid = ? AND seq > ?)) AND (expires IS NULL OR expires > ?)`
+	if settled := d.cfg.Dialect.Settled(); settled != "" {
+		query += ` AND ` + settled
+	}
+	var xid int64
+	row := tx.QueryRowContext(ctx, d.q(query+` ORDER BY xid, seq LIMIT 1`), topic, lastXid, lastXid, lastSeq, now)
+	ev, seq, err := scan(xidScanner{row, &xid})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, d.q(`UPDATE {groups} SET last_xid = ?, last_seq = ? WHERE name = ? AND topic = ?`), xid, seq, group, topic); err != nil {
+		return nil, 0, err
+	}
+	if _, err := tx.ExecContext(ctx, d.q(`INSERT INTO {pending} (name, topic, seq, deliveries, deadline) VALUES (?, ?, ?, 1, ?)`),
+		group, topic, seq, deadline); err != nil {
+		return nil, 0, err
+	}
+
+	return ev, seq, tx.Commit()
+}
+
+func (c *consumer) event(tx *sql.Tx, seq int64) (*events.Event, error) {
+	row := tx.QueryRowContext(c.d.ctx, c.d.q(`SELECT seq, id, topic, created, metadata, payload FROM {events}
+		WHERE seq = ? AND (expires IS NULL OR expires > ?)`), seq, time.Now().UnixNano())
+	ev, _, err := scan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return ev, err
+}
@@ -0,0 +1,258 @@
+// Package kafka provides a Kafka implementation of the events.Stream interface.
+//
+// Each event is published as a JSON record on the topic, keyed by the
+// DefaultKeyMetadata metadata value when present, with the event metadata
+// also copied to record headers for other Kafka consumers.
+//
+// events.WithGroup maps to a Kafka consumer group; consumers without a
+// group get a group of their own and so receive every event. Acking an
+// event commits its offset. Kafka tracks one position per partition, so
+// a commit also covers earlier events on that partition, and an event
+// that is nacked or never acked is delivered again once the group
+// rebalances or restarts from its last commit. events.WithOffset replays
+// from the first event published at or after the given time, for groups
+// with no committed offset.
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"go-micro.dev/v6/events"
+	"go-micro.dev/v6/logger"
+)
+
+// NewStream returns a kafka stream or an error if the cluster can't be
+// reached.
+func NewStream(opts ...Option) (events.Stream, error) {
+	options := Options{
+		Address:     []string{"127.0.0.1:9092"},
+		Logger:      logger.DefaultLogger,
+		KeyMetadata: DefaultKeyMetadata,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	s := &stream{
+		opts:      options,
+		consumers: make(map[*kgo.Client]context.CancelFunc),
+	}
+
+	producer, err := kgo.NewClient(s.clientOpts()...)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := producer.Ping(ctx); err != nil {
+		producer.Close()
+		return nil, fmt.Errorf("error connecting to kafka at %v: %w", options.Address, err)
+	}
+	s.producer = producer
+
+	return s, nil
+}
+
+type stream struct {
+	opts     Options
+	producer *kgo.Client
+
+	sync.Mutex
+	consumers map[*kgo.Client]context.CancelFunc
+}
+
+func (s *stream) clientOpts() []kgo.Opt {
+	opts := []kgo.Opt{
+		kgo.SeedBrokers(s.opts.Address...),
+		kgo.AllowAutoTopicCreation(),
+	}
+	if s.opts.TLSConfig != nil {
+		opts = append(opts, kgo.DialTLSConfig(s.opts.TLSConfig))
+	}
+	return append(opts, s.opts.ClientOptions...)
+}
+
+// Publish a message to a topic.
+func (s *stream) Publish(topic string, msg interface{}, opts ...events.PublishOption) error {
+	// validate the topic
+	if len(topic) == 0 {
+		return events.ErrMissingTopic
+	}
+
+	// parse the options
+	options := events.PublishOptions{
+		Timestamp: time.Now(),
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	// encode the message if it's not already encoded
+	var payload []byte
+	if p, ok := msg.([]byte); ok {
+		payload = p
+	} else {
+		p, err := json.Marshal(msg)
+		if err != nil {
+			return events.ErrEncodingMessage
+		}
+		payload = p
+	}
+
+	// construct the event
+	event := &events.Event{
+		ID:        uuid.New().String(),
+		Topic:     topic,
+		Timestamp: options.Timestamp,
+		Metadata:  options.Metadata,
+		Payload:   payload,
+	}
+
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "Error encoding event")
+	}
+
+	r := &kgo.Record{
+		Topic:     topic,
+		Value:     bytes,
+		Timestamp: options.Timestamp,
+	}
+	for k, v := range options.Metadata {
+		if k == s.opts.KeyMetadata {
+			r.Key = []byte(v)
+		}
+		r.Headers = append(r.Headers, kgo.RecordHeader{Key: k, Value: []byte(v)})
+	}
+
+	if err := s.producer.ProduceSync(context.Background(), r).FirstErr(); err != nil {
+		return errors.Wrap(err, "Error publishing message to topic")
+	}
+	return nil
+}
+
+// Consume from a topic.
+func (s *stream) Consume(topic string, opts ...events.ConsumeOption) (<-chan events.Event, error) {
+	// validate the topic
+	if len(topic) == 0 {
+		return nil, events.ErrMissingTopic
+	}
+
+	// parse the options
+	options := events.ConsumeOptions{
+		Group:   uuid.New().String(),
+		AutoAck: true,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	start := kgo.NewOffset().AtEnd()
+	if !options.Offset.IsZero() {
+		start = kgo.NewOffset().AfterMilli(options.Offset.UnixMilli())
+	}
+
+	c, err := kgo.NewClient(append(s.clientOpts(),
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumerGroup(options.Group),
+		kgo.ConsumeResetOffset(start),
+		kgo.DisableAutoCommit(),
+	)...)
+	if err != nil {
+		return nil, errors.Wrap(err, "Error subscribing to topic")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.Lock()
+	s.consumers[c] = cancel
+	s.Unlock()
+
+	channel := make(chan events.Event)
+	go s.consume(ctx, c, options, channel)
+
+	return channel, nil
+}
+
+func (s *stream) consume(ctx context.Context, c *kgo.Client, options events.ConsumeOptions, channel chan events.Event) {
+	log := s.opts.Logger
+
+	commit := func(r *kgo.Record) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return c.CommitRecords(ctx, r)
+	}
+
+	for {
+		fetches := c.PollFetches(ctx)
+		if ctx.Err() != nil || fetches.IsClientClosed() {
+			return
+		}
+		fetches.EachError(func(t string, p int32, err error) {
+			log.Logf(logger.ErrorLevel, "Error fetching %s/%d: %v", t, p, err)
+		})
+
+		iter := fetches.RecordIter()
+		for !iter.Done() {
+			r := iter.Next()
+
+			var evt events.Event
+			if err := json.Unmarshal(r.Value, &evt); err != nil {
+				log.Logf(logger.ErrorLevel, "Error decoding message: %v", err)
+				continue
+			}
+
+			if options.AutoAck {
+				evt.SetAckFunc(func() error { return nil })
+				evt.SetNackFunc(func() error { return nil })
+			} else {
+				evt.SetAckFunc(func() error { return commit(r) })
+				// leaving the offset uncommitted redelivers the event
+				// after a rebalance or restart
+				evt.SetNackFunc(func() error { return nil })
+			}
+
+			// push onto the channel and wait for the consumer to take the
+			// event off before we acknowledge it
+			select {
+			case channel <- evt:
+			case <-ctx.Done():
+				return
+			}
+
+			if !options.AutoAck {
+				continue
+			}
+			if err := commit(r); err != nil {
+				log.Logf(logger.ErrorLevel, "Error acknowledging message: %v", err)
+			}
+		}
+	}
+}
+
+// Close implements io.Closer and closes the producer and every consumer.
+func (s *stream) Close() error {
+	s.Lock()
+	consumers := s.consumers
+	s.consumers = make(map[*kgo.Client]context.CancelFunc)
+	s.Unlock()
+
+	for c, cancel := range consumers {
+		cancel()
+		c.Close()
+	}
+	s.producer.Close()
+	return nil
+}
+
+// Ensure stream implements io.Closer
+var _ io.Closer = (*stream)(nil)
@@ -0,0 +1,131 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kfake"
+	"go-micro.dev/v6/events"
+)
+
+type payload struct {
+	ID int `json:"id"`
+}
+
+func newStream(t *testing.T) events.Stream {
+	t.Helper()
+
+	c, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(4, "orders"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+
+	s, err := NewStream(Address(c.ListenAddrs()...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.(*stream).Close() })
+	return s
+}
+
+func next(t *testing.T, ch <-chan events.Event) events.Event {
+	t.Helper()
+	select {
+	case ev := <-ch:
+		return ev
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return events.Event{}
+}
+
+func TestPublishConsume(t *testing.T) {
+	s := newStream(t)
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		md := map[string]string{"partition_key": "customer-1", "source": "test"}
+		if err := s.Publish("orders", payload{ID: i}, events.WithMetadata(md)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// two groups each see every event, replayed from the offset
+	for _, group := range []string{"billing", "shipping"} {
+		ch, err := s.Consume("orders", events.WithGroup(group), events.WithOffset(start))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 3; i++ {
+			ev := next(t, ch)
+			var p payload
+			if err := ev.Unmarshal(&p); err != nil {
+				t.Fatal(err)
+			}
+			// one key keeps the events in order on one partition
+			if p.ID != i || ev.Topic != "orders" || ev.Metadata["source"] != "test" {
+				t.Fatalf("%s got event %+v with payload %d, want %d", group, ev, p.ID, i)
+			}
+		}
+	}
+
+	if err := s.Publish("", payload{}); err != events.ErrMissingTopic {
+		t.Fatalf("Publish without topic = %v, want ErrMissingTopic", err)
+	}
+}
+
+func TestManualAck(t *testing.T) {
+	s := newStream(t)
+	start := time.Now()
+
+	md := events.WithMetadata(map[string]string{"partition_key": "k"})
+	for i := 0; i < 4; i++ {
+		if err := s.Publish("orders", payload{ID: i}, md); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	consume := func() <-chan events.Event {
+		ch, err := s.Consume("orders", events.WithGroup("manual"), events.WithOffset(start), events.WithAutoAck(false, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ch
+	}
+
+	ch := consume()
+	for i := 0; i < 4; i++ {
+		ev := next(t, ch)
+		if i < 2 {
+			if err := ev.Ack(); err != nil {
+				t.Fatal(err)
+			}
+		} else if err := ev.Nack(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stopConsumers(s.(*stream))
+
+	// the group resumes after the last acked event
+	ch = consume()
+	ev := next(t, ch)
+	var p payload
+	if err := ev.Unmarshal(&p); err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != 2 {
+		t.Fatalf("resumed at event %d, want 2", p.ID)
+	}
+}
+
+// stopConsumers closes the stream's consumers, as a restart would.
+func stopConsumers(s *stream) {
+	s.Lock()
+	defer s.Unlock()
+	for c, cancel := range s.consumers {
+		cancel()
+		c.Close()
+		delete(s.consumers, c)
+	}
+}
@@ -0,0 +1,62 @@
+package kafka
+
+import (
+	"crypto/tls"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+	"go-micro.dev/v6/logger"
+)
+
+// DefaultKeyMetadata is the event metadata key whose value is used as the
+// record key, so events with the same key land on the same partition and
+// are consumed in order.
+var DefaultKeyMetadata = "partition_key"
+
+// Options which are used to configure the kafka stream.
+type Options struct {
+	Address       []string
+	TLSConfig     *tls.Config
+	Logger        logger.Logger
+	KeyMetadata   string
+	ClientOptions []kgo.Opt
+}
+
+// Option is a function which configures options.
+type Option func(o *Options)
+
+// Address sets the seed brokers of the cluster.
+func Address(addrs ...string) Option {
+	return func(o *Options) {
+		o.Address = addrs
+	}
+}
+
+// TLSConfig to use when connecting to the cluster.
+func TLSConfig(t *tls.Config) Option {
+	return func(o *Options) {
+		o.TLSConfig = t
+	}
+}
+
+// Logger sets the underlying logger.
+func Logger(log logger.Logger) Option {
+	return func(o *Options) {
+		o.Logger = log
+	}
+}
+
+// KeyMetadata sets the event metadata key used as the record key.
+// Defaults to DefaultKeyMetadata.
+func KeyMetadata(key string) Option {
+	return func(o *Options) {
+		o.KeyMetadata = key
+	}
+}
+
+// ClientOptions passes options to the underlying franz-go clients, e.g.
+// SASL authentication or producer batching.
+func ClientOptions(opts ...kgo.Opt) Option {
+	return func(o *Options) {
+		o.ClientOptions = append(o.ClientOptions, opts...)
+	}
+}
@@ -0,0 +1,49 @@
+package postgres
+
+import (
+	"time"
+
+	"go-micro.dev/v6/logger"
+)
+
+// Options which are used to configure the postgres stream.
+type Options struct {
+	Address      string
+	Table        string
+	PollInterval time.Duration
+	Logger       logger.Logger
+}
+
+// Option is a function which configures options.
+type Option func(o *Options)
+
+// Address is the connection string of the database, e.g.
+// "postgresql://user@host:5432/db?sslmode=disable".
+func Address(addr string) Option {
+	return func(o *Options) {
+		o.Address = addr
+	}
+}
+
+// Table sets the name of the events table. The consumer group tables
+// are named after it. Defaults to "micro_events".
+func Table(name string) Option {
+	return func(o *Options) {
+		o.Table = name
+	}
+}
+
+// PollInterval sets how often idle consumers check for expired acks, and
+// for new events if a notification was missed. Defaults to 1s.
+func PollInterval(d time.Duration) Option {
+	return func(o *Options) {
+		o.PollInterval = d
+	}
+}
+
+// Logger sets the underlying logger.
+func Logger(log logger.Logger) Option {
+	return func(o *Options) {
+		o.Logger = log
+	}
+}
@@ -0,0 +1,178 @@
+// Package postgres is an events.Stream and events.Store backed by
+// Postgres, for deployments that already run a database and want durable
+// events without running a broker.
+//
+// Consumers of a group claim events with SELECT ... FOR UPDATE SKIP
+// LOCKED, so any number of processes can share a group, and are woken by
+// LISTEN/NOTIFY when an event is published.
+//
+// A sequence hands out seq numbers when events are inserted, not when
+// they commit, so consumers don't follow seq alone. Each event records
+// its transaction id, and consumers take events in transaction order once
+// every older transaction has finished. A long-running transaction
+// anywhere in the database therefore delays delivery until it ends.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"go-micro.dev/v6/events"
+	"go-micro.dev/v6/events/internal/sqlevents"
+	"go-micro.dev/v6/logger"
+)
+
+var (
+	// DefaultAddress is the database used when none is set.
+	DefaultAddress = "postgresql://postgres@localhost:5432/?sslmode=disable"
+	// DefaultTable is the events table used when none is set.
+	DefaultTable = "micro_events"
+	// DefaultPollInterval is how often idle consumers poll.
+	DefaultPollInterval = time.Second
+)
+
+// NewStream returns an initialized postgres stream or an error if the
+// database can't be reached.
+func NewStream(opts ...Option) (events.Stream, error) {
+	return newStream(opts...)
+}
+
+// NewStore returns an events store on the same tables as the stream, so
+// events written to it are also consumed from the stream.
+func NewStore(opts ...Option) (events.Store, error) {
+	return newStream(opts...)
+}
+
+type stream struct {
+	*sqlevents.DB
+	db       *sql.DB
+	listener *pq.Listener
+}
+
+func newStream(opts ...Option) (*stream, error) {
+	options := Options{
+		Address:      DefaultAddress,
+		Table:        DefaultTable,
+		PollInterval: DefaultPollInterval,
+		Logger:       logger.DefaultLogger,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	db, err := sql.Open("postgres", options.Address)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+
+	d, err := sqlevents.New(sqlevents.Config{
+		DB:           db,
+		Dialect:      dialect{channel: options.Table},
+		Table:        options.Table,
+		PollInterval: options.PollInterval,
+		Logger:       options.Logger,
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	log := options.Logger
+	listener := pq.NewListener(options.Address, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Logf(logger.ErrorLevel, "Error listening for events: %v", err)
+		}
+	})
+	if err := listener.Listen(options.Table); err != nil {
+		listener.Close()
+		d.Close()
+		db.Close()
+		return nil, fmt.Errorf("listen for events: %w", err)
+	}
+
+	go func() {
+		// a nil notification follows a reconnect; the consumers' poll
+		// picks up anything published in between
+		for n := range listener.Notify {
+			if n != nil {
+				d.Notify(n.Extra)
+			}
+		}
+	}()
+
+	return &stream{DB: d, db: db, listener: listener}, nil
+}
+
+// Close stops the consumers and closes the database.
+func (s *stream) Close() error {
+	s.DB.Close()
+	s.listener.Close()
+	return s.db.Close()
+}
+
+type dialect struct {
+	channel string
+}
+
+func (dialect) Schema(table string) []string {
+	return []string{
+		`CREATE TABLE IF NOT EXISTS ` + table + ` (
+			seq BIGSERIAL PRIMARY KEY,
+			xid BIGINT NOT NULL DEFAULT txid_current(),
+			id TEXT NOT NULL,
+			topic TEXT NOT NULL,
+			created BIGINT NOT NULL,
+			metadata TEXT NOT NULL,
+			payload BYTEA,
+			expires BIGINT
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + table + `_topic ON ` + table + ` (topic, xid, seq)`,
+		`CREATE TABLE IF NOT EXISTS ` + table + `_groups (
+			name TEXT NOT NULL,
+			topic TEXT NOT NULL,
+			last_xid BIGINT NOT NULL,
+			last_seq BIGINT NOT NULL,
+			PRIMARY KEY (name, topic)
+		)`,
+		`CREATE TABLE IF NOT EXISTS ` + table + `_pending (
+			name TEXT NOT NULL,
+			topic TEXT NOT NULL,
+			seq BIGINT NOT NULL,
+			deliveries INTEGER NOT NULL,
+			deadline BIGINT NOT NULL,
+			PRIMARY KEY (name, topic, seq)
+		)`,
+	}
+}
+
+func (dialect) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (dialect) Lock(skipLocked bool) string {
+	if skipLocked {
+		return " FOR UPDATE SKIP LOCKED"
+	}
+	return " FOR UPDATE"
+}
+
+// Settled holds for events whose transaction is older than every
+// transaction still running, so nothing ordered before them can commit.
+func (dialect) Settled() string {
+	return `xid < txid_snapshot_xmin(txid_current_snapshot())`
+}
+
+// Notified sends the topic on the table's channel. Postgres delivers it
+// when the transaction commits.
+func (d dialect) Notified(ctx context.Context, tx *sql.Tx, topic string) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, d.channel, topic)
+	return err
+}
@@ -0,0 +1,87 @@
+//go:build integration
+// +build integration
+
+package postgres
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go-micro.dev/v6/events"
+	"go-micro.dev/v6/events/eventstest"
+)
+
+func TestStream(t *testing.T) {
+	stream, err := NewStream()
+	if err != nil {
+		t.Fatalf("NewStream: %v", err)
+	}
+	defer stream.(io.Closer).Close()
+
+	eventstest.RunStream(t, stream)
+}
+
+func TestStore(t *testing.T) {
+	store, err := NewStore()
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.(io.Closer).Close()
+
+	eventstest.RunStore(t, store)
+}
+
+// TestOutOfOrderCommit publishes from two transactions that commit in the
+// opposite order to their seqs. The group must get both events.
+func TestOutOfOrderCommit(t *testing.T) {
+	s, err := newStream(PollInterval(50 * time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewStream: %v", err)
+	}
+	defer s.Close()
+
+	topic := uuid.New().String()
+	ch, err := s.Consume(topic, events.WithGroup("billing"))
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+
+	// the first event takes the lower seq but stays uncommitted
+	tx, err := s.db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	first := uuid.New().String()
+	if _, err := tx.Exec(`INSERT INTO `+DefaultTable+` (id, topic, created, metadata, payload) VALUES ($1, $2, $3, 'null', '1')`,
+		first, topic, time.Now().UnixNano()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Publish(topic, 2); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	select {
+	case ev := <-ch:
+		t.Fatalf("got event %s while an earlier one was uncommitted", ev.ID)
+	case <-time.After(500 * time.Millisecond):
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for len(got) < 2 {
+		select {
+		case ev := <-ch:
+			got[ev.ID] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d of 2 events", len(got))
+		}
+	}
+	if !got[first] {
+		t.Fatal("the event committed late was skipped")
+	}
+}
@@ -0,0 +1,72 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go-micro.dev/v6/client"
+	raw "go-micro.dev/v6/codec/bytes"
+	"go-micro.dev/v6/metadata"
+	"go-micro.dev/v6/transport/headers"
+)
+
+// WithVersion returns a context publishing with a version of the topic's
+// schema rather than the latest.
+func WithVersion(ctx context.Context, version int) context.Context {
+	return metadata.Set(ctx, headers.SchemaVersion, strconv.Itoa(version))
+}
+
+// NewClientWrapper returns a client wrapper that validates published
+// messages against the registry, like NewStream, and sets the
+// Micro-Schema-Version header to the version they were checked against.
+// Payloads are checked as JSON, whatever codec the message is sent with.
+func NewClientWrapper(r Registry) client.Wrapper {
+	return func(c client.Client) client.Client {
+		return &clientWrapper{Client: c, registry: r}
+	}
+}
+
+type clientWrapper struct {
+	client.Client
+	registry Registry
+}
+
+func (w *clientWrapper) Publish(ctx context.Context, msg client.Message, opts ...client.PublishOption) error {
+	version := 0
+	if v, ok := metadata.Get(ctx, headers.SchemaVersion); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s header %q", headers.SchemaVersion, v)
+		}
+		version = n
+	}
+
+	sc, err := w.registry.Get(msg.Topic(), version)
+	if errors.Is(err, ErrNotFound) && version == 0 {
+		return w.Client.Publish(ctx, msg, opts...)
+	}
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	switch p := msg.Payload().(type) {
+	case *raw.Frame:
+		payload = p.Data
+	case []byte:
+		payload = p
+	default:
+		if payload, err = json.Marshal(p); err != nil {
+			return fmt.Errorf("encode %s payload: %w", msg.Topic(), err)
+		}
+	}
+	if err := sc.Validate(payload); err != nil {
+		return err
+	}
+
+	ctx = metadata.Set(ctx, headers.SchemaVersion, strconv.Itoa(sc.Version))
+	return w.Client.Publish(ctx, msg, opts...)
+}
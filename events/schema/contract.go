@@ -0,0 +1,136 @@
+package schema
+
+import (
+	"strconv"
+
+	"go-micro.dev/v6/registry"
+	"go-micro.dev/v6/server"
+)
+
+// Contract is an event a service publishes or subscribes to, as
+// advertised in the service registry.
+type Contract struct {
+	Service string `json:"service"`
+	Topic   string `json:"topic"`
+	// Role is "publisher" or "subscriber".
+	Role string `json:"role"`
+	// Version of the topic's schema, or 0 if the service didn't say.
+	Version int `json:"version,omitempty"`
+	// Endpoint advertising the contract. Its Request describes the
+	// payload.
+	Endpoint *registry.Endpoint `json:"endpoint"`
+}
+
+// Endpoint returns the registry endpoint advertising that a service
+// publishes the schema's events.
+func (s *Schema) Endpoint() *registry.Endpoint {
+	return &registry.Endpoint{
+		Name: s.Topic,
+		Request: &registry.Value{
+			Name:   s.Topic,
+			Type:   "object",
+			Values: values(s.Fields),
+		},
+		Metadata: map[string]string{
+			"topic":         s.Topic,
+			"publisher":     "true",
+			MetadataVersion: strconv.Itoa(s.Version),
+		},
+	}
+}
+
+// values describes fields the way the server describes handler types, so
+// the gateways render them the same way.
+func values(fields []*Field) []*registry.Value {
+	var vals []*registry.Value
+	for _, f := range fields {
+		vals = append(vals, value(f))
+	}
+	return vals
+}
+
+func value(f *Field) *registry.Value {
+	v := &registry.Value{Name: f.Name, Type: goType(f)}
+	if f.Type == Object {
+		v.Values = values(f.Fields)
+	}
+	return v
+}
+
+func goType(f *Field) string {
+	switch f.Type {
+	case String:
+		return "string"
+	case Integer:
+		return "int64"
+	case Number:
+		return "float64"
+	case Boolean:
+		return "bool"
+	case Array:
+		if f.Elem != nil {
+			return "[]" + goType(f.Elem)
+		}
+		return "[]interface{}"
+	case Map:
+		if f.Elem != nil {
+			return "map[string]" + goType(f.Elem)
+		}
+		return "map[string]interface{}"
+	case Object:
+		return "object"
+	default:
+		return "interface{}"
+	}
+}
+
+// Advertise returns a server option advertising that the service
+// publishes the schemas' events.
+func Advertise(schemas ...*Schema) server.Option {
+	eps := make([]*registry.Endpoint, 0, len(schemas))
+	for _, s := range schemas {
+		eps = append(eps, s.Endpoint())
+	}
+	return server.Events(eps...)
+}
+
+// SubscriberVersion returns a subscriber option advertising the version
+// of the topic's schema the subscriber reads.
+func SubscriberVersion(version int) server.SubscriberOption {
+	return server.SubscriberMetadata(map[string]string{
+		MetadataVersion: strconv.Itoa(version),
+	})
+}
+
+// Contracts returns the events the services publish and subscribe to.
+func Contracts(services ...*registry.Service) []Contract {
+	var contracts []Contract
+	for _, svc := range services {
+		for _, ep := range svc.Endpoints {
+			topic := ep.Metadata["topic"]
+			if topic == "" {
+				continue
+			}
+
+			role := ""
+			switch {
+			case ep.Metadata["publisher"] == "true":
+				role = "publisher"
+			case ep.Metadata["subscriber"] == "true":
+				role = "subscriber"
+			default:
+				continue
+			}
+
+			version, _ := strconv.Atoi(ep.Metadata[MetadataVersion])
+			contracts = append(contracts, Contract{
+				Service:  svc.Name,
+				Topic:    topic,
+				Role:     role,
+				Version:  version,
+				Endpoint: ep,
+			})
+		}
+	}
+	return contracts
+}
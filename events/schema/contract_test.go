@@ -0,0 +1,110 @@
+package schema
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go-micro.dev/v6/broker"
+	"go-micro.dev/v6/client"
+	"go-micro.dev/v6/metadata"
+	"go-micro.dev/v6/registry"
+	"go-micro.dev/v6/server"
+	"go-micro.dev/v6/store"
+	"go-micro.dev/v6/transport/headers"
+)
+
+type Settled struct {
+	ID string `json:"id"`
+}
+
+func TestContracts(t *testing.T) {
+	b := broker.NewMemoryBroker()
+	if err := b.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer b.Disconnect()
+	reg := registry.NewMemoryRegistry()
+
+	srv := server.NewServer(
+		server.Name("orders"),
+		server.Address("127.0.0.1:0"),
+		server.Broker(b),
+		server.Registry(reg),
+		Advertise(New("orders.created", 2, orderV2{})),
+	)
+	sub := srv.NewSubscriber("payments.settled", func(ctx context.Context, s *Settled) error {
+		return nil
+	}, SubscriberVersion(1))
+	if err := srv.Subscribe(sub); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	services, err := reg.GetService("orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contracts := Contracts(services...)
+	if len(contracts) != 2 {
+		t.Fatalf("got %d contracts, want 2: %+v", len(contracts), contracts)
+	}
+	for _, c := range contracts {
+		switch c.Topic {
+		case "orders.created":
+			if c.Role != "publisher" || c.Version != 2 {
+				t.Errorf("got %+v, want publisher of version 2", c)
+			}
+			if c.Endpoint.Request == nil || len(c.Endpoint.Request.Values) != len(New("", 0, orderV2{}).Fields) {
+				t.Errorf("publisher endpoint should describe the payload, got %+v", c.Endpoint.Request)
+			}
+		case "payments.settled":
+			if c.Role != "subscriber" || c.Version != 1 {
+				t.Errorf("got %+v, want subscriber of version 1", c)
+			}
+		default:
+			t.Errorf("unexpected contract %+v", c)
+		}
+	}
+}
+
+type publishClient struct {
+	client.Client
+	ctx context.Context
+}
+
+func (c *publishClient) Publish(ctx context.Context, msg client.Message, opts ...client.PublishOption) error {
+	c.ctx = ctx
+	return nil
+}
+
+func TestClientWrapper(t *testing.T) {
+	r := NewRegistry(WithStore(store.NewMemoryStore()))
+	if err := r.Register(New("orders", 1, orderV1{})); err != nil {
+		t.Fatal(err)
+	}
+
+	pc := &publishClient{Client: client.NewClient()}
+	c := NewClientWrapper(r)(pc)
+
+	err := c.Publish(context.Background(), c.NewMessage("orders", map[string]string{"id": "1"}))
+	if !errors.Is(err, ErrInvalidPayload) {
+		t.Fatalf("got %v, want ErrInvalidPayload", err)
+	}
+
+	if err := c.Publish(context.Background(), c.NewMessage("orders", &orderV1{ID: "1"})); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := metadata.Get(pc.ctx, headers.SchemaVersion); v != "1" {
+		t.Fatalf("got schema version header %q, want 1", v)
+	}
+
+	ctx := WithVersion(context.Background(), 2)
+	if err := c.Publish(ctx, c.NewMessage("orders", &orderV1{ID: "1"})); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got %v, want ErrNotFound", err)
+	}
+}
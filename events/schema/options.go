@@ -0,0 +1,31 @@
+package schema
+
+import "go-micro.dev/v6/store"
+
+// Options configure a registry.
+type Options struct {
+	// Store the schemas are kept in. Services sharing a store share
+	// their schemas.
+	Store store.Store
+	// Compatibility of new versions, unless their schema sets one.
+	Compatibility Compatibility
+}
+
+// Option sets an option.
+type Option func(o *Options)
+
+// WithStore sets the store the schemas are kept in. Defaults to
+// store.DefaultStore.
+func WithStore(s store.Store) Option {
+	return func(o *Options) {
+		o.Store = s
+	}
+}
+
+// WithCompatibility sets the default compatibility checked when a new
+// version is registered. Defaults to Backward.
+func WithCompatibility(c Compatibility) Option {
+	return func(o *Options) {
+		o.Compatibility = c
+	}
+}
@@ -0,0 +1,144 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go-micro.dev/v6/store"
+)
+
+// Registry holds the versions of each topic's schema.
+type Registry interface {
+	// Register a version of a topic's schema. It must be newer than the
+	// latest, and compatible with it. Registering an existing version
+	// again with the same fields is a no-op, so services can register
+	// their schemas every time they start.
+	Register(s *Schema) error
+	// Get a version of a topic's schema, or the latest if version is 0.
+	Get(topic string, version int) (*Schema, error)
+	// Versions of a topic's schema, oldest first.
+	Versions(topic string) ([]int, error)
+}
+
+// prefix of the store keys, followed by the topic and version
+const prefix = "schema/"
+
+type storeRegistry struct {
+	opts Options
+	sync.Mutex
+}
+
+// NewRegistry returns a registry kept in a store.
+func NewRegistry(opts ...Option) Registry {
+	options := Options{
+		Store:         store.DefaultStore,
+		Compatibility: Backward,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	return &storeRegistry{opts: options}
+}
+
+func key(topic string, version int) string {
+	// zero padded so the keys sort by version
+	return fmt.Sprintf("%s%s/%010d", prefix, topic, version)
+}
+
+func (r *storeRegistry) Register(s *Schema) error {
+	if len(s.Topic) == 0 {
+		return errors.New("missing topic")
+	}
+	if s.Version < 1 {
+		return fmt.Errorf("invalid version %d for %s", s.Version, s.Topic)
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	latest, err := r.Get(s.Topic, 0)
+	switch {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		return err
+	case s.Version <= latest.Version:
+		prev, err := r.Get(s.Topic, s.Version)
+		if err == nil && reflect.DeepEqual(prev.Fields, s.Fields) {
+			return nil
+		}
+		if err == nil {
+			return fmt.Errorf("%s version %d is already registered with different fields", s.Topic, s.Version)
+		}
+		return fmt.Errorf("%s version %d is older than the latest, %d", s.Topic, s.Version, latest.Version)
+	default:
+		c := s.Compatibility
+		if c == "" {
+			c = latest.Compatibility
+		}
+		if c == "" {
+			c = r.opts.Compatibility
+		}
+		if err := Check(latest, s, c); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return r.opts.Store.Write(&store.Record{Key: key(s.Topic, s.Version), Value: b})
+}
+
+func (r *storeRegistry) Get(topic string, version int) (*Schema, error) {
+	if version == 0 {
+		versions, err := r.Versions(topic)
+		if err != nil {
+			return nil, err
+		}
+		version = versions[len(versions)-1]
+	}
+
+	recs, err := r.opts.Store.Read(key(topic, version))
+	if errors.Is(err, store.ErrNotFound) || (err == nil && len(recs) == 0) {
+		return nil, fmt.Errorf("%w: %s version %d", ErrNotFound, topic, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s Schema
+	if err := json.Unmarshal(recs[0].Value, &s); err != nil {
+		return nil, fmt.Errorf("decode %s version %d: %w", topic, version, err)
+	}
+	return &s, nil
+}
+
+func (r *storeRegistry) Versions(topic string) ([]int, error) {
+	p := prefix + topic + "/"
+	keys, err := r.opts.Store.List(store.ListPrefix(p))
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []int
+	for _, k := range keys {
+		v, err := strconv.Atoi(strings.TrimPrefix(k, p))
+		if err != nil {
+			// a topic nested under this one
+			continue
+		}
+		versions = append(versions, v)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, topic)
+	}
+	sort.Ints(versions)
+	return versions, nil
+}
@@ -0,0 +1,377 @@
+// Package schema is a registry of versioned event contracts, keyed by
+// topic. A Schema describes the JSON shape of a topic's payload and is
+// derived from a Go type with New. Registering a new version checks it is
+// compatible with the previous one, and NewStream and NewClientWrapper
+// reject payloads that don't match the registered schema, so a producer
+// changing an event's shape fails at startup or on publish rather than
+// breaking consumers at runtime.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when a topic or version has no schema.
+	ErrNotFound = errors.New("schema not found")
+	// ErrIncompatible is returned when a new version breaks compatibility
+	// with the previous one.
+	ErrIncompatible = errors.New("incompatible schema")
+	// ErrInvalidPayload is returned when a payload doesn't match its
+	// schema.
+	ErrInvalidPayload = errors.New("payload does not match schema")
+)
+
+// Compatibility is the rule a new version of a schema is checked against.
+type Compatibility string
+
+const (
+	// Backward compatible versions can read events written with the
+	// previous version: fields may be removed, and fields added only if
+	// optional. Consumers upgrade first.
+	Backward Compatibility = "backward"
+	// Forward compatible versions write events the previous version can
+	// read: fields may be added, and only optional fields removed.
+	// Producers upgrade first.
+	Forward Compatibility = "forward"
+	// Full compatibility is both backward and forward.
+	Full Compatibility = "full"
+	// None disables the check.
+	None Compatibility = "none"
+)
+
+// Field types, as they appear in JSON.
+const (
+	String  = "string"
+	Integer = "integer"
+	Number  = "number"
+	Boolean = "boolean"
+	Object  = "object"
+	Array   = "array"
+	Map     = "map"
+	Any     = "any"
+)
+
+// Schema is one version of the contract for a topic's events.
+type Schema struct {
+	Topic   string `json:"topic"`
+	Version int    `json:"version"`
+	// Compatibility checked when the next version is registered. Defaults
+	// to the registry's.
+	Compatibility Compatibility `json:"compatibility,omitempty"`
+	// Fields of the payload, which is a JSON object.
+	Fields []*Field `json:"fields"`
+}
+
+// Field is a field of an object.
+type Field struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required,omitempty"`
+	// Fields of an object.
+	Fields []*Field `json:"fields,omitempty"`
+	// Elem is the element of an array or the value of a map.
+	Elem *Field `json:"elem,omitempty"`
+}
+
+// New returns the schema of v, a struct or pointer to one, as it is
+// encoded to JSON. Fields are named by their json tags. A field is
+// required unless it is tagged omitempty or can encode as null (a
+// pointer, slice, map or interface), so the zero value of v always
+// validates and the fields of generated protobuf messages are all
+// optional.
+func New(topic string, version int, v interface{}) *Schema {
+	s := &Schema{Topic: topic, Version: version}
+	if f := fieldOf(reflect.TypeOf(v), map[reflect.Type]bool{}); f.Type == Object {
+		s.Fields = f.Fields
+	}
+	return s
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+func fieldOf(t reflect.Type, seen map[reflect.Type]bool) *Field {
+	if t == nil {
+		return &Field{Type: Any}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Field{Type: String}
+	case t.Implements(marshalerType), reflect.PtrTo(t).Implements(marshalerType):
+		// encodes itself, so its shape isn't known
+		return &Field{Type: Any}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Field{Type: String}
+	case reflect.Bool:
+		return &Field{Type: Boolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Field{Type: Integer}
+	case reflect.Float32, reflect.Float64:
+		return &Field{Type: Number}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// base64
+			return &Field{Type: String}
+		}
+		return &Field{Type: Array, Elem: fieldOf(t.Elem(), seen)}
+	case reflect.Map:
+		return &Field{Type: Map, Elem: fieldOf(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			// recursive type
+			return &Field{Type: Object}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		return &Field{Type: Object, Fields: structFields(t, seen)}
+	default:
+		return &Field{Type: Any}
+	}
+}
+
+func structFields(t reflect.Type, seen map[reflect.Type]bool) []*Field {
+	var fields []*Field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// embedded structs are flattened, as encoding/json does
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				fields = append(fields, structFields(ft, seen)...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		f := fieldOf(sf.Type, seen)
+		f.Name = name
+		f.Required = !nullable(sf.Type) && !strings.Contains(opts, "omitempty")
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// nullable reports whether a value of t can encode as null, so the field
+// can't be required.
+func nullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	}
+	return false
+}
+
+// Validate checks a JSON payload against the schema. Required fields must
+// be present and not null, and fields present must have the right type.
+// Fields the schema doesn't know are allowed.
+func (s *Schema) Validate(payload []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+	if err := validate(&Field{Type: Object, Fields: s.Fields}, v, ""); err != nil {
+		return fmt.Errorf("%w: %s version %d: %v", ErrInvalidPayload, s.Topic, s.Version, err)
+	}
+	return nil
+}
+
+func validate(f *Field, v interface{}, path string) error {
+	if v == nil {
+		return nil
+	}
+
+	switch f.Type {
+	case String:
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("%s: want string, got %s", name(path), jsonType(v))
+		}
+	case Integer:
+		n, ok := v.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s: want integer, got %s", name(path), jsonType(v))
+		}
+		if _, err := n.Int64(); err != nil {
+			if _, err := strconv.ParseUint(n.String(), 10, 64); err != nil {
+				return fmt.Errorf("%s: want integer, got %s", name(path), n)
+			}
+		}
+	case Number:
+		if _, ok := v.(json.Number); !ok {
+			return fmt.Errorf("%s: want number, got %s", name(path), jsonType(v))
+		}
+	case Boolean:
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: want boolean, got %s", name(path), jsonType(v))
+		}
+	case Array:
+		a, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: want array, got %s", name(path), jsonType(v))
+		}
+		if f.Elem == nil {
+			return nil
+		}
+		for i, e := range a {
+			if err := validate(f.Elem, e, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case Map, Object:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: want object, got %s", name(path), jsonType(v))
+		}
+		if f.Type == Map {
+			if f.Elem == nil {
+				return nil
+			}
+			for k, e := range m {
+				if err := validate(f.Elem, e, join(path, k)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		for _, sub := range f.Fields {
+			e, ok := m[sub.Name]
+			if sub.Required && (!ok || e == nil) {
+				return fmt.Errorf("%s: required field missing", join(path, sub.Name))
+			}
+			if err := validate(sub, e, join(path, sub.Name)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func name(path string) string {
+	if path == "" {
+		return "payload"
+	}
+	return path
+}
+
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return String
+	case json.Number:
+		return Number
+	case bool:
+		return Boolean
+	case []interface{}:
+		return Array
+	case map[string]interface{}:
+		return Object
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// Check returns an error wrapping ErrIncompatible if next breaks the
+// compatibility rule with prev.
+func Check(prev, next *Schema, c Compatibility) error {
+	var problems []string
+	switch c {
+	case Backward:
+		problems = compare(next.Fields, prev.Fields, "")
+	case Forward:
+		problems = compare(prev.Fields, next.Fields, "")
+	case Full:
+		problems = append(compare(next.Fields, prev.Fields, ""), compare(prev.Fields, next.Fields, "")...)
+	case None, "":
+		return nil
+	default:
+		return fmt.Errorf("unknown compatibility %q", c)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s version %d is not %s compatible with version %d: %s",
+		ErrIncompatible, next.Topic, next.Version, c, prev.Version, strings.Join(problems, "; "))
+}
+
+// compare lists what stops a reader using one set of fields from reading
+// payloads written with another.
+func compare(reader, writer []*Field, path string) []string {
+	written := make(map[string]*Field, len(writer))
+	for _, f := range writer {
+		written[f.Name] = f
+	}
+
+	var problems []string
+	for _, r := range reader {
+		p := join(path, r.Name)
+		w, ok := written[r.Name]
+		switch {
+		case !ok && r.Required:
+			problems = append(problems, p+" is required but not written")
+		case !ok:
+		case r.Required && !w.Required:
+			problems = append(problems, p+" is required but optional when written")
+		default:
+			problems = append(problems, compareType(r, w, p)...)
+		}
+	}
+	return problems
+}
+
+func compareType(r, w *Field, path string) []string {
+	switch {
+	case r.Type == Any:
+		return nil
+	case r.Type == Number && w.Type == Integer:
+		// integers are numbers
+		return nil
+	case r.Type != w.Type:
+		return []string{fmt.Sprintf("%s changed type from %s to %s", path, w.Type, r.Type)}
+	case r.Type == Object:
+		return compare(r.Fields, w.Fields, path)
+	case r.Elem != nil && w.Elem != nil:
+		return compareType(r.Elem, w.Elem, path+"[]")
+	}
+	return nil
+}
@@ -0,0 +1,304 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/events"
+	"go-micro.dev/v6/store"
+)
+
+type shipment struct {
+	ID      string                 `json:"id"`
+	Parcels []string               `json:"parcels"`
+	Tags    map[string]string      `json:"tags"`
+	Extra   interface{}            `json:"extra"`
+	Raw     json.RawMessage        `json:"raw"`
+	Data    []byte                 `json:"data"`
+	Meta    map[string]interface{} `json:"meta,omitempty"`
+}
+
+type address struct {
+	Street string `json:"street"`
+	City   string `json:"city,omitempty"`
+}
+
+type orderV1 struct {
+	ID      string            `json:"id"`
+	Amount  int64             `json:"amount"`
+	Items   []string          `json:"items,omitempty"`
+	Ship    address           `json:"ship"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Created time.Time         `json:"created"`
+	Note    *string           `json:"note"`
+	secret  string
+}
+
+// orderV2 adds an optional field
+type orderV2 struct {
+	orderV1
+	Coupon string `json:"coupon,omitempty"`
+}
+
+// orderV3 adds a required field
+type orderV3 struct {
+	orderV1
+	Currency string `json:"currency"`
+}
+
+// orderV4 changes a type
+type orderV4 struct {
+	ID     string  `json:"id"`
+	Amount float64 `json:"amount"`
+}
+
+func field(fields []*Field, name string) *Field {
+	for _, f := range fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func TestNew(t *testing.T) {
+	s := New("orders", 1, &orderV1{})
+
+	tests := []struct {
+		name     string
+		typ      string
+		required bool
+	}{
+		{"id", String, true},
+		{"amount", Integer, true},
+		{"items", Array, false},
+		{"ship", Object, true},
+		{"labels", Map, false},
+		{"created", String, true},
+		{"note", String, false},
+	}
+	if len(s.Fields) != len(tests) {
+		t.Fatalf("got %d fields, want %d", len(s.Fields), len(tests))
+	}
+	for _, tt := range tests {
+		f := field(s.Fields, tt.name)
+		if f == nil {
+			t.Fatalf("missing field %s", tt.name)
+		}
+		if f.Type != tt.typ || f.Required != tt.required {
+			t.Errorf("%s: got %s required=%v, want %s required=%v", tt.name, f.Type, f.Required, tt.typ, tt.required)
+		}
+	}
+
+	if f := field(field(s.Fields, "ship").Fields, "city"); f == nil || f.Required {
+		t.Errorf("ship.city should be an optional field, got %+v", f)
+	}
+	if f := field(s.Fields, "items"); f.Elem == nil || f.Elem.Type != String {
+		t.Errorf("items should be an array of strings, got %+v", f.Elem)
+	}
+
+	// embedded structs are flattened
+	if f := field(New("orders", 2, orderV2{}).Fields, "id"); f == nil {
+		t.Error("embedded fields should be flattened")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := New("orders", 1, &orderV1{})
+
+	tests := []struct {
+		payload string
+		valid   bool
+	}{
+		{`{"id":"1","amount":5,"ship":{"street":"a"},"created":"2024-01-01T00:00:00Z"}`, true},
+		{`{"id":"1","amount":5,"ship":{"street":"a"},"created":"x","items":["a"],"labels":{"k":"v"},"extra":true}`, true},
+		{`{"id":"1","amount":5,"ship":{"street":"a"},"created":"x","note":null}`, true},
+		{`{"amount":5,"ship":{"street":"a"},"created":"x"}`, false},
+		{`{"id":"1","amount":5.5,"ship":{"street":"a"},"created":"x"}`, false},
+		{`{"id":"1","amount":"5","ship":{"street":"a"},"created":"x"}`, false},
+		{`{"id":"1","amount":5,"ship":{},"created":"x"}`, false},
+		{`{"id":"1","amount":5,"ship":{"street":"a"},"created":"x","items":[1]}`, false},
+		{`{"id":"1","amount":5,"ship":{"street":"a"},"created":"x","labels":{"k":1}}`, false},
+		{`[]`, false},
+		{`not json`, false},
+	}
+	for _, tt := range tests {
+		err := s.Validate([]byte(tt.payload))
+		if tt.valid && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.payload, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidPayload) {
+			t.Errorf("%s: got %v, want ErrInvalidPayload", tt.payload, err)
+		}
+	}
+}
+
+func TestValidateZeroValue(t *testing.T) {
+	for _, v := range []interface{}{shipment{}, orderV1{}, orderV3{}} {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := New("t", 1, v).Validate(b); err != nil {
+			t.Errorf("%T: zero value %s does not validate: %v", v, b, err)
+		}
+	}
+
+	s := New("shipments", 1, shipment{})
+	for _, name := range []string{"parcels", "tags", "extra", "raw", "data"} {
+		if f := field(s.Fields, name); f == nil || f.Required {
+			t.Errorf("%s should be optional, got %+v", name, f)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	v1 := New("orders", 1, orderV1{})
+	optional := New("orders", 2, orderV2{})
+	required := New("orders", 2, orderV3{})
+	retyped := New("orders", 2, orderV4{})
+	removed := New("orders", 2, struct {
+		ID string `json:"id"`
+	}{})
+
+	tests := []struct {
+		name string
+		next *Schema
+		c    Compatibility
+		ok   bool
+	}{
+		{"backward add optional", optional, Backward, true},
+		{"backward add required", required, Backward, false},
+		{"backward remove", removed, Backward, true},
+		{"forward add required", required, Forward, true},
+		{"forward remove required", removed, Forward, false},
+		{"full add optional", optional, Full, true},
+		{"full add required", required, Full, false},
+		{"backward widen integer", retyped, Backward, true},
+		{"forward widen integer", retyped, Forward, false},
+		{"none", retyped, None, true},
+	}
+	for _, tt := range tests {
+		err := Check(v1, tt.next, tt.c)
+		if tt.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		if !tt.ok && !errors.Is(err, ErrIncompatible) {
+			t.Errorf("%s: got %v, want ErrIncompatible", tt.name, err)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry(WithStore(store.NewMemoryStore()))
+
+	if _, err := r.Get("orders", 0); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got %v, want ErrNotFound", err)
+	}
+
+	if err := r.Register(New("orders", 1, orderV1{})); err != nil {
+		t.Fatal(err)
+	}
+	// registering the same version again is a no-op
+	if err := r.Register(New("orders", 1, orderV1{})); err != nil {
+		t.Fatalf("re-registering: %v", err)
+	}
+	if err := r.Register(New("orders", 1, orderV2{})); err == nil {
+		t.Fatal("changing a registered version should fail")
+	}
+	if err := r.Register(New("orders", 2, orderV3{})); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("got %v, want ErrIncompatible", err)
+	}
+	if err := r.Register(New("orders", 2, orderV2{})); err != nil {
+		t.Fatal(err)
+	}
+
+	// the compatibility of a version carries over to the next
+	full := New("payments", 1, orderV1{})
+	full.Compatibility = Full
+	if err := r.Register(full); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(New("payments", 2, orderV3{})); !errors.Is(err, ErrIncompatible) {
+		t.Fatalf("got %v, want ErrIncompatible", err)
+	}
+
+	versions, err := r.Versions("orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0] != 1 || versions[1] != 2 {
+		t.Fatalf("got versions %v, want [1 2]", versions)
+	}
+
+	latest, err := r.Get("orders", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.Version != 2 || field(latest.Fields, "coupon") == nil {
+		t.Fatalf("got %+v, want version 2", latest)
+	}
+}
+
+func TestStream(t *testing.T) {
+	r := NewRegistry(WithStore(store.NewMemoryStore()))
+	if err := r.Register(New("orders", 1, orderV1{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(New("orders", 2, orderV2{})); err != nil {
+		t.Fatal(err)
+	}
+
+	mem, err := events.NewStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := NewStream(mem, r)
+
+	ch, err := stream.Consume("orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := stream.Publish("orders", map[string]interface{}{"id": "1"}); !errors.Is(err, ErrInvalidPayload) {
+		t.Fatalf("got %v, want ErrInvalidPayload", err)
+	}
+	if err := stream.Publish("orders", orderV1{ID: "1"}, events.WithMetadata(map[string]string{"foo": "bar"})); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case ev := <-ch:
+		if ev.Metadata[MetadataVersion] != "2" || ev.Metadata["foo"] != "bar" {
+			t.Fatalf("got metadata %v, want schema version 2 and foo", ev.Metadata)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event not received")
+	}
+
+	// an older version can still be published
+	md := map[string]string{MetadataVersion: "1"}
+	if err := stream.Publish("orders", orderV1{ID: "1"}, events.WithMetadata(md)); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-ch:
+		if ev.Metadata[MetadataVersion] != "1" {
+			t.Fatalf("got metadata %v, want schema version 1", ev.Metadata)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event not received")
+	}
+
+	md = map[string]string{MetadataVersion: "3"}
+	if err := stream.Publish("orders", orderV1{ID: "1"}, events.WithMetadata(md)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("got %v, want ErrNotFound", err)
+	}
+
+	// topics without a schema pass through
+	if err := stream.Publish("other", "anything"); err != nil {
+		t.Fatal(err)
+	}
+}
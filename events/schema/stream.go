@@ -0,0 +1,72 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go-micro.dev/v6/events"
+)
+
+// MetadataVersion is the event metadata key holding the version of the
+// schema an event was published with.
+const MetadataVersion = "schema_version"
+
+type stream struct {
+	events.Stream
+	registry Registry
+}
+
+// NewStream wraps an events stream to validate what is published against
+// the registry. An event is checked against the version in its
+// MetadataVersion metadata, or the topic's latest schema, which is then
+// recorded in the metadata. Topics with no schema are published as is.
+// To enforce schemas on events.Publish, wrap the default stream:
+//
+//	events.DefaultStream = schema.NewStream(events.DefaultStream, reg)
+func NewStream(s events.Stream, r Registry) events.Stream {
+	return &stream{Stream: s, registry: r}
+}
+
+func (s *stream) Publish(topic string, msg interface{}, opts ...events.PublishOption) error {
+	var options events.PublishOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	version := 0
+	if v, ok := options.Metadata[MetadataVersion]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s metadata %q", MetadataVersion, v)
+		}
+		version = n
+	}
+
+	sc, err := s.registry.Get(topic, version)
+	if errors.Is(err, ErrNotFound) && version == 0 {
+		return s.Stream.Publish(topic, msg, opts...)
+	}
+	if err != nil {
+		return err
+	}
+
+	payload, ok := msg.([]byte)
+	if !ok {
+		if payload, err = json.Marshal(msg); err != nil {
+			return events.ErrEncodingMessage
+		}
+	}
+	if err := sc.Validate(payload); err != nil {
+		return err
+	}
+
+	md := make(map[string]string, len(options.Metadata)+1)
+	for k, v := range options.Metadata {
+		md[k] = v
+	}
+	md[MetadataVersion] = strconv.Itoa(sc.Version)
+
+	return s.Stream.Publish(topic, msg, append(opts, events.WithMetadata(md))...)
+}
@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"time"
+
+	"go-micro.dev/v6/logger"
+)
+
+// Options which are used to configure the sqlite stream.
+type Options struct {
+	Path         string
+	Table        string
+	PollInterval time.Duration
+	Logger       logger.Logger
+}
+
+// Option is a function which configures options.
+type Option func(o *Options)
+
+// Path of the database file, or a go-sqlite3 DSN. Defaults to
+// "events.db".
+func Path(p string) Option {
+	return func(o *Options) {
+		o.Path = p
+	}
+}
+
+// Table sets the name of the events table. The consumer group tables
+// are named after it. Defaults to "micro_events".
+func Table(name string) Option {
+	return func(o *Options) {
+		o.Table = name
+	}
+}
+
+// PollInterval sets how often idle consumers check for events written by
+// other processes and for expired acks. Defaults to 250ms.
+func PollInterval(d time.Duration) Option {
+	return func(o *Options) {
+		o.PollInterval = d
+	}
+}
+
+// Logger sets the underlying logger.
+func Logger(log logger.Logger) Option {
+	return func(o *Options) {
+		o.Logger = log
+	}
+}
@@ -0,0 +1,151 @@
+// Package sqlite is an events.Stream and events.Store backed by a SQLite
+// database, for single-node deployments that want durable events without
+// running a broker.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"go-micro.dev/v6/events"
+	"go-micro.dev/v6/events/internal/sqlevents"
+	"go-micro.dev/v6/logger"
+)
+
+var (
+	// DefaultPath is the database file used when none is set.
+	DefaultPath = "events.db"
+	// DefaultTable is the events table used when none is set.
+	DefaultTable = "micro_events"
+	// DefaultPollInterval is how often idle consumers poll.
+	DefaultPollInterval = 250 * time.Millisecond
+)
+
+// NewStream returns an initialized sqlite stream or an error if the
+// database can't be opened.
+func NewStream(opts ...Option) (events.Stream, error) {
+	return newStream(opts...)
+}
+
+// NewStore returns an events store on the same tables as the stream, so
+// events written to it are also consumed from the stream.
+func NewStore(opts ...Option) (events.Store, error) {
+	return newStream(opts...)
+}
+
+type stream struct {
+	*sqlevents.DB
+	db *sql.DB
+}
+
+func newStream(opts ...Option) (*stream, error) {
+	options := Options{
+		Path:         DefaultPath,
+		Table:        DefaultTable,
+		PollInterval: DefaultPollInterval,
+		Logger:       logger.DefaultLogger,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	// claims take the write lock up front so that concurrent consumers
+	// wait on it rather than fail to upgrade
+	dsn := options.Path
+	if strings.Contains(dsn, "?") {
+		dsn += "&_txlock=immediate&_busy_timeout=5000"
+	} else {
+		dsn += "?_txlock=immediate&_busy_timeout=5000"
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open %q: %w", options.Path, err)
+	}
+	// SQLite has one writer; a single connection queues them in process
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open %q: %w", options.Path, err)
+	}
+
+	d, err := sqlevents.New(sqlevents.Config{
+		DB:           db,
+		Dialect:      dialect{},
+		Table:        options.Table,
+		PollInterval: options.PollInterval,
+		Logger:       options.Logger,
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &stream{DB: d, db: db}, nil
+}
+
+// Close stops the consumers and closes the database.
+func (s *stream) Close() error {
+	s.DB.Close()
+	return s.db.Close()
+}
+
+type dialect struct{}
+
+func (dialect) Schema(table string) []string {
+	return []string{
+		`CREATE TABLE IF NOT EXISTS ` + table + ` (
+			seq INTEGER PRIMARY KEY AUTOINCREMENT,
+			xid INTEGER NOT NULL DEFAULT 0,
+			id TEXT NOT NULL,
+			topic TEXT NOT NULL,
+			created INTEGER NOT NULL,
+			metadata TEXT NOT NULL,
+			payload BLOB,
+			expires INTEGER
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + table + `_topic ON ` + table + ` (topic, xid, seq)`,
+		`CREATE TABLE IF NOT EXISTS ` + table + `_groups (
+			name TEXT NOT NULL,
+			topic TEXT NOT NULL,
+			last_xid INTEGER NOT NULL,
+			last_seq INTEGER NOT NULL,
+			PRIMARY KEY (name, topic)
+		)`,
+		`CREATE TABLE IF NOT EXISTS ` + table + `_pending (
+			name TEXT NOT NULL,
+			topic TEXT NOT NULL,
+			seq INTEGER NOT NULL,
+			deliveries INTEGER NOT NULL,
+			deadline INTEGER NOT NULL,
+			PRIMARY KEY (name, topic, seq)
+		)`,
+	}
+}
+
+func (dialect) Placeholder(n int) string {
+	return "?"
+}
+
+// Lock is empty: transactions take the database write lock when they
+// begin, so a claim already has the group to itself.
+func (dialect) Lock(skipLocked bool) string {
+	return ""
+}
+
+// Settled is empty: a writer holds the database write lock from insert to
+// commit, so events commit in seq order and xid stays 0.
+func (dialect) Settled() string {
+	return ""
+}
+
+// Notified is a no-op. Consumers in this process are woken directly, and
+// those in other processes poll.
+func (dialect) Notified(ctx context.Context, tx *sql.Tx, topic string) error {
+	return nil
+}
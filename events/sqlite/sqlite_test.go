@@ -0,0 +1,173 @@
+package sqlite
+
+import (
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/events"
+	"go-micro.dev/v6/events/eventstest"
+)
+
+func TestStream(t *testing.T) {
+	stream, err := NewStream(Path(filepath.Join(t.TempDir(), "events.db")))
+	if err != nil {
+		t.Fatalf("NewStream: %v", err)
+	}
+	defer stream.(io.Closer).Close()
+
+	eventstest.RunStream(t, stream)
+}
+
+func TestStore(t *testing.T) {
+	store, err := NewStore(Path(filepath.Join(t.TempDir(), "events.db")))
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.(io.Closer).Close()
+
+	eventstest.RunStore(t, store)
+}
+
+func TestSharedGroup(t *testing.T) {
+	stream, err := NewStream(Path(filepath.Join(t.TempDir(), "events.db")))
+	if err != nil {
+		t.Fatalf("NewStream: %v", err)
+	}
+	defer stream.(io.Closer).Close()
+
+	var chans []<-chan events.Event
+	for i := 0; i < 3; i++ {
+		ch, err := stream.Consume("shared", events.WithGroup("workers"))
+		if err != nil {
+			t.Fatalf("Consume: %v", err)
+		}
+		chans = append(chans, ch)
+	}
+
+	const n = 30
+	for i := 0; i < n; i++ {
+		if err := stream.Publish("shared", i); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	seen := make(map[string]int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, ch := range chans {
+		wg.Add(1)
+		go func(ch <-chan events.Event) {
+			defer wg.Done()
+			for {
+				select {
+				case ev := <-ch:
+					mu.Lock()
+					seen[ev.ID]++
+					mu.Unlock()
+				case <-time.After(time.Second):
+					return
+				}
+			}
+		}(ch)
+	}
+	wg.Wait()
+
+	if len(seen) != n {
+		t.Fatalf("got %d distinct events, want %d", len(seen), n)
+	}
+	for id, count := range seen {
+		if count != 1 {
+			t.Errorf("event %s delivered %d times", id, count)
+		}
+	}
+}
+
+func TestResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.db")
+
+	stream, err := NewStream(Path(path))
+	if err != nil {
+		t.Fatalf("NewStream: %v", err)
+	}
+	ch, err := stream.Consume("orders", events.WithGroup("billing"), events.WithAutoAck(false, time.Second))
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := stream.Publish("orders", i); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+
+	// ack the first and leave the second unacked when the process stops
+	ev := <-ch
+	first := ev.ID
+	ev.Ack()
+	ev = <-ch
+	second := ev.ID
+	stream.(io.Closer).Close()
+
+	stream, err = NewStream(Path(path))
+	if err != nil {
+		t.Fatalf("NewStream: %v", err)
+	}
+	defer stream.(io.Closer).Close()
+
+	ch, err = stream.Consume("orders", events.WithGroup("billing"), events.WithAutoAck(false, time.Second))
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	select {
+	case ev := <-ch:
+		if ev.ID == first {
+			t.Fatal("acked event was redelivered")
+		}
+		if ev.ID != second {
+			t.Fatalf("got event %s, want the unacked %s", ev.ID, second)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("unacked event was not redelivered")
+	}
+}
+
+func TestRewind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.db")
+
+	stream, err := NewStream(Path(path))
+	if err != nil {
+		t.Fatalf("NewStream: %v", err)
+	}
+	ch, err := stream.Consume("orders", events.WithGroup("billing"))
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := stream.Publish("orders", i); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+		<-ch
+	}
+	stream.(io.Closer).Close()
+
+	stream, err = NewStream(Path(path))
+	if err != nil {
+		t.Fatalf("NewStream: %v", err)
+	}
+	defer stream.(io.Closer).Close()
+
+	// an offset moves the existing group back to replay from the start
+	ch, err = stream.Consume("orders", events.WithGroup("billing"), events.WithOffset(time.Unix(1, 0)))
+	if err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-ch:
+		case <-time.After(3 * time.Second):
+			t.Fatalf("replayed %d of 2 events", i)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"go-micro.dev/v6/ai"
+	"go-micro.dev/v6/prompt"
 )
 
 // AnalyzeOptions configures Analyze.
@@ -31,6 +32,25 @@ func AnalyzeMaxFeedbackSamples(n int) AnalyzeOption {
 // worst to best so an agent, CLI, or human can pick the first improvement to try.
 type Report struct {
 	Candidates []Candidate `json:"candidates"`
+	// Prompts compares the registry prompt versions the runs used (see
+	// PromptFrom), by prompt name then version. Runs without a registry
+	// prompt aren't counted.
+	Prompts []PromptStats `json:"prompts,omitempty"`
+}
+
+// PromptStats is how the runs that used one prompt version did. A run
+// fails if it failed, a step errored, or a step failed verification;
+// PassRate is the share of graded steps that passed, or of runs that
+// didn't fail when no step was graded.
+type PromptStats struct {
+	Prompt     string        `json:"prompt"`
+	Version    int           `json:"version"`
+	Runs       int           `json:"runs"`
+	Failures   int           `json:"failures"`
+	PassRate   float64       `json:"pass_rate"`
+	ErrorRate  float64       `json:"error_rate"`
+	P50Latency time.Duration `json:"p50_latency"`
+	P95Latency time.Duration `json:"p95_latency"`
 }
 
 // Candidate identifies one underperforming flow step and the trace evidence that
@@ -99,7 +119,7 @@ func Analyze(runs []Run, opts ...AnalyzeOption) Report {
 		}
 	}
 
-	report := Report{}
+	report := Report{Prompts: promptStats(runs)}
 	for step, s := range stats {
 		if s.runs == 0 {
 			continue
@@ -143,6 +163,74 @@ type stepStats struct {
 	latencies                                    []time.Duration
 }
 
+type promptKey struct {
+	name    string
+	version int
+}
+
+type promptRuns struct {
+	runs, failures, errored, graded, passed int
+	latencies                               []time.Duration
+}
+
+func promptStats(runs []Run) []PromptStats {
+	byVersion := map[promptKey]*promptRuns{}
+	for _, run := range runs {
+		if run.Prompt == "" {
+			continue
+		}
+		k := promptKey{run.Prompt, run.PromptVersion}
+		p := byVersion[k]
+		if p == nil {
+			p = &promptRuns{}
+			byVersion[k] = p
+		}
+		p.runs++
+		failed, errored := run.Status == "failed", run.Status == "failed"
+		for _, step := range run.Steps {
+			if step.Status == "failed" || step.Error != "" {
+				failed, errored = true, true
+			}
+			if passed, _, ok := verificationFields(step.Result); ok {
+				p.graded++
+				if passed {
+					p.passed++
+				} else {
+					failed = true
+				}
+			}
+		}
+		if failed {
+			p.failures++
+		}
+		if errored {
+			p.errored++
+		}
+		if !run.Started.IsZero() && !run.Updated.IsZero() {
+			p.latencies = append(p.latencies, run.Updated.Sub(run.Started))
+		}
+	}
+	out := make([]PromptStats, 0, len(byVersion))
+	for k, p := range byVersion {
+		passRate := float64(p.runs-p.failures) / float64(p.runs)
+		if p.graded > 0 {
+			passRate = float64(p.passed) / float64(p.graded)
+		}
+		out = append(out, PromptStats{
+			Prompt: k.name, Version: k.version, Runs: p.runs, Failures: p.failures,
+			PassRate: passRate, ErrorRate: float64(p.errored) / float64(p.runs),
+			P50Latency: percentile(p.latencies, 0.50), P95Latency: percentile(p.latencies, 0.95),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Prompt == out[j].Prompt {
+			return out[i].Version < out[j].Version
+		}
+		return out[i].Prompt < out[j].Prompt
+	})
+	return out
+}
+
 // PromptOptimizer proposes prompt improvements for a candidate without mutating
 // the source flow. Applying the returned prompt stays explicitly gated by the caller.
 type PromptOptimizer struct{ model ai.Model }
@@ -173,6 +261,24 @@ func (o *PromptOptimizer) OptimizePrompt(ctx context.Context, candidate Candidat
 	return proposal, nil
 }
 
+// Propose asks the model to revise the latest version of the registry
+// prompt name for candidate and pushes the revision as a new, unlabeled
+// version. Nothing serves it until it's labeled or rolled out, so trying
+// it stays an explicit step: label it "canary" and give the label a
+// rollout to A/B it against "prod".
+func (o *PromptOptimizer) Propose(ctx context.Context, reg *prompt.Registry, name string, candidate Candidate) (*prompt.Version, error) {
+	current, err := reg.Get(name, 0)
+	if err != nil {
+		return nil, err
+	}
+	proposal, err := o.OptimizePrompt(ctx, candidate, current.Text)
+	if err != nil {
+		return nil, err
+	}
+	note := fmt.Sprintf("proposed from %s for step %s (%s %.2f)", current.Ref(), candidate.Step, candidate.Metric, candidate.Score)
+	return reg.Push(name, proposal, note)
+}
+
 func verificationFields(result string) (bool, string, bool) {
 	if result == "" {
 		return false, "", false
@@ -2,11 +2,14 @@ package flow
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
 
 	"go-micro.dev/v6/ai"
+	"go-micro.dev/v6/prompt"
+	"go-micro.dev/v6/store"
 )
 
 func TestAnalyzeRanksFailedGraderStepAbovePassingStep(t *testing.T) {
@@ -87,3 +90,45 @@ func (m *optimizerModel) Stream(context.Context, *ai.Request, ...ai.GenerateOpti
 	return nil, ai.ErrStreamingUnsupported
 }
 func (m *optimizerModel) String() string { return "optimizer" }
+
+func TestAnalyzeComparesPromptVersions(t *testing.T) {
+	now := time.Now()
+	graded := func(passed bool) []StepRecord {
+		return []StepRecord{{Name: "draft", Status: "done", Result: fmt.Sprintf(`{"verification_passed":%v}`, passed)}}
+	}
+	report := Analyze([]Run{
+		{ID: "a", Prompt: "draft", PromptVersion: 2, Started: now, Updated: now.Add(time.Second), Steps: graded(true)},
+		{ID: "b", Prompt: "draft", PromptVersion: 2, Started: now, Updated: now.Add(3 * time.Second), Steps: graded(true)},
+		{ID: "c", Prompt: "draft", PromptVersion: 1, Started: now, Updated: now.Add(time.Second), Steps: graded(false)},
+		{ID: "d", Prompt: "draft", PromptVersion: 1, Status: "failed", Steps: []StepRecord{{Name: "draft", Status: "failed", Error: "boom"}}},
+		{ID: "e", Steps: graded(false)},
+	})
+	if len(report.Prompts) != 2 {
+		t.Fatalf("prompt stats = %+v, want two versions", report.Prompts)
+	}
+	v1, v2 := report.Prompts[0], report.Prompts[1]
+	if v1.Version != 1 || v1.Runs != 2 || v1.Failures != 2 || v1.PassRate != 0 || v1.ErrorRate != 0.5 {
+		t.Fatalf("v1 stats = %+v", v1)
+	}
+	if v2.Version != 2 || v2.Runs != 2 || v2.Failures != 0 || v2.PassRate != 1 || v2.P95Latency != time.Second {
+		t.Fatalf("v2 stats = %+v", v2)
+	}
+}
+
+func TestPromptOptimizerProposePushesUnlabeledVersion(t *testing.T) {
+	reg := prompt.NewRegistry(store.NewMemoryStore())
+	reg.Push("draft", "Write a draft.", "")
+	reg.SetLabel("draft", "prod", 1)
+
+	v, err := LLMOptimizer(&optimizerModel{reply: "Write a cited draft."}).Propose(context.Background(), reg, "draft",
+		Candidate{Step: "draft", Metric: "pass_rate", SampleFeedback: []string{"cite sources"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Version != 2 || v.Text != "Write a cited draft." || !strings.Contains(v.Note, "draft@1") {
+		t.Fatalf("proposal = %+v", v)
+	}
+	if prod, _ := reg.Labeled("draft", "prod"); prod.Version != 1 {
+		t.Fatalf("prod moved to %d", prod.Version)
+	}
+}
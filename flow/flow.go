@@ -36,6 +36,7 @@ import (
 	"go-micro.dev/v6/client"
 	codecbytes "go-micro.dev/v6/codec/bytes"
 	"go-micro.dev/v6/logger"
+	"go-micro.dev/v6/prompt"
 	"go-micro.dev/v6/registry"
 
 	// Register default providers.
@@ -55,6 +56,7 @@ type Flow struct {
 	name         string
 	opts         Options
 	model        ai.Model
+	cache        *ai.CachedModel
 	toolSet      *ai.Tools
 	client       client.Client
 	tmpl         *template.Template
@@ -69,16 +71,18 @@ type Flow struct {
 
 // Result records one flow execution.
 type Result struct {
-	FlowName  string    `json:"flow"`
-	Trigger   string    `json:"trigger"`
-	Prompt    string    `json:"prompt"`
-	Reply     string    `json:"reply,omitempty"`
-	Answer    string    `json:"answer,omitempty"`
-	ToolCalls []string  `json:"tool_calls,omitempty"`
-	Error     string    `json:"error,omitempty"`
-	ErrorKind string    `json:"error_kind,omitempty"`
-	Timestamp time.Time `json:"timestamp"`
-	Duration  float64   `json:"duration_seconds"`
+	FlowName string `json:"flow"`
+	Trigger  string `json:"trigger"`
+	Prompt   string `json:"prompt"`
+	// PromptVersion is the registry prompt version used, as name@version.
+	PromptVersion string    `json:"prompt_version,omitempty"`
+	Reply         string    `json:"reply,omitempty"`
+	Answer        string    `json:"answer,omitempty"`
+	ToolCalls     []string  `json:"tool_calls,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	ErrorKind     string    `json:"error_kind,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
+	Duration      float64   `json:"duration_seconds"`
 }
 
 // New creates a Flow with the given name and options.
@@ -94,11 +98,7 @@ func New(name string, opts ...Option) *Flow {
 
 	var tmpl *template.Template
 	if o.Prompt != "" {
-		var err error
-		tmpl, err = template.New(name).Parse(o.Prompt)
-		if err != nil {
-			tmpl = template.Must(template.New(name).Parse("{{.Data}}"))
-		}
+		tmpl = parsePrompt(name, o.Prompt)
 	}
 
 	return &Flow{
@@ -132,11 +132,20 @@ func (f *Flow) Register(reg registry.Registry, br broker.Broker, cl client.Clien
 			modelOpts = append(modelOpts, ai.WithBaseURL(f.opts.BaseURL))
 		}
 		modelOpts = append(modelOpts, ai.WithTools(f.toolSet))
+		params := f.opts.ModelParams
+		if params.PromptCache && params.PromptCacheKey == "" {
+			params.PromptCacheKey = f.name
+		}
+		modelOpts = append(modelOpts, ai.WithParams(params))
 
 		f.model = ai.New(f.opts.Provider, modelOpts...)
 		if f.model == nil {
 			return fmt.Errorf("unknown provider: %s", f.opts.Provider)
 		}
+		if f.opts.ModelCache != nil {
+			f.cache = ai.Cached(f.model, f.opts.ModelCache...)
+			f.model = f.cache
+		}
 	}
 
 	if f.opts.TriggerTopic != "" {
@@ -231,10 +240,15 @@ func (f *Flow) Execute(ctx context.Context, data string) error {
 
 	start := time.Now()
 
+	tmpl := f.tmpl
+	version := f.resolvePrompt(ctx, runID)
+	if version != nil {
+		tmpl = parsePrompt(f.name, version.Text)
+	}
 	prompt := data
-	if f.tmpl != nil {
+	if tmpl != nil {
 		var buf bytes.Buffer
-		_ = f.tmpl.Execute(&buf, map[string]string{"Data": data})
+		_ = tmpl.Execute(&buf, map[string]string{"Data": data})
 		prompt = buf.String()
 	}
 
@@ -244,6 +258,9 @@ func (f *Flow) Execute(ctx context.Context, data string) error {
 		Prompt:    prompt,
 		Timestamp: start,
 	}
+	if version != nil {
+		result.PromptVersion = version.Ref()
+	}
 
 	// Flow triggers, Agent reasons: hand the event to the named agent.
 	if f.opts.Agent != "" {
@@ -301,6 +318,32 @@ func (f *Flow) Execute(ctx context.Context, data string) error {
 	return nil
 }
 
+// parsePrompt parses a prompt template, falling back to the bare event
+// payload if it doesn't parse.
+func parsePrompt(name, text string) *template.Template {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return template.Must(template.New(name).Parse("{{.Data}}"))
+	}
+	return tmpl
+}
+
+// resolvePrompt picks the registry prompt version for a run, bucketed on
+// the key on ctx (prompt.WithKey) or runID without one. It returns nil if
+// the flow has no registry prompt or it can't be read.
+func (f *Flow) resolvePrompt(ctx context.Context, runID string) *prompt.Version {
+	reg, name := f.opts.PromptRegistry, f.opts.PromptName
+	if reg == nil || name == "" {
+		return nil
+	}
+	v, err := reg.Resolve(name, prompt.KeyFrom(ctx, runID))
+	if err != nil {
+		f.log.Logf(logger.WarnLevel, "Flow %s: prompt %s: %v", f.name, name, err)
+		return nil
+	}
+	return v
+}
+
 // callAgent hands the rendered prompt to a registered agent's Agent.Chat
 // endpoint over RPC and returns its reply.
 func (f *Flow) callAgent(ctx context.Context, name, message string) (string, error) {
@@ -320,6 +363,15 @@ func (f *Flow) callAgent(ctx context.Context, name, message string) (string, err
 	return out.Reply, nil
 }
 
+// CacheStats returns the hit and miss counts of the flow's ModelCache. It
+// reports false for a flow without one, or one that isn't registered yet.
+func (f *Flow) CacheStats() (ai.CacheStats, bool) {
+	if f.cache == nil {
+		return ai.CacheStats{}, false
+	}
+	return f.cache.Stats(), true
+}
+
 // Results returns a copy of all recorded execution results.
 func (f *Flow) Results() []Result {
 	f.mu.Lock()
@@ -5,7 +5,11 @@ import (
 	"testing"
 
 	"go-micro.dev/v6/ai"
+	"go-micro.dev/v6/broker"
+	"go-micro.dev/v6/client"
+	"go-micro.dev/v6/prompt"
 	"go-micro.dev/v6/registry"
+	"go-micro.dev/v6/store"
 )
 
 func TestNew(t *testing.T) {
@@ -129,3 +133,116 @@ func (m *runInfoModel) Stream(context.Context, *ai.Request, ...ai.GenerateOption
 }
 
 func (m *runInfoModel) String() string { return "run-info-model" }
+
+func TestPromptFromRecordsVersion(t *testing.T) {
+	reg := prompt.NewRegistry(store.NewMemoryStore())
+	reg.Push("welcome", "Welcome {{.Data}}.", "")
+	model := &runInfoModel{}
+	f := New("welcome", Prompt("fallback {{.Data}}"), PromptFrom(reg, "welcome"))
+	f.model = model
+	f.toolSet = ai.NewTools(registry.NewMemoryRegistry())
+
+	if err := f.Execute(context.Background(), "ada"); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	res := f.Results()[0]
+	if res.Prompt != "Welcome ada." || res.PromptVersion != "welcome@1" {
+		t.Fatalf("result prompt = %q (%s)", res.Prompt, res.PromptVersion)
+	}
+}
+
+func TestPromptFromBucketsOnKey(t *testing.T) {
+	reg := prompt.NewRegistry(store.NewMemoryStore())
+	reg.Push("welcome", "v1 {{.Data}}", "")
+	reg.Push("welcome", "v2 {{.Data}}", "")
+	reg.SetLabel("welcome", "prod", 1)
+	reg.SetLabel("welcome", "canary", 2)
+	reg.SetRollout("welcome", "canary", 50)
+	f := New("welcome", PromptFrom(reg, "welcome"))
+	f.model = &runInfoModel{}
+	f.toolSet = ai.NewTools(registry.NewMemoryRegistry())
+
+	ctx := prompt.WithKey(context.Background(), "user-42")
+	want, _ := reg.Resolve("welcome", "user-42")
+	for i := 0; i < 10; i++ {
+		if err := f.Execute(ctx, "ada"); err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+	}
+	for _, res := range f.Results() {
+		if res.PromptVersion != want.Ref() {
+			t.Fatalf("run used %s, want the key's %s", res.PromptVersion, want.Ref())
+		}
+	}
+}
+
+func TestSteppedPromptFromRecordsVersion(t *testing.T) {
+	reg := prompt.NewRegistry(store.NewMemoryStore())
+	reg.Push("welcome", "v1", "")
+	reg.Push("welcome", "v2", "")
+	reg.SetLabel("welcome", "prod", 2)
+	var seen string
+	cp := StoreCheckpoint(store.NewMemoryStore(), "stepped-welcome")
+	f := New("stepped-welcome", PromptFrom(reg, "welcome"), WithCheckpoint(cp), Steps(Step{Name: "greet", Run: func(ctx context.Context, in State) (State, error) {
+		if v, ok := prompt.FromContext(ctx); ok {
+			seen = v.Text
+		}
+		return in, nil
+	}}))
+	if err := f.Execute(context.Background(), "ada"); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	runs, _ := cp.List(context.Background())
+	if len(runs) != 1 || runs[0].Prompt != "welcome" || runs[0].PromptVersion != 2 || seen != "v2" {
+		t.Fatalf("runs = %+v, step saw %q", runs, seen)
+	}
+}
+
+// countingModel replies to every request, counting calls.
+type countingModel struct {
+	opts  ai.Options
+	calls int
+}
+
+func (m *countingModel) Init(opts ...ai.Option) error {
+	for _, o := range opts {
+		o(&m.opts)
+	}
+	return nil
+}
+func (m *countingModel) Options() ai.Options { return m.opts }
+func (m *countingModel) String() string      { return "counting" }
+func (m *countingModel) Generate(context.Context, *ai.Request, ...ai.GenerateOption) (*ai.Response, error) {
+	m.calls++
+	return &ai.Response{Reply: "welcome sent"}, nil
+}
+func (m *countingModel) Stream(context.Context, *ai.Request, ...ai.GenerateOption) (ai.Stream, error) {
+	return nil, ai.ErrStreamingUnsupported
+}
+
+func TestModelCacheServesRepeatedExecutions(t *testing.T) {
+	model := &countingModel{}
+	ai.Register("flow-counting", func(opts ...ai.Option) ai.Model {
+		model.Init(opts...)
+		return model
+	})
+	br := broker.NewMemoryBroker()
+	f := New("cached", Provider("flow-counting"), Prompt("Welcome {{.Data}}"), ModelCache())
+	if _, ok := f.CacheStats(); ok {
+		t.Fatal("an unregistered flow reported cache stats")
+	}
+	if err := f.Register(registry.NewMemoryRegistry(), br, client.DefaultClient); err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range []string{"ada", "ada", "grace"} {
+		if err := f.Execute(context.Background(), data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if model.calls != 2 {
+		t.Fatalf("model calls = %d, want 2", model.calls)
+	}
+	if s, ok := f.CacheStats(); !ok || s.Hits != 1 || s.Misses != 2 {
+		t.Fatalf("stats = %+v, %v", s, ok)
+	}
+}
@@ -3,6 +3,9 @@ package flow
 import (
 	"time"
 
+	"go-micro.dev/v6/ai"
+	"go-micro.dev/v6/prompt"
+
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -12,6 +15,10 @@ type Options struct {
 	TriggerTopic string
 	// Prompt is a Go template string. {{.Data}} is the event payload.
 	Prompt string
+	// PromptRegistry and PromptName, when set, take the prompt template
+	// from a versioned registry prompt instead of Prompt; see PromptFrom.
+	PromptRegistry *prompt.Registry
+	PromptName     string
 	// SystemPrompt is the system instruction for the LLM.
 	SystemPrompt string
 	// Provider is the AI provider name (e.g. "anthropic", "openai").
@@ -22,6 +29,12 @@ type Options struct {
 	Model string
 	// BaseURL overrides the provider's default base URL.
 	BaseURL string
+	// ModelCache, when set, serves repeated model requests from a response
+	// cache configured by these options; see ai.Cached. Nil disables it.
+	ModelCache []ai.CacheOption
+	// ModelParams are the generation parameters sent with each model
+	// request; see ai.Params.
+	ModelParams ai.Params
 	// HistoryLimit is the max messages per flow execution.
 	HistoryLimit int
 	// Timeout bounds one flow execution when the caller did not already
@@ -47,6 +60,9 @@ type Options struct {
 	// Checkpoint is the durability backend for stepped runs. Nil with
 	// steps present means a store-backed default; set it to swap backends.
 	Checkpoint Checkpoint
+	// RunLease is how long a running run stays claimed by its execution
+	// without a renewal. Zero means DefaultRunLease.
+	RunLease time.Duration
 	// TraceProvider emits OpenTelemetry spans for stepped flow runs.
 	TraceProvider trace.TracerProvider
 	// DeleteOnSuccess removes a run's checkpoint when it completes
@@ -67,6 +83,20 @@ func Prompt(p string) Option {
 	return func(o *Options) { o.Prompt = p }
 }
 
+// PromptFrom takes the prompt template from the registry prompt name.
+// Each run resolves a version through the prompt's labels and rollout,
+// keyed by the key on the run's context (prompt.WithKey), such as a
+// session or user id, or by the run id without one. The version is
+// recorded on the run (Run.Prompt and Run.PromptVersion,
+// Result.PromptVersion) so Analyze can compare versions. A stepped run's steps read its version with
+// prompt.FromContext. Prompt is the fallback if the registry can't be read.
+func PromptFrom(reg *prompt.Registry, name string) Option {
+	return func(o *Options) {
+		o.PromptRegistry = reg
+		o.PromptName = name
+	}
+}
+
 // SystemPrompt sets the system instruction for the LLM.
 func SystemPrompt(p string) Option {
 	return func(o *Options) { o.SystemPrompt = p }
@@ -92,6 +122,21 @@ func BaseURL(url string) Option {
 	return func(o *Options) { o.BaseURL = url }
 }
 
+// ModelCache serves the flow's repeated model requests from a response
+// cache: LLM steps and graders that see the same input again, and with
+// ai.CacheSemantic similar input. A turn that ran a service tool is never
+// cached unless the tool is named with ai.CacheSafeTools. Read the hit
+// counts with Flow.CacheStats.
+func ModelCache(opts ...ai.CacheOption) Option {
+	return func(o *Options) { o.ModelCache = append([]ai.CacheOption{}, opts...) }
+}
+
+// ModelParams sets the generation parameters of the flow's model
+// requests. With PromptCache set the cache key defaults to the flow name.
+func ModelParams(p ai.Params) Option {
+	return func(o *Options) { o.ModelParams = p }
+}
+
 // HistoryLimit sets the max messages per execution.
 func HistoryLimit(n int) Option {
 	return func(o *Options) { o.HistoryLimit = n }
@@ -143,6 +188,14 @@ func WithCheckpoint(c Checkpoint) Option {
 	return func(o *Options) { o.Checkpoint = c }
 }
 
+// RunLease sets how long a running run stays claimed by the execution
+// driving it. The execution renews the lease while it runs, so another
+// replica can only take the run over once the lease has lapsed, e.g.
+// after a crash. Default: DefaultRunLease.
+func RunLease(d time.Duration) Option {
+	return func(o *Options) { o.RunLease = d }
+}
+
 // DeleteOnSuccess removes a run's checkpoint when it completes
 // successfully. Failed runs are always retained. Default: retain all.
 func DeleteOnSuccess() Option {
@@ -16,6 +16,7 @@ import (
 	codecbytes "go-micro.dev/v6/codec/bytes"
 	"go-micro.dev/v6/gateway/a2a"
 	"go-micro.dev/v6/logger"
+	"go-micro.dev/v6/prompt"
 	"go-micro.dev/v6/store"
 )
 
@@ -113,10 +114,45 @@ type Run struct {
 	Steps    []StepRecord `json:"steps"`
 	Status   string       `json:"status"` // running | waiting | done | failed
 	Await    *AwaitState  `json:"await,omitempty"`
+	Owner    string       `json:"owner,omitempty"` // the execution driving the run; see ErrRunClaimed
+	Lease    time.Time    `json:"lease"`           // until when Owner holds a running run
 	Started  time.Time    `json:"started"`
 	Updated  time.Time    `json:"updated"`
+	// Prompt and PromptVersion record the registry prompt version the run
+	// used, if any; see PromptFrom.
+	Prompt        string `json:"prompt,omitempty"`
+	PromptVersion int    `json:"prompt_version,omitempty"`
+
+	// claimedFrom is the owner the run was loaded with, which the current
+	// execution is allowed to take it over from.
+	claimedFrom string
+}
+
+// ErrRunClaimed is returned when saving a run that another execution
+// holds — two replicas resuming the same run. Each execution claims the
+// run it starts or resumes, and StoreCheckpoint refuses the claim while
+// the run is running under another owner's unexpired lease, and any save
+// from an execution the run has since been claimed from. So only one
+// execution runs a step at a time; another takes over only once the
+// owner has stopped renewing its lease, e.g. after a crash.
+var ErrRunClaimed = errors.New("flow: run claimed by another execution")
+
+// DefaultRunLease is how long a running run stays claimed without a
+// renewal; see RunLease.
+var DefaultRunLease = 30 * time.Second
+
+// LeaseRenewer is an optional Checkpoint capability: RenewLease extends
+// the lease of a running run still owned by owner without rewriting the
+// rest of it, so a step that runs longer than the lease keeps its claim.
+// It returns ErrRunClaimed if the run has another owner. Checkpoints
+// without it only renew the lease at each save.
+type LeaseRenewer interface {
+	RenewLease(ctx context.Context, runID, owner string, until time.Time) error
 }
 
+// maxSaveAttempts bounds the retries of a save that raced a lease renewal.
+const maxSaveAttempts = 5
+
 // Checkpoint persists and restores flow runs so a run survives a crash
 // and resumes where it stopped. The built-in StoreCheckpoint is
 // store-backed; implement this interface to plug in another durable
@@ -145,19 +181,106 @@ func StoreCheckpoint(s store.Store, scope string) Checkpoint {
 	return &storeCheckpoint{store: store.Scope(s, "flow", scope)}
 }
 
+// Save writes the run if it still belongs to the execution saving it: the
+// stored owner must be the run's owner, or the owner it was claimed from,
+// and a claim fails while that owner still holds a lease on the running
+// run. Stores that implement store.CompareAndSwapper make the check
+// atomic; others fall back to a plain write after it.
 func (c *storeCheckpoint) Save(ctx context.Context, run Run) error {
-	if err := ctx.Err(); err != nil {
-		return err
-	}
 	run.Updated = time.Now()
 	b, err := json.Marshal(run)
 	if err != nil {
 		return err
 	}
-	if err := ctx.Err(); err != nil {
+
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rec := &store.Record{Key: run.ID, Value: b}
+		stored, rev, ok, err := c.read(run.ID)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if stored.Owner != run.Owner {
+				if stored.Owner != run.claimedFrom {
+					return ErrRunClaimed
+				}
+				if stored.Status == "running" && time.Now().Before(stored.Lease) {
+					return ErrRunClaimed
+				}
+			}
+			rec.Revision = rev
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err = store.CompareAndSwap(c.store, rec)
+		switch {
+		case errors.Is(err, store.ErrNotSupported):
+			return c.store.Write(rec)
+		case errors.Is(err, store.ErrConflict):
+			// a lease renewal or another owner; read again to tell
+			if attempt < maxSaveAttempts {
+				continue
+			}
+			return ErrRunClaimed
+		}
 		return err
 	}
-	return c.store.Write(&store.Record{Key: run.ID, Value: b})
+}
+
+// RenewLease extends the lease of a running run owned by owner. Stores
+// without compare-and-swap can't renew without risking a stale write, so
+// their leases are only renewed at each save.
+func (c *storeCheckpoint) RenewLease(ctx context.Context, runID, owner string, until time.Time) error {
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		run, rev, ok, err := c.read(runID)
+		if err != nil || !ok {
+			return err
+		}
+		if run.Owner != owner {
+			return ErrRunClaimed
+		}
+		if run.Status != "running" {
+			return nil
+		}
+		run.Lease = until
+		b, err := json.Marshal(run)
+		if err != nil {
+			return err
+		}
+
+		err = store.CompareAndSwap(c.store, &store.Record{Key: runID, Value: b, Revision: rev})
+		switch {
+		case errors.Is(err, store.ErrNotSupported):
+			return nil
+		case errors.Is(err, store.ErrConflict) && attempt < maxSaveAttempts:
+			continue
+		}
+		return err
+	}
+}
+
+// read returns the stored run and its revision.
+func (c *storeCheckpoint) read(runID string) (Run, uint64, bool, error) {
+	recs, err := c.store.Read(runID)
+	if errors.Is(err, store.ErrNotFound) || (err == nil && len(recs) == 0) {
+		return Run{}, 0, false, nil
+	}
+	if err != nil {
+		return Run{}, 0, false, err
+	}
+	var run Run
+	if err := json.Unmarshal(recs[0].Value, &run); err != nil {
+		return Run{}, 0, false, err
+	}
+	return run, recs[0].Revision, true, nil
 }
 
 func (c *storeCheckpoint) Load(ctx context.Context, runID string) (Run, bool, error) {
@@ -307,15 +430,19 @@ func A2A(url string) StepFunc {
 // LLM returns a StepFunc that runs one augmented-LLM turn: it renders the
 // prompt template against the current state (.Data, .Stage), lets the
 // model call the flow's services as tools, and stores the reply as the
-// new Data.
-func LLM(prompt string) StepFunc {
+// new Data. An empty template uses the run's registry prompt (PromptFrom).
+func LLM(text string) StepFunc {
 	return func(ctx context.Context, in State) (State, error) {
 		d := depsFrom(ctx)
 		if d == nil || d.model == nil {
 			return in, fmt.Errorf("LLM step requires a flow model (set Provider/APIKey)")
 		}
-		text := prompt
-		if tmpl, err := template.New("step").Parse(prompt); err == nil {
+		src := text
+		if v, ok := prompt.FromContext(ctx); ok && src == "" {
+			src = v.Text
+		}
+		text := src
+		if tmpl, err := template.New("step").Parse(src); err == nil {
 			var buf bytes.Buffer
 			if tmpl.Execute(&buf, map[string]string{"Data": in.String(), "Stage": in.Stage}) == nil {
 				text = buf.String()
@@ -406,6 +533,9 @@ func (f *Flow) startRun(ctx context.Context, data string) (Run, error) {
 	for _, s := range f.opts.Steps {
 		run.Steps = append(run.Steps, StepRecord{Name: s.Name, Status: "pending"})
 	}
+	if v := f.resolvePrompt(ctx, run.ID); v != nil {
+		run.Prompt, run.PromptVersion = v.Name, v.Version
+	}
 	return f.runFrom(ctx, run)
 }
 
@@ -440,9 +570,10 @@ func (f *Flow) Resume(ctx context.Context, runID string) error {
 //
 // It is a convenience for service startup and recovery loops: after a process
 // restart, call ResumePending to drain the durable backlog without having to
-// list and resume each run manually. If any run fails again, ResumePending
-// stops and returns that run id with the error so callers can log, alert, or
-// retry later without hiding the failing run.
+// list and resume each run manually. Runs another replica claims while they
+// are being resumed are skipped (see ErrRunClaimed). If any run fails again,
+// ResumePending stops and returns that run id with the error so callers can
+// log, alert, or retry later without hiding the failing run.
 func (f *Flow) ResumePending(ctx context.Context) (string, error) {
 	ctx, cancel := f.withTimeout(ctx)
 	defer cancel()
@@ -452,7 +583,13 @@ func (f *Flow) ResumePending(ctx context.Context) (string, error) {
 		return "", err
 	}
 	for _, run := range runs {
-		if err := f.Resume(ctx, run.ID); err != nil {
+		err := f.Resume(ctx, run.ID)
+		if errors.Is(err, ErrRunClaimed) {
+			// Another replica is resuming it.
+			f.log.Logf(logger.InfoLevel, "Flow %s run %s claimed by another execution, skipping", f.name, run.ID)
+			continue
+		}
+		if err != nil {
 			return run.ID, err
 		}
 	}
@@ -548,6 +685,19 @@ func (f *Flow) ResumeWith(ctx context.Context, runID, input string) error {
 // checkpointing before and after each step.
 func (f *Flow) runFrom(ctx context.Context, run Run) (Run, error) {
 	steps := f.opts.Steps
+	// Claim the run. The checkpoint refuses the claim while another
+	// execution holds it, and from then on rejects saves from whichever
+	// execution owned it before.
+	run.claimedFrom = run.Owner
+	run.Owner = uuid.New().String()
+	run.Status = "running"
+	if err := f.save(ctx, run); err != nil {
+		return run, err
+	}
+	run.claimedFrom = ""
+	stopRenew := f.renewLease(ctx, run)
+	defer stopRenew()
+
 	ctx = withDeps(ctx, &runDeps{client: f.client, model: f.model, tools: f.toolSet})
 	info, _ := ai.RunInfoFrom(ctx)
 	info.RunID = run.ID
@@ -555,6 +705,12 @@ func (f *Flow) runFrom(ctx context.Context, run Run) (Run, error) {
 	info.Agent = f.name
 	info.Flow = f.name
 	ctx = ai.WithRunInfo(ctx, info)
+	if reg := f.opts.PromptRegistry; reg != nil && run.Prompt != "" {
+		// a resumed run keeps the prompt version it started with
+		if v, err := reg.Get(run.Prompt, run.PromptVersion); err == nil {
+			ctx = prompt.NewContext(ctx, v)
+		}
+	}
 	ctx, finishSpan := f.startRunSpan(ctx, run)
 	var spanErr error
 	defer func() { finishSpan(run, spanErr) }()
@@ -711,6 +867,10 @@ func (f *Flow) save(ctx context.Context, run Run) error {
 	if f.checkpoint == nil {
 		return nil
 	}
+	run.Lease = time.Time{}
+	if run.Status == "running" {
+		run.Lease = time.Now().Add(f.lease())
+	}
 	if err := f.checkpoint.Save(ctx, run); err != nil {
 		f.log.Logf(logger.ErrorLevel, "Flow %s checkpoint save: %v", f.name, err)
 		return fmt.Errorf("flow %s checkpoint save: %w", f.name, err)
@@ -718,6 +878,48 @@ func (f *Flow) save(ctx context.Context, run Run) error {
 	return nil
 }
 
+func (f *Flow) lease() time.Duration {
+	if f.opts.RunLease > 0 {
+		return f.opts.RunLease
+	}
+	return DefaultRunLease
+}
+
+// renewLease keeps the run claimed while it runs, renewing its lease a few
+// times per lease period until the returned func is called.
+func (f *Flow) renewLease(ctx context.Context, run Run) func() {
+	r, ok := f.checkpoint.(LeaseRenewer)
+	if !ok {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(f.lease() / 3)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+			}
+			err := r.RenewLease(ctx, run.ID, run.Owner, time.Now().Add(f.lease()))
+			if errors.Is(err, ErrRunClaimed) {
+				// taken over; the next save reports it
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				f.log.Logf(logger.WarnLevel, "Flow %s run %s lease renewal: %v", f.name, run.ID, err)
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
 func validateSteps(steps []Step) error {
 	seen := make(map[string]struct{}, len(steps))
 	for i, step := range steps {
@@ -3,6 +3,7 @@ package flow
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -293,6 +294,110 @@ func TestFlowResumePendingResumesOldestRunsUntilFailure(t *testing.T) {
 	}
 }
 
+// Two replicas resuming the same run: the one that claims it first holds
+// it until it finishes, and the other is refused instead of running the
+// step again.
+func TestFlowResumeIsSingleOwner(t *testing.T) {
+	mem := store.NewMemoryStore()
+	ctx := context.Background()
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	var first, second int32
+	steps := []Step{
+		{Name: "first", Run: func(_ context.Context, in State) (State, error) {
+			if atomic.AddInt32(&first, 1) == 1 {
+				close(entered)
+				<-release
+			}
+			return in, nil
+		}},
+		{Name: "second", Run: func(_ context.Context, in State) (State, error) {
+			atomic.AddInt32(&second, 1)
+			in.Data = []byte("done")
+			return in, nil
+		}},
+	}
+	// a lease shorter than the step, so only renewal keeps the claim
+	a := New("single-owner", WithCheckpoint(StoreCheckpoint(mem, "single-owner")), Steps(steps...), RunLease(30*time.Millisecond))
+	b := New("single-owner", WithCheckpoint(StoreCheckpoint(mem, "single-owner")), Steps(steps...), RunLease(30*time.Millisecond))
+
+	if err := a.checkpoint.Save(ctx, Run{
+		ID:      "run-1",
+		Flow:    "single-owner",
+		State:   State{Stage: "first"},
+		Steps:   []StepRecord{{Name: "first", Status: "failed"}, {Name: "second", Status: "pending"}},
+		Status:  "failed",
+		Started: time.Now(),
+	}); err != nil {
+		t.Fatalf("seed run: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- a.Resume(ctx, "run-1") }()
+
+	<-entered
+	time.Sleep(100 * time.Millisecond)
+	if err := b.Resume(ctx, "run-1"); !errors.Is(err, ErrRunClaimed) {
+		t.Fatalf("replica b resume = %v, want ErrRunClaimed", err)
+	}
+	if id, err := b.ResumePending(ctx); err != nil || id != "" {
+		t.Fatalf("replica b ResumePending = %q, %v; want the held run skipped", id, err)
+	}
+	close(release)
+
+	if err := <-done; err != nil {
+		t.Fatalf("replica a resume: %v", err)
+	}
+	if n := atomic.LoadInt32(&first); n != 1 {
+		t.Fatalf("first step ran %d times, want once", n)
+	}
+	if n := atomic.LoadInt32(&second); n != 1 {
+		t.Fatalf("second step ran %d times, want once", n)
+	}
+	run, ok, err := a.checkpoint.Load(ctx, "run-1")
+	if err != nil || !ok {
+		t.Fatalf("Load(run-1) ok=%v err=%v", ok, err)
+	}
+	if run.Status != "done" || run.State.String() != "done" {
+		t.Fatalf("run-1 = %+v, want done by replica a", run)
+	}
+}
+
+// A run left running by an execution that stopped renewing its lease, e.g.
+// a crashed replica, is taken over, and the old owner can't save over it.
+func TestFlowResumeTakesOverLapsedLease(t *testing.T) {
+	mem := store.NewMemoryStore()
+	ctx := context.Background()
+	cp := StoreCheckpoint(mem, "lapsed")
+	f := New("lapsed", WithCheckpoint(cp), Steps(Step{Name: "work", Run: func(_ context.Context, in State) (State, error) {
+		return in, nil
+	}}))
+
+	stale := Run{
+		ID:      "run-1",
+		Flow:    "lapsed",
+		State:   State{Stage: "work"},
+		Steps:   []StepRecord{{Name: "work", Status: "in_progress"}},
+		Status:  "running",
+		Owner:   "crashed",
+		Lease:   time.Now().Add(-time.Second),
+		Started: time.Now(),
+	}
+	if err := cp.Save(ctx, stale); err != nil {
+		t.Fatalf("seed run: %v", err)
+	}
+	if err := f.Resume(ctx, "run-1"); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if run, _, _ := cp.Load(ctx, "run-1"); run.Status != "done" || run.Owner == "crashed" {
+		t.Fatalf("run-1 = %+v, want done by a new owner", run)
+	}
+	if err := cp.Save(ctx, stale); !errors.Is(err, ErrRunClaimed) {
+		t.Fatalf("save by the old owner = %v, want ErrRunClaimed", err)
+	}
+}
+
 // A flow-level Retry re-runs a failing step until it succeeds.
 func TestFlowStepRetry(t *testing.T) {
 	var attempts int
@@ -35,6 +35,7 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -43,6 +44,7 @@ import (
 	"go-micro.dev/v6/ai"
 	"go-micro.dev/v6/client"
 	codecbytes "go-micro.dev/v6/codec/bytes"
+	"go-micro.dev/v6/events/schema"
 	"go-micro.dev/v6/registry"
 )
 
@@ -127,6 +129,17 @@ type Invoke func(ctx context.Context, text string) (string, error)
 // StreamInvoke runs an agent for one message and returns streaming output chunks.
 type StreamInvoke func(ctx context.Context, text string) (ai.Stream, error)
 
+// MessageFiles returns the file parts of the A2A message an Invoke or
+// StreamInvoke is running for, as ai parts: images and audio by their MIME
+// type, anything else as a file. The text of the message is the Invoke's
+// text argument.
+func MessageFiles(ctx context.Context) []ai.Part {
+	files, _ := ctx.Value(filesKey{}).([]ai.Part)
+	return files
+}
+
+type filesKey struct{}
+
 // AgentHandlerOption configures an embedded A2A agent handler.
 type AgentHandlerOption func(*dispatcher)
 
@@ -269,10 +282,21 @@ type Skill struct {
 	Examples    []string `json:"examples,omitempty"`
 }
 
-// Part is one piece of message/artifact content. This gateway handles text.
+// Part is one piece of message/artifact content. This gateway handles text
+// and file parts; an incoming message's files reach the agent as ai.Parts
+// (see MessageFiles).
 type Part struct {
-	Kind string `json:"kind"` // "text"
-	Text string `json:"text,omitempty"`
+	Kind string       `json:"kind"` // "text" | "file"
+	Text string       `json:"text,omitempty"`
+	File *FileContent `json:"file,omitempty"`
+}
+
+// FileContent is the content of a file part: inline bytes or a URI.
+type FileContent struct {
+	Name     string `json:"name,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Bytes    []byte `json:"bytes,omitempty"` // base64 in JSON
+	URI      string `json:"uri,omitempty"`
 }
 
 // Message is a turn in an A2A conversation.
@@ -442,7 +466,36 @@ func (g *Gateway) card(name string, meta map[string]string) AgentCard {
 	if meta["services"] != "" {
 		services = strings.Split(meta["services"], ",")
 	}
-	return Card(name, g.opts.BaseURL+"/agents/"+name, meta["description"], services)
+	card := Card(name, g.opts.BaseURL+"/agents/"+name, meta["description"], services)
+	for i, skill := range card.Skills {
+		if len(skill.Tags) > 0 {
+			card.Skills[i].Tags = append(skill.Tags, g.eventTags(skill.Tags[0])...)
+		}
+	}
+	return card
+}
+
+// eventTags returns a tag for each event a service publishes or
+// subscribes to, e.g. "publishes:orders.created@v2", so callers can see
+// the service's event contracts on its skill.
+func (g *Gateway) eventTags(service string) []string {
+	recs, err := g.opts.Registry.GetService(service)
+	if err != nil || len(recs) == 0 {
+		return nil
+	}
+	var tags []string
+	for _, c := range schema.Contracts(recs[0]) {
+		verb := "publishes"
+		if c.Role == "subscriber" {
+			verb = "subscribes"
+		}
+		tag := verb + ":" + c.Topic
+		if c.Version > 0 {
+			tag += "@v" + strconv.Itoa(c.Version)
+		}
+		tags = append(tags, tag)
+	}
+	return tags
 }
 
 // Card builds an Agent Card for an agent. url is the agent's A2A endpoint
@@ -672,11 +725,14 @@ func (d *dispatcher) streamChunks(ctx context.Context, w http.ResponseWriter, re
 		writeRPC(w, req.ID, nil, &rpcError{Code: errInvalidParams, Message: "invalid params"})
 		return
 	}
-	text := textOf(p.Message.Parts)
-	if text == "" {
-		writeRPC(w, req.ID, nil, &rpcError{Code: errInvalidParams, Message: "message has no text part"})
+	text, files := textOf(p.Message.Parts), filesOf(p.Message.Parts)
+	if text == "" && len(files) == 0 {
+		writeRPC(w, req.ID, nil, &rpcError{Code: errInvalidParams, Message: "message has no text or file part"})
 		return
 	}
+	if len(files) > 0 {
+		ctx = context.WithValue(ctx, filesKey{}, files)
+	}
 	stream, err := invoke(ctx, text)
 	if err != nil {
 		if errors.Is(err, ai.ErrStreamingUnsupported) && fallback != nil {
@@ -742,9 +798,12 @@ func (d *dispatcher) run(ctx context.Context, params json.RawMessage, invoke Inv
 	if err := json.Unmarshal(params, &p); err != nil {
 		return nil, &rpcError{Code: errInvalidParams, Message: "invalid params"}
 	}
-	text := textOf(p.Message.Parts)
-	if text == "" {
-		return nil, &rpcError{Code: errInvalidParams, Message: "message has no text part"}
+	text, files := textOf(p.Message.Parts), filesOf(p.Message.Parts)
+	if text == "" && len(files) == 0 {
+		return nil, &rpcError{Code: errInvalidParams, Message: "message has no text or file part"}
+	}
+	if len(files) > 0 {
+		ctx = context.WithValue(ctx, filesKey{}, files)
 	}
 
 	reply, err := invoke(ctx, text)
@@ -875,9 +934,18 @@ func (d *dispatcher) getPushConfig(w http.ResponseWriter, req rpcRequest) {
 // ---------------------------------------------------------------------------
 
 // callAgent invokes an agent's Agent.Chat endpoint over RPC and returns
-// its reply — the same call the delegate tool and flows use.
+// its reply — the same call the delegate tool and flows use. The message's
+// files, if any, are sent as the request's parts.
 func (g *Gateway) callAgent(ctx context.Context, name, message string) (string, error) {
-	body, _ := json.Marshal(map[string]string{"message": message})
+	chat := map[string]any{"message": message}
+	if files := MessageFiles(ctx); len(files) > 0 {
+		parts := files
+		if message != "" {
+			parts = append([]ai.Part{ai.TextPart(message)}, files...)
+		}
+		chat["parts"] = parts
+	}
+	body, _ := json.Marshal(chat)
 	req := g.opts.Client.NewRequest(name, "Agent.Chat", &codecbytes.Frame{Data: body})
 	var rsp codecbytes.Frame
 	if err := g.opts.Client.Call(ctx, req, &rsp); err != nil {
@@ -1114,6 +1182,26 @@ func textOf(parts []Part) string {
 	return b.String()
 }
 
+// filesOf returns the file parts as ai parts.
+func filesOf(parts []Part) []ai.Part {
+	var files []ai.Part
+	for _, p := range parts {
+		if p.Kind != "file" || p.File == nil {
+			continue
+		}
+		f := p.File
+		typ := ai.PartFile
+		switch {
+		case strings.HasPrefix(f.MimeType, "image/"):
+			typ = ai.PartImage
+		case strings.HasPrefix(f.MimeType, "audio/"):
+			typ = ai.PartAudio
+		}
+		files = append(files, ai.Part{Type: typ, Name: f.Name, MIMEType: f.MimeType, Data: f.Bytes, URL: f.URI})
+	}
+	return files
+}
+
 func textArtifact(text string) Artifact {
 	return Artifact{
 		ArtifactID: uuid.New().String(),
@@ -189,6 +189,42 @@ func TestMessageSendAndGet(t *testing.T) {
 	}
 }
 
+// A message may carry files instead of, or as well as, text; the invoke
+// reads them with MessageFiles.
+func TestMessageSendCarriesFileParts(t *testing.T) {
+	var files []ai.Part
+	invoke := func(ctx context.Context, text string) (string, error) {
+		files = MessageFiles(ctx)
+		return "seen", nil
+	}
+	task := rpcTaskFromBody(t, newDispatcher(), `{
+		"jsonrpc":"2.0","id":1,"method":"message/send",
+		"params":{"message":{"role":"user","kind":"message","parts":[
+			{"kind":"file","file":{"name":"cat.png","mimeType":"image/png","bytes":"aGk="}},
+			{"kind":"file","file":{"name":"spec.pdf","mimeType":"application/pdf","uri":"https://example.com/spec.pdf"}}]}}}`, invoke)
+	if task.Status.State != stateCompleted {
+		t.Fatalf("task state = %q, want completed", task.Status.State)
+	}
+	if len(files) != 2 {
+		t.Fatalf("files = %+v, want 2", files)
+	}
+	if files[0].Type != ai.PartImage || string(files[0].Data) != "hi" || files[0].Name != "cat.png" {
+		t.Errorf("image part = %+v", files[0])
+	}
+	if files[1].Type != ai.PartFile || files[1].URL != "https://example.com/spec.pdf" || files[1].MIMEType != "application/pdf" {
+		t.Errorf("file part = %+v", files[1])
+	}
+
+	var resp struct {
+		Error *rpcError `json:"error"`
+	}
+	rpcDispatcher(t, newDispatcher(), `{"jsonrpc":"2.0","id":2,"method":"message/send",
+		"params":{"message":{"role":"user","kind":"message","parts":[]}}}`, invoke, &resp)
+	if resp.Error == nil || resp.Error.Code != errInvalidParams {
+		t.Fatalf("empty message error = %+v, want invalid params", resp.Error)
+	}
+}
+
 func TestMessageSendContinuesExistingTask(t *testing.T) {
 	d := newDispatcher()
 	first := rpcTaskFromBody(t, d, `{
@@ -902,3 +938,41 @@ func skillIDs(skills []Skill) []string {
 	}
 	return ids
 }
+
+func TestAgentCardEventTags(t *testing.T) {
+	reg := registry.NewMemoryRegistry()
+	agent := &registry.Service{
+		Name: "echo",
+		Nodes: []*registry.Node{{
+			Id:       "echo-1",
+			Address:  "localhost:9090",
+			Metadata: map[string]string{"type": "agent", "services": "task"},
+		}},
+	}
+	task := &registry.Service{
+		Name:  "task",
+		Nodes: []*registry.Node{{Id: "task-1", Address: "localhost:9091"}},
+		Endpoints: []*registry.Endpoint{
+			{Name: "task.created", Metadata: map[string]string{"topic": "task.created", "publisher": "true", "schema_version": "3"}},
+			{Name: "Func", Metadata: map[string]string{"topic": "project.archived", "subscriber": "true"}},
+		},
+	}
+	for _, svc := range []*registry.Service{agent, task} {
+		if err := reg.Register(svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g := New(Options{Registry: reg, BaseURL: "http://gw"})
+	card, ok := g.lookupCard("echo")
+	if !ok {
+		t.Fatal("expected a card for echo")
+	}
+	if len(card.Skills) != 1 {
+		t.Fatalf("got %d skills, want 1", len(card.Skills))
+	}
+	tags := strings.Join(card.Skills[0].Tags, ",")
+	if tags != "task,publishes:task.created@v3,subscribes:project.archived" {
+		t.Fatalf("got tags %q", tags)
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"go-micro.dev/v6/broker"
 	"go-micro.dev/v6/client"
 	"go-micro.dev/v6/codec/bytes"
+	"go-micro.dev/v6/events/schema"
 	"go-micro.dev/v6/metadata"
 	"go-micro.dev/v6/registry"
 	"go-micro.dev/v6/store"
@@ -304,6 +305,11 @@ func (s *Server) discoverServices() error {
 
 		// Convert endpoints to tools
 		for _, ep := range fullSvcs[0].Endpoints {
+			// Published events are contracts, not callable endpoints;
+			// they're listed by micro_events_list
+			if ep.Metadata != nil && ep.Metadata["publisher"] == "true" {
+				continue
+			}
 			toolName := fmt.Sprintf("%s.%s", svc.Name, ep.Name)
 
 			// Build input schema from endpoint request type
@@ -442,6 +448,44 @@ func (s *Server) registerFrameworkTools() {
 		},
 	})
 
+	addFramework(&Tool{
+		Name:        "micro_events_list",
+		Description: "List the events services publish and subscribe to, with their topic, schema version and payload schema",
+		InputSchema: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"topic": map[string]interface{}{"type": "string", "description": "Only list events on this topic"},
+			},
+		},
+		Handler: func(input map[string]interface{}) (interface{}, error) {
+			topic, _ := input["topic"].(string)
+			services, err := s.opts.Registry.ListServices()
+			if err != nil {
+				return nil, err
+			}
+			var out []map[string]interface{}
+			for _, svc := range services {
+				full, err := s.opts.Registry.GetService(svc.Name)
+				if err != nil || len(full) == 0 {
+					continue
+				}
+				for _, c := range schema.Contracts(full[0]) {
+					if topic != "" && c.Topic != topic {
+						continue
+					}
+					out = append(out, map[string]interface{}{
+						"service": c.Service,
+						"topic":   c.Topic,
+						"role":    c.Role,
+						"version": c.Version,
+						"schema":  s.buildInputSchema(c.Endpoint.Request),
+					})
+				}
+			}
+			return map[string]interface{}{"events": out}, nil
+		},
+	})
+
 	addFramework(&Tool{
 		Name:        "micro_store_list",
 		Description: "List keys in the data store",
@@ -144,6 +144,64 @@ func TestToolScopesFromMetadata(t *testing.T) {
 	}
 }
 
+func TestEventContracts(t *testing.T) {
+	reg := registry.NewMemoryRegistry()
+	svc := &registry.Service{
+		Name: "orders",
+		Nodes: []*registry.Node{{
+			Id:      "orders-1",
+			Address: "localhost:9090",
+		}},
+		Endpoints: []*registry.Endpoint{
+			{
+				Name: "orders.created",
+				Request: &registry.Value{
+					Name:   "orders.created",
+					Type:   "object",
+					Values: []*registry.Value{{Name: "id", Type: "string"}},
+				},
+				Metadata: map[string]string{
+					"topic":          "orders.created",
+					"publisher":      "true",
+					"schema_version": "2",
+				},
+			},
+		},
+	}
+	if err := reg.Register(svc); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newTestServer(Options{Registry: reg})
+	if err := s.discoverServices(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := s.tools["orders.orders.created"]; ok {
+		t.Fatal("published events should not be tools")
+	}
+
+	tool := s.tools["micro_events_list"]
+	if tool == nil {
+		t.Fatal("expected tool micro_events_list")
+	}
+	out, err := tool.Handler(map[string]interface{}{"topic": "orders.created"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := out.(map[string]interface{})["events"].([]map[string]interface{})
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	if events[0]["role"] != "publisher" || events[0]["version"] != 2 {
+		t.Errorf("unexpected event contract: %v", events[0])
+	}
+	props := events[0]["schema"].(map[string]interface{})["properties"].(map[string]interface{})
+	if _, ok := props["id"]; !ok {
+		t.Errorf("expected the payload schema, got %v", events[0]["schema"])
+	}
+}
+
 func TestHandleCallTool_AuthRequired(t *testing.T) {
 	ma := &mockAuth{
 		accounts: map[string]*auth.Account{
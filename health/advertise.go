@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+
+	log "go-micro.dev/v6/logger"
+	"go-micro.dev/v6/metadata"
+	"go-micro.dev/v6/registry"
+	"go-micro.dev/v6/server"
+)
+
+// Advertise publishes the health status in srv's registry entry, so
+// selectors skip nodes that are down and prefer ones that aren't degraded.
+// It polls the checks (see Poll) and, each time the status changes, sets
+// it as the registry.MetadataHealth node metadata. A node that's already
+// registered is updated straight away, and servers that implement
+// server.MetadataSetter also send it with every later registration.
+// Advertise returns at once and stops when ctx is done.
+//
+//	health.Advertise(ctx, service.Server())
+func Advertise(ctx context.Context, srv server.Server) {
+	logger := srv.Options().Logger
+	if logger == nil {
+		logger = log.DefaultLogger
+	}
+	update := func(status Status) {
+		if err := advertise(srv, status); err != nil {
+			logger.Logf(log.ErrorLevel, "Health failed to advertise %s status: %v", status, err)
+		}
+	}
+
+	stop := watch(update)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// polling reports the first status, unless it was already running
+	if !startPolling(ctx) {
+		go update(Run(ctx).Status)
+	}
+}
+
+func advertise(srv server.Server, status Status) error {
+	// future registrations carry the status; Init would reconfigure a
+	// running server, so only servers that can set metadata alone get it
+	if ms, ok := srv.(server.MetadataSetter); ok {
+		ms.SetMetadata(registry.MetadataHealth, string(status))
+	}
+	opts := srv.Options()
+
+	// and the current one is updated in place
+	services, err := opts.Registry.GetService(opts.Name)
+	if err == registry.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	nodeID := opts.Name + "-" + opts.Id
+	for _, service := range services {
+		for _, node := range service.Nodes {
+			if node.Id != nodeID {
+				continue
+			}
+
+			n := *node
+			n.Metadata = metadata.Copy(node.Metadata)
+			n.Metadata[registry.MetadataHealth] = string(status)
+
+			s := *service
+			s.Nodes = []*registry.Node{&n}
+
+			return opts.Registry.Register(&s, registry.RegisterTTL(opts.RegisterTTL))
+		}
+	}
+
+	return nil
+}
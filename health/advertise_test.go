@@ -0,0 +1,142 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/registry"
+	"go-micro.dev/v6/server"
+	"go-micro.dev/v6/server/grpc"
+	"go-micro.dev/v6/server/mock"
+)
+
+func TestAdvertise(t *testing.T) {
+	Reset()
+
+	reg := registry.NewMemoryRegistry()
+	srv := mock.NewServer(server.Name("greeter"), server.Id("1"), server.Registry(reg))
+	if err := reg.Register(&registry.Service{
+		Name:  "greeter",
+		Nodes: []*registry.Node{{Id: "greeter-1", Address: "10.0.0.1:8080"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var cacheDown atomic.Bool
+	cacheDown.Store(true)
+	Register("database", func(ctx context.Context) error { return nil })
+	RegisterCheck(Check{
+		Name: "cache",
+		Check: func(ctx context.Context) error {
+			if cacheDown.Load() {
+				return errors.New("cache unavailable")
+			}
+			return nil
+		},
+		Interval: 10 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	Advertise(ctx, srv)
+
+	waitForHealth(t, reg, srv, "degraded")
+
+	cacheDown.Store(false)
+	waitForHealth(t, reg, srv, "up")
+}
+
+// Advertising on a running gRPC server must not replace the server it is
+// serving with, so Stop still shuts down the listener.
+func TestAdvertiseGRPCServer(t *testing.T) {
+	Reset()
+	waitForPollingStopped(t)
+
+	reg := registry.NewMemoryRegistry()
+	srv := grpc.NewServer(
+		server.Name("greeter"),
+		server.Id("1"),
+		server.Registry(reg),
+		server.Address("127.0.0.1:0"),
+	)
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	addr := srv.Options().Address
+
+	var down atomic.Bool
+	down.Store(true)
+	RegisterCheck(Check{
+		Name: "cache",
+		Check: func(ctx context.Context) error {
+			if down.Load() {
+				return errors.New("cache unavailable")
+			}
+			return nil
+		},
+		Interval: 10 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	Advertise(ctx, srv)
+
+	waitForHealth(t, reg, srv, "degraded")
+	down.Store(false)
+	waitForHealth(t, reg, srv, "up")
+
+	// a later registration keeps the status
+	if err := srv.(interface{ Register() error }).Register(); err != nil {
+		t.Fatal(err)
+	}
+	waitForHealth(t, reg, srv, "up")
+
+	cancel()
+	if err := srv.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+		c.Close()
+		t.Fatalf("listener at %s still accepting after Stop", addr)
+	}
+}
+
+// waitForPollingStopped waits for the poller an earlier test started to
+// see its context is done.
+func waitForPollingStopped(t *testing.T) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.RLock()
+		p := polling
+		mu.RUnlock()
+		if !p {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("polling did not stop")
+}
+
+// waitForHealth waits until the registered node and the server's own
+// metadata both carry status.
+func waitForHealth(t *testing.T, reg registry.Registry, srv server.Server, status string) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		services, err := reg.GetService("greeter")
+		if err == nil && len(services) == 1 && len(services[0].Nodes) == 1 &&
+			services[0].Nodes[0].Metadata[registry.MetadataHealth] == status &&
+			srv.Options().Metadata[registry.MetadataHealth] == status {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("health was not advertised as %s", status)
+}
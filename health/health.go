@@ -18,6 +18,11 @@
 // Or use the convenience function to register all routes:
 //
 //	health.RegisterHandlers(mux)
+//
+// A failing critical check marks the service down and not ready; a failing
+// non-critical one marks it degraded, which is still ready. Checks run on
+// each request unless they set an Interval to cache their result, or Poll
+// runs them in the background.
 package health
 
 import (
@@ -38,8 +43,9 @@ import (
 type Status string
 
 const (
-	StatusUp   Status = "up"
-	StatusDown Status = "down"
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
 )
 
 // CheckFunc is a function that performs a health check.
@@ -51,7 +57,11 @@ type Check struct {
 	Name     string
 	Check    CheckFunc
 	Timeout  time.Duration
-	Critical bool // If true, failure marks the service as not ready
+	Critical bool // If true, failure marks the service as not ready, otherwise as degraded
+	// Interval is how long a result is reused before the check runs
+	// again, and how often Poll runs it. Zero runs the check on every Run
+	// and every DefaultInterval when polling.
+	Interval time.Duration
 }
 
 // Result represents the result of a health check
@@ -60,6 +70,8 @@ type Result struct {
 	Status   Status        `json:"status"`
 	Error    string        `json:"error,omitempty"`
 	Duration time.Duration `json:"duration"`
+	Critical bool          `json:"critical"`
+	Checked  time.Time     `json:"checked"`
 }
 
 // Response represents the overall health response
@@ -69,11 +81,20 @@ type Response struct {
 	Info   map[string]string `json:"info,omitempty"`
 }
 
+// DefaultInterval is how often Poll runs checks that don't set an Interval.
+var DefaultInterval = 10 * time.Second
+
 var (
 	mu             sync.RWMutex
 	checks         []Check
 	info           = make(map[string]string)
 	defaultTimeout = 5 * time.Second
+
+	// lastResults holds the last result of each check by name
+	lastResults = make(map[string]Result)
+	polling     bool
+	watchers    = make(map[int]func(Status))
+	watchID     int
 )
 
 // Register adds a health check with default settings (critical, 5s timeout)
@@ -108,10 +129,13 @@ func Reset() {
 	mu.Lock()
 	checks = nil
 	info = make(map[string]string)
+	lastResults = make(map[string]Result)
 	mu.Unlock()
 }
 
-// Run executes all health checks and returns the results
+// Run executes the health checks and returns the results. Checks whose
+// last result is still within their Interval, or all of them while
+// polling, report that result instead of running again.
 func Run(ctx context.Context) Response {
 	mu.RLock()
 	checksCopy := make([]Check, len(checks))
@@ -120,6 +144,7 @@ func Run(ctx context.Context) Response {
 	for k, v := range info {
 		infoCopy[k] = v
 	}
+	isPolling := polling
 	mu.RUnlock()
 
 	// Add runtime info
@@ -134,11 +159,25 @@ func Run(ctx context.Context) Response {
 		}
 	}
 
-	// Run checks concurrently
-	results := make([]Result, len(checksCopy))
+	results := runChecks(ctx, checksCopy, isPolling)
+
+	return Response{
+		Status: overallStatus(results),
+		Checks: results,
+		Info:   infoCopy,
+	}
+}
+
+// runChecks runs the checks concurrently, reusing results where it can.
+func runChecks(ctx context.Context, checks []Check, isPolling bool) []Result {
+	results := make([]Result, len(checks))
 	var wg sync.WaitGroup
 
-	for i, check := range checksCopy {
+	for i, check := range checks {
+		if result, ok := cachedResult(check, isPolling); ok {
+			results[i] = result
+			continue
+		}
 		wg.Add(1)
 		go func(i int, check Check) {
 			defer wg.Done()
@@ -148,20 +187,38 @@ func Run(ctx context.Context) Response {
 
 	wg.Wait()
 
-	// Determine overall status
-	overallStatus := StatusUp
-	for i, result := range results {
-		if result.Status == StatusDown && checksCopy[i].Critical {
-			overallStatus = StatusDown
-			break
+	return results
+}
+
+// overallStatus is down if a critical check is down, degraded if any
+// other check is, and up otherwise.
+func overallStatus(results []Result) Status {
+	status := StatusUp
+	for _, result := range results {
+		if result.Status != StatusDown {
+			continue
+		}
+		if result.Critical {
+			return StatusDown
 		}
+		status = StatusDegraded
 	}
+	return status
+}
 
-	return Response{
-		Status: overallStatus,
-		Checks: results,
-		Info:   infoCopy,
+// cachedResult returns the check's last result if it can be reused.
+func cachedResult(check Check, isPolling bool) (Result, bool) {
+	mu.RLock()
+	result, ok := lastResults[check.Name]
+	mu.RUnlock()
+
+	if !ok {
+		return Result{}, false
 	}
+	if isPolling || (check.Interval > 0 && time.Since(result.Checked) < check.Interval) {
+		return result, true
+	}
+	return Result{}, false
 }
 
 func runCheck(ctx context.Context, check Check) Result {
@@ -176,6 +233,8 @@ func runCheck(ctx context.Context, check Check) Result {
 		Name:     check.Name,
 		Status:   StatusUp,
 		Duration: duration,
+		Critical: check.Critical,
+		Checked:  start,
 	}
 
 	if err != nil {
@@ -183,13 +242,110 @@ func runCheck(ctx context.Context, check Check) Result {
 		result.Error = err.Error()
 	}
 
+	mu.Lock()
+	lastResults[check.Name] = result
+	mu.Unlock()
+
 	return result
 }
 
-// IsReady returns true if all critical checks pass
+// Poll runs each check in the background on its Interval, or every
+// DefaultInterval, until ctx is done. While polling, Run and the handlers
+// report the latest results rather than running checks on each request.
+// Calling Poll while already polling does nothing.
+func Poll(ctx context.Context) {
+	startPolling(ctx)
+}
+
+// startPolling starts polling unless it's already running, and reports
+// whether it did.
+func startPolling(ctx context.Context) bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if polling {
+		return false
+	}
+	polling = true
+
+	go poll(ctx)
+	return true
+}
+
+func poll(ctx context.Context) {
+	defer func() {
+		mu.Lock()
+		polling = false
+		mu.Unlock()
+	}()
+
+	var last Status
+	for {
+		mu.RLock()
+		checksCopy := make([]Check, len(checks))
+		copy(checksCopy, checks)
+		mu.RUnlock()
+
+		// run the checks that are due, and wake for the next one
+		next := DefaultInterval
+		for i := range checksCopy {
+			if checksCopy[i].Interval == 0 {
+				checksCopy[i].Interval = DefaultInterval
+			}
+			if checksCopy[i].Interval < next {
+				next = checksCopy[i].Interval
+			}
+		}
+		results := runChecks(ctx, checksCopy, false)
+
+		if status := overallStatus(results); status != last {
+			last = status
+			notify(status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(next):
+		}
+	}
+}
+
+// watch calls fn with the overall status each time polling finds it has
+// changed, until the returned func is called.
+func watch(fn func(Status)) func() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	watchID++
+	id := watchID
+	watchers[id] = fn
+
+	return func() {
+		mu.Lock()
+		delete(watchers, id)
+		mu.Unlock()
+	}
+}
+
+func notify(status Status) {
+	mu.RLock()
+	fns := make([]func(Status), 0, len(watchers))
+	for _, fn := range watchers {
+		fns = append(fns, fn)
+	}
+	mu.RUnlock()
+
+	for _, fn := range fns {
+		fn(status)
+	}
+}
+
+// IsReady returns true unless a critical check fails. A degraded service
+// is ready.
 func IsReady(ctx context.Context) bool {
 	resp := Run(ctx)
-	return resp.Status == StatusUp
+	return resp.Status != StatusDown
 }
 
 // IsLive always returns true (basic liveness)
@@ -199,7 +355,7 @@ func IsLive() bool {
 }
 
 // Handler returns an http.Handler for the main health endpoint
-// Returns 200 if healthy, 503 if unhealthy
+// Returns 200 if up or degraded, 503 if down
 func Handler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		resp := Run(r.Context())
@@ -232,7 +388,7 @@ func ReadyHandler() http.Handler {
 
 func writeResponse(w http.ResponseWriter, resp Response) {
 	w.Header().Set("Content-Type", "application/json")
-	if resp.Status == StatusUp {
+	if resp.Status != StatusDown {
 		w.WriteHeader(http.StatusOK)
 	} else {
 		w.WriteHeader(http.StatusServiceUnavailable)
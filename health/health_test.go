@@ -7,6 +7,7 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -63,9 +64,12 @@ func TestNonCriticalCheck(t *testing.T) {
 
 	resp := Run(context.Background())
 
-	// Overall status should be up because check is not critical
-	if resp.Status != StatusUp {
-		t.Errorf("expected status up for non-critical failure, got %s", resp.Status)
+	// Overall status should be degraded because check is not critical
+	if resp.Status != StatusDegraded {
+		t.Errorf("expected status degraded for non-critical failure, got %s", resp.Status)
+	}
+	if !IsReady(context.Background()) {
+		t.Error("expected a degraded service to be ready")
 	}
 	// But the check itself should show as down
 	if resp.Checks[0].Status != StatusDown {
@@ -376,3 +380,74 @@ func TestConcurrentChecks(t *testing.T) {
 		t.Errorf("expected status up, got %s", resp.Status)
 	}
 }
+
+func TestCheckInterval(t *testing.T) {
+	Reset()
+
+	var runs int32
+	RegisterCheck(Check{
+		Name: "cached",
+		Check: func(ctx context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		},
+		Interval: time.Hour,
+	})
+
+	Run(context.Background())
+	Run(context.Background())
+
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Errorf("expected the cached result to be reused, check ran %d times", n)
+	}
+}
+
+func TestPoll(t *testing.T) {
+	Reset()
+
+	var runs int32
+	var failing atomic.Bool
+	RegisterCheck(Check{
+		Name: "polled",
+		Check: func(ctx context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			if failing.Load() {
+				return errors.New("down")
+			}
+			return nil
+		},
+		Critical: true,
+		Interval: 10 * time.Millisecond,
+	})
+
+	statuses := make(chan Status, 10)
+	stop := watch(func(s Status) { statuses <- s })
+	defer stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	Poll(ctx)
+
+	if s := <-statuses; s != StatusUp {
+		t.Fatalf("first status = %s, want up", s)
+	}
+
+	failing.Store(true)
+	select {
+	case s := <-statuses:
+		if s != StatusDown {
+			t.Fatalf("status = %s, want down", s)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("poll did not report the failing check")
+	}
+
+	// requests report the polled result rather than running the check
+	before := atomic.LoadInt32(&runs)
+	if resp := Run(context.Background()); resp.Status != StatusDown {
+		t.Errorf("run status = %s, want down", resp.Status)
+	}
+	if atomic.LoadInt32(&runs) > before+1 {
+		t.Error("run executed the check while polling")
+	}
+}
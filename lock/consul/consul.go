@@ -0,0 +1,166 @@
+// Package consul is a lock.Locker backed by consul sessions.
+//
+// A lock is a key acquired with a session whose TTL is renewed while the
+// lease is held; if the holder stops renewing, consul invalidates the
+// session and deletes the key. The key's modify index after acquiring it
+// is the fencing token.
+//
+// Consul doesn't accept session TTLs below ten seconds, so shorter lease
+// TTLs are rounded up. After a session is invalidated rather than
+// released, consul also holds the key for the session's lock delay
+// (fifteen seconds by default) before anyone else can acquire it.
+package consul
+
+import (
+	"context"
+	"time"
+
+	consul "github.com/hashicorp/consul/api"
+	"go-micro.dev/v6/lock"
+)
+
+// MinTTL is the shortest session TTL consul accepts.
+const MinTTL = 10 * time.Second
+
+type consulLocker struct {
+	opts   lock.Options
+	client *consul.Client
+	// err is the error creating the client, returned from every call
+	err error
+}
+
+// NewLocker returns a locker on the consul agent at the first of
+// lock.Nodes, or the consul default if none are set. Requests go to the
+// agent as the locker is used, so errors are returned from Lock or
+// TryLock.
+func NewLocker(opts ...lock.Option) lock.Locker {
+	options := lock.NewOptions(opts...)
+
+	config := consul.DefaultConfig()
+	if c, ok := options.Context.Value(configKey{}).(*consul.Config); ok {
+		config = c
+	}
+	if len(options.Nodes) > 0 {
+		config.Address = options.Nodes[0]
+	}
+
+	client, err := consul.NewClient(config)
+	return &consulLocker{opts: options, client: client, err: err}
+}
+
+func (c *consulLocker) Lock(ctx context.Context, key string, opts ...lock.LockOption) (lock.Lease, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	options := lock.NewLockOptions(opts...)
+
+	for {
+		lease, index, err := c.tryLock(ctx, key, options)
+		if err != lock.ErrLocked {
+			return lease, err
+		}
+		if err := c.waitRelease(ctx, c.opts.Prefix+key, index); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// waitRelease blocks until key has no session, watching from index.
+func (c *consulLocker) waitRelease(ctx context.Context, key string, index uint64) error {
+	for {
+		q := (&consul.QueryOptions{WaitIndex: index}).WithContext(ctx)
+		pair, meta, err := c.client.KV().Get(key, q)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if pair == nil || pair.Session == "" {
+			return nil
+		}
+		index = meta.LastIndex
+	}
+}
+
+func (c *consulLocker) TryLock(ctx context.Context, key string, opts ...lock.LockOption) (lock.Lease, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	lease, _, err := c.tryLock(ctx, key, lock.NewLockOptions(opts...))
+	return lease, err
+}
+
+// tryLock acquires key, or returns ErrLocked with the index the holder was
+// seen at.
+func (c *consulLocker) tryLock(ctx context.Context, key string, options lock.LockOptions) (lock.Lease, uint64, error) {
+	k := c.opts.Prefix + key
+	w := (&consul.WriteOptions{}).WithContext(ctx)
+	q := (&consul.QueryOptions{}).WithContext(ctx)
+
+	ttl := options.TTL
+	if ttl < MinTTL {
+		ttl = MinTTL
+	}
+
+	session, _, err := c.client.Session().Create(&consul.SessionEntry{
+		Name:     "micro lock " + key,
+		TTL:      ttl.String(),
+		Behavior: consul.SessionBehaviorDelete,
+	}, w)
+	if err != nil {
+		return nil, 0, err
+	}
+	destroy := func() {
+		_, _ = c.client.Session().Destroy(session, nil)
+	}
+
+	acquired, _, err := c.client.KV().Acquire(&consul.KVPair{Key: k, Session: session}, w)
+	if err != nil {
+		destroy()
+		return nil, 0, err
+	}
+
+	pair, meta, err := c.client.KV().Get(k, q)
+	if err != nil {
+		destroy()
+		return nil, 0, err
+	}
+	if !acquired || pair == nil || pair.Session != session {
+		destroy()
+		return nil, meta.LastIndex, lock.ErrLocked
+	}
+
+	renew := func(ctx context.Context) error {
+		entry, _, err := c.client.Session().Renew(session, (&consul.WriteOptions{}).WithContext(ctx))
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			return lock.ErrLeaseLost
+		}
+		return nil
+	}
+	release := func(ctx context.Context) error {
+		w := (&consul.WriteOptions{}).WithContext(ctx)
+		if _, _, err := c.client.KV().Release(&consul.KVPair{Key: k, Session: session}, w); err != nil {
+			return err
+		}
+		_, err := c.client.Session().Destroy(session, w)
+		return err
+	}
+
+	return lock.NewLease(key, pair.ModifyIndex, ttl, renew, release), 0, nil
+}
+
+func (c *consulLocker) Options() lock.Options {
+	return c.opts
+}
+
+func (c *consulLocker) Close() error {
+	return nil
+}
+
+func (c *consulLocker) String() string {
+	return "consul"
+}
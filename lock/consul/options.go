@@ -0,0 +1,21 @@
+package consul
+
+import (
+	"context"
+
+	consul "github.com/hashicorp/consul/api"
+	"go-micro.dev/v6/lock"
+)
+
+type configKey struct{}
+
+// Config sets the consul client config. The first of lock.Nodes, if set,
+// replaces its address.
+func Config(c *consul.Config) lock.Option {
+	return func(o *lock.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, configKey{}, c)
+	}
+}
@@ -0,0 +1,171 @@
+// Package etcd is a lock.Locker backed by etcd.
+//
+// A lock is a key attached to an etcd lease, created in a transaction that
+// only succeeds if the key doesn't exist. The key disappears with the
+// lease if its holder stops renewing. The revision the key was created at
+// is the fencing token.
+package etcd
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"go-micro.dev/v6/lock"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type etcdLocker struct {
+	opts   lock.Options
+	config clientv3.Config
+
+	mu     sync.Mutex
+	client *clientv3.Client
+}
+
+// NewLocker returns a locker on the etcd endpoints in lock.Nodes, or
+// 127.0.0.1:2379 if none are set. It connects on first use, so a
+// connection error is returned from Lock or TryLock.
+func NewLocker(opts ...lock.Option) lock.Locker {
+	options := lock.NewOptions(opts...)
+
+	config := clientv3.Config{
+		Endpoints:   []string{"127.0.0.1:2379"},
+		DialTimeout: 5 * time.Second,
+	}
+	if len(options.Nodes) > 0 {
+		config.Endpoints = options.Nodes
+	}
+	if u, ok := options.Context.Value(authKey{}).(*authCreds); ok {
+		config.Username = u.Username
+		config.Password = u.Password
+	}
+
+	return &etcdLocker{opts: options, config: config}
+}
+
+// conn returns the client, connecting if this is the first use or the
+// last attempt failed.
+func (e *etcdLocker) conn() (*clientv3.Client, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.client == nil {
+		client, err := clientv3.New(e.config)
+		if err != nil {
+			return nil, err
+		}
+		e.client = client
+	}
+	return e.client, nil
+}
+
+func (e *etcdLocker) Lock(ctx context.Context, key string, opts ...lock.LockOption) (lock.Lease, error) {
+	client, err := e.conn()
+	if err != nil {
+		return nil, err
+	}
+	options := lock.NewLockOptions(opts...)
+
+	for {
+		lease, revision, err := e.tryLock(ctx, key, options)
+		if err != lock.ErrLocked {
+			return lease, err
+		}
+
+		// wait for the key to go, from the revision it was seen at
+		w := client.Watch(clientv3.WithRequireLeader(ctx), e.opts.Prefix+key, clientv3.WithRev(revision+1))
+		if err := waitDelete(ctx, w); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func waitDelete(ctx context.Context, w clientv3.WatchChan) error {
+	for resp := range w {
+		if err := resp.Err(); err != nil {
+			return err
+		}
+		for _, ev := range resp.Events {
+			if ev.Type == mvccpb.DELETE {
+				return nil
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+func (e *etcdLocker) TryLock(ctx context.Context, key string, opts ...lock.LockOption) (lock.Lease, error) {
+	lease, _, err := e.tryLock(ctx, key, lock.NewLockOptions(opts...))
+	return lease, err
+}
+
+// tryLock acquires key, or returns ErrLocked with the revision the holder
+// was seen at.
+func (e *etcdLocker) tryLock(ctx context.Context, key string, options lock.LockOptions) (lock.Lease, int64, error) {
+	client, err := e.conn()
+	if err != nil {
+		return nil, 0, err
+	}
+	k := e.opts.Prefix + key
+
+	grant, err := client.Grant(ctx, int64(math.Ceil(options.TTL.Seconds())))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(k), "=", 0)).
+		Then(clientv3.OpPut(k, "", clientv3.WithLease(grant.ID))).
+		Commit()
+	if err != nil || !resp.Succeeded {
+		_, _ = client.Revoke(context.Background(), grant.ID)
+		if err != nil {
+			return nil, 0, err
+		}
+		return nil, resp.Header.Revision, lock.ErrLocked
+	}
+
+	renew := func(ctx context.Context) error {
+		_, err := client.KeepAliveOnce(ctx, grant.ID)
+		if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+			return lock.ErrLeaseLost
+		}
+		return err
+	}
+	release := func(ctx context.Context) error {
+		// revoking the lease deletes the key
+		_, err := client.Revoke(ctx, grant.ID)
+		if errors.Is(err, rpctypes.ErrLeaseNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// etcd may grant a longer lease than asked for
+	ttl := time.Duration(grant.TTL) * time.Second
+
+	return lock.NewLease(key, uint64(resp.Header.Revision), ttl, renew, release), 0, nil
+}
+
+func (e *etcdLocker) Options() lock.Options {
+	return e.opts
+}
+
+func (e *etcdLocker) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.client == nil {
+		return nil
+	}
+	return e.client.Close()
+}
+
+func (e *etcdLocker) String() string {
+	return "etcd"
+}
@@ -0,0 +1,18 @@
+//go:build integration
+// +build integration
+
+package etcd
+
+import (
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/lock"
+	"go-micro.dev/v6/lock/locktest"
+)
+
+func TestConformance(t *testing.T) {
+	locktest.Run(t, func(t *testing.T) lock.Locker {
+		return NewLocker()
+	}, locktest.TTL(2*time.Second))
+}
@@ -0,0 +1,24 @@
+package etcd
+
+import (
+	"context"
+
+	"go-micro.dev/v6/lock"
+)
+
+type authKey struct{}
+
+type authCreds struct {
+	Username string
+	Password string
+}
+
+// Auth sets the username and password used to connect to etcd.
+func Auth(username, password string) lock.Option {
+	return func(o *lock.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, authKey{}, &authCreds{Username: username, Password: password})
+	}
+}
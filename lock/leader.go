@@ -0,0 +1,64 @@
+package lock
+
+import (
+	"context"
+	"time"
+
+	"go-micro.dev/v6/logger"
+)
+
+// DefaultRetryInterval is how long Leader waits before campaigning again
+// when the locker returns an error.
+var DefaultRetryInterval = time.Second
+
+// Leader runs fn while this process is the leader of name, for work only
+// one replica should do. It campaigns by locking name on l and passes fn
+// the lease, whose token fences the leader's writes. If the lease is lost,
+// fn's context is cancelled and Leader campaigns again once fn returns.
+//
+// Leader returns fn's error when fn returns while still leader, and ctx's
+// error if ctx is done while campaigning.
+func Leader(ctx context.Context, l Locker, name string, fn func(ctx context.Context, lease Lease) error, opts ...LockOption) error {
+	log := l.Options().Logger
+	if log == nil {
+		log = logger.DefaultLogger
+	}
+
+	for {
+		lease, err := l.Lock(ctx, name, opts...)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Logf(logger.ErrorLevel, "Leader %s campaign failed: %v", name, err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(DefaultRetryInterval):
+			}
+			continue
+		}
+
+		leaderCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-lease.Done():
+				cancel()
+			case <-leaderCtx.Done():
+			}
+		}()
+
+		err = fn(leaderCtx, lease)
+		cancel()
+
+		releaseCtx, releaseCancel := context.WithTimeout(context.Background(), DefaultRetryInterval)
+		lost := lease.Release(releaseCtx) == ErrLeaseLost
+		releaseCancel()
+
+		if lost && ctx.Err() == nil {
+			log.Logf(logger.InfoLevel, "Leader %s lost its lease, campaigning again", name)
+			continue
+		}
+		return err
+	}
+}
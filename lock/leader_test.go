@@ -0,0 +1,39 @@
+package lock
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLeaderCampaignsAgainAfterLoss(t *testing.T) {
+	l := NewMemoryLocker()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var terms int32
+	err := Leader(ctx, l, "leader", func(ctx context.Context, lease Lease) error {
+		if atomic.AddInt32(&terms, 1) == 1 {
+			// lose the first term by taking the lock from under it
+			m := l.(*memoryLocker)
+			m.mu.Lock()
+			m.holds[m.opts.Prefix+"leader"].expires = time.Now()
+			m.mu.Unlock()
+			other, err := l.TryLock(context.Background(), "leader")
+			if err != nil {
+				t.Fatalf("take over: %v", err)
+			}
+			<-ctx.Done()
+			other.Release(context.Background())
+			return ctx.Err()
+		}
+		return nil
+	}, TTL(90*time.Millisecond))
+	if err != nil {
+		t.Fatalf("leader: %v", err)
+	}
+	if terms != 2 {
+		t.Fatalf("%d terms, want 2", terms)
+	}
+}
@@ -0,0 +1,121 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// NewLease returns a Lease on key that calls renew every third of ttl
+// until it's released, for Locker implementations to hand out. The lease
+// is lost if renew returns ErrLeaseLost, or keeps failing until the next
+// attempt would come after ttl has run out. Release stops renewing and
+// calls release once.
+func NewLease(key string, token uint64, ttl time.Duration, renew, release func(ctx context.Context) error) Lease {
+	l := &lease{
+		key:     key,
+		token:   token,
+		ttl:     ttl,
+		renew:   renew,
+		release: release,
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go l.keepalive()
+	return l
+}
+
+type lease struct {
+	key            string
+	token          uint64
+	ttl            time.Duration
+	renew, release func(ctx context.Context) error
+
+	stop    chan struct{}
+	stopped chan struct{}
+	done    chan struct{}
+
+	mu         sync.Mutex
+	err        error
+	ended      bool
+	once       sync.Once
+	releaseErr error
+}
+
+func (l *lease) Key() string {
+	return l.key
+}
+
+func (l *lease) Token() uint64 {
+	return l.token
+}
+
+func (l *lease) Done() <-chan struct{} {
+	return l.done
+}
+
+func (l *lease) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+func (l *lease) Release(ctx context.Context) error {
+	l.once.Do(func() {
+		close(l.stop)
+		<-l.stopped
+
+		// release even a lost lease, in case the backend still has it
+		err := l.release(ctx)
+		l.end(nil)
+		if lost := l.Err(); lost != nil {
+			err = lost
+		}
+		l.releaseErr = err
+	})
+	return l.releaseErr
+}
+
+func (l *lease) keepalive() {
+	defer close(l.stopped)
+
+	interval := l.ttl / 3
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	deadline := time.Now().Add(l.ttl)
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		err := l.renew(ctx)
+		cancel()
+
+		switch {
+		case err == nil:
+			deadline = start.Add(l.ttl)
+		case errors.Is(err, ErrLeaseLost), time.Now().Add(interval).After(deadline):
+			l.end(ErrLeaseLost)
+			return
+		}
+	}
+}
+
+// end closes done with err, unless the lease has already ended.
+func (l *lease) end(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ended {
+		return
+	}
+	l.ended = true
+	l.err = err
+	close(l.done)
+}
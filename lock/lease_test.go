@@ -0,0 +1,56 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLeaseLost(t *testing.T) {
+	var released int32
+	lease := NewLease("k", 1, 30*time.Millisecond,
+		func(ctx context.Context) error { return ErrLeaseLost },
+		func(ctx context.Context) error { atomic.AddInt32(&released, 1); return nil },
+	)
+
+	select {
+	case <-lease.Done():
+	case <-time.After(time.Second):
+		t.Fatal("lease not done after failed renewal")
+	}
+	if err := lease.Err(); err != ErrLeaseLost {
+		t.Fatalf("err = %v, want ErrLeaseLost", err)
+	}
+	if err := lease.Release(context.Background()); err != ErrLeaseLost {
+		t.Fatalf("release = %v, want ErrLeaseLost", err)
+	}
+	if err := lease.Release(context.Background()); err != ErrLeaseLost {
+		t.Fatalf("second release = %v, want ErrLeaseLost", err)
+	}
+	if released != 1 {
+		t.Fatalf("release called %d times, want 1", released)
+	}
+}
+
+func TestLeaseLostAfterFailedRenewals(t *testing.T) {
+	// transient errors are retried until the ttl runs out
+	var renewals int32
+	lease := NewLease("k", 1, 60*time.Millisecond,
+		func(ctx context.Context) error { atomic.AddInt32(&renewals, 1); return errors.New("unreachable") },
+		func(ctx context.Context) error { return nil },
+	)
+
+	select {
+	case <-lease.Done():
+	case <-time.After(time.Second):
+		t.Fatal("lease not done after failed renewals")
+	}
+	if lease.Err() != ErrLeaseLost {
+		t.Fatalf("err = %v, want ErrLeaseLost", lease.Err())
+	}
+	if n := atomic.LoadInt32(&renewals); n < 2 {
+		t.Fatalf("%d renewals before losing the lease, want a retry", n)
+	}
+}
@@ -0,0 +1,61 @@
+// Package lock provides distributed locks and leader election, for work
+// that only one replica of a service should do at a time.
+//
+// A lock is held through a Lease, which the Locker keeps alive in the
+// background until it's released. Each lease carries a fencing token that
+// increases every time the key is acquired; pass it along with writes to
+// the resource the lock protects and reject any write carrying a lower
+// token than the last one seen, so a holder that stalled past its lease
+// can't overwrite the work of the next one.
+package lock
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// DefaultLocker is the default locker. Its locks are only shared
+	// within the process.
+	DefaultLocker Locker = NewMemoryLocker()
+	// DefaultTTL is how long a lease lasts without being renewed, which
+	// is how long a key stays locked after its holder dies.
+	DefaultTTL = 15 * time.Second
+
+	// ErrLocked is returned by TryLock when the key is held by someone else.
+	ErrLocked = errors.New("lock: key is locked")
+	// ErrLeaseLost is returned by Lease.Err when the lease couldn't be
+	// renewed before it expired, and the lock may now be held by someone else.
+	ErrLeaseLost = errors.New("lock: lease lost")
+)
+
+// Locker hands out exclusive leases on keys.
+type Locker interface {
+	// Lock blocks until it holds key or ctx is done.
+	Lock(ctx context.Context, key string, opts ...LockOption) (Lease, error)
+	// TryLock acquires key if it's free and returns ErrLocked if it isn't.
+	TryLock(ctx context.Context, key string, opts ...LockOption) (Lease, error)
+	// Options returns the locker's options.
+	Options() Options
+	// Close releases the locker's connections. Leases still held expire.
+	Close() error
+	// String returns the name of the implementation.
+	String() string
+}
+
+// Lease is a held lock.
+type Lease interface {
+	// Key is the locked key.
+	Key() string
+	// Token is the fencing token of this acquisition of the key.
+	Token() uint64
+	// Done is closed when the lease ends, because it was released or lost.
+	Done() <-chan struct{}
+	// Err is nil while the lease is held or once it's released, and
+	// ErrLeaseLost if it was lost.
+	Err() error
+	// Release gives up the lock. It returns ErrLeaseLost if the lease had
+	// already been lost.
+	Release(ctx context.Context) error
+}
@@ -0,0 +1,301 @@
+// Package locktest provides a conformance suite for lock.Locker
+// implementations. Run it from a test in the implementation's package:
+//
+//	func TestConformance(t *testing.T) {
+//		locktest.Run(t, func(t *testing.T) lock.Locker {
+//			return mylocker.NewLocker(lock.Nodes(addr))
+//		})
+//	}
+package locktest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go-micro.dev/v6/lock"
+)
+
+// Options configure a conformance run.
+type Options struct {
+	// TTL is the lease used by the renewal case. Defaults to one second;
+	// backends with a larger minimum should set theirs.
+	TTL time.Duration
+}
+
+type Option func(*Options)
+
+// TTL sets the lease used by the renewal case.
+func TTL(d time.Duration) Option {
+	return func(o *Options) {
+		o.TTL = d
+	}
+}
+
+// Run exercises the behaviour every locker must share. newLocker is called
+// once per case; the locker is closed when the case ends. Keys are random,
+// so the backend needn't be empty.
+func Run(t *testing.T, newLocker func(t *testing.T) lock.Locker, opts ...Option) {
+	options := Options{
+		TTL: time.Second,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	s := &suite{opts: options, newLocker: newLocker}
+
+	t.Run("TryLock", s.testTryLock)
+	t.Run("Token", s.testToken)
+	t.Run("LockWaits", s.testLockWaits)
+	t.Run("LockContext", s.testLockContext)
+	t.Run("Renewal", s.testRenewal)
+	t.Run("Exclusive", s.testExclusive)
+	t.Run("Leader", s.testLeader)
+}
+
+type suite struct {
+	opts      Options
+	newLocker func(t *testing.T) lock.Locker
+}
+
+func (s *suite) locker(t *testing.T) lock.Locker {
+	t.Helper()
+	l := s.newLocker(t)
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func key() string {
+	return "locktest-" + uuid.New().String()
+}
+
+func release(t *testing.T, lease lock.Lease) {
+	t.Helper()
+	if err := lease.Release(context.Background()); err != nil {
+		t.Fatalf("release %s: %v", lease.Key(), err)
+	}
+}
+
+func (s *suite) testTryLock(t *testing.T) {
+	l := s.locker(t)
+	ctx := context.Background()
+	k := key()
+
+	lease, err := l.TryLock(ctx, k)
+	if err != nil {
+		t.Fatalf("try lock: %v", err)
+	}
+	if lease.Key() != k {
+		t.Fatalf("lease key = %q, want %q", lease.Key(), k)
+	}
+	if _, err := l.TryLock(ctx, k); !errors.Is(err, lock.ErrLocked) {
+		t.Fatalf("second try lock = %v, want ErrLocked", err)
+	}
+
+	// other keys are independent
+	other, err := l.TryLock(ctx, key())
+	if err != nil {
+		t.Fatalf("try lock other key: %v", err)
+	}
+	release(t, other)
+
+	release(t, lease)
+	select {
+	case <-lease.Done():
+	default:
+		t.Fatal("lease not done after release")
+	}
+	if err := lease.Err(); err != nil {
+		t.Fatalf("released lease err = %v, want nil", err)
+	}
+
+	again, err := l.TryLock(ctx, k)
+	if err != nil {
+		t.Fatalf("try lock after release: %v", err)
+	}
+	release(t, again)
+}
+
+func (s *suite) testToken(t *testing.T) {
+	l := s.locker(t)
+	ctx := context.Background()
+	k := key()
+
+	var last uint64
+	for i := 0; i < 3; i++ {
+		lease, err := l.TryLock(ctx, k)
+		if err != nil {
+			t.Fatalf("try lock %d: %v", i, err)
+		}
+		if lease.Token() <= last {
+			t.Fatalf("token %d = %d, want more than %d", i, lease.Token(), last)
+		}
+		last = lease.Token()
+		release(t, lease)
+	}
+}
+
+func (s *suite) testLockWaits(t *testing.T) {
+	l := s.locker(t)
+	ctx := context.Background()
+	k := key()
+
+	first, err := l.Lock(ctx, k)
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+
+	acquired := make(chan lock.Lease, 1)
+	go func() {
+		lease, err := l.Lock(ctx, k)
+		if err != nil {
+			t.Errorf("waiting lock: %v", err)
+		}
+		acquired <- lease
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while held")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	release(t, first)
+
+	select {
+	case second := <-acquired:
+		if second == nil {
+			return
+		}
+		if second.Token() <= first.Token() {
+			t.Fatalf("token = %d, want more than %d", second.Token(), first.Token())
+		}
+		release(t, second)
+	case <-time.After(10 * time.Second):
+		t.Fatal("lock not acquired after release")
+	}
+}
+
+func (s *suite) testLockContext(t *testing.T) {
+	l := s.locker(t)
+	k := key()
+
+	lease, err := l.Lock(context.Background(), k)
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	defer release(t, lease)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := l.Lock(ctx, k); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("lock with expired context = %v, want DeadlineExceeded", err)
+	}
+}
+
+func (s *suite) testRenewal(t *testing.T) {
+	l := s.locker(t)
+	ctx := context.Background()
+	k := key()
+
+	lease, err := l.TryLock(ctx, k, lock.TTL(s.opts.TTL))
+	if err != nil {
+		t.Fatalf("try lock: %v", err)
+	}
+
+	// the lease is kept alive past its TTL
+	time.Sleep(2 * s.opts.TTL)
+
+	if _, err := l.TryLock(ctx, k); !errors.Is(err, lock.ErrLocked) {
+		t.Fatalf("try lock after ttl = %v, want ErrLocked", err)
+	}
+	if err := lease.Err(); err != nil {
+		t.Fatalf("lease err = %v, want nil", err)
+	}
+	release(t, lease)
+}
+
+func (s *suite) testExclusive(t *testing.T) {
+	l := s.locker(t)
+	k := key()
+
+	var (
+		wg      sync.WaitGroup
+		holders int32
+		mu      sync.Mutex
+		tokens  []uint64
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 3; j++ {
+				lease, err := l.Lock(context.Background(), k)
+				if err != nil {
+					t.Errorf("lock: %v", err)
+					return
+				}
+				if n := atomic.AddInt32(&holders, 1); n != 1 {
+					t.Errorf("%d holders at once", n)
+				}
+				mu.Lock()
+				tokens = append(tokens, lease.Token())
+				mu.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				atomic.AddInt32(&holders, -1)
+				if err := lease.Release(context.Background()); err != nil {
+					t.Errorf("release: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// tokens were recorded while held, so in acquisition order
+	for i := 1; i < len(tokens); i++ {
+		if tokens[i] <= tokens[i-1] {
+			t.Fatalf("tokens not increasing: %v", tokens)
+		}
+	}
+}
+
+func (s *suite) testLeader(t *testing.T) {
+	l := s.locker(t)
+	name := key()
+
+	var (
+		wg      sync.WaitGroup
+		leaders int32
+		terms   int32
+	)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			err := lock.Leader(ctx, l, name, func(ctx context.Context, lease lock.Lease) error {
+				if n := atomic.AddInt32(&leaders, 1); n != 1 {
+					t.Errorf("%d leaders at once", n)
+				}
+				atomic.AddInt32(&terms, 1)
+				time.Sleep(100 * time.Millisecond)
+				atomic.AddInt32(&leaders, -1)
+				return nil
+			})
+			if err != nil {
+				t.Errorf("leader: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if terms != 2 {
+		t.Fatalf("%d terms, want one per candidate", terms)
+	}
+}
@@ -0,0 +1,118 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// NewMemoryLocker returns a locker whose locks are only shared within the
+// process, for tests and single-instance services.
+func NewMemoryLocker(opts ...Option) Locker {
+	return &memoryLocker{
+		opts:   NewOptions(opts...),
+		holds:  make(map[string]*memoryHold),
+		tokens: make(map[string]uint64),
+	}
+}
+
+type memoryLocker struct {
+	opts Options
+
+	mu    sync.Mutex
+	holds map[string]*memoryHold
+	// tokens outlive holds so a key's token never goes backwards
+	tokens map[string]uint64
+}
+
+type memoryHold struct {
+	expires time.Time
+	// released is closed when the hold is released or taken over
+	released chan struct{}
+}
+
+func (m *memoryLocker) Lock(ctx context.Context, key string, opts ...LockOption) (Lease, error) {
+	for {
+		lease, released, expires, err := m.tryLock(key, NewLockOptions(opts...))
+		if err != ErrLocked {
+			return lease, err
+		}
+
+		// wait for the holder to release it, or for its lease to run out
+		timer := time.NewTimer(time.Until(expires))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-released:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+func (m *memoryLocker) TryLock(ctx context.Context, key string, opts ...LockOption) (Lease, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	lease, _, _, err := m.tryLock(key, NewLockOptions(opts...))
+	return lease, err
+}
+
+// tryLock acquires key, or returns ErrLocked with when the current hold is
+// released and when it expires unless renewed.
+func (m *memoryLocker) tryLock(key string, options LockOptions) (Lease, <-chan struct{}, time.Time, error) {
+	k := m.opts.Prefix + key
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if hold, ok := m.holds[k]; ok {
+		if now.Before(hold.expires) {
+			return nil, hold.released, hold.expires, ErrLocked
+		}
+		// the holder stopped renewing
+		close(hold.released)
+	}
+
+	hold := &memoryHold{
+		expires:  now.Add(options.TTL),
+		released: make(chan struct{}),
+	}
+	m.holds[k] = hold
+	m.tokens[k]++
+
+	renew := func(ctx context.Context) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.holds[k] != hold {
+			return ErrLeaseLost
+		}
+		hold.expires = time.Now().Add(options.TTL)
+		return nil
+	}
+	release := func(ctx context.Context) error {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.holds[k] == hold {
+			delete(m.holds, k)
+			close(hold.released)
+		}
+		return nil
+	}
+
+	return NewLease(key, m.tokens[k], options.TTL, renew, release), nil, time.Time{}, nil
+}
+
+func (m *memoryLocker) Options() Options {
+	return m.opts
+}
+
+func (m *memoryLocker) Close() error {
+	return nil
+}
+
+func (m *memoryLocker) String() string {
+	return "memory"
+}
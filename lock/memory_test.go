@@ -0,0 +1,14 @@
+package lock_test
+
+import (
+	"testing"
+
+	"go-micro.dev/v6/lock"
+	"go-micro.dev/v6/lock/locktest"
+)
+
+func TestMemoryConformance(t *testing.T) {
+	locktest.Run(t, func(t *testing.T) lock.Locker {
+		return lock.NewMemoryLocker()
+	})
+}
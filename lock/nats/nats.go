@@ -0,0 +1,246 @@
+// Package nats is a lock.Locker backed by a NATS JetStream key-value
+// bucket.
+//
+// A lock is a key holding the time its lease expires, written with
+// Create and renewed with Update against the last revision, so only one
+// holder's writes succeed. The revision is the fencing token. A lock whose
+// holder stopped renewing is taken over once its expiry passes, which
+// relies on the holders' clocks being roughly in step.
+package nats
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	natsp "github.com/nats-io/nats.go"
+	"go-micro.dev/v6/lock"
+)
+
+// DefaultBucket is the bucket used when none is set.
+var DefaultBucket = "micro_lock"
+
+type natsLocker struct {
+	opts   lock.Options
+	nopts  natsp.Options
+	bucket string
+
+	mu   sync.Mutex
+	conn *natsp.Conn
+	kv   natsp.KeyValue
+}
+
+// value is what a lock key holds.
+type value struct {
+	Expires time.Time `json:"expires"`
+}
+
+// NewLocker returns a locker on the NATS servers in lock.Nodes, or the
+// default URL if none are set. It connects on first use, so a connection
+// error is returned from Lock or TryLock.
+func NewLocker(opts ...lock.Option) lock.Locker {
+	options := lock.NewOptions(opts...)
+
+	nopts := natsp.GetDefaultOptions()
+	if o, ok := options.Context.Value(natsOptionsKey{}).(natsp.Options); ok {
+		nopts = o
+	}
+	if len(options.Nodes) > 0 {
+		nopts.Servers = options.Nodes
+	}
+	if len(nopts.Servers) == 0 && len(nopts.Url) == 0 {
+		nopts.Url = natsp.DefaultURL
+	}
+
+	bucket := DefaultBucket
+	if b, ok := options.Context.Value(bucketKey{}).(string); ok {
+		bucket = b
+	}
+
+	return &natsLocker{opts: options, nopts: nopts, bucket: bucket}
+}
+
+// bucketKV returns the lock bucket, connecting and creating the bucket if
+// this is the first use or the last attempt failed.
+func (n *natsLocker) bucketKV() (natsp.KeyValue, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.kv != nil {
+		return n.kv, nil
+	}
+
+	conn, err := n.nopts.Connect()
+	if err != nil {
+		return nil, err
+	}
+	js, err := conn.JetStream()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	kv, err := js.KeyValue(n.bucket)
+	if errors.Is(err, natsp.ErrBucketNotFound) {
+		kv, err = js.CreateKeyValue(&natsp.KeyValueConfig{
+			Bucket:      n.bucket,
+			Description: "Locks managed by go-micro",
+			History:     1,
+		})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	n.conn, n.kv = conn, kv
+	return kv, nil
+}
+
+// key maps key to a valid bucket key; the prefix is used as is.
+func (n *natsLocker) key(key string) string {
+	return strings.TrimSuffix(n.opts.Prefix, "/") + "." + base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+func (n *natsLocker) Lock(ctx context.Context, key string, opts ...lock.LockOption) (lock.Lease, error) {
+	kv, err := n.bucketKV()
+	if err != nil {
+		return nil, err
+	}
+	k := n.key(key)
+	options := lock.NewLockOptions(opts...)
+
+	for {
+		// watch before trying, so a release in between isn't missed
+		w, err := kv.Watch(k, natsp.UpdatesOnly(), natsp.Context(ctx))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+
+		lease, expires, err := n.tryLock(kv, key, options)
+		if err != lock.ErrLocked {
+			w.Stop()
+			return lease, err
+		}
+
+		err = wait(ctx, w, expires)
+		w.Stop()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait returns when the key is deleted, its expiry passes or ctx is done.
+func wait(ctx context.Context, w natsp.KeyWatcher, expires time.Time) error {
+	timer := time.NewTimer(time.Until(expires))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		case entry, ok := <-w.Updates():
+			if !ok {
+				return ctx.Err()
+			}
+			if entry != nil && entry.Operation() != natsp.KeyValuePut {
+				return nil
+			}
+		}
+	}
+}
+
+func (n *natsLocker) TryLock(ctx context.Context, key string, opts ...lock.LockOption) (lock.Lease, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	kv, err := n.bucketKV()
+	if err != nil {
+		return nil, err
+	}
+	lease, _, err := n.tryLock(kv, key, lock.NewLockOptions(opts...))
+	return lease, err
+}
+
+// tryLock acquires key, or returns ErrLocked with when the current lease
+// expires unless renewed.
+func (n *natsLocker) tryLock(kv natsp.KeyValue, key string, options lock.LockOptions) (lock.Lease, time.Time, error) {
+	k := n.key(key)
+
+	var revision uint64
+	entry, err := kv.Get(k)
+	switch {
+	case errors.Is(err, natsp.ErrKeyNotFound):
+		revision, err = kv.Create(k, encode(options.TTL))
+	case err != nil:
+		return nil, time.Time{}, err
+	default:
+		var v value
+		if err := json.Unmarshal(entry.Value(), &v); err == nil && time.Now().Before(v.Expires) {
+			return nil, v.Expires, lock.ErrLocked
+		}
+		// the holder stopped renewing
+		revision, err = kv.Update(k, encode(options.TTL), entry.Revision())
+	}
+	if errors.Is(err, natsp.ErrKeyExists) {
+		// someone else got there first
+		return nil, time.Now().Add(options.TTL), lock.ErrLocked
+	} else if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	token := revision
+	renew := func(ctx context.Context) error {
+		rev, err := kv.Update(k, encode(options.TTL), revision)
+		if errors.Is(err, natsp.ErrKeyExists) {
+			return lock.ErrLeaseLost
+		} else if err != nil {
+			return err
+		}
+		revision = rev
+		return nil
+	}
+	release := func(ctx context.Context) error {
+		err := kv.Delete(k, natsp.LastRevision(revision))
+		if errors.Is(err, natsp.ErrKeyExists) {
+			// it's no longer ours to delete
+			return nil
+		}
+		return err
+	}
+
+	return lock.NewLease(key, token, options.TTL, renew, release), time.Time{}, nil
+}
+
+func encode(ttl time.Duration) []byte {
+	b, _ := json.Marshal(value{Expires: time.Now().Add(ttl)})
+	return b
+}
+
+func (n *natsLocker) Options() lock.Options {
+	return n.opts
+}
+
+func (n *natsLocker) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn != nil {
+		n.conn.Close()
+	}
+	return nil
+}
+
+func (n *natsLocker) String() string {
+	return "nats"
+}
@@ -0,0 +1,84 @@
+package nats
+
+import (
+	"testing"
+	"time"
+
+	nserver "github.com/nats-io/nats-server/v2/server"
+	"go-micro.dev/v6/lock"
+	"go-micro.dev/v6/lock/locktest"
+)
+
+func startServer(t *testing.T) string {
+	t.Helper()
+
+	s, err := nserver.NewServer(&nserver.Options{
+		Host:      "127.0.0.1",
+		Port:      -1,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		t.Fatalf("nats server: %v", err)
+	}
+	go s.Start()
+	if !s.ReadyForConnections(10 * time.Second) {
+		t.Fatal("nats server not ready")
+	}
+	t.Cleanup(s.Shutdown)
+
+	return s.ClientURL()
+}
+
+func TestConformance(t *testing.T) {
+	addr := startServer(t)
+
+	locktest.Run(t, func(t *testing.T) lock.Locker {
+		return NewLocker(lock.Nodes(addr))
+	})
+}
+
+func TestTakeOverExpiredLock(t *testing.T) {
+	addr := startServer(t)
+	a := NewLocker(lock.Nodes(addr))
+	b := NewLocker(lock.Nodes(addr))
+	defer b.Close()
+
+	ctx := t.Context()
+	first, err := a.TryLock(ctx, "job", lock.TTL(300*time.Millisecond))
+	if err != nil {
+		t.Fatalf("try lock: %v", err)
+	}
+	// the holder goes away without releasing
+	a.Close()
+
+	second, err := b.Lock(ctx, "job")
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	defer second.Release(ctx)
+	if second.Token() <= first.Token() {
+		t.Fatalf("token = %d, want more than %d", second.Token(), first.Token())
+	}
+
+	select {
+	case <-first.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("abandoned lease not lost")
+	}
+	if first.Err() != lock.ErrLeaseLost {
+		t.Fatalf("abandoned lease err = %v, want ErrLeaseLost", first.Err())
+	}
+}
+
+func TestConnectOnUse(t *testing.T) {
+	// nothing listens here; the error comes from the first use
+	l := NewLocker(lock.Nodes("nats://127.0.0.1:1"))
+	defer l.Close()
+
+	if _, err := l.TryLock(t.Context(), "job"); err == nil {
+		t.Fatal("TryLock succeeded without a server")
+	}
+}
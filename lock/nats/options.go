@@ -0,0 +1,32 @@
+package nats
+
+import (
+	"context"
+
+	natsp "github.com/nats-io/nats.go"
+	"go-micro.dev/v6/lock"
+)
+
+type bucketKey struct{}
+type natsOptionsKey struct{}
+
+// Bucket sets the key-value bucket the locks are kept in. It's created if
+// it doesn't exist. Defaults to "micro_lock".
+func Bucket(name string) lock.Option {
+	return setOption(bucketKey{}, name)
+}
+
+// NatsOptions sets the options used to connect to NATS. Nodes, if set,
+// replace its servers.
+func NatsOptions(opts natsp.Options) lock.Option {
+	return setOption(natsOptionsKey{}, opts)
+}
+
+func setOption(k, v interface{}) lock.Option {
+	return func(o *lock.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, k, v)
+	}
+}
@@ -0,0 +1,97 @@
+package lock
+
+import (
+	"context"
+	"time"
+
+	"go-micro.dev/v6/logger"
+)
+
+// Options configure a Locker.
+type Options struct {
+	// Context should contain all implementation specific options, using context.WithValue.
+	Context context.Context
+	// Logger is the logger used by the locker
+	Logger logger.Logger
+	// Prefix is prepended to every key, so lockers sharing a backend
+	// don't collide. Defaults to "micro/lock/".
+	Prefix string
+	// Nodes are the addresses of the backend.
+	Nodes []string
+}
+
+// Option manipulates the Options passed.
+type Option func(o *Options)
+
+// Nodes sets the addresses of the backend.
+func Nodes(addrs ...string) Option {
+	return func(o *Options) {
+		o.Nodes = addrs
+	}
+}
+
+// Prefix sets the prefix prepended to every key.
+func Prefix(p string) Option {
+	return func(o *Options) {
+		o.Prefix = p
+	}
+}
+
+// WithContext sets the locker context, for any extra configuration.
+func WithContext(c context.Context) Option {
+	return func(o *Options) {
+		o.Context = c
+	}
+}
+
+// WithLogger sets the underlying logger.
+func WithLogger(l logger.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// NewOptions returns options with the defaults applied.
+func NewOptions(opts ...Option) Options {
+	options := Options{
+		Context: context.Background(),
+		Logger:  logger.DefaultLogger,
+		Prefix:  "micro/lock/",
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
+
+// LockOptions configure a single Lock or TryLock.
+type LockOptions struct {
+	// TTL is how long the lease lasts without being renewed.
+	TTL time.Duration
+}
+
+// LockOption manipulates the LockOptions passed.
+type LockOption func(o *LockOptions)
+
+// TTL sets how long the lease lasts without being renewed. Backends may
+// round it up to their minimum.
+func TTL(d time.Duration) LockOption {
+	return func(o *LockOptions) {
+		o.TTL = d
+	}
+}
+
+// NewLockOptions returns lock options with the defaults applied.
+func NewLockOptions(opts ...LockOption) LockOptions {
+	options := LockOptions{
+		TTL: DefaultTTL,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
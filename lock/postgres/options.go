@@ -0,0 +1,20 @@
+package postgres
+
+import (
+	"context"
+
+	"go-micro.dev/v6/lock"
+)
+
+type tableKey struct{}
+
+// Table sets the name of the table that keeps each key's fencing token.
+// Defaults to "micro_lock_tokens".
+func Table(name string) lock.Option {
+	return func(o *lock.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, tableKey{}, name)
+	}
+}
@@ -0,0 +1,203 @@
+// Package postgres is a lock.Locker backed by Postgres advisory locks, for
+// deployments that already run a database.
+//
+// Each lease holds a session-level advisory lock on its own connection,
+// so Postgres releases it if the holder's connection drops. Keys are
+// hashed to the lock's 64-bit id, so two keys may rarely contend for the
+// same lock. The fencing token is a per-key counter kept in a table.
+//
+// Advisory locks don't expire: the lease TTL only sets how often the
+// connection is checked, and the lease is lost once it can't be.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	// the postgres driver
+	_ "github.com/lib/pq"
+
+	"go-micro.dev/v6/lock"
+)
+
+var (
+	// DefaultAddress is the database used when no node is set.
+	DefaultAddress = "postgresql://postgres@localhost:5432/?sslmode=disable"
+	// DefaultTable is the token table used when none is set.
+	DefaultTable = "micro_lock_tokens"
+)
+
+type postgresLocker struct {
+	opts    lock.Options
+	address string
+	table   string
+
+	mu sync.Mutex
+	db *sql.DB
+}
+
+// NewLocker returns a locker on the database at the first of lock.Nodes,
+// or DefaultAddress if none are set. It connects and creates the token
+// table on first use, so those errors are returned from Lock or TryLock.
+func NewLocker(opts ...lock.Option) lock.Locker {
+	options := lock.NewOptions(opts...)
+
+	address := DefaultAddress
+	if len(options.Nodes) > 0 {
+		address = options.Nodes[0]
+	}
+	table := DefaultTable
+	if t, ok := options.Context.Value(tableKey{}).(string); ok {
+		table = t
+	}
+
+	return &postgresLocker{opts: options, address: address, table: table}
+}
+
+// conn returns the database, connecting and creating the token table if
+// this is the first use or the last attempt failed.
+func (p *postgresLocker) conn(ctx context.Context) (*sql.DB, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.db != nil {
+		return p.db, nil
+	}
+
+	db, err := sql.Open("postgres", p.address)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect to database: %w", err)
+	}
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+p.table+` (
+		key TEXT PRIMARY KEY,
+		token BIGINT NOT NULL
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create token table: %w", err)
+	}
+
+	p.db = db
+	return db, nil
+}
+
+// id hashes key to an advisory lock id.
+func (p *postgresLocker) id(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(p.opts.Prefix + key))
+	return int64(h.Sum64())
+}
+
+func (p *postgresLocker) Lock(ctx context.Context, key string, opts ...lock.LockOption) (lock.Lease, error) {
+	db, err := p.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// blocks in Postgres until the lock is free or ctx is cancelled
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, p.id(key)); err != nil {
+		// the lock may have been taken as the query was cancelled
+		discard(conn)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	return p.lease(ctx, conn, key, lock.NewLockOptions(opts...))
+}
+
+func (p *postgresLocker) TryLock(ctx context.Context, key string, opts ...lock.LockOption) (lock.Lease, error) {
+	db, err := p.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, p.id(key)).Scan(&locked); err != nil {
+		discard(conn)
+		return nil, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, lock.ErrLocked
+	}
+
+	return p.lease(ctx, conn, key, lock.NewLockOptions(opts...))
+}
+
+// lease takes the next token for key, whose lock conn holds.
+func (p *postgresLocker) lease(ctx context.Context, conn *sql.Conn, key string, options lock.LockOptions) (lock.Lease, error) {
+	id := p.id(key)
+
+	var token uint64
+	err := conn.QueryRowContext(ctx, `INSERT INTO `+p.table+` AS t (key, token) VALUES ($1, 1)
+		ON CONFLICT (key) DO UPDATE SET token = t.token + 1
+		RETURNING token`, p.opts.Prefix+key).Scan(&token)
+	if err != nil {
+		discard(conn)
+		return nil, err
+	}
+
+	renew := func(ctx context.Context) error {
+		_, err := conn.ExecContext(ctx, `SELECT 1`)
+		if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+			return lock.ErrLeaseLost
+		}
+		return err
+	}
+	release := func(ctx context.Context) error {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, id); err != nil {
+			discard(conn)
+			return err
+		}
+		return conn.Close()
+	}
+
+	return lock.NewLease(key, token, options.TTL, renew, release), nil
+}
+
+// discard closes conn's connection rather than returning it to the pool,
+// which drops any lock it holds.
+func discard(conn *sql.Conn) {
+	_ = conn.Raw(func(interface{}) error {
+		return driver.ErrBadConn
+	})
+	conn.Close()
+}
+
+func (p *postgresLocker) Options() lock.Options {
+	return p.opts
+}
+
+func (p *postgresLocker) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.db == nil {
+		return nil
+	}
+	return p.db.Close()
+}
+
+func (p *postgresLocker) String() string {
+	return "postgres"
+}
@@ -0,0 +1,17 @@
+//go:build integration
+// +build integration
+
+package postgres
+
+import (
+	"testing"
+
+	"go-micro.dev/v6/lock"
+	"go-micro.dev/v6/lock/locktest"
+)
+
+func TestConformance(t *testing.T) {
+	locktest.Run(t, func(t *testing.T) lock.Locker {
+		return NewLocker()
+	})
+}
@@ -9,6 +9,7 @@ import (
 	"go-micro.dev/v6/ai"
 	"go-micro.dev/v6/client"
 	"go-micro.dev/v6/flow"
+	"go-micro.dev/v6/prompt"
 	"go-micro.dev/v6/server"
 	"go-micro.dev/v6/service"
 	"go-micro.dev/v6/store"
@@ -92,6 +93,20 @@ func AgentServices(names ...string) AgentOption { return agent.Services(names...
 // AgentPrompt sets the agent's system prompt.
 func AgentPrompt(p string) AgentOption { return agent.Prompt(p) }
 
+// AgentModelCache serves the agent's repeated model requests from a
+// response cache; see ai.Cached.
+func AgentModelCache(opts ...ai.CacheOption) AgentOption { return agent.ModelCache(opts...) }
+
+// AgentModelParams sets the generation parameters of the agent's model
+// requests; see ai.Params.
+func AgentModelParams(p ai.Params) AgentOption { return agent.ModelParams(p) }
+
+// AgentPromptFrom takes the agent's system prompt from a versioned
+// prompt registry, recording the version each run used.
+func AgentPromptFrom(reg *prompt.Registry, name string) AgentOption {
+	return agent.PromptFrom(reg, name)
+}
+
 // AgentProvider sets the LLM provider.
 func AgentProvider(p string) AgentOption { return agent.Provider(p) }
 
@@ -121,6 +136,13 @@ func AgentLoopLimit(n int) AgentOption { return agent.LoopLimit(n) }
 // each action the agent takes.
 func AgentApproveTool(fn ApproveFunc) AgentOption { return agent.ApproveTool(fn) }
 
+// Guardrail checks the content flowing through an agent.
+type Guardrail = agent.Guardrail
+
+// AgentGuard adds content guardrails over user messages, tool results and
+// the model's replies: agent.RedactPII, agent.ScreenInjection and friends.
+func AgentGuard(g ...Guardrail) AgentOption { return agent.Guard(g...) }
+
 // AgentMaxSpend bounds paid x402 tool spend per Ask, in the asset's smallest
 // unit (0 = disabled). Calls that would exceed it are refused before payment.
 func AgentMaxSpend(amount int64) AgentOption { return agent.MaxSpend(amount) }
@@ -0,0 +1,378 @@
+// Package prompt is a versioned prompt registry kept in a store.Store.
+//
+// Each push of a prompt's text adds a new immutable version. Labels such
+// as "prod" and "canary" point at versions, and a rollout sends a
+// percentage of traffic to a label's version, for staged rollouts and A/B
+// experiments:
+//
+//	reg := prompt.NewRegistry(store.DefaultStore)
+//	v, _ := reg.Push("support", "You are the support agent...", "tone down refunds")
+//	_ = reg.SetLabel("support", "canary", v.Version)
+//	_ = reg.SetRollout("support", "canary", 10) // 10% canary, the rest prod
+//
+// Agents and flows resolve their prompt with agent.PromptFrom and
+// flow.PromptFrom and record the version each run used, so flow.Analyze
+// can compare prompt versions. They bucket a run on the key set with
+// WithKey, such as a session or user id, or on the run id without one.
+package prompt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"time"
+
+	"go-micro.dev/v6/store"
+)
+
+// DefaultLabel is the label that gets the traffic no rollout claims.
+const DefaultLabel = "prod"
+
+// ErrNotFound is returned for a prompt, version or label that doesn't exist.
+var ErrNotFound = errors.New("prompt: not found")
+
+// Version is one immutable revision of a prompt's text.
+type Version struct {
+	Name    string    `json:"name"`
+	Version int       `json:"version"`
+	Text    string    `json:"text"`
+	Note    string    `json:"note,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// Ref returns "<name>@<version>".
+func (v *Version) Ref() string {
+	return fmt.Sprintf("%s@%d", v.Name, v.Version)
+}
+
+// Prompt is a prompt's labels and rollout.
+type Prompt struct {
+	Name   string `json:"name"`
+	Latest int    `json:"latest"`
+	// Labels point names like "prod" and "canary" at versions.
+	Labels map[string]int `json:"labels,omitempty"`
+	// Rollout sends a percentage of traffic to a label's version. The
+	// rest goes to DefaultLabel, or the latest version without one.
+	Rollout map[string]int `json:"rollout,omitempty"`
+	Updated time.Time      `json:"updated"`
+}
+
+// Registry keeps prompts in a store: the "prompt" database, "prompts"
+// table, with each prompt under "<name>/meta" and its versions under
+// "<name>/versions/<n>".
+type Registry struct {
+	st store.Store
+}
+
+// NewRegistry returns a registry kept in s, or store.DefaultStore if s is nil.
+func NewRegistry(s store.Store) *Registry {
+	if s == nil {
+		s = store.DefaultStore
+	}
+	return &Registry{st: store.Scope(s, "prompt", "prompts")}
+}
+
+func metaKey(name string) string { return name + "/meta" }
+
+func versionKey(name string, version int) string {
+	return fmt.Sprintf("%s/versions/%06d", name, version)
+}
+
+// Push adds text as the next version of name, creating the prompt if it's
+// new. It doesn't move any label. The version is written before Latest
+// moves to it, so Latest never names a version that isn't there.
+func (r *Registry) Push(name, text, note string) (*Version, error) {
+	if name == "" || strings.ContainsAny(name, "/@") {
+		return nil, fmt.Errorf("prompt: invalid name %q", name)
+	}
+	if text == "" {
+		return nil, fmt.Errorf("prompt %s: empty text", name)
+	}
+	p, _, err := r.read(name)
+	if err != nil {
+		return nil, err
+	}
+	n := 1
+	if p != nil {
+		n = p.Latest + 1
+	}
+	v := &Version{Name: name, Text: text, Note: note, Created: time.Now()}
+	for attempt := 0; ; attempt++ {
+		v.Version = n
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		// claim the version; a concurrent push, or one that failed
+		// before moving Latest, may already hold it
+		rec := &store.Record{Key: versionKey(name, n), Value: b}
+		err = store.CompareAndSwap(r.st, rec)
+		if errors.Is(err, store.ErrNotSupported) {
+			err = r.st.Write(rec)
+		}
+		if errors.Is(err, store.ErrConflict) && attempt < 10 {
+			n++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		break
+	}
+	if err := r.update(name, true, func(p *Prompt) error {
+		p.Latest = max(p.Latest, n)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Get returns a version of name; version 0 is the latest.
+func (r *Registry) Get(name string, version int) (*Version, error) {
+	if version == 0 {
+		p, err := r.Info(name)
+		if err != nil {
+			return nil, err
+		}
+		version = p.Latest
+	}
+	recs, err := r.st.Read(versionKey(name, version))
+	if errors.Is(err, store.ErrNotFound) || (err == nil && len(recs) == 0) {
+		return nil, fmt.Errorf("%w: %s@%d", ErrNotFound, name, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var v Version
+	if err := json.Unmarshal(recs[0].Value, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Labeled returns the version label points at.
+func (r *Registry) Labeled(name, label string) (*Version, error) {
+	p, err := r.Info(name)
+	if err != nil {
+		return nil, err
+	}
+	n, ok := p.Labels[label]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s has no label %q", ErrNotFound, name, label)
+	}
+	return r.Get(name, n)
+}
+
+// Versions returns every version of name, oldest first.
+func (r *Registry) Versions(name string) ([]*Version, error) {
+	keys, err := r.st.List(store.ListPrefix(name + "/versions/"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	out := make([]*Version, 0, len(keys))
+	for _, k := range keys {
+		recs, err := r.st.Read(k)
+		if err != nil || len(recs) == 0 {
+			continue
+		}
+		var v Version
+		if json.Unmarshal(recs[0].Value, &v) == nil {
+			out = append(out, &v)
+		}
+	}
+	return out, nil
+}
+
+// Info returns name's labels and rollout.
+func (r *Registry) Info(name string) (*Prompt, error) {
+	p, _, err := r.read(name)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return p, nil
+}
+
+// List returns every prompt, by name.
+func (r *Registry) List() ([]*Prompt, error) {
+	keys, err := r.st.List(store.ListSuffix("/meta"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	out := make([]*Prompt, 0, len(keys))
+	for _, k := range keys {
+		if p, _, err := r.read(strings.TrimSuffix(k, "/meta")); err == nil && p != nil {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// SetLabel points label at version of name.
+func (r *Registry) SetLabel(name, label string, version int) error {
+	if label == "" {
+		return fmt.Errorf("prompt %s: empty label", name)
+	}
+	if _, err := r.Get(name, version); err != nil {
+		return err
+	}
+	return r.update(name, false, func(p *Prompt) error {
+		if p.Labels == nil {
+			p.Labels = map[string]int{}
+		}
+		p.Labels[label] = version
+		return nil
+	})
+}
+
+// SetRollout sends percent of name's traffic to label's version; 0 ends
+// the rollout. The rollouts of a prompt add up to at most 100.
+func (r *Registry) SetRollout(name, label string, percent int) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("prompt %s: rollout of %d%% is out of range", name, percent)
+	}
+	return r.update(name, false, func(p *Prompt) error {
+		if _, ok := p.Labels[label]; !ok && percent > 0 {
+			return fmt.Errorf("%w: %s has no label %q", ErrNotFound, name, label)
+		}
+		total := percent
+		for l, pc := range p.Rollout {
+			if l != label {
+				total += pc
+			}
+		}
+		if total > 100 {
+			return fmt.Errorf("prompt %s: rollouts would add up to %d%%", name, total)
+		}
+		if percent == 0 {
+			delete(p.Rollout, label)
+			return nil
+		}
+		if p.Rollout == nil {
+			p.Rollout = map[string]int{}
+		}
+		p.Rollout[label] = percent
+		return nil
+	})
+}
+
+// Resolve picks the version of name to serve for key, such as a session
+// or user id. A key always lands in the same bucket, so a user stays on
+// one side of an experiment while the rollout is unchanged.
+func (r *Registry) Resolve(name, key string) (*Version, error) {
+	p, err := r.Info(name)
+	if err != nil {
+		return nil, err
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name + "/" + key))
+	bucket := int(h.Sum32() % 100)
+
+	labels := make([]string, 0, len(p.Rollout))
+	for l := range p.Rollout {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	cum := 0
+	for _, l := range labels {
+		cum += p.Rollout[l]
+		if bucket < cum {
+			if n, ok := p.Labels[l]; ok {
+				return r.Get(name, n)
+			}
+		}
+	}
+	if n, ok := p.Labels[DefaultLabel]; ok {
+		return r.Get(name, n)
+	}
+	return r.Get(name, p.Latest)
+}
+
+func (r *Registry) read(name string) (*Prompt, uint64, error) {
+	recs, err := r.st.Read(metaKey(name))
+	if errors.Is(err, store.ErrNotFound) || (err == nil && len(recs) == 0) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	var p Prompt
+	if err := json.Unmarshal(recs[0].Value, &p); err != nil {
+		return nil, 0, err
+	}
+	return &p, recs[0].Revision, nil
+}
+
+// update applies fn to name's metadata and saves it, retrying when a
+// concurrent writer got there first on stores with compare-and-swap.
+func (r *Registry) update(name string, create bool, fn func(*Prompt) error) error {
+	for attempt := 0; ; attempt++ {
+		p, rev, err := r.read(name)
+		if err != nil {
+			return err
+		}
+		if p == nil {
+			if !create {
+				return fmt.Errorf("%w: %s", ErrNotFound, name)
+			}
+			p = &Prompt{Name: name}
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+		p.Updated = time.Now()
+		b, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		rec := &store.Record{Key: metaKey(name), Value: b, Revision: rev}
+		err = store.CompareAndSwap(r.st, rec)
+		if errors.Is(err, store.ErrNotSupported) {
+			return r.st.Write(rec)
+		}
+		if errors.Is(err, store.ErrConflict) && attempt < 10 {
+			continue
+		}
+		return err
+	}
+}
+
+type versionKeyCtx struct{}
+type resolveKeyCtx struct{}
+
+// WithKey returns ctx carrying the key that agents and flows started
+// with it pass to Resolve, such as a session or user id. Without one they
+// pass the run id, so each run of the same user can land on a different
+// side of an experiment.
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, resolveKeyCtx{}, key)
+}
+
+// KeyFrom returns the key carried by ctx, or fallback when it has none.
+func KeyFrom(ctx context.Context, fallback string) string {
+	if key, ok := ctx.Value(resolveKeyCtx{}).(string); ok && key != "" {
+		return key
+	}
+	return fallback
+}
+
+// NewContext returns ctx carrying the prompt version a run resolved, so
+// code inside the run, such as a flow step, can use it.
+func NewContext(ctx context.Context, v *Version) context.Context {
+	return context.WithValue(ctx, versionKeyCtx{}, v)
+}
+
+// FromContext returns the prompt version carried by ctx.
+func FromContext(ctx context.Context) (*Version, bool) {
+	v, ok := ctx.Value(versionKeyCtx{}).(*Version)
+	return v, ok && v != nil
+}
@@ -0,0 +1,178 @@
+package prompt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"go-micro.dev/v6/store"
+)
+
+func TestRegistryVersionsAndLabels(t *testing.T) {
+	reg := NewRegistry(store.NewMemoryStore())
+	if _, err := reg.Get("support", 0); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get of a missing prompt = %v, want ErrNotFound", err)
+	}
+	v1, err := reg.Push("support", "You are support.", "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, _ := reg.Push("support", "You are friendly support.", "")
+	if v1.Version != 1 || v2.Version != 2 || v2.Ref() != "support@2" {
+		t.Fatalf("versions = %d, %d (%s)", v1.Version, v2.Version, v2.Ref())
+	}
+	if _, err := reg.Push("bad/name", "x", ""); err == nil {
+		t.Fatal("pushed a prompt named with a slash")
+	}
+
+	if latest, _ := reg.Get("support", 0); latest.Text != "You are friendly support." {
+		t.Fatalf("latest = %+v", latest)
+	}
+	if err := reg.SetLabel("support", "prod", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.SetLabel("support", "prod", 9); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("labeling a missing version = %v", err)
+	}
+	if v, _ := reg.Labeled("support", "prod"); v.Version != 1 {
+		t.Fatalf("prod = %+v", v)
+	}
+	versions, _ := reg.Versions("support")
+	if len(versions) != 2 || versions[0].Note != "first" {
+		t.Fatalf("versions = %+v", versions)
+	}
+	reg.Push("billing", "You are billing.", "")
+	prompts, _ := reg.List()
+	if len(prompts) != 2 || prompts[0].Name != "billing" || prompts[1].Labels["prod"] != 1 {
+		t.Fatalf("prompts = %+v", prompts)
+	}
+}
+
+func TestRegistryResolveRollout(t *testing.T) {
+	reg := NewRegistry(store.NewMemoryStore())
+	reg.Push("support", "v1", "")
+	reg.Push("support", "v2", "")
+	reg.Push("support", "v3", "")
+
+	// no labels: the latest version
+	if v, _ := reg.Resolve("support", "run-1"); v.Version != 3 {
+		t.Fatalf("unlabeled resolve = %d", v.Version)
+	}
+	reg.SetLabel("support", "prod", 1)
+	reg.SetLabel("support", "canary", 2)
+	if err := reg.SetRollout("support", "canary", 25); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.SetRollout("support", "missing", 10); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("rollout of a missing label = %v", err)
+	}
+	reg.SetLabel("support", "other", 3)
+	if err := reg.SetRollout("support", "other", 80); err == nil {
+		t.Fatal("rollouts over 100% were accepted")
+	}
+
+	counts := map[int]int{}
+	for i := 0; i < 2000; i++ {
+		key := fmt.Sprintf("run-%d", i)
+		v, err := reg.Resolve("support", key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[v.Version]++
+		if again, _ := reg.Resolve("support", key); again.Version != v.Version {
+			t.Fatalf("%s resolved to %d then %d", key, v.Version, again.Version)
+		}
+	}
+	if counts[3] != 0 || counts[2] < 400 || counts[2] > 600 {
+		t.Fatalf("25%% canary split = %v", counts)
+	}
+
+	if err := reg.SetRollout("support", "canary", 0); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		if v, _ := reg.Resolve("support", fmt.Sprint(i)); v.Version != 1 {
+			t.Fatalf("ended rollout resolved to %d", v.Version)
+		}
+	}
+}
+
+// failingStore fails writes of version records while fail is set.
+type failingStore struct {
+	store.Store
+	fail bool
+}
+
+func (s *failingStore) Write(r *store.Record, opts ...store.WriteOption) error {
+	if s.fail && strings.Contains(r.Key, "/versions/") {
+		return errors.New("store down")
+	}
+	return s.Store.Write(r, opts...)
+}
+
+func TestRegistryPushWritesVersionFirst(t *testing.T) {
+	st := &failingStore{Store: store.NewMemoryStore()}
+	reg := NewRegistry(st)
+	if _, err := reg.Push("support", "You are support.", ""); err != nil {
+		t.Fatal(err)
+	}
+	st.fail = true
+	if _, err := reg.Push("support", "You are friendly support.", ""); err == nil {
+		t.Fatal("Push succeeded without writing the version")
+	}
+	// latest still names a version that exists
+	if v, err := reg.Get("support", 0); err != nil || v.Version != 1 {
+		t.Fatalf("latest after a failed push = %+v, %v", v, err)
+	}
+}
+
+func TestRegistryConcurrentPush(t *testing.T) {
+	reg := NewRegistry(store.NewMemoryStore())
+	const pushes = 10
+	var wg sync.WaitGroup
+	versions := make([]int, pushes)
+	for i := range pushes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := reg.Push("support", fmt.Sprint("text ", i), "")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			versions[i] = v.Version
+		}()
+	}
+	wg.Wait()
+
+	seen := map[int]bool{}
+	for _, n := range versions {
+		if seen[n] {
+			t.Fatalf("versions = %v, want each push its own", versions)
+		}
+		seen[n] = true
+	}
+	if p, _ := reg.Info("support"); p.Latest != pushes {
+		t.Fatalf("latest = %d, want %d", p.Latest, pushes)
+	}
+}
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Fatal("empty context carried a version")
+	}
+	ctx := NewContext(context.Background(), &Version{Name: "support", Version: 4})
+	if v, ok := FromContext(ctx); !ok || v.Ref() != "support@4" {
+		t.Fatalf("FromContext = %+v, %v", v, ok)
+	}
+
+	if key := KeyFrom(ctx, "run-1"); key != "run-1" {
+		t.Fatalf("KeyFrom without a key = %q, want the fallback", key)
+	}
+	if key := KeyFrom(WithKey(ctx, "user-42"), "run-1"); key != "user-42" {
+		t.Fatalf("KeyFrom = %q, want user-42", key)
+	}
+}
@@ -0,0 +1,270 @@
+// Package dns provides a read-only registry that resolves services from
+// DNS SRV records, for deployments that already publish services in DNS
+// and want no registry server.
+//
+// A service named greeter is resolved from the SRV record
+// _greeter._tcp.<domain>; each target and port becomes a node, with the
+// record's priority and weight in the node metadata. TXT records on the
+// same name add the service version ("version=1.0.0") and metadata
+// ("key=value").
+//
+// Register and Deregister do nothing: records are managed in DNS. Watch
+// resolves the known services every refresh interval and reports what
+// changed, so the registry works behind registry/cache and the selector
+// like any other.
+package dns
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go-micro.dev/v6/logger"
+	"go-micro.dev/v6/registry"
+	"go-micro.dev/v6/registry/internal/snapshot"
+)
+
+// DefaultRefreshInterval is how often watched services are resolved.
+var DefaultRefreshInterval = 30 * time.Second
+
+type dnsRegistry struct {
+	options  registry.Options
+	domain   string
+	interval time.Duration
+	resolver *net.Resolver
+	snap     *snapshot.Snapshot
+
+	sync.Mutex
+	names   map[string]bool
+	running bool
+}
+
+// NewRegistry returns a DNS registry. registry.Addrs sets the DNS server
+// to query; the system resolver is used otherwise.
+func NewRegistry(opts ...registry.Option) registry.Registry {
+	d := &dnsRegistry{
+		snap:  snapshot.New(),
+		names: make(map[string]bool),
+	}
+	configure(d, opts...)
+	return d
+}
+
+func configure(d *dnsRegistry, opts ...registry.Option) {
+	for _, o := range opts {
+		o(&d.options)
+	}
+
+	if d.options.Timeout == 0 {
+		d.options.Timeout = 5 * time.Second
+	}
+	if d.options.Logger == nil {
+		d.options.Logger = logger.DefaultLogger
+	}
+
+	d.interval = DefaultRefreshInterval
+	if ctx := d.options.Context; ctx != nil {
+		if v, ok := ctx.Value(domainKey{}).(string); ok {
+			d.domain = strings.Trim(v, ".")
+		}
+		if v, ok := ctx.Value(refreshKey{}).(time.Duration); ok && v > 0 {
+			d.interval = v
+		}
+		if v, ok := ctx.Value(servicesKey{}).([]string); ok {
+			d.Lock()
+			for _, n := range v {
+				d.names[n] = true
+			}
+			d.Unlock()
+		}
+	}
+
+	d.resolver = net.DefaultResolver
+	for _, a := range d.options.Addrs {
+		if len(a) == 0 {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(a); err != nil {
+			a = net.JoinHostPort(a, "53")
+		}
+		d.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, a)
+			},
+		}
+		break
+	}
+}
+
+func (d *dnsRegistry) Init(opts ...registry.Option) error {
+	configure(d, opts...)
+	return nil
+}
+
+func (d *dnsRegistry) Options() registry.Options {
+	return d.options
+}
+
+// Register is a no-op: services are published as DNS records.
+func (d *dnsRegistry) Register(s *registry.Service, opts ...registry.RegisterOption) error {
+	d.options.Logger.Logf(logger.TraceLevel, "dns registry is read-only, not registering %s", s.Name)
+	return nil
+}
+
+// Deregister is a no-op: services are published as DNS records.
+func (d *dnsRegistry) Deregister(s *registry.Service, opts ...registry.DeregisterOption) error {
+	return nil
+}
+
+func (d *dnsRegistry) GetService(name string, opts ...registry.GetOption) ([]*registry.Service, error) {
+	d.Lock()
+	d.names[name] = true
+	d.Unlock()
+
+	services, err := d.resolve(name)
+	if err != nil {
+		return nil, err
+	}
+	d.snap.Set(name, services)
+	if len(services) == 0 {
+		return nil, registry.ErrNotFound
+	}
+	return services, nil
+}
+
+func (d *dnsRegistry) ListServices(opts ...registry.ListOption) ([]*registry.Service, error) {
+	d.refresh()
+	return d.snap.List(), nil
+}
+
+func (d *dnsRegistry) Watch(opts ...registry.WatchOption) (registry.Watcher, error) {
+	var wo registry.WatchOptions
+	for _, o := range opts {
+		o(&wo)
+	}
+
+	d.Lock()
+	if len(wo.Service) > 0 && !d.names[wo.Service] {
+		d.names[wo.Service] = true
+		d.Unlock()
+		// seed the snapshot so the watcher only reports later changes
+		if services, err := d.resolve(wo.Service); err == nil {
+			d.snap.Set(wo.Service, services)
+		}
+		d.Lock()
+	}
+	if !d.running {
+		d.running = true
+		go d.run()
+	}
+	d.Unlock()
+
+	return d.snap.Watch(opts...), nil
+}
+
+func (d *dnsRegistry) String() string {
+	return "dns"
+}
+
+// run resolves the known services every interval.
+func (d *dnsRegistry) run() {
+	t := time.NewTicker(d.interval)
+	defer t.Stop()
+
+	for range t.C {
+		d.refresh()
+	}
+}
+
+// refresh resolves every known service. Names that fail to resolve keep
+// their last result rather than being reported as gone.
+func (d *dnsRegistry) refresh() {
+	d.Lock()
+	names := make([]string, 0, len(d.names))
+	for n := range d.names {
+		names = append(names, n)
+	}
+	d.Unlock()
+
+	for _, n := range names {
+		services, err := d.resolve(n)
+		if err != nil {
+			d.options.Logger.Logf(logger.DebugLevel, "dns registry: resolve %s: %v", n, err)
+			continue
+		}
+		d.snap.Set(n, services)
+	}
+}
+
+// resolve looks up the SRV and TXT records of name. A name with no
+// records resolves to no services and no error.
+func (d *dnsRegistry) resolve(name string) ([]*registry.Service, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.options.Timeout)
+	defer cancel()
+
+	record := recordName(name, d.domain)
+
+	_, srvs, err := d.resolver.LookupSRV(ctx, "", "", record)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	svc := &registry.Service{
+		Name:     name,
+		Metadata: map[string]string{},
+	}
+
+	txts, err := d.resolver.LookupTXT(ctx, record)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	for _, txt := range txts {
+		k, v, ok := strings.Cut(txt, "=")
+		if !ok {
+			continue
+		}
+		if k == "version" {
+			svc.Version = v
+			continue
+		}
+		svc.Metadata[k] = v
+	}
+
+	for _, s := range srvs {
+		addr := net.JoinHostPort(strings.TrimSuffix(s.Target, "."), strconv.Itoa(int(s.Port)))
+		svc.Nodes = append(svc.Nodes, &registry.Node{
+			Id:      addr,
+			Address: addr,
+			Metadata: map[string]string{
+				"priority": strconv.Itoa(int(s.Priority)),
+				"weight":   strconv.Itoa(int(s.Weight)),
+			},
+		})
+	}
+	sort.Slice(svc.Nodes, func(i, j int) bool { return svc.Nodes[i].Id < svc.Nodes[j].Id })
+
+	return []*registry.Service{svc}, nil
+}
+
+// recordName is the DNS name of the records for service name.
+func recordName(name, domain string) string {
+	n := "_" + name + "._tcp"
+	if len(domain) > 0 {
+		n += "." + domain
+	}
+	return n
+}
+
+func isNotFound(err error) bool {
+	dnsErr, ok := err.(*net.DNSError)
+	return ok && dnsErr.IsNotFound
+}
@@ -0,0 +1,163 @@
+package dns
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	mdns "github.com/miekg/dns"
+	"go-micro.dev/v6/registry"
+)
+
+// fakeDNS serves SRV and TXT records from a map that tests can change.
+type fakeDNS struct {
+	sync.Mutex
+	srv map[string][]*mdns.SRV
+	txt map[string][]string
+}
+
+func (f *fakeDNS) ServeDNS(w mdns.ResponseWriter, req *mdns.Msg) {
+	m := new(mdns.Msg)
+	m.SetReply(req)
+
+	f.Lock()
+	for _, q := range req.Question {
+		hdr := mdns.RR_Header{Name: q.Name, Class: mdns.ClassINET, Ttl: 1}
+		switch q.Qtype {
+		case mdns.TypeSRV:
+			for _, s := range f.srv[q.Name] {
+				rr := *s
+				hdr.Rrtype = mdns.TypeSRV
+				rr.Hdr = hdr
+				m.Answer = append(m.Answer, &rr)
+			}
+		case mdns.TypeTXT:
+			// one record per string: the resolver joins the strings of
+			// a single record
+			for _, txt := range f.txt[q.Name] {
+				hdr.Rrtype = mdns.TypeTXT
+				m.Answer = append(m.Answer, &mdns.TXT{Hdr: hdr, Txt: []string{txt}})
+			}
+		}
+	}
+	if len(m.Answer) == 0 {
+		m.Rcode = mdns.RcodeNameError
+	}
+	f.Unlock()
+
+	w.WriteMsg(m)
+}
+
+func (f *fakeDNS) setNodes(name string, targets ...string) {
+	f.Lock()
+	defer f.Unlock()
+	f.srv[name] = nil
+	for _, t := range targets {
+		f.srv[name] = append(f.srv[name], &mdns.SRV{Priority: 10, Weight: 5, Port: 8080, Target: t})
+	}
+}
+
+func (f *fakeDNS) setTXT(name string, txt ...string) {
+	f.Lock()
+	f.txt[name] = txt
+	f.Unlock()
+}
+
+func newFakeDNS(t *testing.T) (*fakeDNS, string) {
+	f := &fakeDNS{
+		srv: make(map[string][]*mdns.SRV),
+		txt: make(map[string][]string),
+	}
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &mdns.Server{PacketConn: pc, Handler: f}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+
+	return f, pc.LocalAddr().String()
+}
+
+func TestGetService(t *testing.T) {
+	f, addr := newFakeDNS(t)
+	f.setNodes("_greeter._tcp.svc.example.com.", "a.example.com.", "b.example.com.")
+	f.setTXT("_greeter._tcp.svc.example.com.", "version=1.2.0", "team=core")
+
+	r := NewRegistry(registry.Addrs(addr), Domain("svc.example.com"))
+
+	services, err := r.GetService("greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 {
+		t.Fatalf("got %d services, want 1", len(services))
+	}
+	s := services[0]
+	if s.Version != "1.2.0" || s.Metadata["team"] != "core" {
+		t.Fatalf("unexpected service %+v", s)
+	}
+	if len(s.Nodes) != 2 || s.Nodes[0].Address != "a.example.com:8080" || s.Nodes[0].Metadata["weight"] != "5" {
+		t.Fatalf("unexpected nodes %+v", s.Nodes)
+	}
+
+	if _, err := r.GetService("missing"); err != registry.ErrNotFound {
+		t.Fatalf("GetService(missing) = %v, want ErrNotFound", err)
+	}
+
+	list, err := r.ListServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != "greeter" {
+		t.Fatalf("ListServices = %+v", list)
+	}
+
+	// read-only: registering succeeds but changes nothing
+	if err := r.Register(&registry.Service{Name: "other"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	f, addr := newFakeDNS(t)
+	f.setNodes("_greeter._tcp.", "a.example.com.")
+
+	r := NewRegistry(registry.Addrs(addr), RefreshInterval(20*time.Millisecond))
+
+	w, err := r.Watch(registry.WatchService("greeter"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	next := func(action, node string) {
+		t.Helper()
+		ch := make(chan *registry.Result, 1)
+		go func() {
+			res, _ := w.Next()
+			ch <- res
+		}()
+		select {
+		case res := <-ch:
+			if res == nil || res.Action != action || res.Service.Nodes[0].Id != node {
+				t.Fatalf("got %+v, want %s of %s", res, action, node)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s of %s", action, node)
+		}
+	}
+
+	f.setNodes("_greeter._tcp.", "a.example.com.", "b.example.com.")
+	next("create", "b.example.com:8080")
+
+	f.setNodes("_greeter._tcp.", "b.example.com.")
+	next("delete", "a.example.com:8080")
+
+	w.Stop()
+	if _, err := w.Next(); err != registry.ErrWatcherStopped {
+		t.Fatalf("Next after Stop = %v, want ErrWatcherStopped", err)
+	}
+}
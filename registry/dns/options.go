@@ -0,0 +1,43 @@
+package dns
+
+import (
+	"context"
+	"time"
+
+	"go-micro.dev/v6/registry"
+)
+
+type domainKey struct{}
+
+type servicesKey struct{}
+
+type refreshKey struct{}
+
+// Domain sets the domain service records live under, so a lookup of
+// "greeter" resolves the SRV record _greeter._tcp.<domain>. Defaults to
+// no domain, leaving the resolver's search list to complete the name.
+func Domain(d string) registry.Option {
+	return setOption(domainKey{}, d)
+}
+
+// Services sets the names ListServices and watchers resolve. DNS can't be
+// enumerated, so without it only names looked up with GetService are
+// known.
+func Services(names ...string) registry.Option {
+	return setOption(servicesKey{}, names)
+}
+
+// RefreshInterval sets how often watched services are resolved again.
+// Defaults to 30 seconds.
+func RefreshInterval(d time.Duration) registry.Option {
+	return setOption(refreshKey{}, d)
+}
+
+func setOption(k, v interface{}) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, k, v)
+	}
+}
@@ -0,0 +1,264 @@
+// Package federation merges the registries of several regions, such as an
+// etcd cluster per region, into one registry.
+//
+// Discovery queries every member and merges the results, tagging each
+// node with the region it came from (registry.MetadataRegion) unless it
+// already carries one. Register goes to the local region's member only,
+// stamping the local region and zone on the nodes, so each region stays
+// the source of truth for its own services.
+//
+// Pair it with selector.FilterLocality to prefer same-zone nodes and fail
+// over to other regions, and set the Micro-Region metadata on a context
+// to pin calls to a region.
+package federation
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"go-micro.dev/v6/registry"
+)
+
+type federation struct {
+	sync.RWMutex
+	options registry.Options
+	members []member
+	local   locality
+}
+
+// NewRegistry returns a registry federating the members added with Member.
+func NewRegistry(opts ...registry.Option) registry.Registry {
+	f := new(federation)
+	f.configure(opts...)
+	return f
+}
+
+func (f *federation) configure(opts ...registry.Option) {
+	f.Lock()
+	defer f.Unlock()
+
+	for _, o := range opts {
+		o(&f.options)
+	}
+
+	f.local = locality{region: os.Getenv("MICRO_REGION"), zone: os.Getenv("MICRO_ZONE")}
+	if ctx := f.options.Context; ctx != nil {
+		if m, ok := ctx.Value(membersKey{}).([]member); ok {
+			f.members = m
+		}
+		if l, ok := ctx.Value(localityKey{}).(locality); ok {
+			f.local = l
+		}
+	}
+	if len(f.local.region) == 0 && len(f.members) > 0 {
+		f.local.region = f.members[0].region
+	}
+}
+
+func (f *federation) Init(opts ...registry.Option) error {
+	f.configure(opts...)
+	return nil
+}
+
+func (f *federation) Options() registry.Options {
+	f.RLock()
+	defer f.RUnlock()
+	return f.options
+}
+
+// home returns the member of the local region.
+func (f *federation) home() (registry.Registry, error) {
+	f.RLock()
+	defer f.RUnlock()
+
+	for _, m := range f.members {
+		if m.region == f.local.region {
+			return m.registry, nil
+		}
+	}
+	return nil, fmt.Errorf("federation: no registry for local region %q", f.local.region)
+}
+
+func (f *federation) Register(s *registry.Service, opts ...registry.RegisterOption) error {
+	r, err := f.home()
+	if err != nil {
+		return err
+	}
+
+	f.RLock()
+	local := f.local
+	f.RUnlock()
+
+	svc := *s
+	svc.Nodes = make([]*registry.Node, len(s.Nodes))
+	for i, n := range s.Nodes {
+		node := *n
+		node.Metadata = make(map[string]string, len(n.Metadata)+2)
+		for k, v := range n.Metadata {
+			node.Metadata[k] = v
+		}
+		node.Metadata[registry.MetadataRegion] = local.region
+		if len(local.zone) > 0 {
+			node.Metadata[registry.MetadataZone] = local.zone
+		}
+		svc.Nodes[i] = &node
+	}
+
+	return r.Register(&svc, opts...)
+}
+
+func (f *federation) Deregister(s *registry.Service, opts ...registry.DeregisterOption) error {
+	r, err := f.home()
+	if err != nil {
+		return err
+	}
+	return r.Deregister(s, opts...)
+}
+
+// each calls fn on every member concurrently. It fails only if every
+// member fails, so one unreachable region doesn't stop discovery.
+func (f *federation) each(fn func(m member) error) error {
+	f.RLock()
+	members := f.members
+	f.RUnlock()
+
+	if len(members) == 0 {
+		return errors.New("federation: no member registries")
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(members))
+	for i, m := range members {
+		wg.Add(1)
+		go func(i int, m member) {
+			defer wg.Done()
+			errs[i] = fn(m)
+		}(i, m)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err == nil {
+			return nil
+		}
+	}
+	return errs[0]
+}
+
+func (f *federation) GetService(name string, opts ...registry.GetOption) ([]*registry.Service, error) {
+	var mu sync.Mutex
+	var all []*registry.Service
+
+	err := f.each(func(m member) error {
+		services, err := m.registry.GetService(name, opts...)
+		if errors.Is(err, registry.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		for _, s := range services {
+			all = append(all, tag(s, m.region))
+		}
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	services := merge(all)
+	if len(services) == 0 {
+		return nil, registry.ErrNotFound
+	}
+	return services, nil
+}
+
+func (f *federation) ListServices(opts ...registry.ListOption) ([]*registry.Service, error) {
+	var mu sync.Mutex
+	var all []*registry.Service
+
+	err := f.each(func(m member) error {
+		services, err := m.registry.ListServices(opts...)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		for _, s := range services {
+			all = append(all, tag(s, m.region))
+		}
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return merge(all), nil
+}
+
+func (f *federation) Watch(opts ...registry.WatchOption) (registry.Watcher, error) {
+	f.RLock()
+	members := f.members
+	f.RUnlock()
+
+	return newWatcher(members, opts...)
+}
+
+func (f *federation) String() string {
+	return "federation"
+}
+
+// tag returns a copy of s with each node's region set to region, unless
+// the node already carries one.
+func tag(s *registry.Service, region string) *registry.Service {
+	svc := *s
+	svc.Nodes = make([]*registry.Node, len(s.Nodes))
+	for i, n := range s.Nodes {
+		node := *n
+		if len(n.Metadata[registry.MetadataRegion]) == 0 {
+			node.Metadata = make(map[string]string, len(n.Metadata)+1)
+			for k, v := range n.Metadata {
+				node.Metadata[k] = v
+			}
+			node.Metadata[registry.MetadataRegion] = region
+		}
+		svc.Nodes[i] = &node
+	}
+	return &svc
+}
+
+// merge combines services of the same name and version from different
+// members into one, with the nodes of all of them.
+func merge(services []*registry.Service) []*registry.Service {
+	index := make(map[string]*registry.Service)
+	var merged []*registry.Service
+
+	for _, s := range services {
+		key := s.Name + ":" + s.Version
+		m, ok := index[key]
+		if !ok {
+			cp := *s
+			cp.Nodes = append([]*registry.Node(nil), s.Nodes...)
+			index[key] = &cp
+			merged = append(merged, &cp)
+			continue
+		}
+		seen := make(map[string]bool, len(m.Nodes))
+		for _, n := range m.Nodes {
+			seen[n.Id] = true
+		}
+		for _, n := range s.Nodes {
+			if !seen[n.Id] {
+				m.Nodes = append(m.Nodes, n)
+			}
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Name < merged[j].Name })
+	return merged
+}
@@ -0,0 +1,143 @@
+package federation
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/registry"
+	"go-micro.dev/v6/selector"
+)
+
+func service(id, addr string) *registry.Service {
+	return &registry.Service{
+		Name:    "greeter",
+		Version: "1.0.0",
+		Nodes:   []*registry.Node{{Id: id, Address: addr}},
+	}
+}
+
+// broken is a member whose region can't be reached.
+type broken struct {
+	registry.Registry
+}
+
+func (broken) GetService(string, ...registry.GetOption) ([]*registry.Service, error) {
+	return nil, errors.New("unreachable")
+}
+
+func TestFederation(t *testing.T) {
+	eu, us := registry.NewMemoryRegistry(), registry.NewMemoryRegistry()
+
+	if err := us.Register(service("us-1", "10.1.0.1:8080")); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRegistry(
+		Member("eu", eu),
+		Member("us", us),
+		Member("ap", broken{registry.NewMemoryRegistry()}),
+		Locality("eu", "eu-1a"),
+	)
+
+	if err := r.Register(service("eu-1", "10.0.0.1:8080")); err != nil {
+		t.Fatal(err)
+	}
+
+	// registered with the local region only
+	if _, err := us.GetService("greeter"); err != nil {
+		t.Fatal(err)
+	}
+	local, err := eu.GetService("greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md := local[0].Nodes[0].Metadata; md[registry.MetadataRegion] != "eu" || md[registry.MetadataZone] != "eu-1a" {
+		t.Fatalf("local node metadata = %v", md)
+	}
+
+	services, err := r.GetService("greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 || len(services[0].Nodes) != 2 {
+		t.Fatalf("got %+v, want one service with both nodes", services)
+	}
+	regions := map[string]string{}
+	for _, n := range services[0].Nodes {
+		regions[n.Id] = n.Metadata[registry.MetadataRegion]
+	}
+	if regions["eu-1"] != "eu" || regions["us-1"] != "us" {
+		t.Fatalf("node regions = %v", regions)
+	}
+
+	list, err := r.ListServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("ListServices returned %d services, want 1", len(list))
+	}
+
+	// the selector prefers local nodes and fails over once they're gone
+	s := selector.NewSelector(selector.Registry(r))
+	defer s.Close()
+
+	next, err := s.Select("greeter", selector.WithFilter(selector.FilterLocality("eu", "eu-1a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := next(); n.Id != "eu-1" {
+		t.Fatalf("selected %s, want the local node", n.Id)
+	}
+
+	if err := r.Deregister(service("eu-1", "10.0.0.1:8080")); err != nil {
+		t.Fatal(err)
+	}
+	s2 := selector.NewSelector(selector.Registry(r))
+	defer s2.Close()
+	next, err = s2.Select("greeter", selector.WithFilter(selector.FilterLocality("eu", "eu-1a")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := next(); n.Id != "us-1" {
+		t.Fatalf("selected %s, want failover to us-1", n.Id)
+	}
+}
+
+func TestNoLocalMember(t *testing.T) {
+	r := NewRegistry(Member("us", registry.NewMemoryRegistry()), Locality("eu", ""))
+	if err := r.Register(service("eu-1", "10.0.0.1:8080")); err == nil {
+		t.Fatal("Register without a member for the local region should fail")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	eu, us := registry.NewMemoryRegistry(), registry.NewMemoryRegistry()
+	r := NewRegistry(Member("eu", eu), Member("us", us))
+
+	w, err := r.Watch(registry.WatchService("greeter"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	// give the memory registry's watchers a moment to start
+	time.Sleep(10 * time.Millisecond)
+	if err := us.Register(service("us-1", "10.1.0.1:8080")); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md := res.Service.Nodes[0].Metadata; md[registry.MetadataRegion] != "us" {
+		t.Fatalf("watched node metadata = %v, want region us", md)
+	}
+
+	w.Stop()
+	if _, err := w.Next(); err != registry.ErrWatcherStopped {
+		t.Fatalf("Next after Stop = %v, want ErrWatcherStopped", err)
+	}
+}
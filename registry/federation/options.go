@@ -0,0 +1,46 @@
+package federation
+
+import (
+	"context"
+
+	"go-micro.dev/v6/registry"
+)
+
+type membersKey struct{}
+
+type localityKey struct{}
+
+type member struct {
+	region   string
+	registry registry.Registry
+}
+
+type locality struct {
+	region, zone string
+}
+
+// Member adds the registry of a region, e.g. the etcd cluster there.
+// Nodes discovered through it are tagged with the region.
+func Member(region string, r registry.Registry) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		members, _ := o.Context.Value(membersKey{}).([]member)
+		members = append(members[:len(members):len(members)], member{region, r})
+		o.Context = context.WithValue(o.Context, membersKey{}, members)
+	}
+}
+
+// Locality sets the region and zone this process runs in. Services are
+// registered with the member of that region, with the region and zone in
+// their node metadata. Defaults to $MICRO_REGION and $MICRO_ZONE, and to
+// the first member's region.
+func Locality(region, zone string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, localityKey{}, locality{region, zone})
+	}
+}
@@ -0,0 +1,83 @@
+package federation
+
+import (
+	"sync"
+
+	"go-micro.dev/v6/registry"
+)
+
+// watcher fans in the watchers of every member, tagging the nodes of each
+// result with the member's region. If any member's watcher fails the
+// error is returned from Next, so the caller (usually registry/cache)
+// watches again.
+type watcher struct {
+	watchers []registry.Watcher
+	results  chan *registry.Result
+	errs     chan error
+	exit     chan struct{}
+	once     sync.Once
+}
+
+func newWatcher(members []member, opts ...registry.WatchOption) (registry.Watcher, error) {
+	w := &watcher{
+		results: make(chan *registry.Result),
+		errs:    make(chan error, len(members)),
+		exit:    make(chan struct{}),
+	}
+
+	for _, m := range members {
+		mw, err := m.registry.Watch(opts...)
+		if err != nil {
+			w.Stop()
+			return nil, err
+		}
+		w.watchers = append(w.watchers, mw)
+	}
+
+	for i, m := range members {
+		go w.run(m.region, w.watchers[i])
+	}
+
+	return w, nil
+}
+
+func (w *watcher) run(region string, mw registry.Watcher) {
+	for {
+		r, err := mw.Next()
+		if err != nil {
+			select {
+			case w.errs <- err:
+			default:
+			}
+			return
+		}
+		if r.Service != nil {
+			r = &registry.Result{Action: r.Action, Service: tag(r.Service, region)}
+		}
+		select {
+		case w.results <- r:
+		case <-w.exit:
+			return
+		}
+	}
+}
+
+func (w *watcher) Next() (*registry.Result, error) {
+	select {
+	case <-w.exit:
+		return nil, registry.ErrWatcherStopped
+	case err := <-w.errs:
+		return nil, err
+	case r := <-w.results:
+		return r, nil
+	}
+}
+
+func (w *watcher) Stop() {
+	w.once.Do(func() {
+		close(w.exit)
+		for _, mw := range w.watchers {
+			mw.Stop()
+		}
+	})
+}
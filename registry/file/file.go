@@ -0,0 +1,227 @@
+// Package file provides a read-only registry backed by a YAML or JSON
+// file, for static deployments that want no registry server:
+//
+//	services:
+//	  - name: greeter
+//	    version: 1.0.0
+//	    nodes:
+//	      - id: greeter-1
+//	        address: 10.0.0.1:8080
+//	      - address: 10.0.0.2:8080
+//
+// Field names are those of registry.Service. A node without an id uses
+// its address. The file is watched, and edits are reported to watchers
+// as the nodes that were added, changed or removed, so the registry
+// works behind registry/cache and the selector like any other.
+//
+// Register and Deregister do nothing: the file is the source of truth.
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"go-micro.dev/v6/logger"
+	"go-micro.dev/v6/registry"
+	"go-micro.dev/v6/registry/internal/snapshot"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath is the file read when no path or address is set.
+var DefaultPath = "registry.yaml"
+
+type fileRegistry struct {
+	options registry.Options
+	snap    *snapshot.Snapshot
+
+	sync.Mutex
+	path    string
+	watcher *fsnotify.Watcher
+}
+
+// NewRegistry returns a file registry. The file is read immediately; if it
+// doesn't exist yet the registry is empty until it is created.
+func NewRegistry(opts ...registry.Option) registry.Registry {
+	f := &fileRegistry{
+		snap: snapshot.New(),
+	}
+	configure(f, opts...)
+	return f
+}
+
+func configure(f *fileRegistry, opts ...registry.Option) {
+	for _, o := range opts {
+		o(&f.options)
+	}
+
+	if f.options.Logger == nil {
+		f.options.Logger = logger.DefaultLogger
+	}
+
+	path := DefaultPath
+	for _, a := range f.options.Addrs {
+		if len(a) > 0 {
+			path = a
+			break
+		}
+	}
+	if ctx := f.options.Context; ctx != nil {
+		if p, ok := ctx.Value(pathKey{}).(string); ok && len(p) > 0 {
+			path = p
+		}
+	}
+
+	f.Lock()
+	defer f.Unlock()
+
+	if path == f.path {
+		return
+	}
+	f.path = path
+
+	if err := f.load(); err != nil && !os.IsNotExist(err) {
+		f.options.Logger.Logf(logger.ErrorLevel, "file registry: %v", err)
+	}
+
+	if f.watcher != nil {
+		f.watcher.Close()
+		f.watcher = nil
+	}
+	if err := f.watch(); err != nil {
+		f.options.Logger.Logf(logger.ErrorLevel, "file registry: watch %s: %v", path, err)
+	}
+}
+
+// watch follows the file's directory rather than the file, so editors
+// and config management that replace the file by renaming are noticed.
+// Called with the lock held.
+func (f *fileRegistry) watch() error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := w.Add(filepath.Dir(f.path)); err != nil {
+		w.Close()
+		return err
+	}
+	f.watcher = w
+
+	go func(path string) {
+		for {
+			select {
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(ev.Name) != filepath.Clean(path) {
+					continue
+				}
+				f.Lock()
+				err := f.load()
+				f.Unlock()
+				if os.IsNotExist(err) {
+					// removed: keep serving the last services
+					continue
+				}
+				if err != nil {
+					f.options.Logger.Logf(logger.ErrorLevel, "file registry: %v", err)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				f.options.Logger.Logf(logger.ErrorLevel, "file registry: watch %s: %v", path, err)
+			}
+		}
+	}(f.path)
+
+	return nil
+}
+
+// load reads the file and replaces the snapshot. A file that fails to
+// parse leaves the last services in place. Called with the lock held.
+func (f *fileRegistry) load() error {
+	b, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	services, err := parse(b)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", f.path, err)
+	}
+	f.snap.Replace(services)
+	return nil
+}
+
+// parse decodes a services file. JSON is valid YAML, so both are read as
+// YAML and then mapped onto registry.Service through its JSON tags.
+func parse(b []byte) ([]*registry.Service, error) {
+	var raw interface{}
+	if err := yaml.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	j, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Services []*registry.Service `json:"services"`
+	}
+	if err := json.Unmarshal(j, &file); err != nil {
+		return nil, err
+	}
+
+	for _, s := range file.Services {
+		if len(s.Name) == 0 {
+			return nil, fmt.Errorf("service without a name")
+		}
+		for _, n := range s.Nodes {
+			if len(n.Id) == 0 {
+				n.Id = n.Address
+			}
+		}
+	}
+
+	return file.Services, nil
+}
+
+func (f *fileRegistry) Init(opts ...registry.Option) error {
+	configure(f, opts...)
+	return nil
+}
+
+func (f *fileRegistry) Options() registry.Options {
+	return f.options
+}
+
+// Register is a no-op: services are listed in the file.
+func (f *fileRegistry) Register(s *registry.Service, opts ...registry.RegisterOption) error {
+	f.options.Logger.Logf(logger.TraceLevel, "file registry is read-only, not registering %s", s.Name)
+	return nil
+}
+
+// Deregister is a no-op: services are listed in the file.
+func (f *fileRegistry) Deregister(s *registry.Service, opts ...registry.DeregisterOption) error {
+	return nil
+}
+
+func (f *fileRegistry) GetService(name string, opts ...registry.GetOption) ([]*registry.Service, error) {
+	return f.snap.Get(name)
+}
+
+func (f *fileRegistry) ListServices(opts ...registry.ListOption) ([]*registry.Service, error) {
+	return f.snap.List(), nil
+}
+
+func (f *fileRegistry) Watch(opts ...registry.WatchOption) (registry.Watcher, error) {
+	return f.snap.Watch(opts...), nil
+}
+
+func (f *fileRegistry) String() string {
+	return "file"
+}
@@ -0,0 +1,169 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/registry"
+	"go-micro.dev/v6/registry/cache"
+)
+
+const services = `
+services:
+  - name: greeter
+    version: 1.0.0
+    metadata:
+      team: core
+    nodes:
+      - id: greeter-1
+        address: 10.0.0.1:8080
+      - address: 10.0.0.2:8080
+  - name: users
+    version: 2.0.0
+    nodes:
+      - address: 10.0.0.3:8080
+`
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	// write and rename, as config management does
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.yaml")
+	writeFile(t, path, services)
+
+	r := NewRegistry(Path(path))
+
+	s, err := r.GetService("greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s) != 1 || s[0].Version != "1.0.0" || s[0].Metadata["team"] != "core" || len(s[0].Nodes) != 2 {
+		t.Fatalf("unexpected service %+v", s)
+	}
+	if n := s[0].Nodes[1]; n.Id != "10.0.0.2:8080" {
+		t.Fatalf("node without id got id %q, want its address", n.Id)
+	}
+
+	list, err := r.ListServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("got %d services, want 2", len(list))
+	}
+
+	if _, err := r.GetService("missing"); err != registry.ErrNotFound {
+		t.Fatalf("GetService(missing) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.json")
+	writeFile(t, path, `{"services": [{"name": "greeter", "nodes": [{"id": "a", "address": "10.0.0.1:8080"}]}]}`)
+
+	s, err := NewRegistry(registry.Addrs(path)).GetService("greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s[0].Nodes) != 1 || s[0].Nodes[0].Address != "10.0.0.1:8080" {
+		t.Fatalf("unexpected service %+v", s[0])
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.yaml")
+	writeFile(t, path, services)
+
+	r := NewRegistry(Path(path))
+	w, err := r.Watch(registry.WatchService("greeter"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	next := func(action, node string) {
+		t.Helper()
+		ch := make(chan *registry.Result, 1)
+		go func() {
+			res, _ := w.Next()
+			ch <- res
+		}()
+		select {
+		case res := <-ch:
+			if res == nil || res.Action != action || res.Service.Nodes[0].Id != node {
+				t.Fatalf("got %+v, want %s of %s", res, action, node)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s of %s", action, node)
+		}
+	}
+
+	writeFile(t, path, `
+services:
+  - name: greeter
+    version: 1.0.0
+    metadata:
+      team: core
+    nodes:
+      - id: greeter-1
+        address: 10.0.0.1:9090
+      - address: 10.0.0.4:8080
+`)
+	next("create", "10.0.0.4:8080")
+	next("update", "greeter-1")
+	next("delete", "10.0.0.2:8080")
+
+	// a broken file keeps the last services
+	writeFile(t, path, "services: [")
+	time.Sleep(100 * time.Millisecond)
+	if s, err := r.GetService("greeter"); err != nil || len(s[0].Nodes) != 2 {
+		t.Fatalf("GetService after bad write = %+v, %v", s, err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.yaml")
+	writeFile(t, path, services)
+
+	c := cache.New(NewRegistry(Path(path)))
+	defer c.Stop()
+
+	if s, err := c.GetService("greeter"); err != nil || len(s[0].Nodes) != 2 {
+		t.Fatalf("GetService = %+v, %v", s, err)
+	}
+
+	// the cache starts its watcher up to 100ms after the first lookup
+	time.Sleep(200 * time.Millisecond)
+
+	writeFile(t, path, `
+services:
+  - name: greeter
+    version: 1.0.0
+    nodes:
+      - id: greeter-1
+        address: 10.0.0.1:8080
+`)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s, err := c.GetService("greeter")
+		if err == nil && len(s[0].Nodes) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("cache still has %+v, %v", s, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
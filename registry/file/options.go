@@ -0,0 +1,20 @@
+package file
+
+import (
+	"context"
+
+	"go-micro.dev/v6/registry"
+)
+
+type pathKey struct{}
+
+// Path sets the file services are read from. Defaults to the first
+// registry address, then "registry.yaml".
+func Path(p string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, pathKey{}, p)
+	}
+}
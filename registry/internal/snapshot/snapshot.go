@@ -0,0 +1,221 @@
+// Package snapshot serves discovery for registries whose backend can only
+// be re-read in full, such as DNS records or a file. Each read replaces the
+// snapshot of a service and the difference is published to watchers with
+// the same node-level create, update and delete results the etcd registry
+// emits, which is what registry/cache expects.
+package snapshot
+
+import (
+	"sort"
+	"sync"
+
+	hash "github.com/mitchellh/hashstructure"
+	"go-micro.dev/v6/registry"
+)
+
+// Snapshot holds the last read services and the watchers to notify.
+type Snapshot struct {
+	sync.RWMutex
+	services map[string][]*registry.Service
+	watchers map[*watcher]bool
+}
+
+// New returns an empty snapshot.
+func New() *Snapshot {
+	return &Snapshot{
+		services: make(map[string][]*registry.Service),
+		watchers: make(map[*watcher]bool),
+	}
+}
+
+// Set replaces the versions of the named service and notifies watchers
+// of what changed. An empty list removes the service.
+func (s *Snapshot) Set(name string, services []*registry.Service) {
+	s.Lock()
+	prev := s.services[name]
+	if len(services) > 0 {
+		s.services[name] = services
+	} else {
+		delete(s.services, name)
+	}
+	s.notify(Diff(prev, services))
+	s.Unlock()
+}
+
+// Replace replaces every service, e.g. after re-reading a file.
+func (s *Snapshot) Replace(services []*registry.Service) {
+	next := make(map[string][]*registry.Service)
+	for _, svc := range services {
+		next[svc.Name] = append(next[svc.Name], svc)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	for name, prev := range s.services {
+		if _, ok := next[name]; !ok {
+			s.notify(Diff(prev, nil))
+		}
+	}
+	for name, svcs := range next {
+		s.notify(Diff(s.services[name], svcs))
+	}
+	s.services = next
+}
+
+// Names returns the names of the services in the snapshot.
+func (s *Snapshot) Names() []string {
+	s.RLock()
+	defer s.RUnlock()
+	names := make([]string, 0, len(s.services))
+	for name := range s.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the versions of the named service.
+func (s *Snapshot) Get(name string) ([]*registry.Service, error) {
+	s.RLock()
+	defer s.RUnlock()
+	services, ok := s.services[name]
+	if !ok {
+		return nil, registry.ErrNotFound
+	}
+	return copyServices(services), nil
+}
+
+// List returns every service, sorted by name.
+func (s *Snapshot) List() []*registry.Service {
+	s.RLock()
+	defer s.RUnlock()
+	var services []*registry.Service
+	for _, svcs := range s.services {
+		services = append(services, copyServices(svcs)...)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services
+}
+
+// Watch returns a watcher for changes from now on.
+func (s *Snapshot) Watch(opts ...registry.WatchOption) registry.Watcher {
+	var wo registry.WatchOptions
+	for _, o := range opts {
+		o(&wo)
+	}
+
+	w := &watcher{
+		s:    s,
+		wo:   wo,
+		next: make(chan struct{}, 1),
+		exit: make(chan struct{}),
+	}
+
+	s.Lock()
+	s.watchers[w] = true
+	s.Unlock()
+
+	return w
+}
+
+// copyServices copies services and their node lists so callers can't
+// change the snapshot.
+func copyServices(services []*registry.Service) []*registry.Service {
+	cp := make([]*registry.Service, len(services))
+	for i, s := range services {
+		c := *s
+		c.Nodes = make([]*registry.Node, len(s.Nodes))
+		for j, n := range s.Nodes {
+			nc := *n
+			c.Nodes[j] = &nc
+		}
+		cp[i] = &c
+	}
+	return cp
+}
+
+// notify queues results on every watcher. Called with the lock held.
+func (s *Snapshot) notify(results []*registry.Result) {
+	if len(results) == 0 {
+		return
+	}
+	for w := range s.watchers {
+		w.push(results)
+	}
+}
+
+// Diff compares two reads of one service and returns a result per node:
+// create for new nodes, update for nodes whose details or service
+// changed, and delete for nodes that are gone. Each result carries the
+// service with just that node.
+func Diff(prev, next []*registry.Service) []*registry.Result {
+	type entry struct {
+		svc  *registry.Service
+		node *registry.Node
+	}
+	index := func(services []*registry.Service) map[string]entry {
+		m := make(map[string]entry)
+		for _, s := range services {
+			for _, n := range s.Nodes {
+				m[s.Version+"\x00"+n.Id] = entry{s, n}
+			}
+		}
+		return m
+	}
+
+	old, cur := index(prev), index(next)
+
+	var results []*registry.Result
+	for key, e := range cur {
+		o, ok := old[key]
+		switch {
+		case !ok:
+			results = append(results, result("create", e.svc, e.node))
+		case changed(o.svc, o.node, e.svc, e.node):
+			results = append(results, result("update", e.svc, e.node))
+		}
+	}
+	for key, o := range old {
+		if _, ok := cur[key]; !ok {
+			results = append(results, result("delete", o.svc, o.node))
+		}
+	}
+
+	// new nodes before removals, so a cache following the results never
+	// drops to no nodes while a service is being replaced
+	sort.Slice(results, func(i, j int) bool {
+		di, dj := results[i].Action == "delete", results[j].Action == "delete"
+		if di != dj {
+			return dj
+		}
+		return results[i].Service.Nodes[0].Id < results[j].Service.Nodes[0].Id
+	})
+
+	return results
+}
+
+func result(action string, s *registry.Service, n *registry.Node) *registry.Result {
+	return &registry.Result{
+		Action: action,
+		Service: &registry.Service{
+			Name:      s.Name,
+			Version:   s.Version,
+			Metadata:  s.Metadata,
+			Endpoints: s.Endpoints,
+			Nodes:     []*registry.Node{n},
+		},
+	}
+}
+
+func changed(os *registry.Service, on *registry.Node, ns *registry.Service, nn *registry.Node) bool {
+	a, err := hash.Hash(result("", os, on).Service, nil)
+	if err != nil {
+		return true
+	}
+	b, err := hash.Hash(result("", ns, nn).Service, nil)
+	if err != nil {
+		return true
+	}
+	return a != b
+}
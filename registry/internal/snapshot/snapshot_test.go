@@ -0,0 +1,69 @@
+package snapshot
+
+import (
+	"testing"
+
+	"go-micro.dev/v6/registry"
+)
+
+func service(version string, nodes ...*registry.Node) *registry.Service {
+	return &registry.Service{Name: "greeter", Version: version, Nodes: nodes}
+}
+
+func TestDiff(t *testing.T) {
+	a := &registry.Node{Id: "a", Address: "10.0.0.1:8080"}
+	b := &registry.Node{Id: "b", Address: "10.0.0.2:8080"}
+	a2 := &registry.Node{Id: "a", Address: "10.0.0.1:9090"}
+
+	prev := []*registry.Service{service("1.0.0", a, b)}
+	next := []*registry.Service{service("1.0.0", a2), service("2.0.0", b)}
+
+	want := []struct{ action, version, node string }{
+		{"create", "2.0.0", "b"},
+		{"update", "1.0.0", "a"},
+		{"delete", "1.0.0", "b"},
+	}
+
+	got := Diff(prev, next)
+	if len(got) != len(want) {
+		t.Fatalf("got %d results, want %d", len(got), len(want))
+	}
+	// creates and updates are sorted together by node id
+	if got[0].Action == "update" {
+		got[0], got[1] = got[1], got[0]
+	}
+	for i, w := range want {
+		r := got[i]
+		if r.Action != w.action || r.Service.Version != w.version || r.Service.Nodes[0].Id != w.node {
+			t.Errorf("result %d = %s %s/%s, want %s %s/%s", i, r.Action, r.Service.Version, r.Service.Nodes[0].Id, w.action, w.version, w.node)
+		}
+	}
+
+	if r := Diff(next, next); len(r) != 0 {
+		t.Fatalf("unchanged diff returned %d results", len(r))
+	}
+}
+
+func TestWatchFilter(t *testing.T) {
+	s := New()
+	w := s.Watch(registry.WatchService("greeter"))
+	defer w.Stop()
+
+	s.Replace([]*registry.Service{
+		{Name: "users", Nodes: []*registry.Node{{Id: "u"}}},
+		service("1.0.0", &registry.Node{Id: "a"}),
+	})
+
+	r, err := w.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Service.Name != "greeter" || r.Action != "create" {
+		t.Fatalf("got %s of %s, want create of greeter", r.Action, r.Service.Name)
+	}
+
+	s.Replace(nil)
+	if r, _ := w.Next(); r.Action != "delete" {
+		t.Fatalf("got %s after removing everything, want delete", r.Action)
+	}
+}
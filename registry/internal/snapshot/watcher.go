@@ -0,0 +1,64 @@
+package snapshot
+
+import (
+	"sync"
+
+	"go-micro.dev/v6/registry"
+)
+
+// watcher queues results without bound so a slow reader never blocks
+// the refresh that produced them.
+type watcher struct {
+	s  *Snapshot
+	wo registry.WatchOptions
+
+	mu    sync.Mutex
+	queue []*registry.Result
+	next  chan struct{}
+	exit  chan struct{}
+	once  sync.Once
+}
+
+func (w *watcher) push(results []*registry.Result) {
+	w.mu.Lock()
+	for _, r := range results {
+		if len(w.wo.Service) > 0 && r.Service.Name != w.wo.Service {
+			continue
+		}
+		w.queue = append(w.queue, r)
+	}
+	w.mu.Unlock()
+
+	select {
+	case w.next <- struct{}{}:
+	default:
+	}
+}
+
+func (w *watcher) Next() (*registry.Result, error) {
+	for {
+		w.mu.Lock()
+		if len(w.queue) > 0 {
+			r := w.queue[0]
+			w.queue = w.queue[1:]
+			w.mu.Unlock()
+			return r, nil
+		}
+		w.mu.Unlock()
+
+		select {
+		case <-w.exit:
+			return nil, registry.ErrWatcherStopped
+		case <-w.next:
+		}
+	}
+}
+
+func (w *watcher) Stop() {
+	w.once.Do(func() {
+		close(w.exit)
+		w.s.Lock()
+		delete(w.s.watchers, w)
+		w.s.Unlock()
+	})
+}
@@ -0,0 +1,232 @@
+package kubernetes
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+var (
+	// serviceAccountPath is where Kubernetes mounts the pod's service
+	// account token, CA and namespace.
+	serviceAccountPath = "/var/run/secrets/kubernetes.io/serviceaccount"
+)
+
+// apiError is a non-2xx response from the API server.
+type apiError struct {
+	Code    int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("kubernetes: %d %s", e.Code, e.Message)
+}
+
+func isNotFound(err error) bool {
+	var ae *apiError
+	return errors.As(err, &ae) && ae.Code == http.StatusNotFound
+}
+
+func isConflict(err error) bool {
+	var ae *apiError
+	return errors.As(err, &ae) && ae.Code == http.StatusConflict
+}
+
+// client is a minimal Kubernetes REST client for the handful of calls
+// the registry makes.
+type client struct {
+	host      string
+	token     string
+	namespace string
+	http      *http.Client
+}
+
+// newClient returns a client for the API server at host. With no host
+// the in-cluster service account config is used.
+func newClient(host, namespace string, tlsConfig *tls.Config) *client {
+	c := &client{
+		host:      host,
+		namespace: namespace,
+	}
+
+	if len(c.host) == 0 {
+		h, p := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if len(h) > 0 && len(p) > 0 {
+			c.host = "https://" + net.JoinHostPort(h, p)
+		}
+	}
+	if len(c.host) > 0 && !strings.Contains(c.host, "://") {
+		c.host = "https://" + c.host
+	}
+
+	if b, err := os.ReadFile(serviceAccountPath + "/token"); err == nil {
+		c.token = strings.TrimSpace(string(b))
+	}
+
+	if len(c.namespace) == 0 {
+		if b, err := os.ReadFile(serviceAccountPath + "/namespace"); err == nil {
+			c.namespace = strings.TrimSpace(string(b))
+		}
+	}
+	if len(c.namespace) == 0 {
+		c.namespace = "default"
+	}
+
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		if b, err := os.ReadFile(serviceAccountPath + "/ca.crt"); err == nil {
+			pool := x509.NewCertPool()
+			pool.AppendCertsFromPEM(b)
+			tlsConfig.RootCAs = pool
+		}
+	}
+
+	c.http = &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+
+	return c
+}
+
+func (c *client) do(ctx context.Context, method, path string, query url.Values, contentType string, body, out interface{}) error {
+	rsp, err := c.request(ctx, method, path, query, contentType, body)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if out == nil {
+		_, err = io.Copy(io.Discard, rsp.Body)
+		return err
+	}
+	return json.NewDecoder(rsp.Body).Decode(out)
+}
+
+// request sends a request and returns the response, or an apiError for
+// non-2xx statuses. The caller closes the body.
+func (c *client) request(ctx context.Context, method, path string, query url.Values, contentType string, body interface{}) (*http.Response, error) {
+	if len(c.host) == 0 {
+		return nil, errors.New("kubernetes: no API server address and not running in a cluster")
+	}
+
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	u := c.host + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if len(c.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	rsp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		defer rsp.Body.Close()
+		var st status
+		b, _ := io.ReadAll(rsp.Body)
+		if json.Unmarshal(b, &st) != nil || len(st.Message) == 0 {
+			st.Message = strings.TrimSpace(string(b))
+		}
+		return nil, &apiError{Code: rsp.StatusCode, Message: st.Message}
+	}
+
+	return rsp, nil
+}
+
+func (c *client) podsPath() string {
+	return "/api/v1/namespaces/" + c.namespace + "/pods"
+}
+
+func (c *client) servicesPath() string {
+	return "/api/v1/namespaces/" + c.namespace + "/services"
+}
+
+func (c *client) endpointSlicesPath() string {
+	return "/apis/discovery.k8s.io/v1/namespaces/" + c.namespace + "/endpointslices"
+}
+
+// patchPod applies a JSON merge patch to the named pod.
+func (c *client) patchPod(ctx context.Context, name string, patch interface{}) error {
+	return c.do(ctx, http.MethodPatch, c.podsPath()+"/"+name, nil, "application/merge-patch+json", patch, nil)
+}
+
+func (c *client) listPods(ctx context.Context, selector string) (*podList, error) {
+	var list podList
+	q := url.Values{"labelSelector": {selector}}
+	if err := c.do(ctx, http.MethodGet, c.podsPath(), q, "", nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (c *client) getService(ctx context.Context, name string) (*service, error) {
+	var svc service
+	if err := c.do(ctx, http.MethodGet, c.servicesPath()+"/"+name, nil, "", nil, &svc); err != nil {
+		return nil, err
+	}
+	return &svc, nil
+}
+
+func (c *client) createService(ctx context.Context, svc *service) error {
+	return c.do(ctx, http.MethodPost, c.servicesPath(), nil, "application/json", svc, nil)
+}
+
+func (c *client) listEndpointSlices(ctx context.Context, selector string) (*endpointSliceList, error) {
+	var list endpointSliceList
+	q := url.Values{"labelSelector": {selector}}
+	if err := c.do(ctx, http.MethodGet, c.endpointSlicesPath(), q, "", nil, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// watchEndpointSlices opens a watch stream of EndpointSlice events from
+// resourceVersion on. The caller closes the body.
+func (c *client) watchEndpointSlices(ctx context.Context, selector, resourceVersion string) (io.ReadCloser, error) {
+	q := url.Values{
+		"labelSelector":       {selector},
+		"watch":               {"true"},
+		"allowWatchBookmarks": {"false"},
+	}
+	if len(resourceVersion) > 0 {
+		q.Set("resourceVersion", resourceVersion)
+	}
+	rsp, err := c.request(ctx, http.MethodGet, c.endpointSlicesPath(), q, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return rsp.Body, nil
+}
@@ -0,0 +1,385 @@
+// Package kubernetes provides a registry backed by the Kubernetes API, so
+// services running in a cluster need no separate etcd, consul or NATS.
+//
+// Register annotates the pod the process runs in with the service (its
+// version, metadata and endpoints as JSON) and labels it so a headless
+// Kubernetes Service selects it. Kubernetes then maintains EndpointSlices
+// for that Service, and discovery only returns pods the slices report as
+// ready. Watch follows the EndpointSlices, so pods that become unready or
+// are deleted drop out without waiting for a TTL.
+//
+// The pod's service account needs get/list/patch on pods, get/create on
+// services and list/watch on endpointslices in its namespace.
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	hash "github.com/mitchellh/hashstructure"
+	"go-micro.dev/v6/logger"
+	"go-micro.dev/v6/registry"
+)
+
+const (
+	// registryLabel marks pods and Services managed by the registry.
+	registryLabel = "micro.dev/registry"
+	// servicePrefix prefixes the per-service pod label and annotation.
+	servicePrefix = "service.micro.dev/"
+	// nameAnnotation carries the original service name on the Service.
+	nameAnnotation = "micro.dev/service-name"
+	// serviceNameLabel is set on EndpointSlices by Kubernetes.
+	serviceNameLabel = "kubernetes.io/service-name"
+)
+
+type kregistry struct {
+	options registry.Options
+	client  *client
+	pod     string
+
+	sync.Mutex
+	// hash of the last registration of each service, to skip rewriting
+	// the pod when nothing changed
+	register map[string]uint64
+}
+
+// NewRegistry returns a Kubernetes registry. registry.Addrs sets the API
+// server address; in a cluster the service account config is used.
+func NewRegistry(opts ...registry.Option) registry.Registry {
+	k := &kregistry{
+		register: make(map[string]uint64),
+	}
+	configure(k, opts...)
+	return k
+}
+
+func configure(k *kregistry, opts ...registry.Option) {
+	for _, o := range opts {
+		o(&k.options)
+	}
+
+	if k.options.Timeout == 0 {
+		k.options.Timeout = 5 * time.Second
+	}
+	if k.options.Logger == nil {
+		k.options.Logger = logger.DefaultLogger
+	}
+
+	var namespace string
+	k.pod = os.Getenv("POD_NAME")
+	if len(k.pod) == 0 {
+		k.pod, _ = os.Hostname()
+	}
+	if ctx := k.options.Context; ctx != nil {
+		if ns, ok := ctx.Value(namespaceKey{}).(string); ok {
+			namespace = ns
+		}
+		if p, ok := ctx.Value(podNameKey{}).(string); ok && len(p) > 0 {
+			k.pod = p
+		}
+	}
+
+	var host string
+	for _, a := range k.options.Addrs {
+		if len(a) > 0 {
+			host = a
+			break
+		}
+	}
+
+	k.client = newClient(host, namespace, k.options.TLSConfig)
+}
+
+// serviceName maps a micro service name to a valid Kubernetes Service
+// name (an RFC 1035 label). Names that collide are told apart by the
+// name carried in each pod's annotation.
+func serviceName(name string) string {
+	var b strings.Builder
+	b.WriteString("micro-")
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	s := b.String()
+	if len(s) > 63 {
+		s = s[:63]
+	}
+	return strings.TrimRight(s, "-")
+}
+
+func serviceKey(name string) string {
+	return servicePrefix + serviceName(name)
+}
+
+func (k *kregistry) Init(opts ...registry.Option) error {
+	configure(k, opts...)
+	return nil
+}
+
+func (k *kregistry) Options() registry.Options {
+	return k.options
+}
+
+func (k *kregistry) Register(s *registry.Service, opts ...registry.RegisterOption) error {
+	if len(s.Nodes) == 0 {
+		return errors.New("require at least one node")
+	}
+
+	h, err := hash.Hash(s, nil)
+	if err != nil {
+		return err
+	}
+
+	k.Lock()
+	seen := k.register[s.Name] == h
+	k.Unlock()
+	if seen {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), k.options.Timeout)
+	defer cancel()
+
+	if err := k.ensureService(ctx, s); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	key := serviceKey(s.Name)
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]string{
+				registryLabel: "true",
+				key:           "true",
+			},
+			"annotations": map[string]string{
+				key: string(b),
+			},
+		},
+	}
+
+	k.options.Logger.Logf(logger.TraceLevel, "Registering %s on pod %s", s.Name, k.pod)
+	if err := k.client.patchPod(ctx, k.pod, patch); err != nil {
+		return err
+	}
+
+	k.Lock()
+	k.register[s.Name] = h
+	k.Unlock()
+
+	return nil
+}
+
+// ensureService creates the headless Service that selects the pods of s,
+// so that Kubernetes maintains EndpointSlices for it.
+func (k *kregistry) ensureService(ctx context.Context, s *registry.Service) error {
+	name := serviceName(s.Name)
+
+	_, err := k.client.getService(ctx, name)
+	if err == nil {
+		return nil
+	}
+	if !isNotFound(err) {
+		return err
+	}
+
+	svc := &service{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Metadata: objectMeta{
+			Name:        name,
+			Labels:      map[string]string{registryLabel: "true"},
+			Annotations: map[string]string{nameAnnotation: s.Name},
+		},
+		Spec: serviceSpec{
+			ClusterIP: "None",
+			Selector:  map[string]string{serviceKey(s.Name): "true"},
+		},
+	}
+	if _, p, err := net.SplitHostPort(s.Nodes[0].Address); err == nil {
+		if port, err := strconv.Atoi(p); err == nil {
+			svc.Spec.Ports = []servicePort{{Name: "micro", Protocol: "TCP", Port: port, TargetPort: port}}
+		}
+	}
+
+	// another replica may have created it first
+	if err := k.client.createService(ctx, svc); err != nil && !isConflict(err) {
+		return err
+	}
+	return nil
+}
+
+func (k *kregistry) Deregister(s *registry.Service, opts ...registry.DeregisterOption) error {
+	if len(s.Nodes) == 0 {
+		return errors.New("require at least one node")
+	}
+
+	k.Lock()
+	delete(k.register, s.Name)
+	k.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), k.options.Timeout)
+	defer cancel()
+
+	// null removes the keys in a merge patch
+	key := serviceKey(s.Name)
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels":      map[string]interface{}{key: nil},
+			"annotations": map[string]interface{}{key: nil},
+		},
+	}
+
+	k.options.Logger.Logf(logger.TraceLevel, "Deregistering %s from pod %s", s.Name, k.pod)
+	return k.client.patchPod(ctx, k.pod, patch)
+}
+
+func (k *kregistry) GetService(name string, opts ...registry.GetOption) ([]*registry.Service, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), k.options.Timeout)
+	defer cancel()
+
+	services, err := k.lookup(ctx, serviceName(name), name)
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		return nil, registry.ErrNotFound
+	}
+	return services, nil
+}
+
+// lookup returns the services behind the Kubernetes Service svc, one per
+// name and version, with the nodes of every pod its EndpointSlices report
+// as ready. If name is set only that service is returned.
+func (k *kregistry) lookup(ctx context.Context, svc, name string) ([]*registry.Service, error) {
+	slices, err := k.client.listEndpointSlices(ctx, serviceNameLabel+"="+svc)
+	if err != nil {
+		return nil, err
+	}
+
+	ready := make(map[string]bool)
+	for _, sl := range slices.Items {
+		for _, ep := range sl.Endpoints {
+			if ep.ready() && ep.TargetRef != nil && ep.TargetRef.Kind == "Pod" {
+				ready[ep.TargetRef.Name] = true
+			}
+		}
+	}
+	if len(ready) == 0 {
+		return nil, nil
+	}
+
+	key := servicePrefix + svc
+	pods, err := k.client.listPods(ctx, key+"=true")
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]*registry.Service)
+	for _, p := range pods.Items {
+		if !ready[p.Metadata.Name] {
+			continue
+		}
+		sn := decode(p.Metadata.Annotations[key])
+		if sn == nil || (len(name) > 0 && sn.Name != name) {
+			continue
+		}
+		s, ok := versions[sn.Name+":"+sn.Version]
+		if !ok {
+			s = &registry.Service{
+				Name:      sn.Name,
+				Version:   sn.Version,
+				Metadata:  sn.Metadata,
+				Endpoints: sn.Endpoints,
+			}
+			versions[sn.Name+":"+sn.Version] = s
+		}
+		s.Nodes = append(s.Nodes, sn.Nodes...)
+	}
+
+	services := make([]*registry.Service, 0, len(versions))
+	for _, s := range versions {
+		services = append(services, s)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		if services[i].Name != services[j].Name {
+			return services[i].Name < services[j].Name
+		}
+		return services[i].Version < services[j].Version
+	})
+
+	return services, nil
+}
+
+func (k *kregistry) ListServices(opts ...registry.ListOption) ([]*registry.Service, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), k.options.Timeout)
+	defer cancel()
+
+	pods, err := k.client.listPods(ctx, registryLabel+"=true")
+	if err != nil {
+		return nil, err
+	}
+
+	type nameVersion struct{ name, version string }
+	versions := make(map[nameVersion]*registry.Service)
+	for _, p := range pods.Items {
+		for key, val := range p.Metadata.Annotations {
+			if !strings.HasPrefix(key, servicePrefix) {
+				continue
+			}
+			sn := decode(val)
+			if sn == nil {
+				continue
+			}
+			nv := nameVersion{sn.Name, sn.Version}
+			v, ok := versions[nv]
+			if !ok {
+				versions[nv] = sn
+				continue
+			}
+			v.Nodes = append(v.Nodes, sn.Nodes...)
+		}
+	}
+
+	services := make([]*registry.Service, 0, len(versions))
+	for _, s := range versions {
+		services = append(services, s)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+
+	return services, nil
+}
+
+func (k *kregistry) Watch(opts ...registry.WatchOption) (registry.Watcher, error) {
+	return newWatcher(k, opts...)
+}
+
+func (k *kregistry) String() string {
+	return "kubernetes"
+}
+
+func decode(s string) *registry.Service {
+	if len(s) == 0 {
+		return nil
+	}
+	var svc *registry.Service
+	if err := json.Unmarshal([]byte(s), &svc); err != nil {
+		return nil
+	}
+	return svc
+}
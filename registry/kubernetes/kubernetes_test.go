@@ -0,0 +1,424 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/registry"
+)
+
+// fakeAPI is an in-memory Kubernetes API server covering the calls the
+// registry makes. It plays the EndpointSlice controller too: whenever a
+// pod or Service changes, the slices of every Service are recomputed from
+// the pods its selector matches.
+type fakeAPI struct {
+	sync.Mutex
+	rv       int
+	pods     map[string]*pod
+	ready    map[string]bool
+	services map[string]*service
+	slices   map[string]*endpointSlice
+	watches  map[chan watchEvent]string
+}
+
+func newFakeAPI(t *testing.T, pods ...string) (*fakeAPI, string) {
+	f := &fakeAPI{
+		pods:     make(map[string]*pod),
+		ready:    make(map[string]bool),
+		services: make(map[string]*service),
+		slices:   make(map[string]*endpointSlice),
+		watches:  make(map[chan watchEvent]string),
+	}
+	for i, name := range pods {
+		f.pods[name] = &pod{
+			Metadata: objectMeta{Name: name, Namespace: "default"},
+			Status:   podStatus{PodIP: fmt.Sprintf("10.0.0.%d", i+1), Phase: "Running"},
+		}
+		f.ready[name] = true
+	}
+
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv.URL
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	const (
+		pods     = "/api/v1/namespaces/default/pods"
+		services = "/api/v1/namespaces/default/services"
+		slices   = "/apis/discovery.k8s.io/v1/namespaces/default/endpointslices"
+	)
+	sel := r.URL.Query().Get("labelSelector")
+
+	switch {
+	case r.URL.Path == pods && r.Method == http.MethodGet:
+		f.Lock()
+		list := podList{Metadata: listMeta{ResourceVersion: f.version()}}
+		for _, p := range f.pods {
+			if matches(sel, p.Metadata.Labels) {
+				list.Items = append(list.Items, *p)
+			}
+		}
+		f.Unlock()
+		json.NewEncoder(w).Encode(list)
+
+	case strings.HasPrefix(r.URL.Path, pods+"/") && r.Method == http.MethodPatch:
+		if ct := r.Header.Get("Content-Type"); ct != "application/merge-patch+json" {
+			http.Error(w, "unsupported patch "+ct, http.StatusUnsupportedMediaType)
+			return
+		}
+		var patch struct {
+			Metadata struct {
+				Labels      map[string]*string `json:"labels"`
+				Annotations map[string]*string `json:"annotations"`
+			} `json:"metadata"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.Lock()
+		defer f.Unlock()
+		p, ok := f.pods[strings.TrimPrefix(r.URL.Path, pods+"/")]
+		if !ok {
+			notFound(w)
+			return
+		}
+		p.Metadata.Labels = merge(p.Metadata.Labels, patch.Metadata.Labels)
+		p.Metadata.Annotations = merge(p.Metadata.Annotations, patch.Metadata.Annotations)
+		f.reconcile()
+		json.NewEncoder(w).Encode(p)
+
+	case strings.HasPrefix(r.URL.Path, services+"/") && r.Method == http.MethodGet:
+		f.Lock()
+		defer f.Unlock()
+		svc, ok := f.services[strings.TrimPrefix(r.URL.Path, services+"/")]
+		if !ok {
+			notFound(w)
+			return
+		}
+		json.NewEncoder(w).Encode(svc)
+
+	case r.URL.Path == services && r.Method == http.MethodPost:
+		var svc service
+		if err := json.NewDecoder(r.Body).Decode(&svc); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.Lock()
+		defer f.Unlock()
+		if _, ok := f.services[svc.Metadata.Name]; ok {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(status{Code: http.StatusConflict, Reason: "AlreadyExists"})
+			return
+		}
+		f.services[svc.Metadata.Name] = &svc
+		f.reconcile()
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(svc)
+
+	case r.URL.Path == slices && r.URL.Query().Get("watch") == "true":
+		ch := make(chan watchEvent, 16)
+		f.Lock()
+		f.watches[ch] = sel
+		f.Unlock()
+		defer func() {
+			f.Lock()
+			delete(f.watches, ch)
+			f.Unlock()
+		}()
+
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		enc := json.NewEncoder(w)
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case ev := <-ch:
+				enc.Encode(ev)
+				w.(http.Flusher).Flush()
+			}
+		}
+
+	case r.URL.Path == slices && r.Method == http.MethodGet:
+		f.Lock()
+		list := endpointSliceList{Metadata: listMeta{ResourceVersion: f.version()}}
+		for _, sl := range f.slices {
+			if matches(sel, sl.Metadata.Labels) {
+				list.Items = append(list.Items, *sl)
+			}
+		}
+		f.Unlock()
+		json.NewEncoder(w).Encode(list)
+
+	default:
+		notFound(w)
+	}
+}
+
+func (f *fakeAPI) version() string {
+	return strconv.Itoa(f.rv)
+}
+
+// setReady marks a pod ready or not, as a failing readiness probe would.
+func (f *fakeAPI) setReady(name string, ready bool) {
+	f.Lock()
+	defer f.Unlock()
+	f.ready[name] = ready
+	f.reconcile()
+}
+
+func (f *fakeAPI) deletePod(name string) {
+	f.Lock()
+	defer f.Unlock()
+	delete(f.pods, name)
+	f.reconcile()
+}
+
+// reconcile recomputes the slices and notifies watches. Called with the
+// lock held.
+func (f *fakeAPI) reconcile() {
+	for name, svc := range f.services {
+		labels := map[string]string{serviceNameLabel: name}
+		for k, v := range svc.Metadata.Labels {
+			labels[k] = v
+		}
+		sl := &endpointSlice{Metadata: objectMeta{Name: name + "-abcde", Labels: labels}}
+		for _, p := range f.pods {
+			if !matchesMap(svc.Spec.Selector, p.Metadata.Labels) {
+				continue
+			}
+			ready := f.ready[p.Metadata.Name]
+			sl.Endpoints = append(sl.Endpoints, endpoint{
+				Addresses:  []string{p.Status.PodIP},
+				Conditions: endpointConditions{Ready: &ready},
+				TargetRef:  &objectReference{Kind: "Pod", Name: p.Metadata.Name, Namespace: "default"},
+			})
+		}
+
+		old, ok := f.slices[name]
+		typ := "ADDED"
+		if ok {
+			if reflect.DeepEqual(old.Endpoints, sl.Endpoints) {
+				continue
+			}
+			typ = "MODIFIED"
+		}
+		f.rv++
+		f.slices[name] = sl
+		for ch, sel := range f.watches {
+			if matches(sel, labels) {
+				ch <- watchEvent{Type: typ, Object: *sl}
+			}
+		}
+	}
+}
+
+func notFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(status{Code: http.StatusNotFound, Reason: "NotFound", Message: "not found"})
+}
+
+func merge(dst map[string]string, patch map[string]*string) map[string]string {
+	if dst == nil {
+		dst = make(map[string]string)
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(dst, k)
+		} else {
+			dst[k] = *v
+		}
+	}
+	return dst
+}
+
+func matches(selector string, labels map[string]string) bool {
+	for _, req := range strings.Split(selector, ",") {
+		if len(req) == 0 {
+			continue
+		}
+		k, v, _ := strings.Cut(req, "=")
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesMap(selector, labels map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func newTestRegistry(addr, pod string) registry.Registry {
+	return NewRegistry(registry.Addrs(addr), Namespace("default"), PodName(pod))
+}
+
+func testService(id, addr string) *registry.Service {
+	return &registry.Service{
+		Name:    "go.micro.srv.greeter",
+		Version: "1.0.0",
+		Endpoints: []*registry.Endpoint{{
+			Name:     "Greeter.Hello",
+			Metadata: map[string]string{"stream": "false"},
+		}},
+		Nodes: []*registry.Node{{Id: id, Address: addr, Metadata: map[string]string{"zone": "a"}}},
+	}
+}
+
+func TestServiceName(t *testing.T) {
+	for in, want := range map[string]string{
+		"helloworld":            "micro-helloworld",
+		"go.micro.srv.Greeter":  "micro-go-micro-srv-greeter",
+		strings.Repeat("x", 80): "micro-" + strings.Repeat("x", 57),
+	} {
+		if got := serviceName(in); got != want {
+			t.Errorf("serviceName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestRegisterAndGetService(t *testing.T) {
+	api, addr := newFakeAPI(t, "pod-a", "pod-b")
+	ra := newTestRegistry(addr, "pod-a")
+	rb := newTestRegistry(addr, "pod-b")
+
+	if err := ra.Register(testService("greeter-a", "10.0.0.1:8080")); err != nil {
+		t.Fatal(err)
+	}
+	if err := rb.Register(testService("greeter-b", "10.0.0.2:8080")); err != nil {
+		t.Fatal(err)
+	}
+
+	api.Lock()
+	svc := api.services["micro-go-micro-srv-greeter"]
+	api.Unlock()
+	if svc == nil || svc.Spec.ClusterIP != "None" || svc.Spec.Selector[servicePrefix+"micro-go-micro-srv-greeter"] != "true" {
+		t.Fatalf("headless Service not created as expected: %+v", svc)
+	}
+
+	services, err := ra.GetService("go.micro.srv.greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 1 || len(services[0].Nodes) != 2 {
+		t.Fatalf("expected one version with two nodes, got %+v", services)
+	}
+	ep := services[0].Endpoints
+	if len(ep) != 1 || ep[0].Name != "Greeter.Hello" || ep[0].Metadata["stream"] != "false" {
+		t.Fatalf("endpoint metadata not carried: %+v", ep)
+	}
+
+	// an unready pod drops out of discovery
+	api.setReady("pod-b", false)
+	services, err = ra.GetService("go.micro.srv.greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services[0].Nodes) != 1 || services[0].Nodes[0].Id != "greeter-a" {
+		t.Fatalf("expected only greeter-a, got %+v", services[0].Nodes)
+	}
+
+	if err := ra.Deregister(testService("greeter-a", "10.0.0.1:8080")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ra.GetService("go.micro.srv.greeter"); err != registry.ErrNotFound {
+		t.Fatalf("GetService after deregister = %v, want ErrNotFound", err)
+	}
+
+	api.Lock()
+	labels := api.pods["pod-a"].Metadata.Labels
+	api.Unlock()
+	if _, ok := labels[servicePrefix+"micro-go-micro-srv-greeter"]; ok {
+		t.Fatal("service label not removed on deregister")
+	}
+}
+
+func TestListServices(t *testing.T) {
+	_, addr := newFakeAPI(t, "pod-a")
+	r := newTestRegistry(addr, "pod-a")
+
+	// "ab" version "c" and "a" version "bc" are different services
+	for _, nv := range [][2]string{{"ab", "c"}, {"a", "bc"}} {
+		s := testService("node", "10.0.0.1:8080")
+		s.Name, s.Version = nv[0], nv[1]
+		if err := r.Register(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	services, err := r.ListServices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services) != 2 || services[0].Name != "a" || services[1].Name != "ab" {
+		t.Fatalf("unexpected services: %+v", services)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	api, addr := newFakeAPI(t, "pod-a", "pod-b")
+	ra := newTestRegistry(addr, "pod-a")
+	rb := newTestRegistry(addr, "pod-b")
+
+	w, err := ra.Watch(registry.WatchService("go.micro.srv.greeter"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	next := func(action, node string) {
+		t.Helper()
+		ch := make(chan *registry.Result, 1)
+		go func() {
+			r, err := w.Next()
+			if err != nil {
+				t.Error(err)
+			}
+			ch <- r
+		}()
+		select {
+		case r := <-ch:
+			if r == nil || r.Action != action || len(r.Service.Nodes) != 1 || r.Service.Nodes[0].Id != node {
+				t.Fatalf("got %+v, want %s of %s", r, action, node)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s", action)
+		}
+	}
+
+	if err := ra.Register(testService("greeter-a", "10.0.0.1:8080")); err != nil {
+		t.Fatal(err)
+	}
+	next("create", "greeter-a")
+
+	if err := rb.Register(testService("greeter-b", "10.0.0.2:8080")); err != nil {
+		t.Fatal(err)
+	}
+	next("create", "greeter-b")
+
+	api.deletePod("pod-b")
+	next("delete", "greeter-b")
+
+	api.setReady("pod-a", false)
+	next("delete", "greeter-a")
+
+	w.Stop()
+	if _, err := w.Next(); err != registry.ErrWatcherStopped {
+		t.Fatalf("Next after Stop = %v, want ErrWatcherStopped", err)
+	}
+}
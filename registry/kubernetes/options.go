@@ -0,0 +1,33 @@
+package kubernetes
+
+import (
+	"context"
+
+	"go-micro.dev/v6/registry"
+)
+
+type namespaceKey struct{}
+
+type podNameKey struct{}
+
+// Namespace sets the namespace services are registered in. Defaults to
+// the namespace of the pod's service account, or "default".
+func Namespace(ns string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, namespaceKey{}, ns)
+	}
+}
+
+// PodName sets the name of the pod this process runs in, which is
+// annotated on Register. Defaults to $POD_NAME, then $HOSTNAME.
+func PodName(name string) registry.Option {
+	return func(o *registry.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, podNameKey{}, name)
+	}
+}
@@ -0,0 +1,98 @@
+package kubernetes
+
+// The subset of the Kubernetes API objects the registry reads and writes.
+// They are declared here rather than imported from k8s.io/api to keep the
+// registry free of the client-go dependency tree.
+
+type objectMeta struct {
+	Name            string            `json:"name,omitempty"`
+	Namespace       string            `json:"namespace,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Annotations     map[string]string `json:"annotations,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+}
+
+type listMeta struct {
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type pod struct {
+	Metadata objectMeta `json:"metadata"`
+	Status   podStatus  `json:"status"`
+}
+
+type podStatus struct {
+	PodIP string `json:"podIP,omitempty"`
+	Phase string `json:"phase,omitempty"`
+}
+
+type podList struct {
+	Metadata listMeta `json:"metadata"`
+	Items    []pod    `json:"items"`
+}
+
+type service struct {
+	APIVersion string      `json:"apiVersion,omitempty"`
+	Kind       string      `json:"kind,omitempty"`
+	Metadata   objectMeta  `json:"metadata"`
+	Spec       serviceSpec `json:"spec"`
+}
+
+type serviceSpec struct {
+	ClusterIP string            `json:"clusterIP,omitempty"`
+	Selector  map[string]string `json:"selector,omitempty"`
+	Ports     []servicePort     `json:"ports,omitempty"`
+	// PublishNotReadyAddresses is left false so EndpointSlices only
+	// report ready pods as ready.
+	PublishNotReadyAddresses bool `json:"publishNotReadyAddresses,omitempty"`
+}
+
+type servicePort struct {
+	Name       string `json:"name,omitempty"`
+	Protocol   string `json:"protocol,omitempty"`
+	Port       int    `json:"port"`
+	TargetPort int    `json:"targetPort,omitempty"`
+}
+
+type endpointSlice struct {
+	Metadata  objectMeta `json:"metadata"`
+	Endpoints []endpoint `json:"endpoints"`
+}
+
+type endpoint struct {
+	Addresses  []string           `json:"addresses"`
+	Conditions endpointConditions `json:"conditions"`
+	TargetRef  *objectReference   `json:"targetRef,omitempty"`
+}
+
+type endpointConditions struct {
+	Ready *bool `json:"ready,omitempty"`
+}
+
+type objectReference struct {
+	Kind      string `json:"kind,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type endpointSliceList struct {
+	Metadata listMeta        `json:"metadata"`
+	Items    []endpointSlice `json:"items"`
+}
+
+type watchEvent struct {
+	Type   string        `json:"type"`
+	Object endpointSlice `json:"object"`
+}
+
+type status struct {
+	Message string `json:"message,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Code    int    `json:"code,omitempty"`
+}
+
+// ready reports whether the endpoint is ready. A missing condition means
+// ready, as documented for EndpointSlices.
+func (e endpoint) ready() bool {
+	return e.Conditions.Ready == nil || *e.Conditions.Ready
+}
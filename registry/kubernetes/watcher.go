@@ -0,0 +1,180 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"go-micro.dev/v6/logger"
+	"go-micro.dev/v6/registry"
+	"go-micro.dev/v6/registry/internal/snapshot"
+)
+
+// watcher follows EndpointSlice events. Each event names a Kubernetes
+// Service; the watcher resolves the services behind it again and diffs
+// them against what it last saw to produce create, update and delete
+// results per node, as registry/cache expects.
+type watcher struct {
+	k        *kregistry
+	name     string
+	selector string
+	ctx      context.Context
+	cancel   context.CancelFunc
+	results  chan *registry.Result
+
+	// last seen services by Kubernetes Service name, only touched by run
+	seen map[string][]*registry.Service
+}
+
+func newWatcher(k *kregistry, opts ...registry.WatchOption) (registry.Watcher, error) {
+	var wo registry.WatchOptions
+	for _, o := range opts {
+		o(&wo)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &watcher{
+		k:        k,
+		name:     wo.Service,
+		selector: registryLabel + "=true",
+		ctx:      ctx,
+		cancel:   cancel,
+		results:  make(chan *registry.Result, 64),
+		seen:     make(map[string][]*registry.Service),
+	}
+	if len(wo.Service) > 0 {
+		w.selector = serviceNameLabel + "=" + serviceName(wo.Service)
+	}
+
+	// the initial list only records the current state; like the other
+	// registries, the watcher reports changes from here on
+	rv, err := w.list(false)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go w.run(rv)
+
+	return w, nil
+}
+
+func (w *watcher) Next() (*registry.Result, error) {
+	select {
+	case <-w.ctx.Done():
+		return nil, registry.ErrWatcherStopped
+	case r := <-w.results:
+		return r, nil
+	}
+}
+
+func (w *watcher) Stop() {
+	w.cancel()
+}
+
+func (w *watcher) run(rv string) {
+	for {
+		if err := w.watch(rv); err != nil && w.ctx.Err() == nil {
+			w.k.options.Logger.Logf(logger.DebugLevel, "kubernetes watch: %v", err)
+			select {
+			case <-w.ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+		if w.ctx.Err() != nil {
+			return
+		}
+
+		// the stream ended or the resource version expired: list again,
+		// reporting anything missed, and resume from there
+		var err error
+		if rv, err = w.list(true); err != nil {
+			rv = ""
+		}
+	}
+}
+
+// list resolves every Service matching the selector, plus any seen before,
+// and returns the list's resource version.
+func (w *watcher) list(notify bool) (string, error) {
+	ctx, cancel := context.WithTimeout(w.ctx, w.k.options.Timeout)
+	defer cancel()
+
+	slices, err := w.k.client.listEndpointSlices(ctx, w.selector)
+	if err != nil {
+		return "", err
+	}
+
+	names := make(map[string]bool)
+	for _, sl := range slices.Items {
+		if n := sl.Metadata.Labels[serviceNameLabel]; len(n) > 0 {
+			names[n] = true
+		}
+	}
+	for n := range w.seen {
+		names[n] = true
+	}
+	for n := range names {
+		w.sync(n, notify)
+	}
+
+	return slices.Metadata.ResourceVersion, nil
+}
+
+func (w *watcher) watch(rv string) error {
+	body, err := w.k.client.watchEndpointSlices(w.ctx, w.selector, rv)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	dec := json.NewDecoder(body)
+	for {
+		var ev watchEvent
+		if err := dec.Decode(&ev); err != nil {
+			return err
+		}
+
+		switch ev.Type {
+		case "ADDED", "MODIFIED", "DELETED":
+			if n := ev.Object.Metadata.Labels[serviceNameLabel]; len(n) > 0 {
+				w.sync(n, true)
+			}
+		case "ERROR":
+			// typically 410 Gone for an expired resource version
+			return nil
+		}
+	}
+}
+
+// sync resolves the services behind the Kubernetes Service svc and, if
+// notify is set, queues results for what changed since the last sync.
+func (w *watcher) sync(svc string, notify bool) {
+	ctx, cancel := context.WithTimeout(w.ctx, w.k.options.Timeout)
+	defer cancel()
+
+	services, err := w.k.lookup(ctx, svc, w.name)
+	if err != nil {
+		w.k.options.Logger.Logf(logger.DebugLevel, "kubernetes watch: lookup %s: %v", svc, err)
+		return
+	}
+
+	prev := w.seen[svc]
+	if len(services) > 0 {
+		w.seen[svc] = services
+	} else {
+		delete(w.seen, svc)
+	}
+
+	if !notify {
+		return
+	}
+
+	for _, r := range snapshot.Diff(prev, services) {
+		select {
+		case w.results <- r:
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
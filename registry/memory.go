@@ -179,11 +179,30 @@ func (m *memRegistry) Register(s *Service, opts ...RegisterOption) error {
 		return nil
 	}
 
-	// refresh TTL and timestamp
+	// refresh TTL and timestamp, and pick up changed node metadata
+	updatedNodes := false
 	for _, n := range s.Nodes {
 		logger.Logf(log.DebugLevel, "Updated registration for service: %s, version: %s", s.Name, s.Version)
-		m.records[s.Name][s.Version].Nodes[n.Id].TTL = options.TTL
-		m.records[s.Name][s.Version].Nodes[n.Id].LastSeen = time.Now()
+		rn := m.records[s.Name][s.Version].Nodes[n.Id]
+		rn.TTL = options.TTL
+		rn.LastSeen = time.Now()
+
+		if rn.Address != n.Address || !equalMetadata(rn.Metadata, n.Metadata) {
+			metadata := make(map[string]string)
+			for k, v := range n.Metadata {
+				metadata[k] = v
+			}
+			rn.Node = &Node{
+				Id:       n.Id,
+				Address:  n.Address,
+				Metadata: metadata,
+			}
+			updatedNodes = true
+		}
+	}
+
+	if updatedNodes {
+		go m.sendEvent(&Result{Action: "update", Service: s})
 	}
 
 	return nil
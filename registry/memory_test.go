@@ -242,3 +242,26 @@ func TestMemoryRegistryTTLConcurrent(t *testing.T) {
 		}
 	}
 }
+
+func TestMemoryRegistryUpdatesNodeMetadata(t *testing.T) {
+	m := NewMemoryRegistry()
+	node := &Node{Id: "foo-1", Address: "localhost:9999"}
+	service := &Service{Name: "foo", Version: "1.0.0", Nodes: []*Node{node}}
+	if err := m.Register(service); err != nil {
+		t.Fatal(err)
+	}
+
+	// re-registering a known node replaces its metadata
+	node.Metadata = map[string]string{MetadataHealth: "degraded"}
+	if err := m.Register(service); err != nil {
+		t.Fatal(err)
+	}
+
+	services, err := m.GetService("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := services[0].Nodes[0].Metadata[MetadataHealth]; got != "degraded" {
+		t.Fatalf("node health = %q, want degraded", got)
+	}
+}
@@ -85,3 +85,16 @@ func recordToService(r *record) *Service {
 		Nodes:     nodes,
 	}
 }
+
+// equalMetadata reports whether two node metadata maps hold the same values.
+func equalMetadata(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
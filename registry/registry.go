@@ -12,6 +12,18 @@ var (
 	ErrWatcherStopped = errors.New("watcher stopped")
 )
 
+// Node metadata keys for where a node runs. The federation registry sets
+// them and selector.FilterLocality prefers nodes that match the caller.
+const (
+	MetadataRegion = "region"
+	MetadataZone   = "zone"
+)
+
+// MetadataHealth is the node metadata key for a node's health status,
+// "up", "degraded" or "down", set by health.Advertise. Selectors skip down
+// nodes and only use degraded ones when no others are left.
+const MetadataHealth = "health"
+
 // The registry provides an interface for service discovery
 // and an abstraction over varying implementations
 // {consul, etcd, zookeeper, ...}.
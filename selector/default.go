@@ -70,10 +70,16 @@ func (c *registrySelector) Select(service string, opts ...SelectOption) (Next, e
 		return nil, err
 	}
 
-	// apply the filters
+	// drop nodes that are down and apply the filters, then prefer
+	// healthy nodes over degraded ones among those left, so a filter
+	// whose only matches are degraded still gets them
+	services = filterNodes(services, func(n *registry.Node) bool {
+		return n.Metadata[registry.MetadataHealth] != "down"
+	})
 	for _, filter := range sopts.Filters {
 		services = filter(services)
 	}
+	services = FilterHealth()(services)
 
 	// if there's nothing left, return
 	if len(services) == 0 {
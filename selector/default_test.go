@@ -30,3 +30,60 @@ func TestRegistrySelector(t *testing.T) {
 		t.Logf("Selector Counts %v", counts)
 	}
 }
+
+func TestRegistrySelectorSkipsUnhealthyNodes(t *testing.T) {
+	r := registry.NewMemoryRegistry()
+	if err := r.Register(&registry.Service{
+		Name:    "bar",
+		Version: "1.0.0",
+		Nodes: []*registry.Node{
+			{Id: "bar-down", Address: "localhost:1111", Metadata: map[string]string{registry.MetadataHealth: "down"}},
+			{Id: "bar-degraded", Address: "localhost:2222", Metadata: map[string]string{registry.MetadataHealth: "degraded"}},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s := NewSelector(Registry(r))
+
+	next, err := s.Select("bar")
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		node, err := next()
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		if node.Id != "bar-degraded" {
+			t.Fatalf("selected %s, want the degraded node over the down one", node.Id)
+		}
+	}
+}
+
+func TestRegistrySelectorFiltersBeforeHealth(t *testing.T) {
+	r := registry.NewMemoryRegistry()
+	for _, svc := range []*registry.Service{
+		{Name: "bar", Version: "1.0.0", Nodes: []*registry.Node{{Id: "bar-v1", Address: "localhost:1111"}}},
+		{Name: "bar", Version: "2.0.0", Nodes: []*registry.Node{
+			{Id: "bar-v2", Address: "localhost:2222", Metadata: map[string]string{registry.MetadataHealth: "degraded"}},
+		}},
+	} {
+		if err := r.Register(svc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := NewSelector(Registry(r))
+
+	// only the pinned version is degraded; it's still served
+	next, err := s.Select("bar", WithFilter(FilterVersion("2.0.0")))
+	if err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	node, err := next()
+	if err != nil {
+		t.Fatalf("next: %v", err)
+	}
+	if node.Id != "bar-v2" {
+		t.Fatalf("selected %s, want the degraded node of the pinned version", node.Id)
+	}
+}
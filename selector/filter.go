@@ -71,3 +71,75 @@ func FilterVersion(version string) Filter {
 		return services
 	}
 }
+
+// FilterLocality is a locality based Select Filter which prefers nodes in
+// the given zone, then in the given region, using the registry.MetadataZone
+// and registry.MetadataRegion node metadata. It fails over to the next
+// tier only when no node matches, so calls stay local while local nodes
+// are registered and go remote when they're gone. An empty zone skips
+// the zone tier.
+func FilterLocality(region, zone string) Filter {
+	return func(old []*registry.Service) []*registry.Service {
+		if len(zone) > 0 {
+			if services := filterNodes(old, func(n *registry.Node) bool {
+				return n.Metadata[registry.MetadataRegion] == region && n.Metadata[registry.MetadataZone] == zone
+			}); len(services) > 0 {
+				return services
+			}
+		}
+
+		if len(region) > 0 {
+			if services := filterNodes(old, func(n *registry.Node) bool {
+				return n.Metadata[registry.MetadataRegion] == region
+			}); len(services) > 0 {
+				return services
+			}
+		}
+
+		return old
+	}
+}
+
+// FilterHealth is a health based Select Filter which drops nodes whose
+// registry.MetadataHealth is "down", and degraded nodes while any other
+// node is left. The registry selector applies it on every Select, after
+// the select filters.
+func FilterHealth() Filter {
+	return func(old []*registry.Service) []*registry.Service {
+		if services := filterNodes(old, func(n *registry.Node) bool {
+			h := n.Metadata[registry.MetadataHealth]
+			return h != "down" && h != "degraded"
+		}); len(services) > 0 {
+			return services
+		}
+
+		return filterNodes(old, func(n *registry.Node) bool {
+			return n.Metadata[registry.MetadataHealth] != "down"
+		})
+	}
+}
+
+// filterNodes returns copies of the services with only the nodes that
+// match, dropping services left with none.
+func filterNodes(old []*registry.Service, match func(*registry.Node) bool) []*registry.Service {
+	var services []*registry.Service
+
+	for _, service := range old {
+		var nodes []*registry.Node
+
+		for _, node := range service.Nodes {
+			if match(node) {
+				nodes = append(nodes, node)
+			}
+		}
+
+		if len(nodes) > 0 {
+			serv := new(registry.Service)
+			*serv = *service
+			serv.Nodes = nodes
+			services = append(services, serv)
+		}
+	}
+
+	return services
+}
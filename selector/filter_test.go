@@ -1,6 +1,7 @@
 package selector
 
 import (
+	"strings"
 	"testing"
 
 	"go-micro.dev/v6/registry"
@@ -237,3 +238,100 @@ func TestFilterVersion(t *testing.T) {
 		}
 	}
 }
+
+func TestFilterLocality(t *testing.T) {
+	node := func(id, region, zone string) *registry.Node {
+		return &registry.Node{
+			Id: id,
+			Metadata: map[string]string{
+				registry.MetadataRegion: region,
+				registry.MetadataZone:   zone,
+			},
+		}
+	}
+
+	services := []*registry.Service{
+		{
+			Name:    "test",
+			Version: "1.0.0",
+			Nodes: []*registry.Node{
+				node("eu-a", "eu", "eu-1a"),
+				node("eu-b", "eu", "eu-1b"),
+				node("us-a", "us", "us-1a"),
+			},
+		},
+		{
+			Name:    "test",
+			Version: "1.1.0",
+			Nodes: []*registry.Node{
+				node("eu-b2", "eu", "eu-1b"),
+			},
+		},
+	}
+
+	testData := []struct {
+		region, zone string
+		nodes        []string
+	}{
+		// same zone
+		{"eu", "eu-1a", []string{"eu-a"}},
+		{"eu", "eu-1b", []string{"eu-b", "eu-b2"}},
+		// no nodes in the zone, fail over to the region
+		{"eu", "eu-1c", []string{"eu-a", "eu-b", "eu-b2"}},
+		{"us", "", []string{"us-a"}},
+		// no nodes in the region, fail over to everything
+		{"ap", "ap-1a", []string{"eu-a", "eu-b", "us-a", "eu-b2"}},
+	}
+
+	for _, td := range testData {
+		var got []string
+		for _, s := range FilterLocality(td.region, td.zone)(services) {
+			for _, n := range s.Nodes {
+				got = append(got, n.Id)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(td.nodes, ",") {
+			t.Errorf("FilterLocality(%q, %q) = %v, want %v", td.region, td.zone, got, td.nodes)
+		}
+	}
+
+	// the input is left alone
+	if len(services[0].Nodes) != 3 {
+		t.Fatalf("filter changed the input services")
+	}
+}
+
+func TestFilterHealth(t *testing.T) {
+	node := func(id, health string) *registry.Node {
+		n := &registry.Node{Id: id}
+		if health != "" {
+			n.Metadata = map[string]string{registry.MetadataHealth: health}
+		}
+		return n
+	}
+
+	testData := []struct {
+		nodes []*registry.Node
+		want  []string
+	}{
+		// nodes that don't advertise health are healthy
+		{[]*registry.Node{node("a", ""), node("b", "up"), node("c", "degraded"), node("d", "down")}, []string{"a", "b"}},
+		// degraded nodes only when nothing else is left
+		{[]*registry.Node{node("c", "degraded"), node("d", "down")}, []string{"c"}},
+		{[]*registry.Node{node("d", "down")}, nil},
+	}
+
+	for _, td := range testData {
+		services := []*registry.Service{{Name: "test", Nodes: td.nodes}}
+
+		var got []string
+		for _, s := range FilterHealth()(services) {
+			for _, n := range s.Nodes {
+				got = append(got, n.Id)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(td.want, ",") {
+			t.Errorf("FilterHealth() = %v, want %v", got, td.want)
+		}
+	}
+}
@@ -703,6 +703,23 @@ func (g *grpcServer) Init(opts ...server.Option) error {
 	return nil
 }
 
+// SetMetadata sets key in the metadata the server registers with. Unlike
+// Init it leaves the running gRPC server alone.
+func (g *grpcServer) SetMetadata(key, value string) {
+	g.Lock()
+	defer g.Unlock()
+
+	md := make(map[string]string, len(g.opts.Metadata)+1)
+	for k, v := range g.opts.Metadata {
+		md[k] = v
+	}
+	md[key] = value
+	g.opts.Metadata = md
+
+	// the cached service carries the old metadata
+	g.rsvc = nil
+}
+
 func (g *grpcServer) NewHandler(h interface{}, opts ...server.HandlerOption) server.Handler {
 	return newRpcHandler(h, opts...)
 }
@@ -864,6 +881,7 @@ func (g *grpcServer) Register() error {
 	for _, e := range subscriberList {
 		endpoints = append(endpoints, e.Endpoints()...)
 	}
+	endpoints = append(endpoints, config.Events...)
 	g.RUnlock()
 
 	service := &registry.Service{
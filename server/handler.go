@@ -18,6 +18,8 @@ type SubscriberOptions struct {
 	// with a nil error the message is acked.
 	AutoAck  bool
 	Internal bool
+	// Metadata is added to the subscriber's endpoints.
+	Metadata map[string]string
 }
 
 // EndpointMetadata is a Handler option that allows metadata to be added to
@@ -44,6 +46,20 @@ func InternalSubscriber(b bool) SubscriberOption {
 		o.Internal = b
 	}
 }
+
+// SubscriberMetadata adds metadata to the endpoints a subscriber advertises
+// to the discovery system.
+func SubscriberMetadata(md map[string]string) SubscriberOption {
+	return func(o *SubscriberOptions) {
+		if o.Metadata == nil {
+			o.Metadata = make(map[string]string, len(md))
+		}
+		for k, v := range md {
+			o.Metadata[k] = v
+		}
+	}
+}
+
 func NewSubscriberOptions(opts ...SubscriberOption) SubscriberOptions {
 	opt := SubscriberOptions{
 		AutoAck: true,
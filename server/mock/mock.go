@@ -51,6 +51,18 @@ func (m *MockServer) Init(opts ...server.Option) error {
 	return nil
 }
 
+func (m *MockServer) SetMetadata(key, value string) {
+	m.Lock()
+	defer m.Unlock()
+
+	md := make(map[string]string, len(m.Opts.Metadata)+1)
+	for k, v := range m.Opts.Metadata {
+		md[k] = v
+	}
+	md[key] = value
+	m.Opts.Metadata = md
+}
+
 func (m *MockServer) Handle(h server.Handler) error {
 	m.Lock()
 	defer m.Unlock()
@@ -58,6 +58,10 @@ type Options struct {
 	RegisterCheck func(context.Context) error
 	Metadata      map[string]string
 
+	// Events the service publishes, advertised alongside its handlers
+	// and subscribers
+	Events []*registry.Endpoint
+
 	// TLSConfig specifies tls.Config for secure serving
 	TLSConfig *tls.Config
 
@@ -212,6 +216,14 @@ func Metadata(md map[string]string) Option {
 	}
 }
 
+// Events advertises events the service publishes. Each endpoint should
+// carry "topic" and "publisher" metadata.
+func Events(eps ...*registry.Endpoint) Option {
+	return func(o *Options) {
+		o.Events = append(o.Events, eps...)
+	}
+}
+
 // RegisterCheck run func before registry service.
 func RegisterCheck(fn func(context.Context) error) Option {
 	return func(o *Options) {
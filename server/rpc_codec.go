@@ -9,9 +9,11 @@ import (
 
 	"go-micro.dev/v6/codec"
 	raw "go-micro.dev/v6/codec/bytes"
+	"go-micro.dev/v6/codec/cbor"
 	"go-micro.dev/v6/codec/grpc"
 	"go-micro.dev/v6/codec/json"
 	"go-micro.dev/v6/codec/jsonrpc"
+	"go-micro.dev/v6/codec/msgpack"
 	"go-micro.dev/v6/codec/proto"
 	"go-micro.dev/v6/codec/protorpc"
 	"go-micro.dev/v6/transport"
@@ -48,6 +50,8 @@ var (
 		"application/grpc+proto":   grpc.NewCodec,
 		"application/json":         json.NewCodec,
 		"application/json-rpc":     jsonrpc.NewCodec,
+		"application/msgpack":      msgpack.NewCodec,
+		"application/cbor":         cbor.NewCodec,
 		"application/protobuf":     proto.NewCodec,
 		"application/proto-rpc":    protorpc.NewCodec,
 		"application/octet-stream": raw.NewCodec,
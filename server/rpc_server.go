@@ -88,6 +88,22 @@ func (s *rpcServer) Init(opts ...Option) error {
 	return nil
 }
 
+// SetMetadata sets key in the metadata the server registers with.
+func (s *rpcServer) SetMetadata(key, value string) {
+	s.Lock()
+	defer s.Unlock()
+
+	md := make(map[string]string, len(s.opts.Metadata)+1)
+	for k, v := range s.opts.Metadata {
+		md[k] = v
+	}
+	md[key] = value
+	s.opts.Metadata = md
+
+	// the cached service carries the old metadata
+	s.rsvc = nil
+}
+
 // ServeConn serves a single connection.
 func (s *rpcServer) ServeConn(sock transport.Socket) {
 	logger := s.opts.Logger
@@ -727,6 +743,8 @@ func (s *rpcServer) getEndpoints() []*registry.Endpoint {
 		endpoints = append(endpoints, e.Endpoints()...)
 	}
 
+	endpoints = append(endpoints, s.opts.Events...)
+
 	return endpoints
 }
 
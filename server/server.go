@@ -37,6 +37,14 @@ type Server interface {
 	String() string
 }
 
+// MetadataSetter is implemented by servers that can change the metadata
+// they register with while running. Unlike Init, SetMetadata touches
+// nothing else, so it is safe on a started server. The change goes out
+// with the next registration.
+type MetadataSetter interface {
+	SetMetadata(key, value string)
+}
+
 // Router handle serving messages.
 type Router interface {
 	// ProcessMessage processes a message
@@ -55,12 +55,9 @@ func newSubscriber(topic string, sub interface{}, opts ...SubscriberOption) Subs
 		handlers = append(handlers, h)
 
 		endpoints = append(endpoints, &registry.Endpoint{
-			Name:    "Func",
-			Request: extractSubValue(typ),
-			Metadata: map[string]string{
-				"topic":      topic,
-				"subscriber": "true",
-			},
+			Name:     "Func",
+			Request:  extractSubValue(typ),
+			Metadata: subscriberMetadata(topic, options.Metadata),
 		})
 	} else {
 		hdlr := reflect.ValueOf(sub)
@@ -83,12 +80,9 @@ func newSubscriber(topic string, sub interface{}, opts ...SubscriberOption) Subs
 			handlers = append(handlers, h)
 
 			endpoints = append(endpoints, &registry.Endpoint{
-				Name:    name + "." + method.Name,
-				Request: extractSubValue(method.Type),
-				Metadata: map[string]string{
-					"topic":      topic,
-					"subscriber": "true",
-				},
+				Name:     name + "." + method.Name,
+				Request:  extractSubValue(method.Type),
+				Metadata: subscriberMetadata(topic, options.Metadata),
 			})
 		}
 	}
@@ -173,3 +167,18 @@ func (s *subscriber) Endpoints() []*registry.Endpoint {
 func (s *subscriber) Options() SubscriberOptions {
 	return s.opts
 }
+
+// subscriberMetadata returns the metadata of a subscriber endpoint, the
+// topic plus any set with SubscriberMetadata.
+func subscriberMetadata(topic string, md map[string]string) map[string]string {
+	meta := map[string]string{
+		"topic":      topic,
+		"subscriber": "true",
+	}
+	for k, v := range md {
+		if _, ok := meta[k]; !ok {
+			meta[k] = v
+		}
+	}
+	return meta
+}
@@ -0,0 +1,79 @@
+package store
+
+import "errors"
+
+var (
+	// ErrConflict is returned by CompareAndSwap when the stored record's
+	// revision doesn't match the one the caller read.
+	ErrConflict = errors.New("revision conflict")
+	// ErrNotSupported is returned when a store lacks an optional capability.
+	ErrNotSupported = errors.New("not supported")
+)
+
+// Batcher is implemented by stores that read and write several records in
+// one round trip. Call ReadMany and WriteMany rather than asserting it, so
+// stores without it fall back to one call per key.
+type Batcher interface {
+	// ReadMany returns the records for the keys that exist, in the order
+	// the keys were given. Missing keys are skipped rather than reported
+	// as ErrNotFound.
+	ReadMany(keys []string, opts ...ReadOption) ([]*Record, error)
+	// WriteMany writes every record, atomically where the backend can.
+	WriteMany(recs []*Record, opts ...WriteOption) error
+}
+
+// CompareAndSwapper is implemented by stores that can write a record only
+// if it hasn't changed since it was read.
+type CompareAndSwapper interface {
+	// CompareAndSwap writes r if the stored record's revision is still
+	// r.Revision, or, when r.Revision is zero, if the key does not exist
+	// (an expired record counts as missing). On success r.Revision is set
+	// to the new revision; otherwise it returns ErrConflict.
+	CompareAndSwap(r *Record, opts ...WriteOption) error
+}
+
+// ReadMany reads keys from s in one round trip if s is a Batcher, or with
+// one Read per key if it isn't.
+func ReadMany(s Store, keys []string, opts ...ReadOption) ([]*Record, error) {
+	if b, ok := s.(Batcher); ok {
+		return b.ReadMany(keys, opts...)
+	}
+
+	recs := make([]*Record, 0, len(keys))
+	for _, k := range keys {
+		r, err := s.Read(k, opts...)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		recs = append(recs, r...)
+	}
+	return recs, nil
+}
+
+// WriteMany writes recs to s in one round trip if s is a Batcher, or with
+// one Write per record if it isn't. The fallback is not atomic: it stops
+// at the first error, leaving earlier records written.
+func WriteMany(s Store, recs []*Record, opts ...WriteOption) error {
+	if b, ok := s.(Batcher); ok {
+		return b.WriteMany(recs, opts...)
+	}
+
+	for _, r := range recs {
+		if err := s.Write(r, opts...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CompareAndSwap writes r to s if its revision still matches, and returns
+// ErrNotSupported if s is not a CompareAndSwapper. There is no fallback: a
+// read followed by a write can't rule out a concurrent writer.
+func CompareAndSwap(s Store, r *Record, opts ...WriteOption) error {
+	if c, ok := s.(CompareAndSwapper); ok {
+		return c.CompareAndSwap(r, opts...)
+	}
+	return ErrNotSupported
+}
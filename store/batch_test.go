@@ -0,0 +1,59 @@
+package store
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBatchFallback(t *testing.T) {
+	// the file store has no native batching
+	s := NewFileStore(DirOption(t.TempDir()))
+	defer s.Close()
+	if _, ok := s.(Batcher); ok {
+		t.Fatal("file store implements Batcher; pick another store for the fallback test")
+	}
+
+	if err := WriteMany(s, []*Record{{Key: "a", Value: []byte("1")}, {Key: "b", Value: []byte("2")}}); err != nil {
+		t.Fatalf("write many: %v", err)
+	}
+	recs, err := ReadMany(s, []string{"b", "missing", "a"})
+	if err != nil {
+		t.Fatalf("read many: %v", err)
+	}
+	if len(recs) != 2 || recs[0].Key != "b" || recs[1].Key != "a" {
+		t.Fatalf("read many = %v, want b then a", recs)
+	}
+
+	if err := CompareAndSwap(s, &Record{Key: "a"}); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("compare and swap = %v, want ErrNotSupported", err)
+	}
+}
+
+func TestScopeCompareAndSwap(t *testing.T) {
+	base := NewMemoryStore()
+	a := Scope(base, "flow", "one")
+
+	r := &Record{Key: "run", Value: []byte("A")}
+	if err := CompareAndSwap(a, r); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	// the same key in another table is a separate record
+	if err := CompareAndSwap(Scope(base, "flow", "two"), &Record{Key: "run"}); err != nil {
+		t.Fatalf("create in another scope: %v", err)
+	}
+	if err := CompareAndSwap(a, &Record{Key: "run"}); !errors.Is(err, ErrConflict) {
+		t.Fatalf("second create = %v, want ErrConflict", err)
+	}
+
+	recs, err := ReadMany(a, []string{"run"})
+	if err != nil || len(recs) != 1 || recs[0].Revision != r.Revision {
+		t.Fatalf("scoped read many = %v %v, want revision %d", recs, err, r.Revision)
+	}
+
+	// a scope over a store without CompareAndSwap reports it
+	f := Scope(NewFileStore(DirOption(t.TempDir())), "flow", "one")
+	defer f.Close()
+	if err := CompareAndSwap(f, &Record{Key: "run"}); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("compare and swap on file scope = %v, want ErrNotSupported", err)
+	}
+}
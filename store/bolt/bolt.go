@@ -0,0 +1,397 @@
+// Package bolt is a store backed by a single bbolt file, for single-binary
+// deployments that want an embedded, transactional store.
+//
+// Each database is a top level bucket and each table a bucket inside it,
+// so prefix reads are range scans over sorted keys. The first node is the
+// file path.
+package bolt
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go-micro.dev/v6/store"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// DefaultPath is the file used when no node is set.
+	DefaultPath = filepath.Join(store.DefaultDir, "bolt.db")
+	// purgeInterval is how often expired records are deleted.
+	purgeInterval = time.Minute
+)
+
+// NewStore returns a bolt store. The file is opened on Init or on first
+// use; bbolt allows one process to hold it at a time.
+func NewStore(opts ...store.Option) store.Store {
+	options := store.Options{
+		Database: store.DefaultDatabase,
+		Table:    store.DefaultTable,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return &boltStore{options: options}
+}
+
+type boltStore struct {
+	sync.Mutex
+	options store.Options
+	db      *bolt.DB
+	purged  time.Time
+}
+
+// record is the stored form of a store.Record.
+type record struct {
+	Value     []byte                 `json:"value"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	ExpiresAt int64                  `json:"expires_at,omitempty"`
+}
+
+func (r *record) expired(now int64) bool {
+	return r.ExpiresAt > 0 && r.ExpiresAt <= now
+}
+
+// conn returns the open database, opening it if needed.
+func (b *boltStore) conn() (*bolt.DB, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.db != nil {
+		return b.db, nil
+	}
+
+	path := DefaultPath
+	if len(b.options.Nodes) > 0 && len(b.options.Nodes[0]) > 0 {
+		path = b.options.Nodes[0]
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	b.db = db
+	return db, nil
+}
+
+func (b *boltStore) scope(database, table string) ([]byte, []byte) {
+	b.Lock()
+	defer b.Unlock()
+
+	if len(database) == 0 {
+		database = b.options.Database
+	}
+	if len(database) == 0 {
+		database = store.DefaultDatabase
+	}
+	if len(table) == 0 {
+		table = b.options.Table
+	}
+	if len(table) == 0 {
+		table = store.DefaultTable
+	}
+	return []byte(database), []byte(table)
+}
+
+// bucket returns the table bucket, or nil if nothing was written to it.
+func bucket(tx *bolt.Tx, database, table []byte) *bolt.Bucket {
+	db := tx.Bucket(database)
+	if db == nil {
+		return nil
+	}
+	return db.Bucket(table)
+}
+
+func (b *boltStore) Init(opts ...store.Option) error {
+	b.Lock()
+	for _, o := range opts {
+		o(&b.options)
+	}
+	// reopen in case the path changed
+	if b.db != nil {
+		b.db.Close()
+		b.db = nil
+	}
+	b.Unlock()
+
+	_, err := b.conn()
+	return err
+}
+
+func (b *boltStore) Options() store.Options {
+	b.Lock()
+	defer b.Unlock()
+	return b.options
+}
+
+// scan walks the live records of a table matching prefix and suffix in key
+// order, applying offset and limit to the matches.
+func scan(bkt *bolt.Bucket, prefix, suffix string, limit, offset uint, fn func(k []byte, r *record) error) error {
+	now := time.Now().UnixNano()
+	p := []byte(prefix)
+	c := bkt.Cursor()
+
+	var matched uint
+	for k, v := c.Seek(p); k != nil && bytes.HasPrefix(k, p); k, v = c.Next() {
+		if !strings.HasSuffix(string(k), suffix) {
+			continue
+		}
+
+		r := &record{}
+		if err := json.Unmarshal(v, r); err != nil {
+			return err
+		}
+		if r.expired(now) {
+			continue
+		}
+
+		matched++
+		if matched <= offset {
+			continue
+		}
+		if err := fn(k, r); err != nil {
+			return err
+		}
+		if limit > 0 && matched-offset >= limit {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func toRecord(k []byte, r *record) *store.Record {
+	rec := &store.Record{
+		Key:      string(k),
+		Value:    r.Value,
+		Metadata: make(map[string]interface{}),
+	}
+	for mk, mv := range r.Metadata {
+		rec.Metadata[mk] = mv
+	}
+	if r.ExpiresAt > 0 {
+		rec.Expiry = time.Until(time.Unix(0, r.ExpiresAt))
+	}
+	return rec
+}
+
+func (b *boltStore) Read(key string, opts ...store.ReadOption) ([]*store.Record, error) {
+	var options store.ReadOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	db, err := b.conn()
+	if err != nil {
+		return nil, err
+	}
+	database, table := b.scope(options.Database, options.Table)
+
+	var recs []*store.Record
+	err = db.View(func(tx *bolt.Tx) error {
+		bkt := bucket(tx, database, table)
+		if bkt == nil {
+			return nil
+		}
+
+		if !options.Prefix && !options.Suffix {
+			v := bkt.Get([]byte(key))
+			if v == nil {
+				return nil
+			}
+			r := &record{}
+			if err := json.Unmarshal(v, r); err != nil {
+				return err
+			}
+			if !r.expired(time.Now().UnixNano()) {
+				recs = append(recs, toRecord([]byte(key), r))
+			}
+			return nil
+		}
+
+		var prefix, suffix string
+		if options.Prefix {
+			prefix = key
+		}
+		if options.Suffix {
+			suffix = key
+		}
+		return scan(bkt, prefix, suffix, options.Limit, options.Offset, func(k []byte, r *record) error {
+			recs = append(recs, toRecord(k, r))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(recs) == 0 && !options.Prefix && !options.Suffix {
+		return nil, store.ErrNotFound
+	}
+
+	return recs, nil
+}
+
+func (b *boltStore) Write(r *store.Record, opts ...store.WriteOption) error {
+	var options store.WriteOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	db, err := b.conn()
+	if err != nil {
+		return err
+	}
+	database, table := b.scope(options.Database, options.Table)
+
+	item := &record{Value: r.Value, Metadata: r.Metadata}
+	switch {
+	case options.TTL != 0:
+		item.ExpiresAt = time.Now().Add(options.TTL).UnixNano()
+	case !options.Expiry.IsZero():
+		item.ExpiresAt = options.Expiry.UnixNano()
+	case r.Expiry != 0:
+		item.ExpiresAt = time.Now().Add(r.Expiry).UnixNano()
+	}
+
+	v, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		dbBkt, err := tx.CreateBucketIfNotExists(database)
+		if err != nil {
+			return err
+		}
+		bkt, err := dbBkt.CreateBucketIfNotExists(table)
+		if err != nil {
+			return err
+		}
+		return bkt.Put([]byte(r.Key), v)
+	})
+	if err != nil {
+		return err
+	}
+
+	return b.purge(db)
+}
+
+// purge deletes expired records from every table, at most once per
+// purgeInterval. Reads skip them either way.
+func (b *boltStore) purge(db *bolt.DB) error {
+	b.Lock()
+	if time.Since(b.purged) < purgeInterval {
+		b.Unlock()
+		return nil
+	}
+	b.purged = time.Now()
+	b.Unlock()
+
+	now := time.Now().UnixNano()
+	return db.Update(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(_ []byte, dbBkt *bolt.Bucket) error {
+			return dbBkt.ForEachBucket(func(name []byte) error {
+				bkt := dbBkt.Bucket(name)
+
+				var expired [][]byte
+				if err := bkt.ForEach(func(k, v []byte) error {
+					r := &record{}
+					if err := json.Unmarshal(v, r); err != nil {
+						return err
+					}
+					if r.expired(now) {
+						expired = append(expired, k)
+					}
+					return nil
+				}); err != nil {
+					return err
+				}
+
+				for _, k := range expired {
+					if err := bkt.Delete(k); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		})
+	})
+}
+
+func (b *boltStore) Delete(key string, opts ...store.DeleteOption) error {
+	var options store.DeleteOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	db, err := b.conn()
+	if err != nil {
+		return err
+	}
+	database, table := b.scope(options.Database, options.Table)
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bkt := bucket(tx, database, table)
+		if bkt == nil {
+			return nil
+		}
+		return bkt.Delete([]byte(key))
+	})
+}
+
+func (b *boltStore) List(opts ...store.ListOption) ([]string, error) {
+	var options store.ListOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	db, err := b.conn()
+	if err != nil {
+		return nil, err
+	}
+	database, table := b.scope(options.Database, options.Table)
+
+	keys := []string{}
+	err = db.View(func(tx *bolt.Tx) error {
+		bkt := bucket(tx, database, table)
+		if bkt == nil {
+			return nil
+		}
+		return scan(bkt, options.Prefix, options.Suffix, options.Limit, options.Offset, func(k []byte, _ *record) error {
+			keys = append(keys, string(k))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (b *boltStore) Close() error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.db == nil {
+		return nil
+	}
+	err := b.db.Close()
+	b.db = nil
+	return err
+}
+
+func (b *boltStore) String() string {
+	return "bolt"
+}
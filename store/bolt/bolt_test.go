@@ -0,0 +1,43 @@
+package bolt
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"go-micro.dev/v6/store"
+	"go-micro.dev/v6/store/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		return NewStore(store.Nodes(filepath.Join(t.TempDir(), "bolt.db")))
+	})
+}
+
+func TestPurge(t *testing.T) {
+	s := NewStore(store.Nodes(filepath.Join(t.TempDir(), "bolt.db")))
+	defer s.Close()
+
+	if err := s.Write(&store.Record{Key: "gone", Expiry: -1}); err != nil {
+		t.Fatal(err)
+	}
+	bs := s.(*boltStore)
+	bs.purged = time.Time{}
+	if err := s.Write(&store.Record{Key: "kept"}); err != nil {
+		t.Fatal(err)
+	}
+
+	database, table := bs.scope("", "")
+	err := bs.db.View(func(tx *bbolt.Tx) error {
+		if v := bucket(tx, database, table).Get([]byte("gone")); v != nil {
+			t.Fatal("expired record was not purged")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,20 @@
+package store_test
+
+import (
+	"testing"
+
+	"go-micro.dev/v6/store"
+	"go-micro.dev/v6/store/storetest"
+)
+
+func TestMemoryConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		return store.NewMemoryStore()
+	})
+}
+
+func TestFileConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		return store.NewFileStore(store.DirOption(t.TempDir()))
+	})
+}
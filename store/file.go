@@ -5,8 +5,6 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
-	"sort"
-	"strings"
 	"sync"
 	"time"
 
@@ -149,7 +147,7 @@ func (m *fileStore) getDB(database, table string) (*fileHandle, error) {
 	return fd, nil
 }
 
-func (m *fileStore) list(fd *fileHandle, limit, offset uint) []string {
+func (m *fileStore) list(fd *fileHandle) []string {
 	var allItems []string
 
 	_ = fd.db.View(func(tx *bolt.Tx) error {
@@ -183,22 +181,7 @@ func (m *fileStore) list(fd *fileHandle, limit, offset uint) []string {
 		return nil
 	})
 
-	allKeys := make([]string, len(allItems))
-
-	copy(allKeys, allItems)
-
-	if limit != 0 || offset != 0 {
-		sort.Slice(allKeys, func(i, j int) bool { return allKeys[i] < allKeys[j] })
-		min := func(i, j uint) uint {
-			if i < j {
-				return i
-			}
-			return j
-		}
-		return allKeys[offset:min(limit, uint(len(allKeys)))]
-	}
-
-	return allKeys
+	return allItems
 }
 
 func (m *fileStore) get(fd *fileHandle, k string) (*Record, error) {
@@ -320,19 +303,14 @@ func (m *fileStore) Read(key string, opts ...ReadOption) ([]*Record, error) {
 	// Handle Prefix / suffix
 	// TODO: do range scan here rather than listing all keys
 	if readOpts.Prefix || readOpts.Suffix {
-		// list the keys
-		k := m.list(fd, readOpts.Limit, readOpts.Offset)
-
-		// check for prefix and suffix
-		for _, v := range k {
-			if readOpts.Prefix && !strings.HasPrefix(v, key) {
-				continue
-			}
-			if readOpts.Suffix && !strings.HasSuffix(v, key) {
-				continue
-			}
-			keys = append(keys, v)
+		var prefix, suffix string
+		if readOpts.Prefix {
+			prefix = key
+		}
+		if readOpts.Suffix {
+			suffix = key
 		}
+		keys = filterKeys(m.list(fd), prefix, suffix, readOpts.Limit, readOpts.Offset)
 	} else {
 		keys = []string{key}
 	}
@@ -403,29 +381,7 @@ func (m *fileStore) List(opts ...ListOption) ([]string, error) {
 	}
 
 	// TODO apply prefix/suffix in range query
-	allKeys := m.list(fd, listOptions.Limit, listOptions.Offset)
-
-	if len(listOptions.Prefix) > 0 {
-		var prefixKeys []string
-		for _, k := range allKeys {
-			if strings.HasPrefix(k, listOptions.Prefix) {
-				prefixKeys = append(prefixKeys, k)
-			}
-		}
-		allKeys = prefixKeys
-	}
-
-	if len(listOptions.Suffix) > 0 {
-		var suffixKeys []string
-		for _, k := range allKeys {
-			if strings.HasSuffix(k, listOptions.Suffix) {
-				suffixKeys = append(suffixKeys, k)
-			}
-		}
-		allKeys = suffixKeys
-	}
-
-	return allKeys, nil
+	return filterKeys(m.list(fd), listOptions.Prefix, listOptions.Suffix, listOptions.Limit, listOptions.Offset), nil
 }
 
 func (m *fileStore) String() string {
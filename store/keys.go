@@ -0,0 +1,29 @@
+package store
+
+import (
+	"sort"
+	"strings"
+)
+
+// filterKeys returns the keys matching prefix and suffix in sorted order,
+// with offset and limit applied to the matches. A zero limit returns every
+// match from offset on.
+func filterKeys(keys []string, prefix, suffix string, limit, offset uint) []string {
+	matched := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if !strings.HasPrefix(k, prefix) || !strings.HasSuffix(k, suffix) {
+			continue
+		}
+		matched = append(matched, k)
+	}
+	sort.Strings(matched)
+
+	if offset >= uint(len(matched)) {
+		return nil
+	}
+	matched = matched[offset:]
+	if limit > 0 && limit < uint(len(matched)) {
+		matched = matched[:limit]
+	}
+	return matched
+}
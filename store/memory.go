@@ -2,8 +2,8 @@ package store
 
 import (
 	"path/filepath"
-	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/patrickmn/go-cache"
@@ -29,6 +29,11 @@ type memoryStore struct {
 	options Options
 
 	store *cache.Cache
+
+	// mu serialises writes so CompareAndSwap can check a revision and
+	// write without another writer in between.
+	mu       sync.Mutex
+	revision uint64
 }
 
 type storeRecord struct {
@@ -36,6 +41,7 @@ type storeRecord struct {
 	metadata  map[string]interface{}
 	key       string
 	value     []byte
+	revision  uint64
 }
 
 func (m *memoryStore) key(prefix, key string) string {
@@ -71,6 +77,7 @@ func (m *memoryStore) get(prefix, key string) (*Record, error) {
 	newRecord.Key = strings.TrimPrefix(storedRecord.key, prefix+"/")
 	newRecord.Value = make([]byte, len(storedRecord.value))
 	newRecord.Metadata = make(map[string]interface{})
+	newRecord.Revision = storedRecord.revision
 
 	// copy the value into the new record
 	copy(newRecord.Value, storedRecord.value)
@@ -88,8 +95,10 @@ func (m *memoryStore) get(prefix, key string) (*Record, error) {
 	return newRecord, nil
 }
 
-func (m *memoryStore) set(prefix string, r *Record) {
+// set stores r and returns its new revision. The caller holds mu.
+func (m *memoryStore) set(prefix string, r *Record) uint64 {
 	key := m.key(prefix, r.Key)
+	m.revision++
 
 	// copy the incoming record and then
 	// convert the expiry in to a hard timestamp
@@ -97,6 +106,7 @@ func (m *memoryStore) set(prefix string, r *Record) {
 	i.key = r.Key
 	i.value = make([]byte, len(r.Value))
 	i.metadata = make(map[string]interface{})
+	i.revision = m.revision
 
 	// copy the the value
 	copy(i.value, r.Value)
@@ -112,6 +122,8 @@ func (m *memoryStore) set(prefix string, r *Record) {
 	}
 
 	m.store.Set(key, i, r.Expiry)
+
+	return i.revision
 }
 
 func (m *memoryStore) delete(prefix, key string) {
@@ -119,7 +131,7 @@ func (m *memoryStore) delete(prefix, key string) {
 	m.store.Delete(key)
 }
 
-func (m *memoryStore) list(prefix string, limit, offset uint) []string {
+func (m *memoryStore) list(prefix string) []string {
 	allItems := m.store.Items()
 	foundKeys := make([]string, 0, len(allItems))
 
@@ -130,17 +142,6 @@ func (m *memoryStore) list(prefix string, limit, offset uint) []string {
 		foundKeys = append(foundKeys, strings.TrimPrefix(k, prefix+"/"))
 	}
 
-	if limit != 0 || offset != 0 {
-		sort.Slice(foundKeys, func(i, j int) bool { return foundKeys[i] < foundKeys[j] })
-		min := func(i, j uint) uint {
-			if i < j {
-				return i
-			}
-			return j
-		}
-		return foundKeys[offset:min(offset+limit, uint(len(foundKeys)))]
-	}
-
 	return foundKeys
 }
 
@@ -166,42 +167,20 @@ func (m *memoryStore) Read(key string, opts ...ReadOption) ([]*Record, error) {
 		o(&readOpts)
 	}
 
-	prefix := m.prefix(readOpts.Database, readOpts.Table)
+	dbPrefix := m.prefix(readOpts.Database, readOpts.Table)
 
 	var keys []string
 
 	// Handle Prefix / suffix
 	if readOpts.Prefix || readOpts.Suffix {
-		k := m.list(prefix, 0, 0)
-
-		// First, filter by prefix/suffix to get all matching keys
-		var matchingKeys []string
-		for _, kk := range k {
-			if readOpts.Prefix && !strings.HasPrefix(kk, key) {
-				continue
-			}
-
-			if readOpts.Suffix && !strings.HasSuffix(kk, key) {
-				continue
-			}
-
-			matchingKeys = append(matchingKeys, kk)
+		var prefix, suffix string
+		if readOpts.Prefix {
+			prefix = key
 		}
-
-		// Then apply limit and offset to the filtered results
-		limit := int(readOpts.Limit)
-		offset := int(readOpts.Offset)
-
-		if offset > len(matchingKeys) {
-			offset = len(matchingKeys)
+		if readOpts.Suffix {
+			suffix = key
 		}
-
-		endIdx := offset + limit
-		if endIdx > len(matchingKeys) || limit == 0 {
-			endIdx = len(matchingKeys)
-		}
-
-		keys = matchingKeys[offset:endIdx]
+		keys = filterKeys(m.list(dbPrefix), prefix, suffix, readOpts.Limit, readOpts.Offset)
 	} else {
 		keys = []string{key}
 	}
@@ -209,7 +188,7 @@ func (m *memoryStore) Read(key string, opts ...ReadOption) ([]*Record, error) {
 	var results []*Record
 
 	for _, k := range keys {
-		r, err := m.get(prefix, k)
+		r, err := m.get(dbPrefix, k)
 		if err != nil {
 			return results, err
 		}
@@ -220,6 +199,16 @@ func (m *memoryStore) Read(key string, opts ...ReadOption) ([]*Record, error) {
 }
 
 func (m *memoryStore) Write(r *Record, opts ...WriteOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.write(r, opts...)
+	return nil
+}
+
+// write stores r with opts applied and returns its new revision. The
+// caller holds mu.
+func (m *memoryStore) write(r *Record, opts ...WriteOption) uint64 {
 	writeOpts := WriteOptions{}
 	for _, o := range opts {
 		o(&writeOpts)
@@ -247,13 +236,65 @@ func (m *memoryStore) Write(r *Record, opts ...WriteOption) error {
 			newRecord.Metadata[k] = v
 		}
 
-		m.set(prefix, &newRecord)
-		return nil
+		return m.set(prefix, &newRecord)
 	}
 
 	// set
-	m.set(prefix, r)
+	return m.set(prefix, r)
+}
+
+func (m *memoryStore) ReadMany(keys []string, opts ...ReadOption) ([]*Record, error) {
+	readOpts := ReadOptions{}
+	for _, o := range opts {
+		o(&readOpts)
+	}
+
+	prefix := m.prefix(readOpts.Database, readOpts.Table)
+
+	results := make([]*Record, 0, len(keys))
+	for _, k := range keys {
+		r, err := m.get(prefix, k)
+		if err == ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+func (m *memoryStore) WriteMany(recs []*Record, opts ...WriteOption) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range recs {
+		m.write(r, opts...)
+	}
+	return nil
+}
+
+func (m *memoryStore) CompareAndSwap(r *Record, opts ...WriteOption) error {
+	writeOpts := WriteOptions{}
+	for _, o := range opts {
+		o(&writeOpts)
+	}
+
+	prefix := m.prefix(writeOpts.Database, writeOpts.Table)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var current uint64
+	if stored, err := m.get(prefix, r.Key); err == nil {
+		current = stored.Revision
+	}
+	if current != r.Revision {
+		return ErrConflict
+	}
 
+	r.Revision = m.write(r, opts...)
 	return nil
 }
 
@@ -264,6 +305,10 @@ func (m *memoryStore) Delete(key string, opts ...DeleteOption) error {
 	}
 
 	prefix := m.prefix(deleteOptions.Database, deleteOptions.Table)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.delete(prefix, key)
 	return nil
 }
@@ -280,27 +325,7 @@ func (m *memoryStore) List(opts ...ListOption) ([]string, error) {
 	}
 
 	prefix := m.prefix(listOptions.Database, listOptions.Table)
-	keys := m.list(prefix, listOptions.Limit, listOptions.Offset)
-
-	if len(listOptions.Prefix) > 0 {
-		var prefixKeys []string
-		for _, k := range keys {
-			if strings.HasPrefix(k, listOptions.Prefix) {
-				prefixKeys = append(prefixKeys, k)
-			}
-		}
-		keys = prefixKeys
-	}
-
-	if len(listOptions.Suffix) > 0 {
-		var suffixKeys []string
-		for _, k := range keys {
-			if strings.HasSuffix(k, listOptions.Suffix) {
-				suffixKeys = append(suffixKeys, k)
-			}
-		}
-		keys = suffixKeys
-	}
+	keys := filterKeys(m.list(prefix), listOptions.Prefix, listOptions.Suffix, listOptions.Limit, listOptions.Offset)
 
 	return keys, nil
 }
@@ -0,0 +1,21 @@
+//go:build integration
+// +build integration
+
+package mysql
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/store"
+	"go-micro.dev/v6/store/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		// a table per case keeps them empty across runs
+		table := fmt.Sprintf("conformance_%d", time.Now().UnixNano())
+		return NewMysqlStore(store.Nodes("root:123@(127.0.0.1:3306)/test?charset=utf8&parseTime=true"), store.Table(table))
+	})
+}
@@ -3,6 +3,7 @@ package mysql
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 	"unicode"
 
@@ -18,6 +19,13 @@ var (
 	DefaultTable = "micro"
 )
 
+// Revisions are microsecond timestamps that only move forward, so a key
+// that is deleted and written again doesn't reuse the revision it had.
+const (
+	firstRevision = "CAST(UNIX_TIMESTAMP(NOW(6)) * 1000000 AS UNSIGNED)"
+	nextRevision  = "GREATEST(revision + 1, " + firstRevision + ")"
+)
+
 type sqlStore struct {
 	db *sql.DB
 
@@ -57,7 +65,7 @@ func (s *sqlStore) List(opts ...store.ListOption) ([]string, error) {
 	defer rows.Close()
 
 	var records []string
-	var cachedTime time.Time
+	var cachedTime sql.NullTime
 
 	for rows.Next() {
 		record := &store.Record{}
@@ -65,7 +73,7 @@ func (s *sqlStore) List(opts ...store.ListOption) ([]string, error) {
 			return nil, err
 		}
 
-		if cachedTime.Before(time.Now()) {
+		if cachedTime.Valid && cachedTime.Time.Before(time.Now()) {
 			// record has expired
 			go func() { _ = s.Delete(record.Key) }()
 		} else {
@@ -95,20 +103,22 @@ func (s *sqlStore) Read(key string, opts ...store.ReadOption) ([]*store.Record,
 	var records []*store.Record
 	row := s.readPrepare.QueryRow(key)
 	record := &store.Record{}
-	var cachedTime time.Time
+	var cachedTime sql.NullTime
 
-	if err := row.Scan(&record.Key, &record.Value, &cachedTime); err != nil {
+	if err := row.Scan(&record.Key, &record.Value, &cachedTime, &record.Revision); err != nil {
 		if err == sql.ErrNoRows {
 			return records, store.ErrNotFound
 		}
 		return records, err
 	}
-	if cachedTime.Before(time.Now()) {
-		// record has expired
-		go func() { _ = s.Delete(key) }()
-		return records, store.ErrNotFound
+	if cachedTime.Valid {
+		if cachedTime.Time.Before(time.Now()) {
+			// record has expired
+			go func() { _ = s.Delete(key) }()
+			return records, store.ErrNotFound
+		}
+		record.Expiry = time.Until(cachedTime.Time)
 	}
-	record.Expiry = time.Until(cachedTime)
 	records = append(records, record)
 
 	return records, nil
@@ -116,7 +126,7 @@ func (s *sqlStore) Read(key string, opts ...store.ReadOption) ([]*store.Record,
 
 // Write records.
 func (s *sqlStore) Write(r *store.Record, opts ...store.WriteOption) error {
-	timeCached := time.Now().Add(r.Expiry)
+	timeCached := expiryColumn(r)
 	_, err := s.writePrepare.Exec(r.Key, r.Value, timeCached, r.Value, timeCached)
 	if err != nil {
 		return errors.Wrap(err, "Couldn't insert record "+r.Key)
@@ -125,6 +135,128 @@ func (s *sqlStore) Write(r *store.Record, opts ...store.WriteOption) error {
 	return nil
 }
 
+// ReadMany reads the records that exist for keys in one query.
+func (s *sqlStore) ReadMany(keys []string, opts ...store.ReadOption) ([]*store.Record, error) {
+	if len(keys) == 0 {
+		return []*store.Record{}, nil
+	}
+
+	args := make([]interface{}, len(keys))
+	for i, k := range keys {
+		args[i] = k
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ")
+
+	rows, err := s.db.Query(fmt.Sprintf("SELECT `key`, value, expiry, revision FROM %s.%s WHERE `key` IN (%s);", s.database, s.table, placeholders), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[string]*store.Record, len(keys))
+	for rows.Next() {
+		record := &store.Record{}
+		var cachedTime sql.NullTime
+		if err := rows.Scan(&record.Key, &record.Value, &cachedTime, &record.Revision); err != nil {
+			return nil, err
+		}
+		if cachedTime.Valid {
+			if cachedTime.Time.Before(time.Now()) {
+				// record has expired
+				go func() { _ = s.Delete(record.Key) }()
+				continue
+			}
+			record.Expiry = time.Until(cachedTime.Time)
+		}
+		found[record.Key] = record
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// return them in the order asked for
+	records := make([]*store.Record, 0, len(found))
+	for _, k := range keys {
+		if record, ok := found[k]; ok {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
+// WriteMany writes records in one transaction.
+func (s *sqlStore) WriteMany(recs []*store.Record, opts ...store.WriteOption) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	st := tx.Stmt(s.writePrepare)
+	for _, r := range recs {
+		timeCached := expiryColumn(r)
+		if _, err := st.Exec(r.Key, r.Value, timeCached, r.Value, timeCached); err != nil {
+			return errors.Wrap(err, "Couldn't insert record "+r.Key)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CompareAndSwap writes r only if the stored revision is still r.Revision.
+// A zero revision creates the key, or replaces it if it has expired.
+func (s *sqlStore) CompareAndSwap(r *store.Record, opts ...store.WriteOption) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	timeCached := expiryColumn(r)
+	update := fmt.Sprintf("UPDATE %s.%s SET value = ?, expiry = ?, revision = %s WHERE `key` = ?", s.database, s.table, nextRevision)
+
+	var res sql.Result
+	if r.Revision == 0 {
+		res, err = tx.Exec(update+" AND expiry < NOW();", r.Value, timeCached, r.Key)
+		if err == nil {
+			if n, _ := res.RowsAffected(); n == 0 {
+				res, err = tx.Exec(fmt.Sprintf("INSERT IGNORE INTO %s.%s (`key`, value, expiry, revision) VALUES (?, ?, ?, %s);", s.database, s.table, firstRevision), r.Key, r.Value, timeCached)
+			}
+		}
+	} else {
+		res, err = tx.Exec(update+" AND revision = ? AND (expiry IS NULL OR expiry > NOW());", r.Value, timeCached, r.Key, r.Revision)
+	}
+	if err != nil {
+		return errors.Wrap(err, "Couldn't swap record "+r.Key)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return store.ErrConflict
+	}
+
+	// the row stays locked until commit, so this is the revision written
+	var revision uint64
+	if err := tx.QueryRow(fmt.Sprintf("SELECT revision FROM %s.%s WHERE `key` = ?;", s.database, s.table), r.Key).Scan(&revision); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.Revision = revision
+
+	return nil
+}
+
+// expiryColumn returns the record's expiry time, or nil if it never expires.
+func expiryColumn(r *store.Record) interface{} {
+	if r.Expiry == 0 {
+		return nil
+	}
+	return time.Now().Add(r.Expiry)
+}
+
 // Delete records with keys.
 func (s *sqlStore) Delete(key string, opts ...store.DeleteOption) error {
 	result, err := s.deletePrepare.Exec(key)
@@ -152,21 +284,38 @@ func (s *sqlStore) initDB() error {
 	}
 
 	// Create a table for the namespace's prefix
-	createSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (`key` varchar(255) primary key, value blob null, expiry timestamp not null);", s.table)
+	createSQL := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (`key` varchar(255) primary key, value blob null, expiry timestamp null, revision bigint unsigned not null default 1);", s.table)
 	_, err = s.db.Exec(createSQL)
 	if err != nil {
 		return errors.Wrap(err, "Couldn't create table")
 	}
 
+	// Tables created before records could live forever or had revisions
+	_, err = s.db.Exec(fmt.Sprintf("ALTER TABLE %s MODIFY expiry timestamp null;", s.table))
+	if err != nil {
+		return errors.Wrap(err, "Couldn't migrate expiry column")
+	}
+	var columns int
+	err = s.db.QueryRow("SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = ? AND table_name = ? AND column_name = 'revision';", s.database, s.table).Scan(&columns)
+	if err != nil {
+		return errors.Wrap(err, "Couldn't check for revision column")
+	}
+	if columns == 0 {
+		_, err = s.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN revision bigint unsigned not null default 1;", s.table))
+		if err != nil {
+			return errors.Wrap(err, "Couldn't add revision column")
+		}
+	}
+
 	// prepare statements
 	var prepareErr error
 
-	s.readPrepare, prepareErr = s.db.Prepare(fmt.Sprintf("SELECT `key`, value, expiry FROM %s.%s WHERE `key` = ?;", s.database, s.table))
+	s.readPrepare, prepareErr = s.db.Prepare(fmt.Sprintf("SELECT `key`, value, expiry, revision FROM %s.%s WHERE `key` = ?;", s.database, s.table))
 	if prepareErr != nil {
 		return errors.Wrap(prepareErr, "failed to prepare read statement")
 	}
 
-	s.writePrepare, prepareErr = s.db.Prepare(fmt.Sprintf("INSERT INTO %s.%s (`key`, value, expiry) VALUES(?, ?, ?) ON DUPLICATE KEY UPDATE `value`= ?, `expiry` = ?", s.database, s.table))
+	s.writePrepare, prepareErr = s.db.Prepare(fmt.Sprintf("INSERT INTO %s.%s (`key`, value, expiry, revision) VALUES(?, ?, ?, %s) ON DUPLICATE KEY UPDATE `value`= ?, `expiry` = ?, revision = %s", s.database, s.table, firstRevision, nextRevision))
 	if prepareErr != nil {
 		return errors.Wrap(prepareErr, "failed to prepare write statement")
 	}
@@ -0,0 +1,18 @@
+package natsjskv
+
+import (
+	"context"
+	"testing"
+
+	"go-micro.dev/v6/store"
+	"go-micro.dev/v6/store/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	// nats-js-kv only expires whole buckets, see DefaultTTL
+	storetest.Run(t, func(t *testing.T) store.Store {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		return testSetup(ctx, t)
+	}, storetest.WithoutExpiry())
+}
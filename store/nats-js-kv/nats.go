@@ -4,6 +4,7 @@ package natsjskv
 import (
 	"context"
 	"encoding/json"
+	"sort"
 	"sync"
 	"time"
 
@@ -181,7 +182,10 @@ func (n *natsStore) Read(key string, opts ...store.ReadOption) ([]*store.Record,
 
 	bucket, ok := n.buckets.Get(opt.Database)
 	if !ok {
-		return nil, ErrBucketNotFound
+		if opt.Prefix || opt.Suffix {
+			return []*store.Record{}, nil
+		}
+		return nil, store.ErrNotFound
 	}
 
 	keys, err := n.natsKeys(bucket, opt.Table, key, opt.Prefix, opt.Suffix)
@@ -245,6 +249,114 @@ func (n *natsStore) Write(rec *store.Record, opts ...store.WriteOption) error {
 	return nil
 }
 
+// ReadMany returns the records for the keys that exist, in the order given.
+func (n *natsStore) ReadMany(keys []string, opts ...store.ReadOption) ([]*store.Record, error) {
+	if err := n.initConn(); err != nil {
+		return nil, err
+	}
+
+	opt := store.ReadOptions{}
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	if opt.Database == "" {
+		opt.Database = n.opts.Database
+	}
+
+	if opt.Table == "" {
+		opt.Table = n.opts.Table
+	}
+
+	records := make([]*store.Record, 0, len(keys))
+
+	bucket, ok := n.buckets.Get(opt.Database)
+	if !ok {
+		return records, nil
+	}
+
+	for _, key := range keys {
+		rec, ok, err := n.getRecord(bucket, n.NatsKey(opt.Table, key))
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			records = append(records, rec)
+		}
+	}
+
+	return records, nil
+}
+
+// WriteMany writes every record. JetStream KV has no multi-key
+// transactions, so a failure can leave earlier records written.
+func (n *natsStore) WriteMany(recs []*store.Record, opts ...store.WriteOption) error {
+	for _, rec := range recs {
+		if err := n.Write(rec, opts...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CompareAndSwap writes rec only if the key is still at rec.Revision, using
+// the bucket's revision check so the compare happens on the server.
+func (n *natsStore) CompareAndSwap(rec *store.Record, opts ...store.WriteOption) error {
+	if err := n.initConn(); err != nil {
+		return err
+	}
+
+	opt := store.WriteOptions{}
+	for _, o := range opts {
+		o(&opt)
+	}
+
+	if opt.Database == "" {
+		opt.Database = n.opts.Database
+	}
+
+	if opt.Table == "" {
+		opt.Table = n.opts.Table
+	}
+
+	bucket, err := n.mustGetBucketByName(opt.Database)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(KeyValueEnvelope{
+		Key:      rec.Key,
+		Data:     rec.Value,
+		Metadata: rec.Metadata,
+	})
+	if err != nil {
+		return errors.Wrap(err, "Failed to marshal object")
+	}
+
+	key := n.NatsKey(opt.Table, rec.Key)
+
+	var rev uint64
+	if rec.Revision == 0 {
+		// Create also succeeds over a deleted key
+		rev, err = bucket.Create(key, b)
+	} else {
+		rev, err = bucket.Update(key, b, rec.Revision)
+	}
+	if errors.Is(err, nats.ErrKeyExists) {
+		return store.ErrConflict
+	} else if err != nil {
+		return errors.Wrapf(err, "Failed to store data in bucket '%s'", key)
+	}
+
+	rec.Revision = rev
+
+	return nil
+}
+
 // Delete removes the record with the corresponding key from the store.
 func (n *natsStore) Delete(key string, opts ...store.DeleteOption) error {
 	if err := n.initConn(); err != nil {
@@ -308,7 +420,7 @@ func (n *natsStore) List(opts ...store.ListOption) ([]string, error) {
 
 	store, ok := n.buckets.Get(opt.Database)
 	if !ok {
-		return nil, ErrBucketNotFound
+		return []string{}, nil
 	}
 
 	keys, err := n.microKeys(store, opt.Table, opt.Prefix, opt.Suffix)
@@ -410,6 +522,7 @@ func (n *natsStore) getRecord(bucket nats.KeyValue, key string) (*store.Record,
 		Key:      kv.Key,
 		Value:    kv.Data,
 		Metadata: kv.Metadata,
+		Revision: obj.Revision(),
 	}, true, nil
 }
 
@@ -439,7 +552,7 @@ func (n *natsStore) microKeys(bucket nats.KeyValue, table, prefix, suffix string
 
 func (n *natsStore) getKeys(bucket nats.KeyValue, table string, prefix, suffix string) ([]string, []string, error) {
 	names, err := bucket.Keys(nats.IgnoreDeletes())
-	if errors.Is(err, nats.ErrKeyNotFound) {
+	if errors.Is(err, nats.ErrKeyNotFound) || errors.Is(err, nats.ErrNoKeysFound) {
 		return []string{}, []string{}, nil
 	} else if err != nil {
 		return []string{}, []string{}, errors.Wrap(err, "Failed to list objects")
@@ -458,9 +571,24 @@ func (n *natsStore) getKeys(bucket nats.KeyValue, table string, prefix, suffix s
 		microKeys = append(microKeys, mkey)
 	}
 
+	// order by micro key so limit and offset page consistently
+	sort.Sort(keyPairs{natsKeys, microKeys})
+
 	return natsKeys, microKeys, nil
 }
 
+// keyPairs sorts nats keys alongside the micro keys they encode.
+type keyPairs struct {
+	nats, micro []string
+}
+
+func (k keyPairs) Len() int           { return len(k.micro) }
+func (k keyPairs) Less(i, j int) bool { return k.micro[i] < k.micro[j] }
+func (k keyPairs) Swap(i, j int) {
+	k.nats[i], k.nats[j] = k.nats[j], k.nats[i]
+	k.micro[i], k.micro[j] = k.micro[j], k.micro[i]
+}
+
 // enforces offset and limit without causing a panic.
 func enforceLimits[V any](recs []V, limit, offset uint) []V {
 	l := uint(len(recs))
@@ -0,0 +1,21 @@
+//go:build integration
+// +build integration
+
+package pgx
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/store"
+	"go-micro.dev/v6/store/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		// a table per case keeps them empty across runs
+		table := fmt.Sprintf("conformance_%d", time.Now().UnixNano())
+		return NewStore(store.Nodes("postgresql://postgres@localhost:5432/?sslmode=disable"), store.Table(table))
+	})
+}
@@ -91,11 +91,22 @@ func (s *sqlStore) db(database, table string) (*pgxpool.Pool, Queries, error) {
 func (s *sqlStore) initTable(database, table string) error {
 	db := s.databases[database].conn
 
-	_, err := db.Exec(s.options.Context, fmt.Sprintf(createTable, database, table))
+	_, err := db.Exec(s.options.Context, fmt.Sprintf(createRevisionSeq, database, table))
+	if err != nil {
+		return errors.Wrap(err, "cannot create revision sequence")
+	}
+
+	_, err = db.Exec(s.options.Context, fmt.Sprintf(createTable, database, table))
 	if err != nil {
 		return errors.Wrap(err, "cannot create table")
 	}
 
+	// tables created before revisions were tracked
+	_, err = db.Exec(s.options.Context, fmt.Sprintf(addRevision, database, table))
+	if err != nil {
+		return errors.Wrap(err, "cannot add revision column")
+	}
+
 	_, err = db.Exec(s.options.Context, fmt.Sprintf(createMDIndex, table, database, table))
 	if err != nil {
 		return errors.Wrap(err, "cannot create metadata index")
@@ -232,8 +243,8 @@ func (s *sqlStore) rowToRecord(row pgx.Row) (*store.Record, error) {
 	record := &store.Record{}
 	metadata := make(Metadata)
 
-	if err := row.Scan(&record.Key, &record.Value, &metadata, &expiry); err != nil {
-		if err == sql.ErrNoRows {
+	if err := row.Scan(&record.Key, &record.Value, &metadata, &expiry, &record.Revision); err != nil {
+		if err == sql.ErrNoRows || errors.Is(err, pgx.ErrNoRows) {
 			return record, store.ErrNotFound
 		}
 		return nil, err
@@ -257,7 +268,7 @@ func (s *sqlStore) rowsToRecords(rows pgx.Rows) ([]*store.Record, error) {
 		record := &store.Record{}
 		metadata := make(Metadata)
 
-		if err := rows.Scan(&record.Key, &record.Value, &metadata, &expiry); err != nil {
+		if err := rows.Scan(&record.Key, &record.Value, &metadata, &expiry, &record.Revision); err != nil {
 			return records, err
 		}
 
@@ -335,23 +346,127 @@ func (s *sqlStore) Write(r *store.Record, opts ...store.WriteOption) error {
 		return err
 	}
 
-	metadata := make(Metadata)
-	for k, v := range r.Metadata {
-		metadata[k] = v
+	_, err = db.Exec(s.options.Context, queries.Write, r.Key, r.Value, toMetadataColumn(r), expiryColumn(r))
+	if err != nil {
+		return errors.Wrap(err, "cannot upsert record "+r.Key)
 	}
 
-	if r.Expiry != 0 {
-		_, err = db.Exec(s.options.Context, queries.Write, r.Key, r.Value, metadata, time.Now().Add(r.Expiry))
-	} else {
-		_, err = db.Exec(s.options.Context, queries.Write, r.Key, r.Value, metadata, nil)
+	return nil
+}
+
+// ReadMany reads the given keys in one query
+func (s *sqlStore) ReadMany(keys []string, opts ...store.ReadOption) ([]*store.Record, error) {
+	options := store.ReadOptions{}
+	for _, o := range opts {
+		o(&options)
 	}
+
+	db, queries, err := s.db(options.Database, options.Table)
 	if err != nil {
-		return errors.Wrap(err, "cannot upsert record "+r.Key)
+		return nil, err
+	}
+
+	rows, err := db.Query(s.options.Context, queries.ReadKeys, keys)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records, err := s.rowsToRecords(rows)
+	if err != nil {
+		return nil, err
 	}
 
+	// return them in the order asked for
+	found := make(map[string]*store.Record, len(records))
+	for _, r := range records {
+		found[r.Key] = r
+	}
+	ordered := make([]*store.Record, 0, len(records))
+	for _, k := range keys {
+		if r, ok := found[k]; ok {
+			ordered = append(ordered, r)
+		}
+	}
+
+	return ordered, nil
+}
+
+// WriteMany writes records in one transaction
+func (s *sqlStore) WriteMany(recs []*store.Record, opts ...store.WriteOption) error {
+	var options store.WriteOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	db, queries, err := s.db(options.Database, options.Table)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin(s.options.Context)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(s.options.Context)
+
+	for _, r := range recs {
+		_, err = tx.Exec(s.options.Context, queries.Write, r.Key, r.Value, toMetadataColumn(r), expiryColumn(r))
+		if err != nil {
+			return errors.Wrap(err, "cannot upsert record "+r.Key)
+		}
+	}
+
+	return tx.Commit(s.options.Context)
+}
+
+// CompareAndSwap writes r only if the stored revision is still r.Revision
+func (s *sqlStore) CompareAndSwap(r *store.Record, opts ...store.WriteOption) error {
+	var options store.WriteOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	db, queries, err := s.db(options.Database, options.Table)
+	if err != nil {
+		return err
+	}
+
+	var row pgx.Row
+	if r.Revision == 0 {
+		row = db.QueryRow(s.options.Context, queries.CASCreate, r.Key, r.Value, toMetadataColumn(r), expiryColumn(r))
+	} else {
+		row = db.QueryRow(s.options.Context, queries.CASUpdate, r.Key, r.Value, toMetadataColumn(r), expiryColumn(r), r.Revision)
+	}
+
+	var revision uint64
+	if err := row.Scan(&revision); errors.Is(err, pgx.ErrNoRows) {
+		return store.ErrConflict
+	} else if err != nil {
+		return errors.Wrap(err, "cannot swap record "+r.Key)
+	}
+	r.Revision = revision
+
 	return nil
 }
 
+// toMetadataColumn copies the record's metadata for writing
+func toMetadataColumn(r *store.Record) Metadata {
+	metadata := make(Metadata)
+	for k, v := range r.Metadata {
+		metadata[k] = v
+	}
+	return metadata
+}
+
+// expiryColumn returns the record's expiry time, or nil if it has none
+func expiryColumn(r *store.Record) interface{} {
+	if r.Expiry == 0 {
+		return nil
+	}
+	return time.Now().Add(r.Expiry)
+}
+
 // Delete records with keys
 func (s *sqlStore) Delete(key string, opts ...store.DeleteOption) error {
 	var options store.DeleteOptions
@@ -13,9 +13,12 @@ type Queries struct {
 	ReadManyAscLimit  string
 	ReadManyDesc      string
 	ReadManyDescLimit string
+	ReadKeys          string
 
 	// change
 	Write         string
+	CASCreate     string
+	CASUpdate     string
 	Delete        string
 	DeleteExpired string
 }
@@ -31,7 +34,10 @@ func NewQueries(database, table string) Queries {
 		ReadManyAscLimit:  fmt.Sprintf(readMany, database, table) + asc + limit,
 		ReadManyDesc:      fmt.Sprintf(readMany, database, table) + desc,
 		ReadManyDescLimit: fmt.Sprintf(readMany, database, table) + desc + limit,
+		ReadKeys:          fmt.Sprintf(readKeys, database, table),
 		Write:             fmt.Sprintf(write, database, table),
+		CASCreate:         fmt.Sprintf(casCreate, database, table),
+		CASUpdate:         fmt.Sprintf(casUpdate, database, table),
 		Delete:            fmt.Sprintf(deleteRecord, database, table),
 		DeleteExpired:     fmt.Sprintf(deleteExpired, database, table),
 	}
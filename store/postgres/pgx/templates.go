@@ -3,26 +3,45 @@ package pgx
 // init
 
 const createSchema = "CREATE SCHEMA IF NOT EXISTS %s"
-const createTable = `CREATE TABLE IF NOT EXISTS %s.%s
+
+// revisions come from a sequence so a key that is deleted and written again
+// never reuses one
+const createRevisionSeq = "CREATE SEQUENCE IF NOT EXISTS %s.%s_revision"
+const createTable = `CREATE TABLE IF NOT EXISTS %[1]s.%[2]s
 (
 	key text primary key,
 	value bytea,
 	metadata JSONB,
-	expiry timestamp with time zone
+	expiry timestamp with time zone,
+	revision bigint NOT NULL DEFAULT nextval('%[1]s.%[2]s_revision')
 )`
+const addRevision = `ALTER TABLE %[1]s.%[2]s ADD COLUMN IF NOT EXISTS revision bigint NOT NULL DEFAULT nextval('%[1]s.%[2]s_revision')`
 const createMDIndex = `create index if not exists idx_md_%s ON %s.%s USING GIN (metadata)`
 const createExpiryIndex = `create index if not exists idx_expiry_%s on %s.%s (expiry) where (expiry IS NOT NULL)`
 
 // base queries
 const (
-	list     = "SELECT key FROM %s.%s WHERE key LIKE $1 and (expiry < now() or expiry isnull)"
-	readOne  = "SELECT key, value, metadata, expiry FROM %s.%s WHERE key = $1 and (expiry < now() or expiry isnull)"
-	readMany = "SELECT key, value, metadata, expiry FROM %s.%s WHERE key LIKE $1 and (expiry < now() or expiry isnull)"
-	write    = `INSERT INTO %s.%s(key, value, metadata, expiry)
+	list     = "SELECT key FROM %s.%s WHERE key LIKE $1 and (expiry > now() or expiry isnull)"
+	readOne  = "SELECT key, value, metadata, expiry, revision FROM %s.%s WHERE key = $1 and (expiry > now() or expiry isnull)"
+	readMany = "SELECT key, value, metadata, expiry, revision FROM %s.%s WHERE key LIKE $1 and (expiry > now() or expiry isnull)"
+	readKeys = "SELECT key, value, metadata, expiry, revision FROM %s.%s WHERE key = ANY($1) and (expiry > now() or expiry isnull)"
+	write    = `INSERT INTO %[1]s.%[2]s AS t (key, value, metadata, expiry)
+VALUES ($1, $2::bytea, $3, $4)
+ON CONFLICT (key)
+DO UPDATE
+SET value = EXCLUDED.value, metadata = EXCLUDED.metadata, expiry = EXCLUDED.expiry, revision = nextval('%[1]s.%[2]s_revision')`
+	// casCreate inserts the key, replacing it only if it has expired
+	casCreate = `INSERT INTO %[1]s.%[2]s AS t (key, value, metadata, expiry)
 VALUES ($1, $2::bytea, $3, $4)
 ON CONFLICT (key)
 DO UPDATE
-SET value = EXCLUDED.value, metadata = EXCLUDED.metadata, expiry = EXCLUDED.expiry`
+SET value = EXCLUDED.value, metadata = EXCLUDED.metadata, expiry = EXCLUDED.expiry, revision = nextval('%[1]s.%[2]s_revision')
+WHERE t.expiry < now()
+RETURNING revision`
+	casUpdate = `UPDATE %[1]s.%[2]s
+SET value = $2::bytea, metadata = $3, expiry = $4, revision = nextval('%[1]s.%[2]s_revision')
+WHERE key = $1 and revision = $5 and (expiry > now() or expiry isnull)
+RETURNING revision`
 	deleteRecord  = "DELETE FROM %s.%s WHERE key = $1"
 	deleteExpired = "DELETE FROM %s.%s WHERE expiry < now()"
 )
@@ -48,11 +48,14 @@ var (
 
 	// the sql statements we prepare and use
 	statements = map[string]string{
-		"list":          "SELECT key, value, metadata, expiry FROM %s.%s WHERE key LIKE $1 ORDER BY key ASC LIMIT $2 OFFSET $3;",
-		"read":          "SELECT key, value, metadata, expiry FROM %s.%s WHERE key = $1;",
-		"readMany":      "SELECT key, value, metadata, expiry FROM %s.%s WHERE key LIKE $1 ORDER BY key ASC;",
-		"readOffset":    "SELECT key, value, metadata, expiry FROM %s.%s WHERE key LIKE $1 ORDER BY key ASC LIMIT $2 OFFSET $3;",
-		"write":         "INSERT INTO %s.%s(key, value, metadata, expiry) VALUES ($1, $2::bytea, $3, $4) ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, metadata = EXCLUDED.metadata, expiry = EXCLUDED.expiry;",
+		"list":          "SELECT key, value, metadata, expiry, revision FROM %s.%s WHERE key LIKE $1 ORDER BY key ASC LIMIT $2 OFFSET $3;",
+		"read":          "SELECT key, value, metadata, expiry, revision FROM %s.%s WHERE key = $1;",
+		"readMany":      "SELECT key, value, metadata, expiry, revision FROM %s.%s WHERE key LIKE $1 ORDER BY key ASC;",
+		"readOffset":    "SELECT key, value, metadata, expiry, revision FROM %s.%s WHERE key LIKE $1 ORDER BY key ASC LIMIT $2 OFFSET $3;",
+		"readKeys":      "SELECT key, value, metadata, expiry, revision FROM %s.%s WHERE key = ANY($1);",
+		"write":         "INSERT INTO %[1]s.%[2]s AS t (key, value, metadata, expiry) VALUES ($1, $2::bytea, $3, $4) ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, metadata = EXCLUDED.metadata, expiry = EXCLUDED.expiry, revision = nextval('%[1]s.%[2]s_revision');",
+		"casCreate":     "INSERT INTO %[1]s.%[2]s AS t (key, value, metadata, expiry) VALUES ($1, $2::bytea, $3, $4) ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, metadata = EXCLUDED.metadata, expiry = EXCLUDED.expiry, revision = nextval('%[1]s.%[2]s_revision') WHERE t.expiry < now() RETURNING revision;",
+		"casUpdate":     "UPDATE %[1]s.%[2]s SET value = $2::bytea, metadata = $3, expiry = $4, revision = nextval('%[1]s.%[2]s_revision') WHERE key = $1 AND revision = $5 AND (expiry IS NULL OR expiry > now()) RETURNING revision;",
 		"delete":        "DELETE FROM %s.%s WHERE key = $1;",
 		"deleteExpired": "DELETE FROM %s.%s WHERE expiry < now();",
 		"showTables":    "SELECT schemaname, tablename FROM pg_catalog.pg_tables WHERE schemaname != 'pg_catalog' AND schemaname != 'information_schema';",
@@ -180,19 +183,33 @@ func (s *sqlStore) initDB(database, table string) error {
 		}
 	}
 
+	// Revisions come from a sequence so a key that is deleted and written
+	// again never reuses one
+	_, err = db.Exec(fmt.Sprintf("CREATE SEQUENCE IF NOT EXISTS %s.%s_revision;", database, table))
+	if err != nil {
+		return errors.Wrap(err, "Couldn't create revision sequence")
+	}
+
 	// Create a table for the namespace's prefix
-	_, err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s.%s
+	_, err = db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s.%[2]s
 	(
 		key text NOT NULL,
 		value bytea,
 		metadata JSONB,
 		expiry timestamp with time zone,
-		CONSTRAINT %s_pkey PRIMARY KEY (key)
-	);`, database, table, table))
+		revision bigint NOT NULL DEFAULT nextval('%[1]s.%[2]s_revision'),
+		CONSTRAINT %[2]s_pkey PRIMARY KEY (key)
+	);`, database, table))
 	if err != nil {
 		return errors.Wrap(err, "Couldn't create table")
 	}
 
+	// Tables created before revisions were tracked
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %[1]s.%[2]s ADD COLUMN IF NOT EXISTS revision bigint NOT NULL DEFAULT nextval('%[1]s.%[2]s_revision');", database, table))
+	if err != nil {
+		return errors.Wrap(err, "Couldn't add revision column")
+	}
+
 	// Create Index
 	_, err = db.Exec(fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "%s" ON %s.%s USING btree ("key");`, "key_index_"+table, database, table))
 	if err != nil {
@@ -354,7 +371,7 @@ func (s *sqlStore) rowToRecord(row *sql.Row) (*store.Record, error) {
 	record := &store.Record{}
 	metadata := make(Metadata)
 
-	if err := row.Scan(&record.Key, &record.Value, &metadata, &timehelper); err != nil {
+	if err := row.Scan(&record.Key, &record.Value, &metadata, &timehelper, &record.Revision); err != nil {
 		if err == sql.ErrNoRows {
 			return record, store.ErrNotFound
 		}
@@ -384,7 +401,7 @@ func (s *sqlStore) rowsToRecords(rows *sql.Rows) ([]*store.Record, error) {
 		record := &store.Record{}
 		metadata := make(Metadata)
 
-		if err := rows.Scan(&record.Key, &record.Value, &metadata, &timehelper); err != nil {
+		if err := rows.Scan(&record.Key, &record.Value, &metadata, &timehelper, &record.Revision); err != nil {
 			return records, err
 		}
 
@@ -511,29 +528,151 @@ func (s *sqlStore) Write(r *store.Record, opts ...store.WriteOption) error {
 	}
 	defer st.Close()
 
-	metadata := make(Metadata)
-	for k, v := range r.Metadata {
-		metadata[k] = v
+	_, err = st.Exec(r.Key, r.Value, toMetadataColumn(r), expiryColumn(r))
+	if err != nil {
+		return errors.Wrap(err, "Couldn't insert record "+r.Key)
 	}
 
-	var expiry time.Time
-	if r.Expiry != 0 {
-		expiry = time.Now().Add(r.Expiry)
+	return nil
+}
+
+// ReadMany reads the given keys in one query
+func (s *sqlStore) ReadMany(keys []string, opts ...store.ReadOption) ([]*store.Record, error) {
+	options := store.ReadOptions{}
+	for _, o := range opts {
+		o(&options)
 	}
 
-	if expiry.IsZero() {
-		_, err = st.Exec(r.Key, r.Value, metadata, nil)
-	} else {
-		_, err = st.Exec(r.Key, r.Value, metadata, expiry)
+	// create the db if not exists
+	if err := s.createDB(options.Database, options.Table); err != nil {
+		return nil, err
 	}
 
+	st, err := s.prepare(options.Database, options.Table, "readKeys")
 	if err != nil {
-		return errors.Wrap(err, "Couldn't insert record "+r.Key)
+		return nil, err
+	}
+	defer st.Close()
+
+	rows, err := st.Query(pq.Array(keys))
+	if err != nil {
+		return nil, errors.Wrap(err, "sqlStore.ReadMany failed")
+	}
+	defer rows.Close()
+
+	records, err := s.rowsToRecords(rows)
+	if err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// return them in the order asked for
+	found := make(map[string]*store.Record, len(records))
+	for _, r := range records {
+		found[r.Key] = r
+	}
+	ordered := make([]*store.Record, 0, len(records))
+	for _, k := range keys {
+		if r, ok := found[k]; ok {
+			ordered = append(ordered, r)
+		}
+	}
+
+	return ordered, nil
+}
+
+// WriteMany writes records in one transaction
+func (s *sqlStore) WriteMany(recs []*store.Record, opts ...store.WriteOption) error {
+	var options store.WriteOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	// create the db if not exists
+	if err := s.createDB(options.Database, options.Table); err != nil {
+		return err
+	}
+
+	st, err := s.prepare(options.Database, options.Table, "write")
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	db, err := s.db()
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	txst := tx.Stmt(st)
+	for _, r := range recs {
+		if _, err := txst.Exec(r.Key, r.Value, toMetadataColumn(r), expiryColumn(r)); err != nil {
+			return errors.Wrap(err, "Couldn't insert record "+r.Key)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CompareAndSwap writes r only if the stored revision is still r.Revision.
+// A zero revision inserts the key, replacing it only if it has expired.
+func (s *sqlStore) CompareAndSwap(r *store.Record, opts ...store.WriteOption) error {
+	var options store.WriteOptions
+	for _, o := range opts {
+		o(&options)
 	}
 
+	// create the db if not exists
+	if err := s.createDB(options.Database, options.Table); err != nil {
+		return err
+	}
+
+	query, args := "casCreate", []interface{}{r.Key, r.Value, toMetadataColumn(r), expiryColumn(r)}
+	if r.Revision != 0 {
+		query, args = "casUpdate", append(args, r.Revision)
+	}
+
+	st, err := s.prepare(options.Database, options.Table, query)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	var revision uint64
+	if err := st.QueryRow(args...).Scan(&revision); err == sql.ErrNoRows {
+		return store.ErrConflict
+	} else if err != nil {
+		return errors.Wrap(err, "Couldn't swap record "+r.Key)
+	}
+	r.Revision = revision
+
 	return nil
 }
 
+// toMetadataColumn copies the record's metadata for writing
+func toMetadataColumn(r *store.Record) Metadata {
+	metadata := make(Metadata)
+	for k, v := range r.Metadata {
+		metadata[k] = v
+	}
+	return metadata
+}
+
+// expiryColumn returns the record's expiry time, or nil if it has none
+func expiryColumn(r *store.Record) interface{} {
+	if r.Expiry == 0 {
+		return nil
+	}
+	return time.Now().Add(r.Expiry)
+}
+
 // Delete records with keys
 func (s *sqlStore) Delete(key string, opts ...store.DeleteOption) error {
 	var options store.DeleteOptions
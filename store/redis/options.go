@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"context"
+
+	rclient "github.com/go-redis/redis/v8"
+	"go-micro.dev/v6/store"
+)
+
+type redisOptionsContextKey struct{}
+
+// WithRedisOptions sets advanced options for redis, such as a cluster or
+// sentinel setup. Nodes are used as the addresses if none are set.
+func WithRedisOptions(options rclient.UniversalOptions) store.Option {
+	return func(o *store.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+
+		o.Context = context.WithValue(o.Context, redisOptionsContextKey{}, options)
+	}
+}
+
+func newUniversalClient(options store.Options) rclient.UniversalClient {
+	if options.Context == nil {
+		options.Context = context.Background()
+	}
+
+	opts, ok := options.Context.Value(redisOptionsContextKey{}).(rclient.UniversalOptions)
+	if !ok {
+		if len(options.Nodes) > 1 {
+			return rclient.NewUniversalClient(&rclient.UniversalOptions{Addrs: options.Nodes})
+		}
+
+		addr := "redis://127.0.0.1:6379"
+		if len(options.Nodes) > 0 {
+			addr = options.Nodes[0]
+		}
+
+		redisOptions, err := rclient.ParseURL(addr)
+		if err != nil {
+			redisOptions = &rclient.Options{Addr: addr}
+		}
+
+		return rclient.NewClient(redisOptions)
+	}
+
+	if len(opts.Addrs) == 0 && len(options.Nodes) > 0 {
+		opts.Addrs = options.Nodes
+	}
+
+	return rclient.NewUniversalClient(&opts)
+}
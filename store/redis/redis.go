@@ -0,0 +1,303 @@
+// Package redis is a store backed by redis. Records are kept under
+// "<database>:<table>:<key>" and expire with redis' own key expiry.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	rclient "github.com/go-redis/redis/v8"
+	"go-micro.dev/v6/store"
+)
+
+// NewStore returns a redis store. The first node is the redis URL or
+// address, and several nodes connect to a cluster.
+func NewStore(opts ...store.Option) store.Store {
+	options := store.Options{
+		Database: store.DefaultDatabase,
+		Table:    store.DefaultTable,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return &redisStore{
+		options: options,
+		client:  newUniversalClient(options),
+	}
+}
+
+type redisStore struct {
+	sync.RWMutex
+	options store.Options
+	client  rclient.UniversalClient
+}
+
+// value is what is stored under each key.
+type value struct {
+	Value    []byte                 `json:"value"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+func (r *redisStore) conn() rclient.UniversalClient {
+	r.RLock()
+	defer r.RUnlock()
+	return r.client
+}
+
+func (r *redisStore) prefix(database, table string) string {
+	r.RLock()
+	defer r.RUnlock()
+
+	if len(database) == 0 {
+		database = r.options.Database
+	}
+	if len(table) == 0 {
+		table = r.options.Table
+	}
+	return database + ":" + table + ":"
+}
+
+func (r *redisStore) Init(opts ...store.Option) error {
+	r.Lock()
+	defer r.Unlock()
+
+	for _, o := range opts {
+		o(&r.options)
+	}
+
+	if r.client != nil {
+		r.client.Close()
+	}
+	r.client = newUniversalClient(r.options)
+
+	return r.client.Ping(context.Background()).Err()
+}
+
+func (r *redisStore) Options() store.Options {
+	r.RLock()
+	defer r.RUnlock()
+	return r.options
+}
+
+func (r *redisStore) Read(key string, opts ...store.ReadOption) ([]*store.Record, error) {
+	var options store.ReadOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	ctx := context.Background()
+	prefix := r.prefix(options.Database, options.Table)
+
+	if !options.Prefix && !options.Suffix {
+		recs, err := r.get(ctx, prefix, []string{key})
+		if err != nil {
+			return nil, err
+		}
+		if len(recs) == 0 {
+			return nil, store.ErrNotFound
+		}
+		return recs, nil
+	}
+
+	var match, suffix string
+	if options.Prefix {
+		match = key
+	}
+	if options.Suffix {
+		suffix = key
+	}
+
+	keys, err := r.keys(ctx, prefix, match, suffix, options.Limit, options.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.get(ctx, prefix, keys)
+}
+
+// get reads keys in one round trip, skipping any that no longer exist.
+func (r *redisStore) get(ctx context.Context, prefix string, keys []string) ([]*store.Record, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	pipe := r.conn().Pipeline()
+	gets := make([]*rclient.StringCmd, len(keys))
+	ttls := make([]*rclient.DurationCmd, len(keys))
+	for i, k := range keys {
+		gets[i] = pipe.Get(ctx, prefix+k)
+		ttls[i] = pipe.PTTL(ctx, prefix+k)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != rclient.Nil {
+		return nil, err
+	}
+
+	recs := make([]*store.Record, 0, len(keys))
+	for i, k := range keys {
+		b, err := gets[i].Bytes()
+		if err == rclient.Nil {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		var v value
+		if err := json.Unmarshal(b, &v); err != nil {
+			return nil, err
+		}
+
+		rec := &store.Record{
+			Key:      k,
+			Value:    v.Value,
+			Metadata: v.Metadata,
+		}
+		if rec.Metadata == nil {
+			rec.Metadata = make(map[string]interface{})
+		}
+		// PTTL is negative for keys with no expiry
+		if ttl := ttls[i].Val(); ttl > 0 {
+			rec.Expiry = ttl
+		}
+		recs = append(recs, rec)
+	}
+
+	return recs, nil
+}
+
+func (r *redisStore) Write(rec *store.Record, opts ...store.WriteOption) error {
+	var options store.WriteOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	ctx := context.Background()
+	key := r.prefix(options.Database, options.Table) + rec.Key
+
+	expiry := rec.Expiry
+	if !options.Expiry.IsZero() {
+		expiry = time.Until(options.Expiry)
+	}
+	if options.TTL != 0 {
+		expiry = options.TTL
+	}
+	// already expired; redis would read a negative expiration as KEEPTTL
+	if expiry < 0 {
+		return r.conn().Del(ctx, key).Err()
+	}
+
+	b, err := json.Marshal(value{Value: rec.Value, Metadata: rec.Metadata})
+	if err != nil {
+		return err
+	}
+
+	return r.conn().Set(ctx, key, b, expiry).Err()
+}
+
+func (r *redisStore) Delete(key string, opts ...store.DeleteOption) error {
+	var options store.DeleteOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return r.conn().Del(context.Background(), r.prefix(options.Database, options.Table)+key).Err()
+}
+
+func (r *redisStore) List(opts ...store.ListOption) ([]string, error) {
+	var options store.ListOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	prefix := r.prefix(options.Database, options.Table)
+
+	return r.keys(context.Background(), prefix, options.Prefix, options.Suffix, options.Limit, options.Offset)
+}
+
+// keys scans for the keys of a table matching prefix and suffix, and
+// returns them sorted with offset and limit applied.
+func (r *redisStore) keys(ctx context.Context, table, prefix, suffix string, limit, offset uint) ([]string, error) {
+	match := escape(table+prefix) + "*"
+
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	scan := func(ctx context.Context, c rclient.Cmdable) error {
+		iter := c.Scan(ctx, 0, match, 100).Iterator()
+		for iter.Next(ctx) {
+			k := strings.TrimPrefix(iter.Val(), table)
+			if !strings.HasSuffix(k, suffix) {
+				continue
+			}
+			mu.Lock()
+			keys = append(keys, k)
+			mu.Unlock()
+		}
+		return iter.Err()
+	}
+
+	var err error
+	switch c := r.conn().(type) {
+	case *rclient.ClusterClient:
+		err = c.ForEachMaster(ctx, func(ctx context.Context, c *rclient.Client) error {
+			return scan(ctx, c)
+		})
+	default:
+		err = scan(ctx, c)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(keys)
+	// SCAN may return a key more than once
+	keys = dedupe(keys)
+
+	if offset >= uint(len(keys)) {
+		return []string{}, nil
+	}
+	keys = keys[offset:]
+	if limit > 0 && limit < uint(len(keys)) {
+		keys = keys[:limit]
+	}
+
+	return keys, nil
+}
+
+func (r *redisStore) Close() error {
+	return r.conn().Close()
+}
+
+func (r *redisStore) String() string {
+	return "redis"
+}
+
+// escape quotes the glob characters SCAN MATCH treats specially.
+func escape(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// dedupe removes adjacent duplicates from sorted keys.
+func dedupe(keys []string) []string {
+	out := keys[:0]
+	for _, k := range keys {
+		if len(out) > 0 && k == out[len(out)-1] {
+			continue
+		}
+		out = append(out, k)
+	}
+	return out
+}
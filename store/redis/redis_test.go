@@ -0,0 +1,38 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"go-micro.dev/v6/store"
+	"go-micro.dev/v6/store/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	m := miniredis.RunT(t)
+
+	storetest.Run(t, func(t *testing.T) store.Store {
+		m.FlushAll()
+		return NewStore(store.Nodes(m.Addr()))
+	}, storetest.Advance(m.FastForward))
+}
+
+func TestEscape(t *testing.T) {
+	m := miniredis.RunT(t)
+	s := NewStore(store.Nodes(m.Addr()))
+	defer s.Close()
+
+	for _, k := range []string{"a*", "a?", "a[b]", "ab"} {
+		if err := s.Write(&store.Record{Key: k}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keys, err := s.List(store.ListPrefix("a*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "a*" {
+		t.Fatalf("list a* = %v, want [a*]", keys)
+	}
+}
@@ -39,3 +39,18 @@ func (s *scopedStore) Delete(key string, opts ...DeleteOption) error {
 func (s *scopedStore) List(opts ...ListOption) ([]string, error) {
 	return s.Store.List(append([]ListOption{ListFrom(s.database, s.table)}, opts...)...)
 }
+
+// ReadMany, WriteMany and CompareAndSwap forward to the underlying store's
+// optional capabilities; CompareAndSwap returns ErrNotSupported if it has
+// none.
+func (s *scopedStore) ReadMany(keys []string, opts ...ReadOption) ([]*Record, error) {
+	return ReadMany(s.Store, keys, append([]ReadOption{ReadFrom(s.database, s.table)}, opts...)...)
+}
+
+func (s *scopedStore) WriteMany(recs []*Record, opts ...WriteOption) error {
+	return WriteMany(s.Store, recs, append([]WriteOption{WriteTo(s.database, s.table)}, opts...)...)
+}
+
+func (s *scopedStore) CompareAndSwap(r *Record, opts ...WriteOption) error {
+	return CompareAndSwap(s.Store, r, append([]WriteOption{WriteTo(s.database, s.table)}, opts...)...)
+}
@@ -0,0 +1,355 @@
+// Package sqlite is a store backed by a SQLite database file, for
+// single-binary deployments that want durable, transactional storage
+// without running a database server.
+//
+// Every database and table share one SQL table, keyed by
+// (database, table, key). The first node is the database path.
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"go-micro.dev/v6/store"
+)
+
+var (
+	// DefaultPath is the database file used when no node is set.
+	DefaultPath = filepath.Join(store.DefaultDir, "store.db")
+	// DefaultTable is the SQL table records are kept in.
+	DefaultTable = "micro_store"
+	// purgeInterval is how often expired rows are deleted.
+	purgeInterval = time.Minute
+)
+
+// NewStore returns a sqlite store. The database is opened on Init or on
+// first use.
+func NewStore(opts ...store.Option) store.Store {
+	options := store.Options{
+		Database: store.DefaultDatabase,
+		Table:    store.DefaultTable,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return &sqliteStore{options: options}
+}
+
+type sqliteStore struct {
+	sync.Mutex
+	options store.Options
+	db      *sql.DB
+	purged  time.Time
+}
+
+func (s *sqliteStore) path() string {
+	if len(s.options.Nodes) > 0 && len(s.options.Nodes[0]) > 0 {
+		return s.options.Nodes[0]
+	}
+	return DefaultPath
+}
+
+// conn returns the open database, opening it if needed.
+func (s *sqliteStore) conn() (*sql.DB, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.db != nil {
+		return s.db, nil
+	}
+
+	path := s.path()
+	if !strings.HasPrefix(path, "file:") && path != ":memory:" {
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	dsn := path
+	if strings.Contains(dsn, "?") {
+		dsn += "&_txlock=immediate&_busy_timeout=5000"
+	} else {
+		dsn += "?_txlock=immediate&_busy_timeout=5000"
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open %q: %w", path, err)
+	}
+	// SQLite has one writer; a single connection queues them in process
+	db.SetMaxOpenConns(1)
+
+	stmts := []string{
+		"PRAGMA journal_mode=WAL",
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			db TEXT NOT NULL,
+			tbl TEXT NOT NULL,
+			key TEXT NOT NULL,
+			value BLOB,
+			metadata TEXT,
+			expiry INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (db, tbl, key)
+		)`, DefaultTable),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s_expiry ON %s (expiry) WHERE expiry > 0", DefaultTable, DefaultTable),
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("open %q: %w", path, err)
+		}
+	}
+
+	s.db = db
+	return db, nil
+}
+
+func (s *sqliteStore) scope(database, table string) (string, string) {
+	s.Lock()
+	defer s.Unlock()
+
+	if len(database) == 0 {
+		database = s.options.Database
+	}
+	if len(table) == 0 {
+		table = s.options.Table
+	}
+	return database, table
+}
+
+func (s *sqliteStore) Init(opts ...store.Option) error {
+	s.Lock()
+	for _, o := range opts {
+		o(&s.options)
+	}
+	// reopen in case the path changed
+	if s.db != nil {
+		s.db.Close()
+		s.db = nil
+	}
+	s.Unlock()
+
+	_, err := s.conn()
+	return err
+}
+
+func (s *sqliteStore) Options() store.Options {
+	s.Lock()
+	defer s.Unlock()
+	return s.options
+}
+
+// query selects the live records of a table matching prefix and suffix,
+// ordered by key.
+func (s *sqliteStore) query(db *sql.DB, columns, database, table, prefix, suffix string, limit, offset uint) (*sql.Rows, error) {
+	// substr rather than LIKE, which ignores case and treats % and _ as
+	// wildcards
+	q := fmt.Sprintf(`SELECT %s FROM %s
+		WHERE db = ? AND tbl = ? AND (expiry = 0 OR expiry > ?)
+		AND substr(key, 1, ?) = ?
+		AND (? = '' OR substr(key, -?) = ?)
+		ORDER BY key LIMIT ? OFFSET ?`, columns, DefaultTable)
+
+	l := int64(-1)
+	if limit > 0 {
+		l = int64(limit)
+	}
+	n := utf8.RuneCountInString(suffix)
+
+	return db.Query(q, database, table, time.Now().UnixNano(),
+		utf8.RuneCountInString(prefix), prefix,
+		suffix, n, suffix,
+		l, offset)
+}
+
+func (s *sqliteStore) Read(key string, opts ...store.ReadOption) ([]*store.Record, error) {
+	var options store.ReadOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	db, err := s.conn()
+	if err != nil {
+		return nil, err
+	}
+	database, table := s.scope(options.Database, options.Table)
+
+	var rows *sql.Rows
+	if options.Prefix || options.Suffix {
+		var prefix, suffix string
+		if options.Prefix {
+			prefix = key
+		}
+		if options.Suffix {
+			suffix = key
+		}
+		rows, err = s.query(db, "key, value, metadata, expiry", database, table, prefix, suffix, options.Limit, options.Offset)
+	} else {
+		rows, err = db.Query(fmt.Sprintf(`SELECT key, value, metadata, expiry FROM %s
+			WHERE db = ? AND tbl = ? AND key = ? AND (expiry = 0 OR expiry > ?)`, DefaultTable),
+			database, table, key, time.Now().UnixNano())
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recs []*store.Record
+	for rows.Next() {
+		var (
+			rec      = &store.Record{Metadata: make(map[string]interface{})}
+			metadata sql.NullString
+			expiry   int64
+		)
+		if err := rows.Scan(&rec.Key, &rec.Value, &metadata, &expiry); err != nil {
+			return nil, err
+		}
+		if metadata.Valid && len(metadata.String) > 0 {
+			if err := json.Unmarshal([]byte(metadata.String), &rec.Metadata); err != nil {
+				return nil, err
+			}
+		}
+		if expiry > 0 {
+			rec.Expiry = time.Until(time.Unix(0, expiry))
+		}
+		recs = append(recs, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(recs) == 0 && !options.Prefix && !options.Suffix {
+		return nil, store.ErrNotFound
+	}
+
+	return recs, nil
+}
+
+func (s *sqliteStore) Write(r *store.Record, opts ...store.WriteOption) error {
+	var options store.WriteOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	db, err := s.conn()
+	if err != nil {
+		return err
+	}
+	database, table := s.scope(options.Database, options.Table)
+
+	var expiry int64
+	switch {
+	case options.TTL != 0:
+		expiry = time.Now().Add(options.TTL).UnixNano()
+	case !options.Expiry.IsZero():
+		expiry = options.Expiry.UnixNano()
+	case r.Expiry != 0:
+		expiry = time.Now().Add(r.Expiry).UnixNano()
+	}
+
+	var metadata []byte
+	if len(r.Metadata) > 0 {
+		if metadata, err = json.Marshal(r.Metadata); err != nil {
+			return err
+		}
+	}
+
+	_, err = db.Exec(fmt.Sprintf(`INSERT INTO %s (db, tbl, key, value, metadata, expiry)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (db, tbl, key) DO UPDATE SET
+		value = excluded.value, metadata = excluded.metadata, expiry = excluded.expiry`, DefaultTable),
+		database, table, r.Key, r.Value, string(metadata), expiry)
+	if err != nil {
+		return err
+	}
+
+	s.purge(db)
+	return nil
+}
+
+// purge deletes expired rows, at most once per purgeInterval. Reads skip
+// them either way.
+func (s *sqliteStore) purge(db *sql.DB) {
+	s.Lock()
+	if time.Since(s.purged) < purgeInterval {
+		s.Unlock()
+		return
+	}
+	s.purged = time.Now()
+	s.Unlock()
+
+	_, _ = db.Exec(fmt.Sprintf("DELETE FROM %s WHERE expiry > 0 AND expiry <= ?", DefaultTable), time.Now().UnixNano())
+}
+
+func (s *sqliteStore) Delete(key string, opts ...store.DeleteOption) error {
+	var options store.DeleteOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	db, err := s.conn()
+	if err != nil {
+		return err
+	}
+	database, table := s.scope(options.Database, options.Table)
+
+	_, err = db.Exec(fmt.Sprintf("DELETE FROM %s WHERE db = ? AND tbl = ? AND key = ?", DefaultTable), database, table, key)
+	return err
+}
+
+func (s *sqliteStore) List(opts ...store.ListOption) ([]string, error) {
+	var options store.ListOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	db, err := s.conn()
+	if err != nil {
+		return nil, err
+	}
+	database, table := s.scope(options.Database, options.Table)
+
+	rows, err := s.query(db, "key", database, table, options.Prefix, options.Suffix, options.Limit, options.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+func (s *sqliteStore) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.db == nil {
+		return nil
+	}
+	err := s.db.Close()
+	s.db = nil
+	return err
+}
+
+func (s *sqliteStore) String() string {
+	return "sqlite"
+}
@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go-micro.dev/v6/store"
+	"go-micro.dev/v6/store/storetest"
+)
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		return NewStore(store.Nodes(filepath.Join(t.TempDir(), "store.db")))
+	})
+}
+
+func TestLiteralKeys(t *testing.T) {
+	s := NewStore(store.Nodes(filepath.Join(t.TempDir(), "store.db")))
+	defer s.Close()
+
+	for _, k := range []string{"a%", "a_", "A1", "a1"} {
+		if err := s.Write(&store.Record{Key: k}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// LIKE would match a_ and a1 for "a_", and A1 for "a"
+	keys, err := s.List(store.ListPrefix("a_"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0] != "a_" {
+		t.Fatalf("list a_ = %v, want [a_]", keys)
+	}
+	keys, err = s.List(store.ListPrefix("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 3 {
+		t.Fatalf("list a = %v, want 3 keys", keys)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+
+	s := NewStore(store.Nodes(path))
+	if err := s.Write(&store.Record{Key: "foo", Value: []byte("bar")}); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s = NewStore(store.Nodes(path))
+	defer s.Close()
+	recs, err := s.Read("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(recs[0].Value) != "bar" {
+		t.Fatalf("read %q after reopening, want bar", recs[0].Value)
+	}
+}
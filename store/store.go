@@ -46,6 +46,10 @@ type Record struct {
 	Value []byte `json:"value"`
 	// Time to expire a record: TODO: change to timestamp
 	Expiry time.Duration `json:"expiry,omitempty"`
+	// Revision is set on read by stores that implement CompareAndSwapper,
+	// and changes every time the record is written. Zero means the record
+	// does not exist yet.
+	Revision uint64 `json:"revision,omitempty"`
 }
 
 func NewStore(opts ...Option) Store {
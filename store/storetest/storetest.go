@@ -0,0 +1,561 @@
+// Package storetest provides a conformance suite for store.Store
+// implementations. Run it from a test in the implementation's package:
+//
+//	func TestConformance(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) store.Store {
+//			return mystore.NewStore(store.Nodes(addr))
+//		})
+//	}
+package storetest
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/store"
+)
+
+// Options configure a conformance run.
+type Options struct {
+	// TTL is the expiry given to records in the expiry case. Defaults to
+	// one second, the coarsest granularity any backend is expected to keep.
+	TTL time.Duration
+	// Advance lets time pass in the expiry case. Defaults to time.Sleep.
+	Advance func(time.Duration)
+	// Expiry runs the per-record expiry case. Defaults to true.
+	Expiry bool
+}
+
+type Option func(*Options)
+
+// TTL sets the expiry used by the expiry case.
+func TTL(d time.Duration) Option {
+	return func(o *Options) {
+		o.TTL = d
+	}
+}
+
+// Advance sets how the suite lets time pass, for backends run against a
+// server with a fake clock (e.g. miniredis) that can fast-forward instead
+// of sleeping.
+func Advance(fn func(time.Duration)) Option {
+	return func(o *Options) {
+		o.Advance = fn
+	}
+}
+
+// WithoutExpiry skips the per-record expiry case, for backends that only
+// expire whole tables.
+func WithoutExpiry() Option {
+	return func(o *Options) {
+		o.Expiry = false
+	}
+}
+
+// Run exercises the behaviour every store must share. newStore is called
+// once per case and must return an empty store; it is closed when the case
+// ends.
+func Run(t *testing.T, newStore func(t *testing.T) store.Store, opts ...Option) {
+	options := Options{
+		TTL:     time.Second,
+		Advance: time.Sleep,
+		Expiry:  true,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	s := &suite{opts: options, newStore: newStore}
+
+	t.Run("ReadWrite", s.testReadWrite)
+	t.Run("Overwrite", s.testOverwrite)
+	t.Run("Delete", s.testDelete)
+	t.Run("Scope", s.testScope)
+	t.Run("ReadPrefixSuffix", s.testReadPrefixSuffix)
+	t.Run("ReadLimitOffset", s.testReadLimitOffset)
+	t.Run("List", s.testList)
+	t.Run("ListLimitOffset", s.testListLimitOffset)
+	if options.Expiry {
+		t.Run("Expiry", s.testExpiry)
+	}
+	t.Run("Batch", s.testBatch)
+	t.Run("CompareAndSwap", s.testCompareAndSwap)
+}
+
+type suite struct {
+	opts     Options
+	newStore func(t *testing.T) store.Store
+}
+
+func (s *suite) store(t *testing.T) store.Store {
+	t.Helper()
+
+	st := s.newStore(t)
+	t.Cleanup(func() { st.Close() })
+	return st
+}
+
+func write(t *testing.T, st store.Store, keys ...string) {
+	t.Helper()
+
+	for _, k := range keys {
+		if err := st.Write(&store.Record{Key: k, Value: []byte("value of " + k)}); err != nil {
+			t.Fatalf("write %q: %v", k, err)
+		}
+	}
+}
+
+// keysOf returns the keys of recs in the order they were returned.
+func keysOf(recs []*store.Record) []string {
+	keys := make([]string, 0, len(recs))
+	for _, r := range recs {
+		keys = append(keys, r.Key)
+	}
+	return keys
+}
+
+func sorted(keys []string) []string {
+	out := append([]string{}, keys...)
+	sort.Strings(out)
+	return out
+}
+
+func equal(t *testing.T, what string, got, want []string) {
+	t.Helper()
+
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("%s = %v, want %v", what, got, want)
+	}
+}
+
+func notFound(t *testing.T, what string, recs []*store.Record, err error) {
+	t.Helper()
+
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("%s: got %d records and error %v, want store.ErrNotFound", what, len(recs), err)
+	}
+}
+
+func (s *suite) testReadWrite(t *testing.T) {
+	st := s.store(t)
+
+	in := &store.Record{
+		Key:      "foo",
+		Value:    []byte("bar"),
+		Metadata: map[string]interface{}{"owner": "alice"},
+	}
+	if err := st.Write(in); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	recs, err := st.Read("foo")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(recs) != 1 {
+		t.Fatalf("read returned %d records, want 1", len(recs))
+	}
+	r := recs[0]
+	if r.Key != "foo" || string(r.Value) != "bar" {
+		t.Fatalf("read %q=%q, want foo=bar", r.Key, r.Value)
+	}
+	if r.Metadata["owner"] != "alice" {
+		t.Fatalf("metadata = %v, want owner=alice", r.Metadata)
+	}
+
+	recs, err = st.Read("missing")
+	notFound(t, "read missing", recs, err)
+}
+
+func (s *suite) testOverwrite(t *testing.T) {
+	st := s.store(t)
+
+	write(t, st, "foo")
+	if err := st.Write(&store.Record{Key: "foo", Value: []byte("new")}); err != nil {
+		t.Fatalf("overwrite: %v", err)
+	}
+
+	recs, err := st.Read("foo")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(recs) != 1 || string(recs[0].Value) != "new" {
+		t.Fatalf("read %v, want one record with value new", recs)
+	}
+}
+
+func (s *suite) testDelete(t *testing.T) {
+	st := s.store(t)
+
+	write(t, st, "foo", "bar")
+	if err := st.Delete("foo"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	recs, err := st.Read("foo")
+	notFound(t, "read deleted", recs, err)
+
+	if _, err := st.Read("bar"); err != nil {
+		t.Fatalf("delete removed another key: %v", err)
+	}
+	if err := st.Delete("missing"); err != nil {
+		t.Fatalf("delete missing key: %v", err)
+	}
+}
+
+func (s *suite) testScope(t *testing.T) {
+	st := s.store(t)
+
+	write(t, st, "shared")
+	if err := st.Write(&store.Record{Key: "shared", Value: []byte("scoped")}, store.WriteTo("conformance", "one")); err != nil {
+		t.Fatalf("write scoped: %v", err)
+	}
+	if err := st.Write(&store.Record{Key: "only"}, store.WriteTo("conformance", "one")); err != nil {
+		t.Fatalf("write scoped: %v", err)
+	}
+
+	recs, err := st.Read("shared", store.ReadFrom("conformance", "one"))
+	if err != nil {
+		t.Fatalf("read scoped: %v", err)
+	}
+	if string(recs[0].Value) != "scoped" {
+		t.Fatalf("scoped read = %q, want scoped", recs[0].Value)
+	}
+	recs, err = st.Read("shared")
+	if err != nil {
+		t.Fatalf("read default: %v", err)
+	}
+	if string(recs[0].Value) != "value of shared" {
+		t.Fatalf("scoped write leaked into the default table: %q", recs[0].Value)
+	}
+
+	recs, err = st.Read("only")
+	notFound(t, "read scoped key from default table", recs, err)
+	recs, err = st.Read("only", store.ReadFrom("conformance", "two"))
+	notFound(t, "read scoped key from another table", recs, err)
+	recs, err = st.Read("only", store.ReadFrom("other", "one"))
+	notFound(t, "read scoped key from another database", recs, err)
+
+	keys, err := st.List(store.ListFrom("conformance", "one"))
+	if err != nil {
+		t.Fatalf("list scoped: %v", err)
+	}
+	equal(t, "scoped list", sorted(keys), []string{"only", "shared"})
+	keys, err = st.List()
+	if err != nil {
+		t.Fatalf("list default: %v", err)
+	}
+	equal(t, "default list", keys, []string{"shared"})
+
+	if err := st.Delete("shared", store.DeleteFrom("conformance", "one")); err != nil {
+		t.Fatalf("delete scoped: %v", err)
+	}
+	recs, err = st.Read("shared", store.ReadFrom("conformance", "one"))
+	notFound(t, "read deleted scoped key", recs, err)
+	if _, err := st.Read("shared"); err != nil {
+		t.Fatalf("scoped delete removed the default key: %v", err)
+	}
+}
+
+func (s *suite) testReadPrefixSuffix(t *testing.T) {
+	st := s.store(t)
+
+	write(t, st, "user/1/name", "user/1/email", "user/2/name", "team/1/name")
+
+	recs, err := st.Read("user/", store.ReadPrefix())
+	if err != nil {
+		t.Fatalf("read prefix: %v", err)
+	}
+	equal(t, "prefix read", sorted(keysOf(recs)), []string{"user/1/email", "user/1/name", "user/2/name"})
+	for _, r := range recs {
+		if string(r.Value) != "value of "+r.Key {
+			t.Fatalf("prefix read %q=%q", r.Key, r.Value)
+		}
+	}
+
+	recs, err = st.Read("/name", store.ReadSuffix())
+	if err != nil {
+		t.Fatalf("read suffix: %v", err)
+	}
+	equal(t, "suffix read", sorted(keysOf(recs)), []string{"team/1/name", "user/1/name", "user/2/name"})
+
+	recs, err = st.Read("nobody/", store.ReadPrefix())
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("read unmatched prefix: %v", err)
+	}
+	if len(recs) != 0 {
+		t.Fatalf("unmatched prefix returned %v", keysOf(recs))
+	}
+}
+
+func (s *suite) testReadLimitOffset(t *testing.T) {
+	st := s.store(t)
+
+	write(t, st, "b0", "a3", "a1", "a4", "a0", "a2", "b1")
+
+	recs, err := st.Read("a", store.ReadPrefix(), store.ReadLimit(2), store.ReadOffset(1))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	equal(t, "prefix read with limit 2 offset 1", keysOf(recs), []string{"a1", "a2"})
+
+	recs, err = st.Read("a", store.ReadPrefix(), store.ReadOffset(3))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	equal(t, "prefix read with offset 3", keysOf(recs), []string{"a3", "a4"})
+
+	recs, err = st.Read("a", store.ReadPrefix(), store.ReadOffset(10))
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("read past the end: %v", err)
+	}
+	if len(recs) != 0 {
+		t.Fatalf("offset past the end returned %v", keysOf(recs))
+	}
+}
+
+func (s *suite) testList(t *testing.T) {
+	st := s.store(t)
+
+	keys, err := st.List()
+	if err != nil {
+		t.Fatalf("list empty: %v", err)
+	}
+	equal(t, "empty list", keys, nil)
+
+	write(t, st, "user/1/name", "user/1/email", "user/2/name", "team/1/name")
+
+	keys, err = st.List()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	equal(t, "list", sorted(keys), []string{"team/1/name", "user/1/email", "user/1/name", "user/2/name"})
+
+	keys, err = st.List(store.ListPrefix("user/"))
+	if err != nil {
+		t.Fatalf("list prefix: %v", err)
+	}
+	equal(t, "prefix list", sorted(keys), []string{"user/1/email", "user/1/name", "user/2/name"})
+
+	keys, err = st.List(store.ListSuffix("/name"))
+	if err != nil {
+		t.Fatalf("list suffix: %v", err)
+	}
+	equal(t, "suffix list", sorted(keys), []string{"team/1/name", "user/1/name", "user/2/name"})
+
+	keys, err = st.List(store.ListPrefix("user/"), store.ListSuffix("/name"))
+	if err != nil {
+		t.Fatalf("list prefix and suffix: %v", err)
+	}
+	equal(t, "prefix and suffix list", sorted(keys), []string{"user/1/name", "user/2/name"})
+}
+
+func (s *suite) testListLimitOffset(t *testing.T) {
+	st := s.store(t)
+
+	write(t, st, "b0", "a3", "a1", "a4", "a0", "a2", "b1")
+
+	keys, err := st.List(store.ListLimit(3))
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	equal(t, "list with limit 3", keys, []string{"a0", "a1", "a2"})
+
+	keys, err = st.List(store.ListLimit(3), store.ListOffset(5))
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	equal(t, "list with limit 3 offset 5", keys, []string{"b0", "b1"})
+
+	// limit and offset page through the filtered keys, not all of them
+	keys, err = st.List(store.ListPrefix("b"), store.ListLimit(1), store.ListOffset(1))
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	equal(t, "prefix list with limit 1 offset 1", keys, []string{"b1"})
+
+	keys, err = st.List(store.ListOffset(10))
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	equal(t, "list with offset past the end", keys, nil)
+}
+
+func (s *suite) testExpiry(t *testing.T) {
+	st := s.store(t)
+	ttl := s.opts.TTL
+
+	if err := st.Write(&store.Record{Key: "record", Expiry: ttl}); err != nil {
+		t.Fatalf("write with record expiry: %v", err)
+	}
+	if err := st.Write(&store.Record{Key: "ttl"}, store.WriteTTL(ttl)); err != nil {
+		t.Fatalf("write with ttl: %v", err)
+	}
+	if err := st.Write(&store.Record{Key: "expiry"}, store.WriteExpiry(time.Now().Add(ttl))); err != nil {
+		t.Fatalf("write with expiry: %v", err)
+	}
+	// WriteTTL takes precedence over the record's own expiry
+	if err := st.Write(&store.Record{Key: "override", Expiry: ttl}, store.WriteTTL(time.Hour)); err != nil {
+		t.Fatalf("write with overridden expiry: %v", err)
+	}
+	write(t, st, "forever")
+
+	recs, err := st.Read("record")
+	if err != nil {
+		t.Fatalf("read before expiry: %v", err)
+	}
+	if recs[0].Expiry <= 0 || recs[0].Expiry > ttl {
+		t.Fatalf("remaining expiry = %v, want within (0, %v]", recs[0].Expiry, ttl)
+	}
+	keys, err := st.List()
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	equal(t, "list before expiry", sorted(keys), []string{"expiry", "forever", "override", "record", "ttl"})
+
+	s.opts.Advance(ttl * 2)
+
+	for _, k := range []string{"record", "ttl", "expiry"} {
+		recs, err := st.Read(k)
+		notFound(t, "read "+k+" after expiry", recs, err)
+	}
+	recs, err = st.Read("", store.ReadPrefix())
+	if err != nil {
+		t.Fatalf("prefix read after expiry: %v", err)
+	}
+	equal(t, "prefix read after expiry", sorted(keysOf(recs)), []string{"forever", "override"})
+	keys, err = st.List()
+	if err != nil {
+		t.Fatalf("list after expiry: %v", err)
+	}
+	equal(t, "list after expiry", sorted(keys), []string{"forever", "override"})
+}
+
+// testBatch runs only for stores that implement store.Batcher.
+func (s *suite) testBatch(t *testing.T) {
+	st := s.store(t)
+	b, ok := st.(store.Batcher)
+	if !ok {
+		t.Skipf("%s does not implement store.Batcher", st)
+	}
+
+	recs := []*store.Record{
+		{Key: "a", Value: []byte("1")},
+		{Key: "b", Value: []byte("2"), Metadata: map[string]interface{}{"owner": "alice"}},
+		{Key: "c", Value: []byte("3")},
+	}
+	if err := b.WriteMany(recs); err != nil {
+		t.Fatalf("write many: %v", err)
+	}
+	if err := b.WriteMany([]*store.Record{{Key: "a", Value: []byte("scoped")}}, store.WriteTo("conformance", "one")); err != nil {
+		t.Fatalf("write many scoped: %v", err)
+	}
+
+	got, err := b.ReadMany([]string{"c", "missing", "a", "b"})
+	if err != nil {
+		t.Fatalf("read many: %v", err)
+	}
+	equal(t, "read many", keysOf(got), []string{"c", "a", "b"})
+	for i, want := range []string{"3", "1", "2"} {
+		if string(got[i].Value) != want {
+			t.Fatalf("read many %q=%q, want %q", got[i].Key, got[i].Value, want)
+		}
+	}
+	if got[2].Metadata["owner"] != "alice" {
+		t.Fatalf("read many metadata = %v, want owner=alice", got[2].Metadata)
+	}
+
+	got, err = b.ReadMany([]string{"a", "b"}, store.ReadFrom("conformance", "one"))
+	if err != nil {
+		t.Fatalf("read many scoped: %v", err)
+	}
+	if len(got) != 1 || string(got[0].Value) != "scoped" {
+		t.Fatalf("read many scoped = %v, want only a=scoped", keysOf(got))
+	}
+
+	got, err = b.ReadMany(nil)
+	if err != nil {
+		t.Fatalf("read many with no keys: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("read many with no keys returned %v", keysOf(got))
+	}
+}
+
+// testCompareAndSwap runs only for stores that implement
+// store.CompareAndSwapper.
+func (s *suite) testCompareAndSwap(t *testing.T) {
+	st := s.store(t)
+	c, ok := st.(store.CompareAndSwapper)
+	if !ok {
+		t.Skipf("%s does not implement store.CompareAndSwapper", st)
+	}
+
+	// revision zero creates the key, once
+	first := &store.Record{Key: "run", Value: []byte("one")}
+	if err := c.CompareAndSwap(first); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if first.Revision == 0 {
+		t.Fatal("create left the revision at zero")
+	}
+	if err := c.CompareAndSwap(&store.Record{Key: "run", Value: []byte("again")}); !errors.Is(err, store.ErrConflict) {
+		t.Fatalf("second create: %v, want store.ErrConflict", err)
+	}
+
+	recs, err := st.Read("run")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	read := recs[0]
+	if read.Revision != first.Revision {
+		t.Fatalf("read revision %d, want %d", read.Revision, first.Revision)
+	}
+
+	// two writers holding the same revision: only the first wins
+	read.Value = []byte("two")
+	if err := c.CompareAndSwap(read); err != nil {
+		t.Fatalf("swap: %v", err)
+	}
+	if read.Revision == first.Revision {
+		t.Fatal("swap did not change the revision")
+	}
+	first.Value = []byte("stale")
+	if err := c.CompareAndSwap(first); !errors.Is(err, store.ErrConflict) {
+		t.Fatalf("stale swap: %v, want store.ErrConflict", err)
+	}
+
+	// a plain write moves the revision on too
+	write(t, st, "run")
+	if err := c.CompareAndSwap(read); !errors.Is(err, store.ErrConflict) {
+		t.Fatalf("swap after write: %v, want store.ErrConflict", err)
+	}
+
+	recs, err = st.Read("run")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(recs[0].Value) != "value of run" {
+		t.Fatalf("conflicting swaps changed the value to %q", recs[0].Value)
+	}
+
+	// revisions are per table
+	scoped := &store.Record{Key: "run", Value: []byte("scoped")}
+	if err := c.CompareAndSwap(scoped, store.WriteTo("conformance", "one")); err != nil {
+		t.Fatalf("create scoped: %v", err)
+	}
+
+	// a deleted key can be created again
+	if err := st.Delete("run"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := c.CompareAndSwap(&store.Record{Key: "run", Value: []byte("three")}); err != nil {
+		t.Fatalf("create after delete: %v", err)
+	}
+}
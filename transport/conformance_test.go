@@ -0,0 +1,20 @@
+package transport_test
+
+import (
+	"testing"
+
+	"go-micro.dev/v6/transport"
+	"go-micro.dev/v6/transport/transporttest"
+)
+
+func TestHTTPTransportConformance(t *testing.T) {
+	transporttest.Run(t, func() transport.Transport {
+		return transport.NewHTTPTransport()
+	})
+}
+
+func TestMemoryTransportConformance(t *testing.T) {
+	transporttest.Run(t, func() transport.Transport {
+		return transport.NewMemoryTransport()
+	})
+}
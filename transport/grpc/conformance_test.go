@@ -0,0 +1,14 @@
+package grpc
+
+import (
+	"testing"
+
+	"go-micro.dev/v6/transport"
+	"go-micro.dev/v6/transport/transporttest"
+)
+
+func TestGRPCTransportConformance(t *testing.T) {
+	transporttest.Run(t, func() transport.Transport {
+		return NewTransport()
+	})
+}
@@ -17,14 +17,22 @@ import (
 	pb "go-micro.dev/v6/transport/grpc/proto"
 )
 
+var (
+	// DefaultMaxMsgSize is the maximum message size the transport can send
+	// or receive. It leaves room for headers on top of a 4MB body, which
+	// the other transports carry without a limit.
+	DefaultMaxMsgSize = 1024 * 1024 * 16
+)
+
 type grpcTransport struct {
 	opts transport.Options
 }
 
 type grpcTransportListener struct {
-	listener net.Listener
-	secure   bool
-	tls      *tls.Config
+	listener   net.Listener
+	secure     bool
+	tls        *tls.Config
+	maxMsgSize int
 }
 
 func init() {
@@ -61,7 +69,10 @@ func (t *grpcTransportListener) Close() error {
 }
 
 func (t *grpcTransportListener) Accept(fn func(transport.Socket)) error {
-	var opts []grpc.ServerOption
+	opts := []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(t.maxMsgSize),
+		grpc.MaxSendMsgSize(t.maxMsgSize),
+	}
 
 	// setup tls if specified
 	if t.secure || t.tls != nil {
@@ -98,8 +109,13 @@ func (t *grpcTransport) Dial(addr string, opts ...transport.DialOption) (transpo
 		opt(&dopts)
 	}
 
+	maxMsgSize := getMaxMsgSize(t.opts)
 	options := []grpc.DialOption{
 		grpc.WithTimeout(dopts.Timeout),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(maxMsgSize),
+			grpc.MaxCallSendMsgSize(maxMsgSize),
+		),
 	}
 
 	if t.opts.Secure || t.opts.TLSConfig != nil {
@@ -149,9 +165,10 @@ func (t *grpcTransport) Listen(addr string, opts ...transport.ListenOption) (tra
 	}
 
 	return &grpcTransportListener{
-		listener: ln,
-		tls:      t.opts.TLSConfig,
-		secure:   t.opts.Secure,
+		listener:   ln,
+		tls:        t.opts.TLSConfig,
+		secure:     t.opts.Secure,
+		maxMsgSize: getMaxMsgSize(t.opts),
 	}, nil
 }
 
@@ -0,0 +1,31 @@
+package grpc
+
+import (
+	"context"
+
+	"go-micro.dev/v6/transport"
+)
+
+type maxMsgSizeKey struct{}
+
+// MaxMsgSize sets the maximum message in bytes the transport can send and
+// receive, headers included. Default is DefaultMaxMsgSize.
+func MaxMsgSize(s int) transport.Option {
+	return func(o *transport.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, maxMsgSizeKey{}, s)
+	}
+}
+
+func getMaxMsgSize(o transport.Options) int {
+	if o.Context == nil {
+		return DefaultMaxMsgSize
+	}
+	s, ok := o.Context.Value(maxMsgSizeKey{}).(int)
+	if !ok || s <= 0 {
+		return DefaultMaxMsgSize
+	}
+	return s
+}
@@ -1,14 +1,23 @@
 package grpc
 
 import (
+	"sync"
+	"time"
+
 	"go-micro.dev/v6/transport"
 	pb "go-micro.dev/v6/transport/grpc/proto"
 	"google.golang.org/grpc"
 )
 
+// closeGrace bounds how long Close waits for the server to finish the
+// stream before it tears down the connection.
+var closeGrace = time.Second
+
 type grpcTransportClient struct {
 	conn   *grpc.ClientConn
 	stream pb.Transport_StreamClient
+	// recvMu serializes stream.Recv between Recv and Close
+	recvMu sync.Mutex
 
 	local  string
 	remote string
@@ -33,7 +42,9 @@ func (g *grpcTransportClient) Recv(m *transport.Message) error {
 		return nil
 	}
 
+	g.recvMu.Lock()
 	msg, err := g.stream.Recv()
+	g.recvMu.Unlock()
 	if err != nil {
 		return err
 	}
@@ -55,6 +66,26 @@ func (g *grpcTransportClient) Send(m *transport.Message) error {
 }
 
 func (g *grpcTransportClient) Close() error {
+	// half-close first so the server still receives what was sent, and
+	// only drop the connection once it has ended the stream
+	_ = g.stream.CloseSend()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.recvMu.Lock()
+		defer g.recvMu.Unlock()
+		for {
+			if _, err := g.stream.Recv(); err != nil {
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(closeGrace):
+	}
 	return g.conn.Close()
 }
 
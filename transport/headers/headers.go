@@ -30,4 +30,9 @@ const (
 	TraceIDKey = "Micro-Trace-ID"
 	// Stream header.
 	Stream = "Micro-Stream"
+	// Region header pins calls to nodes in a region.
+	Region = "Micro-Region"
+	// SchemaVersion header is the event contract version a message was
+	// published with.
+	SchemaVersion = "Micro-Schema-Version"
 )
@@ -29,6 +29,9 @@ type memorySocket struct {
 	exit chan bool
 	// listener exit
 	lexit chan bool
+	// both ends of both pipes, closed with the socket so that a
+	// blocked Send or Recv on either side returns
+	pipes []io.Closer
 
 	local  string
 	remote string
@@ -139,6 +142,9 @@ func (ms *memorySocket) Close() error {
 	default:
 		close(ms.exit)
 	}
+	for _, p := range ms.pipes {
+		p.Close()
+	}
 	return nil
 }
 
@@ -168,6 +174,7 @@ func (m *memoryListener) Accept(fn func(Socket)) error {
 				server:  true,
 				lexit:   c.lexit,
 				exit:    c.exit,
+				pipes:   c.pipes,
 				ssend:   c.ssend,
 				srecv:   c.srecv,
 				local:   c.Remote(),
@@ -203,6 +210,7 @@ func (m *memoryTransport) Dial(addr string, opts ...DialOption) (Client, error)
 			crecv:  gob.NewDecoder(creader),
 			ssend:  gob.NewEncoder(swriter),
 			srecv:  gob.NewDecoder(sreader), exit: make(chan bool),
+			pipes:   []io.Closer{creader, swriter, sreader, cwriter},
 			lexit:   listener.exit,
 			local:   addr,
 			remote:  addr,
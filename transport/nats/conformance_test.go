@@ -0,0 +1,126 @@
+package nats
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	nserver "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"go-micro.dev/v6/server"
+	"go-micro.dev/v6/transport"
+	"go-micro.dev/v6/transport/transporttest"
+)
+
+// embeddedServer starts a NATS server for the test.
+func embeddedServer(t *testing.T) *nserver.Server {
+	t.Helper()
+
+	ns, err := nserver.NewServer(&nserver.Options{
+		Host:       "127.0.0.1",
+		Port:       -1,
+		NoLog:      true,
+		NoSigs:     true,
+		MaxPayload: 16 * 1024 * 1024,
+	})
+	if err != nil {
+		t.Fatalf("nats: new server: %v", err)
+	}
+	go ns.Start()
+	t.Cleanup(ns.Shutdown)
+
+	if !ns.ReadyForConnections(10 * time.Second) {
+		t.Fatal("NATS server not ready")
+	}
+	return ns
+}
+
+// TestNATSTransportConformance runs the shared transport suite against an
+// embedded NATS server.
+func TestNATSTransportConformance(t *testing.T) {
+	ns := embeddedServer(t)
+
+	transporttest.Run(t, func() transport.Transport {
+		return NewTransport(transport.Addrs(ns.ClientURL()))
+	}, transporttest.Addr(server.DefaultAddress))
+}
+
+// TestNATSCloseSkipsOlderPeers checks that no close message goes to a peer
+// whose messages do not say it understands them, as an older version would
+// pass it to the handler as an empty message.
+func TestNATSCloseSkipsOlderPeers(t *testing.T) {
+	ns := embeddedServer(t)
+	tr := NewTransport(transport.Addrs(ns.ClientURL()))
+
+	raw, err := nats.Connect(ns.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+
+	body, err := json.Marshal(&transport.Message{Body: []byte("hello")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an older client talking to this listener
+	l, err := tr.Listen(server.DefaultAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go l.Accept(func(sock transport.Socket) {
+		var m transport.Message
+		if err := sock.Recv(&m); err != nil {
+			return
+		}
+		_ = sock.Send(&m)
+		sock.Close()
+	})
+	// give Accept a moment to subscribe
+	time.Sleep(100 * time.Millisecond)
+
+	inbox := nats.NewInbox()
+	sub, err := raw.SubscribeSync(inbox)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := raw.PublishRequest(l.Addr(), inbox, body); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sub.NextMsg(5 * time.Second); err != nil {
+		t.Fatalf("reply: %v", err)
+	}
+	if m, err := sub.NextMsg(200 * time.Millisecond); err == nil {
+		t.Fatalf("older client got %+v after the reply", m)
+	}
+
+	// an older server answering this client
+	subject := nats.NewInbox()
+	srv, err := raw.SubscribeSync(subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := tr.Dial(subject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Send(&transport.Message{Body: []byte("hello")}); err != nil {
+		t.Fatal(err)
+	}
+	req, err := srv.NextMsg(5 * time.Second)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	if err := raw.Publish(req.Reply, body); err != nil {
+		t.Fatal(err)
+	}
+	var m transport.Message
+	if err := c.Recv(&m); err != nil {
+		t.Fatalf("recv: %v", err)
+	}
+	c.Close()
+	if m, err := srv.NextMsg(200 * time.Millisecond); err == nil {
+		t.Fatalf("older server got %+v after the client closed", m)
+	}
+}
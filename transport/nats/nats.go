@@ -35,6 +35,12 @@ type ntportClient struct {
 	remote     string
 	sub        *nats.Subscription
 	opts       transport.Options
+
+	mu     sync.Mutex
+	closed bool
+	// peerClose is set once the server has said it understands close
+	// messages
+	peerClose bool
 }
 
 type ntportSocket struct {
@@ -46,6 +52,11 @@ type ntportSocket struct {
 
 	sync.Mutex
 	bl []*nats.Msg
+	// remoteClosed is set when the client closed first
+	remoteClosed bool
+	// peerClose is set once the client has said it understands close
+	// messages
+	peerClose bool
 
 	opts   transport.Options
 	local  string
@@ -67,6 +78,42 @@ var (
 	DefaultTimeout = time.Minute
 )
 
+// closeHeader marks the control message one side publishes when it closes
+// its socket, so the peer's pending Recv returns instead of timing out.
+// Older versions would hand that message to the handler as an empty one,
+// so every data message carries featuresHeader and a side only sends a
+// close once its peer's messages have carried it too.
+const (
+	closeHeader    = "Micro-Transport-Close"
+	featuresHeader = "Micro-Transport-Features"
+	featureClose   = "close"
+)
+
+func closeMsg(subject, reply string) *nats.Msg {
+	return &nats.Msg{
+		Subject: subject,
+		Reply:   reply,
+		Header:  nats.Header{closeHeader: []string{"1"}},
+	}
+}
+
+func dataMsg(subject, reply string, b []byte) *nats.Msg {
+	return &nats.Msg{
+		Subject: subject,
+		Reply:   reply,
+		Data:    b,
+		Header:  nats.Header{featuresHeader: []string{featureClose}},
+	}
+}
+
+func isClose(m *nats.Msg) bool {
+	return m.Header.Get(closeHeader) != ""
+}
+
+func supportsClose(m *nats.Msg) bool {
+	return m.Header.Get(featuresHeader) == featureClose
+}
+
 func configure(n *ntport, opts ...transport.Option) {
 	for _, o := range opts {
 		o(&n.opts)
@@ -169,14 +216,14 @@ func (n *ntportClient) Send(m *transport.Message) error {
 
 	// no deadline
 	if n.opts.Timeout == time.Duration(0) {
-		return n.conn.PublishRequest(n.addr, n.id, b)
+		return n.conn.PublishMsg(dataMsg(n.addr, n.id, b))
 	}
 
 	// use the deadline
 	ch := make(chan error, 1)
 
 	go func() {
-		ch <- n.conn.PublishRequest(n.addr, n.id, b)
+		ch <- n.conn.PublishMsg(dataMsg(n.addr, n.id, b))
 	}()
 
 	select {
@@ -193,11 +240,32 @@ func (n *ntportClient) Recv(m *transport.Message) error {
 		timeout = n.opts.Timeout
 	}
 
+	n.mu.Lock()
+	closed := n.closed
+	n.mu.Unlock()
+	if closed {
+		return io.EOF
+	}
+
 	rsp, err := n.sub.NextMsg(timeout)
 	if err != nil {
 		return err
 	}
 
+	// the server closed its side of the socket
+	if isClose(rsp) {
+		n.mu.Lock()
+		n.closed = true
+		n.mu.Unlock()
+		return io.EOF
+	}
+
+	if supportsClose(rsp) {
+		n.mu.Lock()
+		n.peerClose = true
+		n.mu.Unlock()
+	}
+
 	var mr transport.Message
 	if err := n.opts.Codec.Unmarshal(rsp.Data, &mr); err != nil {
 		return err
@@ -208,6 +276,17 @@ func (n *ntportClient) Recv(m *transport.Message) error {
 }
 
 func (n *ntportClient) Close() error {
+	n.mu.Lock()
+	closed := n.closed
+	peerClose := n.peerClose
+	n.closed = true
+	n.mu.Unlock()
+
+	// tell the server so its socket stops waiting on us
+	if !closed && peerClose {
+		_ = n.conn.PublishMsg(closeMsg(n.addr, n.id))
+	}
+
 	_ = n.sub.Unsubscribe()
 
 	// If using a pooled connection, return it to the pool
@@ -251,6 +330,16 @@ func (n *ntportSocket) Recv(m *transport.Message) error {
 		return io.EOF
 	}
 
+	// a nil message is queued when the client closes its side; put it
+	// back so later calls see the close too
+	if r == nil {
+		select {
+		case n.r <- nil:
+		default:
+		}
+		return io.EOF
+	}
+
 	n.Lock()
 	if len(n.bl) > 0 {
 		select {
@@ -275,14 +364,14 @@ func (n *ntportSocket) Send(m *transport.Message) error {
 
 	// no deadline
 	if n.opts.Timeout == time.Duration(0) {
-		return n.conn.Publish(n.m.Reply, b)
+		return n.conn.PublishMsg(dataMsg(n.m.Reply, "", b))
 	}
 
 	// use the deadline
 	ch := make(chan error, 1)
 
 	go func() {
-		ch <- n.conn.Publish(n.m.Reply, b)
+		ch <- n.conn.PublishMsg(dataMsg(n.m.Reply, "", b))
 	}()
 
 	select {
@@ -294,12 +383,20 @@ func (n *ntportSocket) Send(m *transport.Message) error {
 }
 
 func (n *ntportSocket) Close() error {
+	n.Lock()
+	defer n.Unlock()
+
 	select {
 	case <-n.close:
 		return nil
 	default:
 		close(n.close)
 	}
+
+	// tell the client so its pending Recv returns
+	if !n.remoteClosed && n.peerClose {
+		_ = n.conn.PublishMsg(closeMsg(n.m.Reply, ""))
+	}
 	return nil
 }
 
@@ -336,6 +433,22 @@ func (n *ntportListener) Accept(fn func(transport.Socket)) error {
 		sock, ok := n.so[m.Reply]
 		n.RUnlock()
 
+		if isClose(m) {
+			// queue the close behind any messages not yet received
+			if ok {
+				sock.Lock()
+				sock.remoteClosed = true
+				sock.bl = append(sock.bl, nil)
+				select {
+				case sock.r <- sock.bl[0]:
+					sock.bl = sock.bl[1:]
+				default:
+				}
+				sock.Unlock()
+			}
+			continue
+		}
+
 		if !ok {
 			sock = &ntportSocket{
 				conn:   n.conn,
@@ -375,6 +488,9 @@ func (n *ntportListener) Accept(fn func(transport.Socket)) error {
 		}
 
 		sock.Lock()
+		if supportsClose(m) {
+			sock.peerClose = true
+		}
 		sock.bl = append(sock.bl, m)
 		select {
 		case sock.r <- sock.bl[0]:
@@ -0,0 +1,36 @@
+package quic
+
+import (
+	"context"
+
+	"github.com/quic-go/quic-go"
+	"go-micro.dev/v6/transport"
+)
+
+type configKey struct{}
+type enable0RTTKey struct{}
+
+// Config sets the underlying quic-go configuration. Stream limits, idle
+// timeouts and flow control windows are taken from it; Allow0RTT is
+// controlled by Enable0RTT.
+func Config(c *quic.Config) transport.Option {
+	return func(o *transport.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, configKey{}, c)
+	}
+}
+
+// Enable0RTT lets a client that reconnects to a peer it has talked to
+// before send its first request in the handshake packets, and lets the
+// listener accept such requests. 0-RTT data can be replayed by an attacker
+// on the path, so only enable it when handlers are idempotent.
+func Enable0RTT() transport.Option {
+	return func(o *transport.Options) {
+		if o.Context == nil {
+			o.Context = context.Background()
+		}
+		o.Context = context.WithValue(o.Context, enable0RTTKey{}, true)
+	}
+}
@@ -0,0 +1,406 @@
+// Package quic provides a QUIC transport. Every Dial opens a new stream on
+// a single shared QUIC connection per peer, so concurrent calls do not
+// suffer TCP head-of-line blocking and reconnects can resume in 0-RTT.
+package quic
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"go-micro.dev/v6/cmd"
+	maddr "go-micro.dev/v6/internal/util/addr"
+	mtls "go-micro.dev/v6/internal/util/tls"
+	"go-micro.dev/v6/transport"
+)
+
+// alpn is the TLS application protocol negotiated by the transport.
+const alpn = "go-micro"
+
+// DrainTimeout bounds how long a closed listener waits for open sockets to
+// finish before closing their connections.
+var DrainTimeout = 10 * time.Second
+
+type quicTransport struct {
+	opts transport.Options
+
+	sync.Mutex
+	// udp is the shared client socket all outgoing connections use
+	udp *quic.Transport
+	// conns holds the live connection per peer address
+	conns map[string]*quic.Conn
+	// sessions caches TLS session tickets for resumption and 0-RTT
+	sessions tls.ClientSessionCache
+}
+
+type quicListener struct {
+	udp      *quic.Transport
+	listener *quic.EarlyListener
+	timeout  time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	// wg tracks accepted connections so the socket is only released
+	// once in-flight requests have been answered
+	wg        sync.WaitGroup
+	accepting atomic.Bool
+	closeOnce sync.Once
+}
+
+func init() {
+	cmd.DefaultTransports["quic"] = NewTransport
+}
+
+func getTLSConfig(addr string) (*tls.Config, error) {
+	hosts := []string{addr}
+
+	// check if its a valid host:port
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		if len(host) == 0 {
+			hosts = maddr.IPs()
+		} else {
+			hosts = []string{host}
+		}
+	}
+
+	// generate a certificate
+	cert, err := mtls.Certificate(hosts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, nil
+}
+
+func (q *quicTransport) config() *quic.Config {
+	conf := &quic.Config{
+		MaxIncomingStreams: 10000,
+		KeepAlivePeriod:    15 * time.Second,
+	}
+	if q.opts.Context != nil {
+		if c, ok := q.opts.Context.Value(configKey{}).(*quic.Config); ok && c != nil {
+			conf = c.Clone()
+		}
+	}
+	conf.Allow0RTT = q.zeroRTT()
+	return conf
+}
+
+func (q *quicTransport) zeroRTT() bool {
+	if q.opts.Context == nil {
+		return false
+	}
+	v, _ := q.opts.Context.Value(enable0RTTKey{}).(bool)
+	return v
+}
+
+func (q *quicTransport) clientTLS(dopts transport.DialOptions) *tls.Config {
+	var config *tls.Config
+	switch {
+	case q.opts.TLSConfig != nil:
+		config = q.opts.TLSConfig.Clone()
+	case q.opts.Secure && !dopts.InsecureSkipVerify:
+		config = mtls.Config()
+	default:
+		// QUIC is always encrypted; without Secure we accept the
+		// listener's self-signed certificate like the other transports
+		// accept plaintext.
+		config = mtls.InsecureConfig()
+	}
+
+	config.NextProtos = []string{alpn}
+	if config.ClientSessionCache == nil {
+		config.ClientSessionCache = q.sessions
+	}
+	return config
+}
+
+// conn returns the live connection to addr, dialing a new one if there is
+// none or the previous one has gone away.
+func (q *quicTransport) conn(ctx context.Context, addr string, dopts transport.DialOptions) (*quic.Conn, error) {
+	q.Lock()
+	if c, ok := q.conns[addr]; ok {
+		if c.Context().Err() == nil {
+			q.Unlock()
+			return c, nil
+		}
+		delete(q.conns, addr)
+	}
+
+	if q.udp == nil {
+		udp, err := net.ListenUDP("udp", nil)
+		if err != nil {
+			q.Unlock()
+			return nil, err
+		}
+		q.udp = &quic.Transport{Conn: udp, ConnectionIDLength: 4}
+	}
+	udp := q.udp
+	q.Unlock()
+
+	raddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// dial without the lock so a slow peer doesn't hold up dials to others
+	var c *quic.Conn
+	if q.zeroRTT() {
+		c, err = udp.DialEarly(ctx, raddr, q.clientTLS(dopts), q.config())
+	} else {
+		c, err = udp.Dial(ctx, raddr, q.clientTLS(dopts), q.config())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	// another dial to the same peer may have finished first; keep one
+	if cur, ok := q.conns[addr]; ok && cur.Context().Err() == nil {
+		_ = c.CloseWithError(0, "duplicate connection")
+		return cur, nil
+	}
+
+	q.conns[addr] = c
+	return c, nil
+}
+
+func (q *quicTransport) Dial(addr string, opts ...transport.DialOption) (transport.Client, error) {
+	dopts := transport.DialOptions{
+		Timeout: transport.DefaultDialTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(&dopts)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dopts.Timeout)
+	defer cancel()
+
+	// a connection that died since it was cached fails to open a stream;
+	// retry once on a fresh connection
+	for i := 0; ; i++ {
+		c, err := q.conn(ctx, addr, dopts)
+		if err != nil {
+			return nil, err
+		}
+
+		stream, err := c.OpenStreamSync(ctx)
+		if err != nil {
+			if i == 0 && c.Context().Err() != nil {
+				continue
+			}
+			return nil, err
+		}
+
+		return newSocket(stream, q.opts.Timeout, c.LocalAddr().String(), c.RemoteAddr().String()), nil
+	}
+}
+
+func (q *quicTransport) Listen(addr string, opts ...transport.ListenOption) (transport.Listener, error) {
+	var options transport.ListenOptions
+	for _, o := range opts {
+		o(&options)
+	}
+
+	config := q.opts.TLSConfig
+	if config == nil {
+		var err error
+		config, err = getTLSConfig(addr)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		config = config.Clone()
+	}
+	config.NextProtos = []string{alpn}
+
+	laddr, err := net.ResolveUDPAddr("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	udp, err := net.ListenUDP("udp", laddr)
+	if err != nil {
+		return nil, err
+	}
+
+	// the quic.Transport is ours rather than the listener's, so closing the
+	// listener stops new connections without tearing down accepted ones
+	tr := &quic.Transport{Conn: udp}
+
+	ln, err := tr.ListenEarly(config, q.config())
+	if err != nil {
+		udp.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &quicListener{
+		udp:      tr,
+		listener: ln,
+		timeout:  q.opts.Timeout,
+		ctx:      ctx,
+		cancel:   cancel,
+	}, nil
+}
+
+func (q *quicTransport) Init(opts ...transport.Option) error {
+	for _, o := range opts {
+		o(&q.opts)
+	}
+	return nil
+}
+
+func (q *quicTransport) Options() transport.Options {
+	return q.opts
+}
+
+func (q *quicTransport) String() string {
+	return "quic"
+}
+
+func (l *quicListener) Addr() string {
+	return l.listener.Addr().String()
+}
+
+func (l *quicListener) Close() error {
+	l.cancel()
+	err := l.listener.Close()
+
+	// Accept releases the socket itself once its connections drain
+	if !l.accepting.Load() {
+		l.closeUDP()
+	}
+
+	return err
+}
+
+func (l *quicListener) closeUDP() {
+	l.closeOnce.Do(func() {
+		l.udp.Close()
+	})
+}
+
+func (l *quicListener) Accept(fn func(transport.Socket)) error {
+	l.accepting.Store(true)
+	defer func() {
+		go func() {
+			l.wg.Wait()
+			l.closeUDP()
+		}()
+	}()
+
+	for {
+		c, err := l.listener.Accept(l.ctx)
+		if err != nil {
+			if l.ctx.Err() != nil || errors.Is(err, quic.ErrServerClosed) {
+				return nil
+			}
+			return err
+		}
+
+		l.wg.Add(1)
+		go l.serve(c, fn)
+	}
+}
+
+// serve accepts streams on c until the listener closes, then closes c
+// once the sockets already handed to fn are done or DrainTimeout passes.
+func (l *quicListener) serve(c *quic.Conn, fn func(transport.Socket)) {
+	defer l.wg.Done()
+
+	var wg sync.WaitGroup
+	defer func() {
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-c.Context().Done():
+		case <-time.After(DrainTimeout):
+		}
+		_ = c.CloseWithError(0, "listener closed")
+	}()
+
+	for {
+		stream, err := c.AcceptStream(l.ctx)
+		if err != nil {
+			return
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sock := newSocket(stream, l.timeout, c.LocalAddr().String(), c.RemoteAddr().String())
+			defer sock.Close()
+			fn(sock)
+		}()
+	}
+}
+
+// Migrate moves the connection to addr onto a fresh local UDP socket, as a
+// client would when its network changes. Open streams carry on over the new
+// path once the peer has validated it.
+func Migrate(t transport.Transport, addr string) error {
+	q, ok := t.(*quicTransport)
+	if !ok {
+		return errors.New("quic: not a quic transport")
+	}
+
+	q.Lock()
+	c, ok := q.conns[addr]
+	q.Unlock()
+	if !ok || c.Context().Err() != nil {
+		return errors.New("quic: no connection to " + addr)
+	}
+
+	udp, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return err
+	}
+
+	tr := &quic.Transport{Conn: udp, ConnectionIDLength: 4}
+	path, err := c.AddPath(tr)
+	if err != nil {
+		udp.Close()
+		return err
+	}
+
+	// the new path's socket lives as long as the connection
+	go func() {
+		<-c.Context().Done()
+		tr.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), transport.DefaultDialTimeout)
+	defer cancel()
+
+	if err := path.Probe(ctx); err != nil {
+		path.Close()
+		return err
+	}
+	return path.Switch()
+}
+
+func NewTransport(opts ...transport.Option) transport.Transport {
+	var options transport.Options
+	for _, o := range opts {
+		o(&options)
+	}
+	return &quicTransport{
+		opts:     options,
+		conns:    make(map[string]*quic.Conn),
+		sessions: tls.NewLRUClientSessionCache(64),
+	}
+}
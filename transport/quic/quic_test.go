@@ -0,0 +1,281 @@
+package quic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/client"
+	"go-micro.dev/v6/registry"
+	"go-micro.dev/v6/selector"
+	"go-micro.dev/v6/server"
+	"go-micro.dev/v6/transport"
+)
+
+func echo(sock transport.Socket) {
+	for {
+		var m transport.Message
+		if err := sock.Recv(&m); err != nil {
+			return
+		}
+		if err := sock.Send(&m); err != nil {
+			return
+		}
+	}
+}
+
+func listen(t *testing.T, tr transport.Transport) transport.Listener {
+	t.Helper()
+
+	l, err := tr.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected listen err: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		if err := l.Accept(echo); err != nil {
+			t.Errorf("Unexpected accept err: %v", err)
+		}
+	}()
+	return l
+}
+
+func roundTrip(t *testing.T, c transport.Client, body string) {
+	t.Helper()
+
+	m := transport.Message{
+		Header: map[string]string{"Content-Type": "application/json"},
+		Body:   []byte(body),
+	}
+	if err := c.Send(&m); err != nil {
+		t.Fatalf("Unexpected send err: %v", err)
+	}
+
+	var rm transport.Message
+	if err := c.Recv(&rm); err != nil {
+		t.Fatalf("Unexpected recv err: %v", err)
+	}
+	if string(rm.Body) != body {
+		t.Fatalf("Expected %q, got %q", body, rm.Body)
+	}
+	if rm.Header["Content-Type"] != "application/json" {
+		t.Fatalf("Header not propagated: %v", rm.Header)
+	}
+}
+
+func TestQUICTransportCommunication(t *testing.T) {
+	tr := NewTransport()
+	l := listen(t, tr)
+
+	c, err := tr.Dial(l.Addr())
+	if err != nil {
+		t.Fatalf("Unexpected dial err: %v", err)
+	}
+	defer c.Close()
+
+	for i := 0; i < 3; i++ {
+		roundTrip(t, c, fmt.Sprintf(`{"message": "Hello World %d"}`, i))
+	}
+}
+
+func TestQUICTransportMultiplexesStreams(t *testing.T) {
+	tr := NewTransport()
+	l := listen(t, tr)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c, err := tr.Dial(l.Addr())
+			if err != nil {
+				t.Errorf("Unexpected dial err: %v", err)
+				return
+			}
+			defer c.Close()
+			roundTrip(t, c, fmt.Sprintf("call %d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	if n := len(tr.(*quicTransport).conns); n != 1 {
+		t.Fatalf("Expected 1 connection to the peer, got %d", n)
+	}
+}
+
+func TestQUICTransportSlowPeerDoesNotBlockDials(t *testing.T) {
+	tr := NewTransport()
+	l := listen(t, tr)
+
+	// a UDP socket that never answers the handshake
+	silent, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := tr.Dial(silent.LocalAddr().String(), transport.WithTimeout(3*time.Second)); err == nil {
+			t.Error("Expected dialing a silent peer to fail")
+		}
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	start := time.Now()
+	c, err := tr.Dial(l.Addr())
+	if err != nil {
+		t.Fatalf("Unexpected dial err: %v", err)
+	}
+	defer c.Close()
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("Dial waited %s behind a dial to a silent peer", d)
+	}
+	roundTrip(t, c, "hello")
+	<-done
+}
+
+func TestQUICTransportLargeMessage(t *testing.T) {
+	tr := NewTransport()
+	l := listen(t, tr)
+
+	c, err := tr.Dial(l.Addr())
+	if err != nil {
+		t.Fatalf("Unexpected dial err: %v", err)
+	}
+	defer c.Close()
+
+	roundTrip(t, c, string(bytes.Repeat([]byte("x"), 8*1024*1024)))
+}
+
+func TestQUICTransport0RTTReconnect(t *testing.T) {
+	tr := NewTransport(Enable0RTT())
+	l := listen(t, tr)
+
+	c, err := tr.Dial(l.Addr())
+	if err != nil {
+		t.Fatalf("Unexpected dial err: %v", err)
+	}
+	roundTrip(t, c, "first")
+	c.Close()
+
+	// drop the connection; the next dial resumes the TLS session
+	qt := tr.(*quicTransport)
+	qt.Lock()
+	first := qt.conns[l.Addr()]
+	qt.Unlock()
+	first.CloseWithError(0, "")
+
+	c, err = tr.Dial(l.Addr())
+	if err != nil {
+		t.Fatalf("Unexpected redial err: %v", err)
+	}
+	defer c.Close()
+	roundTrip(t, c, "second")
+
+	qt.Lock()
+	second := qt.conns[l.Addr()]
+	qt.Unlock()
+	if second == first {
+		t.Fatal("Expected a new connection after close")
+	}
+	if !second.ConnectionState().Used0RTT {
+		t.Fatal("Expected reconnect to use 0-RTT")
+	}
+}
+
+func TestQUICTransportMigrate(t *testing.T) {
+	tr := NewTransport()
+	l := listen(t, tr)
+
+	c, err := tr.Dial(l.Addr())
+	if err != nil {
+		t.Fatalf("Unexpected dial err: %v", err)
+	}
+	defer c.Close()
+	roundTrip(t, c, "before")
+
+	if err := Migrate(tr, l.Addr()); err != nil {
+		t.Fatalf("Unexpected migrate err: %v", err)
+	}
+
+	// the same stream keeps working over the new path
+	roundTrip(t, c, "after")
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	in := &transport.Message{
+		Header: map[string]string{"Micro-Endpoint": "Greeter.Hello", "": "empty"},
+		Body:   []byte("body"),
+	}
+
+	frame := encode(in)
+	var out transport.Message
+	if err := decode(frame[4:], &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Header) != 2 || out.Header["Micro-Endpoint"] != "Greeter.Hello" || string(out.Body) != "body" {
+		t.Fatalf("Unexpected decode %+v", out)
+	}
+
+	if err := decode(frame[4:10], &out); err == nil {
+		t.Fatal("Expected error on truncated frame")
+	}
+}
+
+type Greeter struct{}
+
+type Request struct {
+	Name string `json:"name"`
+}
+
+type Response struct {
+	Msg string `json:"msg"`
+}
+
+func (Greeter) Hello(_ context.Context, req *Request, rsp *Response) error {
+	rsp.Msg = "Hello " + req.Name
+	return nil
+}
+
+func TestQUICTransportRPC(t *testing.T) {
+	reg := registry.NewMemoryRegistry()
+	tr := NewTransport()
+
+	srv := server.NewServer(
+		server.Name("greeter"),
+		server.Address("127.0.0.1:0"),
+		server.Registry(reg),
+		server.Transport(tr),
+	)
+	if err := srv.Handle(srv.NewHandler(&Greeter{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	cl := client.NewClient(
+		client.Registry(reg),
+		client.Selector(selector.NewSelector(selector.Registry(reg))),
+		client.Transport(tr),
+	)
+
+	for i := 0; i < 3; i++ {
+		req := cl.NewRequest("greeter", "Greeter.Hello", &Request{Name: "John"})
+		var rsp Response
+		if err := cl.Call(context.Background(), req, &rsp); err != nil {
+			t.Fatalf("call: %v", err)
+		}
+		if rsp.Msg != "Hello John" {
+			t.Fatalf("reply = %q", rsp.Msg)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package quic
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"go-micro.dev/v6/transport"
+)
+
+var (
+	// MaxMessageSize is the largest frame a socket will accept.
+	MaxMessageSize = 64 * 1024 * 1024
+
+	errFrameTooLarge = errors.New("quic: frame exceeds MaxMessageSize")
+)
+
+// quicSocket frames transport messages over a single bidirectional QUIC
+// stream. Each frame is a big-endian uint32 length followed by the header
+// count, length-prefixed header pairs and the body.
+type quicSocket struct {
+	stream  *quic.Stream
+	timeout time.Duration
+	local   string
+	remote  string
+
+	rmu sync.Mutex
+	buf *bufio.Reader
+
+	wmu sync.Mutex
+
+	once sync.Once
+}
+
+func newSocket(s *quic.Stream, timeout time.Duration, local, remote string) *quicSocket {
+	return &quicSocket{
+		stream:  s,
+		timeout: timeout,
+		local:   local,
+		remote:  remote,
+		buf:     bufio.NewReader(s),
+	}
+}
+
+func (q *quicSocket) Local() string {
+	return q.local
+}
+
+func (q *quicSocket) Remote() string {
+	return q.remote
+}
+
+func (q *quicSocket) Recv(m *transport.Message) error {
+	if m == nil {
+		return errors.New("message passed in is nil")
+	}
+
+	q.rmu.Lock()
+	defer q.rmu.Unlock()
+
+	if q.timeout > 0 {
+		_ = q.stream.SetReadDeadline(time.Now().Add(q.timeout))
+	}
+
+	var size uint32
+	if err := binary.Read(q.buf, binary.BigEndian, &size); err != nil {
+		return err
+	}
+	if int(size) > MaxMessageSize {
+		return errFrameTooLarge
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(q.buf, frame); err != nil {
+		return err
+	}
+
+	return decode(frame, m)
+}
+
+func (q *quicSocket) Send(m *transport.Message) error {
+	frame := encode(m)
+	if len(frame)-4 > MaxMessageSize {
+		return errFrameTooLarge
+	}
+
+	q.wmu.Lock()
+	defer q.wmu.Unlock()
+
+	if q.timeout > 0 {
+		_ = q.stream.SetWriteDeadline(time.Now().Add(q.timeout))
+	}
+
+	_, err := q.stream.Write(frame)
+	return err
+}
+
+// Close finishes the send side and stops reading. The underlying
+// connection stays open for other streams.
+func (q *quicSocket) Close() error {
+	var err error
+	q.once.Do(func() {
+		q.stream.CancelRead(0)
+		err = q.stream.Close()
+	})
+	return err
+}
+
+func encode(m *transport.Message) []byte {
+	size := 4 + 4 + len(m.Body)
+	for k, v := range m.Header {
+		size += 8 + len(k) + len(v)
+	}
+
+	b := make([]byte, 4, 4+size)
+	binary.BigEndian.PutUint32(b, uint32(size))
+
+	b = binary.BigEndian.AppendUint32(b, uint32(len(m.Header)))
+	for k, v := range m.Header {
+		b = binary.BigEndian.AppendUint32(b, uint32(len(k)))
+		b = append(b, k...)
+		b = binary.BigEndian.AppendUint32(b, uint32(len(v)))
+		b = append(b, v...)
+	}
+	b = binary.BigEndian.AppendUint32(b, uint32(len(m.Body)))
+	return append(b, m.Body...)
+}
+
+func decode(b []byte, m *transport.Message) error {
+	next := func() ([]byte, error) {
+		if len(b) < 4 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		n := binary.BigEndian.Uint32(b)
+		b = b[4:]
+		if uint32(len(b)) < n {
+			return nil, io.ErrUnexpectedEOF
+		}
+		v := b[:n]
+		b = b[n:]
+		return v, nil
+	}
+
+	if len(b) < 4 {
+		return io.ErrUnexpectedEOF
+	}
+	count := binary.BigEndian.Uint32(b)
+	b = b[4:]
+	if int(count) > len(b)/8 {
+		return io.ErrUnexpectedEOF
+	}
+
+	m.Header = make(map[string]string, count)
+	for i := uint32(0); i < count; i++ {
+		k, err := next()
+		if err != nil {
+			return err
+		}
+		v, err := next()
+		if err != nil {
+			return err
+		}
+		m.Header[string(k)] = string(v)
+	}
+
+	body, err := next()
+	if err != nil {
+		return err
+	}
+	m.Body = body
+	return nil
+}
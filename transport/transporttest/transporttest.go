@@ -0,0 +1,485 @@
+// Package transporttest provides a conformance suite for transport.Transport
+// implementations. Run it from a test in the implementation's package:
+//
+//	func TestConformance(t *testing.T) {
+//		transporttest.Run(t, func() transport.Transport {
+//			return mytransport.NewTransport()
+//		})
+//	}
+package transporttest
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go-micro.dev/v6/transport"
+)
+
+// Options configure a conformance run.
+type Options struct {
+	// Addr is passed to Listen. Defaults to "127.0.0.1:0".
+	Addr string
+	// MaxMessageSize is the largest body the transport is expected to
+	// carry in one message. Defaults to 4MB.
+	MaxMessageSize int
+	// Timeout bounds each blocking step. Defaults to 5 seconds.
+	Timeout time.Duration
+}
+
+type Option func(*Options)
+
+// Addr sets the address the suite listens on, for transports that do not
+// listen on host:port (e.g. a NATS subject).
+func Addr(a string) Option {
+	return func(o *Options) {
+		o.Addr = a
+	}
+}
+
+// MaxMessageSize sets the size of the large message case.
+func MaxMessageSize(n int) Option {
+	return func(o *Options) {
+		o.MaxMessageSize = n
+	}
+}
+
+// Timeout sets how long the suite waits on any single blocking step.
+func Timeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = d
+	}
+}
+
+// Run exercises the behaviour every transport must share. newTransport is
+// called once per case so state does not leak between them.
+func Run(t *testing.T, newTransport func() transport.Transport, opts ...Option) {
+	options := Options{
+		Addr:           "127.0.0.1:0",
+		MaxMessageSize: 4 * 1024 * 1024,
+		Timeout:        5 * time.Second,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	s := &suite{opts: options, newTransport: newTransport}
+
+	t.Run("RoundTrip", s.testRoundTrip)
+	t.Run("HeaderPropagation", s.testHeaderPropagation)
+	t.Run("MultipleMessages", s.testMultipleMessages)
+	t.Run("LargeMessage", s.testLargeMessage)
+	t.Run("ConcurrentSendRecv", s.testConcurrentSendRecv)
+	t.Run("ConcurrentSockets", s.testConcurrentSockets)
+	t.Run("ClientClose", s.testClientClose)
+	t.Run("HalfClose", s.testHalfClose)
+	t.Run("ServerClose", s.testServerClose)
+	t.Run("ListenerCloseDuringAccept", s.testListenerCloseDuringAccept)
+}
+
+type suite struct {
+	opts         Options
+	newTransport func() transport.Transport
+}
+
+// serve listens on a fresh transport and hands every socket to fn. The
+// listener is closed when the test ends.
+func (s *suite) serve(t *testing.T, fn func(transport.Socket)) (transport.Transport, transport.Listener) {
+	t.Helper()
+
+	tr := s.newTransport()
+	l, err := tr.Listen(s.opts.Addr)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = l.Accept(fn)
+	}()
+
+	t.Cleanup(func() {
+		l.Close()
+		select {
+		case <-done:
+		case <-time.After(s.opts.Timeout):
+		}
+	})
+
+	return tr, l
+}
+
+func (s *suite) dial(t *testing.T, tr transport.Transport, l transport.Listener, opts ...transport.DialOption) transport.Client {
+	t.Helper()
+
+	c, err := tr.Dial(l.Addr(), opts...)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// within runs fn and fails the test if it has not returned in time.
+func (s *suite) within(t *testing.T, what string, fn func() error) error {
+	t.Helper()
+
+	ch := make(chan error, 1)
+	go func() { ch <- fn() }()
+
+	select {
+	case err := <-ch:
+		return err
+	case <-time.After(s.opts.Timeout):
+		t.Fatalf("%s did not return within %v", what, s.opts.Timeout)
+		return nil
+	}
+}
+
+func echo(sock transport.Socket) {
+	for {
+		var m transport.Message
+		if err := sock.Recv(&m); err != nil {
+			return
+		}
+		if err := sock.Send(&m); err != nil {
+			return
+		}
+	}
+}
+
+func (s *suite) roundTrip(t *testing.T, c transport.Client, in *transport.Message) *transport.Message {
+	t.Helper()
+
+	if err := s.within(t, "Send", func() error { return c.Send(in) }); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	out := new(transport.Message)
+	if err := s.within(t, "Recv", func() error { return c.Recv(out) }); err != nil {
+		t.Fatalf("recv: %v", err)
+	}
+	return out
+}
+
+func (s *suite) testRoundTrip(t *testing.T) {
+	tr, l := s.serve(t, echo)
+	c := s.dial(t, tr, l)
+
+	in := &transport.Message{
+		Header: map[string]string{"Content-Type": "application/json"},
+		Body:   []byte(`{"message": "Hello World"}`),
+	}
+	out := s.roundTrip(t, c, in)
+
+	if !bytes.Equal(out.Body, in.Body) {
+		t.Fatalf("body = %q, want %q", out.Body, in.Body)
+	}
+	if len(l.Addr()) == 0 {
+		t.Fatal("listener has no address")
+	}
+}
+
+func (s *suite) testHeaderPropagation(t *testing.T) {
+	received := make(chan map[string]string, 1)
+
+	tr, l := s.serve(t, func(sock transport.Socket) {
+		var m transport.Message
+		if err := sock.Recv(&m); err != nil {
+			return
+		}
+		received <- m.Header
+		_ = sock.Send(&transport.Message{
+			Header: map[string]string{"Micro-Reply": "pong", "Content-Type": "text/plain"},
+			Body:   []byte("ok"),
+		})
+		_ = sock.Recv(&m)
+	})
+	c := s.dial(t, tr, l)
+
+	want := map[string]string{
+		"Content-Type":   "application/json",
+		"Micro-Endpoint": "Greeter.Hello",
+		"Micro-Id":       "1234",
+	}
+	out := s.roundTrip(t, c, &transport.Message{Header: want, Body: []byte("ping")})
+
+	got := <-received
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("request header %s = %q, want %q (got %v)", k, got[k], v, got)
+		}
+	}
+	if out.Header["Micro-Reply"] != "pong" {
+		t.Errorf("reply header Micro-Reply = %q, want pong (got %v)", out.Header["Micro-Reply"], out.Header)
+	}
+}
+
+func (s *suite) testMultipleMessages(t *testing.T) {
+	tr, l := s.serve(t, echo)
+	c := s.dial(t, tr, l)
+
+	for i := 0; i < 10; i++ {
+		body := []byte(fmt.Sprintf("message %d", i))
+		out := s.roundTrip(t, c, &transport.Message{
+			Header: map[string]string{"Micro-Id": fmt.Sprint(i)},
+			Body:   body,
+		})
+		if !bytes.Equal(out.Body, body) {
+			t.Fatalf("message %d: body = %q, want %q", i, out.Body, body)
+		}
+	}
+}
+
+func (s *suite) testLargeMessage(t *testing.T) {
+	tr, l := s.serve(t, echo)
+	c := s.dial(t, tr, l)
+
+	body := make([]byte, s.opts.MaxMessageSize)
+	for i := range body {
+		body[i] = byte(i % 251)
+	}
+
+	out := s.roundTrip(t, c, &transport.Message{
+		Header: map[string]string{"Content-Type": "application/octet-stream"},
+		Body:   body,
+	})
+	if !bytes.Equal(out.Body, body) {
+		t.Fatalf("large body corrupted: got %d bytes, want %d", len(out.Body), len(body))
+	}
+}
+
+// testConcurrentSendRecv sends and receives on one streaming socket from
+// separate goroutines, as a bidirectional stream does.
+func (s *suite) testConcurrentSendRecv(t *testing.T) {
+	tr, l := s.serve(t, echo)
+	c := s.dial(t, tr, l, transport.WithStream())
+
+	const n = 50
+	errs := make(chan error, 2)
+
+	go func() {
+		for i := 0; i < n; i++ {
+			if err := c.Send(&transport.Message{
+				Header: map[string]string{"Micro-Id": fmt.Sprint(i)},
+				Body:   []byte(fmt.Sprintf("message %d", i)),
+			}); err != nil {
+				errs <- fmt.Errorf("send %d: %w", i, err)
+				return
+			}
+		}
+		errs <- nil
+	}()
+
+	go func() {
+		for i := 0; i < n; i++ {
+			var m transport.Message
+			if err := c.Recv(&m); err != nil {
+				errs <- fmt.Errorf("recv %d: %w", i, err)
+				return
+			}
+			if want := fmt.Sprintf("message %d", i); string(m.Body) != want {
+				errs <- fmt.Errorf("recv %d: body = %q, want %q", i, m.Body, want)
+				return
+			}
+		}
+		errs <- nil
+	}()
+
+	for i := 0; i < 2; i++ {
+		if err := s.within(t, "concurrent send/recv", func() error { return <-errs }); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testConcurrentSockets runs many independent sockets against one listener.
+func (s *suite) testConcurrentSockets(t *testing.T) {
+	tr, l := s.serve(t, echo)
+
+	const n = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			c, err := tr.Dial(l.Addr())
+			if err != nil {
+				errs <- fmt.Errorf("dial %d: %w", i, err)
+				return
+			}
+			defer c.Close()
+
+			body := fmt.Sprintf("socket %d", i)
+			if err := c.Send(&transport.Message{Body: []byte(body)}); err != nil {
+				errs <- fmt.Errorf("send %d: %w", i, err)
+				return
+			}
+			var m transport.Message
+			if err := c.Recv(&m); err != nil {
+				errs <- fmt.Errorf("recv %d: %w", i, err)
+				return
+			}
+			if string(m.Body) != body {
+				errs <- fmt.Errorf("socket %d: body = %q, want %q", i, m.Body, body)
+			}
+		}(i)
+	}
+
+	if err := s.within(t, "concurrent sockets", func() error {
+		wg.Wait()
+		close(errs)
+		return <-errs
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testClientClose checks that the server side notices a client closing
+// its socket: a pending Recv returns an error instead of blocking forever.
+func (s *suite) testClientClose(t *testing.T) {
+	closed := make(chan error, 1)
+
+	tr, l := s.serve(t, func(sock transport.Socket) {
+		var m transport.Message
+		if err := sock.Recv(&m); err != nil {
+			closed <- fmt.Errorf("first recv: %w", err)
+			return
+		}
+		if err := sock.Send(&m); err != nil {
+			closed <- fmt.Errorf("send: %w", err)
+			return
+		}
+		closed <- sock.Recv(&m)
+	})
+
+	c, err := tr.Dial(l.Addr())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	s.roundTrip(t, c, &transport.Message{Body: []byte("hello")})
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if err := s.within(t, "server Recv after client Close", func() error { return <-closed }); err == nil {
+		t.Fatal("server Recv after client Close returned nil error")
+	}
+}
+
+// testHalfClose checks that closing a client only closes its sending side
+// once everything it sent is in: the server still receives the messages
+// sent just before Close, in order, and only then sees the close.
+func (s *suite) testHalfClose(t *testing.T) {
+	const n = 3
+	got := make(chan string, n)
+	closed := make(chan error, 1)
+
+	tr, l := s.serve(t, func(sock transport.Socket) {
+		var m transport.Message
+		if err := sock.Recv(&m); err != nil {
+			closed <- fmt.Errorf("first recv: %w", err)
+			return
+		}
+		if err := sock.Send(&m); err != nil {
+			closed <- fmt.Errorf("send: %w", err)
+			return
+		}
+		for {
+			var m transport.Message
+			if err := sock.Recv(&m); err != nil {
+				closed <- err
+				return
+			}
+			got <- string(m.Body)
+		}
+	})
+
+	c, err := tr.Dial(l.Addr())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	s.roundTrip(t, c, &transport.Message{Body: []byte("hello")})
+
+	for i := 0; i < n; i++ {
+		if err := c.Send(&transport.Message{Body: []byte(fmt.Sprintf("msg %d", i))}); err != nil {
+			t.Fatalf("send %d: %v", i, err)
+		}
+	}
+	if err := c.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	if err := s.within(t, "server Recv after client Close", func() error { return <-closed }); err == nil {
+		t.Fatal("server Recv after client Close returned nil error")
+	}
+	close(got)
+	i := 0
+	for body := range got {
+		if want := fmt.Sprintf("msg %d", i); body != want {
+			t.Fatalf("message %d = %q, want %q", i, body, want)
+		}
+		i++
+	}
+	if i != n {
+		t.Fatalf("server received %d of %d messages sent before Close", i, n)
+	}
+}
+
+// testServerClose checks that a client sees an error, not a hang, once the
+// server has replied and closed its side.
+func (s *suite) testServerClose(t *testing.T) {
+	tr, l := s.serve(t, func(sock transport.Socket) {
+		var m transport.Message
+		if err := sock.Recv(&m); err != nil {
+			return
+		}
+		_ = sock.Send(&m)
+		sock.Close()
+	})
+	c := s.dial(t, tr, l)
+
+	out := s.roundTrip(t, c, &transport.Message{Body: []byte("last")})
+	if string(out.Body) != "last" {
+		t.Fatalf("body = %q, want last", out.Body)
+	}
+
+	var m transport.Message
+	if err := s.within(t, "client Recv after server Close", func() error { return c.Recv(&m) }); err == nil {
+		t.Fatal("client Recv after server Close returned nil error")
+	}
+}
+
+// testListenerCloseDuringAccept checks that closing a listener unblocks a
+// running Accept.
+func (s *suite) testListenerCloseDuringAccept(t *testing.T) {
+	tr := s.newTransport()
+	l, err := tr.Listen(s.opts.Addr)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	accepting := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		close(accepting)
+		done <- l.Accept(echo)
+	}()
+
+	<-accepting
+	// give Accept a moment to block
+	time.Sleep(50 * time.Millisecond)
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	s.within(t, "Accept after listener Close", func() error { return <-done })
+}